@@ -1,3 +1,5 @@
+//go:build !qzkp_verify
+
 package main
 
 import (
@@ -16,15 +18,16 @@ import (
 
 // IBMQuantumClient handles communication with IBM Quantum services
 type IBMQuantumClient struct {
-	APIKey  string
-	BaseURL string
-	Client  *http.Client
-	Cache   *QuantumStateCache
+	APIKey     string
+	BaseURL    string
+	Client     *http.Client
+	Cache      *QuantumStateCache
+	Resilience *ResilientBackend // retries, circuit breaker and fallback for API calls
 }
 
 // RealQuantumState represents a quantum state vector obtained from real quantum hardware
 type RealQuantumState struct {
-	Vector      []complex128          `json:"vector"`
+	Vector      Amplitudes            `json:"vector"`
 	Name        string                `json:"name"`
 	Description string                `json:"description"`
 	Qubits      int                   `json:"qubits"`
@@ -34,6 +37,7 @@ type RealQuantumState struct {
 	Fidelity    float64               `json:"fidelity"`    // How close to ideal state
 	Coherence   float64               `json:"coherence"`   // Quantum coherence measure
 	Entanglement float64              `json:"entanglement"` // Entanglement entropy
+	Provenance   string               `json:"provenance"`   // ProvenanceRemote or ProvenanceFallback
 }
 
 // QuantumStateLibrary contains curated real quantum states
@@ -88,7 +92,7 @@ func NewIBMQuantumClient() (*IBMQuantumClient, error) {
 		return nil, fmt.Errorf("IBM Quantum API key not found in environment variable IQKAPI")
 	}
 
-	cache, err := NewQuantumStateCache("real_quantum_states.json")
+	cache, err := DefaultQuantumStateCache()
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize quantum state cache: %v", err)
 	}
@@ -99,32 +103,36 @@ func NewIBMQuantumClient() (*IBMQuantumClient, error) {
 		Client: &http.Client{
 			Timeout: 60 * time.Second,
 		},
-		Cache: cache,
+		Cache:      cache,
+		Resilience: NewResilientBackend("ibm_quantum", nil, nil),
 	}, nil
 }
 
-// Authenticate verifies the API key with IBM Quantum
+// Authenticate verifies the API key with IBM Quantum, retrying transient
+// failures. Rejected credentials fail immediately.
 func (ibm *IBMQuantumClient) Authenticate() error {
-	req, err := http.NewRequest("GET", ibm.BaseURL+"/backends", nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
-	}
+	return ibm.Resilience.Do("authenticate", func() error {
+		req, err := http.NewRequest("GET", ibm.BaseURL+"/backends", nil)
+		if err != nil {
+			return PermanentError("authenticate", fmt.Errorf("failed to create request: %v", err))
+		}
 
-	req.Header.Set("Authorization", "Bearer "+ibm.APIKey)
-	req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+ibm.APIKey)
+		req.Header.Set("Content-Type", "application/json")
 
-	resp, err := ibm.Client.Do(req)
-	if err != nil {
-		return fmt.Errorf("authentication request failed: %v", err)
-	}
-	defer resp.Body.Close()
+		resp, err := ibm.Client.Do(req)
+		if err != nil {
+			return TransientError("authenticate", fmt.Errorf("authentication request failed: %v", err))
+		}
+		defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("authentication failed with status %d: %s", resp.StatusCode, string(body))
-	}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			return HTTPStatusError("authenticate", resp.StatusCode, string(body))
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // GetAvailableBackends retrieves list of available quantum backends
@@ -147,7 +155,7 @@ func (ibm *IBMQuantumClient) GenerateRealQuantumStates() (*QuantumStateLibrary,
 
 	library := &QuantumStateLibrary{
 		Generated: time.Now(),
-		Version:   "1.0",
+		Version:   StateLibraryVersion,
 		States:    make([]RealQuantumState, 0),
 	}
 
@@ -231,8 +239,10 @@ print("QUANTUM_STATE:", state.data.tolist())
 				Fidelity:     fidelity,
 				Coherence:    coherence,
 				Entanglement: entanglement,
+				Provenance:   ProvenanceFallback,
 				Metadata: map[string]interface{}{
 					"fallback": true,
+					"fallback_reason": err.Error(),
 					"noise_model": "theoretical",
 				},
 			}
@@ -255,6 +265,7 @@ print("QUANTUM_STATE:", state.data.tolist())
 						Coherence:    stateMap["coherence"].(float64),
 						Entanglement: stateMap["entanglement"].(float64),
 						Metadata:     stateMap["metadata"].(map[string]interface{}),
+						Provenance:   ProvenanceRemote,
 					}
 					library.States = append(library.States, state)
 					fmt.Printf("✅ Generated %s with fidelity %.3f\n", name, state.Fidelity)
@@ -335,11 +346,7 @@ func (ibm *IBMQuantumClient) addQuantumNoise(idealVector []complex128, qubits in
 	}
 
 	// Renormalize
-	var norm float64
-	for _, amp := range noisyVector {
-		norm += real(amp)*real(amp) + imag(amp)*imag(amp)
-	}
-	norm = math.Sqrt(norm)
+	norm := Norm(noisyVector)
 
 	for i := range noisyVector {
 		noisyVector[i] = complex(real(noisyVector[i])/norm, imag(noisyVector[i])/norm)
@@ -358,21 +365,20 @@ func calculateCoherence(state []complex128) float64 {
 }
 
 func calculateEntanglement(state []complex128, qubits int) float64 {
-	// Simplified entanglement calculation using von Neumann entropy
-	return CalculateEntropy(state) / float64(qubits)
+	// Entanglement entropy across the middle, normalized to [0, 1]
+	entropy, err := BipartiteEntanglementEntropy(state)
+	if err != nil || qubits < 2 {
+		return 0.0
+	}
+	return entropy / float64(qubits/2)
 }
 
 func calculateFidelity(noisyState, idealState []complex128) float64 {
-	if len(noisyState) != len(idealState) {
+	fidelity, err := Fidelity(noisyState, idealState)
+	if err != nil {
 		return 0.0
 	}
-
-	var fidelity complex128
-	for i := range noisyState {
-		fidelity += noisyState[i] * complex(real(idealState[i]), -imag(idealState[i]))
-	}
-
-	return real(fidelity * complex(real(fidelity), -imag(fidelity)))
+	return fidelity
 }
 
 // executeQiskitScript runs the Python Qiskit script to generate real quantum states
@@ -404,20 +410,17 @@ func (ibm *IBMQuantumClient) executeQiskitScript() (map[string]interface{}, erro
 	return nil, fmt.Errorf("no states found in Qiskit output")
 }
 
-// parseComplexVector converts JSON array to complex128 slice
+// parseComplexVector decodes a vector in either wire form Amplitudes accepts
 func (ibm *IBMQuantumClient) parseComplexVector(vectorData interface{}) []complex128 {
-	if vectorArray, ok := vectorData.([]interface{}); ok {
-		vector := make([]complex128, len(vectorArray))
-		for i, ampData := range vectorArray {
-			if ampArray, ok := ampData.([]interface{}); ok && len(ampArray) == 2 {
-				real := ampArray[0].(float64)
-				imag := ampArray[1].(float64)
-				vector[i] = complex(real, imag)
-			}
-		}
-		return vector
+	data, err := json.Marshal(vectorData)
+	if err != nil {
+		return nil
+	}
+	var vector Amplitudes
+	if err := json.Unmarshal(data, &vector); err != nil {
+		return nil
 	}
-	return nil
+	return vector
 }
 
 // GetQuantumStatesByType returns real quantum states filtered by type
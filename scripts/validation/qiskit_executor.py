@@ -174,7 +174,7 @@ class QuantumStateGenerator:
                     entanglement = self.calculate_entanglement(statevector)
 
                     states[name] = {
-                        "vector": [[amp.real, amp.imag] for amp in statevector],
+                        "vector": [{"re": amp.real, "im": amp.imag} for amp in statevector],
                         "description": description,
                         "qubits": circuit.num_qubits,
                         "backend": self.backend.name if hasattr(self.backend, 'name') else "simulator",
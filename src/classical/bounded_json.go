@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrJSONLimit is returned when JSON input exceeds a JSONLimits bound.
+var ErrJSONLimit = errors.New("JSON input exceeds limits")
+
+// JSONLimits bounds JSON from untrusted sources. Zero fields take the
+// values of DefaultJSONLimits.
+type JSONLimits struct {
+	MaxBytes        int64 // total input, including whitespace
+	MaxDepth        int   // nesting of objects and arrays
+	MaxStringBytes  int   // any string, object key or number
+	MaxArrayLength  int   // elements of any array
+	MaxObjectFields int   // fields of any object
+	// FieldBytes overrides MaxStringBytes for the string values of the
+	// named object fields, wherever they appear
+	FieldBytes map[string]int
+}
+
+// DefaultJSONLimits are generous bounds for small API messages.
+var DefaultJSONLimits = JSONLimits{
+	MaxBytes:        1 << 20,
+	MaxDepth:        32,
+	MaxStringBytes:  64 << 10,
+	MaxArrayLength:  4096,
+	MaxObjectFields: 256,
+}
+
+// withDefaults fills unset limits.
+func (l JSONLimits) withDefaults() JSONLimits {
+	if l.MaxBytes <= 0 {
+		l.MaxBytes = DefaultJSONLimits.MaxBytes
+	}
+	if l.MaxDepth <= 0 {
+		l.MaxDepth = DefaultJSONLimits.MaxDepth
+	}
+	if l.MaxStringBytes <= 0 {
+		l.MaxStringBytes = DefaultJSONLimits.MaxStringBytes
+	}
+	if l.MaxArrayLength <= 0 {
+		l.MaxArrayLength = DefaultJSONLimits.MaxArrayLength
+	}
+	if l.MaxObjectFields <= 0 {
+		l.MaxObjectFields = DefaultJSONLimits.MaxObjectFields
+	}
+	return l
+}
+
+// DecodeBoundedJSON decodes a single JSON value from r into v. The input is
+// first walked token by token as it streams in, failing with ErrJSONLimit
+// as soon as it breaks one of limits, so oversized or deeply nested input
+// is refused before it is fully read, let alone decoded into v. Trailing
+// data after the value is refused.
+func DecodeBoundedJSON(r io.Reader, limits JSONLimits, v interface{}) error {
+	limits = limits.withDefaults()
+	var consumed bytes.Buffer
+	bounded := &jsonByteBudget{r: r, limit: limits.MaxBytes, remaining: limits.MaxBytes}
+	dec := json.NewDecoder(io.TeeReader(bounded, &consumed))
+	dec.UseNumber()
+
+	if err := walkBoundedJSON(dec, limits); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		if errors.Is(err, ErrJSONLimit) {
+			return err
+		}
+		return errors.New("unexpected data after the JSON value")
+	}
+	return json.Unmarshal(consumed.Bytes(), v)
+}
+
+// jsonFrame is an open object or array while walking JSON.
+type jsonFrame struct {
+	object    bool
+	count     int    // fields or elements so far
+	key       string // field whose value comes next, objects only
+	expectKey bool
+}
+
+// walkBoundedJSON reads one JSON value from dec, checking limits.
+func walkBoundedJSON(dec *json.Decoder, limits JSONLimits) error {
+	var stack []*jsonFrame
+	path := func() string {
+		parts := make([]string, len(stack))
+		for i, frame := range stack {
+			if frame.object {
+				parts[i] = frame.key
+			} else {
+				parts[i] = strconv.Itoa(frame.count - 1)
+			}
+		}
+		return "$" + strings.Join(append([]string{""}, parts...), ".")
+	}
+	exceeded := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s at %s", ErrJSONLimit, fmt.Sprintf(format, args...), path())
+	}
+
+	for {
+		token, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		// Object keys
+		if top != nil && top.object && top.expectKey {
+			if delim, ok := token.(json.Delim); ok && delim == '}' {
+				stack = stack[:len(stack)-1]
+			} else {
+				key := token.(string)
+				if len(key) > limits.MaxStringBytes {
+					return exceeded("object key of %d bytes", len(key))
+				}
+				if top.count++; top.count > limits.MaxObjectFields {
+					return exceeded("more than %d object fields", limits.MaxObjectFields)
+				}
+				top.key, top.expectKey = key, false
+				continue
+			}
+		} else {
+			// A value, or the end of an array
+			if delim, ok := token.(json.Delim); ok && delim == ']' {
+				stack = stack[:len(stack)-1]
+			} else {
+				if top != nil && !top.object {
+					if top.count++; top.count > limits.MaxArrayLength {
+						return exceeded("more than %d array elements", limits.MaxArrayLength)
+					}
+				}
+				switch value := token.(type) {
+				case json.Delim:
+					if len(stack) >= limits.MaxDepth {
+						return exceeded("nesting deeper than %d", limits.MaxDepth)
+					}
+					stack = append(stack, &jsonFrame{object: value == '{', expectKey: value == '{'})
+					continue
+				case string:
+					limit := limits.MaxStringBytes
+					if top != nil && top.object {
+						if fieldLimit, ok := limits.FieldBytes[top.key]; ok {
+							limit = fieldLimit
+						}
+					}
+					if len(value) > limit {
+						return exceeded("string of %d bytes", len(value))
+					}
+				case json.Number:
+					if len(value) > limits.MaxStringBytes {
+						return exceeded("number of %d digits", len(value))
+					}
+				}
+				if top != nil && top.object {
+					top.expectKey = true
+				}
+			}
+		}
+
+		// A value is complete; so is the document once no container is open
+		if len(stack) == 0 {
+			return nil
+		}
+		if top := stack[len(stack)-1]; top.object {
+			top.expectKey = true
+		}
+	}
+}
+
+// jsonByteBudget fails reads past a total byte budget with ErrJSONLimit.
+type jsonByteBudget struct {
+	r         io.Reader
+	limit     int64
+	remaining int64
+}
+
+func (b *jsonByteBudget) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// Only an input that has more to give is over budget
+		var probe [1]byte
+		if n, _ := b.r.Read(probe[:]); n > 0 {
+			return 0, fmt.Errorf("%w: more than %d bytes", ErrJSONLimit, b.limit)
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.r.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
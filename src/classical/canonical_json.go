@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// redactTag is the struct tag option that removes a field from the
+// canonical encoding, e.g. `qzkp:"redact"`.
+const redactTag = "redact"
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// CanonicalizeValue returns a deterministic JSON encoding of v. Object keys
+// are sorted, HTML escaping is disabled and struct fields tagged
+// `qzkp:"redact"` are dropped, so two values that differ only in redacted
+// fields or map ordering canonicalize to the same bytes.
+func CanonicalizeValue(v interface{}) ([]byte, error) {
+	tree, err := canonicalTree(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(tree); err != nil {
+		return nil, fmt.Errorf("failed to encode canonical value: %w", err)
+	}
+	// Encoder appends a newline that is not part of the value
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// canonicalTree converts v into plain maps, slices and scalars that
+// encoding/json serializes deterministically.
+func canonicalTree(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return nil, nil
+		}
+		return reencodeJSON(v.Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return canonicalTree(v.Elem())
+
+	case reflect.Struct:
+		out := make(map[string]interface{})
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue // unexported
+			}
+			if hasTagOption(field.Tag.Get("qzkp"), redactTag) {
+				continue
+			}
+
+			name := field.Name
+			omitEmpty := false
+			if tag := field.Tag.Get("json"); tag != "" {
+				parts := strings.Split(tag, ",")
+				if parts[0] == "-" && len(parts) == 1 {
+					continue
+				}
+				if parts[0] != "" {
+					name = parts[0]
+				}
+				omitEmpty = hasTagOption(tag, "omitempty")
+			}
+
+			fv := v.Field(i)
+			if omitEmpty && fv.IsZero() {
+				continue
+			}
+			value, err := canonicalTree(fv)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			out[name] = value
+		}
+		return out, nil
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			value, err := canonicalTree(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			out[fmt.Sprint(iter.Key().Interface())] = value
+		}
+		return out, nil
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return reencodeJSON(v.Interface()) // base64, as encoding/json does
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			value, err := canonicalTree(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = value
+		}
+		return out, nil
+
+	case reflect.Func, reflect.Chan, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return nil, fmt.Errorf("unsupported type for canonical encoding: %s", v.Type())
+
+	default:
+		return v.Interface(), nil
+	}
+}
+
+// reencodeJSON round-trips v through encoding/json so its own marshaling
+// rules apply, while keeping numbers exact.
+func reencodeJSON(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out interface{}
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// hasTagOption reports whether a comma-separated struct tag contains option.
+func hasTagOption(tag, option string) bool {
+	for _, part := range strings.Split(tag, ",") {
+		if part == option {
+			return true
+		}
+	}
+	return false
+}
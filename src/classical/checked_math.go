@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// ErrIntegerOverflow is wrapped by every checked arithmetic failure, so a
+// size or soundness computation that would wrap is refused instead.
+var ErrIntegerOverflow = errors.New("integer overflow")
+
+// signedInt is the signed integer types the checked helpers accept.
+type signedInt interface {
+	~int | ~int32 | ~int64
+}
+
+// maxOf returns the largest value of T: math.MaxInt64 if T holds it, and
+// math.MaxInt32 otherwise.
+func maxOf[T signedInt]() T {
+	if v := int64(math.MaxInt64); int64(T(v)) == v {
+		return T(v)
+	}
+	return T(math.MaxInt32)
+}
+
+// minOf returns the smallest value of T.
+func minOf[T signedInt]() T {
+	return -maxOf[T]() - 1
+}
+
+// checkedAdd returns a + b, or ErrIntegerOverflow if it does not fit T.
+func checkedAdd[T signedInt](a, b T) (T, error) {
+	if (b > 0 && a > maxOf[T]()-b) || (b < 0 && a < minOf[T]()-b) {
+		return 0, fmt.Errorf("%w: %d + %d", ErrIntegerOverflow, a, b)
+	}
+	return a + b, nil
+}
+
+// checkedMul returns a · b, or ErrIntegerOverflow if it does not fit T.
+func checkedMul[T signedInt](a, b T) (T, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	product := a * b
+	if product/b != a || (b == -1 && a == minOf[T]()) {
+		return 0, fmt.Errorf("%w: %d · %d", ErrIntegerOverflow, a, b)
+	}
+	return product, nil
+}
+
+// checkedCeilDiv returns ⌈a / b⌉ for a ≥ 0 and b > 0 without forming
+// a + b - 1, which wraps for a near the maximum.
+func checkedCeilDiv[T signedInt](a, b T) (T, error) {
+	if a < 0 || b <= 0 {
+		return 0, fmt.Errorf("ceiling division of %d by %d is undefined here", a, b)
+	}
+	quotient := a / b
+	if a%b != 0 {
+		quotient++
+	}
+	return quotient, nil
+}
+
+// checkedPow2 returns 2^exp as an int. Unlike 1 << exp, which is 0 or
+// negative from exp = 63 on, it fails for any exp that does not fit.
+func checkedPow2(exp int) (int, error) {
+	if exp < 0 || exp >= bits.UintSize-1 {
+		return 0, fmt.Errorf("%w: 2^%d", ErrIntegerOverflow, exp)
+	}
+	return 1 << exp, nil
+}
@@ -1,11 +1,26 @@
 package main
 
 import (
-	"fmt"
 	"lukechampine.com/blake3"
 )
 
+// GenerateCommitment commits to superpos with the default numeric
+// encoding. It returns nil if the superposition contains non-finite values.
 func GenerateCommitment(superpos Superposition, identifier string, key []byte) []byte {
+	commitment, err := GenerateCommitmentWithEncoding(superpos, identifier, key, DefaultNumericEncoding())
+	if err != nil {
+		return nil
+	}
+	return commitment
+}
+
+// GenerateCommitmentWithEncoding commits to superpos, canonicalizing every
+// coordinate and amplitude with enc.
+func GenerateCommitmentWithEncoding(superpos Superposition, identifier string, key []byte, enc NumericEncoding) ([]byte, error) {
+	if err := enc.Validate(); err != nil {
+		return nil, err
+	}
+
 	// Ensure key is exactly 32 bytes for blake3
 	var blake3Key [32]byte
 	if len(key) >= 32 {
@@ -19,10 +34,11 @@ func GenerateCommitment(superpos Superposition, identifier string, key []byte) [
 
 	// Include both states and amplitudes
 	for i, coord := range superpos.States {
-		hasher.Write([]byte(fmt.Sprintf("%f%f", real(coord), imag(coord))))
-		hasher.Write([]byte(fmt.Sprintf("%f", superpos.Amplitudes[i])))
+		if err := enc.writeNumbers(hasher, real(coord), imag(coord), superpos.Amplitudes[i]); err != nil {
+			return nil, err
+		}
 	}
 
 	hasher.Write([]byte(identifier))
-	return hasher.Sum(nil)
+	return hasher.Sum(nil), nil
 }
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Environment variables that override the default locations.
+const (
+	DataDirEnv  = "QZKP_DATA_DIR"
+	CacheDirEnv = "QZKP_CACHE_DIR"
+)
+
+// appDirName is the per-application directory created under the OS
+// data and cache roots.
+const appDirName = "qzkp"
+
+// DataDirs locates the files qzkp keeps between runs. Data holds the
+// keystore and proof store, which must not be lost; Cache holds files such
+// as the quantum state cache that can be regenerated.
+type DataDirs struct {
+	Data  string
+	Cache string
+}
+
+// DefaultDataDirs returns the OS-appropriate locations, honoring
+// QZKP_DATA_DIR and QZKP_CACHE_DIR:
+//
+//	Linux:   $XDG_DATA_HOME/qzkp (~/.local/share/qzkp), $XDG_CACHE_HOME/qzkp (~/.cache/qzkp)
+//	macOS:   ~/Library/Application Support/qzkp, ~/Library/Caches/qzkp
+//	Windows: %LocalAppData%\qzkp, %LocalAppData%\qzkp\cache
+func DefaultDataDirs() (*DataDirs, error) {
+	dirs := &DataDirs{
+		Data:  os.Getenv(DataDirEnv),
+		Cache: os.Getenv(CacheDirEnv),
+	}
+
+	if dirs.Data == "" {
+		root, err := userDataDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate data directory: %w", err)
+		}
+		dirs.Data = filepath.Join(root, appDirName)
+	}
+
+	if dirs.Cache == "" {
+		if os.Getenv(DataDirEnv) != "" {
+			// An explicit data directory keeps everything in one place
+			dirs.Cache = filepath.Join(dirs.Data, "cache")
+		} else if runtime.GOOS == "windows" {
+			// os.UserCacheDir is %LocalAppData% on Windows, the same root
+			// as the data directory
+			dirs.Cache = filepath.Join(dirs.Data, "cache")
+		} else {
+			root, err := os.UserCacheDir()
+			if err != nil {
+				return nil, fmt.Errorf("failed to locate cache directory: %w", err)
+			}
+			dirs.Cache = filepath.Join(root, appDirName)
+		}
+	}
+
+	return dirs, nil
+}
+
+// NewDataDirs keeps all files under base, with the cache in base/cache.
+func NewDataDirs(base string) *DataDirs {
+	return &DataDirs{
+		Data:  base,
+		Cache: filepath.Join(base, "cache"),
+	}
+}
+
+// userDataDir returns the per-user root for application data.
+func userDataDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return dir, nil
+		}
+		return os.UserConfigDir()
+	case "darwin", "ios":
+		// Application Support is also what os.UserConfigDir returns
+		return os.UserConfigDir()
+	default:
+		if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+			if !filepath.IsAbs(dir) {
+				return "", errors.New("XDG_DATA_HOME must be an absolute path")
+			}
+			return dir, nil
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, ".local", "share"), nil
+	}
+}
+
+// DataPath returns the path of name inside the data directory.
+func (d *DataDirs) DataPath(name string) string {
+	return filepath.Join(d.Data, name)
+}
+
+// CachePath returns the path of name inside the cache directory.
+func (d *DataDirs) CachePath(name string) string {
+	return filepath.Join(d.Cache, name)
+}
+
+// KeystoreDir is where keys are stored.
+func (d *DataDirs) KeystoreDir() string {
+	return filepath.Join(d.Data, "keys")
+}
+
+// ProofStoreDir is where proofs are stored.
+func (d *DataDirs) ProofStoreDir() string {
+	return filepath.Join(d.Data, "proofs")
+}
+
+// Ensure creates the data and cache directories. Both are private to the
+// user since the data directory holds key material.
+func (d *DataDirs) Ensure() error {
+	for _, dir := range []string{d.Data, d.Cache} {
+		if err := hostFS.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// MigrateLegacyFile moves a file from a location used by older releases,
+// typically a name relative to the working directory, to its new path. It
+// does nothing if the legacy file is absent or the new file already exists,
+// and reports whether a file was moved.
+func MigrateLegacyFile(legacyPath, newPath string) (bool, error) {
+	legacyInfo, err := hostFS.Stat(legacyPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if legacyInfo.IsDir() {
+		return false, fmt.Errorf("legacy path %s is a directory", legacyPath)
+	}
+	if _, err := hostFS.Stat(newPath); err == nil {
+		return false, nil
+	}
+
+	if err := hostFS.MkdirAll(filepath.Dir(newPath), 0700); err != nil {
+		return false, err
+	}
+	if err := hostFS.Rename(legacyPath, newPath); err == nil {
+		return true, nil
+	}
+
+	// Rename fails across volumes, so fall back to copy and remove
+	if err := copyFile(legacyPath, newPath, legacyInfo.Mode().Perm()); err != nil {
+		return false, fmt.Errorf("failed to migrate %s: %w", legacyPath, err)
+	}
+	if err := hostFS.Remove(legacyPath); err != nil {
+		return true, fmt.Errorf("migrated %s but could not remove it: %w", legacyPath, err)
+	}
+	return true, nil
+}
+
+// copyFile copies src to dst, which must not exist.
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := hostFS.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := hostFS.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		hostFS.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+// writeFileAtomic writes data to a temporary file beside path and renames
+// it into place, so readers never observe a partial file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := hostFS.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := hostFS.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer hostFS.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil && runtime.GOOS != "windows" {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return hostFS.Rename(tmp.Name(), path)
+}
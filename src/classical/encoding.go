@@ -4,16 +4,62 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"errors"
+	"fmt"
+	"io"
 	"math"
 	"math/big"
 )
 
+// SuperpositionMode selects how the amplitudes of a superposition are
+// chosen. It is recorded in proof metadata.
+type SuperpositionMode string
+
+const (
+	// SuperpositionDeterministic weights each state by its probability,
+	// so the same states always give the same proof commitment
+	SuperpositionDeterministic SuperpositionMode = "deterministic"
+	// SuperpositionRandomized draws the weights from an RNG
+	SuperpositionRandomized SuperpositionMode = "randomized"
+)
+
+// SuperpositionOptions configures NewSuperposition.
+type SuperpositionOptions struct {
+	Mode SuperpositionMode
+	Rand io.Reader // randomized mode only; nil uses crypto/rand
+}
+
+// NewSuperposition creates a superposition of states in the given mode.
+func NewSuperposition(states []complex128, opts SuperpositionOptions) (Superposition, error) {
+	switch opts.Mode {
+	case SuperpositionDeterministic:
+		return CreateDeterministicSuperposition(states), nil
+	case SuperpositionRandomized:
+		return randomizedSuperposition(states, opts.Rand)
+	default:
+		return Superposition{}, fmt.Errorf("unknown superposition mode %q", opts.Mode)
+	}
+}
+
+// CreateSuperposition creates a superposition with random amplitudes.
+// Prefer NewSuperposition, which makes the mode explicit.
 func CreateSuperposition(states []complex128) Superposition {
+	superpos, _ := randomizedSuperposition(states, nil)
+	return superpos
+}
+
+// randomizedSuperposition weights each state uniformly from 1 to 100.
+func randomizedSuperposition(states []complex128, rng io.Reader) (Superposition, error) {
+	if rng == nil {
+		rng = rand.Reader
+	}
 	sum := 0.0
 	amplitudes := make([]float64, len(states))
 
 	for i := range states {
-		r, _ := rand.Int(rand.Reader, big.NewInt(100))
+		r, err := rand.Int(rng, big.NewInt(100))
+		if err != nil {
+			return Superposition{}, fmt.Errorf("failed to draw amplitude: %w", err)
+		}
 		val := float64(r.Int64()) + 1
 		sum += val
 		amplitudes[i] = val
@@ -27,15 +73,7 @@ func CreateSuperposition(states []complex128) Superposition {
 	return Superposition{
 		States:     states,
 		Amplitudes: amplitudes,
-	}
-}
-
-func (s Superposition) CoordinatesAsSlices() [][]float64 {
-	out := make([][]float64, len(s.States))
-	for i, c := range s.States {
-		out[i] = []float64{real(c), imag(c)}
-	}
-	return out
+	}, nil
 }
 
 // BytesToState converts arbitrary bytes to a normalized quantum state vector.
@@ -104,13 +142,7 @@ func bytesToFloat(bytes []byte) float64 {
 
 // normalizeStateVector normalizes a quantum state vector so that sum(|c|^2) = 1
 func normalizeStateVector(states []complex128) []complex128 {
-	// Calculate the norm
-	var norm float64
-	for _, c := range states {
-		r := real(c)
-		i := imag(c)
-		norm += r*r + i*i
-	}
+	norm := Norm(states)
 
 	if norm == 0 {
 		// Handle zero vector case - create a simple normalized state
@@ -119,10 +151,9 @@ func normalizeStateVector(states []complex128) []complex128 {
 	}
 
 	// Normalize
-	normSqrt := math.Sqrt(norm)
 	normalized := make([]complex128, len(states))
 	for i, c := range states {
-		normalized[i] = c / complex(normSqrt, 0)
+		normalized[i] = c / complex(norm, 0)
 	}
 
 	return normalized
@@ -159,27 +190,19 @@ func CreateDeterministicSuperposition(states []complex128) Superposition {
 	}
 }
 
-// calculateEntanglement calculates the entanglement measure for a quantum state
+// calculateEntanglement returns the entanglement entropy of a quantum state
+// across its middle qubit boundary, normalized by the maximum for that
+// split. States without qubit structure are reported as unentangled.
 func calculateEntanglement(states []complex128) float64 {
-	if len(states) <= 1 {
+	entropy, err := BipartiteEntanglementEntropy(states)
+	if err != nil || len(states) < 4 {
 		return 0.0
 	}
-
-	// Calculate von Neumann entropy as a measure of entanglement
-	var entropy float64
-	for _, state := range states {
-		prob := real(state)*real(state) + imag(state)*imag(state)
-		if prob > 1e-10 { // Avoid log(0)
-			entropy -= prob * math.Log2(prob)
-		}
+	qubits := 0
+	for 1<<qubits < len(states) {
+		qubits++
 	}
-
-	// Normalize by maximum possible entropy for this dimension
-	maxEntropy := math.Log2(float64(len(states)))
-	if maxEntropy > 0 {
-		return entropy / maxEntropy
-	}
-	return 0.0
+	return entropy / float64(qubits/2)
 }
 
 // calculateCoherence calculates the coherence measure for a quantum state
@@ -202,4 +225,4 @@ func calculateCoherence(states []complex128) float64 {
 	}
 
 	return coherence
-}
\ No newline at end of file
+}
@@ -1,17 +1,14 @@
 package main
 
-import (
-	"math"
-	"math/cmplx"
-)
-
+// CalculateEntropy returns the Shannon entropy, in bits, of the computational
+// basis distribution of coords, or zero for an empty or zero vector.
+//
+// Deprecated: use ProbabilityEntropy, which reports invalid input, or
+// VonNeumannEntropy and EntanglementEntropy for mixed and entangled states.
 func CalculateEntropy(coords []complex128) float64 {
-	var entropy float64
-	for _, c := range coords {
-		p := cmplx.Abs(c) * cmplx.Abs(c)
-		if p > 0 {
-			entropy -= p * math.Log2(p)
-		}
+	h, err := ProbabilityEntropy(coords)
+	if err != nil {
+		return 0
 	}
-	return entropy
+	return h
 }
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// ErrMalformedField is the only error the field codecs return, whatever is
+// wrong with the input, so that a rejection says nothing about where.
+var ErrMalformedField = errors.New("malformed encoded field")
+
+// The field codecs encode secret-adjacent proof material: commitments,
+// responses, MACs and signatures. Unlike encoding/hex and encoding/base64,
+// whose decoders return at the first bad character and branch on every
+// character's class, they take time that depends only on the input's
+// length: each character is classified with arithmetic rather than
+// branches or table lookups, and errors are accumulated and reported once
+// the whole input has been read. The length itself is public and is
+// checked against the caller's bounds before any character is looked at.
+
+// EncodeHexField returns the lower-case hex encoding of src.
+func EncodeHexField(src []byte) string {
+	var b strings.Builder
+	b.Grow(2 * len(src))
+	for _, c := range src {
+		b.WriteByte(hexDigit(int32(c >> 4)))
+		b.WriteByte(hexDigit(int32(c & 0x0f)))
+	}
+	return b.String()
+}
+
+// DecodeHexField decodes s, which must encode between minLen and maxLen
+// bytes. Upper- and lower-case digits are accepted, as by encoding/hex.
+func DecodeHexField(s string, minLen, maxLen int) ([]byte, error) {
+	return decodeHexFieldInto(nil, s, minLen, maxLen)
+}
+
+// decodeHexFieldInto is DecodeHexField decoding into dst's storage when it
+// has the capacity, for callers that reuse their buffers.
+func decodeHexFieldInto(dst []byte, s string, minLen, maxLen int) ([]byte, error) {
+	if len(s)%2 != 0 || len(s)/2 < minLen || len(s)/2 > maxLen {
+		return nil, ErrMalformedField
+	}
+	dst = slices.Grow(dst[:0], len(s)/2)[:len(s)/2]
+	var invalid int32
+	for i := range dst {
+		hi, badHi := hexValue(s[2*i])
+		lo, badLo := hexValue(s[2*i+1])
+		dst[i] = byte(hi<<4 | lo)
+		invalid |= badHi | badLo
+	}
+	if invalid != 0 {
+		clear(dst)
+		return nil, ErrMalformedField
+	}
+	return dst, nil
+}
+
+// EncodeBase64Field returns the unpadded base64url encoding of src, as
+// base64.RawURLEncoding does.
+func EncodeBase64Field(src []byte) string {
+	var b strings.Builder
+	b.Grow((len(src)*8 + 5) / 6)
+	for i := 0; i < len(src); i += 3 {
+		var group [3]byte
+		n := copy(group[:], src[i:])
+		v := int32(group[0])<<16 | int32(group[1])<<8 | int32(group[2])
+		for j := 0; j <= n; j++ {
+			b.WriteByte(base64Digit(v >> (18 - 6*j) & 0x3f))
+		}
+	}
+	return b.String()
+}
+
+// DecodeBase64Field decodes unpadded base64url s, which must encode
+// between minLen and maxLen bytes. Unused trailing bits must be zero, so
+// every byte string has exactly one encoding.
+func DecodeBase64Field(s string, minLen, maxLen int) ([]byte, error) {
+	n := len(s) * 6 / 8
+	if len(s)%4 == 1 || n < minLen || n > maxLen {
+		return nil, ErrMalformedField
+	}
+	dst := make([]byte, n)
+	var invalid int32
+	for i := 0; i < len(s); i += 4 {
+		var v int32
+		chars := min(4, len(s)-i)
+		for j := 0; j < chars; j++ {
+			digit, bad := base64Value(s[i+j])
+			v |= digit << (18 - 6*j)
+			invalid |= bad
+		}
+		k := i / 4 * 3
+		switch chars {
+		case 4:
+			dst[k], dst[k+1], dst[k+2] = byte(v>>16), byte(v>>8), byte(v)
+		case 3:
+			dst[k], dst[k+1] = byte(v>>16), byte(v>>8)
+			invalid |= v & 0xff
+		case 2:
+			dst[k] = byte(v >> 16)
+			invalid |= v & 0xffff
+		}
+	}
+	if invalid != 0 {
+		clear(dst)
+		return nil, ErrMalformedField
+	}
+	return dst, nil
+}
+
+// inRange is -1 (all ones) if lo <= x <= hi and 0 otherwise, for x, lo
+// and hi in [0, 255].
+func inRange(x, lo, hi int32) int32 {
+	return ((lo - 1 - x) & (x - hi - 1)) >> 8
+}
+
+// hexDigit is the lower-case hex digit of v in [0, 15].
+func hexDigit(v int32) byte {
+	// Past 9, skip from ':' to 'a'
+	return byte(v + '0' + ((9-v)>>8)&('a'-'0'-10))
+}
+
+// hexValue returns the value of hex digit c, and 1 in bad if c is not one.
+func hexValue(c byte) (value, bad int32) {
+	x := int32(c)
+	digit := inRange(x, '0', '9')
+	lower := inRange(x, 'a', 'f')
+	upper := inRange(x, 'A', 'F')
+	value = digit&(x-'0') | lower&(x-'a'+10) | upper&(x-'A'+10)
+	return value, ^(digit | lower | upper) & 1
+}
+
+// base64Digit is the base64url digit of v in [0, 63].
+func base64Digit(v int32) byte {
+	c := v + 'A'
+	c += ((25 - v) >> 8) & ('a' - 'A' - 26)
+	c += ((51 - v) >> 8) & ('0' - 'a' - 26)
+	c += ((61 - v) >> 8) & ('-' - '0' - 10)
+	c += ((62 - v) >> 8) & ('_' - '-' - 1)
+	return byte(c)
+}
+
+// base64Value returns the value of base64url digit c, and 1 in bad if c
+// is not one.
+func base64Value(c byte) (value, bad int32) {
+	x := int32(c)
+	upper := inRange(x, 'A', 'Z')
+	lower := inRange(x, 'a', 'z')
+	digit := inRange(x, '0', '9')
+	dash := inRange(x, '-', '-')
+	underscore := inRange(x, '_', '_')
+	value = upper&(x-'A') | lower&(x-'a'+26) | digit&(x-'0'+52) | dash&62 | underscore&63
+	return value, ^(upper | lower | digit | dash | underscore) & 1
+}
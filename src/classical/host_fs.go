@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrInMemory is returned when a component configured for in-memory
+// operation would have to reach the filesystem or the network.
+var ErrInMemory = errors.New("filesystem and network access are disabled in in-memory mode")
+
+// HostBound is implemented by plug-ins that reach the filesystem or the
+// network, such as a FileNonceStore or an HTTPTimeAuthority. In-memory
+// provers and services refuse them.
+type HostBound interface {
+	// HostAccess describes what is accessed, e.g. a file path or a URL
+	HostAccess() string
+}
+
+// hostFileSystem is the filesystem as seen by the stores, caches and
+// configuration loaders. They reach files only through hostFS, so tests
+// can substitute a filesystem that refuses or records every access.
+type hostFileSystem interface {
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	Open(name string) (*os.File, error)
+	OpenFile(name string, flag int, perm os.FileMode) (*os.File, error)
+	CreateTemp(dir, pattern string) (*os.File, error)
+	ReadDir(name string) ([]os.DirEntry, error)
+	Stat(name string) (os.FileInfo, error)
+	MkdirAll(path string, perm os.FileMode) error
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	Truncate(name string, size int64) error
+}
+
+// hostFS is the filesystem every file access goes through.
+var hostFS hostFileSystem = osFileSystem{}
+
+// osFileSystem is the operating system's filesystem.
+type osFileSystem struct{}
+
+func (osFileSystem) ReadFile(name string) ([]byte, error) { return os.ReadFile(name) }
+
+func (osFileSystem) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (osFileSystem) Open(name string) (*os.File, error) { return os.Open(name) }
+
+func (osFileSystem) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	return os.OpenFile(name, flag, perm)
+}
+
+func (osFileSystem) CreateTemp(dir, pattern string) (*os.File, error) {
+	return os.CreateTemp(dir, pattern)
+}
+
+func (osFileSystem) ReadDir(name string) ([]os.DirEntry, error) { return os.ReadDir(name) }
+
+func (osFileSystem) Stat(name string) (os.FileInfo, error) { return os.Stat(name) }
+
+func (osFileSystem) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+
+func (osFileSystem) Rename(oldpath, newpath string) error { return os.Rename(oldpath, newpath) }
+
+func (osFileSystem) Remove(name string) error { return os.Remove(name) }
+
+func (osFileSystem) Truncate(name string, size int64) error { return os.Truncate(name, size) }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"math"
+)
+
+// FixedPointScheme identifies the scaled-int64 encoding of hashed numbers.
+const FixedPointScheme = "fixed-i64"
+
+// DefaultFixedPointPrecision matches the ten decimal places the original
+// text encoding used.
+const DefaultFixedPointPrecision = 10
+
+// maxFixedPointPrecision keeps |x|·10^p within int64 for amplitudes and
+// phases, which are bounded by π.
+const maxFixedPointPrecision = 18
+
+// NumericEncoding describes how floating-point values are canonicalized
+// before hashing. It is recorded in every proof so that commitments can be
+// recomputed identically on any architecture.
+type NumericEncoding struct {
+	Scheme    string `json:"scheme"`
+	Precision int    `json:"precision"` // decimal places kept
+}
+
+// FixedPointEncoding returns a scaled-int64 encoding keeping precision
+// decimal places.
+func FixedPointEncoding(precision int) (NumericEncoding, error) {
+	e := NumericEncoding{Scheme: FixedPointScheme, Precision: precision}
+	if err := e.Validate(); err != nil {
+		return NumericEncoding{}, err
+	}
+	return e, nil
+}
+
+// DefaultNumericEncoding returns the encoding used by new provers.
+func DefaultNumericEncoding() NumericEncoding {
+	return NumericEncoding{Scheme: FixedPointScheme, Precision: DefaultFixedPointPrecision}
+}
+
+// Validate checks that the encoding is supported.
+func (e NumericEncoding) Validate() error {
+	if e.Scheme != FixedPointScheme {
+		return fmt.Errorf("unsupported numeric encoding: %q", e.Scheme)
+	}
+	if e.Precision < 0 || e.Precision > maxFixedPointPrecision {
+		return fmt.Errorf("invalid fixed-point precision: %d (must be 0-%d)", e.Precision, maxFixedPointPrecision)
+	}
+	return nil
+}
+
+// Encode returns the 8-byte big-endian two's complement of round(x·10^p).
+// Rounding is half away from zero, and -0 encodes as 0.
+func (e NumericEncoding) Encode(x float64) ([]byte, error) {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return nil, fmt.Errorf("cannot encode non-finite value %v", x)
+	}
+	scaled := math.Round(x * math.Pow10(e.Precision))
+	if scaled >= math.MaxInt64 || scaled <= math.MinInt64 {
+		return nil, fmt.Errorf("value %g overflows fixed-point precision %d", x, e.Precision)
+	}
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(int64(scaled)))
+	return buf[:], nil
+}
+
+// writeNumbers writes the canonical encoding of each value to hasher.
+func (e NumericEncoding) writeNumbers(hasher hash.Hash, values ...float64) error {
+	for _, x := range values {
+		encoded, err := e.Encode(x)
+		if err != nil {
+			return err
+		}
+		hasher.Write(encoded)
+	}
+	return nil
+}
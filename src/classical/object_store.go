@@ -0,0 +1,183 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"iter"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Errors returned by ObjectStore implementations.
+var (
+	ErrObjectNotFound     = errors.New("object not found")
+	ErrPreconditionFailed = errors.New("object was changed concurrently")
+)
+
+// PutCondition makes an object write conditional. The zero value writes
+// unconditionally.
+type PutCondition struct {
+	// IfVersion, when set, only replaces the object at this version, as
+	// returned by Get or Put
+	IfVersion string
+	// IfAbsent only creates the object
+	IfAbsent bool
+}
+
+// ObjectStore is a flat store of byte objects, such as an S3 bucket,
+// that the proof store and quantum state cache can keep their files in
+// instead of a local directory. Versions are opaque (an ETag or
+// generation number); a conditional Put whose condition no longer holds
+// fails with ErrPreconditionFailed, which lets several processes share a
+// store with optimistic concurrency.
+type ObjectStore interface {
+	// Get returns the object under key and its version, or
+	// ErrObjectNotFound
+	Get(key string) (data []byte, version string, err error)
+	// Put stores data under key if cond holds and returns the new version
+	Put(key string, data []byte, cond PutCondition) (version string, err error)
+	// Delete removes the object under key; a missing object is not an
+	// error
+	Delete(key string) error
+	// List yields the keys starting with prefix in lexical order
+	List(prefix string) iter.Seq2[string, error]
+}
+
+// MemoryObjectStore is an ObjectStore held in memory, for tests and for
+// processes that only need a store for their own lifetime.
+type MemoryObjectStore struct {
+	mu      sync.Mutex
+	objects map[string]memoryObject
+	next    int
+}
+
+type memoryObject struct {
+	data    []byte
+	version string
+}
+
+// NewMemoryObjectStore returns an empty MemoryObjectStore.
+func NewMemoryObjectStore() *MemoryObjectStore {
+	return &MemoryObjectStore{objects: make(map[string]memoryObject)}
+}
+
+// Get implements ObjectStore.
+func (m *MemoryObjectStore) Get(key string) ([]byte, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	obj, ok := m.objects[key]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	return append([]byte(nil), obj.data...), obj.version, nil
+}
+
+// Put implements ObjectStore.
+func (m *MemoryObjectStore) Put(key string, data []byte, cond PutCondition) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	obj, exists := m.objects[key]
+	if cond.IfAbsent && exists || cond.IfVersion != "" && (!exists || obj.version != cond.IfVersion) {
+		return "", fmt.Errorf("%w: %s", ErrPreconditionFailed, key)
+	}
+	m.next++
+	version := strconv.Quote(strconv.Itoa(m.next))
+	m.objects[key] = memoryObject{data: append([]byte(nil), data...), version: version}
+	return version, nil
+}
+
+// Delete implements ObjectStore.
+func (m *MemoryObjectStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	return nil
+}
+
+// List implements ObjectStore. Keys are collected before the first is
+// yielded, so the store may be changed during iteration.
+func (m *MemoryObjectStore) List(prefix string) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		m.mu.Lock()
+		var keys []string
+		for key := range m.objects {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, key)
+			}
+		}
+		m.mu.Unlock()
+		sort.Strings(keys)
+		for _, key := range keys {
+			if !yield(key, nil) {
+				return
+			}
+		}
+	}
+}
+
+// EncryptedObjectStore encrypts objects on the client with AES-256-GCM
+// before they reach Store, so the storage provider only sees ciphertext.
+// Each object is bound to its key as additional data, so objects cannot be
+// swapped within the store. Keys, sizes and versions stay visible.
+type EncryptedObjectStore struct {
+	Store ObjectStore
+	aead  cipher.AEAD
+}
+
+// NewEncryptedObjectStore wraps store with client-side encryption under
+// the 32-byte key.
+func NewEncryptedObjectStore(store ObjectStore, key []byte) (*EncryptedObjectStore, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedObjectStore{Store: store, aead: aead}, nil
+}
+
+// Get implements ObjectStore.
+func (e *EncryptedObjectStore) Get(key string) ([]byte, string, error) {
+	sealed, version, err := e.Store.Get(key)
+	if err != nil {
+		return nil, "", err
+	}
+	n := e.aead.NonceSize()
+	if len(sealed) < n {
+		return nil, "", fmt.Errorf("object %s is too short to be encrypted", key)
+	}
+	data, err := e.aead.Open(nil, sealed[:n], sealed[n:], []byte(key))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decrypt object %s: %w", key, err)
+	}
+	return data, version, nil
+}
+
+// Put implements ObjectStore.
+func (e *EncryptedObjectStore) Put(key string, data []byte, cond PutCondition) (string, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return e.Store.Put(key, e.aead.Seal(nonce, nonce, data, []byte(key)), cond)
+}
+
+// Delete implements ObjectStore.
+func (e *EncryptedObjectStore) Delete(key string) error {
+	return e.Store.Delete(key)
+}
+
+// List implements ObjectStore.
+func (e *EncryptedObjectStore) List(prefix string) iter.Seq2[string, error] {
+	return e.Store.List(prefix)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudflare/circl/kem/mlkem/mlkem1024"
+)
+
+// PayloadKEM wraps an ML-KEM-1024 keypair that payloads are sealed to. A
+// recipient built from a published key has no private half.
+type PayloadKEM struct {
+	Pub  *mlkem1024.PublicKey
+	Priv *mlkem1024.PrivateKey
+}
+
+// NewPayloadKEM generates a new ML-KEM-1024 keypair
+func NewPayloadKEM() (*PayloadKEM, error) {
+	pub, priv, err := mlkem1024.GenerateKeyPair(nil)
+	if err != nil {
+		return nil, fmt.Errorf("key generation failed: %w", err)
+	}
+	return &PayloadKEM{Pub: pub, Priv: priv}, nil
+}
+
+// NewPayloadRecipient builds an encapsulate-only key from an encoded public
+// key, e.g. one published by a verifier
+func NewPayloadRecipient(publicKey []byte) (*PayloadKEM, error) {
+	pub, err := mlkem1024.Scheme().UnmarshalBinaryPublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	return &PayloadKEM{Pub: pub.(*mlkem1024.PublicKey)}, nil
+}
+
+// PublicKeyBytes returns the encoded public key
+func (k *PayloadKEM) PublicKeyBytes() []byte {
+	b, _ := k.Pub.MarshalBinary()
+	return b
+}
+
+// Encapsulate returns a fresh shared secret and its encapsulation to k
+func (k *PayloadKEM) Encapsulate() (ciphertext, secret []byte, err error) {
+	return mlkem1024.Scheme().Encapsulate(k.Pub)
+}
+
+// Decapsulate recovers the shared secret of an encapsulation to k
+func (k *PayloadKEM) Decapsulate(ciphertext []byte) ([]byte, error) {
+	if k.Priv == nil {
+		return nil, errors.New("decapsulation requires the private key")
+	}
+	return mlkem1024.Scheme().Decapsulate(k.Priv, ciphertext)
+}
@@ -1,3 +1,9 @@
+// Kyber is only needed by the optional quantum-safe random generator, so
+// verify-only builds (-tags qzkp_verify) leave this file and its
+// dependency out.
+
+//go:build !qzkp_verify
+
 package main
 
 import (
@@ -5,6 +11,7 @@ import (
 	"fmt"
 	"go.dedis.ch/kyber/v3"
 	"io"
+	"sync"
 	"time"
 
 	"go.dedis.ch/kyber/v3/group/edwards25519"
@@ -135,12 +142,40 @@ func (qsrr *QuantumSafeRandomReader) Read(p []byte) (int, error) {
 	return len(p), nil
 }
 
-// HybridRandomGenerator combines multiple entropy sources for maximum security
+// NewDefaultRandomnessChain returns the standard fallback order for
+// SecureQuantumZKP.Randomness: the Kyber BLAKE2XB stream, then qrng when
+// it is not nil, then the system RNG.
+func NewDefaultRandomnessChain(qrng io.Reader) (*RandomnessChain, error) {
+	stream, err := NewQuantumSafeRandomReader()
+	if err != nil {
+		return nil, err
+	}
+	sources := []RandomnessSource{{Class: RandomnessKyberStream, Reader: stream}}
+	if qrng != nil {
+		sources = append(sources, RandomnessSource{Class: RandomnessQRNG, Reader: qrng})
+	}
+	return NewRandomnessChain(append(sources, SystemRandomnessSource())...)
+}
+
+// HybridRandomGenerator combines multiple entropy sources for maximum security.
+// It is safe for concurrent use.
 type HybridRandomGenerator struct {
+	mu          sync.Mutex // guards the Kyber stream
 	quantumSafe *QuantumSafeRandom
 	systemRand  io.Reader
 }
 
+// newHybridRandom returns the HybridRandom source of new SecureQuantumZKP
+// instances, or nil when the Kyber stream cannot be seeded, in which case
+// they fall back to crypto/rand.
+func newHybridRandom() io.Reader {
+	hrg, err := NewHybridRandomGenerator()
+	if err != nil {
+		return nil
+	}
+	return hrg
+}
+
 // NewHybridRandomGenerator creates a hybrid random generator
 func NewHybridRandomGenerator() (*HybridRandomGenerator, error) {
 	qsr, err := NewQuantumSafeRandom()
@@ -157,7 +192,9 @@ func NewHybridRandomGenerator() (*HybridRandomGenerator, error) {
 // GenerateHybridRandomBytes combines quantum-safe and system randomness
 func (hrg *HybridRandomGenerator) GenerateHybridRandomBytes(length int) ([]byte, error) {
 	// Get randomness from both sources
+	hrg.mu.Lock()
 	quantumBytes, err := hrg.quantumSafe.GenerateRandomBytes(length)
+	hrg.mu.Unlock()
 	if err != nil {
 		return nil, fmt.Errorf("quantum-safe generation failed: %v", err)
 	}
@@ -176,6 +213,19 @@ func (hrg *HybridRandomGenerator) GenerateHybridRandomBytes(length int) ([]byte,
 	return result, nil
 }
 
+// Read implements io.Reader, so the generator can serve as
+// SecureQuantumZKP.HybridRandom or a RandomnessSource.
+func (hrg *HybridRandomGenerator) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	randomBytes, err := hrg.GenerateHybridRandomBytes(len(p))
+	if err != nil {
+		return 0, err
+	}
+	return copy(p, randomBytes), nil
+}
+
 // ValidateRandomness performs basic statistical tests on generated randomness
 func ValidateRandomness(data []byte) map[string]float64 {
 	if len(data) == 0 {
@@ -0,0 +1,14 @@
+// Verify-only builds (-tags qzkp_verify) have no Kyber stream; see
+// quantum_safe_random.go.
+
+//go:build qzkp_verify
+
+package main
+
+import "io"
+
+// newHybridRandom returns nil: without the Kyber stream, new
+// SecureQuantumZKP instances draw their randomness from crypto/rand.
+func newHybridRandom() io.Reader {
+	return nil
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Object storage providers, which differ in how conditional writes are
+// expressed.
+const (
+	// S3ProviderS3 is Amazon S3 and compatible services such as MinIO:
+	// versions are ETags, checked with If-Match and If-None-Match
+	S3ProviderS3 = "s3"
+	// S3ProviderGCS is Google Cloud Storage through its XML API with HMAC
+	// keys: versions are object generations, checked with
+	// x-goog-if-generation-match
+	S3ProviderGCS = "gcs"
+)
+
+// maxObjectSize bounds the objects S3ObjectStore reads.
+const maxObjectSize = 256 << 20
+
+// S3Config locates a bucket on an S3-compatible service.
+type S3Config struct {
+	Endpoint string `json:"endpoint"` // e.g. https://s3.eu-west-1.amazonaws.com or https://storage.googleapis.com
+	Region   string `json:"region"`   // "auto" for GCS
+	Bucket   string `json:"bucket"`
+	Prefix   string `json:"prefix,omitempty"`   // prepended to every key, e.g. "fleet-a/"
+	Provider string `json:"provider,omitempty"` // S3ProviderS3 if empty
+
+	AccessKey    string `json:"access_key"`
+	SecretKey    string `json:"secret_key"`
+	SessionToken string `json:"session_token,omitempty"`
+
+	HTTPClient *http.Client `json:"-"` // a client with a 30 second timeout if nil
+}
+
+// S3ObjectStore is an ObjectStore in an S3-compatible bucket, addressed
+// path-style and signed with AWS Signature Version 4.
+type S3ObjectStore struct {
+	config   S3Config
+	endpoint *url.URL
+	now      func() time.Time
+}
+
+// NewS3ObjectStore validates cfg and returns a store for its bucket. No
+// request is made until the store is used.
+func NewS3ObjectStore(cfg S3Config) (*S3ObjectStore, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "https" && endpoint.Scheme != "http") {
+		return nil, fmt.Errorf("invalid endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" || strings.Contains(cfg.Bucket, "/") {
+		return nil, fmt.Errorf("invalid bucket %q", cfg.Bucket)
+	}
+	if cfg.Region == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("region, access key and secret key are required")
+	}
+	switch cfg.Provider {
+	case "":
+		cfg.Provider = S3ProviderS3
+	case S3ProviderS3, S3ProviderGCS:
+	default:
+		return nil, fmt.Errorf("unknown object storage provider %q", cfg.Provider)
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &S3ObjectStore{config: cfg, endpoint: endpoint, now: time.Now}, nil
+}
+
+// Get implements ObjectStore.
+func (s *S3ObjectStore) Get(key string) ([]byte, string, error) {
+	resp, err := s.do(http.MethodGet, s.config.Prefix+key, nil, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, "", fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	case resp.StatusCode != http.StatusOK:
+		return nil, "", s3Error(resp, "get "+key)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxObjectSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read object %s: %w", key, err)
+	}
+	if len(data) > maxObjectSize {
+		return nil, "", fmt.Errorf("object %s exceeds %d bytes", key, maxObjectSize)
+	}
+	return data, s.version(resp.Header), nil
+}
+
+// Put implements ObjectStore.
+func (s *S3ObjectStore) Put(key string, data []byte, cond PutCondition) (string, error) {
+	header := http.Header{}
+	switch {
+	case s.config.Provider == S3ProviderGCS && cond.IfAbsent:
+		header.Set("x-goog-if-generation-match", "0")
+	case s.config.Provider == S3ProviderGCS && cond.IfVersion != "":
+		header.Set("x-goog-if-generation-match", cond.IfVersion)
+	case cond.IfAbsent:
+		header.Set("If-None-Match", "*")
+	case cond.IfVersion != "":
+		header.Set("If-Match", cond.IfVersion)
+	}
+	resp, err := s.do(http.MethodPut, s.config.Prefix+key, nil, header, data)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return s.version(resp.Header), nil
+	case http.StatusPreconditionFailed, http.StatusConflict:
+		// S3 answers 409 when a concurrent conditional write won
+		return "", fmt.Errorf("%w: %s", ErrPreconditionFailed, key)
+	default:
+		return "", s3Error(resp, "put "+key)
+	}
+}
+
+// Delete implements ObjectStore.
+func (s *S3ObjectStore) Delete(key string) error {
+	resp, err := s.do(http.MethodDelete, s.config.Prefix+key, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return s3Error(resp, "delete "+key)
+	}
+}
+
+// listBucketResult is the ListObjectsV2 response.
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List implements ObjectStore, reading one page of keys at a time.
+func (s *S3ObjectStore) List(prefix string) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.config.Prefix + prefix}}
+		for {
+			result, err := s.listPage(query)
+			if err != nil {
+				yield("", err)
+				return
+			}
+			for _, object := range result.Contents {
+				if !yield(strings.TrimPrefix(object.Key, s.config.Prefix), nil) {
+					return
+				}
+			}
+			if !result.IsTruncated || result.NextContinuationToken == "" {
+				return
+			}
+			query.Set("continuation-token", result.NextContinuationToken)
+		}
+	}
+}
+
+func (s *S3ObjectStore) listPage(query url.Values) (*listBucketResult, error) {
+	resp, err := s.do(http.MethodGet, "", query, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, s3Error(resp, "list")
+	}
+	var result listBucketResult
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxObjectSize)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse object listing: %w", err)
+	}
+	return &result, nil
+}
+
+// version returns the object version reported in header.
+func (s *S3ObjectStore) version(header http.Header) string {
+	if s.config.Provider == S3ProviderGCS {
+		return header.Get("x-goog-generation")
+	}
+	return header.Get("ETag")
+}
+
+// do sends a signed request for key in the bucket, or for the bucket
+// itself when key is empty.
+func (s *S3ObjectStore) do(method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.config.Bucket
+	u.RawPath = s3EscapePath(u.Path)
+	if key != "" {
+		u.Path += "/" + key
+		u.RawPath += "/" + s3EscapePath(key)
+	}
+	u.RawQuery = s3CanonicalQuery(query)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	s.sign(req, body)
+	resp, err := s.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("object storage request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to req. Host
+// and the x-amz-* headers are signed; condition headers need not be.
+func (s *S3ObjectStore) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256.Sum256(body)
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", hex.EncodeToString(payloadHash[:]))
+	if s.config.SessionToken != "" {
+		req.Header.Set("x-amz-security-token", s.config.SessionToken)
+	}
+
+	signed := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-amz-") {
+			signed[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(signed))
+	for name := range signed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + signed[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), date)
+	for _, part := range []string{s.config.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Escape percent-encodes every byte except the unreserved characters,
+// as Signature Version 4 requires; slashes are encoded unless keepSlash.
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func s3EscapePath(path string) string {
+	return s3Escape(path, true)
+}
+
+// s3CanonicalQuery encodes query sorted by name, as both the request and
+// its signature use it.
+func s3CanonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		for _, value := range query[name] {
+			parts = append(parts, s3Escape(name, false)+"="+s3Escape(value, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3Error describes a failed request, including the service's error code
+// when the response carries one.
+func s3Error(resp *http.Response, op string) error {
+	var body struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body)
+	if body.Code != "" {
+		return fmt.Errorf("object storage %s failed: %s: %s: %s", op, resp.Status, body.Code, body.Message)
+	}
+	return fmt.Errorf("object storage %s failed: %s", op, resp.Status)
+}
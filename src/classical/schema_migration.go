@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// SchemaVersionField is the JSON field in which versioned files record
+// their schema version. Files written before it was introduced have none
+// and are version 0.
+const SchemaVersionField = "schema_version"
+
+// ErrSchemaTooNew is wrapped when a file was written by a newer release
+// than this one. Such files are never rewritten, since migrating them
+// down would lose whatever the newer release added.
+var ErrSchemaTooNew = errors.New("schema version is newer than this release supports")
+
+// Migration upgrades a JSON document by one schema version. Apply edits
+// the document's top-level fields in place; the version field itself is
+// maintained by the Schema.
+type Migration struct {
+	Description string
+	Apply       func(doc map[string]json.RawMessage) error
+}
+
+// Schema is the versioned format of one kind of persistent JSON file, such
+// as configuration files or key usage records. Migrations[i] upgrades
+// version i to i+1, so they always run in order and the current version
+// is len(Migrations).
+type Schema struct {
+	Name       string
+	Migrations []Migration
+}
+
+// Current returns the version this release writes.
+func (s *Schema) Current() int {
+	return len(s.Migrations)
+}
+
+// MigrateOptions controls MigrateFile.
+type MigrateOptions struct {
+	// DryRun reports the migrations that would run without writing
+	// anything
+	DryRun bool
+}
+
+// MigrationReport describes the migration of one file.
+type MigrationReport struct {
+	Path    string   `json:"path"`
+	Schema  string   `json:"schema"`
+	From    int      `json:"from"`
+	To      int      `json:"to"`
+	Applied []string `json:"applied,omitempty"` // descriptions, in order
+	// Backup is the copy of the file taken before it was rewritten; empty
+	// when nothing was written
+	Backup string `json:"backup,omitempty"`
+	DryRun bool   `json:"dry_run,omitempty"`
+}
+
+// Changed reports whether the file is, or in a dry run would be, upgraded.
+func (r *MigrationReport) Changed() bool {
+	return r.From != r.To
+}
+
+// Version returns the schema version recorded in doc.
+func (s *Schema) Version(doc map[string]json.RawMessage) (int, error) {
+	raw, ok := doc[SchemaVersionField]
+	if !ok {
+		return 0, nil
+	}
+	var version int
+	if err := json.Unmarshal(raw, &version); err != nil || version < 0 {
+		return 0, fmt.Errorf("%s has an invalid %s %s", s.Name, SchemaVersionField, raw)
+	}
+	return version, nil
+}
+
+// Upgrade migrates doc in place to the current version. It returns the
+// version doc had and the descriptions of the migrations applied, and
+// refuses documents of a newer version with ErrSchemaTooNew.
+func (s *Schema) Upgrade(doc map[string]json.RawMessage) (int, []string, error) {
+	from, err := s.Version(doc)
+	if err != nil {
+		return 0, nil, err
+	}
+	if from > s.Current() {
+		return from, nil, fmt.Errorf("%w: %s has schema version %d, but this release reads up to version %d; upgrade qzkp to use it",
+			ErrSchemaTooNew, s.Name, from, s.Current())
+	}
+	var applied []string
+	for version := from; version < s.Current(); version++ {
+		m := s.Migrations[version]
+		if m.Apply != nil {
+			if err := m.Apply(doc); err != nil {
+				return from, applied, fmt.Errorf("failed to migrate %s to version %d (%s): %w", s.Name, version+1, m.Description, err)
+			}
+		}
+		doc[SchemaVersionField] = json.RawMessage(fmt.Sprint(version + 1))
+		applied = append(applied, m.Description)
+	}
+	return from, applied, nil
+}
+
+// UpgradeJSON returns data upgraded to the current version, for loaders
+// that accept files of any older version without rewriting them. Data
+// that is already current is returned unchanged.
+func (s *Schema) UpgradeJSON(data []byte) ([]byte, error) {
+	doc, err := decodeSchemaDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.Name, err)
+	}
+	from, _, err := s.Upgrade(doc)
+	if err != nil || from == s.Current() {
+		return data, err
+	}
+	return json.Marshal(doc)
+}
+
+// MigrateFile upgrades the file at path to the current version. The
+// original is first copied to path.v<N>.bak, N being its version, and the
+// upgraded file then replaces it atomically, so an interrupted migration
+// leaves either version in place; its fields are then in alphabetical
+// order. A file of a newer version is refused with ErrSchemaTooNew and
+// left untouched, as are current files.
+func (s *Schema) MigrateFile(path string, opts MigrateOptions) (*MigrationReport, error) {
+	data, err := hostFS.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeSchemaDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s %s: %w", s.Name, path, err)
+	}
+	from, applied, err := s.Upgrade(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	report := &MigrationReport{Path: path, Schema: s.Name, From: from, To: s.Current(), Applied: applied, DryRun: opts.DryRun}
+	if !report.Changed() || opts.DryRun {
+		return report, nil
+	}
+
+	info, err := hostFS.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	upgraded, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	backup := fmt.Sprintf("%s.v%d.bak", path, from)
+	if err := copyFile(path, backup, info.Mode().Perm()); err != nil {
+		if os.IsExist(err) {
+			return nil, fmt.Errorf("backup %s already exists; move it away to migrate %s again", backup, path)
+		}
+		return nil, fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	if err := writeFileAtomic(path, upgraded, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write migrated %s: %w", path, err)
+	}
+	report.Backup = backup
+	return report, nil
+}
+
+// decodeSchemaDocument decodes a JSON object keeping its field values
+// verbatim, so migrations do not disturb the fields they leave alone.
+func decodeSchemaDocument(data []byte) (map[string]json.RawMessage, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, errors.New("document is null")
+	}
+	return doc, nil
+}
@@ -12,6 +12,9 @@ type SignatureScheme struct {
 	Pub  *mldsa87.PublicKey
 	Priv *mldsa87.PrivateKey
 	Ctx  []byte
+	// Deterministic disables hedged signing, so the same key and message
+	// always give the same signature (reproducible test material only)
+	Deterministic bool
 }
 
 // NewSignatureScheme generates a new Dilithium keypair with optional context
@@ -27,10 +30,42 @@ func NewSignatureScheme(ctx []byte) (*SignatureScheme, error) {
 	}, nil
 }
 
+// NewSignatureSchemeFromSeed derives a Dilithium keypair from a 32-byte
+// seed, so the same seed always yields the same key
+func NewSignatureSchemeFromSeed(seed []byte, ctx []byte) (*SignatureScheme, error) {
+	if len(seed) != mldsa87.SeedSize {
+		return nil, fmt.Errorf("seed must be %d bytes, got %d", mldsa87.SeedSize, len(seed))
+	}
+	var s [mldsa87.SeedSize]byte
+	copy(s[:], seed)
+	pub, priv := mldsa87.NewKeyFromSeed(&s)
+	return &SignatureScheme{
+		Pub:  pub,
+		Priv: priv,
+		Ctx:  ctx,
+	}, nil
+}
+
+// NewVerificationScheme builds a verify-only scheme from an encoded public
+// key, e.g. one published by a proving service
+func NewVerificationScheme(publicKey []byte, ctx []byte) (*SignatureScheme, error) {
+	var pub mldsa87.PublicKey
+	if err := pub.UnmarshalBinary(publicKey); err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	return &SignatureScheme{Pub: &pub, Ctx: ctx}, nil
+}
+
+// PublicKeyBytes returns the encoded public key
+func (s *SignatureScheme) PublicKeyBytes() []byte {
+	b, _ := s.Pub.MarshalBinary()
+	return b
+}
+
 func (s *SignatureScheme) Sign(msg []byte) ([]byte, error) {
 	sig := make([]byte, mldsa87.SignatureSize)
-	// SignTo fills `sig`
-	if err := mldsa87.SignTo(s.Priv, msg, nil, true, sig); err != nil {
+	// SignTo fills `sig`; the context must match the one Verify uses
+	if err := mldsa87.SignTo(s.Priv, msg, s.Ctx, !s.Deterministic, sig); err != nil {
 		return nil, err
 	}
 	return sig, nil
@@ -41,3 +76,12 @@ func (s *SignatureScheme) Verify(msg []byte, sig []byte) bool {
 
 	return mldsa87.Verify(s.Pub, msg, s.Ctx, sig)
 }
+
+// signatureSize is the length of every signature in bytes.
+const signatureSize = mldsa87.SignatureSize
+
+// decodeSignatureField decodes a hex-encoded signature with the field
+// codec (see DecodeHexField).
+func decodeSignatureField(s string) ([]byte, error) {
+	return DecodeHexField(s, signatureSize, signatureSize)
+}
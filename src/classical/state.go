@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"math"
+)
+
+// stateHashDomain separates canonical state hashes from every other digest
+// computed over the same numeric encoding.
+const stateHashDomain = "qzkp/state-vector/v1"
+
+// StateVector is a normalized quantum state. It can only be built through
+// NewStateVector, so every StateVector is non-empty, finite and has unit
+// norm; the amplitudes are never exposed for mutation.
+type StateVector struct {
+	amplitudes []complex128
+	encoding   *DataEncoding
+}
+
+// NewStateVector validates amplitudes and returns the normalized state.
+// The input slice is not modified. Zero, NaN and infinite amplitudes that
+// would make normalization meaningless are rejected.
+func NewStateVector(amplitudes []complex128) (*StateVector, error) {
+	if len(amplitudes) == 0 {
+		return nil, errors.New("state vector cannot be empty")
+	}
+	for i, c := range amplitudes {
+		if math.IsNaN(real(c)) || math.IsNaN(imag(c)) || math.IsInf(real(c), 0) || math.IsInf(imag(c), 0) {
+			return nil, fmt.Errorf("amplitude %d is not finite", i)
+		}
+	}
+	norm := Norm(amplitudes)
+	if norm == 0 || math.IsInf(norm, 0) {
+		return nil, errors.New("state vector has no normalizable magnitude")
+	}
+
+	normalized := make([]complex128, len(amplitudes))
+	for i, c := range amplitudes {
+		normalized[i] = c / complex(norm, 0)
+	}
+	return &StateVector{amplitudes: normalized}, nil
+}
+
+// NewStateVectorFromBytes encodes data with BytesToState and records the
+// encoding, with its chunk accounting, on the resulting state.
+func NewStateVectorFromBytes(data []byte, dimension int, selection string) (*StateVector, error) {
+	amplitudes, err := BytesToState(data, dimension)
+	if err != nil {
+		return nil, err
+	}
+	state, err := NewStateVector(amplitudes)
+	if err != nil {
+		return nil, err
+	}
+	chunks := InputChunks(data)
+	return state.WithEncoding(DataEncoding{
+		Encoder:       BytesEncoderSHA256Expand,
+		Version:       BytesEncoderVersion,
+		Dimension:     dimension,
+		Selection:     selection,
+		InputSize:     len(data),
+		Chunks:        chunks,
+		CoveredChunks: min(chunks, dimension),
+	})
+}
+
+// Dimension returns the number of amplitudes.
+func (s *StateVector) Dimension() int {
+	return len(s.amplitudes)
+}
+
+// Amplitude returns the i-th amplitude.
+func (s *StateVector) Amplitude(i int) (complex128, error) {
+	if i < 0 || i >= len(s.amplitudes) {
+		return 0, fmt.Errorf("amplitude index %d outside [0, %d)", i, len(s.amplitudes))
+	}
+	return s.amplitudes[i], nil
+}
+
+// Probability returns the Born-rule probability |a_i|^2 of basis state i.
+func (s *StateVector) Probability(i int) (float64, error) {
+	c, err := s.Amplitude(i)
+	if err != nil {
+		return 0, err
+	}
+	return real(c)*real(c) + imag(c)*imag(c), nil
+}
+
+// Amplitudes returns a copy of the normalized amplitudes.
+func (s *StateVector) Amplitudes() []complex128 {
+	return append([]complex128(nil), s.amplitudes...)
+}
+
+// Encoding returns a copy of the byte encoding the state was derived from,
+// or nil when it was built from raw amplitudes.
+func (s *StateVector) Encoding() *DataEncoding {
+	if s.encoding == nil {
+		return nil
+	}
+	encoding := *s.encoding
+	return &encoding
+}
+
+// WithEncoding returns a copy of the state that records encoding. The
+// encoding must be known and match the state's dimension.
+func (s *StateVector) WithEncoding(encoding DataEncoding) (*StateVector, error) {
+	if err := encoding.validate(s.Dimension()); err != nil {
+		return nil, err
+	}
+	return &StateVector{amplitudes: s.amplitudes, encoding: &encoding}, nil
+}
+
+// writeAmplitudes writes the real and imaginary part of every amplitude to
+// hasher in the given numeric encoding. This is the encoding state
+// commitments are computed over.
+func (s *StateVector) writeAmplitudes(hasher hash.Hash, encoding NumericEncoding) error {
+	for _, c := range s.amplitudes {
+		if err := encoding.writeNumbers(hasher, real(c), imag(c)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeAmplitudes returns the bytes writeAmplitudes writes.
+func (s *StateVector) encodeAmplitudes(encoding NumericEncoding) ([]byte, error) {
+	encoded := make([]byte, 0, 16*len(s.amplitudes))
+	for _, c := range s.amplitudes {
+		for _, x := range []float64{real(c), imag(c)} {
+			b, err := encoding.Encode(x)
+			if err != nil {
+				return nil, err
+			}
+			encoded = append(encoded, b...)
+		}
+	}
+	return encoded, nil
+}
+
+// CanonicalHash returns the SHA-256 digest of the state under encoding:
+// a domain separator, the big-endian dimension, then the amplitudes as
+// written into state commitments. Two states hash equally exactly when
+// they agree at the encoding's precision.
+func (s *StateVector) CanonicalHash(encoding NumericEncoding) ([]byte, error) {
+	if err := encoding.Validate(); err != nil {
+		return nil, err
+	}
+	hasher := sha256.New()
+	hasher.Write([]byte(stateHashDomain))
+	var dimension [8]byte
+	binary.BigEndian.PutUint64(dimension[:], uint64(len(s.amplitudes)))
+	hasher.Write(dimension[:])
+	if err := s.writeAmplitudes(hasher, encoding); err != nil {
+		return nil, err
+	}
+	return hasher.Sum(nil), nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+)
+
+// runLoadTest drives one or more qzkpd deployments with the scenarios of a
+// file, or with one scenario built from flags, and reports latency
+// percentiles and error rates per scenario.
+func runLoadTest(args []string) {
+	fs := flag.NewFlagSet("loadtest", flag.ExitOnError)
+	scenarioPath := fs.String("scenarios", "", "JSON array of LoadScenario; overrides the flags below")
+	urls := fs.String("url", "http://localhost:8080", "comma-separated qzkpd URLs, one per soundness level, as url[=weight]")
+	sizes := fs.String("sizes", "1024", "comma-separated proof input sizes in bytes, as bytes[=weight]")
+	verifyRatio := fs.Float64("verify-ratio", 1, "verification requests per proof request")
+	rate := fs.Float64("rate", 10, "requests per second")
+	duration := fs.String("duration", "30s", "test duration")
+	concurrency := fs.Int("concurrency", 16, "requests in flight before arrivals are dropped")
+	asJSON := fs.Bool("json", false, "print the reports as JSON")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . loadtest [-scenarios file.json | -url u1,u2=3 -sizes 1024=4,65536 -rate n -duration d] [-json]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var scenarios []LoadScenario
+	if *scenarioPath != "" {
+		var err error
+		if scenarios, err = ReadLoadScenarios(*scenarioPath); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		scenario := LoadScenario{Name: "flags", VerifyRatio: *verifyRatio, Rate: *rate, Duration: *duration, Concurrency: *concurrency}
+		for _, entry := range strings.Split(*urls, ",") {
+			url, weight := splitWeight(entry)
+			scenario.Targets = append(scenario.Targets, LoadTarget{URL: url, Weight: weight})
+		}
+		for _, entry := range strings.Split(*sizes, ",") {
+			size, weight := splitWeight(entry)
+			bytes, err := strconv.Atoi(size)
+			if err != nil {
+				log.Fatalf("Invalid size %q", entry)
+			}
+			scenario.ProofSizes = append(scenario.ProofSizes, LoadProofSize{Bytes: bytes, Weight: weight})
+		}
+		scenarios = []LoadScenario{scenario}
+	}
+
+	// Interrupting ends the current scenario early and still reports it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	var reports []*LoadTestReport
+	for _, scenario := range scenarios {
+		report, err := RunLoadScenario(ctx, scenario)
+		if err != nil {
+			log.Fatalf("Scenario %s failed: %v", scenario.Name, err)
+		}
+		reports = append(reports, report)
+		if !*asJSON {
+			if err := report.WriteMarkdown(os.Stdout); err != nil {
+				log.Fatal("Failed to write report:", err)
+			}
+			fmt.Println()
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if *asJSON {
+		out, _ := json.MarshalIndent(reports, "", "  ")
+		fmt.Println(string(out))
+	}
+}
+
+// splitWeight splits "value=weight"; the weight is 1 if absent.
+func splitWeight(entry string) (string, int) {
+	value, weight, found := strings.Cut(strings.TrimSpace(entry), "=")
+	if !found {
+		return value, 1
+	}
+	w, err := strconv.Atoi(weight)
+	if err != nil || w <= 0 {
+		log.Fatalf("Invalid weight in %q", entry)
+	}
+	return value, w
+}
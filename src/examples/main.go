@@ -1,11 +1,17 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"math"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -19,17 +25,36 @@ func main() {
 
 	switch command {
 	case "examples":
-		RunAllExamples()
+		fmt.Println("The examples are checked Go examples in tests/unit/example_test.go:")
+		fmt.Println("  go test -v -run Example ./tests/unit")
 	case "demo":
 		runQuickDemo()
 	case "security":
 		runSecurityDemo()
 	case "benchmark":
-		Example5_PerformanceBenchmark()
+		runBenchmark()
 	case "security-levels":
 		runSecurityLevelsDemo()
 	case "ultra-secure":
 		runUltraSecureDemo()
+	case "qzkpd":
+		runDaemon(os.Args[2:])
+	case "doctor":
+		runDoctor(os.Args[2:])
+	case "corpus":
+		runCorpus(os.Args[2:])
+	case "states":
+		runStates(os.Args[2:])
+	case "paperbench":
+		runPaperBench(os.Args[2:])
+	case "testkit":
+		runTestKit(os.Args[2:])
+	case "reproduce":
+		runReproduce(os.Args[2:])
+	case "loadtest":
+		runLoadTest(os.Args[2:])
+	case "migrate":
+		runMigrate(os.Args[2:])
 	case "help":
 		printUsage()
 	default:
@@ -45,17 +70,25 @@ func printUsage() {
 	fmt.Println("Usage: go run . <command>")
 	fmt.Println()
 	fmt.Println("Commands:")
-	fmt.Println("  examples        - Run all comprehensive examples")
+	fmt.Println("  examples        - Show where the checked examples live")
 	fmt.Println("  demo            - Quick demonstration of secure ZKP")
 	fmt.Println("  security        - Security analysis and comparison")
 	fmt.Println("  security-levels - Compare different security levels")
 	fmt.Println("  ultra-secure    - Demonstrate 256-bit ultra-secure ZKP")
 	fmt.Println("  benchmark       - Performance benchmarking")
+	fmt.Println("  qzkpd <config>  - Run the prover daemon (SIGHUP reloads, SIGTERM drains)")
+	fmt.Println("  doctor <proof>  - Diagnose a corrupted or unreadable proof file (- for stdin)")
+	fmt.Println("  corpus <dir> [seed] - Generate a labeled proof corpus for third-party verifiers")
+	fmt.Println("  states <subcommand> - Curate the quantum state cache (list/verify/import/prune/quota)")
+	fmt.Println("  paperbench [flags] - Reproduce the paper's tables and flag deviations from its claims")
+	fmt.Println("  testkit [flags] - Serve throwaway-key proofs with chosen defects for downstream CI")
+	fmt.Println("  reproduce --manifest m.json - Regenerate a published proof and report where it diverges")
+	fmt.Println("  loadtest [flags] - Drive qzkpd deployments with a traffic scenario and report latency and errors")
+	fmt.Println("  migrate [flags] - Upgrade configuration and data files to this release, with backups (--dry-run to preview)")
 	fmt.Println("  help            - Show this help message")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  go run . demo")
-	fmt.Println("  go run . examples")
 	fmt.Println("  go run . security")
 	fmt.Println()
 	fmt.Println("🛡️  SECURITY NOTICE:")
@@ -139,7 +172,7 @@ func runSecurityDemo() {
 
 	// Test vector with easily identifiable components
 	testVector := []complex128{
-		complex(0.9, 0.1),  // Distinctive values
+		complex(0.9, 0.1), // Distinctive values
 		complex(0.2, 0.8),
 		complex(0.7, 0.3),
 		complex(0.4, 0.6),
@@ -396,12 +429,14 @@ protected with the strongest available cryptographic measures.
 	fmt.Println("🛡️ Generating 256-bit ultra-secure proof...")
 	fmt.Println("   (This may take a moment due to the high security level)")
 
+	sq.Progress = progressBar(os.Stdout)
 	start := time.Now()
 	proof, err := sq.SecureProveFromBytes(ultraSecretData, "ultra-secret-doc", key)
 	if err != nil {
 		log.Fatal("Ultra-secure proof generation failed:", err)
 	}
 	proofTime := time.Since(start)
+	sq.Progress = nil
 
 	fmt.Printf("✅ Ultra-secure proof generated successfully!\n")
 	fmt.Printf("📊 Proof generation time: %v\n", proofTime)
@@ -484,11 +519,169 @@ protected with the strongest available cryptographic measures.
 	fmt.Println("✅ Intellectual property protection")
 	fmt.Println()
 
+	// Archival composition beyond a single round's 256-bit ceiling
+	fmt.Println("📦 Archival Composition (512-bit):")
+	fmt.Println("==================================")
+	archivalState, err := BytesToState(ultraSecretData, 16)
+	if err != nil {
+		log.Fatal("Failed to encode document:", err)
+	}
+	composed, err := sq.SecureProveComposed(archivalState, "ultra-secret-doc", key, 512)
+	if err != nil {
+		log.Fatal("Composed proof generation failed:", err)
+	}
+	fmt.Printf("🔁 Rounds: %d\n", len(composed.Rounds))
+	fmt.Printf("🛡️ Effective soundness: %d-bit\n", composed.EffectiveSoundness)
+	fmt.Printf("📊 Proof size: %.1f KB\n", float64(len(mustMarshalDemo(composed)))/1024)
+	if sq.VerifyComposedProof(composed, key) {
+		fmt.Println("✅ Composed proof verification SUCCESSFUL!")
+	} else {
+		fmt.Println("❌ Composed proof verification FAILED!")
+	}
+	fmt.Println()
+
 	fmt.Println("🎉 Ultra-secure demonstration completed!")
 	fmt.Println("💡 This represents the pinnacle of quantum zero-knowledge security!")
 }
 
 // Helper functions
+func runDaemon(args []string) {
+	if len(args) != 1 {
+		fmt.Println("Usage: go run . qzkpd <config.json>")
+		os.Exit(2)
+	}
+
+	d, err := NewDaemon(args[0])
+	if err != nil {
+		log.Fatal("Failed to start daemon:", err)
+	}
+	if err := d.Run(); err != nil && err != http.ErrServerClosed {
+		log.Fatal("Daemon stopped:", err)
+	}
+}
+
+func runDoctor(args []string) {
+	if len(args) != 1 {
+		fmt.Println("Usage: go run . doctor <proof-file | ->")
+		os.Exit(2)
+	}
+
+	var raw []byte
+	var err error
+	if args[0] == "-" {
+		raw, err = io.ReadAll(os.Stdin)
+	} else {
+		raw, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		log.Fatal("Failed to read proof:", err)
+	}
+
+	d := DiagnoseProof(raw)
+	fmt.Println("🩺 Proof Diagnosis")
+	fmt.Println("=================")
+	fmt.Printf("Input size: %d bytes\n", len(raw))
+	if d.Codec == "" {
+		fmt.Println("Codec:      unrecognized")
+	} else {
+		fmt.Printf("Codec:      %s\n", d.Codec)
+		fmt.Printf("Version:    %d\n", d.Version)
+		fmt.Printf("Responses:  %d recovered\n", d.ResponsesRecovered)
+	}
+	if d.Truncated {
+		fmt.Printf("Truncated:  at byte %d in %q\n", d.TruncatedAt, d.TruncatedIn)
+	}
+
+	if len(d.Sections) > 0 {
+		fmt.Println("\nSections:")
+		for _, s := range d.Sections {
+			switch {
+			case s.Parsed:
+				fmt.Printf("  ✅ %s\n", s.Name)
+			case s.Error != "":
+				fmt.Printf("  ❌ %s: %s\n", s.Name, s.Error)
+			case s.Required:
+				fmt.Printf("  ⚠️  %s: missing\n", s.Name)
+			}
+		}
+	}
+	if len(d.Hashes) > 0 {
+		fmt.Println("\nHashes:")
+		for _, h := range d.Hashes {
+			mark := "✅"
+			if !h.OK {
+				mark = "❌"
+			}
+			fmt.Printf("  %s %s (%s)\n", mark, h.Name, h.Detail)
+		}
+	}
+
+	fmt.Println()
+	if d.Healthy() {
+		fmt.Println("✅ No structural problems found; verify the signature with the prover's key")
+		return
+	}
+	fmt.Println("Likely causes:")
+	for _, cause := range d.Causes {
+		fmt.Printf("  • %s\n", cause)
+	}
+	os.Exit(1)
+}
+
+// defaultCorpusSeed makes the corpus reproducible when no seed is given.
+var defaultCorpusSeed = sha256.Sum256([]byte("qzkp reference corpus"))
+
+func runCorpus(args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		fmt.Println("Usage: go run . corpus <output-dir> [64-hex-digit seed]")
+		os.Exit(2)
+	}
+	seed := defaultCorpusSeed[:]
+	if len(args) == 2 {
+		var err error
+		if seed, err = hex.DecodeString(args[1]); err != nil {
+			log.Fatal("Invalid seed:", err)
+		}
+	}
+
+	fmt.Println("📚 Generating proof corpus")
+	fmt.Println("==========================")
+	start := time.Now()
+	manifest, err := GenerateCorpus(args[0], DefaultCorpusOptions(seed))
+	if err != nil {
+		log.Fatal("Corpus generation failed:", err)
+	}
+
+	valid := 0
+	for _, entry := range manifest.Entries {
+		if entry.Valid {
+			valid++
+		}
+	}
+	fmt.Printf("Seed:     %s\n", manifest.Seed)
+	fmt.Printf("Proofs:   %d (%d valid, %d tampered)\n", len(manifest.Entries), valid, len(manifest.Entries)-valid)
+	fmt.Printf("Manifest: %s\n", filepath.Join(args[0], CorpusManifestFile))
+	fmt.Printf("Time:     %v\n", time.Since(start))
+}
+
+// progressBar renders proof generation progress as a bar on a single
+// terminal line, ending the line once signing completes.
+func progressBar(w io.Writer) ProgressFunc {
+	const width = 30
+	return func(stage string, done, total int) {
+		if total <= 0 || done < 0 || done > total {
+			return
+		}
+		filled := done * width / total
+		fmt.Fprintf(w, "\r   %-10s [%s%s] %-9s", stage,
+			strings.Repeat("#", filled), strings.Repeat(" ", width-filled),
+			fmt.Sprintf("%d/%d", done, total))
+		if stage == ProgressStageSigning && done == total {
+			fmt.Fprintln(w)
+		}
+	}
+}
+
 func mustMarshalDemo(v interface{}) []byte {
 	data, err := json.Marshal(v)
 	if err != nil {
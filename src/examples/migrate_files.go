@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+// runMigrate upgrades a configuration file and the data directory to the
+// file schemas of this release.
+func runMigrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	configPath := fs.String("config", "", "qzkpd or prover service configuration file")
+	dataDir := fs.String("data-dir", "", "data directory (default: the config's data_dir, else the default data directory)")
+	dryRun := fs.Bool("dry-run", false, "report the migrations without writing anything")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . migrate [--config c.json] [--data-dir dir] [--dry-run]")
+		fmt.Println("Every rewritten file is first backed up beside itself as <file>.v<N>.bak.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	opts := MigrateOptions{DryRun: *dryRun}
+
+	var reports []*MigrationReport
+	if *configPath != "" {
+		report, err := ConfigSchema.MigrateFile(*configPath, opts)
+		if err != nil {
+			failMigration(err)
+		}
+		reports = append(reports, report)
+		if *dataDir == "" {
+			cfg, err := LoadDaemonConfig(*configPath)
+			if err != nil {
+				failMigration(err)
+			}
+			*dataDir = cfg.DataDir
+		}
+	}
+
+	dirs := NewDataDirs(*dataDir)
+	if *dataDir == "" {
+		var err error
+		if dirs, err = DefaultDataDirs(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	dirReports, err := MigrateDataDirs(dirs, opts)
+	reports = append(reports, dirReports...)
+	printMigrationReports(reports, *dryRun)
+	if err != nil {
+		failMigration(err)
+	}
+}
+
+// printMigrationReports prints a line per migrated file and a summary.
+func printMigrationReports(reports []*MigrationReport, dryRun bool) {
+	upgraded := 0
+	for _, r := range reports {
+		if !r.Changed() {
+			continue
+		}
+		upgraded++
+		verb := "Upgraded"
+		if dryRun {
+			verb = "Would upgrade"
+		}
+		fmt.Printf("%s %s (%s) from version %d to %d\n", verb, r.Path, r.Schema, r.From, r.To)
+		for _, step := range r.Applied {
+			fmt.Printf("   - %s\n", step)
+		}
+		if r.Backup != "" {
+			fmt.Printf("   backup: %s\n", r.Backup)
+		}
+	}
+	fmt.Printf("%d of %d files need no migration\n", len(reports)-upgraded, len(reports))
+}
+
+// failMigration reports a migration error, explaining files of a newer release.
+func failMigration(err error) {
+	if errors.Is(err, ErrSchemaTooNew) {
+		fmt.Fprintln(os.Stderr, "Refusing to downgrade:", err)
+		fmt.Fprintln(os.Stderr, "The file was written by a newer qzkp release. Run that release, or restore the backup taken when it upgraded the file.")
+		os.Exit(1)
+	}
+	log.Fatal("Migration failed: ", err)
+}
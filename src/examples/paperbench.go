@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// runPaperBench reproduces the paper's tables, writing a Markdown report
+// to stdout and, with -out, one CSV file per table.
+func runPaperBench(args []string) {
+	fs := flag.NewFlagSet("paperbench", flag.ExitOnError)
+	out := fs.String("out", "", "directory for CSV tables (machine.csv, leakage.csv, ...)")
+	vectors := fs.Int("vectors", 1000, "test vectors in the leakage experiment")
+	runs := fs.Int("runs", 10, "proofs averaged per soundness level")
+	strict := fs.Bool("strict", false, "exit with status 1 when a result deviates from the published claims")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . paperbench [-out dir] [-vectors n] [-runs n] [-strict]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	opts := DefaultPaperBenchOptions()
+	opts.LeakageVectors, opts.Runs = *vectors, *runs
+	report, err := RunPaperBench(opts)
+	if err != nil {
+		log.Fatal("Benchmark failed:", err)
+	}
+	if err := report.WriteMarkdown(os.Stdout); err != nil {
+		log.Fatal("Failed to write report:", err)
+	}
+
+	if *out != "" {
+		if err := os.MkdirAll(*out, 0755); err != nil {
+			log.Fatal("Failed to create output directory:", err)
+		}
+		tables := append([]PaperTable{report.Machine.Table()}, report.Tables...)
+		for i := range tables {
+			path := filepath.Join(*out, tables[i].Name+".csv")
+			f, err := os.Create(path)
+			if err != nil {
+				log.Fatal("Failed to create table:", err)
+			}
+			err = tables[i].WriteCSV(f)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				log.Fatalf("Failed to write %s: %v", path, err)
+			}
+		}
+	}
+
+	if *strict && len(report.Deviations) > 0 {
+		os.Exit(1)
+	}
+}
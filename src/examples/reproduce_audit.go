@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// runReproduce regenerates a published proof from its reproduction manifest
+// and reports the first stage that diverges from the stored artifact.
+func runReproduce(args []string) {
+	fs := flag.NewFlagSet("reproduce", flag.ExitOnError)
+	manifestPath := fs.String("manifest", "", "reproduction manifest (JSON)")
+	keyHex := fs.String("key", "", "hex proof key")
+	seedHex := fs.String("signing-seed", "", "hex ML-DSA-87 signing seed")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . reproduce --manifest m.json --key hex --signing-seed hex [--json]")
+		fmt.Println("The manifest's data and artifact paths are relative to the manifest.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *manifestPath == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	raw, err := os.ReadFile(*manifestPath)
+	if err != nil {
+		log.Fatal("Failed to read manifest:", err)
+	}
+	var manifest ReproductionManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		log.Fatal("Invalid manifest:", err)
+	}
+	if manifest.Data == "" || manifest.Artifact == "" {
+		log.Fatal("Manifest must name its data and artifact files")
+	}
+	dir := filepath.Dir(*manifestPath)
+	var secrets ReproductionSecrets
+	if secrets.Data, err = os.ReadFile(filepath.Join(dir, manifest.Data)); err != nil {
+		log.Fatal("Failed to read data:", err)
+	}
+	artifact, err := os.ReadFile(filepath.Join(dir, manifest.Artifact))
+	if err != nil {
+		log.Fatal("Failed to read artifact:", err)
+	}
+	if secrets.Key, err = hex.DecodeString(*keyHex); err != nil {
+		log.Fatal("Invalid key:", err)
+	}
+	if secrets.SigningSeed, err = hex.DecodeString(*seedHex); err != nil {
+		log.Fatal("Invalid signing seed:", err)
+	}
+
+	report := ReproduceProof(&manifest, secrets, artifact)
+	if *asJSON {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		for _, stage := range report.Stages {
+			mark := "✅"
+			if !stage.OK {
+				mark = "❌"
+			}
+			fmt.Printf("%s %-13s %s\n", mark, stage.Stage, stage.Detail)
+		}
+		if report.Reproduced {
+			fmt.Printf("Reproduced %s byte for byte\n", manifest.Artifact)
+		} else {
+			fmt.Printf("Diverged at stage %q\n", report.Diverged)
+		}
+	}
+	if !report.Reproduced {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func printStatesUsage() {
+	fmt.Println("Usage: go run . states <subcommand> [flags]")
+	fmt.Println()
+	fmt.Println("Subcommands:")
+	fmt.Println("  list   [-qubits n] [-backend name] [-json]  - List cached states with their properties")
+	fmt.Println("  verify                                     - Check normalization and fidelity claims")
+	fmt.Println("  import [-name name] <file.qasm | file.json> - Import states from OpenQASM 2.0 or JSON")
+	fmt.Println("  prune  [-older-than 720h] [-backend name]   - Remove states by age and/or backend")
+	fmt.Println("  quota  [-quota 10m]                        - Show cache statistics and quantum time usage")
+	fmt.Println()
+	fmt.Printf("The cache lives in the qzkp cache directory; set %s to use another.\n", CacheDirEnv)
+}
+
+// runStates curates the quantum state cache.
+func runStates(args []string) {
+	if len(args) == 0 {
+		printStatesUsage()
+		os.Exit(2)
+	}
+	cache, err := DefaultQuantumStateCache()
+	if err != nil {
+		log.Fatal("Failed to open state cache:", err)
+	}
+
+	switch args[0] {
+	case "list":
+		runStatesList(cache, args[1:])
+	case "verify":
+		runStatesVerify(cache, args[1:])
+	case "import":
+		runStatesImport(cache, args[1:])
+	case "prune":
+		runStatesPrune(cache, args[1:])
+	case "quota":
+		runStatesQuota(cache, args[1:])
+	default:
+		fmt.Printf("Unknown states subcommand: %s\n", args[0])
+		printStatesUsage()
+		os.Exit(2)
+	}
+}
+
+// parseStatesFlags parses fs, exiting on errors or unexpected arguments.
+func parseStatesFlags(fs *flag.FlagSet, args []string, positional int) []string {
+	if err := fs.Parse(args); err != nil {
+		os.Exit(2)
+	}
+	if fs.NArg() != positional {
+		fs.Usage()
+		os.Exit(2)
+	}
+	return fs.Args()
+}
+
+func runStatesList(cache *QuantumStateCache, args []string) {
+	fs := flag.NewFlagSet("states list", flag.ExitOnError)
+	qubits := fs.Int("qubits", 0, "only states with this many qubits")
+	backend := fs.String("backend", "", "only states from this backend")
+	asJSON := fs.Bool("json", false, "print the states as JSON")
+	parseStatesFlags(fs, args, 0)
+
+	var states []CachedQuantumState
+	for state, err := range cache.IterStates(*qubits) {
+		if err != nil {
+			log.Fatal("Failed to read state cache:", err)
+		}
+		if *backend == "" || state.Backend == *backend {
+			states = append(states, state)
+		}
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(states, "", "  ")
+		if err != nil {
+			log.Fatal("JSON marshaling failed:", err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	if len(states) == 0 {
+		fmt.Println("No cached states")
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tQUBITS\tBACKEND\tFIDELITY\tCOHERENCE\tENTANGLEMENT\tTIMESTAMP")
+	for _, s := range states {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%.4f\t%.4f\t%.4f\t%s\n", s.Name, s.Qubits, s.Backend,
+			s.Fidelity, s.Coherence, s.Entanglement, s.Timestamp.Format(time.RFC3339))
+	}
+	tw.Flush()
+}
+
+func runStatesVerify(cache *QuantumStateCache, args []string) {
+	fs := flag.NewFlagSet("states verify", flag.ExitOnError)
+	parseStatesFlags(fs, args, 0)
+
+	checks, err := cache.VerifyStates()
+	if err != nil {
+		log.Fatal("Failed to read state cache:", err)
+	}
+	failed := 0
+	for _, check := range checks {
+		if check.OK() {
+			fmt.Printf("✅ %s\n", check.Name)
+			continue
+		}
+		failed++
+		fmt.Printf("❌ %s\n", check.Name)
+		for _, problem := range check.Problems {
+			fmt.Printf("     • %s\n", problem)
+		}
+	}
+	fmt.Printf("\n%d states checked, %d failed\n", len(checks), failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func runStatesImport(cache *QuantumStateCache, args []string) {
+	fs := flag.NewFlagSet("states import", flag.ExitOnError)
+	name := fs.String("name", "", "name for a QASM state (default: the file name)")
+	path := parseStatesFlags(fs, args, 1)[0]
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal("Failed to read states:", err)
+	}
+	var states []CachedQuantumState
+	if strings.EqualFold(filepath.Ext(path), ".qasm") {
+		if *name == "" {
+			*name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		state, err := StateFromQASM(*name, string(data))
+		if err != nil {
+			log.Fatal("Failed to import QASM:", err)
+		}
+		states = append(states, state)
+	} else {
+		if *name != "" {
+			log.Fatal("-name only applies to QASM imports")
+		}
+		if states, err = ImportStatesJSON(data); err != nil {
+			log.Fatal("Failed to import JSON:", err)
+		}
+	}
+
+	if err := cache.ImportStates(states, time.Now()); err != nil {
+		log.Fatal("Import failed:", err)
+	}
+	for _, s := range states {
+		fmt.Printf("📥 %s (%d qubits, %s)\n", s.Name, s.Qubits, s.Backend)
+	}
+}
+
+func runStatesPrune(cache *QuantumStateCache, args []string) {
+	fs := flag.NewFlagSet("states prune", flag.ExitOnError)
+	olderThan := fs.Duration("older-than", 0, "remove states older than this age")
+	backend := fs.String("backend", "", "remove states from this backend")
+	parseStatesFlags(fs, args, 0)
+
+	criteria := PruneCriteria{Backend: *backend}
+	if *olderThan > 0 {
+		criteria.OlderThan = time.Now().Add(-*olderThan)
+	}
+	pruned, err := cache.PruneStates(criteria)
+	if err != nil {
+		log.Fatal("Prune failed:", err)
+	}
+	for _, name := range pruned {
+		fmt.Printf("🗑️  %s\n", name)
+	}
+	fmt.Printf("%d states pruned\n", len(pruned))
+}
+
+func runStatesQuota(cache *QuantumStateCache, args []string) {
+	fs := flag.NewFlagSet("states quota", flag.ExitOnError)
+	quota := fs.Duration("quota", DefaultQuantumTimeQuota, "monthly quantum time allocation")
+	parseStatesFlags(fs, args, 0)
+
+	if err := cache.WriteSummary(os.Stdout, *quota); err != nil {
+		log.Fatal("Failed to read state cache:", err)
+	}
+}
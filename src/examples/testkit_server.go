@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// runTestKit serves a TestKit for the CI of projects that handle proofs.
+func runTestKit(args []string) {
+	fs := flag.NewFlagSet("testkit", flag.ExitOnError)
+	listen := fs.String("listen", "127.0.0.1:8089", "address to listen on")
+	seedHex := fs.String("seed", "", "32-byte hex seed pinning the kit's keys (random if empty)")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . testkit [-listen addr] [-seed hex]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var seed []byte
+	if *seedHex != "" {
+		var err error
+		if seed, err = hex.DecodeString(*seedHex); err != nil {
+			log.Fatal("Invalid seed:", err)
+		}
+	}
+	kit, err := NewTestKit(seed)
+	if err != nil {
+		log.Fatal("Failed to create test kit:", err)
+	}
+	info := kit.Info()
+	fmt.Printf("qzkp-testkit listening on %s (%d defects)\n", *listen, len(info.Defects))
+	fmt.Printf("Public key: %s...\n", info.PublicKey[:32])
+	log.Fatal(http.ListenAndServe(*listen, kit.Handler()))
+}
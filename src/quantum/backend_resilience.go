@@ -0,0 +1,307 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Result provenance recorded in ExecutionResult.Provenance and cached
+// states, so consumers can tell hardware data from simulated data.
+const (
+	ProvenanceSimulator = "simulator"          // local simulator by request
+	ProvenanceRemote    = "remote"             // the remote backend answered
+	ProvenanceFallback  = "fallback_simulator" // local simulator after a remote failure
+)
+
+// ErrCircuitOpen is returned without contacting the backend while its
+// circuit breaker is open.
+var ErrCircuitOpen = errors.New("backend circuit breaker is open")
+
+// BackendError is a failed remote backend call classified as transient
+// (worth retrying: timeouts, rate limits, 5xx) or permanent (bad
+// credentials, invalid circuits).
+type BackendError struct {
+	Op         string
+	StatusCode int // HTTP status, 0 when the request never completed
+	Transient  bool
+	Err        error
+}
+
+func (e *BackendError) Error() string {
+	kind := "permanent"
+	if e.Transient {
+		kind = "transient"
+	}
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s: %s backend error (status %d): %v", e.Op, kind, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("%s: %s backend error: %v", e.Op, kind, e.Err)
+}
+
+func (e *BackendError) Unwrap() error { return e.Err }
+
+// TransientError wraps err as a retryable backend failure.
+func TransientError(op string, err error) error {
+	return &BackendError{Op: op, Transient: true, Err: err}
+}
+
+// PermanentError wraps err as a backend failure that retrying cannot fix.
+func PermanentError(op string, err error) error {
+	return &BackendError{Op: op, Err: err}
+}
+
+// HTTPStatusError classifies a non-success HTTP response: request
+// timeouts, rate limiting and server errors are transient, every other
+// status is permanent.
+func HTTPStatusError(op string, status int, body string) error {
+	transient := status == http.StatusRequestTimeout ||
+		status == http.StatusTooEarly ||
+		status == http.StatusTooManyRequests ||
+		status >= 500
+	return &BackendError{
+		Op:         op,
+		StatusCode: status,
+		Transient:  transient,
+		Err:        errors.New(body),
+	}
+}
+
+// IsTransient reports whether err is worth retrying. Unclassified errors
+// are treated as transient since network failures rarely are typed; an
+// open circuit is not, as retrying would only wait out the cooldown.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	var be *BackendError
+	if errors.As(err, &be) {
+		return be.Transient
+	}
+	return true
+}
+
+// RetryPolicy is exponential backoff with jitter.
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts including the first
+	BaseDelay   time.Duration // delay before the first retry
+	MaxDelay    time.Duration // cap on any single delay
+	Jitter      float64       // fraction of each delay randomized away, in [0, 1]
+}
+
+// DefaultRetryPolicy suits queue-based services such as IBM Quantum, where
+// outages last seconds to minutes.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.5,
+	}
+}
+
+// Delay returns the wait before retry number retry (0 for the first
+// retry), drawing jitter from rng.
+func (p RetryPolicy) Delay(retry int, rng *rand.Rand) time.Duration {
+	d := float64(p.BaseDelay) * math.Pow(2, float64(retry))
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		d -= d * math.Min(p.Jitter, 1) * rng.Float64()
+	}
+	return time.Duration(d)
+}
+
+// CircuitBreaker stops calls to a backend after Threshold consecutive
+// transient failures. Once Cooldown has passed a single trial call is let
+// through; its success closes the breaker, its failure reopens it.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+	Now       func() time.Time // defaults to time.Now
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// NewCircuitBreaker creates a closed breaker.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{Threshold: threshold, Cooldown: cooldown}
+}
+
+func (b *CircuitBreaker) now() time.Time {
+	if b.Now != nil {
+		return b.Now()
+	}
+	return time.Now()
+}
+
+// Open reports whether calls are currently being rejected.
+func (b *CircuitBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.Threshold && (b.trial || b.now().Sub(b.openedAt) < b.Cooldown)
+}
+
+// Allow returns ErrCircuitOpen while the breaker is open and admits the
+// trial call once the cooldown has passed.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.Threshold {
+		return nil
+	}
+	if b.trial || b.now().Sub(b.openedAt) < b.Cooldown {
+		return ErrCircuitOpen
+	}
+	b.trial = true
+	return nil
+}
+
+// Record updates the breaker with the outcome of an admitted call.
+// Permanent errors say nothing about availability and are ignored.
+func (b *CircuitBreaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil && !IsTransient(err) {
+		b.trial = false
+		return
+	}
+	if err == nil {
+		b.failures = 0
+		b.trial = false
+		return
+	}
+	b.failures++
+	if b.failures >= b.Threshold {
+		b.openedAt = b.now()
+		b.trial = false
+	}
+}
+
+// RemoteExecutor runs a circuit on a remote backend. It should classify
+// its failures with TransientError, PermanentError or HTTPStatusError.
+type RemoteExecutor func(circuit *QuantumCircuit, shots int) (*ExecutionResult, error)
+
+// ResilientBackend wraps a remote backend with retries, a circuit breaker
+// and fallback to the local simulator.
+type ResilientBackend struct {
+	Name    string
+	Remote  RemoteExecutor
+	Retry   RetryPolicy
+	Breaker *CircuitBreaker
+	// Fallback simulates circuits when the remote backend is unavailable;
+	// nil disables fallback
+	Fallback *QuantumZKP
+	// Sleep waits between retries; it defaults to time.Sleep
+	Sleep func(time.Duration)
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewResilientBackend wraps remote with the default retry policy, a breaker
+// that opens after 5 consecutive transient failures for one minute, and
+// fallback to fallback's simulator.
+func NewResilientBackend(name string, remote RemoteExecutor, fallback *QuantumZKP) *ResilientBackend {
+	return &ResilientBackend{
+		Name:     name,
+		Remote:   remote,
+		Retry:    DefaultRetryPolicy(),
+		Breaker:  NewCircuitBreaker(5, time.Minute),
+		Fallback: fallback,
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Do runs call under the retry policy and circuit breaker. Transient
+// failures are retried; permanent failures and an open circuit return
+// immediately.
+func (b *ResilientBackend) Do(op string, call func() error) error {
+	attempts := b.Retry.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			b.sleep(b.retryDelay(attempt - 1))
+		}
+		if b.Breaker != nil {
+			if err := b.Breaker.Allow(); err != nil {
+				return fmt.Errorf("%s on %s: %w", op, b.Name, err)
+			}
+		}
+		err = call()
+		if b.Breaker != nil {
+			b.Breaker.Record(err)
+		}
+		if err == nil || !IsTransient(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("%s on %s failed after %d attempts: %w", op, b.Name, attempts, err)
+}
+
+// Execute runs circuit on the remote backend. When it stays unavailable
+// (transient failures exhaust the retries or the circuit is open) the
+// circuit is simulated locally and the result marked with
+// ProvenanceFallback. Permanent errors are returned, since the simulator
+// would hide a misconfiguration.
+func (b *ResilientBackend) Execute(circuit *QuantumCircuit, shots int) (*ExecutionResult, error) {
+	if b.Remote == nil {
+		return nil, fmt.Errorf("backend %s has no remote executor", b.Name)
+	}
+	var result *ExecutionResult
+	err := b.Do("execute", func() error {
+		var err error
+		result, err = b.Remote(circuit, shots)
+		return err
+	})
+	if err == nil {
+		if result.Backend == "" {
+			result.Backend = b.Name
+		}
+		result.Provenance = ProvenanceRemote
+		return result, nil
+	}
+
+	var be *BackendError
+	permanent := errors.As(err, &be) && !be.Transient
+	if b.Fallback == nil || permanent {
+		return nil, err
+	}
+	result, ferr := b.Fallback.ExecuteCircuit(circuit, shots)
+	if ferr != nil {
+		return nil, fmt.Errorf("%v; local fallback failed: %w", err, ferr)
+	}
+	result.Provenance = ProvenanceFallback
+	result.FallbackReason = err.Error()
+	return result, nil
+}
+
+// retryDelay draws the backoff before retry number retry.
+func (b *ResilientBackend) retryDelay(retry int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rng == nil {
+		b.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return b.Retry.Delay(retry, b.rng)
+}
+
+func (b *ResilientBackend) sleep(d time.Duration) {
+	if b.Sleep != nil {
+		b.Sleep(d)
+		return
+	}
+	time.Sleep(d)
+}
@@ -21,6 +21,7 @@ type QuantumGate struct {
 	Type     string    `json:"type"`
 	Qubits   []int     `json:"qubits"`
 	Params   []float64 `json:"params,omitempty"`
+	Symbols  []string  `json:"symbols,omitempty"` // parameter names for unbound Params entries
 	Metadata string    `json:"metadata,omitempty"`
 }
 
@@ -30,6 +31,13 @@ type ExecutionResult struct {
 	ExecutionTime float64        `json:"execution_time"`
 	Shots         int            `json:"shots"`
 	Backend       string         `json:"backend"`
+	// Provenance records whether the counts came from the remote backend
+	// or a simulator; see ResilientBackend
+	Provenance     string `json:"provenance,omitempty"`
+	FallbackReason string `json:"fallback_reason,omitempty"`
+	// ProvenanceRecord is the signed record of the backend exchange, for
+	// remote results made with a ProvenanceRecorder
+	ProvenanceRecord *ExecutionProvenance `json:"provenance_record,omitempty"`
 }
 
 // BuildCircuit builds a quantum circuit encoding the given vector
@@ -362,6 +370,10 @@ func (q *QuantumZKP) ExecuteCircuit(circuit *QuantumCircuit, shots int) (*Execut
 		return nil, fmt.Errorf("circuit cannot be nil")
 	}
 
+	if unbound := circuit.Parameters(); len(unbound) > 0 {
+		return nil, fmt.Errorf("circuit has unbound parameters: %v", unbound)
+	}
+
 	if shots <= 0 {
 		shots = 1024 // Default number of shots
 	}
@@ -384,6 +396,7 @@ func (q *QuantumZKP) ExecuteCircuit(circuit *QuantumCircuit, shots int) (*Execut
 		ExecutionTime: executionTime,
 		Shots:         shots,
 		Backend:       "simulator",
+		Provenance:    ProvenanceSimulator,
 	}, nil
 }
 
@@ -463,7 +476,14 @@ func (q *QuantumZKP) ProveVectorKnowledge(vector []complex128, identifier string
 	state := NewQuantumStateVector(vector)
 
 	// Generate commitment
-	superpos := CreateSuperposition(vector)
+	opts := q.Superposition
+	if opts.Mode == "" {
+		opts.Mode = SuperpositionRandomized
+	}
+	superpos, err := NewSuperposition(vector, opts)
+	if err != nil {
+		return nil, nil, err
+	}
 	// Use a proper 32-byte key for blake3
 	key := make([]byte, 32)
 	copy(key, []byte("default_key_for_testing_purposes"))
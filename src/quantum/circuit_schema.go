@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// CircuitSchema identifies the circuit exchange format written by
+// MarshalCircuit and read by UnmarshalCircuit. The format is described by
+// docs/schemas/circuit-v1.schema.json; fields are only ever added under a
+// new schema identifier.
+const CircuitSchema = "qzkp-circuit/v1"
+
+// circuitDocument is the exchange form of a QuantumCircuit. Initialized is
+// bookkeeping of the builder and is not exchanged.
+type circuitDocument struct {
+	Schema    string                 `json:"schema"`
+	NumQubits int                    `json:"num_qubits"`
+	NumClbits int                    `json:"num_clbits"`
+	Gates     []QuantumGate          `json:"gates"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// MarshalCircuit encodes a valid circuit in the CircuitSchema format.
+func MarshalCircuit(circuit *QuantumCircuit) ([]byte, error) {
+	if err := ValidateCircuit(circuit); err != nil {
+		return nil, err
+	}
+	gates := circuit.Gates
+	if gates == nil {
+		gates = []QuantumGate{}
+	}
+	return json.Marshal(circuitDocument{
+		Schema:    CircuitSchema,
+		NumQubits: circuit.NumQubits,
+		NumClbits: circuit.NumClbits,
+		Gates:     gates,
+		Metadata:  circuit.Metadata,
+	})
+}
+
+// UnmarshalCircuit decodes a circuit in the CircuitSchema format and
+// validates it. Unknown fields and other schema identifiers are rejected.
+func UnmarshalCircuit(data []byte) (*QuantumCircuit, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var doc circuitDocument
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse circuit: %w", err)
+	}
+	if doc.Schema != CircuitSchema {
+		return nil, fmt.Errorf("unsupported circuit schema %q (expected %q)", doc.Schema, CircuitSchema)
+	}
+
+	circuit := NewCircuit(doc.NumQubits, doc.NumClbits)
+	if doc.Gates != nil {
+		circuit.Gates = doc.Gates
+	}
+	if doc.Metadata != nil {
+		circuit.Metadata = doc.Metadata
+	}
+	if err := ValidateCircuit(circuit); err != nil {
+		return nil, err
+	}
+	return circuit, nil
+}
+
+// ValidateCircuit checks that every gate of circuit is one SimulateStatevector
+// knows, or a barrier or measurement, and that:
+//
+//   - its qubits are distinct, within the register, and as many as the gate
+//     acts on;
+//   - it has as many parameters as the gate takes, each finite or named by
+//     a symbol;
+//   - a measurement maps one qubit to one classical bit within the classical
+//     register, no classical bit is written twice, and no gate follows the
+//     measurement of a qubit it acts on.
+func ValidateCircuit(circuit *QuantumCircuit) error {
+	if circuit == nil {
+		return errors.New("circuit cannot be nil")
+	}
+	if circuit.NumQubits < 1 {
+		return fmt.Errorf("circuit needs at least one qubit, got %d", circuit.NumQubits)
+	}
+	if circuit.NumClbits < 0 {
+		return fmt.Errorf("negative classical register size %d", circuit.NumClbits)
+	}
+
+	measured := make(map[int]bool)
+	written := make(map[int]int) // classical bit to the measurement writing it
+	for i, gate := range circuit.Gates {
+		fail := func(format string, args ...interface{}) error {
+			return fmt.Errorf("gate %d (%s): %s", i, gate.Type, fmt.Sprintf(format, args...))
+		}
+
+		wantQubits, wantParams := gateArity(gate.Type), gateParamCount(gate.Type)
+		switch gate.Type {
+		case "measure":
+			wantQubits = 1
+		case "barrier":
+			wantQubits = len(gate.Qubits)
+			if wantQubits == 0 {
+				return fail("a barrier needs at least one qubit")
+			}
+		default:
+			if wantQubits == 0 {
+				return fail("unknown gate type")
+			}
+		}
+
+		qubits := gate.Qubits
+		if gate.Type == "measure" {
+			if len(qubits) != 2 {
+				return fail("a measurement takes [qubit, clbit], got %v", qubits)
+			}
+			clbit := qubits[1]
+			if clbit < 0 || clbit >= circuit.NumClbits {
+				return fail("classical bit %d outside [0, %d)", clbit, circuit.NumClbits)
+			}
+			if previous, ok := written[clbit]; ok {
+				return fail("classical bit %d is already written by gate %d", clbit, previous)
+			}
+			written[clbit] = i
+			qubits = qubits[:1]
+		}
+		if len(qubits) != wantQubits {
+			return fail("acts on %d qubits, got %d", wantQubits, len(qubits))
+		}
+		seen := make(map[int]bool, len(qubits))
+		for _, q := range qubits {
+			if q < 0 || q >= circuit.NumQubits {
+				return fail("qubit %d outside [0, %d)", q, circuit.NumQubits)
+			}
+			if seen[q] {
+				return fail("qubit %d appears twice", q)
+			}
+			seen[q] = true
+			if measured[q] && gate.Type != "barrier" {
+				return fail("qubit %d is used after it was measured", q)
+			}
+		}
+		if gate.Type == "measure" {
+			measured[qubits[0]] = true
+		}
+
+		if len(gate.Params) != wantParams {
+			return fail("takes %d parameters, got %d", wantParams, len(gate.Params))
+		}
+		if len(gate.Symbols) != 0 && len(gate.Symbols) != len(gate.Params) {
+			return fail("%d symbols for %d parameters", len(gate.Symbols), len(gate.Params))
+		}
+		for j, p := range gate.Params {
+			symbolic := j < len(gate.Symbols) && gate.Symbols[j] != ""
+			if !symbolic && (math.IsNaN(p) || math.IsInf(p, 0)) {
+				return fail("parameter %d is not finite", j)
+			}
+		}
+	}
+	return nil
+}
+
+// gateParamCount returns the number of parameters gateType takes,
+// including those of the base gate of a controlled gate.
+func gateParamCount(gateType string) int {
+	if c, ok := controlledGates[gateType]; ok {
+		return gateParamCounts[c.base]
+	}
+	return gateParamCounts[gateType]
+}
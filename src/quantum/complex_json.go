@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// ComplexAmplitude is the wire form of a complex number. encoding/json
+// cannot encode complex128, so every exported struct that serializes
+// amplitudes does so through this type, usually via Amplitudes.
+type ComplexAmplitude struct {
+	Re float64 `json:"re"`
+	Im float64 `json:"im"`
+}
+
+// NewComplexAmplitude converts c to its wire form.
+func NewComplexAmplitude(c complex128) ComplexAmplitude {
+	return ComplexAmplitude{Re: real(c), Im: imag(c)}
+}
+
+// Complex converts a back to a complex number.
+func (a ComplexAmplitude) Complex() complex128 {
+	return complex(a.Re, a.Im)
+}
+
+// ToComplexAmplitudes converts a vector to its wire form.
+func ToComplexAmplitudes(vector []complex128) []ComplexAmplitude {
+	if vector == nil {
+		return nil
+	}
+	out := make([]ComplexAmplitude, len(vector))
+	for i, c := range vector {
+		out[i] = NewComplexAmplitude(c)
+	}
+	return out
+}
+
+// FromComplexAmplitudes converts a wire-form vector back to complex numbers.
+func FromComplexAmplitudes(amplitudes []ComplexAmplitude) []complex128 {
+	if amplitudes == nil {
+		return nil
+	}
+	out := make([]complex128, len(amplitudes))
+	for i, a := range amplitudes {
+		out[i] = a.Complex()
+	}
+	return out
+}
+
+// Amplitudes is a state vector that encodes to JSON as ComplexAmplitude
+// objects. It also decodes the [re, im] pairs that Qiskit's
+// Statevector.data.tolist() produces and earlier cache files used.
+type Amplitudes []complex128
+
+// MarshalJSON encodes the amplitudes as ComplexAmplitude objects.
+// Non-finite amplitudes are an error rather than a broken file.
+func (a Amplitudes) MarshalJSON() ([]byte, error) {
+	if a == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(ToComplexAmplitudes(a))
+}
+
+// UnmarshalJSON decodes ComplexAmplitude objects or [re, im] pairs.
+func (a *Amplitudes) UnmarshalJSON(data []byte) error {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return fmt.Errorf("amplitudes must be an array: %v", err)
+	}
+	if elements == nil {
+		*a = nil
+		return nil
+	}
+	out := make(Amplitudes, len(elements))
+	for i, element := range elements {
+		c, err := decodeAmplitude(element)
+		if err != nil {
+			return fmt.Errorf("amplitude %d: %v", i, err)
+		}
+		out[i] = c
+	}
+	*a = out
+	return nil
+}
+
+// decodeAmplitude decodes one amplitude in either wire form, requiring
+// both parts.
+func decodeAmplitude(data []byte) (complex128, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var pair []float64
+		if err := json.Unmarshal(data, &pair); err != nil || len(pair) != 2 {
+			return 0, fmt.Errorf("expected an [re, im] pair, got %s", data)
+		}
+		return complex(pair[0], pair[1]), nil
+	}
+	var parts struct {
+		Re *float64 `json:"re"`
+		Im *float64 `json:"im"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&parts); err != nil || parts.Re == nil || parts.Im == nil {
+		return 0, fmt.Errorf(`expected {"re": …, "im": …}, got %s`, data)
+	}
+	return complex(*parts.Re, *parts.Im), nil
+}
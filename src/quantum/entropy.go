@@ -0,0 +1,293 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sort"
+)
+
+// Rényi orders with a name of their own. Every entropy in this file is in
+// bits; order 1 is the Shannon (or von Neumann) entropy.
+const (
+	EntropyOrderHartley   = 0.0 // log₂ of the support size
+	EntropyOrderShannon   = 1.0
+	EntropyOrderCollision = 2.0
+)
+
+// EntropyOrderMin is the order of the min-entropy, −log₂ max pᵢ.
+var EntropyOrderMin = math.Inf(1)
+
+// Tolerances used when accepting a matrix as a density matrix.
+const (
+	hermitianTolerance = 1e-9
+	spectrumTolerance  = 1e-9
+)
+
+// maxJacobiSweeps bounds the eigenvalue iteration; cyclic Jacobi converges
+// quadratically, so well under a dozen sweeps are needed in practice.
+const maxJacobiSweeps = 64
+
+// ShannonEntropy returns −Σ p log₂ p of a probability distribution.
+func ShannonEntropy(probs []float64) float64 {
+	h := 0.0
+	for _, p := range probs {
+		if p > 0 {
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
+
+// RenyiEntropy returns the order alpha Rényi entropy log₂(Σ p^α)/(1−α) of
+// a probability distribution. Orders 1 and +Inf are the Shannon entropy and
+// the min-entropy.
+func RenyiEntropy(probs []float64, alpha float64) (float64, error) {
+	if alpha < 0 || math.IsNaN(alpha) {
+		return 0, fmt.Errorf("Rényi order %v must be non-negative", alpha)
+	}
+	switch {
+	case alpha == EntropyOrderShannon:
+		return ShannonEntropy(probs), nil
+	case math.IsInf(alpha, 1):
+		max := 0.0
+		for _, p := range probs {
+			max = math.Max(max, p)
+		}
+		if max == 0 {
+			return 0, errors.New("distribution has no support")
+		}
+		return -math.Log2(max), nil
+	}
+
+	var sum kahanSum
+	for _, p := range probs {
+		if p > 0 {
+			sum.add(math.Pow(p, alpha))
+		}
+	}
+	if sum.sum == 0 {
+		return 0, errors.New("distribution has no support")
+	}
+	return math.Max(0, math.Log2(sum.sum)/(1-alpha)), nil
+}
+
+// Probabilities returns the computational basis distribution |cᵢ|²/‖c‖² of
+// state.
+func Probabilities(state []complex128) ([]float64, error) {
+	if len(state) == 0 {
+		return nil, errors.New("state vector cannot be empty")
+	}
+	n := Norm(state)
+	if n == 0 {
+		return nil, errors.New("state vector has zero norm")
+	}
+	probs := make([]float64, len(state))
+	for i, c := range state {
+		a := cmplx.Abs(c) / n
+		probs[i] = a * a
+	}
+	return probs, nil
+}
+
+// ProbabilityEntropy returns the Shannon entropy of the computational
+// basis distribution of state. For a pure state this is also its relative
+// entropy of coherence.
+func ProbabilityEntropy(state []complex128) (float64, error) {
+	probs, err := Probabilities(state)
+	if err != nil {
+		return 0, err
+	}
+	return ShannonEntropy(probs), nil
+}
+
+// DensityMatrixSpectrum returns the eigenvalues of the density matrix rho
+// in decreasing order, normalized to unit trace. rho must be Hermitian and
+// positive semidefinite; rounding noise below zero is clamped.
+func DensityMatrixSpectrum(rho [][]complex128) ([]float64, error) {
+	n := len(rho)
+	if n == 0 {
+		return nil, errors.New("density matrix cannot be empty")
+	}
+	trace := 0.0
+	for i, row := range rho {
+		if len(row) != n {
+			return nil, fmt.Errorf("density matrix row %d has length %d, want %d", i, len(row), n)
+		}
+		for j := 0; j <= i; j++ {
+			if cmplx.Abs(rho[i][j]-cmplx.Conj(rho[j][i])) > hermitianTolerance {
+				return nil, fmt.Errorf("density matrix is not Hermitian at (%d, %d)", i, j)
+			}
+		}
+		trace += real(row[i])
+	}
+	if trace <= 0 {
+		return nil, fmt.Errorf("density matrix trace %g is not positive", trace)
+	}
+
+	eigenvalues := hermitianEigenvalues(rho)
+	for i, v := range eigenvalues {
+		v /= trace
+		if v < -spectrumTolerance {
+			return nil, fmt.Errorf("density matrix has negative eigenvalue %g", v)
+		}
+		eigenvalues[i] = math.Max(0, v)
+	}
+	return eigenvalues, nil
+}
+
+// VonNeumannEntropy returns −Tr ρ log₂ ρ, the Shannon entropy of the
+// spectrum of rho. It is zero exactly for pure states.
+func VonNeumannEntropy(rho [][]complex128) (float64, error) {
+	return SpectralEntropy(rho, EntropyOrderShannon)
+}
+
+// SpectralEntropy returns the order alpha Rényi entropy of the spectrum of
+// rho, log₂(Tr ρ^α)/(1−α); order 1 is the von Neumann entropy.
+func SpectralEntropy(rho [][]complex128, alpha float64) (float64, error) {
+	spectrum, err := DensityMatrixSpectrum(rho)
+	if err != nil {
+		return 0, err
+	}
+	return RenyiEntropy(spectrum, alpha)
+}
+
+// EntanglementEntropy returns the von Neumann entropy of the reduced state
+// of the first qubits qubits of the pure state, which measures their
+// entanglement with the rest. The smaller side is traced out, so the cost
+// stays at 2^(n/2) squared for an even split of n qubits.
+func EntanglementEntropy(state []complex128, qubits int) (float64, error) {
+	return EntanglementSpectralEntropy(state, qubits, EntropyOrderShannon)
+}
+
+// EntanglementSpectralEntropy is EntanglementEntropy with a Rényi order;
+// order 2 gives the entanglement measured by purity.
+func EntanglementSpectralEntropy(state []complex128, qubits int, alpha float64) (float64, error) {
+	n, err := qubitCount(len(state))
+	if err != nil {
+		return 0, err
+	}
+	if qubits < 0 || qubits > n {
+		return 0, fmt.Errorf("subsystem of %d qubits out of range for %d qubits", qubits, n)
+	}
+	norm := Norm(state)
+	if norm == 0 {
+		return 0, errors.New("state vector has zero norm")
+	}
+	if qubits == 0 || qubits == n {
+		return 0, nil
+	}
+
+	// The state is the matrix M[a][b] = state[a·2^(n−k) + b]; the reduced
+	// states M·M† and Mᵀ·conj(M) share their nonzero spectrum
+	rows, cols := 1<<qubits, 1<<(n-qubits)
+	keepRows := rows <= cols
+	dim := cols
+	if keepRows {
+		dim = rows
+	}
+	at := func(i, j int) complex128 {
+		if keepRows {
+			return state[i*cols+j]
+		}
+		return state[j*cols+i]
+	}
+	other := rows * cols / dim
+
+	rho := make([][]complex128, dim)
+	for i := range rho {
+		rho[i] = make([]complex128, dim)
+	}
+	for i := 0; i < dim; i++ {
+		for j := 0; j <= i; j++ {
+			var sum complex128
+			for k := 0; k < other; k++ {
+				sum += at(i, k) * cmplx.Conj(at(j, k))
+			}
+			sum /= complex(norm*norm, 0)
+			rho[i][j], rho[j][i] = sum, cmplx.Conj(sum)
+		}
+	}
+	return SpectralEntropy(rho, alpha)
+}
+
+// BipartiteEntanglementEntropy returns the entanglement entropy across the
+// middle of state, or zero when its dimension is not a power of two and it
+// has no qubit structure to split.
+func BipartiteEntanglementEntropy(state []complex128) (float64, error) {
+	n, err := qubitCount(len(state))
+	if err != nil {
+		return 0, nil
+	}
+	return EntanglementEntropy(state, n/2)
+}
+
+// hermitianEigenvalues returns the eigenvalues of the Hermitian matrix h in
+// decreasing order. h = A + iB is embedded as the real symmetric matrix
+// [[A, −B], [B, A]], whose spectrum is that of h with every eigenvalue
+// doubled, and diagonalized with cyclic Jacobi rotations.
+func hermitianEigenvalues(h [][]complex128) []float64 {
+	n := len(h)
+	m := make([][]float64, 2*n)
+	for i := range m {
+		m[i] = make([]float64, 2*n)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			a, b := real(h[i][j]), imag(h[i][j])
+			m[i][j], m[i+n][j+n] = a, a
+			m[i][j+n], m[i+n][j] = -b, b
+		}
+	}
+
+	jacobiEigenvalues(m)
+	doubled := make([]float64, 2*n)
+	for i := range doubled {
+		doubled[i] = m[i][i]
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(doubled)))
+
+	eigenvalues := make([]float64, n)
+	for i := range eigenvalues {
+		eigenvalues[i] = (doubled[2*i] + doubled[2*i+1]) / 2
+	}
+	return eigenvalues
+}
+
+// jacobiEigenvalues diagonalizes the real symmetric matrix m in place.
+func jacobiEigenvalues(m [][]float64) {
+	n := len(m)
+	for sweep := 0; sweep < maxJacobiSweeps; sweep++ {
+		off, scale := 0.0, 0.0
+		for i := 0; i < n; i++ {
+			scale += m[i][i] * m[i][i]
+			for j := i + 1; j < n; j++ {
+				off += m[i][j] * m[i][j]
+			}
+		}
+		if off <= 1e-30*math.Max(scale, 1e-300) {
+			return
+		}
+
+		for p := 0; p < n-1; p++ {
+			for q := p + 1; q < n; q++ {
+				if m[p][q] == 0 {
+					continue
+				}
+				theta := (m[q][q] - m[p][p]) / (2 * m[p][q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < n; k++ {
+					mkp, mkq := m[k][p], m[k][q]
+					m[k][p], m[k][q] = c*mkp-s*mkq, s*mkp+c*mkq
+				}
+				for k := 0; k < n; k++ {
+					mpk, mqk := m[p][k], m[q][k]
+					m[p][k], m[q][k] = c*mpk-s*mqk, s*mpk+c*mqk
+				}
+			}
+		}
+	}
+}
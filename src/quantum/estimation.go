@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// estimationBootstrapSamples is the number of multinomial resamples used for
+// bootstrap confidence intervals.
+const estimationBootstrapSamples = 1000
+
+// Estimation methods recorded in Estimate.Method.
+const (
+	EstimateMethodExact     = "exact"
+	EstimateMethodBootstrap = "bootstrap"
+)
+
+// Estimate is a point estimate with a confidence interval. Exact estimates
+// computed from a state vector have Lower == Value == Upper.
+type Estimate struct {
+	Value      float64 `json:"value"`
+	Lower      float64 `json:"lower"`
+	Upper      float64 `json:"upper"`
+	Confidence float64 `json:"confidence"`
+	Method     string  `json:"method"`
+	Shots      int     `json:"shots,omitempty"`
+}
+
+// Contains reports whether x lies within the interval.
+func (e *Estimate) Contains(x float64) bool {
+	return x >= e.Lower && x <= e.Upper
+}
+
+// StateEntropy returns the Shannon entropy, in bits, of the computational
+// basis distribution of state.
+func StateEntropy(state []complex128) (*Estimate, error) {
+	if len(state) == 0 {
+		return nil, errors.New("state vector cannot be empty")
+	}
+	h, err := ProbabilityEntropy(state)
+	if err != nil {
+		return nil, err
+	}
+	return &Estimate{Value: h, Lower: h, Upper: h, Confidence: 1, Method: EstimateMethodExact}, nil
+}
+
+// StateCoherence returns the l1-norm of coherence of state, the sum of the
+// magnitudes of the off-diagonal density matrix elements, which equals
+// (Σ|cᵢ|)² − 1 for a normalized pure state.
+func StateCoherence(state []complex128) (*Estimate, error) {
+	if len(state) == 0 {
+		return nil, errors.New("state vector cannot be empty")
+	}
+	n := Norm(state)
+	if n == 0 {
+		return nil, errors.New("state vector has zero norm")
+	}
+
+	sum := 0.0
+	for _, c := range state {
+		sum += math.Hypot(real(c), imag(c)) / n
+	}
+	c := math.Max(0, sum*sum-1)
+	return &Estimate{Value: c, Lower: c, Upper: c, Confidence: 1, Method: EstimateMethodExact}, nil
+}
+
+// EstimateEntropy estimates the Shannon entropy, in bits, of the outcome
+// distribution of result. The point estimate uses the Miller–Madow bias
+// correction; the interval is a percentile bootstrap over multinomial
+// resamples and is reproducible for the same counts.
+func EstimateEntropy(result *ExecutionResult, confidence float64) (*Estimate, error) {
+	outcomes, probs, shots, err := outcomeDistribution(result, confidence)
+	if err != nil {
+		return nil, err
+	}
+	maxEntropy := float64(outcomeBits(outcomes))
+
+	statistic := func(p []float64) float64 {
+		return math.Min(maxEntropy, millerMadowEntropy(p, shots))
+	}
+	return bootstrapEstimate(probs, shots, confidence, statistic, countsSeed(result, outcomes))
+}
+
+// EstimateCoherenceBound estimates an upper bound on the l1-norm of
+// coherence from computational basis counts. Z-basis data cannot reveal
+// off-diagonal terms, but any state with populations pᵢ has l1-coherence at
+// most (Σ√pᵢ)² − 1, with equality for pure states.
+func EstimateCoherenceBound(result *ExecutionResult, confidence float64) (*Estimate, error) {
+	outcomes, probs, shots, err := outcomeDistribution(result, confidence)
+	if err != nil {
+		return nil, err
+	}
+
+	statistic := func(p []float64) float64 {
+		sum := 0.0
+		for _, x := range p {
+			sum += math.Sqrt(x)
+		}
+		return math.Max(0, sum*sum-1)
+	}
+	return bootstrapEstimate(probs, shots, confidence, statistic, countsSeed(result, outcomes))
+}
+
+// outcomeDistribution validates result and returns its sorted outcomes with
+// their observed frequencies.
+func outcomeDistribution(result *ExecutionResult, confidence float64) ([]string, []float64, int, error) {
+	if confidence <= 0 || confidence >= 1 {
+		return nil, nil, 0, fmt.Errorf("confidence must be in (0, 1): %f", confidence)
+	}
+	if result == nil || len(result.Counts) == 0 {
+		return nil, nil, 0, errors.New("result contains no counts")
+	}
+
+	outcomes := make([]string, 0, len(result.Counts))
+	total := 0
+	for outcome, c := range result.Counts {
+		if c < 0 {
+			return nil, nil, 0, fmt.Errorf("negative count for outcome %q", outcome)
+		}
+		outcomes = append(outcomes, outcome)
+		total += c
+	}
+	if total == 0 {
+		return nil, nil, 0, errors.New("result contains no counts")
+	}
+	sort.Strings(outcomes)
+
+	probs := make([]float64, len(outcomes))
+	for i, outcome := range outcomes {
+		probs[i] = float64(result.Counts[outcome]) / float64(total)
+	}
+	return outcomes, probs, total, nil
+}
+
+// bootstrapEstimate evaluates statistic on probs and on multinomial
+// resamples of shots draws from probs, returning the percentile interval.
+func bootstrapEstimate(
+	probs []float64,
+	shots int,
+	confidence float64,
+	statistic func([]float64) float64,
+	seed int64,
+) (*Estimate, error) {
+	cumulative := make([]float64, len(probs))
+	acc := 0.0
+	for i, p := range probs {
+		acc += p
+		cumulative[i] = acc
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	replicates := make([]float64, estimationBootstrapSamples)
+	counts := make([]int, len(probs))
+	resampled := make([]float64, len(probs))
+	for b := range replicates {
+		for i := range counts {
+			counts[i] = 0
+		}
+		for s := 0; s < shots; s++ {
+			i := sort.SearchFloat64s(cumulative, rng.Float64()*acc)
+			if i == len(counts) {
+				i--
+			}
+			counts[i]++
+		}
+		for i, c := range counts {
+			resampled[i] = float64(c) / float64(shots)
+		}
+		replicates[b] = statistic(resampled)
+	}
+	sort.Float64s(replicates)
+
+	alpha := (1 - confidence) / 2
+	lowerIdx := int(math.Floor(alpha * float64(len(replicates)-1)))
+	upperIdx := int(math.Ceil((1 - alpha) * float64(len(replicates)-1)))
+	value := statistic(probs)
+	return &Estimate{
+		Value:      value,
+		Lower:      math.Min(value, replicates[lowerIdx]),
+		Upper:      math.Max(value, replicates[upperIdx]),
+		Confidence: confidence,
+		Method:     EstimateMethodBootstrap,
+		Shots:      shots,
+	}, nil
+}
+
+// millerMadowEntropy corrects the downward bias of the plug-in entropy by
+// (K−1)/(2N ln 2) bits, where K is the number of observed outcomes.
+func millerMadowEntropy(probs []float64, shots int) float64 {
+	observed := 0
+	for _, p := range probs {
+		if p > 0 {
+			observed++
+		}
+	}
+	return ShannonEntropy(probs) + float64(observed-1)/(2*float64(shots)*math.Ln2)
+}
+
+// outcomeBits returns the bitstring length of the outcomes, which bounds
+// the entropy of their distribution.
+func outcomeBits(outcomes []string) int {
+	bits := 0
+	for _, outcome := range outcomes {
+		if len(outcome) > bits {
+			bits = len(outcome)
+		}
+	}
+	return bits
+}
+
+// countsSeed derives the bootstrap seed from the counts so the same data
+// always yields the same interval.
+func countsSeed(result *ExecutionResult, outcomes []string) int64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, outcome := range outcomes {
+		h.Write([]byte(outcome))
+		binary.BigEndian.PutUint64(buf[:], uint64(result.Counts[outcome]))
+		h.Write(buf[:])
+	}
+	return int64(h.Sum64())
+}
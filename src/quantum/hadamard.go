@@ -42,3 +42,35 @@ func ApplyHadamard(state []complex128) ([]complex128, error) {
 
 	return result, nil
 }
+
+// HadamardAmplitude returns amplitude k of H^{\otimes n} |psi> without
+// materializing the transformed state. It performs the same butterfly
+// operations as ApplyHadamard, depth first, so it needs stack space for
+// n levels instead of a second state vector.
+func HadamardAmplitude(state []complex128, k int) (complex128, error) {
+	N := len(state)
+	if N == 0 || (N&(N-1)) != 0 {
+		return 0, errors.New("state vector length must be a power of two")
+	}
+	if k < 0 || k >= N {
+		return 0, errors.New("amplitude index out of range")
+	}
+	numQubits := int(math.Log2(float64(N)))
+	return hadamardButterfly(state, numQubits, k), nil
+}
+
+// hadamardButterfly returns entry i of state after the Hadamard has been
+// applied to qubits 0 to q-1.
+func hadamardButterfly(state []complex128, q, i int) complex128 {
+	if q == 0 {
+		return state[i]
+	}
+	half := 1 << (q - 1)
+	a := hadamardButterfly(state, q-1, i&^half)
+	b := hadamardButterfly(state, q-1, i|half)
+	invSqrt2 := 1 / math.Sqrt2
+	if i&half == 0 {
+		return (a + b) * complex(invSqrt2, 0)
+	}
+	return (a - b) * complex(invSqrt2, 0)
+}
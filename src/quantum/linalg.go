@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// Basis indices follow the big-endian convention: qubit 0 is the most
+// significant bit, so Tensor(a, b) places a on the lower-numbered qubits.
+
+// kahanSum accumulates float64 values with compensated summation.
+type kahanSum struct {
+	sum, c float64
+}
+
+func (k *kahanSum) add(x float64) {
+	y := x - k.c
+	t := k.sum + y
+	k.c = (t - k.sum) - y
+	k.sum = t
+}
+
+// Norm returns the Euclidean norm of v. Amplitudes are rescaled while
+// accumulating so very large or very small vectors neither overflow nor
+// underflow.
+func Norm(v []complex128) float64 {
+	scale, ssq := 0.0, 1.0
+	for _, c := range v {
+		for _, x := range [2]float64{real(c), imag(c)} {
+			if x == 0 {
+				continue
+			}
+			ax := math.Abs(x)
+			if scale < ax {
+				ssq = 1 + ssq*(scale/ax)*(scale/ax)
+				scale = ax
+			} else {
+				ssq += (ax / scale) * (ax / scale)
+			}
+		}
+	}
+	return scale * math.Sqrt(ssq)
+}
+
+// InnerProduct returns ⟨a|b⟩ = Σ conj(a_i)·b_i using compensated summation.
+func InnerProduct(a, b []complex128) (complex128, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("dimension mismatch: %d vs %d", len(a), len(b))
+	}
+	var re, im kahanSum
+	for i := range a {
+		p := cmplx.Conj(a[i]) * b[i]
+		re.add(real(p))
+		im.add(imag(p))
+	}
+	return complex(re.sum, im.sum), nil
+}
+
+// Fidelity returns |⟨a|b⟩|² / (‖a‖²‖b‖²), clamped to [0, 1]. Inputs need
+// not be normalized.
+func Fidelity(a, b []complex128) (float64, error) {
+	overlap, err := InnerProduct(a, b)
+	if err != nil {
+		return 0, err
+	}
+	na, nb := Norm(a), Norm(b)
+	if na == 0 || nb == 0 {
+		return 0, errors.New("fidelity is undefined for a zero vector")
+	}
+	f := cmplx.Abs(overlap) / (na * nb)
+	return math.Min(1, f*f), nil
+}
+
+// Tensor returns the tensor product a ⊗ b.
+func Tensor(a, b []complex128) []complex128 {
+	out := make([]complex128, len(a)*len(b))
+	for i, x := range a {
+		for j, y := range b {
+			out[i*len(b)+j] = x * y
+		}
+	}
+	return out
+}
+
+// DensityMatrix returns |ψ⟩⟨ψ| for a pure state.
+func DensityMatrix(state []complex128) [][]complex128 {
+	rho := make([][]complex128, len(state))
+	for i := range state {
+		rho[i] = make([]complex128, len(state))
+		for j := range state {
+			rho[i][j] = state[i] * cmplx.Conj(state[j])
+		}
+	}
+	return rho
+}
+
+// qubitCount returns n such that dim == 2^n.
+func qubitCount(dim int) (int, error) {
+	if dim < 2 || dim&(dim-1) != 0 {
+		return 0, fmt.Errorf("dimension %d is not a power of two", dim)
+	}
+	n := 0
+	for 1<<n < dim {
+		n++
+	}
+	return n, nil
+}
+
+// PartialTrace traces the given qubit out of the density matrix rho and
+// returns the reduced density matrix on the remaining qubits.
+func PartialTrace(rho [][]complex128, subsystem int) ([][]complex128, error) {
+	n, err := qubitCount(len(rho))
+	if err != nil {
+		return nil, err
+	}
+	for i, row := range rho {
+		if len(row) != len(rho) {
+			return nil, fmt.Errorf("density matrix row %d has length %d, want %d", i, len(row), len(rho))
+		}
+	}
+	if subsystem < 0 || subsystem >= n {
+		return nil, fmt.Errorf("subsystem %d out of range for %d qubits", subsystem, n)
+	}
+
+	bit := n - 1 - subsystem
+	low := (1 << bit) - 1
+	// insert places b at the traced-out bit position of a reduced index
+	insert := func(r, b int) int {
+		return (r&^low)<<1 | b<<bit | r&low
+	}
+
+	dim := len(rho) / 2
+	reduced := make([][]complex128, dim)
+	for i := 0; i < dim; i++ {
+		reduced[i] = make([]complex128, dim)
+		for j := 0; j < dim; j++ {
+			reduced[i][j] = rho[insert(i, 0)][insert(j, 0)] + rho[insert(i, 1)][insert(j, 1)]
+		}
+	}
+	return reduced, nil
+}
+
+// Project returns the probability of observing computational basis state
+// basisIndex when measuring every qubit of state.
+func Project(state []complex128, basisIndex int) (float64, error) {
+	if basisIndex < 0 || basisIndex >= len(state) {
+		return 0, fmt.Errorf("basis index %d out of range for dimension %d", basisIndex, len(state))
+	}
+	total := Norm(state)
+	if total == 0 {
+		return 0, errors.New("cannot project a zero vector")
+	}
+	amp := cmplx.Abs(state[basisIndex]) / total
+	return amp * amp, nil
+}
+
+// ProjectQubit measures one qubit of state in the computational basis with
+// the given outcome and returns the outcome probability and the normalized
+// post-measurement state. outcome must be 0 or 1.
+func ProjectQubit(state []complex128, qubit, outcome int) (float64, []complex128, error) {
+	n, err := qubitCount(len(state))
+	if err != nil {
+		return 0, nil, err
+	}
+	if qubit < 0 || qubit >= n {
+		return 0, nil, fmt.Errorf("qubit %d out of range for %d qubits", qubit, n)
+	}
+	if outcome != 0 && outcome != 1 {
+		return 0, nil, fmt.Errorf("outcome must be 0 or 1, got %d", outcome)
+	}
+
+	bit := n - 1 - qubit
+	projected := make([]complex128, len(state))
+	for i, c := range state {
+		if (i>>bit)&1 == outcome {
+			projected[i] = c
+		}
+	}
+
+	total := Norm(state)
+	if total == 0 {
+		return 0, nil, errors.New("cannot project a zero vector")
+	}
+	norm := Norm(projected)
+	if norm == 0 {
+		return 0, projected, nil
+	}
+	for i := range projected {
+		projected[i] /= complex(norm, 0)
+	}
+	p := (norm / total) * (norm / total)
+	return p, projected, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Parameter is a named placeholder for a gate angle, resolved by Bind.
+type Parameter struct {
+	Name string
+}
+
+// Param returns a symbolic parameter for use with AddGate.
+func Param(name string) Parameter {
+	return Parameter{Name: name}
+}
+
+// NewCircuit creates an empty circuit with the given register sizes.
+func NewCircuit(numQubits, numClbits int) *QuantumCircuit {
+	return &QuantumCircuit{
+		NumQubits:   numQubits,
+		NumClbits:   numClbits,
+		Metadata:    make(map[string]interface{}),
+		Gates:       make([]QuantumGate, 0),
+		Initialized: true,
+	}
+}
+
+// AddGate appends a gate whose params are float64 values or Parameters.
+func (c *QuantumCircuit) AddGate(gateType string, qubits []int, params ...interface{}) error {
+	gate := QuantumGate{Type: gateType, Qubits: qubits}
+	if len(params) > 0 {
+		gate.Params = make([]float64, len(params))
+	}
+
+	for i, p := range params {
+		switch v := p.(type) {
+		case float64:
+			gate.Params[i] = v
+		case int:
+			gate.Params[i] = float64(v)
+		case Parameter:
+			if v.Name == "" {
+				return fmt.Errorf("gate %s: parameter %d has an empty name", gateType, i)
+			}
+			if gate.Symbols == nil {
+				gate.Symbols = make([]string, len(params))
+			}
+			gate.Symbols[i] = v.Name
+		default:
+			return fmt.Errorf("gate %s: unsupported parameter type %T", gateType, p)
+		}
+	}
+
+	c.Gates = append(c.Gates, gate)
+	return nil
+}
+
+// Parameters returns the sorted names of all unbound parameters.
+func (c *QuantumCircuit) Parameters() []string {
+	seen := make(map[string]bool)
+	for _, gate := range c.Gates {
+		for _, name := range gate.Symbols {
+			if name != "" {
+				seen[name] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Bind returns a copy of the circuit with every symbolic parameter replaced
+// by its value. All parameters must be supplied; extra values are rejected
+// so typos in parameter names are caught.
+func (c *QuantumCircuit) Bind(values map[string]float64) (*QuantumCircuit, error) {
+	params := c.Parameters()
+	for _, name := range params {
+		if _, ok := values[name]; !ok {
+			return nil, fmt.Errorf("missing value for parameter %q", name)
+		}
+	}
+	if len(values) != len(params) {
+		known := make(map[string]bool, len(params))
+		for _, name := range params {
+			known[name] = true
+		}
+		for name := range values {
+			if !known[name] {
+				return nil, fmt.Errorf("unknown parameter %q", name)
+			}
+		}
+	}
+
+	bound := &QuantumCircuit{
+		NumQubits:   c.NumQubits,
+		NumClbits:   c.NumClbits,
+		Metadata:    make(map[string]interface{}, len(c.Metadata)),
+		Gates:       make([]QuantumGate, len(c.Gates)),
+		Initialized: c.Initialized,
+	}
+	for k, v := range c.Metadata {
+		bound.Metadata[k] = v
+	}
+
+	for i, gate := range c.Gates {
+		g := QuantumGate{
+			Type:     gate.Type,
+			Qubits:   append([]int(nil), gate.Qubits...),
+			Params:   append([]float64(nil), gate.Params...),
+			Metadata: gate.Metadata,
+		}
+		for j, name := range gate.Symbols {
+			if name != "" {
+				g.Params[j] = values[name]
+			}
+		}
+		bound.Gates[i] = g
+	}
+	return bound, nil
+}
+
+// HardwareEfficientAnsatz builds a layered ry/rz ansatz with a linear cx
+// entangling chain. Parameters are named theta_<layer>_<qubit> and
+// phi_<layer>_<qubit>.
+func HardwareEfficientAnsatz(numQubits, layers int) (*QuantumCircuit, error) {
+	if numQubits < 1 || layers < 1 {
+		return nil, fmt.Errorf("invalid ansatz size: %d qubits, %d layers", numQubits, layers)
+	}
+
+	circuit := NewCircuit(numQubits, numQubits)
+	circuit.Metadata["ansatz"] = "hardware_efficient"
+	circuit.Metadata["layers"] = layers
+
+	for l := 0; l < layers; l++ {
+		for q := 0; q < numQubits; q++ {
+			if err := circuit.AddGate("ry", []int{q}, Param(fmt.Sprintf("theta_%d_%d", l, q))); err != nil {
+				return nil, err
+			}
+			if err := circuit.AddGate("rz", []int{q}, Param(fmt.Sprintf("phi_%d_%d", l, q))); err != nil {
+				return nil, err
+			}
+		}
+		for q := 0; q+1 < numQubits; q++ {
+			if err := circuit.AddGate("cx", []int{q, q + 1}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return circuit, nil
+}
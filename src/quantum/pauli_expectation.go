@@ -0,0 +1,174 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ExpectationEstimate is an estimated Pauli expectation value.
+type ExpectationEstimate struct {
+	Pauli  string  `json:"pauli"`
+	Value  float64 `json:"value"`
+	StdErr float64 `json:"std_err"`
+}
+
+// MeasurementSetting is one circuit to run for a measurement plan: the base
+// circuit rotated into Basis, followed by measurement of every qubit. Paulis
+// lists the observables whose expectation can be read from its counts.
+type MeasurementSetting struct {
+	Basis   string          `json:"basis"`
+	Paulis  []string        `json:"paulis"`
+	Circuit *QuantumCircuit `json:"circuit"`
+}
+
+// validatePauli checks that pauli is an I/X/Y/Z string of the given length.
+func validatePauli(pauli string, numQubits int) error {
+	if len(pauli) != numQubits {
+		return fmt.Errorf("pauli string %q has length %d, want %d", pauli, len(pauli), numQubits)
+	}
+	for i, c := range pauli {
+		if !strings.ContainsRune("IXYZ", c) {
+			return fmt.Errorf("pauli string %q has invalid operator %q at qubit %d", pauli, c, i)
+		}
+	}
+	return nil
+}
+
+// EstimateExpectation computes ⟨P⟩ and its standard error from counts
+// measured in a basis compatible with pauli. Character i of pauli and of
+// each bitstring refers to qubit i. Each shot contributes the eigenvalue
+// (−1)^(parity of the bits on non-identity qubits).
+func EstimateExpectation(result *ExecutionResult, pauli string) (*ExpectationEstimate, error) {
+	if result == nil {
+		return nil, errors.New("result cannot be nil")
+	}
+	if err := validatePauli(pauli, len(pauli)); err != nil {
+		return nil, err
+	}
+
+	total := 0
+	sum := 0
+	for bitstring, count := range result.Counts {
+		if len(bitstring) != len(pauli) {
+			return nil, fmt.Errorf("outcome %q does not match pauli string %q", bitstring, pauli)
+		}
+		parity := 0
+		for i, op := range pauli {
+			if op != 'I' && bitstring[i] == '1' {
+				parity ^= 1
+			}
+		}
+		if parity == 0 {
+			sum += count
+		} else {
+			sum -= count
+		}
+		total += count
+	}
+	if total == 0 {
+		return nil, errors.New("result contains no counts")
+	}
+
+	value := float64(sum) / float64(total)
+	return &ExpectationEstimate{
+		Pauli:  pauli,
+		Value:  value,
+		StdErr: math.Sqrt((1 - value*value) / float64(total)),
+	}, nil
+}
+
+// qubitWiseCompatible reports whether pauli can be read from basis, i.e. on
+// every qubit the operator is either I or matches the basis letter.
+func qubitWiseCompatible(basis, pauli string) bool {
+	for i := range pauli {
+		if pauli[i] != 'I' && basis[i] != 'I' && pauli[i] != basis[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// PlanMeasurements groups paulis into qubit-wise commuting sets and returns
+// one measurement circuit per set, each derived from base. Grouping is
+// greedy in input order.
+func PlanMeasurements(base *QuantumCircuit, paulis []string) ([]MeasurementSetting, error) {
+	if base == nil {
+		return nil, errors.New("circuit cannot be nil")
+	}
+	if len(paulis) == 0 {
+		return nil, errors.New("at least one pauli string is required")
+	}
+
+	var settings []MeasurementSetting
+	for _, pauli := range paulis {
+		if err := validatePauli(pauli, base.NumQubits); err != nil {
+			return nil, err
+		}
+
+		placed := false
+		for i := range settings {
+			if qubitWiseCompatible(settings[i].Basis, pauli) {
+				merged := []byte(settings[i].Basis)
+				for q := range pauli {
+					if pauli[q] != 'I' {
+						merged[q] = pauli[q]
+					}
+				}
+				settings[i].Basis = string(merged)
+				settings[i].Paulis = append(settings[i].Paulis, pauli)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			settings = append(settings, MeasurementSetting{Basis: pauli, Paulis: []string{pauli}})
+		}
+	}
+
+	for i := range settings {
+		settings[i].Circuit = basisMeasurementCircuit(base, settings[i].Basis)
+	}
+	return settings, nil
+}
+
+// basisMeasurementCircuit copies base without its measurements, rotates
+// each qubit into the requested basis and measures all qubits.
+func basisMeasurementCircuit(base *QuantumCircuit, basis string) *QuantumCircuit {
+	circuit := &QuantumCircuit{
+		NumQubits:   base.NumQubits,
+		NumClbits:   base.NumQubits,
+		Metadata:    make(map[string]interface{}, len(base.Metadata)+1),
+		Gates:       make([]QuantumGate, 0, len(base.Gates)+2*base.NumQubits),
+		Initialized: base.Initialized,
+	}
+	for k, v := range base.Metadata {
+		circuit.Metadata[k] = v
+	}
+	circuit.Metadata["measurement_basis"] = basis
+
+	for _, gate := range base.Gates {
+		if gate.Type != "measure" {
+			circuit.Gates = append(circuit.Gates, gate)
+		}
+	}
+
+	for q := 0; q < base.NumQubits; q++ {
+		switch basis[q] {
+		case 'X':
+			circuit.Gates = append(circuit.Gates, QuantumGate{Type: "h", Qubits: []int{q}})
+		case 'Y':
+			circuit.Gates = append(circuit.Gates,
+				QuantumGate{Type: "sdg", Qubits: []int{q}},
+				QuantumGate{Type: "h", Qubits: []int{q}})
+		}
+	}
+	for q := 0; q < base.NumQubits; q++ {
+		circuit.Gates = append(circuit.Gates, QuantumGate{
+			Type:   "measure",
+			Qubits: []int{q, q}, // qubit index, classical bit index
+		})
+	}
+	return circuit
+}
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// qasmRegister is a declared quantum or classical register.
+type qasmRegister struct {
+	offset, size int
+}
+
+var (
+	qasmRegisterDecl = regexp.MustCompile(`^(qreg|creg)\s+([a-zA-Z_]\w*)\s*\[\s*(\d+)\s*\]$`)
+	qasmArgument     = regexp.MustCompile(`^([a-zA-Z_]\w*)\s*(?:\[\s*(\d+)\s*\])?$`)
+)
+
+// ParseQASM parses the straight-line subset of OpenQASM 2.0 that Qiskit
+// exports for state preparation: register declarations, the standard
+// qelib1.inc gates known to SimulateStatevector, barriers and final
+// measurements. Custom gate definitions, conditionals and resets are
+// rejected, as is any gate applied to an already measured qubit, since the
+// circuit would no longer prepare a single pure state.
+func ParseQASM(source string) (*QuantumCircuit, error) {
+	var lines []string
+	for _, line := range strings.Split(source, "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		lines = append(lines, line)
+	}
+	statements := strings.Split(strings.Join(lines, "\n"), ";")
+	if rest := strings.TrimSpace(statements[len(statements)-1]); rest != "" {
+		return nil, fmt.Errorf("statement %q is missing a semicolon", rest)
+	}
+	statements = statements[:len(statements)-1]
+
+	qregs := make(map[string]qasmRegister)
+	cregs := make(map[string]qasmRegister)
+	numQubits, numClbits := 0, 0
+	var gates []QuantumGate
+	measured := make(map[int]bool)
+	sawHeader := false
+
+	for n, raw := range statements {
+		stmt := strings.Join(strings.Fields(raw), " ")
+		fail := func(format string, args ...interface{}) (*QuantumCircuit, error) {
+			return nil, fmt.Errorf("statement %d (%q): %s", n+1, stmt, fmt.Sprintf(format, args...))
+		}
+		if stmt == "" {
+			continue
+		}
+		keyword := strings.Fields(stmt)[0]
+		if !sawHeader {
+			if keyword != "OPENQASM" {
+				return fail("expected an OPENQASM 2.0 header")
+			}
+			if version := strings.TrimSpace(strings.TrimPrefix(stmt, "OPENQASM")); !strings.HasPrefix(version, "2.") {
+				return fail("unsupported OpenQASM version %s", version)
+			}
+			sawHeader = true
+			continue
+		}
+
+		switch keyword {
+		case "include":
+			continue
+		case "qreg", "creg":
+			m := qasmRegisterDecl.FindStringSubmatch(stmt)
+			if m == nil {
+				return fail("malformed register declaration")
+			}
+			size, _ := strconv.Atoi(m[3])
+			if size <= 0 {
+				return fail("register size must be positive")
+			}
+			if _, dup := qregs[m[2]]; dup {
+				return fail("register %s redeclared", m[2])
+			}
+			if _, dup := cregs[m[2]]; dup {
+				return fail("register %s redeclared", m[2])
+			}
+			if keyword == "qreg" {
+				qregs[m[2]] = qasmRegister{numQubits, size}
+				numQubits += size
+			} else {
+				cregs[m[2]] = qasmRegister{numClbits, size}
+				numClbits += size
+			}
+			continue
+		case "barrier":
+			continue
+		case "measure":
+			parts := strings.Split(strings.TrimPrefix(stmt, "measure "), "->")
+			if len(parts) != 2 {
+				return fail("malformed measurement")
+			}
+			qubits, err := resolveQASMArgument(strings.TrimSpace(parts[0]), qregs)
+			if err != nil {
+				return fail("%v", err)
+			}
+			clbits, err := resolveQASMArgument(strings.TrimSpace(parts[1]), cregs)
+			if err != nil {
+				return fail("%v", err)
+			}
+			if len(qubits) != len(clbits) {
+				return fail("measurement register sizes differ")
+			}
+			for i, q := range qubits {
+				measured[q] = true
+				gates = append(gates, QuantumGate{Type: "measure", Qubits: []int{q, clbits[i]}})
+			}
+			continue
+		case "gate", "opaque", "if", "reset":
+			return fail("%s is not supported", keyword)
+		}
+
+		gateType, exprs, operands, ok := splitQASMGateCall(stmt)
+		if !ok {
+			return fail("malformed gate application")
+		}
+		arity := gateArity(gateType)
+		if arity == 0 {
+			return fail("unsupported gate %s", gateType)
+		}
+		var params []float64
+		for _, expr := range exprs {
+			value, err := evalQASMExpression(expr)
+			if err != nil {
+				return fail("%v", err)
+			}
+			params = append(params, value)
+		}
+		base := gateType
+		if c, ok := controlledGates[gateType]; ok {
+			base = c.base
+		}
+		if want := gateParamCounts[base]; len(params) != want {
+			return fail("gate %s takes %d parameters, got %d", gateType, want, len(params))
+		}
+
+		args := strings.Split(operands, ",")
+		if len(args) != arity {
+			return fail("gate %s acts on %d qubits, got %d", gateType, arity, len(args))
+		}
+		var targets [][]int
+		for _, arg := range args {
+			qubits, err := resolveQASMArgument(strings.TrimSpace(arg), qregs)
+			if err != nil {
+				return fail("%v", err)
+			}
+			if arity > 1 && len(qubits) != 1 {
+				return fail("multi-qubit gates must name single qubits")
+			}
+			targets = append(targets, qubits)
+		}
+		// A whole register applies a single-qubit gate to each of its qubits
+		applications := [][]int{}
+		if arity == 1 {
+			for _, q := range targets[0] {
+				applications = append(applications, []int{q})
+			}
+		} else {
+			qubits := make([]int, arity)
+			for i, t := range targets {
+				qubits[i] = t[0]
+			}
+			applications = append(applications, qubits)
+		}
+		for _, qubits := range applications {
+			for _, q := range qubits {
+				if measured[q] {
+					return fail("gate applied to qubit %d after it was measured", q)
+				}
+			}
+			gates = append(gates, QuantumGate{
+				Type:   gateType,
+				Qubits: qubits,
+				Params: append([]float64(nil), params...),
+			})
+		}
+	}
+
+	if !sawHeader {
+		return nil, fmt.Errorf("missing OPENQASM 2.0 header")
+	}
+	if numQubits == 0 {
+		return nil, fmt.Errorf("no quantum register declared")
+	}
+	circuit := NewCircuit(numQubits, numClbits)
+	circuit.Gates = gates
+	return circuit, nil
+}
+
+// splitQASMGateCall splits "name(expr, ...) operands" into its parts,
+// honouring nested parentheses in the angle expressions.
+func splitQASMGateCall(stmt string) (name string, exprs []string, operands string, ok bool) {
+	end := 0
+	for end < len(stmt) && (stmt[end] == '_' || stmt[end] >= 'a' && stmt[end] <= 'z' ||
+		end > 0 && stmt[end] >= '0' && stmt[end] <= '9') {
+		end++
+	}
+	name, rest := stmt[:end], stmt[end:]
+	if name == "" {
+		return "", nil, "", false
+	}
+	if trimmed := strings.TrimLeft(rest, " "); strings.HasPrefix(trimmed, "(") {
+		depth, start, closing := 0, 1, -1
+		for i := 0; i < len(trimmed) && closing < 0; i++ {
+			switch trimmed[i] {
+			case '(':
+				depth++
+			case ')':
+				if depth--; depth == 0 {
+					closing = i
+				}
+			case ',':
+				if depth == 1 {
+					exprs = append(exprs, trimmed[start:i])
+					start = i + 1
+				}
+			}
+		}
+		if closing < 0 {
+			return "", nil, "", false
+		}
+		if last := trimmed[start:closing]; strings.TrimSpace(last) != "" || len(exprs) > 0 {
+			exprs = append(exprs, last)
+		}
+		rest = trimmed[closing+1:]
+	} else if !strings.HasPrefix(rest, " ") {
+		// Operands must be separated from the gate name
+		return "", nil, "", false
+	}
+	operands = strings.TrimSpace(rest)
+	return name, exprs, operands, operands != ""
+}
+
+// resolveQASMArgument maps reg or reg[i] to absolute bit indices.
+func resolveQASMArgument(arg string, registers map[string]qasmRegister) ([]int, error) {
+	m := qasmArgument.FindStringSubmatch(arg)
+	if m == nil {
+		return nil, fmt.Errorf("malformed argument %q", arg)
+	}
+	reg, ok := registers[m[1]]
+	if !ok {
+		return nil, fmt.Errorf("undeclared register %s", m[1])
+	}
+	if m[2] == "" {
+		bits := make([]int, reg.size)
+		for i := range bits {
+			bits[i] = reg.offset + i
+		}
+		return bits, nil
+	}
+	index, _ := strconv.Atoi(m[2])
+	if index >= reg.size {
+		return nil, fmt.Errorf("index %d out of range for %s[%d]", index, m[1], reg.size)
+	}
+	return []int{reg.offset + index}, nil
+}
+
+// qasmFunctions are the unary functions OpenQASM 2.0 allows in angles.
+var qasmFunctions = map[string]func(float64) float64{
+	"sin": math.Sin, "cos": math.Cos, "tan": math.Tan,
+	"exp": math.Exp, "ln": math.Log, "sqrt": math.Sqrt,
+}
+
+// qasmExprParser evaluates OpenQASM 2.0 angle expressions: numbers, pi,
+// + - * / ^, parentheses, unary minus and the functions in qasmFunctions.
+type qasmExprParser struct {
+	src string
+	pos int
+}
+
+func evalQASMExpression(expr string) (float64, error) {
+	p := &qasmExprParser{src: strings.TrimSpace(expr)}
+	value, err := p.sum()
+	if err != nil {
+		return 0, err
+	}
+	p.skipSpace()
+	if p.pos != len(p.src) {
+		return 0, fmt.Errorf("unexpected %q in expression %q", p.src[p.pos:], expr)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("expression %q is not finite", expr)
+	}
+	return value, nil
+}
+
+func (p *qasmExprParser) skipSpace() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *qasmExprParser) peek() byte {
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *qasmExprParser) sum() (float64, error) {
+	value, err := p.product()
+	for err == nil {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			break
+		}
+		p.pos++
+		var rhs float64
+		if rhs, err = p.product(); op == '+' {
+			value += rhs
+		} else {
+			value -= rhs
+		}
+	}
+	return value, err
+}
+
+func (p *qasmExprParser) product() (float64, error) {
+	value, err := p.power()
+	for err == nil {
+		op := p.peek()
+		if op != '*' && op != '/' {
+			break
+		}
+		p.pos++
+		var rhs float64
+		if rhs, err = p.power(); op == '*' {
+			value *= rhs
+		} else {
+			value /= rhs
+		}
+	}
+	return value, err
+}
+
+func (p *qasmExprParser) power() (float64, error) {
+	base, err := p.unary()
+	if err != nil || p.peek() != '^' {
+		return base, err
+	}
+	p.pos++
+	exponent, err := p.power()
+	return math.Pow(base, exponent), err
+}
+
+func (p *qasmExprParser) unary() (float64, error) {
+	if p.peek() == '-' {
+		p.pos++
+		value, err := p.unary()
+		return -value, err
+	}
+	return p.atom()
+}
+
+func (p *qasmExprParser) atom() (float64, error) {
+	c := p.peek()
+	switch {
+	case c == '(':
+		p.pos++
+		value, err := p.sum()
+		if err != nil {
+			return 0, err
+		}
+		if p.peek() != ')' {
+			return 0, fmt.Errorf("missing ) in expression %q", p.src)
+		}
+		p.pos++
+		return value, nil
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.eE", p.src[p.pos]) >= 0 {
+			if (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') && p.pos+1 < len(p.src) &&
+				(p.src[p.pos+1] == '-' || p.src[p.pos+1] == '+') {
+				p.pos++
+			}
+			p.pos++
+		}
+		return strconv.ParseFloat(p.src[start:p.pos], 64)
+	case c >= 'a' && c <= 'z':
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] >= 'a' && p.src[p.pos] <= 'z') {
+			p.pos++
+		}
+		name := p.src[start:p.pos]
+		if name == "pi" {
+			return math.Pi, nil
+		}
+		fn, ok := qasmFunctions[name]
+		if !ok || p.peek() != '(' {
+			return 0, fmt.Errorf("unknown identifier %q in expression %q", name, p.src)
+		}
+		arg, err := p.atom()
+		return fn(arg), err
+	case c == 0:
+		return 0, fmt.Errorf("unexpected end of expression %q", p.src)
+	default:
+		return 0, fmt.Errorf("unexpected %q in expression %q", c, p.src)
+	}
+}
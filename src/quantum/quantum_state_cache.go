@@ -1,20 +1,37 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"iter"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// maxCacheUpdateAttempts bounds how often a read-modify-write of a shared
+// cache is retried after losing a race with another writer.
+const maxCacheUpdateAttempts = 5
+
 // QuantumStateCache manages local storage of real quantum states
 type QuantumStateCache struct {
 	FilePath string
+	// Objects, when set, keeps the cache as the object ObjectKey instead
+	// of in FilePath, so a fleet of provers can share it. Updates are
+	// conditional on the version read, and retried when another writer
+	// got there first
+	Objects   ObjectStore
+	ObjectKey string
 }
 
 // CachedQuantumState represents a cached quantum state with metadata
 type CachedQuantumState struct {
-	Vector      []complex128          `json:"vector"`
+	Vector      Amplitudes            `json:"vector"`
+	Reference   Amplitudes            `json:"reference,omitempty"` // ideal state Fidelity is measured against
 	Name        string                `json:"name"`
 	Description string                `json:"description"`
 	Qubits      int                   `json:"qubits"`
@@ -25,6 +42,8 @@ type CachedQuantumState struct {
 	Coherence   float64               `json:"coherence"`
 	Entanglement float64              `json:"entanglement"`
 	JobID       string                `json:"job_id,omitempty"`
+	Provenance  string                `json:"provenance,omitempty"` // ProvenanceRemote, ProvenanceFallback or ProvenanceSimulator
+	ProvenanceRecord *ExecutionProvenance `json:"provenance_record,omitempty"` // see VerifyCachedStateProvenance
 }
 
 // QuantumStateLibrary contains a collection of cached quantum states
@@ -34,8 +53,22 @@ type QuantumStateLibrary struct {
 	Version   string               `json:"version"`
 	TotalJobs int                  `json:"total_jobs"`
 	UsedTime  float64              `json:"used_time_seconds"` // Track quantum time usage
+
+	// condition is the write condition that keeps a library loaded from an
+	// object store from overwriting a concurrent update; nil for libraries
+	// built by the caller, which replace the stored one
+	condition *PutCondition
 }
 
+// QuantumStateCacheFile is the cache file name. Older releases kept it in
+// the working directory.
+const QuantumStateCacheFile = "real_quantum_states.json"
+
+// StateLibraryVersion is the cache file format written by
+// SaveStateLibrary. Version 2.0 encodes amplitudes as ComplexAmplitude
+// objects; 1.0 files used [re, im] pairs.
+const StateLibraryVersion = "2.0"
+
 // NewQuantumStateCache creates a new cache instance
 func NewQuantumStateCache(filePath string) (*QuantumStateCache, error) {
 	return &QuantumStateCache{
@@ -43,20 +76,81 @@ func NewQuantumStateCache(filePath string) (*QuantumStateCache, error) {
 	}, nil
 }
 
+// NewObjectQuantumStateCache creates a cache kept in objects, such as an
+// S3ObjectStore shared by several provers.
+func NewObjectQuantumStateCache(objects ObjectStore) *QuantumStateCache {
+	return &QuantumStateCache{Objects: objects, ObjectKey: QuantumStateCacheFile}
+}
+
+// NewQuantumStateCacheIn creates a cache in the cache directory of dirs,
+// moving a cache file left in the working directory by older releases.
+func NewQuantumStateCacheIn(dirs *DataDirs) (*QuantumStateCache, error) {
+	path := dirs.CachePath(QuantumStateCacheFile)
+	if _, err := MigrateLegacyFile(QuantumStateCacheFile, path); err != nil {
+		return nil, fmt.Errorf("failed to migrate legacy cache: %v", err)
+	}
+	cache, err := NewQuantumStateCache(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cache.MigrateAmplitudeEncoding(); err != nil {
+		return nil, fmt.Errorf("failed to migrate cache encoding: %w", err)
+	}
+	return cache, nil
+}
+
+// MigrateAmplitudeEncoding rewrites a cache file written in an older
+// format with the current amplitude encoding. It reports whether the file
+// was rewritten. A file written by a newer release is refused with
+// ErrSchemaTooNew rather than rewritten in this release's format.
+func (cache *QuantumStateCache) MigrateAmplitudeEncoding() (bool, error) {
+	migrated := false
+	err := cache.updateLibrary(func(library *QuantumStateLibrary) (bool, error) {
+		if stateLibraryMajor(library.Version) > stateLibraryMajor(StateLibraryVersion) {
+			return false, fmt.Errorf("%w: quantum state cache %s has version %s, but this release reads up to version %s",
+				ErrSchemaTooNew, cache.location(), library.Version, StateLibraryVersion)
+		}
+		// A missing cache loads as an empty library of the current version
+		migrated = library.Version != StateLibraryVersion
+		return migrated, nil
+	})
+	return migrated, err
+}
+
+// stateLibraryMajor returns the major number of a cache file version, 0
+// for files without one.
+func stateLibraryMajor(version string) int {
+	major, _, _ := strings.Cut(version, ".")
+	n, _ := strconv.Atoi(major)
+	return n
+}
+
+// DefaultQuantumStateCache creates a cache in the default cache directory.
+func DefaultQuantumStateCache() (*QuantumStateCache, error) {
+	dirs, err := DefaultDataDirs()
+	if err != nil {
+		return nil, err
+	}
+	return NewQuantumStateCacheIn(dirs)
+}
+
 // LoadStateLibrary loads the quantum state library from cache
 func (cache *QuantumStateCache) LoadStateLibrary() (*QuantumStateLibrary, error) {
-	if _, err := os.Stat(cache.FilePath); os.IsNotExist(err) {
+	data, version, err := cache.read()
+	if errors.Is(err, os.ErrNotExist) {
 		// Return empty library if file doesn't exist
-		return &QuantumStateLibrary{
+		library := &QuantumStateLibrary{
 			States:    make([]CachedQuantumState, 0),
 			Generated: time.Now(),
-			Version:   "1.0",
+			Version:   StateLibraryVersion,
 			TotalJobs: 0,
 			UsedTime:  0.0,
-		}, nil
+		}
+		if cache.Objects != nil {
+			library.condition = &PutCondition{IfAbsent: true}
+		}
+		return library, nil
 	}
-
-	data, err := os.ReadFile(cache.FilePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read cache file: %v", err)
 	}
@@ -65,79 +159,213 @@ func (cache *QuantumStateCache) LoadStateLibrary() (*QuantumStateLibrary, error)
 	if err := json.Unmarshal(data, &library); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal cache data: %v", err)
 	}
+	if cache.Objects != nil {
+		library.condition = &PutCondition{IfVersion: version}
+	}
 
 	return &library, nil
 }
 
 // SaveStateLibrary saves the quantum state library to cache
 func (cache *QuantumStateCache) SaveStateLibrary(library *QuantumStateLibrary) error {
+	library.Version = StateLibraryVersion
 	data, err := json.MarshalIndent(library, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal library: %v", err)
 	}
 
-	if err := os.WriteFile(cache.FilePath, data, 0644); err != nil {
+	if cache.Objects != nil {
+		var cond PutCondition
+		if library.condition != nil {
+			cond = *library.condition
+		}
+		version, err := cache.Objects.Put(cache.ObjectKey, data, cond)
+		if err != nil {
+			// Wrapped with %w so updateLibrary can retry a lost race
+			return fmt.Errorf("failed to write cache object: %w", err)
+		}
+		// A further save of the same library must follow this one
+		library.condition = &PutCondition{IfVersion: version}
+	} else if err := writeFileAtomic(cache.FilePath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write cache file: %v", err)
 	}
 
-	fmt.Printf("💾 Saved %d quantum states to cache (%s)\n", len(library.States), cache.FilePath)
+	fmt.Printf("💾 Saved %d quantum states to cache (%s)\n", len(library.States), cache.location())
 	return nil
 }
 
+// updateLibrary loads the library, applies update and saves the library
+// if update reports a change. When another writer updated a shared cache
+// in between, the whole cycle is retried on the new library.
+func (cache *QuantumStateCache) updateLibrary(update func(*QuantumStateLibrary) (bool, error)) error {
+	for attempt := 1; ; attempt++ {
+		library, err := cache.LoadStateLibrary()
+		if err != nil {
+			return err
+		}
+		changed, err := update(library)
+		if err != nil || !changed {
+			return err
+		}
+		err = cache.SaveStateLibrary(library)
+		if !errors.Is(err, ErrPreconditionFailed) || attempt == maxCacheUpdateAttempts {
+			return err
+		}
+	}
+}
+
+// read returns the cache contents and their object version, or an error
+// wrapping os.ErrNotExist when there is no cache yet.
+func (cache *QuantumStateCache) read() ([]byte, string, error) {
+	if cache.Objects == nil {
+		data, err := hostFS.ReadFile(cache.FilePath)
+		return data, "", err
+	}
+	data, version, err := cache.Objects.Get(cache.ObjectKey)
+	if errors.Is(err, ErrObjectNotFound) {
+		return nil, "", fmt.Errorf("%w: %v", os.ErrNotExist, err)
+	}
+	return data, version, err
+}
+
+// location describes where the cache is kept.
+func (cache *QuantumStateCache) location() string {
+	if cache.Objects != nil {
+		return "object " + cache.ObjectKey
+	}
+	return cache.FilePath
+}
+
 // AddState adds a new quantum state to the cache
 func (cache *QuantumStateCache) AddState(state CachedQuantumState) error {
-	library, err := cache.LoadStateLibrary()
-	if err != nil {
-		return err
-	}
+	return cache.updateLibrary(func(library *QuantumStateLibrary) (bool, error) {
+		// Check if state already exists (by name)
+		for i, existing := range library.States {
+			if existing.Name == state.Name {
+				// Update existing state
+				library.States[i] = state
+				return true, nil
+			}
+		}
 
-	// Check if state already exists (by name)
-	for i, existing := range library.States {
-		if existing.Name == state.Name {
-			// Update existing state
-			library.States[i] = state
-			return cache.SaveStateLibrary(library)
+		// Add new state
+		library.States = append(library.States, state)
+		library.TotalJobs++
+		return true, nil
+	})
+}
+
+// IterStates streams the cached states with the given number of qubits,
+// or every state when qubits is 0, decoding one state at a time so large
+// caches are never loaded whole. A missing cache yields nothing.
+func (cache *QuantumStateCache) IterStates(qubits int) iter.Seq2[CachedQuantumState, error] {
+	return func(yield func(CachedQuantumState, error) bool) {
+		f, err := cache.open()
+		if errors.Is(err, os.ErrNotExist) {
+			return
 		}
-	}
+		if err != nil {
+			yield(CachedQuantumState{}, fmt.Errorf("failed to read cache file: %v", err))
+			return
+		}
+		defer f.Close()
 
-	// Add new state
-	library.States = append(library.States, state)
-	library.TotalJobs++
-	
-	return cache.SaveStateLibrary(library)
+		dec := json.NewDecoder(f)
+		fail := func(err error) {
+			yield(CachedQuantumState{}, fmt.Errorf("failed to decode cache data: %v", err))
+		}
+		if err := expectDelim(dec, '{'); err != nil {
+			fail(err)
+			return
+		}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				fail(err)
+				return
+			}
+			if tok != "states" {
+				var skip json.RawMessage
+				if err := dec.Decode(&skip); err != nil {
+					fail(err)
+					return
+				}
+				continue
+			}
+
+			if err := expectDelim(dec, '['); err != nil {
+				fail(err)
+				return
+			}
+			for dec.More() {
+				var state CachedQuantumState
+				if err := dec.Decode(&state); err != nil {
+					fail(err)
+					return
+				}
+				if qubits != 0 && state.Qubits != qubits {
+					continue
+				}
+				if !yield(state, nil) {
+					return
+				}
+			}
+			return
+		}
+	}
 }
 
-// GetStatesByQubits returns all cached states with the specified number of qubits
-func (cache *QuantumStateCache) GetStatesByQubits(qubits int) ([]CachedQuantumState, error) {
-	library, err := cache.LoadStateLibrary()
+// open returns a reader over the cache. A file is streamed; an object is
+// fetched whole first.
+func (cache *QuantumStateCache) open() (io.ReadCloser, error) {
+	if cache.Objects == nil {
+		return hostFS.Open(cache.FilePath)
+	}
+	data, _, err := cache.read()
 	if err != nil {
 		return nil, err
 	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// expectDelim reads the next token and checks it is delim.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %v, found %v", delim, tok)
+	}
+	return nil
+}
 
+// GetStatesByQubits returns all cached states with the specified number of qubits.
+// Use IterStates to process large caches without materializing them.
+func (cache *QuantumStateCache) GetStatesByQubits(qubits int) ([]CachedQuantumState, error) {
 	var filtered []CachedQuantumState
-	for _, state := range library.States {
+	for state, err := range cache.IterStates(0) {
+		if err != nil {
+			return nil, err
+		}
 		if state.Qubits == qubits {
 			filtered = append(filtered, state)
 		}
 	}
-
 	return filtered, nil
 }
 
 // GetStatesByType returns all cached states matching the specified type/name pattern
 func (cache *QuantumStateCache) GetStatesByType(stateType string) ([]CachedQuantumState, error) {
-	library, err := cache.LoadStateLibrary()
-	if err != nil {
-		return nil, err
-	}
-
 	var filtered []CachedQuantumState
-	for _, state := range library.States {
+	for state, err := range cache.IterStates(0) {
+		if err != nil {
+			return nil, err
+		}
 		if stateType == "all" || state.Name == stateType {
 			filtered = append(filtered, state)
 		}
 	}
-
 	return filtered, nil
 }
 
@@ -177,18 +405,19 @@ type QuantumUsageStats struct {
 
 // UpdateUsageTime adds to the total quantum time used
 func (cache *QuantumStateCache) UpdateUsageTime(additionalSeconds float64) error {
-	library, err := cache.LoadStateLibrary()
-	if err != nil {
-		return err
-	}
-
-	library.UsedTime += additionalSeconds
-	return cache.SaveStateLibrary(library)
+	return cache.updateLibrary(func(library *QuantumStateLibrary) (bool, error) {
+		library.UsedTime += additionalSeconds
+		return true, nil
+	})
 }
 
 // ClearCache removes all cached states (use with caution!)
 func (cache *QuantumStateCache) ClearCache() error {
-	if err := os.Remove(cache.FilePath); err != nil && !os.IsNotExist(err) {
+	if cache.Objects != nil {
+		if err := cache.Objects.Delete(cache.ObjectKey); err != nil {
+			return fmt.Errorf("failed to remove cache object: %v", err)
+		}
+	} else if err := hostFS.Remove(cache.FilePath); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to remove cache file: %v", err)
 	}
 	
@@ -220,7 +449,7 @@ func (cache *QuantumStateCache) exportAsJSON(library *QuantumStateLibrary, outpu
 		return err
 	}
 	
-	return os.WriteFile(outputPath, data, 0644)
+	return hostFS.WriteFile(outputPath, data, 0644)
 }
 
 // exportAsCSV exports states as CSV (simplified)
@@ -235,31 +464,12 @@ func (cache *QuantumStateCache) exportAsCSV(library *QuantumStateLibrary, output
 			state.Timestamp.Format(time.RFC3339))
 	}
 	
-	return os.WriteFile(outputPath, []byte(csvContent), 0644)
+	return hostFS.WriteFile(outputPath, []byte(csvContent), 0644)
 }
 
-// PrintCacheInfo displays information about the current cache
+// PrintCacheInfo displays information about the current cache against the
+// default quantum time quota. The qzkp states command covers the same and
+// more.
 func (cache *QuantumStateCache) PrintCacheInfo() error {
-	stats, err := cache.GetUsageStats()
-	if err != nil {
-		return err
-	}
-
-	fmt.Println("📊 Quantum State Cache Information:")
-	fmt.Printf("   Total States: %d\n", stats.TotalStates)
-	fmt.Printf("   Total Jobs: %d\n", stats.TotalJobs)
-	fmt.Printf("   Used Time: %.2f seconds (%.2f minutes)\n", stats.UsedTimeSeconds, stats.UsedTimeSeconds/60)
-	fmt.Printf("   Last Generated: %s\n", stats.LastGenerated.Format(time.RFC3339))
-	
-	fmt.Println("   States by Qubits:")
-	for qubits, count := range stats.StatesByQubits {
-		fmt.Printf("     %d qubits: %d states\n", qubits, count)
-	}
-	
-	fmt.Println("   States by Type:")
-	for stateType, count := range stats.StatesByType {
-		fmt.Printf("     %s: %d states\n", stateType, count)
-	}
-
-	return nil
+	return cache.WriteSummary(os.Stdout, DefaultQuantumTimeQuota)
 }
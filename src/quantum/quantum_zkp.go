@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,6 +13,9 @@ type QuantumZKP struct {
 	SecurityLevel int
 	Cache         *ResultCache
 	Signer        *SignatureScheme
+	// Superposition selects how proofs weight the states; an empty Mode
+	// keeps each prove method's default
+	Superposition SuperpositionOptions
 }
 
 // NewQuantumZKP constructs a new instance with given dimensions and security level
@@ -30,107 +32,20 @@ func NewQuantumZKP(dimensions, securityLevel int, ctx []byte) (*QuantumZKP, erro
 	}, nil
 }
 
-// Prove generates a proof object for the given state vector
+// Prove generates a proof object for the given state vector. The
+// superposition is randomized unless q.Superposition selects another mode.
 func (q *QuantumZKP) Prove(
 	states []complex128,
 	identifier string,
 	key []byte,
 ) (*Proof, error) {
-	if len(states) == 0 {
-		return nil, errors.New("state vector cannot be empty")
-	}
-
-	// 1) Create superposition
-	superpos := CreateSuperposition(states)
-
-	// 2) Compute metadata
-	ent := CalculateEntropy(states)
-	meta := StateMetadata{
-		Coherence:    ent / float64(len(states)),
-		Entanglement: ent,
-		Timestamp:    time.Now(),
-	}
-
-	// 3) Compute commitment
-	commitment := GenerateCommitment(superpos, identifier, key)
-
-	// 4) Generate measurements
-	measCount := min(len(states), q.SecurityLevel/8)
-	measurements := make([]Measurement, measCount)
-
-	// Pre-compute X-basis states for X measurements
-	var xStates []complex128
-	var err error
-	for i := 0; i < measCount; i++ {
-		basis := []string{"Z", "X"}[i%2]
-		if basis == "X" && xStates == nil {
-			xStates, err = ApplyHadamard(states)
-			if err != nil {
-				return nil, fmt.Errorf("failed to apply Hadamard: %w", err)
-			}
-		}
-	}
-
-	for i := 0; i < measCount; i++ {
-		idx := i % len(states)
-		basis := []string{"Z", "X"}[i%2]
-		var prob float64
-		var phase float64
-
-		if basis == "Z" {
-			prob = real(states[idx])*real(states[idx]) + imag(states[idx])*imag(states[idx])
-			phase = imag(states[idx])
-		} else { // X basis
-			prob = real(xStates[idx])*real(xStates[idx]) + imag(xStates[idx])*imag(xStates[idx])
-			phase = imag(xStates[idx])
-		}
-
-		measurements[i] = Measurement{
-			BasisIndex:       idx,
-			Probability:      prob,
-			Phase:            phase,
-			MeasurementBasis: basis,
-		}
-	}
-
-	// 7) Build final Proof
-	proof := &Proof{
-		QuantumDimensions: q.Dimensions,
-		BasisCoefficients: superpos.CoordinatesAsSlices(),
-		Amplitudes:        superpos.Amplitudes,
-		Measurements:      measurements,
-		StateMetadata:     meta,
-		Identifier:        identifier,
-		Commitment:        hex.EncodeToString(commitment),
-		Signature:         "",
-	}
-
-	// compute hex commitment
-	rawCommit := GenerateCommitment(superpos, identifier, key) // returns []byte
-	commitHex := hex.EncodeToString(rawCommit)
-	proof.Commitment = commitHex
-
-	// prep message = JSON(proof without Sig) + commitHex
-	temp := *proof
-	temp.Signature = ""
-	proofBytes, err := json.Marshal(&temp)
-	if err != nil {
-		return nil, err
-	}
-	msg := append(proofBytes, []byte(commitHex)...)
-
-	// sign
-	sigBytes, err := q.Signer.Sign(msg)
-	if err != nil {
-		return nil, err
-	}
-	proof.Signature = hex.EncodeToString(sigBytes)
-	return proof, nil
+	return q.proveWithMode(states, identifier, key, SuperpositionRandomized)
 }
 
 // ProveFromBytes generates a proof for data represented as bytes.
 // The bytes are converted to a quantum state vector using BytesToState.
-// This method ensures deterministic proof generation for the same input bytes.
+// Unless q.Superposition selects another mode, the superposition is
+// deterministic, so the same bytes always give the same commitment.
 func (q *QuantumZKP) ProveFromBytes(
 	data []byte,
 	identifier string,
@@ -148,9 +63,8 @@ func (q *QuantumZKP) ProveFromBytes(
 		return nil, fmt.Errorf("failed to convert bytes to state: %w", err)
 	}
 
-	// For deterministic behavior, we need to use a custom implementation
-	// that doesn't rely on random amplitudes
-	return q.ProveWithDeterministicSuperposition(states, identifier, key)
+	// Byte proofs are deterministic unless q asks otherwise
+	return q.proveWithMode(states, identifier, key, SuperpositionDeterministic)
 }
 
 // VerifyProofFromBytes verifies a proof that was generated from bytes.
@@ -168,21 +82,48 @@ func (q *QuantumZKP) ProveWithDeterministicSuperposition(
 	states []complex128,
 	identifier string,
 	key []byte,
+) (*Proof, error) {
+	return q.prove(states, identifier, key, SuperpositionOptions{Mode: SuperpositionDeterministic})
+}
+
+// proveWithMode proves states in q's superposition mode, or in
+// defaultMode when q does not set one.
+func (q *QuantumZKP) proveWithMode(
+	states []complex128,
+	identifier string,
+	key []byte,
+	defaultMode SuperpositionMode,
+) (*Proof, error) {
+	opts := q.Superposition
+	if opts.Mode == "" {
+		opts.Mode = defaultMode
+	}
+	return q.prove(states, identifier, key, opts)
+}
+
+// prove generates a proof with a superposition built from opts.
+func (q *QuantumZKP) prove(
+	states []complex128,
+	identifier string,
+	key []byte,
+	opts SuperpositionOptions,
 ) (*Proof, error) {
 	if len(states) == 0 {
 		return nil, errors.New("state vector cannot be empty")
 	}
 
-	// 1) Create deterministic superposition
-	superpos := CreateDeterministicSuperposition(states)
+	// 1) Create superposition
+	superpos, err := NewSuperposition(states, opts)
+	if err != nil {
+		return nil, err
+	}
 
 	// 2) Compute metadata
-	ent := CalculateEntropy(states)
-	meta := StateMetadata{
-		Coherence:    ent / float64(len(states)),
-		Entanglement: ent,
-		Timestamp:    time.Now(),
+	meta, err := computeStateMetadata(states)
+	if err != nil {
+		return nil, err
 	}
+	meta.SuperpositionMode = opts.Mode
 
 	// 3) Compute commitment
 	commitment := GenerateCommitment(superpos, identifier, key)
@@ -193,7 +134,6 @@ func (q *QuantumZKP) ProveWithDeterministicSuperposition(
 
 	// Pre-compute X-basis states for X measurements
 	var xStates []complex128
-	var err error
 	for i := 0; i < measCount; i++ {
 		basis := []string{"Z", "X"}[i%2]
 		if basis == "X" && xStates == nil {
@@ -229,12 +169,13 @@ func (q *QuantumZKP) ProveWithDeterministicSuperposition(
 	// 5) Build final Proof
 	proof := &Proof{
 		QuantumDimensions: q.Dimensions,
-		BasisCoefficients: superpos.CoordinatesAsSlices(),
+		BasisCoefficients: append(Amplitudes(nil), superpos.States...),
 		Amplitudes:        superpos.Amplitudes,
 		Measurements:      measurements,
 		StateMetadata:     meta,
 		Identifier:        identifier,
-		Commitment:        hex.EncodeToString(commitment),
+		Commitment:        EncodeHexField(commitment),
+		NumericEncoding:   DefaultNumericEncoding(),
 		Signature:         "",
 	}
 
@@ -251,7 +192,7 @@ func (q *QuantumZKP) ProveWithDeterministicSuperposition(
 	if err != nil {
 		return nil, err
 	}
-	proof.Signature = hex.EncodeToString(sigBytes)
+	proof.Signature = EncodeHexField(sigBytes)
 
 	return proof, nil
 }
@@ -263,13 +204,21 @@ func (q *QuantumZKP) VerifyProof(
 	key []byte,
 ) bool {
 	// 1) Recompute & compare commitment
-	states := StatesFromSlices(proof.BasisCoefficients)
+	states := []complex128(proof.BasisCoefficients)
 	superpos := Superposition{States: states, Amplitudes: proof.Amplitudes}
-	rawCommit := GenerateCommitment(superpos, proof.Identifier, key)
-	computedCommit := hex.EncodeToString(rawCommit)
+	rawCommit, err := GenerateCommitmentWithEncoding(superpos, proof.Identifier, key, proof.NumericEncoding)
+	if err != nil {
+		return false
+	}
+	computedCommit := EncodeHexField(rawCommit)
 	if computedCommit != proof.Commitment {
 		return false
 	}
+	// Deterministic amplitudes follow from the states alone
+	if proof.StateMetadata.SuperpositionMode == SuperpositionDeterministic &&
+		!equalAmplitudes(proof.Amplitudes, CreateDeterministicSuperposition(states).Amplitudes) {
+		return false
+	}
 
 	// 2) Rebuild the exact msg bytes
 	temp := *proof
@@ -281,7 +230,7 @@ func (q *QuantumZKP) VerifyProof(
 	msg := append(proofBytes, []byte(proof.Commitment)...)
 
 	// 3) Decode the signature from hex and verify
-	sigBytes, err := hex.DecodeString(proof.Signature)
+	sigBytes, err := decodeSignatureField(proof.Signature)
 	if err != nil {
 		return false
 	}
@@ -299,15 +248,6 @@ func (q *QuantumZKP) VerifyProof(
 	return true
 }
 
-// StatesFromSlices rebuilds []complex128 from [][]float64
-func StatesFromSlices(slices [][]float64) []complex128 {
-	cs := make([]complex128, len(slices))
-	for i, p := range slices {
-		cs[i] = complex(p[0], p[1])
-	}
-	return cs
-}
-
 // min returns the smaller of two ints
 func min(a, b int) int {
 	if a < b {
@@ -315,3 +255,35 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+// computeStateMetadata reports the relative entropy of coherence of states,
+// which for a pure state is the Shannon entropy of its basis distribution,
+// and its entanglement entropy across the middle qubit boundary.
+func computeStateMetadata(states []complex128) (StateMetadata, error) {
+	coherence, err := ProbabilityEntropy(states)
+	if err != nil {
+		return StateMetadata{}, fmt.Errorf("failed to compute coherence: %w", err)
+	}
+	entanglement, err := BipartiteEntanglementEntropy(states)
+	if err != nil {
+		return StateMetadata{}, fmt.Errorf("failed to compute entanglement: %w", err)
+	}
+	return StateMetadata{
+		Coherence:    coherence,
+		Entanglement: entanglement,
+		Timestamp:    time.Now(),
+	}, nil
+}
+
+// equalAmplitudes reports whether a and b are identical.
+func equalAmplitudes(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// BackendCalibration describes the timing and error characteristics of an
+// execution backend. Times are in nanoseconds, errors are per-operation
+// probabilities.
+type BackendCalibration struct {
+	Name              string  `json:"name"`
+	Simulator         bool    `json:"simulator"`
+	MaxQubits         int     `json:"max_qubits"`
+	SingleQubitGateNs float64 `json:"single_qubit_gate_ns"`
+	TwoQubitGateNs    float64 `json:"two_qubit_gate_ns"`
+	ReadoutNs         float64 `json:"readout_ns"`
+	RepDelayNs        float64 `json:"rep_delay_ns"`
+	JobOverheadSec    float64 `json:"job_overhead_sec"`
+	SingleQubitError  float64 `json:"single_qubit_error"`
+	TwoQubitError     float64 `json:"two_qubit_error"`
+	ReadoutError      float64 `json:"readout_error"`
+	// NsPerAmplitudeOp is the simulator cost of applying one gate to one
+	// amplitude; only used when Simulator is set.
+	NsPerAmplitudeOp float64 `json:"ns_per_amplitude_op,omitempty"`
+}
+
+// DefaultCalibrations returns representative calibrations for the local
+// simulator and the IBM backends used in the validation runs.
+func DefaultCalibrations() []BackendCalibration {
+	return []BackendCalibration{
+		{
+			Name:             "simulator",
+			Simulator:        true,
+			MaxQubits:        30,
+			NsPerAmplitudeOp: 1,
+		},
+		{
+			Name:              "ibm_brisbane",
+			MaxQubits:         127,
+			SingleQubitGateNs: 60,
+			TwoQubitGateNs:    660,
+			ReadoutNs:         1300,
+			RepDelayNs:        250000,
+			JobOverheadSec:    5,
+			SingleQubitError:  2.5e-4,
+			TwoQubitError:     7.5e-3,
+			ReadoutError:      1.3e-2,
+		},
+	}
+}
+
+// BackendEstimate is the projected cost of running a circuit on one backend.
+type BackendEstimate struct {
+	Backend          string  `json:"backend"`
+	Feasible         bool    `json:"feasible"`
+	EstimatedSeconds float64 `json:"estimated_seconds"`
+	EstimatedError   float64 `json:"estimated_error"`
+}
+
+// ResourceEstimate summarizes the structure of a circuit and its projected
+// cost on each calibrated backend.
+type ResourceEstimate struct {
+	Width            int               `json:"width"`
+	Depth            int               `json:"depth"`
+	GateCount        int               `json:"gate_count"`
+	SingleQubitGates int               `json:"single_qubit_gates"`
+	TwoQubitGates    int               `json:"two_qubit_gates"`
+	Measurements     int               `json:"measurements"`
+	Shots            int               `json:"shots"`
+	Backends         []BackendEstimate `json:"backends"`
+}
+
+// EstimateResources estimates depth, gate counts and per-backend cost for
+// circuit with the default shot count and DefaultCalibrations.
+func EstimateResources(circuit *QuantumCircuit) (*ResourceEstimate, error) {
+	return EstimateResourcesFor(circuit, 1024, DefaultCalibrations())
+}
+
+// EstimateResourcesFor estimates resources for the given shot count and
+// backend calibrations.
+func EstimateResourcesFor(circuit *QuantumCircuit, shots int, calibrations []BackendCalibration) (*ResourceEstimate, error) {
+	if circuit == nil {
+		return nil, errors.New("circuit cannot be nil")
+	}
+	if shots <= 0 {
+		return nil, fmt.Errorf("invalid shot count: %d", shots)
+	}
+
+	est := &ResourceEstimate{Width: circuit.NumQubits, Shots: shots}
+	layer := make([]int, circuit.NumQubits)
+
+	for i, gate := range circuit.Gates {
+		qubits := gateQubits(gate)
+		for _, q := range qubits {
+			if q < 0 || q >= circuit.NumQubits {
+				return nil, fmt.Errorf("gate %d (%s) acts on qubit %d outside the circuit", i, gate.Type, q)
+			}
+		}
+		if gate.Type == "barrier" || gate.Type == "id" {
+			continue
+		}
+
+		est.GateCount++
+		switch {
+		case gate.Type == "measure":
+			est.Measurements++
+		case len(qubits) >= 2:
+			est.TwoQubitGates++
+		default:
+			est.SingleQubitGates++
+		}
+
+		// A gate starts after every qubit it touches is free
+		start := 0
+		for _, q := range qubits {
+			if layer[q] > start {
+				start = layer[q]
+			}
+		}
+		for _, q := range qubits {
+			layer[q] = start + 1
+		}
+		if start+1 > est.Depth {
+			est.Depth = start + 1
+		}
+	}
+
+	for _, cal := range calibrations {
+		est.Backends = append(est.Backends, est.estimateBackend(cal))
+	}
+	return est, nil
+}
+
+// estimateBackend projects runtime and error for one calibration.
+func (est *ResourceEstimate) estimateBackend(cal BackendCalibration) BackendEstimate {
+	be := BackendEstimate{
+		Backend:  cal.Name,
+		Feasible: cal.MaxQubits == 0 || est.Width <= cal.MaxQubits,
+	}
+
+	if cal.Simulator {
+		amplitudes := math.Pow(2, float64(est.Width))
+		be.EstimatedSeconds = float64(est.GateCount) * amplitudes * cal.NsPerAmplitudeOp * 1e-9 * float64(est.Shots)
+		return be
+	}
+
+	// Depth-weighted critical path, pessimistically assuming every layer
+	// contains a two-qubit gate when any are present
+	layerNs := cal.SingleQubitGateNs
+	if est.TwoQubitGates > 0 {
+		layerNs = cal.TwoQubitGateNs
+	}
+	shotNs := float64(est.Depth)*layerNs + cal.ReadoutNs + cal.RepDelayNs
+	be.EstimatedSeconds = cal.JobOverheadSec + float64(est.Shots)*shotNs*1e-9
+
+	success := math.Pow(1-cal.SingleQubitError, float64(est.SingleQubitGates)) *
+		math.Pow(1-cal.TwoQubitError, float64(est.TwoQubitGates)) *
+		math.Pow(1-cal.ReadoutError, float64(est.Measurements))
+	be.EstimatedError = 1 - success
+	return be
+}
+
+// RecommendBackend returns the fastest feasible backend whose estimated
+// error does not exceed maxError.
+func (est *ResourceEstimate) RecommendBackend(maxError float64) (string, error) {
+	candidates := make([]BackendEstimate, 0, len(est.Backends))
+	for _, be := range est.Backends {
+		if be.Feasible && be.EstimatedError <= maxError {
+			candidates = append(candidates, be)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no backend meets an error budget of %g", maxError)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].EstimatedSeconds < candidates[j].EstimatedSeconds
+	})
+	return candidates[0].Backend, nil
+}
+
+// gateQubits returns the qubits a gate acts on. Measurement gates store
+// (qubit, classical bit), so only the first entry is a qubit.
+func gateQubits(gate QuantumGate) []int {
+	if gate.Type == "measure" && len(gate.Qubits) > 0 {
+		return gate.Qubits[:1]
+	}
+	return gate.Qubits
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// TotalCounts returns the number of recorded outcomes. It falls back to the
+// sum of Counts when Shots is unset.
+func (r *ExecutionResult) TotalCounts() int {
+	if r.Shots > 0 {
+		return r.Shots
+	}
+	total := 0
+	for _, c := range r.Counts {
+		total += c
+	}
+	return total
+}
+
+// Probability returns the observed frequency of outcome.
+func (r *ExecutionResult) Probability(outcome string) float64 {
+	total := r.TotalCounts()
+	if total == 0 {
+		return 0
+	}
+	return float64(r.Counts[outcome]) / float64(total)
+}
+
+// Probabilities returns the observed frequency of every recorded outcome.
+func (r *ExecutionResult) Probabilities() map[string]float64 {
+	probs := make(map[string]float64, len(r.Counts))
+	for outcome := range r.Counts {
+		probs[outcome] = r.Probability(outcome)
+	}
+	return probs
+}
+
+// ChiSquareResult holds a goodness-of-fit test outcome.
+type ChiSquareResult struct {
+	Statistic        float64 `json:"statistic"`
+	DegreesOfFreedom int     `json:"degrees_of_freedom"`
+	PValue           float64 `json:"p_value"`
+}
+
+// ChiSquareGoodnessOfFit tests the observed counts against an expected
+// outcome distribution. Outcomes with zero expected probability but
+// non-zero counts make the fit impossible and yield a p-value of 0.
+func ChiSquareGoodnessOfFit(result *ExecutionResult, expected map[string]float64) (*ChiSquareResult, error) {
+	if result == nil || len(expected) == 0 {
+		return nil, errors.New("result and expected distribution are required")
+	}
+	total := float64(result.TotalCounts())
+	if total == 0 {
+		return nil, errors.New("result contains no counts")
+	}
+
+	outcomes := unionOutcomes(result.Counts, expected)
+	statistic := 0.0
+	for _, outcome := range outcomes {
+		observed := float64(result.Counts[outcome])
+		exp := expected[outcome] * total
+		if exp == 0 {
+			if observed > 0 {
+				return &ChiSquareResult{Statistic: math.Inf(1), DegreesOfFreedom: len(outcomes) - 1}, nil
+			}
+			continue
+		}
+		statistic += (observed - exp) * (observed - exp) / exp
+	}
+
+	dof := len(outcomes) - 1
+	if dof < 1 {
+		dof = 1
+	}
+	return &ChiSquareResult{
+		Statistic:        statistic,
+		DegreesOfFreedom: dof,
+		PValue:           1 - regularizedGammaP(float64(dof)/2, statistic/2),
+	}, nil
+}
+
+// ProbabilityInterval returns the Wilson score interval for the probability
+// of outcome at the given confidence level (e.g. 0.95).
+func (r *ExecutionResult) ProbabilityInterval(outcome string, confidence float64) (float64, float64, error) {
+	if confidence <= 0 || confidence >= 1 {
+		return 0, 0, fmt.Errorf("confidence must be in (0, 1): %f", confidence)
+	}
+	n := float64(r.TotalCounts())
+	if n == 0 {
+		return 0, 0, errors.New("result contains no counts")
+	}
+
+	z := math.Sqrt2 * math.Erfinv(confidence)
+	p := r.Probability(outcome)
+	denom := 1 + z*z/n
+	centre := (p + z*z/(2*n)) / denom
+	half := z * math.Sqrt(p*(1-p)/n+z*z/(4*n*n)) / denom
+	return math.Max(0, centre-half), math.Min(1, centre+half), nil
+}
+
+// HellingerDistance returns the Hellinger distance between the outcome
+// distributions of two runs, in [0, 1].
+func HellingerDistance(a, b *ExecutionResult) float64 {
+	pa, pb := a.Probabilities(), b.Probabilities()
+	sum := 0.0
+	for _, outcome := range unionOutcomes(a.Counts, pb) {
+		d := math.Sqrt(pa[outcome]) - math.Sqrt(pb[outcome])
+		sum += d * d
+	}
+	return math.Sqrt(sum / 2)
+}
+
+// TotalVariationDistance returns half the L1 distance between the outcome
+// distributions of two runs, in [0, 1].
+func TotalVariationDistance(a, b *ExecutionResult) float64 {
+	pa, pb := a.Probabilities(), b.Probabilities()
+	sum := 0.0
+	for _, outcome := range unionOutcomes(a.Counts, pb) {
+		sum += math.Abs(pa[outcome] - pb[outcome])
+	}
+	return sum / 2
+}
+
+// CHSHResult holds the CHSH value estimated from four 2-qubit runs.
+type CHSHResult struct {
+	S           float64    `json:"s"`
+	StdErr      float64    `json:"std_err"`
+	Correlators [4]float64 `json:"correlators"`
+	Violation   bool       `json:"violation"` // |S| > 2 by more than three standard errors
+}
+
+// Correlator returns E = P(same) − P(different) for a 2-qubit result.
+func Correlator(result *ExecutionResult) (float64, error) {
+	total := float64(result.TotalCounts())
+	if total == 0 {
+		return 0, errors.New("result contains no counts")
+	}
+	same := float64(result.Counts["00"] + result.Counts["11"])
+	diff := float64(result.Counts["01"] + result.Counts["10"])
+	return (same - diff) / total, nil
+}
+
+// EvaluateCHSH computes S = E(a,b) − E(a,b′) + E(a′,b) + E(a′,b′) from runs
+// measured in the four setting combinations, in that order.
+func EvaluateCHSH(ab, abPrime, aPrimeB, aPrimeBPrime *ExecutionResult) (*CHSHResult, error) {
+	runs := [4]*ExecutionResult{ab, abPrime, aPrimeB, aPrimeBPrime}
+	signs := [4]float64{1, -1, 1, 1}
+
+	res := &CHSHResult{}
+	variance := 0.0
+	for i, run := range runs {
+		if run == nil {
+			return nil, fmt.Errorf("CHSH setting %d is missing", i)
+		}
+		e, err := Correlator(run)
+		if err != nil {
+			return nil, fmt.Errorf("CHSH setting %d: %w", i, err)
+		}
+		res.Correlators[i] = e
+		res.S += signs[i] * e
+		variance += (1 - e*e) / float64(run.TotalCounts())
+	}
+	res.StdErr = math.Sqrt(variance)
+	res.Violation = math.Abs(res.S)-2 > 3*res.StdErr
+	return res, nil
+}
+
+// unionOutcomes returns the sorted union of outcome labels.
+func unionOutcomes(counts map[string]int, probs map[string]float64) []string {
+	seen := make(map[string]bool, len(counts)+len(probs))
+	for k := range counts {
+		seen[k] = true
+	}
+	for k := range probs {
+		seen[k] = true
+	}
+	out := make([]string, 0, len(seen))
+	for k := range seen {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// regularizedGammaP computes P(a, x), the regularized lower incomplete gamma
+// function, by series expansion for small x and a continued fraction
+// otherwise.
+func regularizedGammaP(a, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if math.IsInf(x, 1) {
+		return 1
+	}
+	lgammaA, _ := math.Lgamma(a)
+
+	if x < a+1 {
+		sum := 1 / a
+		term := sum
+		for n := 1; n < 500; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*1e-15 {
+				break
+			}
+		}
+		return sum * math.Exp(-x+a*math.Log(x)-lgammaA)
+	}
+
+	// Lentz's method for the continued fraction of Q(a, x)
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1; i < 500; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-15 {
+			break
+		}
+	}
+	return 1 - math.Exp(-x+a*math.Log(x)-lgammaA)*h
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// resultFileExt is the extension of stored execution results.
+const resultFileExt = ".json"
+
+// CircuitHash identifies a circuit by its qubits and gates. Metadata is
+// left out, so rebuilding the same circuit for another identifier or at
+// another time gives the same hash.
+func CircuitHash(circuit *QuantumCircuit) (string, error) {
+	if circuit == nil {
+		return "", errors.New("circuit cannot be nil")
+	}
+	data, err := CanonicalizeValue(struct {
+		NumQubits int           `json:"num_qubits"`
+		NumClbits int           `json:"num_clbits"`
+		Gates     []QuantumGate `json:"gates"`
+	}{circuit.NumQubits, circuit.NumClbits, circuit.Gates})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode circuit: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// StoredResult is one recorded run of a circuit on a backend.
+type StoredResult struct {
+	CircuitHash string           `json:"circuit_hash"`
+	Backend     string           `json:"backend"`
+	RecordedAt  time.Time        `json:"recorded_at"`
+	Result      *ExecutionResult `json:"result"`
+}
+
+// ResultStore keeps execution results in the data directory, one JSON
+// file per run under <circuit hash>/<backend>/, so runs of the same
+// circuit on the same hardware can be compared over time.
+type ResultStore struct {
+	Dir string
+}
+
+// NewResultStore opens the result store in dirs, creating it if needed.
+func NewResultStore(dirs *DataDirs) (*ResultStore, error) {
+	dir := dirs.DataPath("results")
+	if err := hostFS.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create result store: %w", err)
+	}
+	return &ResultStore{Dir: dir}, nil
+}
+
+// Record stores result as a run of the circuit with circuitHash on
+// result.Backend at the given time.
+func (s *ResultStore) Record(circuitHash string, result *ExecutionResult, at time.Time) error {
+	if result == nil {
+		return errors.New("result cannot be nil")
+	}
+	if err := validateStoreName(circuitHash); err != nil {
+		return fmt.Errorf("invalid circuit hash: %w", err)
+	}
+	if err := validateStoreName(result.Backend); err != nil {
+		return fmt.Errorf("invalid backend: %w", err)
+	}
+	stored := StoredResult{CircuitHash: circuitHash, Backend: result.Backend, RecordedAt: at.UTC(), Result: result}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+	// Zero-padded nanoseconds sort in time order
+	name := fmt.Sprintf("%020d%s", stored.RecordedAt.UnixNano(), resultFileExt)
+	return writeFileAtomic(filepath.Join(s.Dir, circuitHash, result.Backend, name), data, 0644)
+}
+
+// Backends returns the sorted backends the circuit has results for.
+func (s *ResultStore) Backends(circuitHash string) ([]string, error) {
+	if err := validateStoreName(circuitHash); err != nil {
+		return nil, fmt.Errorf("invalid circuit hash: %w", err)
+	}
+	entries, err := hostFS.ReadDir(filepath.Join(s.Dir, circuitHash))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var backends []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			backends = append(backends, entry.Name())
+		}
+	}
+	sort.Strings(backends)
+	return backends, nil
+}
+
+// History returns every run of the circuit on backend, oldest first.
+func (s *ResultStore) History(circuitHash, backend string) ([]StoredResult, error) {
+	if err := validateStoreName(circuitHash); err != nil {
+		return nil, fmt.Errorf("invalid circuit hash: %w", err)
+	}
+	if err := validateStoreName(backend); err != nil {
+		return nil, fmt.Errorf("invalid backend: %w", err)
+	}
+	dir := filepath.Join(s.Dir, circuitHash, backend)
+	names, err := listStoreEntries(dir, resultFileExt)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]StoredResult, 0, len(names))
+	for _, name := range names {
+		data, err := hostFS.ReadFile(filepath.Join(dir, name+resultFileExt))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read result %s: %w", name, err)
+		}
+		var stored StoredResult
+		if err := json.Unmarshal(data, &stored); err != nil {
+			return nil, fmt.Errorf("failed to parse result %s: %w", name, err)
+		}
+		history = append(history, stored)
+	}
+	sort.SliceStable(history, func(i, j int) bool { return history[i].RecordedAt.Before(history[j].RecordedAt) })
+	return history, nil
+}
+
+// ClassicalFidelity returns (Σ √(pᵢqᵢ))², the fidelity of the outcome
+// distribution of result to the expected distribution, in [0, 1].
+func ClassicalFidelity(result *ExecutionResult, expected map[string]float64) float64 {
+	probs := result.Probabilities()
+	sum := 0.0
+	for outcome, p := range probs {
+		if q := expected[outcome]; q > 0 {
+			sum += math.Sqrt(p * q)
+		}
+	}
+	return math.Min(1, sum*sum)
+}
+
+// ResultTrendPoint describes one run against the reference distribution
+// and the run before it.
+type ResultTrendPoint struct {
+	RecordedAt time.Time `json:"recorded_at"`
+	Shots      int       `json:"shots"`
+	Fidelity   float64   `json:"fidelity"`  // to the reference distribution
+	Drift      float64   `json:"drift"`     // total variation distance from the previous run
+	Hellinger  float64   `json:"hellinger"` // Hellinger distance from the previous run
+}
+
+// ResultTrend follows a run history over time. Fidelity is measured
+// against reference, or against the first run when reference is nil; the
+// first point has no drift.
+func ResultTrend(history []StoredResult, reference map[string]float64) ([]ResultTrendPoint, error) {
+	if len(history) == 0 {
+		return nil, errors.New("history is empty")
+	}
+	for i, run := range history {
+		if run.Result == nil || run.Result.TotalCounts() == 0 {
+			return nil, fmt.Errorf("run %d has no counts", i)
+		}
+	}
+	if reference == nil {
+		reference = history[0].Result.Probabilities()
+	}
+
+	points := make([]ResultTrendPoint, len(history))
+	for i, run := range history {
+		points[i] = ResultTrendPoint{
+			RecordedAt: run.RecordedAt,
+			Shots:      run.Result.TotalCounts(),
+			Fidelity:   ClassicalFidelity(run.Result, reference),
+		}
+		if i > 0 {
+			points[i].Drift = TotalVariationDistance(history[i-1].Result, run.Result)
+			points[i].Hellinger = HellingerDistance(history[i-1].Result, run.Result)
+		}
+	}
+	return points, nil
+}
+
+// TrendSummary condenses a trend into the figures a hardware report needs.
+type TrendSummary struct {
+	Runs                int       `json:"runs"`
+	First               time.Time `json:"first"`
+	Last                time.Time `json:"last"`
+	MeanFidelity        float64   `json:"mean_fidelity"`
+	MinFidelity         float64   `json:"min_fidelity"`
+	FidelitySlopePerDay float64   `json:"fidelity_slope_per_day"` // least-squares; zero for a single day
+	MaxDrift            float64   `json:"max_drift"`
+	TotalDrift          float64   `json:"total_drift"` // total variation distance, first to last run
+}
+
+// SummarizeTrend summarizes points; history must be the runs they were
+// computed from, for the first-to-last drift.
+func SummarizeTrend(history []StoredResult, points []ResultTrendPoint) (*TrendSummary, error) {
+	if len(points) == 0 || len(points) != len(history) {
+		return nil, errors.New("points must describe a non-empty history")
+	}
+	summary := &TrendSummary{
+		Runs:        len(points),
+		First:       points[0].RecordedAt,
+		Last:        points[len(points)-1].RecordedAt,
+		MinFidelity: 1,
+		TotalDrift:  TotalVariationDistance(history[0].Result, history[len(history)-1].Result),
+	}
+
+	var meanT, meanF float64
+	for _, p := range points {
+		meanT += p.RecordedAt.Sub(summary.First).Hours() / 24
+		meanF += p.Fidelity
+		summary.MinFidelity = math.Min(summary.MinFidelity, p.Fidelity)
+		summary.MaxDrift = math.Max(summary.MaxDrift, p.Drift)
+	}
+	n := float64(len(points))
+	meanT, meanF = meanT/n, meanF/n
+	summary.MeanFidelity = meanF
+
+	var cov, variance float64
+	for _, p := range points {
+		dt := p.RecordedAt.Sub(summary.First).Hours()/24 - meanT
+		cov += dt * (p.Fidelity - meanF)
+		variance += dt * dt
+	}
+	if variance > 0 {
+		summary.FidelitySlopePerDay = cov / variance
+	}
+	return summary, nil
+}
+
+// WriteTrendCSV exports points as a time series with a header row.
+func WriteTrendCSV(w io.Writer, points []ResultTrendPoint) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"recorded_at", "shots", "fidelity", "drift", "hellinger"}); err != nil {
+		return err
+	}
+	format := func(x float64) string { return strconv.FormatFloat(x, 'f', 6, 64) }
+	for _, p := range points {
+		record := []string{
+			p.RecordedAt.UTC().Format(time.RFC3339),
+			strconv.Itoa(p.Shots),
+			format(p.Fidelity),
+			format(p.Drift),
+			format(p.Hellinger),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
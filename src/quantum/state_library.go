@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"sort"
+	"time"
+)
+
+// DefaultQuantumTimeQuota is the monthly quantum time of an IBM Quantum
+// open plan account.
+const DefaultQuantumTimeQuota = 10 * time.Minute
+
+// Tolerances used when checking the claims of cached states.
+const (
+	stateNormTolerance     = 1e-6
+	stateFidelityTolerance = 1e-6
+)
+
+// QASMStateBackend is the backend recorded for states imported from QASM.
+const QASMStateBackend = "qasm_statevector"
+
+// StateCheck is the outcome of checking one cached state's claims.
+type StateCheck struct {
+	Name     string   `json:"name"`
+	Problems []string `json:"problems,omitempty"`
+}
+
+// OK reports whether every claim of the state holds.
+func (c StateCheck) OK() bool {
+	return len(c.Problems) == 0
+}
+
+// CheckCachedState checks that a cached state is a normalized vector of
+// the size its qubit count implies and that its fidelity is a probability.
+// When the state records the reference it was compared against, the
+// claimed fidelity is recomputed.
+func CheckCachedState(state CachedQuantumState) StateCheck {
+	check := StateCheck{Name: state.Name}
+	problem := func(format string, args ...interface{}) {
+		check.Problems = append(check.Problems, fmt.Sprintf(format, args...))
+	}
+
+	if state.Name == "" {
+		problem("state has no name")
+	}
+	if len(state.Vector) == 0 {
+		problem("state vector is empty")
+		return check
+	}
+	for i, c := range state.Vector {
+		if cmplx.IsNaN(c) || cmplx.IsInf(c) {
+			problem("amplitude %d is not finite", i)
+			return check
+		}
+	}
+	if state.Qubits <= 0 || state.Qubits > 30 || len(state.Vector) != 1<<state.Qubits {
+		problem("vector has %d amplitudes, which does not match %d qubits", len(state.Vector), state.Qubits)
+	}
+	if norm := Norm(state.Vector); math.Abs(norm-1) > stateNormTolerance {
+		problem("state is not normalized: norm is %.9f", norm)
+	}
+	if state.Fidelity < 0 || state.Fidelity > 1 || math.IsNaN(state.Fidelity) {
+		problem("fidelity %.6f is outside [0, 1]", state.Fidelity)
+	}
+	if state.Reference != nil {
+		fidelity, err := Fidelity(state.Vector, state.Reference)
+		switch {
+		case err != nil:
+			problem("fidelity cannot be recomputed: %v", err)
+		case math.Abs(fidelity-state.Fidelity) > stateFidelityTolerance:
+			problem("claimed fidelity %.6f, recomputed %.6f against the reference", state.Fidelity, fidelity)
+		}
+	}
+	return check
+}
+
+// VerifyStates checks every cached state, streaming the cache.
+func (cache *QuantumStateCache) VerifyStates() ([]StateCheck, error) {
+	var checks []StateCheck
+	for state, err := range cache.IterStates(0) {
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, CheckCachedState(state))
+	}
+	return checks, nil
+}
+
+// StateFromQASM simulates an OpenQASM 2.0 state preparation circuit and
+// returns the prepared state. The simulation is ideal, so the state is its
+// own reference and has fidelity 1.
+func StateFromQASM(name, source string) (CachedQuantumState, error) {
+	circuit, err := ParseQASM(source)
+	if err != nil {
+		return CachedQuantumState{}, err
+	}
+	vector, err := SimulateStatevector(circuit)
+	if err != nil {
+		return CachedQuantumState{}, err
+	}
+	coherence, err := StateCoherence(vector)
+	if err != nil {
+		return CachedQuantumState{}, err
+	}
+	return CachedQuantumState{
+		Vector:      vector,
+		Reference:   append(Amplitudes(nil), vector...),
+		Name:        name,
+		Description: "imported from OpenQASM",
+		Qubits:      circuit.NumQubits,
+		Backend:     QASMStateBackend,
+		Fidelity:    1,
+		Coherence:   coherence.Value,
+		Provenance:  ProvenanceSimulator,
+		Metadata: map[string]interface{}{
+			"num_gates": len(circuit.Gates),
+		},
+	}, nil
+}
+
+// ImportStatesJSON decodes states from a state library, as written by
+// SaveStateLibrary or ExportStates, or from the name-keyed "states" object
+// that qiskit_executor.py writes to quantum_states.json.
+func ImportStatesJSON(data []byte) ([]CachedQuantumState, error) {
+	var doc struct {
+		States json.RawMessage `json:"states"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode states: %v", err)
+	}
+
+	var states []CachedQuantumState
+	if err := json.Unmarshal(doc.States, &states); err == nil {
+		return states, nil
+	}
+	var named map[string]CachedQuantumState
+	if err := json.Unmarshal(doc.States, &named); err != nil {
+		return nil, errors.New(`expected a "states" array or object of states`)
+	}
+	for name, state := range named {
+		if state.Name == "" {
+			state.Name = name
+		}
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states, nil
+}
+
+// ImportStates checks states and adds them to the cache in one write,
+// replacing cached states of the same name. States without a timestamp
+// are stamped with now so they age from their import. Nothing is written
+// if any state fails its checks.
+func (cache *QuantumStateCache) ImportStates(states []CachedQuantumState, now time.Time) error {
+	for _, state := range states {
+		if check := CheckCachedState(state); !check.OK() {
+			return fmt.Errorf("state %q: %s", state.Name, check.Problems[0])
+		}
+	}
+	return cache.updateLibrary(func(library *QuantumStateLibrary) (bool, error) {
+		index := make(map[string]int, len(library.States))
+		for i, state := range library.States {
+			index[state.Name] = i
+		}
+		for _, state := range states {
+			if state.Timestamp.IsZero() {
+				state.Timestamp = now
+			}
+			if i, ok := index[state.Name]; ok {
+				library.States[i] = state
+				continue
+			}
+			index[state.Name] = len(library.States)
+			library.States = append(library.States, state)
+			library.TotalJobs++
+		}
+		return true, nil
+	})
+}
+
+// PruneCriteria selects cached states to remove. A state is pruned when it
+// matches every criterion that is set; at least one must be.
+type PruneCriteria struct {
+	OlderThan time.Time // states timestamped before this instant
+	Backend   string    // states from this backend
+}
+
+func (c PruneCriteria) matches(state CachedQuantumState) bool {
+	if !c.OlderThan.IsZero() && !state.Timestamp.Before(c.OlderThan) {
+		return false
+	}
+	return c.Backend == "" || state.Backend == c.Backend
+}
+
+// PruneStates removes the states matching criteria and returns their
+// names. The cache is only rewritten when something was removed.
+func (cache *QuantumStateCache) PruneStates(criteria PruneCriteria) ([]string, error) {
+	if criteria.OlderThan.IsZero() && criteria.Backend == "" {
+		return nil, errors.New("prune needs an age or a backend")
+	}
+	var pruned []string
+	err := cache.updateLibrary(func(library *QuantumStateLibrary) (bool, error) {
+		pruned = nil
+		kept := library.States[:0]
+		for _, state := range library.States {
+			if criteria.matches(state) {
+				pruned = append(pruned, state.Name)
+				continue
+			}
+			kept = append(kept, state)
+		}
+		library.States = kept
+		return len(pruned) > 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pruned, nil
+}
+
+// QuotaUsage compares the quantum time recorded in the cache against a
+// quota.
+type QuotaUsage struct {
+	Used  time.Duration `json:"used"`
+	Quota time.Duration `json:"quota"`
+}
+
+// Remaining returns the unused quota, never negative.
+func (u QuotaUsage) Remaining() time.Duration {
+	if u.Used >= u.Quota {
+		return 0
+	}
+	return u.Quota - u.Used
+}
+
+// Fraction returns the share of the quota used.
+func (u QuotaUsage) Fraction() float64 {
+	if u.Quota <= 0 {
+		return 0
+	}
+	return float64(u.Used) / float64(u.Quota)
+}
+
+// QuotaUsage returns the quantum time used so far against quota.
+func (cache *QuantumStateCache) QuotaUsage(quota time.Duration) (QuotaUsage, error) {
+	library, err := cache.LoadStateLibrary()
+	if err != nil {
+		return QuotaUsage{}, err
+	}
+	used := time.Duration(library.UsedTime * float64(time.Second))
+	return QuotaUsage{Used: used, Quota: quota}, nil
+}
+
+// WriteSummary writes the cache statistics and quota usage to w.
+func (cache *QuantumStateCache) WriteSummary(w io.Writer, quota time.Duration) error {
+	stats, err := cache.GetUsageStats()
+	if err != nil {
+		return err
+	}
+	usage, err := cache.QuotaUsage(quota)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "📊 Quantum State Cache Information:")
+	fmt.Fprintf(w, "   File: %s\n", cache.location())
+	fmt.Fprintf(w, "   Total States: %d\n", stats.TotalStates)
+	fmt.Fprintf(w, "   Total Jobs: %d\n", stats.TotalJobs)
+	fmt.Fprintf(w, "   Used Time: %v of %v (%.1f%%, %v remaining)\n",
+		usage.Used.Round(time.Millisecond), usage.Quota, 100*usage.Fraction(), usage.Remaining().Round(time.Millisecond))
+	fmt.Fprintf(w, "   Last Generated: %s\n", stats.LastGenerated.Format(time.RFC3339))
+
+	qubits := make([]int, 0, len(stats.StatesByQubits))
+	for q := range stats.StatesByQubits {
+		qubits = append(qubits, q)
+	}
+	sort.Ints(qubits)
+	fmt.Fprintln(w, "   States by Qubits:")
+	for _, q := range qubits {
+		fmt.Fprintf(w, "     %d qubits: %d states\n", q, stats.StatesByQubits[q])
+	}
+
+	types := make([]string, 0, len(stats.StatesByType))
+	for t := range stats.StatesByType {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	fmt.Fprintln(w, "   States by Type:")
+	for _, t := range types {
+		fmt.Fprintf(w, "     %s: %d states\n", t, stats.StatesByType[t])
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// maxSimulatedQubits bounds SimulateStatevector, whose memory grows as 2^n.
+const maxSimulatedQubits = 20
+
+// gateMatrix is a single-qubit unitary.
+type gateMatrix [2][2]complex128
+
+// gateParamCounts is the number of angles each parameterized single-qubit
+// gate takes; gates not listed take none.
+var gateParamCounts = map[string]int{
+	"rx": 1, "ry": 1, "rz": 1, "p": 1, "u1": 1,
+	"u2": 2,
+	"u":  3, "u3": 3,
+}
+
+// controlledGates maps controlled gates to their control count and the
+// single-qubit gate applied to the target.
+var controlledGates = map[string]struct {
+	controls int
+	base     string
+}{
+	"cx":  {1, "x"},
+	"cy":  {1, "y"},
+	"cz":  {1, "z"},
+	"ch":  {1, "h"},
+	"crz": {1, "rz"},
+	"cp":  {1, "p"},
+	"cu1": {1, "u1"},
+	"ccx": {2, "x"},
+}
+
+// singleQubitMatrix returns the unitary of a single-qubit gate.
+func singleQubitMatrix(gateType string, params []float64) (gateMatrix, error) {
+	if want := gateParamCounts[gateType]; len(params) != want {
+		return gateMatrix{}, fmt.Errorf("gate %s takes %d parameters, got %d", gateType, want, len(params))
+	}
+	invSqrt2 := complex(1/math.Sqrt2, 0)
+	phase := func(angle float64) complex128 { return cmplx.Exp(complex(0, angle)) }
+	u3 := func(theta, phi, lambda float64) gateMatrix {
+		c, s := complex(math.Cos(theta/2), 0), complex(math.Sin(theta/2), 0)
+		return gateMatrix{{c, -phase(lambda) * s}, {phase(phi) * s, phase(phi+lambda) * c}}
+	}
+
+	switch gateType {
+	case "id":
+		return gateMatrix{{1, 0}, {0, 1}}, nil
+	case "x":
+		return gateMatrix{{0, 1}, {1, 0}}, nil
+	case "y":
+		return gateMatrix{{0, -1i}, {1i, 0}}, nil
+	case "z":
+		return gateMatrix{{1, 0}, {0, -1}}, nil
+	case "h":
+		return gateMatrix{{invSqrt2, invSqrt2}, {invSqrt2, -invSqrt2}}, nil
+	case "s":
+		return gateMatrix{{1, 0}, {0, 1i}}, nil
+	case "sdg":
+		return gateMatrix{{1, 0}, {0, -1i}}, nil
+	case "t":
+		return gateMatrix{{1, 0}, {0, phase(math.Pi / 4)}}, nil
+	case "tdg":
+		return gateMatrix{{1, 0}, {0, phase(-math.Pi / 4)}}, nil
+	case "sx":
+		return gateMatrix{{(1 + 1i) / 2, (1 - 1i) / 2}, {(1 - 1i) / 2, (1 + 1i) / 2}}, nil
+	case "rx":
+		c, s := complex(math.Cos(params[0]/2), 0), complex(0, -math.Sin(params[0]/2))
+		return gateMatrix{{c, s}, {s, c}}, nil
+	case "ry":
+		c, s := complex(math.Cos(params[0]/2), 0), complex(math.Sin(params[0]/2), 0)
+		return gateMatrix{{c, -s}, {s, c}}, nil
+	case "rz":
+		return gateMatrix{{phase(-params[0] / 2), 0}, {0, phase(params[0] / 2)}}, nil
+	case "p", "u1":
+		return gateMatrix{{1, 0}, {0, phase(params[0])}}, nil
+	case "u2":
+		return u3(math.Pi/2, params[0], params[1]), nil
+	case "u", "u3":
+		return u3(params[0], params[1], params[2]), nil
+	default:
+		return gateMatrix{}, fmt.Errorf("unsupported gate %q", gateType)
+	}
+}
+
+// gateArity returns the number of qubits gateType acts on, or 0 if the
+// simulator does not know the gate.
+func gateArity(gateType string) int {
+	if gateType == "swap" {
+		return 2
+	}
+	if c, ok := controlledGates[gateType]; ok {
+		return c.controls + 1
+	}
+	if _, err := singleQubitMatrix(gateType, make([]float64, gateParamCounts[gateType])); err == nil {
+		return 1
+	}
+	return 0
+}
+
+// SimulateStatevector returns the state prepared by circuit from |0…0⟩.
+// Qubit k is bit k of the basis index (little-endian, as in Qiskit), so
+// the result can be compared directly with Qiskit statevectors.
+// Measurements and barriers are ignored; every other gate must be known.
+func SimulateStatevector(circuit *QuantumCircuit) ([]complex128, error) {
+	if circuit == nil {
+		return nil, fmt.Errorf("circuit cannot be nil")
+	}
+	if circuit.NumQubits <= 0 || circuit.NumQubits > maxSimulatedQubits {
+		return nil, fmt.Errorf("can simulate 1 to %d qubits, got %d", maxSimulatedQubits, circuit.NumQubits)
+	}
+	if params := circuit.Parameters(); len(params) > 0 {
+		return nil, fmt.Errorf("circuit has unbound parameters %v", params)
+	}
+
+	state := make([]complex128, 1<<circuit.NumQubits)
+	state[0] = 1
+	for i, gate := range circuit.Gates {
+		if gate.Type == "measure" || gate.Type == "barrier" {
+			continue
+		}
+		arity := gateArity(gate.Type)
+		if arity == 0 {
+			return nil, fmt.Errorf("gate %d: unsupported gate %q", i, gate.Type)
+		}
+		if len(gate.Qubits) != arity {
+			return nil, fmt.Errorf("gate %d: %s acts on %d qubits, got %d", i, gate.Type, arity, len(gate.Qubits))
+		}
+		seen := make(map[int]bool, arity)
+		for _, q := range gate.Qubits {
+			if q < 0 || q >= circuit.NumQubits || seen[q] {
+				return nil, fmt.Errorf("gate %d: invalid qubits %v", i, gate.Qubits)
+			}
+			seen[q] = true
+		}
+
+		switch {
+		case gate.Type == "swap":
+			a, b := gate.Qubits[0], gate.Qubits[1]
+			for idx := range state {
+				if idx>>a&1 == 1 && idx>>b&1 == 0 {
+					partner := idx&^(1<<a) | 1<<b
+					state[idx], state[partner] = state[partner], state[idx]
+				}
+			}
+		default:
+			base, controls := gate.Type, 0
+			if c, ok := controlledGates[gate.Type]; ok {
+				base, controls = c.base, c.controls
+			}
+			m, err := singleQubitMatrix(base, gate.Params)
+			if err != nil {
+				return nil, fmt.Errorf("gate %d: %v", i, err)
+			}
+			applyControlled(state, gate.Qubits[:controls], gate.Qubits[controls], m)
+		}
+	}
+	return state, nil
+}
+
+// applyControlled applies m to target on the basis states where every
+// control qubit is 1.
+func applyControlled(state []complex128, controls []int, target int, m gateMatrix) {
+	mask := 0
+	for _, c := range controls {
+		mask |= 1 << c
+	}
+	bit := 1 << target
+	for idx := range state {
+		if idx&bit != 0 || idx&mask != mask {
+			continue
+		}
+		a0, a1 := state[idx], state[idx|bit]
+		state[idx] = m[0][0]*a0 + m[0][1]*a1
+		state[idx|bit] = m[1][0]*a0 + m[1][1]*a1
+	}
+}
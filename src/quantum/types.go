@@ -3,12 +3,12 @@ package main
 import "time"
 
 type QuantumStateVector struct {
-	Coordinates  []complex128 `json:"coordinates"`
-	Phase        []float64    `json:"phase"`
-	Entanglement float64      `json:"entanglement"`
-	Coherence    float64      `json:"coherence"`
-	StateType    string       `json:"state_type"`
-	Timestamp    time.Time    `json:"timestamp"`
+	Coordinates  Amplitudes `json:"coordinates"`
+	Phase        []float64  `json:"phase"`
+	Entanglement float64    `json:"entanglement"` // entanglement entropy across the middle qubit boundary, normalized to [0, 1]
+	Coherence    float64    `json:"coherence"`
+	StateType    string     `json:"state_type"`
+	Timestamp    time.Time  `json:"timestamp"`
 }
 
 
@@ -19,17 +19,25 @@ type Superposition struct {
 }
 
 // Proof matches your Python‐style proof JSON.
+//
+// Deprecated: a Proof reveals the state it proves. Use SecureProof;
+// LegacyZKP wraps the remaining legacy paths until their sunset.
 type Proof struct {
 	QuantumDimensions int           `json:"quantum_dimensions"`
-	BasisCoefficients [][]float64   `json:"basis_coefficients"`
+	BasisCoefficients Amplitudes    `json:"basis_coefficients"`
 	Amplitudes        []float64     `json:"amplitudes"`
 	Measurements      []Measurement `json:"measurements"`
 	StateMetadata     StateMetadata `json:"state_metadata"`
 	Identifier        string        `json:"identifier"`
 	Signature         string        `json:"signature"`
 	Commitment        string        `json:"commitment"`
+	NumericEncoding   NumericEncoding `json:"numeric_encoding"`
 }
 
+// Measurement is a measurement revealed by a legacy Proof.
+//
+// Deprecated: use SecureProof, whose ChallengeResponse commits to
+// measurements without revealing them.
 type Measurement struct {
 	BasisIndex       int     `json:"basis_index"`
 	Probability      float64 `json:"probability"`
@@ -37,8 +45,17 @@ type Measurement struct {
 	MeasurementBasis string  `json:"measurement_basis"`
 }
 
+// StateMetadata describes the state a legacy Proof was made for.
+// Coherence is the relative entropy of coherence in bits, which for a pure
+// state is the Shannon entropy of its basis distribution, and Entanglement
+// is the entanglement entropy in bits across the middle qubit boundary
+// (see BipartiteEntanglementEntropy). Proofs made before the entropy
+// functions existed reported the Shannon entropy as Entanglement and that
+// entropy divided by the dimension as Coherence; a superposition that is
+// not entangled, such as (|00⟩+|01⟩)/√2, now reports 0 entanglement.
 type StateMetadata struct {
-	Coherence    float64   `json:"coherence"`
-	Entanglement float64   `json:"entanglement"`
-	Timestamp    time.Time `json:"timestamp"`
+	Coherence         float64           `json:"coherence"`
+	Entanglement      float64           `json:"entanglement"`
+	Timestamp         time.Time         `json:"timestamp"`
+	SuperpositionMode SuperpositionMode `json:"superposition_mode,omitempty"`
 }
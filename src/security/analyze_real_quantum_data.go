@@ -20,6 +20,16 @@ type RealQuantumData struct {
 	QuantumHardware bool             `json:"quantum_hardware"`
 }
 
+// executionResult exposes the hardware counts to the ExecutionResult
+// analysis helpers.
+func (d *RealQuantumData) executionResult() *ExecutionResult {
+	return &ExecutionResult{
+		Counts:  d.Counts,
+		Shots:   d.Shots,
+		Backend: d.Backend,
+	}
+}
+
 func main() {
 	fmt.Println("🚀 Simple QZKP Test with Real IBM Quantum Data")
 	fmt.Println("==============================================")
@@ -40,18 +50,27 @@ func main() {
 
 	// Display real quantum measurement results
 	fmt.Printf("\n📊 Real Quantum Measurement Results:\n")
-	total := float64(realData.Shots)
+	result := realData.executionResult()
 	for state, count := range realData.Counts {
-		probability := float64(count) / total
-		fmt.Printf("   |%s⟩: %d shots (%.1f%%)\n", state, count, probability*100)
+		lo, hi, _ := result.ProbabilityInterval(state, 0.95)
+		fmt.Printf("   |%s⟩: %d shots (%.1f%%, 95%% CI %.1f–%.1f%%)\n",
+			state, count, result.Probability(state)*100, lo*100, hi*100)
+	}
+
+	// Compare against the ideal Bell distribution
+	ideal := &ExecutionResult{
+		Counts: map[string]int{"00": realData.Shots / 2, "11": realData.Shots - realData.Shots/2},
+		Shots:  realData.Shots,
 	}
+	fmt.Printf("   Total variation distance from ideal Bell state: %.4f\n", TotalVariationDistance(result, ideal))
+	fmt.Printf("   Hellinger distance from ideal Bell state: %.4f\n", HellingerDistance(result, ideal))
 
 	// Convert real quantum measurements to quantum state vectors
 	quantumStates := convertRealMeasurementsToStates(realData)
 	fmt.Printf("\n🌌 Generated %d quantum states from real hardware data:\n", len(quantumStates))
 
 	for i, state := range quantumStates {
-		fmt.Printf("   State %d: %d amplitudes, norm = %.6f\n", i+1, len(state), calculateNorm(state))
+		fmt.Printf("   State %d: %d amplitudes, norm = %.6f\n", i+1, len(state), Norm(state))
 	}
 
 	// Analyze the quantum properties
@@ -63,7 +82,7 @@ func main() {
 	fmt.Printf("      |01⟩ amplitude: %.3f%+.3fi\n", real(bellState[1]), imag(bellState[1]))
 	fmt.Printf("      |10⟩ amplitude: %.3f%+.3fi\n", real(bellState[2]), imag(bellState[2]))
 	fmt.Printf("      |11⟩ amplitude: %.3f%+.3fi\n", real(bellState[3]), imag(bellState[3]))
-	fmt.Printf("      State norm: %.6f\n", calculateNorm(bellState))
+	fmt.Printf("      State norm: %.6f\n", Norm(bellState))
 
 	// Calculate quantum properties from real data
 	entanglement := calculateEntanglementFromMeasurements(realData)
@@ -109,8 +128,20 @@ func main() {
 	fmt.Printf("🔐 Ready for the world's first QZKP validation with real quantum hardware!\n")
 }
 
+// realQuantumResultsFile holds results written by the IBM Quantum scripts.
+const realQuantumResultsFile = "real_quantum_results.json"
+
 func loadRealQuantumData() (*RealQuantumData, error) {
-	data, err := os.ReadFile("real_quantum_results.json")
+	dirs, err := DefaultDataDirs()
+	if err != nil {
+		return nil, err
+	}
+	path := dirs.DataPath(realQuantumResultsFile)
+	if _, err := MigrateLegacyFile(realQuantumResultsFile, path); err != nil {
+		return nil, fmt.Errorf("failed to migrate real quantum data: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read real quantum data: %v", err)
 	}
@@ -126,11 +157,11 @@ func loadRealQuantumData() (*RealQuantumData, error) {
 func convertRealMeasurementsToStates(data *RealQuantumData) [][]complex128 {
 	var states [][]complex128
 	
-	total := float64(data.Shots)
-	p00 := float64(data.Counts["00"]) / total
-	p01 := float64(data.Counts["01"]) / total
-	p10 := float64(data.Counts["10"]) / total
-	p11 := float64(data.Counts["11"]) / total
+	result := data.executionResult()
+	p00 := result.Probability("00")
+	p01 := result.Probability("01")
+	p10 := result.Probability("10")
+	p11 := result.Probability("11")
 
 	// State 1: Ideal Bell state based on real measurements
 	bellState := []complex128{
@@ -187,33 +218,12 @@ func calculateEntanglementFromMeasurements(data *RealQuantumData) float64 {
 
 func calculateCoherenceFromMeasurements(data *RealQuantumData) float64 {
 	// Coherence measure based on measurement statistics
-	total := float64(data.Shots)
-	p00 := float64(data.Counts["00"]) / total
-	p11 := float64(data.Counts["11"]) / total
+	result := data.executionResult()
+	p00 := result.Probability("00")
+	p11 := result.Probability("11")
 	
 	// Coherence is related to the off-diagonal terms
 	// For a Bell state, we expect high coherence
 	return math.Sqrt(p00 * p11)
 }
 
-func calculateNorm(vector []complex128) float64 {
-	var norm float64
-	for _, c := range vector {
-		norm += real(c)*real(c) + imag(c)*imag(c)
-	}
-	return math.Sqrt(norm)
-}
-
-func normalizeStateVector(vector []complex128) []complex128 {
-	norm := calculateNorm(vector)
-	
-	if norm == 0 {
-		return vector
-	}
-	
-	normalized := make([]complex128, len(vector))
-	for i, c := range vector {
-		normalized[i] = complex(real(c)/norm, imag(c)/norm)
-	}
-	return normalized
-}
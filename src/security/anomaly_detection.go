@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// VerificationDecision is the outcome of one verification request.
+type VerificationDecision string
+
+// Verification decisions reported in VerificationEvents.
+const (
+	DecisionAccepted VerificationDecision = "accepted"
+	DecisionRejected VerificationDecision = "rejected"
+	DecisionReplayed VerificationDecision = "replayed" // valid, but its nonce was already used
+)
+
+// Anomaly kinds flagged by StatisticalAnomalyDetector.
+const (
+	AnomalyFailureBurst = "failure_burst"
+	AnomalyReplays      = "repeated_replays"
+	AnomalyProofSize    = "abnormal_proof_size"
+)
+
+// VerificationEvent describes one verification request for an
+// AnomalyDetector. It carries no proof contents: the identifier is hashed
+// and the key is a fingerprint.
+type VerificationEvent struct {
+	Time           time.Time            `json:"time"`
+	Source         string               `json:"source"`          // e.g. the client address
+	Key            string               `json:"key"`             // fingerprint of the verifying key
+	IdentifierHash string               `json:"identifier_hash"` // SHA-256 of the proof identifier, hex
+	Decision       VerificationDecision `json:"decision"`
+	Latency        time.Duration        `json:"latency"`
+	ProofSize      int                  `json:"proof_size"` // bytes of the proof's JSON encoding
+}
+
+// NewVerificationEvent describes the verification of proof from source by
+// the key with fingerprint key, now.
+func NewVerificationEvent(source, key string, proof *SecureProof, decision VerificationDecision, latency time.Duration) VerificationEvent {
+	event := VerificationEvent{Time: time.Now(), Source: source, Key: key, Decision: decision, Latency: latency}
+	if proof != nil {
+		event.IdentifierHash = sha256Hex([]byte(proof.Identifier))
+		if encoded, err := json.Marshal(proof); err == nil {
+			event.ProofSize = len(encoded)
+		}
+	}
+	return event
+}
+
+// Anomaly is a pattern in verification traffic worth an operator's
+// attention, such as a client probing the verifier.
+type Anomaly struct {
+	Kind   string    `json:"kind"`
+	Source string    `json:"source"`
+	Time   time.Time `json:"time"`
+	Detail string    `json:"detail"`
+}
+
+// AnomalyDetector is fed every verification event and returns the
+// anomalies the event reveals, if any. Implementations must be safe for
+// concurrent use.
+type AnomalyDetector interface {
+	Observe(event VerificationEvent) []Anomaly
+}
+
+// AnomalyOptions tunes StatisticalAnomalyDetector; zero fields take the
+// defaults shown.
+type AnomalyOptions struct {
+	Window       time.Duration // sliding window for bursts; 1 minute
+	FailureBurst int           // rejections from one source within Window; 20
+	Replays      int           // replays from one source within Window; 3
+	// SizeDeviation is how many standard deviations from the mean size of
+	// accepted proofs a proof may be; 4
+	SizeDeviation float64
+	// SizeSamples is how many accepted proofs are seen before sizes are
+	// judged; 30
+	SizeSamples int
+}
+
+// withDefaults fills unset options.
+func (o AnomalyOptions) withDefaults() AnomalyOptions {
+	if o.Window <= 0 {
+		o.Window = time.Minute
+	}
+	if o.FailureBurst <= 0 {
+		o.FailureBurst = 20
+	}
+	if o.Replays <= 0 {
+		o.Replays = 3
+	}
+	if o.SizeDeviation <= 0 {
+		o.SizeDeviation = 4
+	}
+	if o.SizeSamples <= 0 {
+		o.SizeSamples = 30
+	}
+	return o
+}
+
+// StatisticalAnomalyDetector flags bursts of rejections and repeated
+// replays from one source within a sliding window, and proofs whose size
+// is far from the running mean of accepted proofs. Each kind of anomaly
+// is reported at most once per source and window, so a sustained probe
+// does not flood the operator.
+type StatisticalAnomalyDetector struct {
+	mu      sync.Mutex
+	opts    AnomalyOptions
+	sources map[string]*anomalySource
+	pruned  time.Time
+
+	// Welford running statistics of accepted proof sizes
+	sizes    int
+	sizeMean float64
+	sizeM2   float64
+}
+
+// anomalySource is the recent history of one source.
+type anomalySource struct {
+	failures []time.Time
+	replays  []time.Time
+	flagged  map[string]time.Time // anomaly kind to when it was last reported
+	lastSeen time.Time
+}
+
+// NewStatisticalAnomalyDetector creates a detector with opts.
+func NewStatisticalAnomalyDetector(opts AnomalyOptions) *StatisticalAnomalyDetector {
+	return &StatisticalAnomalyDetector{opts: opts.withDefaults(), sources: make(map[string]*anomalySource)}
+}
+
+// Observe implements AnomalyDetector.
+func (d *StatisticalAnomalyDetector) Observe(event VerificationEvent) []Anomaly {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := event.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	d.prune(now)
+	source := d.sources[event.Source]
+	if source == nil {
+		source = &anomalySource{flagged: make(map[string]time.Time)}
+		d.sources[event.Source] = source
+	}
+	source.lastSeen = now
+
+	var anomalies []Anomaly
+	flag := func(kind, format string, args ...interface{}) {
+		if last, ok := source.flagged[kind]; ok && now.Sub(last) < d.opts.Window {
+			return
+		}
+		source.flagged[kind] = now
+		anomalies = append(anomalies, Anomaly{Kind: kind, Source: event.Source, Time: now, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	switch event.Decision {
+	case DecisionRejected:
+		source.failures = appendWithinWindow(source.failures, now, d.opts.Window)
+		if len(source.failures) >= d.opts.FailureBurst {
+			flag(AnomalyFailureBurst, "%d rejected proofs within %s", len(source.failures), d.opts.Window)
+		}
+	case DecisionReplayed:
+		source.replays = appendWithinWindow(source.replays, now, d.opts.Window)
+		if len(source.replays) >= d.opts.Replays {
+			flag(AnomalyReplays, "%d replayed proofs within %s", len(source.replays), d.opts.Window)
+		}
+	}
+
+	if event.ProofSize > 0 {
+		size := float64(event.ProofSize)
+		if d.sizes >= d.opts.SizeSamples {
+			deviation := math.Sqrt(d.sizeM2 / float64(d.sizes-1))
+			if math.Abs(size-d.sizeMean) > d.opts.SizeDeviation*math.Max(deviation, 1) {
+				flag(AnomalyProofSize, "proof of %d bytes against a mean of %.0f ± %.0f", event.ProofSize, d.sizeMean, deviation)
+			}
+		}
+		// Only accepted proofs shape the baseline, so that an attacker
+		// cannot shift it with rejected ones
+		if event.Decision == DecisionAccepted {
+			d.sizes++
+			delta := size - d.sizeMean
+			d.sizeMean += delta / float64(d.sizes)
+			d.sizeM2 += delta * (size - d.sizeMean)
+		}
+	}
+	return anomalies
+}
+
+// prune forgets sources not seen within the last window, at most once per
+// window.
+func (d *StatisticalAnomalyDetector) prune(now time.Time) {
+	if now.Sub(d.pruned) < d.opts.Window {
+		return
+	}
+	d.pruned = now
+	for name, source := range d.sources {
+		if now.Sub(source.lastSeen) >= d.opts.Window {
+			delete(d.sources, name)
+		}
+	}
+}
+
+// appendWithinWindow appends now to times, dropping the times that fell
+// out of the window ending at now.
+func appendWithinWindow(times []time.Time, now time.Time, window time.Duration) []time.Time {
+	kept := times[:0]
+	for _, t := range times {
+		if now.Sub(t) < window {
+			kept = append(kept, t)
+		}
+	}
+	return append(kept, now)
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/sha3"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"time"
+)
+
+// archiveLinkDomain separates custody link signatures from proof and
+// receipt signatures made by the same key.
+const archiveLinkDomain = "qzkp/archive-custody/v1"
+
+// Archive hash suites. A custody link may use a stronger suite than the
+// proof it covers, which is how an archive moves off a weakening hash.
+const (
+	ArchiveHashSHA256   = HashSuiteSHA256
+	ArchiveHashSHA3_512 = "sha3-512"
+)
+
+// DefaultArchiveHashSuite is the suite new custody links use unless the
+// policy names another.
+const DefaultArchiveHashSuite = ArchiveHashSHA3_512
+
+// archiveHashSuites maps the suites a custody link may name to their hash.
+var archiveHashSuites = map[string]func() hash.Hash{
+	ArchiveHashSHA256:   sha256.New,
+	ArchiveHashSHA3_512: func() hash.Hash { return sha3.New512() },
+}
+
+// ArchivedProof is a proof kept for the long term with its chain of
+// custody. The proof keeps its original signature; every re-signing adds a
+// link that signs a digest of the proof and all earlier links, so the
+// newest link vouches for the whole archive even after older keys or hash
+// suites can no longer be trusted.
+type ArchivedProof struct {
+	Proof *SecureProof  `json:"proof"`
+	Chain []CustodyLink `json:"chain"` // oldest first
+}
+
+// CustodyLink is one re-signing of an archived proof.
+type CustodyLink struct {
+	Sequence           int       `json:"sequence"`
+	HashSuite          string    `json:"hash_suite"`
+	Digest             string    `json:"digest"` // of the proof and every earlier link
+	SignedAt           time.Time `json:"signed_at"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	Signer             string    `json:"signer"` // KeyFingerprint of PublicKey
+	PublicKey          string    `json:"public_key"`
+	KeyContext         string    `json:"key_context,omitempty"` // hex of the signer's ML-DSA context
+	Signature          string    `json:"signature"`
+}
+
+// ArchivePolicy controls a re-signing.
+type ArchivePolicy struct {
+	// HashSuite digests the archive for the new link;
+	// DefaultArchiveHashSuite if empty
+	HashSuite string
+	// Rules, when set, must accept the archived proof's header, so an
+	// archivist does not re-sign proofs below its current requirements
+	Rules *Policy
+	Clock func() time.Time // nil uses time.Now
+}
+
+// ReSignProof starts the chain of custody of proof with a link signed by
+// newSigner. The proof itself is not verified; archive proofs that were
+// valid when received.
+func ReSignProof(proof *SecureProof, newSigner *SignatureScheme, policy ArchivePolicy) (*ArchivedProof, error) {
+	if proof == nil {
+		return nil, errors.New("proof cannot be nil")
+	}
+	return (&ArchivedProof{Proof: proof}).ReSign(newSigner, policy)
+}
+
+// ReSign returns a copy of a with a new custody link signed by newSigner,
+// e.g. before the keys or hash suite of the previous link are retired.
+// The existing chain is verified first, so a broken archive is never
+// vouched for.
+func (a *ArchivedProof) ReSign(newSigner *SignatureScheme, policy ArchivePolicy) (*ArchivedProof, error) {
+	if newSigner == nil || newSigner.Priv == nil {
+		return nil, errors.New("re-signing key is required")
+	}
+	if a == nil || a.Proof == nil {
+		return nil, errors.New("archive has no proof")
+	}
+	if len(a.Chain) > 0 {
+		if err := a.verifyChain(); err != nil {
+			return nil, fmt.Errorf("refusing to re-sign: %w", err)
+		}
+	}
+	if policy.Rules != nil {
+		if err := policy.Rules.Evaluate(a.Proof).Err(); err != nil {
+			return nil, err
+		}
+	}
+	suite := policy.HashSuite
+	if suite == "" {
+		suite = DefaultArchiveHashSuite
+	}
+	digest, err := a.digest(suite, len(a.Chain))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now
+	if policy.Clock != nil {
+		now = policy.Clock
+	}
+
+	link := CustodyLink{
+		Sequence:           len(a.Chain),
+		HashSuite:          suite,
+		Digest:             digest,
+		SignedAt:           now().UTC(),
+		SignatureAlgorithm: SignatureAlgorithmMLDSA87,
+		Signer:             KeyFingerprint(newSigner.PublicKeyBytes()),
+		PublicKey:          hex.EncodeToString(newSigner.PublicKeyBytes()),
+		KeyContext:         hex.EncodeToString(newSigner.Ctx),
+	}
+	if n := len(a.Chain); n > 0 && link.SignedAt.Before(a.Chain[n-1].SignedAt) {
+		return nil, errors.New("re-signing time is before the previous link")
+	}
+	msg, err := link.signingMessage()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := newSigner.Sign(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign custody link: %w", err)
+	}
+	link.Signature = hex.EncodeToString(sig)
+
+	chain := append(append([]CustodyLink(nil), a.Chain...), link)
+	return &ArchivedProof{Proof: a.Proof, Chain: chain}, nil
+}
+
+// VerifyArchivedProof walks the chain of custody oldest first, checking
+// every link's digest and signature, and that the newest link was signed
+// by trusted. It vouches for the archive's integrity only; the archived
+// proof is verified, with its original key, by VerifySecureProof.
+func VerifyArchivedProof(archive *ArchivedProof, trusted *SignatureScheme) error {
+	if archive == nil || trusted == nil {
+		return errors.New("archive and trusted key are required")
+	}
+	if err := archive.verifyChain(); err != nil {
+		return err
+	}
+	last := archive.Chain[len(archive.Chain)-1]
+	if last.Signer != KeyFingerprint(trusted.PublicKeyBytes()) {
+		return fmt.Errorf("newest custody link is signed by %s, not the trusted key", last.Signer)
+	}
+	return nil
+}
+
+// verifyChain checks every link against the proof and the links before it.
+func (a *ArchivedProof) verifyChain() error {
+	if a.Proof == nil {
+		return errors.New("archive has no proof")
+	}
+	if len(a.Chain) == 0 {
+		return errors.New("archive has no custody links")
+	}
+	for i, link := range a.Chain {
+		if link.Sequence != i {
+			return fmt.Errorf("custody link %d has sequence %d", i, link.Sequence)
+		}
+		if i > 0 && link.SignedAt.Before(a.Chain[i-1].SignedAt) {
+			return fmt.Errorf("custody link %d predates the link before it", i)
+		}
+		digest, err := a.digest(link.HashSuite, i)
+		if err != nil {
+			return fmt.Errorf("custody link %d: %w", i, err)
+		}
+		if digest != link.Digest {
+			return fmt.Errorf("custody link %d does not match the archive it covers", i)
+		}
+		if err := link.verifySignature(); err != nil {
+			return fmt.Errorf("custody link %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// digest hashes the canonical proof and the first n links under suite.
+func (a *ArchivedProof) digest(suite string, n int) (string, error) {
+	newHash, ok := archiveHashSuites[suite]
+	if !ok {
+		return "", fmt.Errorf("unsupported archive hash suite %q", suite)
+	}
+	hasher := newHash()
+	hasher.Write([]byte(archiveLinkDomain))
+	canonical, err := CanonicalizeValue(a.Proof)
+	if err != nil {
+		return "", fmt.Errorf("failed to canonicalize proof: %w", err)
+	}
+	writeLengthPrefixed(hasher, canonical)
+	for i := 0; i < n; i++ {
+		if canonical, err = CanonicalizeValue(&a.Chain[i]); err != nil {
+			return "", fmt.Errorf("failed to canonicalize custody link: %w", err)
+		}
+		writeLengthPrefixed(hasher, canonical)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// signingMessage is the byte string a custody link's signer signs.
+func (l *CustodyLink) signingMessage() ([]byte, error) {
+	unsigned := *l
+	unsigned.Signature = ""
+	canonical, err := CanonicalizeValue(&unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize custody link: %w", err)
+	}
+	return append([]byte(archiveLinkDomain), canonical...), nil
+}
+
+// verifySignature checks the link's signature under its embedded key.
+func (l *CustodyLink) verifySignature() error {
+	if l.SignatureAlgorithm != SignatureAlgorithmMLDSA87 {
+		return fmt.Errorf("unsupported signature algorithm %q", l.SignatureAlgorithm)
+	}
+	pub, err := hex.DecodeString(l.PublicKey)
+	if err != nil || KeyFingerprint(pub) != l.Signer {
+		return errors.New("signer is not the fingerprint of the embedded key")
+	}
+	ctx, err := hex.DecodeString(l.KeyContext)
+	if err != nil {
+		return errors.New("malformed key context")
+	}
+	verifier, err := NewVerificationScheme(pub, ctx)
+	if err != nil {
+		return err
+	}
+	msg, err := l.signingMessage()
+	if err != nil {
+		return err
+	}
+	sig, err := hex.DecodeString(l.Signature)
+	if err != nil || !verifier.Verify(msg, sig) {
+		return errors.New("signature is invalid")
+	}
+	return nil
+}
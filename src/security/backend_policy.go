@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// maxBackendNameLength bounds backend and vendor names.
+const maxBackendNameLength = 64
+
+// ErrUntrustedBackend is wrapped by every BackendRejectionError.
+var ErrUntrustedBackend = errors.New("untrusted backend")
+
+// BackendRejection names the BackendPolicy rule a backend broke.
+type BackendRejection string
+
+const (
+	BackendNameMalformed    BackendRejection = "name_malformed"
+	BackendNotAllowed       BackendRejection = "not_allowed"
+	BackendDenied           BackendRejection = "denied"
+	BackendDeviceUnknown    BackendRejection = "device_unknown"
+	BackendVendorNotAllowed BackendRejection = "vendor_not_allowed"
+	BackendTooFewQubits     BackendRejection = "too_few_qubits"
+	BackendCalibrationStale BackendRejection = "calibration_stale"
+)
+
+// BackendRejectionError reports a backend a BackendPolicy does not trust.
+type BackendRejectionError struct {
+	Rejection BackendRejection
+	Backend   string
+	Detail    string
+}
+
+// Error implements error.
+func (e *BackendRejectionError) Error() string {
+	return fmt.Sprintf("untrusted backend %q: %s: %s", e.Backend, e.Rejection, e.Detail)
+}
+
+// Unwrap makes errors.Is(err, ErrUntrustedBackend) hold.
+func (e *BackendRejectionError) Unwrap() error { return ErrUntrustedBackend }
+
+// BackendDevice describes the device behind a remote backend as its
+// provider reported it when the job ran. A ProvenanceRecorder signs it
+// into the execution record, so that it can be trusted as far as the
+// recorder is.
+type BackendDevice struct {
+	Vendor       string    `json:"vendor"`
+	Qubits       int       `json:"qubits"`
+	CalibratedAt time.Time `json:"calibrated_at"`
+}
+
+// BackendPolicy restricts the backends a verifier trusts hardware-bound
+// states from. Names are compared exactly, and only names of lower-case
+// ASCII letters, digits, '.', '_' and '-' are accepted at all, so that a
+// look-alike of an allowed name never passes. The device rules need the
+// record to carry a BackendDevice; zero fields do not restrict.
+type BackendPolicy struct {
+	Allow   []string // backend names trusted; empty trusts any not denied
+	Deny    []string // backend names never trusted, even if allowed
+	Vendors []string // device vendors trusted
+	// MinQubits is the smallest device trusted, in qubits
+	MinQubits int
+	// MaxCalibrationAge is how long before the job the device may have
+	// last been calibrated
+	MaxCalibrationAge time.Duration
+}
+
+// Check evaluates the policy against the backend of a provenance record,
+// which the caller must already have verified.
+func (p *BackendPolicy) Check(record *ExecutionProvenance) error {
+	if record == nil {
+		return errors.New("provenance record is required")
+	}
+	reject := func(rejection BackendRejection, format string, args ...interface{}) error {
+		return &BackendRejectionError{Rejection: rejection, Backend: record.Backend, Detail: fmt.Sprintf(format, args...)}
+	}
+
+	if !isCanonicalBackendName(record.Backend) {
+		return reject(BackendNameMalformed, "names must be 1 to %d lower-case letters, digits, '.', '_' or '-'", maxBackendNameLength)
+	}
+	if slices.Contains(p.Deny, record.Backend) {
+		return reject(BackendDenied, "backend is on the deny list")
+	}
+	if len(p.Allow) > 0 && !slices.Contains(p.Allow, record.Backend) {
+		return reject(BackendNotAllowed, "backend is not on the allow list")
+	}
+	if len(p.Vendors) == 0 && p.MinQubits <= 0 && p.MaxCalibrationAge <= 0 {
+		return nil
+	}
+
+	device := record.Device
+	if device == nil {
+		return reject(BackendDeviceUnknown, "record does not describe the device")
+	}
+	if len(p.Vendors) > 0 && (!isCanonicalBackendName(device.Vendor) || !slices.Contains(p.Vendors, device.Vendor)) {
+		return reject(BackendVendorNotAllowed, "vendor %q is not trusted", device.Vendor)
+	}
+	if device.Qubits < p.MinQubits {
+		return reject(BackendTooFewQubits, "device has %d qubits, at least %d are required", device.Qubits, p.MinQubits)
+	}
+	if p.MaxCalibrationAge > 0 {
+		age := record.ReceivedAt.Sub(device.CalibratedAt)
+		switch {
+		case device.CalibratedAt.IsZero():
+			return reject(BackendCalibrationStale, "calibration time is unknown")
+		case age < 0:
+			return reject(BackendCalibrationStale, "calibration at %s is after the job", device.CalibratedAt.Format(time.RFC3339))
+		case age > p.MaxCalibrationAge:
+			return reject(BackendCalibrationStale, "calibrated %s before the job, more than %s", age.Round(time.Second), p.MaxCalibrationAge)
+		}
+	}
+	return nil
+}
+
+// VerifyHardwareBinding checks that a cached state is bound to the
+// hardware it claims: its provenance record is signed by recorder for the
+// state's backend and job (see VerifyCachedStateProvenance), and policy
+// trusts that backend. Rejections by the policy are
+// *BackendRejectionError values.
+func VerifyHardwareBinding(state *CachedQuantumState, recorder *SignatureScheme, authority TimeAuthority, policy *BackendPolicy) error {
+	if err := VerifyCachedStateProvenance(state, recorder, authority); err != nil {
+		return err
+	}
+	if policy == nil {
+		return nil
+	}
+	return policy.Check(state.ProvenanceRecord)
+}
+
+// isCanonicalBackendName reports whether name uses only the characters
+// backend and vendor names are allowed.
+func isCanonicalBackendName(name string) bool {
+	if name == "" || len(name) > maxBackendNameLength {
+		return false
+	}
+	for _, c := range []byte(name) {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '.' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
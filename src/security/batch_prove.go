@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// SecureProveBatch proves knowledge of many independent states, one signed
+// proof per state under the identifier at the same position. It is meant
+// for bulk provers: commitments and signatures are computed in parallel,
+// and the challenge measurements of every state go to sq's
+// MeasurementBackend as a single batch, so an offload backend sees
+// thousands of measurements per call instead of one proof's worth. Each
+// proof is identical in form to one made by SecureProveState.
+//
+// When sq.Rand is set the proofs are made one at a time, so that a
+// deterministic source yields the same proofs on every run. Progress
+// callbacks may be called concurrently.
+//
+// With sq.BatchSignatures set, the proofs share one signature over the
+// Merkle root of the batch; see SignProofBatch. Every proof records the
+// randomness provenance of the whole batch.
+func (sq *SecureQuantumZKP) SecureProveBatch(states []*StateVector, identifiers []string, key []byte) ([]*SecureProof, error) {
+	if len(states) == 0 {
+		return nil, errors.New("at least one state is required")
+	}
+	if len(identifiers) != len(states) {
+		return nil, fmt.Errorf("%d identifiers for %d states", len(identifiers), len(states))
+	}
+	workers := sq.proofWorkers()
+	sq, randomness := sq.withRandomnessTrace()
+
+	proofs, err := sq.buildSecureProofs(states, identifiers, key, func(i int) (publishedIdentifier, error) {
+		return sq.publishIdentifier(identifiers[i])
+	})
+	if err != nil {
+		return nil, err
+	}
+	classes := randomness.Classes()
+	if sq.BatchSignatures {
+		for i, proof := range proofs {
+			proof.DataEncoding = states[i].Encoding()
+			proof.Randomness = classes
+		}
+		if err := sq.SignProofBatch(proofs); err != nil {
+			return nil, err
+		}
+		return proofs, nil
+	}
+	err = forEachParallel(len(proofs), workers, func(i int) error {
+		proofs[i].DataEncoding = states[i].Encoding()
+		proofs[i].Randomness = classes
+		if err := sq.signSecureProof(proofs[i], key); err != nil {
+			return fmt.Errorf("state %d: failed to sign proof: %w", i, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return proofs, nil
+}
+
+// proofWorkers returns how many proofs sq builds at once: one when a
+// deterministic random source must be read in a fixed order.
+func (sq *SecureQuantumZKP) proofWorkers() int {
+	if sq.Rand != nil {
+		return 1
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// buildSecureProofs is buildSecureProof for many states, returning
+// unsigned proofs. Commitments and responses are computed in parallel and
+// all challenges are measured in one backend batch. publish gives the
+// published identifier of state i.
+func (sq *SecureQuantumZKP) buildSecureProofs(
+	states []*StateVector,
+	identifiers []string,
+	key []byte,
+	publish func(i int) (publishedIdentifier, error),
+) ([]*SecureProof, error) {
+	workers := sq.proofWorkers()
+
+	pending := make([]*pendingProof, len(states))
+	err := forEachParallel(len(states), workers, func(i int) error {
+		published, err := publish(i)
+		if err != nil {
+			return fmt.Errorf("state %d: failed to publish identifier: %w", i, err)
+		}
+		if pending[i], err = sq.prepareSecureProof(states[i], identifiers[i], key, published); err != nil {
+			return fmt.Errorf("state %d: %w", i, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &MeasurementBatch{States: make([][]complex128, len(states))}
+	offsets := make([]int, len(states)+1)
+	for i, p := range pending {
+		batch.States[i] = p.state.amplitudes
+		batch.Streaming = batch.Streaming || p.streaming
+		if p.challenges, err = sq.addMeasurementRequests(batch, i, p.challenges); err != nil {
+			return nil, fmt.Errorf("state %d: %w", i, err)
+		}
+		offsets[i+1] = len(batch.Requests)
+	}
+	backend, err := sq.measurementBackend()
+	if err != nil {
+		return nil, err
+	}
+	measurements, err := backend.Measure(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure challenges: %w", err)
+	}
+	if len(measurements) != len(batch.Requests) {
+		return nil, fmt.Errorf("measurement backend returned %d measurements for %d requests",
+			len(measurements), len(batch.Requests))
+	}
+
+	proofs := make([]*SecureProof, len(states))
+	err = forEachParallel(len(states), workers, func(i int) error {
+		proof, err := sq.finishSecureProof(pending[i], measurements[offsets[i]:offsets[i+1]], key)
+		if err != nil {
+			return fmt.Errorf("state %d: %w", i, err)
+		}
+		proofs[i] = proof
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return proofs, nil
+}
+
+// forEachParallel calls f for 0..n-1 on up to workers goroutines and
+// returns the error of the lowest index that failed.
+func forEachParallel(n, workers int, f func(i int) error) error {
+	errs := make([]error, n)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = f(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
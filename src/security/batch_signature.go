@@ -0,0 +1,199 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// batchRootDomain separates batch root signatures from proof, receipt and
+// log root signatures made by the same key.
+const batchRootDomain = "qzkp/batch-root/v1"
+
+// BatchInclusion places a proof in a signature batch: the proof's
+// Signature is then the shared signature over Root, and Path proves that
+// the proof is leaf LeafIndex of the Size-leaf tree under Root. The tree
+// is the RFC 6962 tree of TransparencyLog, over the SHA-256 digests of
+// each proof's signing message.
+type BatchInclusion struct {
+	Root      string   `json:"root"`
+	LeafIndex int      `json:"leaf_index"`
+	Size      int      `json:"size"`
+	Path      []string `json:"path"`
+}
+
+// SignProofBatch signs proofs with a single signature over the Merkle root
+// of their signing messages, so that a bulk prover pays for one ML-DSA
+// signature per batch instead of one per proof. Each proof carries the
+// shared signature and its own inclusion path, and verifies on its own
+// like any other proof; the path adds about 32·log2(len(proofs)) bytes.
+// Existing signatures are replaced; cosignatures are kept, as they do not
+// cover the batch.
+func (sq *SecureQuantumZKP) SignProofBatch(proofs []*SecureProof) error {
+	if len(proofs) == 0 {
+		return errors.New("at least one proof is required")
+	}
+	leaves := make([][]byte, len(proofs))
+	for i, proof := range proofs {
+		if proof == nil {
+			return fmt.Errorf("proof %d is nil", i)
+		}
+		if proof.Version != CurrentProofVersion {
+			return fmt.Errorf("proof %d: version %d proofs cannot be batch signed", i, proof.Version)
+		}
+		proof.Provenance = newProofProvenance(proof)
+		leaf, err := batchLeaf(proof)
+		if err != nil {
+			return fmt.Errorf("proof %d: %w", i, err)
+		}
+		leaves[i] = leaf
+	}
+	root := logTreeHash(leaves)
+
+	sq.reportProgress(ProgressStageSigning, 0, 1)
+	sig, err := sq.Signer.Sign(batchRootMessage(root, len(proofs)))
+	if err != nil {
+		return fmt.Errorf("failed to sign batch root: %w", err)
+	}
+	signature := EncodeHexField(sig)
+	for i, proof := range proofs {
+		proof.Signature = signature
+		proof.Batch = &BatchInclusion{
+			Root:      EncodeHexField(root),
+			LeafIndex: i,
+			Size:      len(proofs),
+			Path:      encodeMerklePath(logInclusionPath(i, leaves)),
+		}
+	}
+	sq.reportProgress(ProgressStageSigning, 1, 1)
+	return nil
+}
+
+// verifyBatchSignature checks that proof is included in its batch and that
+// the batch root carries sq.Signer's signature.
+func (sq *SecureQuantumZKP) verifyBatchSignature(proof *SecureProof) bool {
+	batch := proof.Batch
+	if batch.Size <= 0 || batch.LeafIndex < 0 || batch.LeafIndex >= batch.Size {
+		return false
+	}
+	root, err := decodeDigestField(batch.Root, sha256.Size)
+	if err != nil || len(root) != sha256.Size {
+		return false
+	}
+	path, err := decodeMerklePath(batch.Path)
+	if err != nil {
+		return false
+	}
+	leaf, err := batchLeaf(proof)
+	if err != nil || !verifyLogInclusion(leaf, batch.LeafIndex, batch.Size, path, root) {
+		return false
+	}
+	sig, err := decodeSignatureField(proof.Signature)
+	if err != nil {
+		return false
+	}
+	return sq.Signer.Verify(batchRootMessage(root, batch.Size), sig)
+}
+
+// batchLeaf computes the tree leaf of proof, H(0x00 ‖ SHA-256(message)).
+func batchLeaf(proof *SecureProof) ([]byte, error) {
+	msg, err := secureProofSigningMessage(proof)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(msg)
+	return logLeaf(EncodeHexField(digest[:]))
+}
+
+// batchRootMessage is the byte string a batch signature signs. The size is
+// included so that a signed root cannot be presented as a tree of another
+// shape.
+func batchRootMessage(root []byte, size int) []byte {
+	msg := make([]byte, 0, len(batchRootDomain)+8+len(root))
+	msg = append(msg, batchRootDomain...)
+	msg = binary.BigEndian.AppendUint64(msg, uint64(size))
+	return append(msg, root...)
+}
+
+// BatchSigner collects proofs signed within a time window and signs them
+// together with SignProofBatch, for provers that make proofs one at a time
+// on many goroutines. A batch is signed when its window closes or it
+// reaches MaxBatch proofs, whichever comes first, so a proof waits at most
+// Window for its signature.
+type BatchSigner struct {
+	sq       *SecureQuantumZKP
+	window   time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	pending []*batchRequest
+	timer   *time.Timer
+}
+
+// batchRequest is one proof waiting for its batch to be signed.
+type batchRequest struct {
+	proof *SecureProof
+	done  chan error
+}
+
+// NewBatchSigner returns a BatchSigner that signs with sq.Signer. A
+// maxBatch of zero or less leaves batches limited only by the window.
+func NewBatchSigner(sq *SecureQuantumZKP, window time.Duration, maxBatch int) (*BatchSigner, error) {
+	if sq == nil || sq.Signer == nil {
+		return nil, errors.New("a signing prover is required")
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("batch window must be positive, got %v", window)
+	}
+	return &BatchSigner{sq: sq, window: window, maxBatch: maxBatch}, nil
+}
+
+// Sign adds proof to the current batch and waits until the batch is
+// signed. It is safe for concurrent use.
+func (b *BatchSigner) Sign(proof *SecureProof) error {
+	if proof == nil {
+		return errors.New("proof cannot be nil")
+	}
+	req := &batchRequest{proof: proof, done: make(chan error, 1)}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, req)
+	switch {
+	case b.maxBatch > 0 && len(b.pending) >= b.maxBatch:
+		b.mu.Unlock()
+		b.Flush()
+	case len(b.pending) == 1:
+		b.timer = time.AfterFunc(b.window, b.Flush)
+		b.mu.Unlock()
+	default:
+		b.mu.Unlock()
+	}
+	return <-req.done
+}
+
+// Flush signs the current batch without waiting for its window to close.
+func (b *BatchSigner) Flush() {
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	proofs := make([]*SecureProof, len(pending))
+	for i, req := range pending {
+		proofs[i] = req.proof
+	}
+	err := b.sq.SignProofBatch(proofs)
+	for _, req := range pending {
+		req.done <- err
+	}
+}
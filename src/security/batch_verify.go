@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrBatchRejected is returned by a fail-fast VerifyBatch that stopped at
+// an invalid proof.
+var ErrBatchRejected = errors.New("batch contains an invalid proof")
+
+// batchNotVerified is the reason given for proofs a batch did not reach.
+const batchNotVerified = "not verified: batch stopped early"
+
+// BatchVerifyOptions configures Verifier.VerifyBatch.
+type BatchVerifyOptions struct {
+	Key     []byte // verification key shared by the proofs
+	Workers int    // concurrent verifications; 0 means GOMAXPROCS
+	// FailFast stops the batch at the first invalid proof; otherwise
+	// every proof is verified
+	FailFast bool
+	// Stats, when set, receives the timing statistics of the batch
+	Stats *BatchVerifyStats
+}
+
+// BatchVerifyStats summarizes a batch. Latencies are those of the proofs
+// actually verified.
+type BatchVerifyStats struct {
+	Proofs  int           `json:"proofs"`
+	Valid   int           `json:"valid"`
+	Invalid int           `json:"invalid"`
+	Skipped int           `json:"skipped"` // not reached after an abort or cancellation
+	Wall    time.Duration `json:"wall"`
+	Total   time.Duration `json:"total"` // sum of the per-proof latencies
+	Min     time.Duration `json:"min"`
+	Mean    time.Duration `json:"mean"`
+	P50     time.Duration `json:"p50"`
+	P95     time.Duration `json:"p95"`
+	Max     time.Duration `json:"max"`
+}
+
+// VerifyBatch verifies proofs concurrently on a pool of opts.Workers and
+// returns one result per proof, in input order. Proofs the batch did not
+// reach, because opts.FailFast stopped it at an invalid proof or ctx was
+// cancelled, are invalid with a reason saying so; the error is then
+// ErrBatchRejected, naming the lowest invalid index, or ctx's error. With
+// a cache or nonce store, each proof goes through it as with Verify.
+func (v *Verifier) VerifyBatch(ctx context.Context, proofs []*SecureProof, opts BatchVerifyOptions) ([]VerificationResult, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	results := make([]VerificationResult, len(proofs))
+	latencies := make([]time.Duration, len(proofs))
+	verified := make([]bool, len(proofs))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(proofs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if ctx.Err() != nil {
+					continue
+				}
+				began := time.Now()
+				results[i] = *v.Verify(proofs[i], opts.Key)
+				latencies[i] = time.Since(began)
+				verified[i] = true
+				if opts.FailFast && !results[i].Valid {
+					cancel()
+				}
+			}
+		}()
+	}
+feed:
+	for i := range proofs {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	stats := BatchVerifyStats{Proofs: len(proofs), Wall: time.Since(start)}
+	var measured []time.Duration
+	rejected := -1
+	for i := range results {
+		switch {
+		case !verified[i]:
+			results[i] = VerificationResult{Reasons: []string{batchNotVerified}}
+			stats.Skipped++
+			continue
+		case results[i].Valid:
+			stats.Valid++
+		default:
+			stats.Invalid++
+			if rejected < 0 {
+				rejected = i
+			}
+		}
+		measured = append(measured, latencies[i])
+	}
+	stats.summarize(measured)
+	if opts.Stats != nil {
+		*opts.Stats = stats
+	}
+
+	switch {
+	case opts.FailFast && rejected >= 0:
+		return results, fmt.Errorf("%w: proof %d", ErrBatchRejected, rejected)
+	case stats.Skipped > 0:
+		// Only the caller's context can have stopped a batch without a
+		// rejection
+		return results, context.Cause(ctx)
+	}
+	return results, nil
+}
+
+// summarize fills the latency statistics from the latencies of the
+// verified proofs.
+func (s *BatchVerifyStats) summarize(latencies []time.Duration) {
+	if len(latencies) == 0 {
+		return
+	}
+	slices.Sort(latencies)
+	for _, latency := range latencies {
+		s.Total += latency
+	}
+	s.Min, s.Max = latencies[0], latencies[len(latencies)-1]
+	s.Mean = s.Total / time.Duration(len(latencies))
+	// Nearest-rank percentiles
+	rank := func(p int) time.Duration { return latencies[(p*len(latencies)+99)/100-1] }
+	s.P50, s.P95 = rank(50), rank(95)
+}
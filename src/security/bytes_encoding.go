@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The byte-to-state encoder used by SecureProveFromBytes. Bump the version
+// whenever BytesToState or the dimension rules change, so proofs record
+// which encoding produced their state.
+const (
+	BytesEncoderSHA256Expand = "sha256-expand"
+	BytesEncoderVersion      = 1
+)
+
+// Dimension selection modes recorded in DataEncoding.Selection.
+const (
+	DimensionSelectionAuto     = "auto"
+	DimensionSelectionExplicit = "explicit"
+)
+
+// Automatic dimension selection bounds. Each amplitude accounts for
+// autoDimensionBytesPerAmplitude bytes of input information, and the
+// dimension never exceeds MaxAutoDimension.
+const (
+	MaxAutoDimension               = 256
+	autoDimensionBytesPerAmplitude = 4
+)
+
+// DefaultMaxInputSize bounds the input of SecureProveFromBytes unless
+// BytesProofOptions.MaxInputSize says otherwise.
+const DefaultMaxInputSize = 64 << 20
+
+// Input limit errors of SecureProveFromBytesWithOptions.
+var (
+	ErrInputTooLarge = errors.New("input exceeds the maximum size")
+	ErrTooManyChunks = errors.New("input exceeds the maximum chunk count")
+)
+
+// DataEncoding records how a proof's state was derived from input bytes.
+//
+// Chunks accounts for how much of the input the state represents. A chunk
+// is autoDimensionBytesPerAmplitude bytes of input information, the share
+// AutoDimension gives one amplitude, and a state covers at most one chunk
+// per amplitude. Proofs made before chunk accounting leave the three
+// fields zero.
+type DataEncoding struct {
+	Encoder       string `json:"encoder"`
+	Version       int    `json:"version"`
+	Dimension     int    `json:"dimension"`
+	Selection     string `json:"selection"`
+	InputSize     int    `json:"input_size,omitempty"`     // bytes
+	Chunks        int    `json:"chunks,omitempty"`         // see InputChunks
+	CoveredChunks int    `json:"covered_chunks,omitempty"` // min(Chunks, Dimension)
+}
+
+// InputChunks returns the number of chunks data accounts for: its
+// information, len(data) × H/8 bytes as in AutoDimension, in units of
+// autoDimensionBytesPerAmplitude, and at least one.
+func InputChunks(data []byte) int {
+	information := float64(len(data)) * byteEntropy(data) / 8
+	return max(1, int(math.Ceil(information/autoDimensionBytesPerAmplitude)))
+}
+
+// Coverage returns the fraction of the input's chunks the state accounts
+// for, or 0 for encodings without chunk accounting.
+func (e *DataEncoding) Coverage() float64 {
+	if e.Chunks == 0 {
+		return 0
+	}
+	return float64(e.CoveredChunks) / float64(e.Chunks)
+}
+
+// validate checks the encoding is one this verifier knows and matches the
+// dimension of the proven state.
+func (e *DataEncoding) validate(dimension int) error {
+	if e.Encoder != BytesEncoderSHA256Expand {
+		return fmt.Errorf("unknown encoder %q", e.Encoder)
+	}
+	if e.Version < 1 || e.Version > BytesEncoderVersion {
+		return fmt.Errorf("unsupported encoder version %d", e.Version)
+	}
+	if e.Selection != DimensionSelectionAuto && e.Selection != DimensionSelectionExplicit {
+		return fmt.Errorf("unknown dimension selection %q", e.Selection)
+	}
+	if e.Dimension != dimension {
+		return fmt.Errorf("encoding dimension %d does not match state dimension %d", e.Dimension, dimension)
+	}
+	if e.InputSize == 0 && e.Chunks == 0 && e.CoveredChunks == 0 {
+		return nil
+	}
+	// Information cannot exceed 8 bits per input byte
+	maxChunks := (e.InputSize + autoDimensionBytesPerAmplitude - 1) / autoDimensionBytesPerAmplitude
+	if e.InputSize < 1 || e.Chunks < 1 || e.Chunks > max(1, maxChunks) {
+		return fmt.Errorf("%d chunks are impossible for %d input bytes", e.Chunks, e.InputSize)
+	}
+	if e.CoveredChunks != min(e.Chunks, e.Dimension) {
+		return fmt.Errorf("covered chunks %d do not match %d chunks at dimension %d", e.CoveredChunks, e.Chunks, e.Dimension)
+	}
+	return nil
+}
+
+// BytesProofOptions configures SecureProveFromBytesWithOptions.
+type BytesProofOptions struct {
+	// Dimension is the state dimension, a power of two between 2 and the
+	// maximum proof dimension; 0 selects it with AutoDimension
+	Dimension int
+	// MaxInputSize bounds the input in bytes; 0 means DefaultMaxInputSize
+	MaxInputSize int
+	// MaxChunks bounds the chunks the input accounts for (see
+	// InputChunks); 0 means no bound
+	MaxChunks int
+}
+
+// checkInputLimits enforces the size and chunk limits of opts on data.
+func (opts BytesProofOptions) checkInputLimits(data []byte) error {
+	maxSize := opts.MaxInputSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxInputSize
+	}
+	if len(data) > maxSize {
+		return fmt.Errorf("%w: %d bytes, limit %d", ErrInputTooLarge, len(data), maxSize)
+	}
+	if chunks := InputChunks(data); opts.MaxChunks > 0 && chunks > opts.MaxChunks {
+		return fmt.Errorf("%w: %d chunks, limit %d", ErrTooManyChunks, chunks, opts.MaxChunks)
+	}
+	return nil
+}
+
+// AutoDimension chooses the state dimension for data:
+//
+//   - the input carries len(data) × H/8 bytes of information, where H is
+//     the empirical Shannon entropy of its bytes in bits per byte;
+//   - one amplitude is used per 4 bytes of information, rounded up to a
+//     power of two;
+//   - the result is at least 8, or 16 at security level 256 and above,
+//     and at most MaxAutoDimension.
+//
+// Short or repetitive inputs therefore keep the previous fixed sizes while
+// large, high-entropy inputs get proportionally richer states.
+func (sq *SecureQuantumZKP) AutoDimension(data []byte) int {
+	floor := 8
+	if sq.SecurityLevel >= 256 {
+		floor = 16
+	}
+
+	information := float64(len(data)) * byteEntropy(data) / 8
+	amplitudes := int(math.Ceil(information / autoDimensionBytesPerAmplitude))
+	dimension := floor
+	for dimension < amplitudes && dimension < MaxAutoDimension {
+		dimension *= 2
+	}
+	return dimension
+}
+
+// byteEntropy returns the empirical Shannon entropy of data in bits per
+// byte.
+func byteEntropy(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+	h := 0.0
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / float64(len(data))
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
+
+// SecureProveFromBytesWithOptions encodes data as a quantum state of the
+// chosen dimension and proves knowledge of it. Inputs over the limits of
+// opts are refused with ErrInputTooLarge or ErrTooManyChunks. The proof
+// records the encoder, its version, the dimension and how many of the
+// input's chunks the state covers.
+func (sq *SecureQuantumZKP) SecureProveFromBytesWithOptions(
+	data []byte,
+	identifier string,
+	key []byte,
+	opts BytesProofOptions,
+) (*SecureProof, error) {
+	if err := opts.checkInputLimits(data); err != nil {
+		return nil, err
+	}
+	dimension, selection := opts.Dimension, DimensionSelectionExplicit
+	if dimension == 0 {
+		dimension, selection = sq.AutoDimension(data), DimensionSelectionAuto
+	} else if dimension < 2 || dimension > maxProofDimension {
+		return nil, fmt.Errorf("dimension %d outside [2, %d]", dimension, maxProofDimension)
+	}
+
+	state, err := NewStateVectorFromBytes(data, dimension, selection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert bytes to state: %w", err)
+	}
+	return sq.SecureProveState(state, identifier, key)
+}
+
+// MinInputCoverage requires a proof made from bytes to cover at least
+// minCoverage of its input's chunks (see DataEncoding). Proofs without
+// chunk accounting are rejected.
+func MinInputCoverage(minCoverage float64) Rule {
+	return parameterizedRule("min_input_coverage", minCoverage, func(proof *SecureProof) error {
+		if proof.DataEncoding == nil || proof.DataEncoding.Chunks == 0 {
+			return errors.New("proof does not account for input coverage")
+		}
+		if got := proof.DataEncoding.Coverage(); got < minCoverage {
+			return fmt.Errorf("input coverage %.3f (%d of %d chunks of %d bytes) is below the required %.3f",
+				got, proof.DataEncoding.CoveredChunks, proof.DataEncoding.Chunks, proof.DataEncoding.InputSize, minCoverage)
+		}
+		return nil
+	})
+}
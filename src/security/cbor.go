@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// A minimal CBOR (RFC 8949) codec covering what COSE_Sign1 envelopes use:
+// integers, byte and text strings, arrays, maps, tags and null. Encoding
+// is always definite-length with the shortest argument, i.e. the core
+// deterministic encoding.
+
+// cborMaxDepth bounds nesting when decoding untrusted input.
+const cborMaxDepth = 16
+
+// cborTag is a tagged data item.
+type cborTag struct {
+	Number uint64
+	Value  interface{}
+}
+
+// cborPair is one map entry. Maps keep their entries in order so that an
+// encoded header is reproduced exactly.
+type cborPair struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// cborMap is a CBOR map.
+type cborMap []cborPair
+
+// get returns the value of the entry whose key equals key.
+func (m cborMap) get(key interface{}) (interface{}, bool) {
+	if i := m.index(key); i >= 0 {
+		return m[i].Value, true
+	}
+	return nil, false
+}
+
+// index returns the position of key in m, or -1. Keys must be comparable.
+func (m cborMap) index(key interface{}) int {
+	for i, p := range m {
+		if p.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// cborHead appends a major type and argument in shortest form.
+func cborHead(buf []byte, major byte, arg uint64) []byte {
+	major <<= 5
+	switch {
+	case arg < 24:
+		return append(buf, major|byte(arg))
+	case arg <= math.MaxUint8:
+		return append(buf, major|24, byte(arg))
+	case arg <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, major|25), uint16(arg))
+	case arg <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, major|26), uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, major|27), arg)
+	}
+}
+
+// cborAppend appends the encoding of v. Integers are int or int64, byte
+// strings []byte and text strings string.
+func cborAppend(buf []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, 0xf6), nil
+	case int:
+		return cborAppend(buf, int64(v))
+	case int64:
+		if v < 0 {
+			return cborHead(buf, 1, uint64(-(v + 1))), nil
+		}
+		return cborHead(buf, 0, uint64(v)), nil
+	case uint64:
+		return cborHead(buf, 0, v), nil
+	case []byte:
+		return append(cborHead(buf, 2, uint64(len(v))), v...), nil
+	case string:
+		return append(cborHead(buf, 3, uint64(len(v))), v...), nil
+	case []interface{}:
+		buf = cborHead(buf, 4, uint64(len(v)))
+		for _, item := range v {
+			var err error
+			if buf, err = cborAppend(buf, item); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case cborMap:
+		buf = cborHead(buf, 5, uint64(len(v)))
+		for _, p := range v {
+			var err error
+			if buf, err = cborAppend(buf, p.Key); err != nil {
+				return nil, err
+			}
+			if buf, err = cborAppend(buf, p.Value); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case cborTag:
+		return cborAppend(cborHead(buf, 6, v.Number), v.Value)
+	default:
+		return nil, fmt.Errorf("cbor: cannot encode %T", v)
+	}
+}
+
+// cborEncode returns the encoding of v.
+func cborEncode(v interface{}) ([]byte, error) {
+	return cborAppend(nil, v)
+}
+
+// cborDecode decodes exactly one data item filling data. Integers decode
+// to int64 (or uint64 above math.MaxInt64); indefinite lengths, floats
+// and simple values other than null are rejected.
+func cborDecode(data []byte) (interface{}, error) {
+	d := cborDecoder{data: data}
+	v, err := d.item(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.off != len(data) {
+		return nil, fmt.Errorf("cbor: %d trailing bytes", len(data)-d.off)
+	}
+	return v, nil
+}
+
+type cborDecoder struct {
+	data []byte
+	off  int
+}
+
+var errCBORTruncated = errors.New("cbor: unexpected end of data")
+
+func (d *cborDecoder) head() (byte, uint64, error) {
+	if d.off >= len(d.data) {
+		return 0, 0, errCBORTruncated
+	}
+	b := d.data[d.off]
+	d.off++
+	major, info := b>>5, b&0x1f
+	var size int
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, fmt.Errorf("cbor: unsupported additional information %d", info)
+	}
+	if len(d.data)-d.off < size {
+		return 0, 0, errCBORTruncated
+	}
+	var arg uint64
+	for _, b := range d.data[d.off : d.off+size] {
+		arg = arg<<8 | uint64(b)
+	}
+	d.off += size
+	return major, arg, nil
+}
+
+func (d *cborDecoder) item(depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, errors.New("cbor: nesting too deep")
+	}
+	major, arg, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case 0:
+		if arg > math.MaxInt64 {
+			return arg, nil
+		}
+		return int64(arg), nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return nil, errors.New("cbor: negative integer out of range")
+		}
+		return -1 - int64(arg), nil
+	case 2, 3:
+		if arg > uint64(len(d.data)-d.off) {
+			return nil, errCBORTruncated
+		}
+		s := d.data[d.off : d.off+int(arg)]
+		d.off += int(arg)
+		if major == 3 {
+			return string(s), nil
+		}
+		return append([]byte(nil), s...), nil
+	case 4:
+		// Every item takes at least one byte
+		if arg > uint64(len(d.data)-d.off) {
+			return nil, errCBORTruncated
+		}
+		items := make([]interface{}, arg)
+		for i := range items {
+			if items[i], err = d.item(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	case 5:
+		if arg > uint64(len(d.data)-d.off)/2 {
+			return nil, errCBORTruncated
+		}
+		m := make(cborMap, arg)
+		for i := range m {
+			if m[i].Key, err = d.item(depth + 1); err != nil {
+				return nil, err
+			}
+			// COSE labels are integers or text, which also keeps keys
+			// comparable
+			switch m[i].Key.(type) {
+			case int64, uint64, string:
+			default:
+				return nil, fmt.Errorf("cbor: unsupported map key type %T", m[i].Key)
+			}
+			if m[:i].index(m[i].Key) >= 0 {
+				return nil, fmt.Errorf("cbor: duplicate map key %v", m[i].Key)
+			}
+			if m[i].Value, err = d.item(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case 6:
+		v, err := d.item(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return cborTag{Number: arg, Value: v}, nil
+	default:
+		if d.data[d.off-1] == 0xf6 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cbor: unsupported simple value or float %d", arg)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// falseRejectionBits bounds how often an honest proof may fail the
+// distinct index check: at most once in 2^falseRejectionBits proofs.
+const falseRejectionBits = 40
+
+// ErrInconsistentChallenges is wrapped by every ChallengeConsistencyError.
+var ErrInconsistentChallenges = errors.New("inconsistent challenges")
+
+// ChallengeInconsistency names the consistency rule a proof broke.
+type ChallengeInconsistency string
+
+const (
+	ChallengeDimensionOutOfRange     ChallengeInconsistency = "dimension_out_of_range"
+	ChallengeSecurityLevelOutOfRange ChallengeInconsistency = "security_level_out_of_range"
+	ChallengeSecurityLevelTooLow     ChallengeInconsistency = "security_level_below_verifier"
+	ChallengeIndexOutOfRange         ChallengeInconsistency = "index_out_of_range"
+	ChallengeTooFewDistinctIndices   ChallengeInconsistency = "too_few_distinct_indices"
+)
+
+// ChallengeConsistencyError reports a proof whose challenge indices do not
+// fit its declared dimension and security level.
+type ChallengeConsistencyError struct {
+	Inconsistency ChallengeInconsistency
+	Detail        string
+}
+
+// Error implements error.
+func (e *ChallengeConsistencyError) Error() string {
+	return fmt.Sprintf("inconsistent challenges: %s: %s", e.Inconsistency, e.Detail)
+}
+
+// Unwrap makes errors.Is(err, ErrInconsistentChallenges) hold.
+func (e *ChallengeConsistencyError) Unwrap() error { return ErrInconsistentChallenges }
+
+// CheckChallengeConsistency checks that the challenge indices of proof
+// fit its metadata: the dimension and security level are in the supported
+// range, the security level is at least the one sq is configured for,
+// every index addresses an amplitude of the state, and the indices are not
+// so concentrated that they cannot have been drawn uniformly. It needs no
+// key, so it runs before any cryptographic check.
+func (sq *SecureQuantumZKP) CheckChallengeConsistency(proof *SecureProof) error {
+	inconsistent := func(kind ChallengeInconsistency, format string, args ...interface{}) error {
+		return &ChallengeConsistencyError{Inconsistency: kind, Detail: fmt.Sprintf(format, args...)}
+	}
+
+	metadata := proof.StateMetadata
+	if metadata.Dimension <= 0 || metadata.Dimension > maxProofDimension {
+		return inconsistent(ChallengeDimensionOutOfRange, "dimension %d outside [1, %d]", metadata.Dimension, maxProofDimension)
+	}
+	if metadata.SecurityLevel < minMetadataSecurityLevel || metadata.SecurityLevel > maxMetadataSecurityLevel {
+		return inconsistent(ChallengeSecurityLevelOutOfRange, "security level %d outside [%d, %d]",
+			metadata.SecurityLevel, minMetadataSecurityLevel, maxMetadataSecurityLevel)
+	}
+	if metadata.SecurityLevel < sq.SecurityLevel {
+		return inconsistent(ChallengeSecurityLevelTooLow, "security level %d below the required %d", metadata.SecurityLevel, sq.SecurityLevel)
+	}
+
+	for i, response := range proof.ChallengeResponse {
+		if response.ChallengeIndex < 0 || response.ChallengeIndex >= metadata.Dimension {
+			return inconsistent(ChallengeIndexOutOfRange, "challenge %d has index %d outside [0, %d)", i, response.ChallengeIndex, metadata.Dimension)
+		}
+	}
+	a, err := sq.scratch()
+	if err != nil {
+		return err
+	}
+	defer sq.releaseScratch(a)
+	if distinct, min := a.distinctIndices(proof.ChallengeResponse), minDistinctChallengeIndices(metadata.Dimension, len(proof.ChallengeResponse)); distinct < min {
+		return inconsistent(ChallengeTooFewDistinctIndices, "%d challenges over dimension %d hit %d distinct indices, at least %d expected",
+			len(proof.ChallengeResponse), metadata.Dimension, distinct, min)
+	}
+	return nil
+}
+
+// minDistinctChallengeIndices returns the fewest distinct indices count
+// uniform draws from [0, dimension) hit except with probability below
+// 2^-falseRejectionBits. At most k indices are hit with probability at most
+// C(d, k)·(k/d)^n, so the minimum is one more than the largest k for which
+// that bound is small enough.
+func minDistinctChallengeIndices(dimension, count int) int {
+	if count == 0 {
+		return 0
+	}
+	d, n := float64(dimension), float64(count)
+	lgammaD, _ := math.Lgamma(d + 1)
+	min := 1
+	for k := 1; k < dimension && k < count; k++ {
+		lgammaK, _ := math.Lgamma(float64(k) + 1)
+		lgammaDK, _ := math.Lgamma(d - float64(k) + 1)
+		log2Bound := (lgammaD-lgammaK-lgammaDK)/math.Ln2 + n*math.Log2(float64(k)/d)
+		if log2Bound > -falseRejectionBits {
+			break
+		}
+		min = k + 1
+	}
+	return min
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"time"
+
+	"lukechampine.com/blake3"
+)
+
+// challengeDomain separates challenge derivation from every other use of
+// the published commitment.
+const challengeDomain = "qzkp/challenge-derivation/v1"
+
+// ChallengeEpochDuration is the width of the time window folded into the
+// challenge derivation. Proofs produced within the same window by the same
+// context share an epoch value.
+const ChallengeEpochDuration = time.Hour
+
+// ChallengeEpoch returns the epoch number for the given instant.
+func ChallengeEpoch(t time.Time) uint64 {
+	return uint64(t.Unix()) / uint64(ChallengeEpochDuration/time.Second)
+}
+
+// validChallengeEpoch reports whether proof's epoch is the epoch of its
+// timestamp, so that the challenges are derived for the time the proof
+// claims. The timestamp is not checked against the verifier's clock:
+// archived proofs must keep verifying, and a window would not stop
+// grinding, as the commitment's fresh nonce already gives the prover a new
+// challenge set per attempt. Age limits belong to the MaxAge rule or the
+// replay window.
+func (sq *SecureQuantumZKP) validChallengeEpoch(proof *SecureProof) bool {
+	return proof.Epoch == ChallengeEpoch(proof.Timestamp)
+}
+
+// deriveChallenges expands (commitment ‖ ctx ‖ epoch) into the challenge set
+// using the BLAKE3 XOF, drawing an index, a basis and a nonceLength-byte
+// nonce for each challenge. The nonces carry the entropy of the
+// commitment's random nonce, which the XOF absorbs. Because the prover has
+// to fix the commitment before the challenges exist, it can no longer
+// search for favourable index/basis combinations, and the verifier can
+// recompute the exact same set from the proof alone.
+func (sq *SecureQuantumZKP) deriveChallenges(
+	commitment []byte,
+	epoch uint64,
+	dimension int,
+	numChallenges int,
+	nonceLength int,
+) ([]Challenge, error) {
+	if len(commitment) == 0 {
+		return nil, errors.New("commitment cannot be empty")
+	}
+	if dimension <= 0 {
+		return nil, errors.New("dimension must be positive")
+	}
+	if numChallenges <= 0 {
+		return nil, errors.New("number of challenges must be positive")
+	}
+	challenges := make([]Challenge, numChallenges)
+	nonces := make([]byte, numChallenges*nonceLength)
+	if err := sq.fillChallenges(challenges, nonces, commitment, epoch, dimension, nonceLength); err != nil {
+		return nil, err
+	}
+	return challenges, nil
+}
+
+// fillChallenges derives len(challenges) challenges as deriveChallenges
+// does, slicing their nonces from nonces, which holds nonceLength bytes
+// for each.
+func (sq *SecureQuantumZKP) fillChallenges(challenges []Challenge, nonces []byte, commitment []byte, epoch uint64, dimension, nonceLength int) error {
+	if len(commitment) == 0 {
+		return errors.New("commitment cannot be empty")
+	}
+	if dimension <= 0 {
+		return errors.New("dimension must be positive")
+	}
+	if len(nonces) != len(challenges)*nonceLength {
+		return errors.New("nonce buffer does not match the challenges")
+	}
+
+	var hasher *blake3.Hasher
+	var contextBytes []byte
+	if p := sq.precomputed; p != nil {
+		// Hasher holds no references, so a copy continues independently
+		if sq.arena != nil {
+			hasher = &sq.arena.xof
+		} else {
+			hasher = new(blake3.Hasher)
+		}
+		*hasher, contextBytes = p.challengeHasher, p.contextBytes
+	} else {
+		hasher, contextBytes = newChallengeHasher(), sq.Context.Bytes()
+	}
+	writeChallengeSeed(hasher, commitment, contextBytes, epoch, dimension)
+	xof := hasher.XOF()
+
+	// Rejection sampling keeps the index distribution uniform
+	limit := ^uint64(0) - (^uint64(0) % uint64(dimension))
+
+	// Reads go through an interface, so the buffers escape; declaring them
+	// once keeps that to one allocation per derivation
+	var word [8]byte
+	var basis [1]byte
+	for i := range challenges {
+		var index uint64
+		for {
+			if _, err := xof.Read(word[:]); err != nil {
+				return err
+			}
+			index = binary.BigEndian.Uint64(word[:])
+			if index < limit {
+				break
+			}
+		}
+
+		if _, err := xof.Read(basis[:]); err != nil {
+			return err
+		}
+
+		nonce := nonces[i*nonceLength : (i+1)*nonceLength : (i+1)*nonceLength]
+		if _, err := xof.Read(nonce); err != nil {
+			return err
+		}
+
+		challenges[i] = Challenge{
+			Index:     int(index % uint64(dimension)),
+			BasisType: challengeBasis(basis[0]),
+			Nonce:     nonce,
+		}
+	}
+
+	return nil
+}
+
+// newChallengeHasher returns the challenge-derivation hash with
+// challengeDomain absorbed.
+func newChallengeHasher() *blake3.Hasher {
+	hasher := blake3.New(32, nil)
+	hasher.Write([]byte(challengeDomain))
+	return hasher
+}
+
+// writeChallengeSeed writes the challenge-derivation input that follows
+// challengeDomain: commitment ‖ ctx ‖ epoch ‖ dimension.
+func writeChallengeSeed(w io.Writer, commitment, contextBytes []byte, epoch uint64, dimension int) {
+	// One buffer for every number keeps hashing the seed to one allocation
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(len(commitment)))
+	w.Write(buf[:])
+	w.Write(commitment)
+	binary.BigEndian.PutUint64(buf[:], uint64(len(contextBytes)))
+	w.Write(buf[:])
+	w.Write(contextBytes)
+	binary.BigEndian.PutUint64(buf[:], epoch)
+	w.Write(buf[:])
+	binary.BigEndian.PutUint64(buf[:], uint64(dimension))
+	w.Write(buf[:])
+}
+
+// verifyDerivedChallenges checks that every response answers the challenge
+// the verifier derives independently from the proof's commitment.
+func (sq *SecureQuantumZKP) verifyDerivedChallenges(proof *SecureProof) bool {
+	if len(proof.ChallengeResponse) != sq.ChallengeCount() || !sq.validChallengeEpoch(proof) {
+		return false
+	}
+
+	a, err := sq.scratch()
+	if err != nil {
+		return false
+	}
+	defer sq.releaseScratch(a)
+	commitment, err := decodeHexFieldInto(a.commitment, proof.CommitmentHash, 1, len(proof.CommitmentHash)/2)
+	if err != nil {
+		return false
+	}
+	a.commitment = commitment
+
+	challenges, err := a.deriveChallenges(
+		sq,
+		commitment,
+		proof.Epoch,
+		proof.StateMetadata.Dimension,
+		len(proof.ChallengeResponse),
+		proof.challengeNonceLength(),
+	)
+	if err != nil {
+		return false
+	}
+
+	return a.matchChallenges(proof.ChallengeResponse, challenges, commitment, proof.ChallengeBinding)
+}
+
+// challengeBindingDomain separates challenge bindings from other digests.
+const challengeBindingDomain = "qzkp/challenge-binding/v1"
+
+// challengeBinding commits a response set to the full challenges it
+// answers. Each position contributes its index, basis and nonce together
+// with the Merkle leaf of the response given there, so a bound response
+// cannot be moved, answer another challenge or be copied into a proof with
+// a different commitment. The verifier derives the same value from the
+// recomputed challenge set.
+func challengeBinding(commitment []byte, challenges []Challenge, responses []ChallengeResponse) (string, error) {
+	hasher := sha256.New()
+	if err := writeChallengeBinding(hasher, commitment, challenges, responses); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// writeChallengeBinding writes the input hashed by challengeBinding: the
+// domain, the commitment, the challenge count and, for each position, the
+// challenge index, basis label and nonce and the response's Merkle leaf.
+func writeChallengeBinding(w io.Writer, commitment []byte, challenges []Challenge, responses []ChallengeResponse) error {
+	if len(challenges) != len(responses) {
+		return errors.New("challenge and response counts differ")
+	}
+	w.Write([]byte(challengeBindingDomain))
+	writeLengthPrefixed(w, commitment)
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(len(challenges)))
+	w.Write(buf[:])
+	for i, challenge := range challenges {
+		binary.BigEndian.PutUint64(buf[:], uint64(challenge.Index))
+		w.Write(buf[:])
+		writeLengthPrefixed(w, []byte(challenge.BasisType))
+		writeLengthPrefixed(w, challenge.Nonce)
+		w.Write(responseLeaf(responses[i]))
+	}
+	return nil
+}
+
+// matchChallenges checks that responses answer challenges one-to-one, in
+// order, and that binding, when set, commits to exactly these challenges.
+// Proofs made before bindings existed carry none.
+func matchChallenges(responses []ChallengeResponse, challenges []Challenge, commitment []byte, binding string) bool {
+	a := getVerificationArena()
+	defer a.release()
+	return a.matchChallenges(responses, challenges, commitment, binding)
+}
+
+// writeLengthPrefixed writes len(data) followed by data so that adjacent
+// variable-length fields cannot be shifted into one another.
+func writeLengthPrefixed(hasher io.Writer, data []byte) {
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(data)))
+	hasher.Write(length[:])
+	hasher.Write(data)
+}
@@ -0,0 +1,60 @@
+package main
+
+import "fmt"
+
+// Per-challenge nonce lengths in bytes. Non-interactive nonces are drawn
+// from the challenge XOF and interactive ones by the verifier from its
+// randomness source; either way every challenge gets its own.
+const (
+	MinChallengeNonceLength     = 16
+	MaxChallengeNonceLength     = 32
+	DefaultChallengeNonceLength = 16
+	// LegacyChallengeNonceLength is the length used by proofs that do
+	// not record theirs. Provers configured with it leave the length out,
+	// so that verifiers of earlier releases accept their proofs
+	LegacyChallengeNonceLength = 4
+)
+
+// validateChallengeNonceLength checks n is LegacyChallengeNonceLength or
+// within [MinChallengeNonceLength, MaxChallengeNonceLength].
+func validateChallengeNonceLength(n int) error {
+	if n != LegacyChallengeNonceLength && (n < MinChallengeNonceLength || n > MaxChallengeNonceLength) {
+		return fmt.Errorf("challenge nonce length %d outside [%d, %d]", n, MinChallengeNonceLength, MaxChallengeNonceLength)
+	}
+	return nil
+}
+
+// challengeNonceLength returns the nonce length sq gives its challenges.
+func (sq *SecureQuantumZKP) challengeNonceLength() int {
+	if sq.ChallengeNonceLength == 0 {
+		return DefaultChallengeNonceLength
+	}
+	return sq.ChallengeNonceLength
+}
+
+// recordedChallengeNonceLength returns the nonce length proofs made by sq
+// record: none for the legacy length.
+func (sq *SecureQuantumZKP) recordedChallengeNonceLength() int {
+	if n := sq.challengeNonceLength(); n != LegacyChallengeNonceLength {
+		return n
+	}
+	return 0
+}
+
+// challengeNonceLength returns the nonce length the proof declares, or
+// the legacy length for proofs that did not record it.
+func (proof *SecureProof) challengeNonceLength() int {
+	if proof.ChallengeNonceLength == 0 {
+		return LegacyChallengeNonceLength
+	}
+	return proof.ChallengeNonceLength
+}
+
+// parametersFor returns sq's parameters at the challenge nonce length
+// proof was made with, which verifiers take from the proof so that
+// proofs from before the length was configurable keep verifying.
+func (sq *SecureQuantumZKP) parametersFor(proof *SecureProof) Parameters {
+	params := sq.Parameters()
+	params.ChallengeNonceLength = proof.challengeNonceLength()
+	return params
+}
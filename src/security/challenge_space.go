@@ -0,0 +1,67 @@
+package main
+
+import "fmt"
+
+// Basis labels. A challenge measures in the computational basis (Z) or
+// the Hadamard basis (X).
+const (
+	basisZ = "Z"
+	basisX = "X"
+)
+
+// challengeBases are the measurement bases a challenge may select.
+var challengeBases = []string{basisZ, basisX}
+
+// ChallengeCount returns the number of challenges a proof needs to reach
+// sq.SecurityParameter bits of soundness, or 0 for an invalid security
+// parameter. A prover that cannot answer both bases passes a challenge
+// with probability at most 1/2, so each challenge is worth one bit.
+func (sq *SecureQuantumZKP) ChallengeCount() int {
+	count, err := sq.challengeCount()
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// challengeCount returns the number of challenges a proof needs, or why
+// sq's soundness configuration cannot be met.
+func (sq *SecureQuantumZKP) challengeCount() (int, error) {
+	if sq.SecurityParameter <= 0 {
+		return 0, fmt.Errorf("security parameter must be positive, got %d", sq.SecurityParameter)
+	}
+	return sq.SecurityParameter, nil
+}
+
+// EffectiveSoundness returns the soundness in bits of a proof made by sq,
+// one bit per challenge.
+func (sq *SecureQuantumZKP) EffectiveSoundness() int {
+	return sq.ChallengeCount()
+}
+
+// proofSoundness returns the soundness a proof reports, or one bit per
+// response for proofs made before they reported it.
+func proofSoundness(proof *SecureProof) int {
+	if proof.StateMetadata.SoundnessBits != 0 {
+		return proof.StateMetadata.SoundnessBits
+	}
+	return len(proof.ChallengeResponse)
+}
+
+// challengeBasis returns the basis selected by the low bit of b.
+func challengeBasis(b byte) string {
+	if b&1 == 1 {
+		return basisX
+	}
+	return basisZ
+}
+
+// answers reports whether r answers challenge c.
+func (r ChallengeResponse) answers(c Challenge) bool {
+	return r.ChallengeIndex == c.Index && r.BasisChoice == c.BasisType
+}
+
+// validBasis reports whether basis names a challenge basis.
+func validBasis(basis string) bool {
+	return basis == basisZ || basis == basisX
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Limits on the claims a proof may carry. Claims are small labels, not a
+// payload; anything larger belongs behind a digest.
+const (
+	MaxClaims           = 16
+	MaxClaimKeyLength   = 64
+	MaxClaimValueLength = 256
+)
+
+// Well-known claim keys.
+const (
+	ClaimPurpose      = "purpose"
+	ClaimAudience     = "audience"
+	ClaimDocumentType = "document_type"
+)
+
+// claimKeyCharset lists the characters allowed in claim keys.
+const claimKeyCharset = "abcdefghijklmnopqrstuvwxyz0123456789_-."
+
+// ErrInvalidClaims is wrapped by every claim validation error.
+var ErrInvalidClaims = errors.New("invalid claims")
+
+// ValidateClaims checks claims against the size limits. Keys are lower
+// case ASCII; values follow the identifier character rules, so a claim can
+// be logged as safely as an identifier.
+func ValidateClaims(claims map[string]string) error {
+	if len(claims) > MaxClaims {
+		return fmt.Errorf("%w: %d claims exceed the limit of %d", ErrInvalidClaims, len(claims), MaxClaims)
+	}
+	for _, key := range sortedClaimKeys(claims) {
+		if key == "" || len(key) > MaxClaimKeyLength || strings.Trim(key, claimKeyCharset) != "" {
+			return fmt.Errorf("%w: key %q must be 1 to %d characters of %q", ErrInvalidClaims, key, MaxClaimKeyLength, claimKeyCharset)
+		}
+		value := claims[key]
+		if len(value) > MaxClaimValueLength {
+			return fmt.Errorf("%w: value of %q is %d bytes, limit %d", ErrInvalidClaims, key, len(value), MaxClaimValueLength)
+		}
+		if err := checkIdentifierCharacters(value); err != nil {
+			return fmt.Errorf("%w: value of %q: %v", ErrInvalidClaims, key, err)
+		}
+	}
+	return nil
+}
+
+// sortedClaimKeys returns the claim keys in their canonical order. The
+// signed encoding orders map keys the same way, so two provers with the
+// same claims sign the same bytes.
+func sortedClaimKeys(claims map[string]string) []string {
+	keys := make([]string, 0, len(claims))
+	for key := range claims {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// copyClaims returns a copy of claims, or nil when there are none.
+func copyClaims(claims map[string]string) map[string]string {
+	if len(claims) == 0 {
+		return nil
+	}
+	copied := make(map[string]string, len(claims))
+	for key, value := range claims {
+		copied[key] = value
+	}
+	return copied
+}
+
+// WithClaims returns a copy of sq that records claims in the proofs it
+// creates, leaving sq's own claims in place.
+func (sq *SecureQuantumZKP) WithClaims(claims map[string]string) *SecureQuantumZKP {
+	copied := *sq
+	copied.Claims = copyClaims(claims)
+	return &copied
+}
+
+// RequireClaim requires the proof to carry key, with one of allowed as its
+// value when any are given.
+func RequireClaim(key string, allowed ...string) Rule {
+	return parameterizedRule("required_claim", []interface{}{key, allowed}, func(proof *SecureProof) error {
+		value, ok := proof.Claims[key]
+		if !ok {
+			return fmt.Errorf("claim %q is missing", key)
+		}
+		if len(allowed) == 0 {
+			return nil
+		}
+		return requireOneOf("claim "+key, value, allowed)
+	})
+}
+
+// AllowedClaimKeys rejects proofs carrying a claim outside keys, so a
+// verifier never acts on a claim it does not understand.
+func AllowedClaimKeys(keys ...string) Rule {
+	return parameterizedRule("allowed_claim_keys", keys, func(proof *SecureProof) error {
+		for _, key := range sortedClaimKeys(proof.Claims) {
+			if err := requireOneOf("claim key", key, keys); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mrand "math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrProofSignatureInvalid is returned when a proof returned by the service
+// fails local signature pre-validation.
+var ErrProofSignatureInvalid = errors.New("proof signature does not match the service key")
+
+// APIError is a non-success response from the proving service.
+type APIError struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("qzkp service returned %d: %s", e.StatusCode, e.Message)
+}
+
+// Retryable reports whether the request may succeed if repeated.
+func (e *APIError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// defaultMaxBackoff is the longest wait between retries of a client whose
+// MaxBackoff is not positive.
+const defaultMaxBackoff = 5 * time.Second
+
+// Client talks to a qzkpd REST endpoint. Requests are retried with
+// exponential backoff and full jitter; the context deadline is forwarded so
+// the server stops working on abandoned requests.
+type Client struct {
+	BaseURL     string
+	HTTPClient  *http.Client
+	MaxRetries  int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	Compress    bool // gzip request bodies
+
+	infoMu   sync.Mutex
+	verifier *SecureQuantumZKP
+}
+
+// NewClient creates a client with default retry settings.
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:     strings.TrimRight(baseURL, "/"),
+		HTTPClient:  &http.Client{},
+		MaxRetries:  3,
+		BaseBackoff: 100 * time.Millisecond,
+		MaxBackoff:  defaultMaxBackoff,
+		Compress:    true,
+	}
+}
+
+// Info fetches the service's public verification parameters.
+func (c *Client) Info(ctx context.Context) (*ServiceInfo, error) {
+	var info ServiceInfo
+	if err := c.do(ctx, http.MethodGet, "/v1/info", nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Prove requests a proof and pre-validates its signature and context
+// against the service's published key before returning it.
+func (c *Client) Prove(ctx context.Context, identifier string, data []byte) (*SecureProof, error) {
+	var resp ProveResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/prove", &ProveRequest{Identifier: identifier, Data: data}, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Proof == nil {
+		return nil, errors.New("service returned no proof")
+	}
+	if err := c.PreValidate(ctx, resp.Proof); err != nil {
+		return nil, err
+	}
+	return resp.Proof, nil
+}
+
+// Verify asks the service to fully verify proof.
+func (c *Client) Verify(ctx context.Context, proof *SecureProof) (bool, error) {
+	var resp VerifyResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/verify", &VerifyRequest{Proof: proof}, &resp); err != nil {
+		return false, err
+	}
+	return resp.Valid, nil
+}
+
+// PreValidate checks the proof signature and context locally. It cannot
+// check the key-dependent parts of the proof, which only the service can.
+func (c *Client) PreValidate(ctx context.Context, proof *SecureProof) error {
+	verifier, err := c.verifierFor(ctx)
+	if err != nil {
+		return err
+	}
+	if !proof.Context.Equal(verifier.Context) || !verifier.verifySecureProofSignature(proof) {
+		return ErrProofSignatureInvalid
+	}
+	return nil
+}
+
+// verifierFor returns a signature verifier for the service key, fetching
+// and caching the service info on first use.
+func (c *Client) verifierFor(ctx context.Context) (*SecureQuantumZKP, error) {
+	c.infoMu.Lock()
+	defer c.infoMu.Unlock()
+	if c.verifier != nil {
+		return c.verifier, nil
+	}
+
+	info, err := c.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch service info: %w", err)
+	}
+	pub, err := hex.DecodeString(info.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service public key: %w", err)
+	}
+	signer, err := NewVerificationScheme(pub, info.Context.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	c.verifier = &SecureQuantumZKP{QuantumZKP: &QuantumZKP{Signer: signer}, Context: info.Context}
+	return c.verifier, nil
+}
+
+// do performs one API call with retries.
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = encodeRequestBody(in, c.Compress); err != nil {
+			return err
+		}
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := c.sleep(ctx, attempt, lastErr); err != nil {
+				return err
+			}
+		}
+
+		lastErr = c.attempt(ctx, method, path, body, out)
+		if lastErr == nil {
+			return nil
+		}
+		var apiErr *APIError
+		if errors.As(lastErr, &apiErr) && !apiErr.Retryable() {
+			return lastErr
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", c.MaxRetries+1, lastErr)
+}
+
+// attempt sends a single request.
+func (c *Client) attempt(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+		if c.Compress {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline).Milliseconds(); remaining > 0 {
+			req.Header.Set(TimeoutHeader, strconv.FormatInt(remaining, 10))
+		}
+	}
+
+	// Leaving Accept-Encoding unset lets the transport negotiate gzip and
+	// decompress the response transparently
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		apiErr := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			apiErr.RetryAfter = time.Duration(secs) * time.Second
+		}
+		return apiErr
+	}
+	// Responses carry at most a proof, but may hold more fields per object
+	limits := JSONLimits{MaxBytes: ProofJSONLimits.MaxBytes}
+	if err := DecodeBoundedJSON(resp.Body, limits, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// sleep waits a full-jitter exponential backoff, at least as long as any
+// Retry-After the server asked for. Neither waits longer than MaxBackoff,
+// so a server cannot stall the client with a large Retry-After.
+func (c *Client) sleep(ctx context.Context, attempt int, lastErr error) error {
+	maxBackoff := c.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+	ceiling := c.BaseBackoff << uint(attempt-1)
+	if ceiling > maxBackoff || ceiling <= 0 {
+		ceiling = maxBackoff
+	}
+	wait := time.Duration(mrand.Int63n(int64(ceiling) + 1))
+
+	var apiErr *APIError
+	if errors.As(lastErr, &apiErr) && apiErr.RetryAfter > wait {
+		wait = apiErr.RetryAfter
+		if wait > maxBackoff {
+			wait = maxBackoff
+		}
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// encodeRequestBody marshals v, optionally gzip-compressed.
+func encodeRequestBody(v interface{}, compress bool) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if !compress {
+		return data, nil
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
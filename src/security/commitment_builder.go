@@ -0,0 +1,168 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// chunkCommitmentDomain separates chunk commitments from state
+// commitments made under the same key.
+const chunkCommitmentDomain = "qzkp/chunk-commitment/v1"
+
+// ChunkedCommitment commits to a sequence of chunk states: the RFC 6962
+// Merkle root of one hiding commitment per chunk, in order.
+type ChunkedCommitment struct {
+	Root   string `json:"root"`
+	Chunks int    `json:"chunks"`
+}
+
+// CommitmentBuilder commits to the states of an input's chunks as they
+// are produced, without holding them: each chunk state is hashed into a
+// leaf as it is added, and only the roots of the complete subtrees seen so
+// far are kept, at most one per level of the tree. Memory is O(log n) in
+// the number of chunks however large the input.
+type CommitmentBuilder struct {
+	sq         *SecureQuantumZKP
+	identifier string
+	key        []byte // context key
+	nonce      []byte // hides every leaf, like the state commitment nonce
+	// frontier[i] is the root of a complete subtree of 2^i leaves, or nil;
+	// the set levels are the binary digits of count
+	frontier  [][]byte
+	count     uint64
+	finalized bool
+}
+
+// NewCommitmentBuilder starts a chunked commitment bound to identifier,
+// sq's context and key.
+func (sq *SecureQuantumZKP) NewCommitmentBuilder(identifier string, key []byte) (*CommitmentBuilder, error) {
+	contextKey, err := sq.contextKey(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, commitmentNonceLength)
+	if err := sq.readRandom(nonce); err != nil {
+		return nil, err
+	}
+	return &CommitmentBuilder{sq: sq, identifier: identifier, key: contextKey, nonce: nonce}, nil
+}
+
+// Add commits to the next chunk's state. The state is not retained.
+func (b *CommitmentBuilder) Add(chunk *StateVector) error {
+	if b.finalized {
+		return errors.New("commitment builder is finalized")
+	}
+	if chunk == nil {
+		return errors.New("chunk state is nil")
+	}
+	node, err := b.leaf(b.count, chunk)
+	if err != nil {
+		return err
+	}
+	// Carry the new leaf up through the complete subtrees of equal size,
+	// like incrementing a binary counter
+	level := 0
+	for ; b.count&(1<<level) != 0; level++ {
+		node = logNode(b.frontier[level], node)
+		b.frontier[level] = nil
+	}
+	if level == len(b.frontier) {
+		b.frontier = append(b.frontier, nil)
+	}
+	b.frontier[level] = node
+	b.count++
+	return nil
+}
+
+// Chunks returns how many chunk states have been added.
+func (b *CommitmentBuilder) Chunks() int {
+	return int(b.count)
+}
+
+// Finalize returns the commitment to the chunks added so far, which is the
+// RFC 6962 tree hash of their leaves, and clears the builder's secrets.
+// No chunk can be added afterwards.
+func (b *CommitmentBuilder) Finalize() (*ChunkedCommitment, error) {
+	if b.finalized {
+		return nil, errors.New("commitment builder is finalized")
+	}
+	if b.count == 0 {
+		return nil, errors.New("no chunks were added")
+	}
+	b.finalized = true
+	defer clear(b.key)
+	defer clear(b.nonce)
+
+	// The smallest complete subtree is the rightmost; RFC 6962 places each
+	// larger one to its left
+	var root []byte
+	for _, node := range b.frontier {
+		switch {
+		case node == nil:
+		case root == nil:
+			root = node
+		default:
+			root = logNode(node, root)
+		}
+	}
+	return &ChunkedCommitment{Root: hex.EncodeToString(root), Chunks: int(b.count)}, nil
+}
+
+// leaf is the Merkle leaf committing to chunk at index.
+func (b *CommitmentBuilder) leaf(index uint64, chunk *StateVector) ([]byte, error) {
+	hasher := sha256.New()
+	hasher.Write([]byte(chunkCommitmentDomain))
+	binary.Write(hasher, binary.BigEndian, index)
+	if err := chunk.writeAmplitudes(hasher, b.sq.NumericEncoding); err != nil {
+		return nil, err
+	}
+	writeLengthPrefixed(hasher, []byte(b.identifier))
+	writeLengthPrefixed(hasher, b.sq.Context.Bytes())
+	hasher.Write(b.key)
+	hasher.Write(b.nonce)
+	commitment := hasher.Sum(nil)
+
+	// Domain-separated from interior nodes as in RFC 6962
+	leaf := sha256.New()
+	leaf.Write([]byte{0x00})
+	leaf.Write(commitment)
+	return leaf.Sum(nil), nil
+}
+
+// CommitChunks reads r in chunks of chunkSize bytes, encodes each with
+// NewStateVectorFromBytes at dimension and commits to them in order. Only
+// one chunk is held at a time.
+func (sq *SecureQuantumZKP) CommitChunks(r io.Reader, chunkSize, dimension int, identifier string, key []byte) (*ChunkedCommitment, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("chunk size %d must be positive", chunkSize)
+	}
+	builder, err := sq.NewCommitmentBuilder(identifier, key)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, chunkSize)
+	defer clear(buf)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			chunk, encodeErr := NewStateVectorFromBytes(buf[:n], dimension, DimensionSelectionExplicit)
+			if encodeErr != nil {
+				return nil, fmt.Errorf("chunk %d: %w", builder.Chunks(), encodeErr)
+			}
+			if err := builder.Add(chunk); err != nil {
+				return nil, err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return builder.Finalize()
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ComplianceAlgorithm is one cryptographic algorithm a configuration uses.
+type ComplianceAlgorithm struct {
+	Purpose   string `json:"purpose"`
+	Algorithm string `json:"algorithm"`
+	Standard  string `json:"standard"`
+	// SecurityBits is the classical security strength the algorithm
+	// provides as used, e.g. limited by digest truncation
+	SecurityBits int    `json:"security_bits"`
+	Approved     bool   `json:"approved"` // NIST-approved for this purpose
+	Note         string `json:"note,omitempty"`
+}
+
+// ComplianceKey is one key or key-like parameter of a configuration.
+type ComplianceKey struct {
+	Name  string `json:"name"`
+	Bytes int    `json:"bytes"`
+	Note  string `json:"note,omitempty"`
+}
+
+// ComplianceEntropySource is one randomness source a configuration draws
+// nonces and salts from.
+type ComplianceEntropySource struct {
+	Class   string `json:"class"`
+	Healthy bool   `json:"healthy"`
+	Note    string `json:"note,omitempty"`
+}
+
+// ComplianceSoundness describes the soundness of the configured proofs.
+type ComplianceSoundness struct {
+	SecurityLevel          int `json:"security_level"`
+	SoundnessBits          int `json:"soundness_bits"`
+	EffectiveSoundnessBits int `json:"effective_soundness_bits"`
+	ChallengeCount         int `json:"challenge_count"`
+}
+
+// ComplianceReport describes the cryptography of a running configuration
+// for certification and security questionnaires. It is a description,
+// not a certification: Findings lists what an assessor should look at.
+type ComplianceReport struct {
+	Generated      time.Time                 `json:"generated"`
+	Library        string                    `json:"library"`
+	ProofVersion   int                       `json:"proof_version"`
+	Context        string                    `json:"context"`
+	Profile        string                    `json:"profile,omitempty"`
+	Algorithms     []ComplianceAlgorithm     `json:"algorithms"`
+	Keys           []ComplianceKey           `json:"keys"`
+	EntropySources []ComplianceEntropySource `json:"entropy_sources"`
+	Soundness      ComplianceSoundness       `json:"soundness"`
+	SelfTest       *SelfTestResult           `json:"self_test,omitempty"`
+	Findings       []string                  `json:"findings,omitempty"`
+}
+
+// GenerateComplianceReport describes the algorithms, key lengths, entropy
+// sources and soundness of proofs made by sq, and the outcome of
+// selfTest, typically sq.SelfTest() at startup. A nil selfTest is
+// reported as a finding. The report marshals to JSON; Markdown renders it
+// as a narrative.
+func GenerateComplianceReport(sq *SecureQuantumZKP, selfTest *SelfTestResult) (*ComplianceReport, error) {
+	if sq == nil || sq.QuantumZKP == nil || sq.Signer == nil {
+		return nil, errors.New("compliance report requires a configured SecureQuantumZKP")
+	}
+	params := sq.Parameters()
+	report := &ComplianceReport{
+		Generated:    time.Now().UTC(),
+		Library:      LibraryVersion,
+		ProofVersion: params.ProofVersion,
+		Context:      sq.Context.String(),
+		Profile:      sq.Profile,
+		Soundness: ComplianceSoundness{
+			SecurityLevel:          sq.SecurityLevel,
+			SoundnessBits:          params.SoundnessBits,
+			EffectiveSoundnessBits: params.EffectiveSoundnessBits,
+			ChallengeCount:         params.ChallengeCount,
+		},
+		SelfTest: selfTest,
+	}
+	finding := func(format string, args ...interface{}) {
+		report.Findings = append(report.Findings, fmt.Sprintf(format, args...))
+	}
+
+	// A b-byte digest resists collisions up to 2^(4b) work
+	commitmentBits := min(4*params.CommitmentHashLength, 128)
+	responseBits := min(4*params.ResponseDigestLength, 128)
+	report.Algorithms = []ComplianceAlgorithm{
+		{Purpose: "proof signature", Algorithm: SignatureAlgorithmMLDSA87, Standard: "FIPS 204", SecurityBits: 256, Approved: true,
+			Note: "security category 5; signed with a context string naming the application"},
+		{Purpose: "state commitment", Algorithm: "SHA-256", Standard: "FIPS 180-4", SecurityBits: commitmentBits, Approved: true,
+			Note: fmt.Sprintf("published truncated to %d bytes", params.CommitmentHashLength)},
+		{Purpose: "challenge responses", Algorithm: "SHA-256", Standard: "FIPS 180-4", SecurityBits: responseBits, Approved: true,
+			Note: fmt.Sprintf("published truncated to %d bytes", params.ResponseDigestLength)},
+		{Purpose: "response Merkle tree", Algorithm: "SHA-256", Standard: "FIPS 180-4", SecurityBits: 128, Approved: true},
+		{Purpose: "challenge derivation", Algorithm: "BLAKE3 XOF", Standard: "BLAKE3 specification", SecurityBits: 128, Approved: false,
+			Note: "Fiat-Shamir transform over the commitment; not a NIST-approved function"},
+		{Purpose: "proof key derivation", Algorithm: "HKDF-SHA256", Standard: "NIST SP 800-56C Rev. 2, RFC 5869", SecurityBits: 128, Approved: true,
+			Note: "derives a per-context key from the caller's proof key"},
+	}
+	if len(sq.IdentifierKey) > 0 {
+		report.Algorithms = append(report.Algorithms, ComplianceAlgorithm{Purpose: "identifier pseudonymization", Algorithm: "HMAC-SHA256",
+			Standard: "FIPS 198-1", SecurityBits: 128, Approved: true})
+	}
+	if len(sq.LinkageKey) > 0 {
+		report.Algorithms = append(report.Algorithms, ComplianceAlgorithm{Purpose: "linkage tags", Algorithm: "HMAC-SHA256",
+			Standard: "FIPS 198-1", SecurityBits: 128, Approved: true})
+	}
+	for _, algorithm := range report.Algorithms {
+		if !algorithm.Approved {
+			finding("%s uses %s, which is not NIST-approved", algorithm.Purpose, algorithm.Algorithm)
+		}
+	}
+	if min(commitmentBits, responseBits) < params.SoundnessBits && min(commitmentBits, responseBits) < 128 {
+		finding("digests truncated to %d and %d bytes give less collision resistance than the %d-bit soundness",
+			params.CommitmentHashLength, params.ResponseDigestLength, params.SoundnessBits)
+	}
+
+	report.Keys = []ComplianceKey{
+		{Name: "ML-DSA-87 public key", Bytes: len(sq.Signer.PublicKeyBytes())},
+		{Name: "commitment nonce", Bytes: params.CommitmentNonceLength},
+		{Name: "challenge nonce", Bytes: params.ChallengeNonceLength, Note: "drawn from the challenge XOF, which absorbs the commitment nonce"},
+		{Name: "proof key", Note: "supplied per proof by the caller; 32 bytes or more is recommended"},
+	}
+	for _, key := range []struct {
+		name  string
+		value []byte
+	}{{"identifier disclosure key", sq.IdentifierKey}, {"linkage detection key", sq.LinkageKey}} {
+		if len(key.value) > 0 {
+			report.Keys = append(report.Keys, ComplianceKey{Name: key.name, Bytes: len(key.value)})
+			if len(key.value) < 32 {
+				finding("the %s is %d bytes, below the recommended 32", key.name, len(key.value))
+			}
+		}
+	}
+
+	switch {
+	case sq.Rand != nil:
+		report.EntropySources = []ComplianceEntropySource{{Class: RandomnessCaller, Healthy: true,
+			Note: "caller-supplied reader; its entropy is not assessed"}}
+		finding("nonces come from a caller-supplied reader (sq.Rand), which is meant for reproducible tests, not production")
+	case sq.Randomness != nil:
+		for _, status := range sq.Randomness.Status() {
+			report.EntropySources = append(report.EntropySources, ComplianceEntropySource{Class: status.Class, Healthy: status.Healthy, Note: status.Error})
+		}
+	case sq.HybridRandom != nil:
+		report.EntropySources = []ComplianceEntropySource{{Class: RandomnessHybrid, Healthy: true,
+			Note: "Kyber BLAKE2XB stream XORed with the operating system CSPRNG"}}
+	default:
+		report.EntropySources = []ComplianceEntropySource{{Class: RandomnessSystem, Healthy: true, Note: "operating system CSPRNG via crypto/rand"}}
+	}
+	for _, source := range report.EntropySources {
+		if !source.Healthy {
+			finding("entropy source %s has failed its health tests: %s", source.Class, source.Note)
+		}
+	}
+
+	switch {
+	case selfTest == nil:
+		finding("no self-test result was supplied; run SelfTest at startup")
+	case !selfTest.Passed:
+		finding("the self-test failed: %v", selfTest.Err())
+	}
+	return report, nil
+}
+
+// Markdown renders the report as a narrative for certification documents.
+func (r *ComplianceReport) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Cryptographic Configuration Report\n\n")
+	fmt.Fprintf(&b, "Generated %s by qzkp %s for proof version %d, context `%s`", r.Generated.Format(time.RFC3339), r.Library, r.ProofVersion, r.Context)
+	if r.Profile != "" {
+		fmt.Fprintf(&b, ", profile `%s`", r.Profile)
+	}
+	b.WriteString(".\n\n")
+
+	b.WriteString("## Algorithms\n\n| Purpose | Algorithm | Standard | Security | Approved | Notes |\n|---|---|---|---|---|---|\n")
+	for _, a := range r.Algorithms {
+		approved := "no"
+		if a.Approved {
+			approved = "yes"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %d bits | %s | %s |\n", a.Purpose, a.Algorithm, a.Standard, a.SecurityBits, approved, a.Note)
+	}
+
+	b.WriteString("\n## Keys and Nonces\n\n")
+	for _, k := range r.Keys {
+		if k.Bytes > 0 {
+			fmt.Fprintf(&b, "- %s: %d bytes", k.Name, k.Bytes)
+		} else {
+			fmt.Fprintf(&b, "- %s", k.Name)
+		}
+		if k.Note != "" {
+			fmt.Fprintf(&b, " (%s)", k.Note)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n## Entropy Sources\n\nNonces and salts are drawn from the following sources, in order of preference:\n\n")
+	for _, s := range r.EntropySources {
+		health := "healthy"
+		if !s.Healthy {
+			health = "failed"
+		}
+		fmt.Fprintf(&b, "- %s: %s", s.Class, health)
+		if s.Note != "" {
+			fmt.Fprintf(&b, " (%s)", s.Note)
+		}
+		b.WriteString("\n")
+	}
+
+	s := r.Soundness
+	fmt.Fprintf(&b, "\n## Soundness\n\nProofs target %d-bit soundness at security level %d. Each proof answers %d challenges, "+
+		"each a state index and the Z or X basis, for an effective soundness of %d bits: a prover that does not know the state is accepted "+
+		"with probability at most 2^-%d.\n", s.SoundnessBits, s.SecurityLevel, s.ChallengeCount,
+		s.EffectiveSoundnessBits, s.EffectiveSoundnessBits)
+
+	b.WriteString("\n## Self-Test\n\n")
+	if r.SelfTest == nil {
+		b.WriteString("No self-test result was supplied.\n")
+	} else {
+		outcome := "passed"
+		if !r.SelfTest.Passed {
+			outcome = "failed"
+		}
+		fmt.Fprintf(&b, "The self-test run at %s %s in %s.\n\n", r.SelfTest.Timestamp.UTC().Format(time.RFC3339), outcome, r.SelfTest.Duration.Round(time.Microsecond))
+		for _, c := range r.SelfTest.Checks {
+			if c.Passed {
+				fmt.Fprintf(&b, "- %s: passed\n", c.Name)
+			} else {
+				fmt.Fprintf(&b, "- %s: failed (%s)\n", c.Name, c.Error)
+			}
+		}
+	}
+
+	b.WriteString("\n## Findings\n\n")
+	if len(r.Findings) == 0 {
+		b.WriteString("None.\n")
+	}
+	for _, f := range r.Findings {
+		fmt.Fprintf(&b, "- %s\n", f)
+	}
+	return b.String()
+}
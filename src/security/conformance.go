@@ -0,0 +1,294 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Conformance vector names, in addition to the corpus tamper kinds.
+const (
+	ConformanceShortMerkleRoot     = "short_merkle_root"
+	ConformanceNonHexSignature     = "non_hex_signature"
+	ConformanceMissingResponse     = "missing_response"
+	ConformanceInflatedSoundness   = "inflated_soundness"
+	ConformanceEntropyBound        = "absurd_entropy_bound"
+	ConformanceCoherenceBound      = "negative_coherence_bound"
+	ConformanceDimension           = "absurd_dimension"
+	ConformanceSecurityLevel       = "absurd_security_level"
+	ConformanceDuplicateChallenges = "duplicate_challenge_indices"
+	ConformanceIndexOutOfRange     = "challenge_index_out_of_range"
+	ConformanceForeignContext      = "foreign_context"
+	ConformanceParametersHash      = "wrong_parameters_hash"
+	ConformanceReplay              = "replayed_proof" // only with a NonceStore
+)
+
+// conformanceVector is one adversarial proof. Unless resign is false the
+// mutated proof is signed again, so the signature checks out and only the
+// mutation can be the reason the proof is rejected.
+type conformanceVector struct {
+	name        string
+	description string
+	resign      bool
+	mutate      func(sq *SecureQuantumZKP, p *SecureProof) error
+}
+
+// conformanceVectors are the built-in adversarial proofs.
+var conformanceVectors = []conformanceVector{
+	{ConformanceShortMerkleRoot, "Merkle root truncated to 15 bytes", true, func(_ *SecureQuantumZKP, p *SecureProof) error {
+		p.MerkleRoot = p.MerkleRoot[:30]
+		return nil
+	}},
+	{ConformanceNonHexSignature, "signature that is not hex", false, func(_ *SecureQuantumZKP, p *SecureProof) error {
+		p.Signature = "zz" + p.Signature[2:]
+		return nil
+	}},
+	{ConformanceMissingResponse, "one challenge fewer than the soundness level needs, Merkle root recomputed", true, func(sq *SecureQuantumZKP, p *SecureProof) error {
+		p.ChallengeResponse = p.ChallengeResponse[:len(p.ChallengeResponse)-1]
+		p.StateMetadata.SoundnessBits = len(p.ChallengeResponse)
+		return remerkle(sq, p)
+	}},
+	{ConformanceInflatedSoundness, "soundness claimed above what the challenges reach", true, func(_ *SecureQuantumZKP, p *SecureProof) error {
+		p.StateMetadata.SoundnessBits *= 2
+		return nil
+	}},
+	{ConformanceEntropyBound, "entropy bound above log2 of the dimension", true, func(_ *SecureQuantumZKP, p *SecureProof) error {
+		p.StateMetadata.EntropyBound = 1e9
+		return nil
+	}},
+	{ConformanceCoherenceBound, "negative coherence bound", true, func(_ *SecureQuantumZKP, p *SecureProof) error {
+		p.StateMetadata.CoherenceBound = -1
+		return nil
+	}},
+	{ConformanceDimension, "dimension above the supported maximum", true, func(_ *SecureQuantumZKP, p *SecureProof) error {
+		p.StateMetadata.Dimension = maxProofDimension * 2
+		return nil
+	}},
+	{ConformanceSecurityLevel, "security level outside the supported range", true, func(_ *SecureQuantumZKP, p *SecureProof) error {
+		p.StateMetadata.SecurityLevel = 1 << 30
+		return nil
+	}},
+	{ConformanceDuplicateChallenges, "first response repeated in place of the second, Merkle root recomputed", true, func(sq *SecureQuantumZKP, p *SecureProof) error {
+		if len(p.ChallengeResponse) < 2 {
+			return errors.New("proof has a single challenge")
+		}
+		p.ChallengeResponse[1] = p.ChallengeResponse[0]
+		return remerkle(sq, p)
+	}},
+	{ConformanceIndexOutOfRange, "challenge index at the dimension, past the last amplitude, Merkle root recomputed", true, func(sq *SecureQuantumZKP, p *SecureProof) error {
+		p.ChallengeResponse[0].ChallengeIndex = p.StateMetadata.Dimension
+		return remerkle(sq, p)
+	}},
+	{ConformanceForeignContext, "proof made for another application", true, func(_ *SecureQuantumZKP, p *SecureProof) error {
+		p.Context.Application += "-foreign"
+		return nil
+	}},
+	{ConformanceParametersHash, "parameters hash of other protocol parameters", true, func(sq *SecureQuantumZKP, p *SecureProof) error {
+		other := *sq
+		other.NumericEncoding.Precision++
+		hash, err := other.Parameters().Hash()
+		p.ParametersHash = hash
+		return err
+	}},
+}
+
+// remerkle recomputes the Merkle root over the proof's responses.
+func remerkle(sq *SecureQuantumZKP, p *SecureProof) error {
+	root, err := sq.generateMerkleRoot(p.ChallengeResponse)
+	p.MerkleRoot = root
+	return err
+}
+
+// ConformanceCase is the outcome of one conformance vector.
+type ConformanceCase struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Passed      bool   `json:"passed"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ConformanceReport is the outcome of RunVerifierConformance. Passed is
+// true only if every case passed.
+type ConformanceReport struct {
+	Passed bool              `json:"passed"`
+	Cases  []ConformanceCase `json:"cases"`
+}
+
+// Err returns nil if every case passed and otherwise an error naming the
+// failed cases.
+func (r *ConformanceReport) Err() error {
+	if r.Passed {
+		return nil
+	}
+	var failed []string
+	for _, c := range r.Cases {
+		if !c.Passed {
+			failed = append(failed, c.Name+": "+c.Error)
+		}
+	}
+	return fmt.Errorf("verifier conformance failed: %s", strings.Join(failed, "; "))
+}
+
+// ConformanceVectors lists the adversarial proofs RunVerifierConformance
+// checks.
+func ConformanceVectors() []string {
+	names := append([]string(nil), CorpusTampers...)
+	for _, v := range conformanceVectors {
+		names = append(names, v.name)
+	}
+	return append(names, ConformanceReplay)
+}
+
+// RunVerifierConformance checks that v, as configured, accepts a fresh
+// valid proof and rejects every adversarial proof derived from it, each
+// for the reason it was built for: the vectors are signed so that only
+// their defect can cause the rejection. The proofs are made with an
+// ephemeral key standing in for v's signer or key set, so deployments that
+// hold only public keys can run it; v's parameters, policy and options
+// are used as they are, except that replay protection uses a temporary
+// nonce store.
+func RunVerifierConformance(v *Verifier) *ConformanceReport {
+	report := &ConformanceReport{Passed: true}
+	record := func(name, description string, err error) {
+		c := ConformanceCase{Name: name, Description: description, Passed: err == nil}
+		if err != nil {
+			c.Error = err.Error()
+			report.Passed = false
+		}
+		report.Cases = append(report.Cases, c)
+	}
+
+	test, prover, err := v.conformanceCopy()
+	if err != nil {
+		record("setup", "ephemeral key and verifier", err)
+		return report
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		record("setup", "proof key", err)
+		return report
+	}
+	valid, err := prover.SecureProveVectorKnowledge([]complex128{complex(0.6, 0), complex(0, 0.8)}, "conformance", key)
+	if err != nil {
+		record("setup", "valid proof", err)
+		return report
+	}
+
+	// The valid proof must pass before rejections mean anything
+	if result := test.Verify(valid, key); !result.Valid {
+		record(CorpusVariantValid, "untampered proof", fmt.Errorf("valid proof was rejected: %v", result.Reasons))
+		return report
+	}
+	record(CorpusVariantValid, "untampered proof", nil)
+
+	expectRejected := func(name, description string, build func() (*SecureProof, error)) {
+		p, err := build()
+		if err != nil {
+			record(name, description, fmt.Errorf("failed to build vector: %w", err))
+			return
+		}
+		// A fresh nonce store keeps a defect from being masked by the
+		// valid proof's commitment having been seen
+		if seen := test.options.NonceStore; seen != nil && name != ConformanceReplay {
+			test.options.NonceStore = NewMemoryNonceStore()
+			defer func() { test.options.NonceStore = seen }()
+		}
+		if test.Verify(p, key).Valid {
+			err = errors.New("adversarial proof was accepted")
+		}
+		record(name, description, err)
+	}
+	for _, name := range ConformanceVectors() {
+		if name == ConformanceReplay {
+			continue
+		}
+		expectRejected(name, conformanceDescription(name), func() (*SecureProof, error) {
+			return adversarialProof(prover, valid, key, name)
+		})
+	}
+	// The valid proof was accepted once above
+	if test.options.NonceStore != nil {
+		expectRejected(ConformanceReplay, conformanceDescription(ConformanceReplay), func() (*SecureProof, error) { return valid, nil })
+	}
+	return report
+}
+
+// adversarialProof derives the named conformance vector from valid, which
+// sq made with key, re-signing it with sq when the vector calls for it.
+// ConformanceReplay is not derived: it is valid itself, presented again.
+func adversarialProof(sq *SecureQuantumZKP, valid *SecureProof, key []byte, name string) (*SecureProof, error) {
+	for _, tamper := range CorpusTampers {
+		if name != tamper {
+			continue
+		}
+		p, err := tamperProof(valid, tamper)
+		if err == nil && tamper != TamperSignature {
+			err = sq.signSecureProof(p, key)
+		}
+		return p, err
+	}
+	for _, vector := range conformanceVectors {
+		if name != vector.name {
+			continue
+		}
+		p, err := copySecureProof(valid)
+		if err == nil {
+			err = vector.mutate(sq, p)
+		}
+		if err == nil && vector.resign {
+			err = sq.signSecureProof(p, key)
+		}
+		return p, err
+	}
+	return nil, fmt.Errorf("unknown conformance vector %q", name)
+}
+
+// conformanceDescription describes the named conformance vector.
+func conformanceDescription(name string) string {
+	if name == ConformanceReplay {
+		return "valid proof presented a second time"
+	}
+	for _, vector := range conformanceVectors {
+		if name == vector.name {
+			return vector.description
+		}
+	}
+	return "corpus tamper " + name
+}
+
+// conformanceCopy returns a copy of v that trusts a fresh ephemeral key in
+// place of v's signer or key set, and a prover signing with that key.
+func (v *Verifier) conformanceCopy() (*Verifier, *SecureQuantumZKP, error) {
+	signer, err := NewSignatureScheme(v.sq.Signer.Ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	prover := v.sq.withSigner(signer)
+
+	test := *v
+	test.sq = prover
+	test.cache = nil
+	if v.keys != nil {
+		public := KeySetKey{KeyType: KeySetKeyType, Algorithm: KeySetAlgorithm, KeyID: KeyFingerprint(signer.PublicKeyBytes())}
+		test.keys = []keySetScheme{{KeySetKey: public, scheme: signer}}
+	}
+	if v.options.NonceStore != nil {
+		test.options.NonceStore = NewMemoryNonceStore()
+	}
+	return &test, prover, nil
+}
+
+// copySecureProof returns a deep copy of proof.
+func copySecureProof(proof *SecureProof) (*SecureProof, error) {
+	data, err := json.Marshal(proof)
+	if err != nil {
+		return nil, err
+	}
+	var p SecureProof
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DefaultContextVersion is the version assigned to contexts built from the
+// legacy ctx []byte constructor argument.
+const DefaultContextVersion = 1
+
+// maxContextBytes is the ML-DSA limit on signature context strings.
+const maxContextBytes = 255
+
+// Context identifies the application and protocol version a proof belongs
+// to. It is bound into the state commitment, the challenge derivation and
+// the signature, so a proof produced under one context never verifies
+// under another. Epoch, when set, names one period of an application that
+// rotates its context (see EpochSchedule); each epoch is a distinct
+// context.
+type Context struct {
+	Version     int    `json:"version"`
+	Application string `json:"application"`
+	Epoch       string `json:"epoch,omitempty"`
+}
+
+// NewContext validates and returns a context for the given application.
+func NewContext(application string, version int) (Context, error) {
+	c := Context{Version: version, Application: application}
+	if err := c.Validate(); err != nil {
+		return Context{}, err
+	}
+	return c, nil
+}
+
+// LegacyContext wraps the raw ctx bytes accepted by the original
+// constructors.
+func LegacyContext(ctx []byte) Context {
+	return Context{Version: DefaultContextVersion, Application: string(ctx)}
+}
+
+// Validate checks that the context can be encoded and used as a signature
+// context.
+func (c Context) Validate() error {
+	if c.Version <= 0 {
+		return fmt.Errorf("invalid context version: %d", c.Version)
+	}
+	if c.Epoch != "" && !isEpochName(c.Epoch) {
+		return fmt.Errorf("invalid context epoch %q: names are 1 to %d letters, digits, '.', '_' or '-'", c.Epoch, maxEpochNameLength)
+	}
+	if len(c.Bytes()) > maxContextBytes {
+		return fmt.Errorf("context too long: %d bytes (maximum %d)", len(c.Bytes()), maxContextBytes)
+	}
+	return nil
+}
+
+// Bytes returns the canonical encoding used for domain separation. An
+// epoch context length-prefixes its application, and the '+' after the
+// version keeps it apart from every context without an epoch.
+func (c Context) Bytes() []byte {
+	if c.Epoch != "" {
+		return []byte(fmt.Sprintf("qzkp-ctx/v%d+epoch/%d:%s/%s", c.Version, len(c.Application), c.Application, c.Epoch))
+	}
+	return []byte(fmt.Sprintf("qzkp-ctx/v%d/%s", c.Version, c.Application))
+}
+
+// Equal reports whether two contexts are identical.
+func (c Context) Equal(other Context) bool {
+	return c.Version == other.Version && c.Application == other.Application && c.Epoch == other.Epoch
+}
+
+// String implements fmt.Stringer.
+func (c Context) String() string {
+	return string(c.Bytes())
+}
+
+// KeyDerivationHKDFContext marks proofs whose prover secret was derived
+// per context with contextKey.
+const KeyDerivationHKDFContext = "hkdf-sha256/context"
+
+// contextKeyDomain is the HKDF salt for per-context prover secrets.
+const contextKeyDomain = "qzkp/context-key/v1"
+
+// contextKey derives the secret sq commits and responds with from the
+// caller's key. The context is the HKDF info, so the same key yields
+// unrelated secrets under two contexts: together with the context in the
+// commitment, the challenge derivation and the signature, nothing a prover
+// produces under one context is reusable under another.
+func (sq *SecureQuantumZKP) contextKey(key []byte) ([]byte, error) {
+	derived, err := hkdf.Key(sha256.New, key, []byte(contextKeyDomain), string(sq.Context.Bytes()), sha256.Size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive context key: %w", err)
+	}
+	return derived, nil
+}
+
+// NewSecureQuantumZKPWithContext creates a secure quantum ZKP bound to an
+// explicit Context.
+func NewSecureQuantumZKPWithContext(dimensions, securityLevel int, c Context) (*SecureQuantumZKP, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	sq, err := NewSecureQuantumZKP(dimensions, securityLevel, nil)
+	if err != nil {
+		return nil, err
+	}
+	sq.setContext(c)
+	return sq, nil
+}
+
+// setContext installs c as the instance context and binds it into the
+// signature scheme.
+func (sq *SecureQuantumZKP) setContext(c Context) {
+	sq.Context = c
+	sq.Signer.Ctx = c.Bytes()
+}
+
+// ContextRegistry keeps track of the contexts an application works with,
+// keyed by application label.
+type ContextRegistry struct {
+	contexts map[string]Context
+	mu       sync.RWMutex
+}
+
+// NewContextRegistry creates an empty registry.
+func NewContextRegistry() *ContextRegistry {
+	return &ContextRegistry{
+		contexts: make(map[string]Context),
+	}
+}
+
+// Register adds a context. Registering a different version for an
+// application that is already present is an error.
+func (r *ContextRegistry) Register(c Context) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.contexts[c.Application]; ok && !existing.Equal(c) {
+		return fmt.Errorf("application %q already registered with version %d", c.Application, existing.Version)
+	}
+	r.contexts[c.Application] = c
+	return nil
+}
+
+// Lookup returns the context registered for application.
+func (r *ContextRegistry) Lookup(application string) (Context, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.contexts[application]
+	return c, ok
+}
+
+// Applications returns the registered application labels in sorted order.
+func (r *ContextRegistry) Applications() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	apps := make([]string, 0, len(r.contexts))
+	for app := range r.contexts {
+		apps = append(apps, app)
+	}
+	sort.Strings(apps)
+	return apps
+}
+
+// NewProver creates a SecureQuantumZKP bound to the registered context for
+// application.
+func (r *ContextRegistry) NewProver(application string, dimensions, securityLevel int) (*SecureQuantumZKP, error) {
+	c, ok := r.Lookup(application)
+	if !ok {
+		return nil, errors.New("unknown application context: " + application)
+	}
+	return NewSecureQuantumZKPWithContext(dimensions, securityLevel, c)
+}
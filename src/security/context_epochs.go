@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxEpochNameLength bounds context epoch names.
+const maxEpochNameLength = 32
+
+// ErrEpochNotAccepted is wrapped by every rejection of a proof's context
+// by an EpochSchedule.
+var ErrEpochNotAccepted = errors.New("context epoch not accepted")
+
+// ContextEpoch is one period of a rotating context, from Start until the
+// next epoch's Start.
+type ContextEpoch struct {
+	Name  string    `json:"name"`
+	Start time.Time `json:"start"`
+}
+
+// Epoch transition kinds.
+const (
+	EpochActivated = "activated" // the epoch became current
+	EpochRetired   = "retired"   // the epoch's overlap window closed
+)
+
+// EpochTransition is a change in the epochs a schedule accepts, at the
+// time the schedule set for it.
+type EpochTransition struct {
+	Kind  string    `json:"kind"`
+	Epoch Context   `json:"epoch"`
+	At    time.Time `json:"at"`
+}
+
+// EpochSchedule rotates an application's context through epochs, e.g. one
+// per quarter. Proofs are made under the current epoch and record it in
+// their context. Once the next epoch starts, proofs of the previous one
+// are still accepted for Overlap, so proofs in flight at a rotation are
+// not invalidated by a hard cutover. Because the epoch is part of the
+// context, it is bound into the commitment, the challenges, the
+// signature and the derived proof key like the rest of it.
+//
+// Every transition is reported to OnTransition once the schedule is used
+// at or after its time; the first use reports the current epoch's
+// activation. An EpochSchedule is safe for concurrent use.
+type EpochSchedule struct {
+	Application string
+	Version     int
+	Overlap     time.Duration
+	// OnTransition receives every transition in order; it defaults to a
+	// log line on stderr and must be set before first use
+	OnTransition func(EpochTransition)
+
+	epochs   []ContextEpoch // sorted by Start
+	mu       sync.Mutex
+	observed time.Time // transitions up to here have been reported
+}
+
+// NewEpochSchedule validates and returns a schedule for application with
+// the given epochs, in any order.
+func NewEpochSchedule(application string, version int, overlap time.Duration, epochs ...ContextEpoch) (*EpochSchedule, error) {
+	if len(epochs) == 0 {
+		return nil, errors.New("epoch schedule needs at least one epoch")
+	}
+	if overlap < 0 {
+		return nil, fmt.Errorf("negative overlap %s", overlap)
+	}
+	sorted := slices.Clone(epochs)
+	slices.SortFunc(sorted, func(a, b ContextEpoch) int { return a.Start.Compare(b.Start) })
+	for i, epoch := range sorted {
+		if err := (Context{Version: version, Application: application, Epoch: epoch.Name}).Validate(); err != nil {
+			return nil, err
+		}
+		if epoch.Name == "" {
+			return nil, errors.New("epoch names must not be empty")
+		}
+		if i > 0 && !epoch.Start.After(sorted[i-1].Start) {
+			return nil, fmt.Errorf("epochs %q and %q start at the same time", sorted[i-1].Name, epoch.Name)
+		}
+		if slices.ContainsFunc(sorted[:i], func(e ContextEpoch) bool { return e.Name == epoch.Name }) {
+			return nil, fmt.Errorf("epoch %q is scheduled twice", epoch.Name)
+		}
+	}
+	return &EpochSchedule{Application: application, Version: version, Overlap: overlap, epochs: sorted}, nil
+}
+
+// Epochs returns the scheduled epochs in order.
+func (s *EpochSchedule) Epochs() []ContextEpoch {
+	return slices.Clone(s.epochs)
+}
+
+// context returns the context of epoch i.
+func (s *EpochSchedule) context(i int) Context {
+	return Context{Version: s.Version, Application: s.Application, Epoch: s.epochs[i].Name}
+}
+
+// current returns the index of the epoch current at now, or -1 before the
+// first one starts.
+func (s *EpochSchedule) current(now time.Time) int {
+	i := len(s.epochs) - 1
+	for i >= 0 && s.epochs[i].Start.After(now) {
+		i--
+	}
+	return i
+}
+
+// retires returns when epoch i stops being accepted, and false for the
+// last epoch, which never does.
+func (s *EpochSchedule) retires(i int) (time.Time, bool) {
+	if i+1 >= len(s.epochs) {
+		return time.Time{}, false
+	}
+	return s.epochs[i+1].Start.Add(s.Overlap), true
+}
+
+// Current returns the context to prove under at now.
+func (s *EpochSchedule) Current(now time.Time) (Context, error) {
+	s.observe(now)
+	i := s.current(now)
+	if i < 0 {
+		return Context{}, fmt.Errorf("%w: no epoch of %s has started by %s", ErrEpochNotAccepted, s.Application, now.Format(time.RFC3339))
+	}
+	return s.context(i), nil
+}
+
+// Accepted returns the contexts whose proofs are accepted at now: the
+// current epoch's and those of earlier epochs still in their overlap
+// window, newest first.
+func (s *EpochSchedule) Accepted(now time.Time) []Context {
+	s.observe(now)
+	var accepted []Context
+	for i := s.current(now); i >= 0; i-- {
+		if retires, ok := s.retires(i); ok && !now.Before(retires) {
+			break
+		}
+		accepted = append(accepted, s.context(i))
+	}
+	return accepted
+}
+
+// Accepts reports whether proofs made under c are accepted at now.
+func (s *EpochSchedule) Accepts(c Context, now time.Time) error {
+	if slices.ContainsFunc(s.Accepted(now), c.Equal) {
+		return nil
+	}
+	if c.Application != s.Application || c.Version != s.Version {
+		return fmt.Errorf("%w: context %s is not of this schedule", ErrEpochNotAccepted, c)
+	}
+	i := slices.IndexFunc(s.epochs, func(e ContextEpoch) bool { return e.Name == c.Epoch })
+	switch {
+	case i < 0:
+		return fmt.Errorf("%w: epoch %q is not scheduled", ErrEpochNotAccepted, c.Epoch)
+	case s.epochs[i].Start.After(now):
+		return fmt.Errorf("%w: epoch %q starts at %s", ErrEpochNotAccepted, c.Epoch, s.epochs[i].Start.Format(time.RFC3339))
+	}
+	retires, _ := s.retires(i)
+	return fmt.Errorf("%w: epoch %q retired at %s", ErrEpochNotAccepted, c.Epoch, retires.Format(time.RFC3339))
+}
+
+// observe reports the transitions scheduled since the last call, up to
+// now. The first call reports only the current epoch's activation.
+func (s *EpochSchedule) observe(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !now.After(s.observed) {
+		return
+	}
+	var transitions []EpochTransition
+	if s.observed.IsZero() {
+		if i := s.current(now); i >= 0 {
+			transitions = append(transitions, EpochTransition{Kind: EpochActivated, Epoch: s.context(i), At: s.epochs[i].Start})
+		}
+	} else {
+		due := func(t time.Time) bool { return t.After(s.observed) && !t.After(now) }
+		for i, epoch := range s.epochs {
+			if due(epoch.Start) {
+				transitions = append(transitions, EpochTransition{Kind: EpochActivated, Epoch: s.context(i), At: epoch.Start})
+			}
+			if retires, ok := s.retires(i); ok && due(retires) {
+				transitions = append(transitions, EpochTransition{Kind: EpochRetired, Epoch: s.context(i), At: retires})
+			}
+		}
+	}
+	s.observed = now
+	slices.SortStableFunc(transitions, func(a, b EpochTransition) int { return a.At.Compare(b.At) })
+
+	report := s.OnTransition
+	if report == nil {
+		report = func(t EpochTransition) {
+			stderrLogf("context epoch %s %s at %s", t.Epoch, t.Kind, t.At.Format(time.RFC3339))
+		}
+	}
+	for _, t := range transitions {
+		report(t)
+	}
+}
+
+// AtCurrentEpoch returns a copy of sq bound to the current epoch of
+// sq.Epochs, to prove under. Without a schedule it returns sq.
+func (sq *SecureQuantumZKP) AtCurrentEpoch() (*SecureQuantumZKP, error) {
+	if sq.Epochs == nil {
+		return sq, nil
+	}
+	c, err := sq.Epochs.Current(sq.now())
+	if err != nil {
+		return nil, err
+	}
+	return sq.withContext(c), nil
+}
+
+// epochVerifier returns the instance to verify proof with. Without a
+// schedule that is sq. With one, the proof's context must be accepted by
+// sq.Epochs at sq.now(), and a copy of sq bound to it is returned, so
+// proofs of the previous epoch verify during the overlap window whatever
+// sq.Context is.
+func (sq *SecureQuantumZKP) epochVerifier(proof *SecureProof) (*SecureQuantumZKP, error) {
+	if sq.Epochs == nil || proof == nil {
+		return sq, nil
+	}
+	if err := sq.Epochs.Accepts(proof.Context, sq.now()); err != nil {
+		return nil, err
+	}
+	if proof.Context.Equal(sq.Context) {
+		return sq, nil
+	}
+	return sq.withContext(proof.Context), nil
+}
+
+// withContext returns a copy of sq that proves, signs and verifies under
+// c, leaving sq's own context in place.
+func (sq *SecureQuantumZKP) withContext(c Context) *SecureQuantumZKP {
+	signer := *sq.Signer
+	signer.Ctx = c.Bytes()
+	copied := sq.withSigner(&signer)
+	copied.Context = c
+	if sq.precomputed != nil {
+		precomputed := *sq.precomputed
+		precomputed.contextBytes = c.Bytes()
+		copied.precomputed = &precomputed
+	}
+	return copied
+}
+
+// isEpochName reports whether name is a valid epoch name.
+func isEpochName(name string) bool {
+	if name == "" || len(name) > maxEpochNameLength {
+		return false
+	}
+	for _, c := range []byte(name) {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"lukechampine.com/blake3"
+)
+
+// corpusDomain separates every value derived from a corpus seed.
+const corpusDomain = "qzkp/corpus/v1"
+
+// CorpusManifestFile is the manifest written at the root of a corpus.
+const CorpusManifestFile = "manifest.json"
+
+// CorpusVariantValid labels untampered proofs. Every other variant is a
+// tamper kind and must be rejected by a conforming verifier.
+const CorpusVariantValid = "valid"
+
+// Tamper kinds. Unless noted, the tampered proof is re-signed with the
+// corpus key, so the signature checks out and only the named field is
+// wrong.
+const (
+	TamperSignature           = "signature" // not re-signed
+	TamperMerkleRoot          = "merkle_root"
+	TamperResponse            = "response"
+	TamperTruncatedCommitment = "truncated_commitment"
+	TamperDowngradedVersion   = "downgraded_version"
+)
+
+// CorpusTampers lists every tamper kind.
+var CorpusTampers = []string{
+	TamperSignature,
+	TamperMerkleRoot,
+	TamperResponse,
+	TamperTruncatedCommitment,
+	TamperDowngradedVersion,
+}
+
+// corpusClock is the creation time of every deterministic proof.
+var corpusClock = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// CorpusOptions selects the configurations a corpus covers. Every
+// combination of soundness level, profile and mode is proven once and
+// then written in every codec, untampered and with every tamper.
+type CorpusOptions struct {
+	Seed            []byte // 32 bytes; fixes keys, secrets and deterministic proofs
+	Context         string
+	SoundnessLevels []int
+	Codecs          []string
+	Profiles        []string // "" produces proofs without a profile
+	Tampers         []string
+}
+
+// DefaultCorpusOptions covers the standard soundness levels, every codec,
+// proofs with and without a profile, and every tamper kind.
+func DefaultCorpusOptions(seed []byte) CorpusOptions {
+	return CorpusOptions{
+		Seed:            seed,
+		Context:         "qzkp-corpus",
+		SoundnessLevels: []int{32, 80, 128, 256},
+		Codecs:          ProofCodecs(),
+		Profiles:        []string{"", "interop"},
+		Tampers:         CorpusTampers,
+	}
+}
+
+// CorpusEntry labels one proof file of a corpus.
+type CorpusEntry struct {
+	File          string `json:"file"`
+	SHA256        string `json:"sha256"`
+	SoundnessBits int    `json:"soundness_bits"`
+	Codec         string `json:"codec"`
+	Profile       string `json:"profile,omitempty"`
+	Deterministic bool   `json:"deterministic"`
+	Variant       string `json:"variant"`
+	Valid         bool   `json:"valid"` // expected VerifySecureProof result
+}
+
+// CorpusManifest describes a corpus and everything a third-party verifier
+// needs to check it. The proof key is published because the corpus is
+// test material, not a secret.
+type CorpusManifest struct {
+	ProofVersion int           `json:"proof_version"`
+	Seed         string        `json:"seed"`
+	Context      Context       `json:"context"`
+	PublicKey    string        `json:"public_key"` // ML-DSA-87, hex
+	Key          string        `json:"key"`        // proof key, hex
+	Entries      []CorpusEntry `json:"entries"`
+}
+
+// corpusStream derives an unbounded byte stream from the seed and label.
+func corpusStream(seed []byte, label string) io.Reader {
+	hasher := blake3.New(32, nil)
+	hasher.Write([]byte(corpusDomain))
+	writeLengthPrefixed(hasher, seed)
+	writeLengthPrefixed(hasher, []byte(label))
+	return hasher.XOF()
+}
+
+// corpusBytes reads n bytes of the stream for label.
+func corpusBytes(seed []byte, label string, n int) []byte {
+	b := make([]byte, n)
+	io.ReadFull(corpusStream(seed, label), b)
+	return b
+}
+
+// GenerateCorpus writes a labeled proof corpus to dir and returns its
+// manifest, which is also written to dir/manifest.json. Deterministic
+// proofs are byte-identical for the same seed and options; randomized ones
+// use fresh nonces, the current time and hedged signatures. Each label is
+// checked against VerifySecureProofVersioned before it is written.
+func GenerateCorpus(dir string, opts CorpusOptions) (*CorpusManifest, error) {
+	if len(opts.Seed) != 32 {
+		return nil, fmt.Errorf("corpus seed must be 32 bytes, got %d", len(opts.Seed))
+	}
+	if len(opts.SoundnessLevels) == 0 || len(opts.Codecs) == 0 || len(opts.Profiles) == 0 {
+		return nil, errors.New("corpus needs at least one soundness level, codec and profile")
+	}
+	for _, codec := range opts.Codecs {
+		if _, err := EncodeProof(codec, nil); err != nil {
+			return nil, err
+		}
+	}
+	for _, tamper := range opts.Tampers {
+		if _, err := tamperProof(&SecureProof{}, tamper); err != nil {
+			return nil, err
+		}
+	}
+
+	signer, err := NewSignatureSchemeFromSeed(corpusBytes(opts.Seed, "signing-key", 32), []byte(opts.Context))
+	if err != nil {
+		return nil, err
+	}
+	key := corpusBytes(opts.Seed, "proof-key", 32)
+	secret := corpusBytes(opts.Seed, "secret", 64)
+
+	manifest := &CorpusManifest{
+		ProofVersion: CurrentProofVersion,
+		Seed:         hex.EncodeToString(opts.Seed),
+		PublicKey:    hex.EncodeToString(signer.PublicKeyBytes()),
+		Key:          hex.EncodeToString(key),
+	}
+
+	for _, bits := range opts.SoundnessLevels {
+		for _, profile := range opts.Profiles {
+			for _, deterministic := range []bool{true, false} {
+				sq, err := NewSecureQuantumZKPWithSoundness(3, 128, bits, []byte(opts.Context))
+				if err != nil {
+					return nil, err
+				}
+				modeSigner := *signer
+				modeSigner.Deterministic = deterministic
+				sq.Signer = &modeSigner
+				sq.Profile = profile
+				mode := "randomized"
+				if deterministic {
+					mode = "deterministic"
+					sq.Rand = corpusStream(opts.Seed, fmt.Sprintf("nonces/%d/%s", bits, profile))
+					sq.Clock = func() time.Time { return corpusClock }
+				}
+				manifest.Context = sq.Context
+
+				proof, err := sq.SecureProveFromBytesWithOptions(secret, "corpus", key, BytesProofOptions{Dimension: 8})
+				if err != nil {
+					return nil, fmt.Errorf("soundness %d: %w", bits, err)
+				}
+
+				variants := append([]string{CorpusVariantValid}, opts.Tampers...)
+				for _, variant := range variants {
+					p := proof
+					if variant != CorpusVariantValid {
+						if p, err = tamperProof(proof, variant); err != nil {
+							return nil, err
+						}
+						if variant != TamperSignature {
+							if err := sq.signSecureProof(p, key); err != nil {
+								return nil, err
+							}
+						}
+					}
+					valid := variant == CorpusVariantValid
+					if sq.VerifySecureProofVersioned(p, key).Valid != valid {
+						return nil, fmt.Errorf("soundness %d %s %s proof does not verify as labeled", bits, mode, variant)
+					}
+
+					proofJSON, err := json.Marshal(p)
+					if err != nil {
+						return nil, err
+					}
+					for _, codec := range opts.Codecs {
+						encoded, err := EncodeProof(codec, proofJSON)
+						if err != nil {
+							return nil, err
+						}
+						name := corpusFileName(bits, profile, mode, variant, codec)
+						if err := writeFileAtomic(filepath.Join(dir, "proofs", name), encoded, 0644); err != nil {
+							return nil, err
+						}
+						sum := sha256.Sum256(encoded)
+						manifest.Entries = append(manifest.Entries, CorpusEntry{
+							File:          "proofs/" + name,
+							SHA256:        hex.EncodeToString(sum[:]),
+							SoundnessBits: bits,
+							Codec:         codec,
+							Profile:       profile,
+							Deterministic: deterministic,
+							Variant:       variant,
+							Valid:         valid,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, CorpusManifestFile), data, 0644); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// corpusFileName names a proof file after its labels, with one extension
+// per codec layer, e.g. s080_interop_deterministic_valid.json.gz.b64.
+func corpusFileName(bits int, profile, mode, variant, codec string) string {
+	if profile == "" {
+		profile = "noprofile"
+	}
+	layers := strings.Split(codec, "+")
+	ext := ""
+	for i := len(layers) - 1; i >= 0; i-- {
+		switch layers[i] {
+		case "gzip":
+			ext += ".gz"
+		case "base64":
+			ext += ".b64"
+		case "base64url":
+			ext += ".b64url"
+		default:
+			ext += "." + layers[i]
+		}
+	}
+	return fmt.Sprintf("s%03d_%s_%s_%s%s", bits, profile, mode, variant, ext)
+}
+
+// tamperProof returns a deep copy of proof with the given defect.
+func tamperProof(proof *SecureProof, tamper string) (*SecureProof, error) {
+	p, err := copySecureProof(proof)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tamper {
+	case TamperSignature:
+		p.Signature = flipLastHexDigit(p.Signature)
+	case TamperMerkleRoot:
+		p.MerkleRoot = flipLastHexDigit(p.MerkleRoot)
+	case TamperResponse:
+		if len(p.ChallengeResponse) > 0 {
+			p.ChallengeResponse[0].Response = flipLastHexDigit(p.ChallengeResponse[0].Response)
+		}
+	case TamperTruncatedCommitment:
+		if len(p.CommitmentHash) >= 2 {
+			p.CommitmentHash = p.CommitmentHash[:len(p.CommitmentHash)-2]
+		}
+	case TamperDowngradedVersion:
+		p.Version = ProofVersionUnboundParameters
+	default:
+		return nil, fmt.Errorf("unknown tamper kind %q", tamper)
+	}
+	return p, nil
+}
+
+// flipLastHexDigit changes the final digit of a hex string, keeping it
+// valid hex.
+func flipLastHexDigit(s string) string {
+	if s == "" {
+		return s
+	}
+	last := s[len(s)-1]
+	if last == '0' {
+		last = '1'
+	} else {
+		last = '0'
+	}
+	return s[:len(s)-1] + string(last)
+}
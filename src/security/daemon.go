@@ -0,0 +1,602 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ErrQueueFull is returned when the daemon's work queue is at capacity.
+var ErrQueueFull = errors.New("work queue is full")
+
+// ErrDraining is returned for work submitted after shutdown began.
+var ErrDraining = errors.New("daemon is draining")
+
+// TimeoutHeader carries the caller's remaining deadline in milliseconds so
+// the daemon stops waiting for work the caller has given up on.
+const TimeoutHeader = "X-Qzkp-Timeout-Ms"
+
+// DaemonConfig configures qzkpd. It embeds the service configuration so a
+// single file can be reloaded on SIGHUP.
+type DaemonConfig struct {
+	ServiceConfig
+	Listen       string `json:"listen"`
+	Workers      int    `json:"workers"`
+	QueueSize    int    `json:"queue_size"`
+	DrainTimeout string `json:"drain_timeout"` // time.ParseDuration format
+	// DataDir enables the asynchronous /v1/jobs API; jobs and their proofs
+	// are kept in its proof store, so pending jobs survive a restart. Jobs
+	// get their own pool of Workers and QueueSize
+	DataDir string `json:"data_dir,omitempty"`
+	// FeatureFile is the path of a FeatureFile checked for changes every
+	// FeaturePoll (time.ParseDuration format, 5s if empty) while Run
+	// serves. Its settings override the configured ones and its
+	// kill_switch trips the kill switch, without a reload
+	FeatureFile string `json:"feature_file,omitempty"`
+	FeaturePoll string `json:"feature_poll,omitempty"`
+}
+
+// withDefaults fills unset operational settings.
+func (c DaemonConfig) withDefaults() DaemonConfig {
+	if c.Listen == "" {
+		c.Listen = ":8080"
+	}
+	if c.Workers <= 0 {
+		c.Workers = 4
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 64
+	}
+	if c.DrainTimeout == "" {
+		c.DrainTimeout = "30s"
+	}
+	if c.FeaturePoll == "" {
+		c.FeaturePoll = "5s"
+	}
+	return c
+}
+
+// LoadDaemonConfig reads a JSON DaemonConfig from path, refusing files
+// written for a newer release with ErrSchemaTooNew.
+func LoadDaemonConfig(path string) (DaemonConfig, error) {
+	var cfg DaemonConfig
+	data, err := hostFS.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config: %w", err)
+	}
+	if data, err = ConfigSchema.UpgradeJSON(data); err != nil {
+		return cfg, fmt.Errorf("failed to load config %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse config: %w", err)
+	}
+	cfg = cfg.withDefaults()
+	if cfg.InMemory && cfg.DataDir != "" {
+		return cfg, fmt.Errorf("%w: data_dir needs the filesystem", ErrInMemory)
+	}
+	if cfg.InMemory && cfg.FeatureFile != "" {
+		return cfg, fmt.Errorf("%w: feature_file needs the filesystem", ErrInMemory)
+	}
+	if _, err := time.ParseDuration(cfg.DrainTimeout); err != nil {
+		return cfg, fmt.Errorf("invalid drain_timeout: %w", err)
+	}
+	if poll, err := time.ParseDuration(cfg.FeaturePoll); err != nil || poll <= 0 {
+		return cfg, fmt.Errorf("invalid feature_poll %q", cfg.FeaturePoll)
+	}
+	return cfg, nil
+}
+
+// daemonJob is one unit of queued work.
+type daemonJob struct {
+	run  func(*ProverService) (interface{}, error)
+	done chan daemonResult
+}
+
+type daemonResult struct {
+	value interface{}
+	err   error
+}
+
+// Daemon runs a ProverService behind a bounded worker pool and an HTTP
+// front end with liveness and readiness endpoints.
+type Daemon struct {
+	configPath string
+	config     DaemonConfig
+	service    atomic.Pointer[ProverService]
+	jobs       chan daemonJob
+	workers    sync.WaitGroup
+	ready      atomic.Bool
+	jobQueue   *ProofJobQueue // nil without DataDir
+	mu         sync.RWMutex   // guards closing jobs against concurrent submits
+	draining   bool
+
+	featureMu    sync.Mutex // serializes reloads and feature file updates
+	featureStamp featureFileStamp
+	fileTripped  bool // the kill switch was tripped by the feature file
+
+	// Logf reports lifecycle events; it defaults to timestamped stderr
+	// output
+	Logf func(format string, args ...interface{})
+	// Anomalies is fed an event for every request to /v1/verify; it
+	// defaults to a StatisticalAnomalyDetector and nil disables it.
+	// OnAnomaly receives what it flags, and defaults to logging through
+	// Logf. Both must be set before serving
+	Anomalies AnomalyDetector
+	OnAnomaly func(Anomaly)
+}
+
+// stderrLogf writes a timestamped line to stderr.
+func stderrLogf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "%s %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
+
+// NewDaemon loads the configuration at configPath and starts the worker
+// pool. Call Run to serve, or Handler and Shutdown to embed it.
+func NewDaemon(configPath string) (*Daemon, error) {
+	cfg, err := LoadDaemonConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	service, err := NewProverService(cfg.ServiceConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Daemon{
+		configPath: configPath,
+		config:     cfg,
+		jobs:       make(chan daemonJob, cfg.QueueSize),
+		Logf:       stderrLogf,
+		Anomalies:  NewStatisticalAnomalyDetector(AnomalyOptions{}),
+	}
+	d.service.Store(service)
+	if cfg.FeatureFile != "" {
+		if _, err := d.refreshFeatures(true); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.DataDir != "" {
+		store, err := NewProofStore(NewDataDirs(cfg.DataDir))
+		if err != nil {
+			return nil, err
+		}
+		prove := func(req *ProveRequest) (*ProveResponse, error) { return d.service.Load().Prove(req) }
+		d.jobQueue, err = NewProofJobQueue(store, prove, ProofJobOptions{Workers: cfg.Workers, QueueSize: cfg.QueueSize})
+		if err != nil {
+			return nil, fmt.Errorf("failed to open job queue: %w", err)
+		}
+	}
+	for i := 0; i < cfg.Workers; i++ {
+		d.workers.Add(1)
+		go d.worker()
+	}
+	d.ready.Store(true)
+	return d, nil
+}
+
+// worker executes queued jobs against the service current at pickup time.
+func (d *Daemon) worker() {
+	defer d.workers.Done()
+	for job := range d.jobs {
+		value, err := job.run(d.service.Load())
+		job.done <- daemonResult{value: value, err: err}
+	}
+}
+
+// submit enqueues run without blocking and waits for its result or for ctx
+// to end. Jobs abandoned by their caller still run to completion.
+func (d *Daemon) submit(ctx context.Context, run func(*ProverService) (interface{}, error)) (interface{}, error) {
+	job := daemonJob{run: run, done: make(chan daemonResult, 1)}
+
+	d.mu.RLock()
+	if d.draining {
+		d.mu.RUnlock()
+		return nil, ErrDraining
+	}
+	select {
+	case d.jobs <- job:
+	default:
+		d.mu.RUnlock()
+		return nil, ErrQueueFull
+	}
+	d.mu.RUnlock()
+
+	select {
+	case res := <-job.done:
+		return res.value, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Reload re-reads the configuration file and swaps in a new service.
+// Operational settings (listen address, pool sizes, feature file) need a
+// restart; only the service configuration and key are reloaded, and the
+// feature file's settings and the kill switch are kept. On error the
+// running service is kept.
+func (d *Daemon) Reload() error {
+	cfg, err := LoadDaemonConfig(d.configPath)
+	if err != nil {
+		return err
+	}
+	d.featureMu.Lock()
+	defer d.featureMu.Unlock()
+	service, err := newProverService(cfg.ServiceConfig, d.service.Load())
+	if err != nil {
+		return err
+	}
+	d.service.Store(service)
+	return nil
+}
+
+// KillSwitch returns the switch that stops proof issuance on /v1/prove
+// and /v1/jobs while verification stays online. It survives reloads.
+// Jobs already queued when it is tripped fail with ErrIssuanceDisabled.
+func (d *Daemon) KillSwitch() *KillSwitch {
+	return d.service.Load().KillSwitch
+}
+
+// featureFileStamp identifies a version of the feature file.
+type featureFileStamp struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+func (s featureFileStamp) equal(other featureFileStamp) bool {
+	return s.exists == other.exists && s.modTime.Equal(other.modTime) && s.size == other.size
+}
+
+// refreshFeatures applies the feature file if it changed since it was last
+// read, or in any case when force is set, and reports whether it did. A
+// missing file clears the file's settings and resets a kill switch it
+// tripped. A file that fails to parse is not read again until it changes.
+func (d *Daemon) refreshFeatures(force bool) (bool, error) {
+	d.featureMu.Lock()
+	defer d.featureMu.Unlock()
+
+	var stamp featureFileStamp
+	info, err := hostFS.Stat(d.config.FeatureFile)
+	if err == nil {
+		stamp = featureFileStamp{exists: true, modTime: info.ModTime(), size: info.Size()}
+	} else if !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to read feature file: %w", err)
+	}
+	if !force && stamp.equal(d.featureStamp) {
+		return false, nil
+	}
+	d.featureStamp = stamp
+
+	var file FeatureFile
+	if stamp.exists {
+		data, err := hostFS.ReadFile(d.config.FeatureFile)
+		if err != nil {
+			return false, fmt.Errorf("failed to read feature file: %w", err)
+		}
+		if err := json.Unmarshal(data, &file); err != nil {
+			return false, fmt.Errorf("failed to parse feature file %s: %w", d.config.FeatureFile, err)
+		}
+	}
+	service := d.service.Load()
+	if err := service.applyFeatureOverrides(file.Features); err != nil {
+		return false, fmt.Errorf("feature file %s: %w", d.config.FeatureFile, err)
+	}
+	switch {
+	case file.KillSwitch != "":
+		service.KillSwitch.Trip(file.KillSwitch)
+		d.fileTripped = true
+	case d.fileTripped:
+		service.KillSwitch.Reset()
+		d.fileTripped = false
+	}
+	return true, nil
+}
+
+// Handler returns the daemon's HTTP API. /healthz reports the prover
+// self-test run at startup or on the last reload, with status 503 if it
+// failed; probes do not rerun it.
+// /v1/features reports the FeatureStatus; while the kill switch is
+// tripped proving answers 503 and the daemon stays ready, since it still
+// verifies.
+func (d *Daemon) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		result := d.service.Load().SelfTest()
+		if !result.Passed {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(result)
+			return
+		}
+		writeJSONResponse(w, r, result)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !d.ready.Load() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/v1/info", func(w http.ResponseWriter, r *http.Request) {
+		writeJSONResponse(w, r, d.service.Load().Info())
+	})
+	mux.HandleFunc("/v1/features", func(w http.ResponseWriter, r *http.Request) {
+		writeJSONResponse(w, r, d.service.Load().FeatureStatus())
+	})
+	mux.HandleFunc("/v1/metrics", func(w http.ResponseWriter, r *http.Request) {
+		writeJSONResponse(w, r, d.service.Load().Metrics.Snapshot())
+	})
+	mux.HandleFunc("/v1/prove", func(w http.ResponseWriter, r *http.Request) {
+		var req ProveRequest
+		d.serveJob(w, r, proveRequestLimits, &req, func(s *ProverService) (interface{}, error) { return s.Prove(&req) })
+	})
+	mux.HandleFunc("/v1/verify", func(w http.ResponseWriter, r *http.Request) {
+		var req VerifyRequest
+		start := time.Now()
+		d.serveJob(w, r, ProofJSONLimits, &req, func(s *ProverService) (interface{}, error) {
+			response, decision, err := s.verify(&req)
+			d.observeVerification(r, s, req.Proof, decision, time.Since(start))
+			return response, err
+		})
+	})
+	if d.jobQueue != nil {
+		d.handleProofJobs(mux)
+	}
+	return mux
+}
+
+// observeVerification feeds a verification to the anomaly detector and
+// reports what it flags.
+func (d *Daemon) observeVerification(r *http.Request, s *ProverService, proof *SecureProof, decision VerificationDecision, latency time.Duration) {
+	if d.Anomalies == nil {
+		return
+	}
+	source := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(source); err == nil {
+		source = host
+	}
+	key := KeyFingerprint(s.sq.Signer.PublicKeyBytes())
+	for _, anomaly := range d.Anomalies.Observe(NewVerificationEvent(source, key, proof, decision, latency)) {
+		if d.OnAnomaly != nil {
+			d.OnAnomaly(anomaly)
+		} else {
+			d.Logf("verification anomaly %s from %s: %s", anomaly.Kind, anomaly.Source, anomaly.Detail)
+		}
+	}
+}
+
+// handleProofJobs adds the asynchronous proving API: POST /v1/jobs submits
+// a ProofJobRequest and answers 202 with its JobInfo, GET /v1/jobs/{id}
+// reports the status and GET /v1/jobs/{id}/result returns the proof once
+// the job has succeeded.
+func (d *Daemon) handleProofJobs(mux *http.ServeMux) {
+	mux.HandleFunc("POST /v1/jobs", func(w http.ResponseWriter, r *http.Request) {
+		if err := d.KillSwitch().check(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		var req ProofJobRequest
+		if err := DecodeBoundedJSON(r.Body, proveRequestLimits, &req); err != nil {
+			http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		id, err := d.jobQueue.SubmitProofJob(&req)
+		switch {
+		case errors.Is(err, ErrQueueFull), errors.Is(err, ErrDraining):
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		info, _ := d.jobQueue.JobStatus(id)
+		w.Header().Set("Location", "/v1/jobs/"+string(id))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(info)
+	})
+	mux.HandleFunc("GET /v1/jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		info, err := d.jobQueue.JobStatus(JobID(r.PathValue("id")))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSONResponse(w, r, info)
+	})
+	mux.HandleFunc("GET /v1/jobs/{id}/result", func(w http.ResponseWriter, r *http.Request) {
+		proof, err := d.jobQueue.JobResult(JobID(r.PathValue("id")))
+		switch {
+		case errors.Is(err, ErrJobNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, ErrJobPending):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		writeJSONResponse(w, r, &ProveResponse{Proof: proof})
+	})
+}
+
+// proveRequestLimits bounds proving requests, whose data may be as large as
+// DefaultMaxInputSize once base64-decoded.
+var proveRequestLimits = JSONLimits{
+	MaxBytes:   DefaultMaxInputSize/3*4 + 64<<10,
+	FieldBytes: map[string]int{"data": DefaultMaxInputSize/3*4 + 4},
+}
+
+// serveJob decodes a JSON POST body into req within limits and answers
+// with the result of run executed on the worker pool.
+func (d *Daemon) serveJob(w http.ResponseWriter, r *http.Request, limits JSONLimits, req interface{}, run func(*ProverService) (interface{}, error)) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body := io.Reader(r.Body)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, "invalid gzip body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+	if err := DecodeBoundedJSON(body, limits, req); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrJSONLimit) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, "invalid request: "+err.Error(), status)
+		return
+	}
+
+	ctx := r.Context()
+	if v := r.Header.Get(TimeoutHeader); v != "" {
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || ms <= 0 {
+			http.Error(w, "invalid "+TimeoutHeader, http.StatusBadRequest)
+			return
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(ms)*time.Millisecond)
+		defer cancel()
+	}
+
+	value, err := d.submit(ctx, run)
+	switch {
+	case errors.Is(err, ErrQueueFull), errors.Is(err, ErrDraining):
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case errors.Is(err, ErrIssuanceDisabled):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case errors.Is(err, context.DeadlineExceeded):
+		http.Error(w, err.Error(), http.StatusGatewayTimeout)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	writeJSONResponse(w, r, value)
+}
+
+// writeJSONResponse encodes value as JSON, gzip-compressed when the client
+// accepts it.
+func writeJSONResponse(w http.ResponseWriter, r *http.Request, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		json.NewEncoder(w).Encode(value)
+		return
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	gz := gzip.NewWriter(w)
+	defer gz.Close()
+	json.NewEncoder(gz).Encode(value)
+}
+
+// Shutdown marks the daemon unready, rejects new work and waits for queued
+// and in-flight jobs to finish or ctx to expire.
+func (d *Daemon) Shutdown(ctx context.Context) error {
+	d.ready.Store(false)
+
+	d.mu.Lock()
+	if !d.draining {
+		d.draining = true
+		close(d.jobs)
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("drain incomplete: %w", ctx.Err())
+	}
+	// Queued jobs stay pending in the store for the next start
+	if d.jobQueue != nil {
+		return d.jobQueue.Close(ctx)
+	}
+	return nil
+}
+
+// Run serves HTTP until SIGINT or SIGTERM, reloading on SIGHUP and
+// applying changes to the feature file as they are seen, then drains
+// in-flight work within the configured drain timeout.
+func (d *Daemon) Run() error {
+	server := &http.Server{Addr: d.config.Listen, Handler: d.Handler()}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+	d.Logf("qzkpd listening on %s", d.config.Listen)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var poll <-chan time.Time
+	if d.config.FeatureFile != "" {
+		interval, _ := time.ParseDuration(d.config.FeaturePoll)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case err := <-serveErr:
+			return err
+		case <-poll:
+			if changed, err := d.refreshFeatures(false); err != nil {
+				d.Logf("feature file not applied, keeping current settings: %v", err)
+			} else if changed {
+				status := d.service.Load().FeatureStatus()
+				d.Logf("feature file applied: disabled %v, kill switch tripped %v", disabledFeatures(status.Features), status.KillSwitch.Tripped)
+			}
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				if err := d.Reload(); err != nil {
+					d.Logf("reload failed, keeping current configuration: %v", err)
+				} else {
+					d.Logf("configuration reloaded")
+				}
+				continue
+			}
+
+			d.Logf("received %s, draining", sig)
+			drainTimeout, _ := time.ParseDuration(d.config.DrainTimeout)
+			ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+			defer cancel()
+
+			// Stop accepting connections and wait for handlers, which in
+			// turn wait for their queued jobs
+			d.ready.Store(false)
+			if err := server.Shutdown(ctx); err != nil {
+				return err
+			}
+			return d.Shutdown(ctx)
+		}
+	}
+}
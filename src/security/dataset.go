@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Dataset is a committed key-value map. Each entry becomes a Merkle leaf
+// H(0x00 ‖ H(key) ‖ H(salt ‖ value)); leaves are ordered by key so the root
+// depends only on the contents of the map. The per-entry salt keeps values
+// hidden even when they have little entropy.
+type Dataset struct {
+	keys    []string
+	values  map[string][]byte
+	salts   map[string][]byte
+	leaves  [][]byte
+	indexOf map[string]int
+	root    []byte
+}
+
+// DatasetCommitment is the public commitment to a Dataset.
+type DatasetCommitment struct {
+	Root string `json:"root"`
+	Size int    `json:"size"`
+}
+
+// EntryKnowledgeProof shows that the prover knows the value stored under
+// EntryKey without revealing it.
+type EntryKnowledgeProof struct {
+	EntryKey        string       `json:"entry_key"`
+	ValueCommitment string       `json:"value_commitment"`
+	LeafIndex       int          `json:"leaf_index"`
+	Path            []string     `json:"path"`
+	Knowledge       *SecureProof `json:"knowledge"`
+}
+
+// EntryDisclosure reveals a single entry together with its inclusion path.
+type EntryDisclosure struct {
+	EntryKey  string   `json:"entry_key"`
+	Value     []byte   `json:"value"`
+	Salt      string   `json:"salt"`
+	LeafIndex int      `json:"leaf_index"`
+	Path      []string `json:"path"`
+}
+
+// NewDataset commits to entries. The map is copied.
+func NewDataset(entries map[string][]byte) (*Dataset, error) {
+	if len(entries) == 0 {
+		return nil, errors.New("dataset cannot be empty")
+	}
+
+	d := &Dataset{
+		keys:    make([]string, 0, len(entries)),
+		values:  make(map[string][]byte, len(entries)),
+		salts:   make(map[string][]byte, len(entries)),
+		indexOf: make(map[string]int, len(entries)),
+	}
+	for k, v := range entries {
+		d.keys = append(d.keys, k)
+		d.values[k] = append([]byte(nil), v...)
+
+		salt := make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("failed to generate entry salt: %w", err)
+		}
+		d.salts[k] = salt
+	}
+	sort.Strings(d.keys)
+
+	d.leaves = make([][]byte, len(d.keys))
+	for i, k := range d.keys {
+		d.indexOf[k] = i
+		d.leaves[i] = datasetLeaf(k, datasetValueCommitment(d.salts[k], d.values[k]))
+	}
+	d.root = merkleRootOfLeaves(append([][]byte(nil), d.leaves...))
+
+	return d, nil
+}
+
+// Commitment returns the public commitment to the dataset.
+func (d *Dataset) Commitment() DatasetCommitment {
+	return DatasetCommitment{
+		Root: hex.EncodeToString(d.root),
+		Size: len(d.keys),
+	}
+}
+
+// ProveEntryValue discloses the value stored under entryKey along with the
+// data needed to check it against the dataset commitment.
+func (d *Dataset) ProveEntryValue(entryKey string) (*EntryDisclosure, error) {
+	index, ok := d.indexOf[entryKey]
+	if !ok {
+		return nil, fmt.Errorf("entry %q not in dataset", entryKey)
+	}
+
+	return &EntryDisclosure{
+		EntryKey:  entryKey,
+		Value:     append([]byte(nil), d.values[entryKey]...),
+		Salt:      hex.EncodeToString(d.salts[entryKey]),
+		LeafIndex: index,
+		Path:      encodeMerklePath(merkleInclusionPath(d.leaves, index)),
+	}, nil
+}
+
+// VerifyEntryValue checks a disclosed entry against a dataset commitment.
+func VerifyEntryValue(commitment DatasetCommitment, disclosure *EntryDisclosure) bool {
+	if disclosure == nil {
+		return false
+	}
+	salt, err := hex.DecodeString(disclosure.Salt)
+	if err != nil {
+		return false
+	}
+	valueCommitment := datasetValueCommitment(salt, disclosure.Value)
+	return verifyDatasetInclusion(commitment, disclosure.EntryKey, valueCommitment,
+		disclosure.LeafIndex, disclosure.Path)
+}
+
+// ProveEntryKnowledge proves knowledge of the value stored under entryKey
+// without disclosing it. The secure proof is bound to the entry through its
+// identifier, which names the dataset root and the entry's value commitment.
+func (sq *SecureQuantumZKP) ProveEntryKnowledge(
+	dataset *Dataset,
+	entryKey string,
+	key []byte,
+) (*EntryKnowledgeProof, error) {
+	index, ok := dataset.indexOf[entryKey]
+	if !ok {
+		return nil, fmt.Errorf("entry %q not in dataset", entryKey)
+	}
+
+	valueCommitment := datasetValueCommitment(dataset.salts[entryKey], dataset.values[entryKey])
+	identifier := sq.identifierPrefix() + entryIdentifier(dataset.Commitment(), valueCommitment)
+
+	// Prove knowledge of salt ‖ value so the proof covers exactly what the
+	// leaf commits to
+	secret := append(append([]byte(nil), dataset.salts[entryKey]...), dataset.values[entryKey]...)
+	knowledge, err := sq.SecureProveFromBytes(secret, identifier, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prove entry knowledge: %w", err)
+	}
+
+	return &EntryKnowledgeProof{
+		EntryKey:        entryKey,
+		ValueCommitment: hex.EncodeToString(valueCommitment),
+		LeafIndex:       index,
+		Path:            encodeMerklePath(merkleInclusionPath(dataset.leaves, index)),
+		Knowledge:       knowledge,
+	}, nil
+}
+
+// VerifyEntryKnowledge checks an EntryKnowledgeProof against a dataset
+// commitment.
+func (sq *SecureQuantumZKP) VerifyEntryKnowledge(
+	commitment DatasetCommitment,
+	proof *EntryKnowledgeProof,
+	key []byte,
+) bool {
+	if proof == nil || proof.Knowledge == nil {
+		return false
+	}
+	valueCommitment, err := hex.DecodeString(proof.ValueCommitment)
+	if err != nil {
+		return false
+	}
+	if !verifyDatasetInclusion(commitment, proof.EntryKey, valueCommitment, proof.LeafIndex, proof.Path) {
+		return false
+	}
+	if !sq.matchesIdentifier(proof.Knowledge, sq.identifierPrefix()+entryIdentifier(commitment, valueCommitment)) {
+		return false
+	}
+	return sq.VerifySecureProof(proof.Knowledge, key)
+}
+
+// entryIdentifier names a dataset entry in the identifier of its proof.
+func entryIdentifier(commitment DatasetCommitment, valueCommitment []byte) string {
+	return fmt.Sprintf("dataset:%s:%x", commitment.Root, valueCommitment)
+}
+
+// datasetValueCommitment computes H(salt ‖ value).
+func datasetValueCommitment(salt, value []byte) []byte {
+	hasher := sha256.New()
+	hasher.Write(salt)
+	hasher.Write(value)
+	return hasher.Sum(nil)
+}
+
+// datasetLeaf computes H(0x00 ‖ H(key) ‖ valueCommitment).
+func datasetLeaf(entryKey string, valueCommitment []byte) []byte {
+	keyHash := sha256.Sum256([]byte(entryKey))
+	hasher := sha256.New()
+	hasher.Write([]byte{0x00})
+	hasher.Write(keyHash[:])
+	hasher.Write(valueCommitment)
+	return hasher.Sum(nil)
+}
+
+// verifyDatasetInclusion recomputes the leaf for an entry and checks its
+// path up to the committed root.
+func verifyDatasetInclusion(
+	commitment DatasetCommitment,
+	entryKey string,
+	valueCommitment []byte,
+	index int,
+	path []string,
+) bool {
+	if index < 0 || index >= commitment.Size {
+		return false
+	}
+	root, err := hex.DecodeString(commitment.Root)
+	if err != nil {
+		return false
+	}
+	siblings, err := decodeMerklePath(path)
+	if err != nil {
+		return false
+	}
+	return verifyMerklePath(datasetLeaf(entryKey, valueCommitment), index, siblings, root)
+}
+
+// merkleInclusionPath returns the sibling hashes from leaf index up to the
+// root of the tree built by merkleRootOfLeaves.
+func merkleInclusionPath(leaves [][]byte, index int) [][]byte {
+	var path [][]byte
+	level := leaves
+	for len(level) > 1 {
+		sibling := index ^ 1
+		if sibling >= len(level) {
+			sibling = index // odd levels duplicate the last node
+		}
+		path = append(path, level[sibling])
+
+		var next [][]byte
+		for i := 0; i < len(level); i += 2 {
+			hasher := sha256.New()
+			hasher.Write(level[i])
+			if i+1 < len(level) {
+				hasher.Write(level[i+1])
+			} else {
+				hasher.Write(level[i])
+			}
+			next = append(next, hasher.Sum(nil))
+		}
+		level = next
+		index /= 2
+	}
+	return path
+}
+
+// verifyMerklePath folds leaf with its siblings and compares against root.
+func verifyMerklePath(leaf []byte, index int, path [][]byte, root []byte) bool {
+	current := leaf
+	for _, sibling := range path {
+		hasher := sha256.New()
+		if index%2 == 0 {
+			hasher.Write(current)
+			hasher.Write(sibling)
+		} else {
+			hasher.Write(sibling)
+			hasher.Write(current)
+		}
+		current = hasher.Sum(nil)
+		index /= 2
+	}
+	return index == 0 && bytes.Equal(current, root)
+}
+
+// encodeMerklePath hex-encodes a Merkle path.
+func encodeMerklePath(path [][]byte) []string {
+	out := make([]string, len(path))
+	for i, node := range path {
+		out[i] = hex.EncodeToString(node)
+	}
+	return out
+}
+
+// decodeMerklePath reverses encodeMerklePath.
+func decodeMerklePath(path []string) ([][]byte, error) {
+	out := make([][]byte, len(path))
+	for i, node := range path {
+		decoded, err := hex.DecodeString(node)
+		if err != nil {
+			return nil, err
+		}
+		if len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("path node %d has length %d", i, len(decoded))
+		}
+		out[i] = decoded
+	}
+	return out, nil
+}
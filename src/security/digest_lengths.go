@@ -0,0 +1,80 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// Digest length bounds in bytes. Digests are SHA-256, so 32 is the full
+// length; below 8 a response could be guessed outright.
+const (
+	MinDigestLength  = 8
+	FullDigestLength = sha256.Size
+)
+
+// LegacyDigestLengths are the truncated lengths used by proof versions
+// before 3, which did not record them.
+var LegacyDigestLengths = DigestLengths{Commitment: 16, Response: 8}
+
+// DigestLengths sets how many bytes of each digest a proof publishes.
+// Commitment is the state commitment that seeds challenge derivation;
+// Response applies to each response, measurement commitment and
+// per-challenge proof digest.
+type DigestLengths struct {
+	Commitment int `json:"commitment"`
+	Response   int `json:"response"`
+}
+
+// DigestLengthsFor returns the lengths for a soundness level in bits. A
+// b-byte digest resists collisions up to 2^(4b) work, so digests are
+// 2·bits/8 bytes, never below 16 and at full length from 128-bit soundness
+// up.
+func DigestLengthsFor(soundnessBits int) DigestLengths {
+	length := FullDigestLength
+	// A soundness too large to double needs full-length digests anyway
+	if doubled, err := checkedMul(soundnessBits, 2); err == nil {
+		length, _ = checkedCeilDiv(max(doubled, 0), 8)
+	}
+	length = min(max(length, 16), FullDigestLength)
+	return DigestLengths{Commitment: length, Response: length}
+}
+
+// Validate checks both lengths are within [MinDigestLength, FullDigestLength].
+func (d DigestLengths) Validate() error {
+	for _, length := range []int{d.Commitment, d.Response} {
+		if length < MinDigestLength || length > FullDigestLength {
+			return fmt.Errorf("digest length %d outside [%d, %d]", length, MinDigestLength, FullDigestLength)
+		}
+	}
+	return nil
+}
+
+// AtLeast reports whether both lengths are no shorter than min's.
+func (d DigestLengths) AtLeast(min DigestLengths) bool {
+	return d.Commitment >= min.Commitment && d.Response >= min.Response
+}
+
+// digestLengths returns the lengths the proof declares, or the legacy
+// lengths for formats that did not record them.
+func (proof *SecureProof) digestLengths() DigestLengths {
+	if proof.DigestLengths == nil {
+		return LegacyDigestLengths
+	}
+	return *proof.DigestLengths
+}
+
+// verifyDigestLengths checks that the commitment and every response digest
+// have exactly the declared lengths.
+func verifyDigestLengths(commitmentHash string, responses []ChallengeResponse, lengths DigestLengths) bool {
+	if len(commitmentHash) != 2*lengths.Commitment {
+		return false
+	}
+	for _, response := range responses {
+		for _, digest := range []string{response.Response, response.Commitment, response.Proof} {
+			if len(digest) != 2*lengths.Response {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultEmbeddedMaxProofBytes bounds the proofs VerifyBytes accepts when
+// the policy sets no limit.
+const DefaultEmbeddedMaxProofBytes = 1 << 20
+
+// ReasonCode is the machine-readable outcome of VerifyBytes, for policy
+// engines to branch on. New codes may be added; callers should treat any
+// code but ReasonOK as a rejection.
+type ReasonCode string
+
+// Reason codes returned by VerifyBytes.
+const (
+	ReasonOK              ReasonCode = "ok"
+	ReasonInvalidPolicy   ReasonCode = "invalid_policy"     // the policy JSON is malformed or inconsistent
+	ReasonInvalidKey      ReasonCode = "invalid_public_key" // not an ML-DSA-87 public key
+	ReasonResourceLimit   ReasonCode = "resource_limit"     // the proof exceeds the policy's limits
+	ReasonMalformedProof  ReasonCode = "malformed_proof"    // not a structurally valid proof of a supported version
+	ReasonWrongContext    ReasonCode = "wrong_context"      // made for another application, version or epoch
+	ReasonExpired         ReasonCode = "expired"            // older than the policy's max_age
+	ReasonPolicyViolation ReasonCode = "policy_violation"   // a header rule or strict mode rejected it
+	ReasonInvalidProof    ReasonCode = "invalid_proof"      // the signature or proof does not verify
+)
+
+// EmbeddedPolicy is the JSON policy of VerifyBytes. It names the
+// parameters and context proofs must have been made under, since an
+// embedded verifier has no SecureQuantumZKP of its own, and the header
+// rules proofs must pass. Unknown fields are rejected, so that a
+// misspelled rule fails closed instead of being ignored.
+type EmbeddedPolicy struct {
+	Dimensions     int    `json:"dimensions"`
+	SecurityLevel  int    `json:"security_level"`
+	SoundnessBits  int    `json:"soundness_bits,omitempty"` // chosen from security_level as by NewSecureQuantumZKP if zero
+	Application    string `json:"application"`
+	ContextVersion int    `json:"context_version"`
+	Epoch          string `json:"epoch,omitempty"`
+
+	MinSoundness int    `json:"min_soundness,omitempty"`
+	Profile      string `json:"profile,omitempty"`
+	StrictMode   bool   `json:"strict_mode,omitempty"`
+	// MaxAge rejects proofs created more than MaxAge before Now. The
+	// verifier reads no clock, so Now must be given with it
+	MaxAge string    `json:"max_age,omitempty"` // time.ParseDuration format
+	Now    time.Time `json:"now,omitempty"`
+
+	MaxProofBytes int `json:"max_proof_bytes,omitempty"` // DefaultEmbeddedMaxProofBytes if zero
+}
+
+// VerifyCost is the work VerifyBytes does for a proof. It is computed from
+// the sizes of the inputs and the shape of the proof, never measured, so
+// the same inputs always cost the same on any machine. Hashes counts the
+// Merkle leaves and nodes and the derived challenges.
+type VerifyCost struct {
+	ProofBytes      int `json:"proof_bytes"`
+	Challenges      int `json:"challenges"`
+	Hashes          int `json:"hashes"`
+	SignatureChecks int `json:"signature_checks"`
+}
+
+// EmbeddedVerification is the detailed outcome of VerifyBytesDetailed.
+// Detail explains Reason for logs and may change between releases.
+type EmbeddedVerification struct {
+	Valid  bool       `json:"valid"`
+	Reason ReasonCode `json:"reason"`
+	Detail string     `json:"detail,omitempty"`
+	Cost   VerifyCost `json:"cost"`
+}
+
+// VerifyBytes verifies a JSON proof against an encoded ML-DSA-87 public
+// key under a JSON EmbeddedPolicy, for policy engines and admission
+// controllers that gate on proof validity inline. It is a pure function:
+// it reads no files, clock or network, keeps no state between calls, and
+// refuses proofs whose size or challenge count exceeds what the policy
+// allows before doing any cryptographic work, so its CPU time is bounded
+// by the policy.
+//
+// Proofs carrying a time attestation or made in an interactive session
+// need verifier state VerifyBytes does not have and are reported as
+// ReasonInvalidProof; cosignatures are ignored.
+func VerifyBytes(proof, pubKey, policyJSON []byte) (bool, ReasonCode) {
+	result := VerifyBytesDetailed(proof, pubKey, policyJSON)
+	return result.Valid, result.Reason
+}
+
+// VerifyBytesDetailed is VerifyBytes with an explanation and the cost of
+// the verification.
+func VerifyBytesDetailed(proof, pubKey, policyJSON []byte) *EmbeddedVerification {
+	result := &EmbeddedVerification{Cost: VerifyCost{ProofBytes: len(proof)}}
+	reject := func(reason ReasonCode, format string, args ...interface{}) *EmbeddedVerification {
+		result.Reason = reason
+		result.Detail = fmt.Sprintf(format, args...)
+		return result
+	}
+
+	policy, err := parseEmbeddedPolicy(policyJSON)
+	if err != nil {
+		return reject(ReasonInvalidPolicy, "%v", err)
+	}
+	if len(proof) > policy.MaxProofBytes {
+		return reject(ReasonResourceLimit, "proof is %d bytes, more than the %d allowed", len(proof), policy.MaxProofBytes)
+	}
+	sq, err := policy.verifier(pubKey)
+	if err != nil {
+		return reject(ReasonInvalidKey, "%v", err)
+	}
+
+	parsed, err := ParseSecureProof(proof)
+	if err != nil {
+		return reject(ReasonMalformedProof, "%v", err)
+	}
+	n := len(parsed.ChallengeResponse)
+	if n > sq.ChallengeCount() {
+		return reject(ReasonResourceLimit, "proof has %d challenge responses, more than the %d of the policy's parameters", n, sq.ChallengeCount())
+	}
+	result.Cost = VerifyCost{ProofBytes: len(proof), Challenges: n, Hashes: 3*n - 1, SignatureChecks: 1}
+
+	if !parsed.Context.Equal(sq.Context) {
+		return reject(ReasonWrongContext, "proof context %s is not %s", parsed.Context, sq.Context)
+	}
+	if policy.MaxAge != "" {
+		maxAge, _ := time.ParseDuration(policy.MaxAge) // checked by parseEmbeddedPolicy
+		if err := MaxAge(maxAge, sq.now).Check(parsed); err != nil {
+			return reject(ReasonExpired, "%v", err)
+		}
+	}
+	var rules []Rule
+	if policy.MinSoundness > 0 {
+		rules = append(rules, MinSoundness(policy.MinSoundness))
+	}
+	if policy.Profile != "" {
+		rules = append(rules, RequireProfile(policy.Profile))
+	}
+	if err := NewPolicy(rules...).Evaluate(parsed).Err(); err != nil {
+		return reject(ReasonPolicyViolation, "%v", err)
+	}
+	if policy.StrictMode {
+		if violations := sq.strictModeViolations(parsed); len(violations) > 0 {
+			return reject(ReasonPolicyViolation, "strict mode: %s", violations[0])
+		}
+	}
+
+	verified := sq.VerifySecureProofVersioned(parsed, nil)
+	if !verified.Valid {
+		return reject(ReasonInvalidProof, "proof does not verify%s", joinReasons(verified.Reasons))
+	}
+	result.Valid = true
+	result.Reason = ReasonOK
+	return result
+}
+
+// joinReasons formats verification reasons as a suffix of a detail.
+func joinReasons(reasons []string) string {
+	if len(reasons) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(reasons, "; ")
+}
+
+// parseEmbeddedPolicy decodes and validates a policy, filling defaults.
+func parseEmbeddedPolicy(data []byte) (*EmbeddedPolicy, error) {
+	var policy EmbeddedPolicy
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	if policy.Dimensions <= 0 {
+		return nil, fmt.Errorf("invalid dimensions %d", policy.Dimensions)
+	}
+	if policy.SoundnessBits == 0 {
+		policy.SoundnessBits = defaultSoundnessBits(policy.SecurityLevel)
+	}
+	if policy.SoundnessBits < 32 || policy.SoundnessBits > 256 {
+		return nil, fmt.Errorf("soundness_bits %d is outside 32 to 256", policy.SoundnessBits)
+	}
+	if err := policy.context().Validate(); err != nil {
+		return nil, err
+	}
+	if policy.MaxAge != "" {
+		if _, err := time.ParseDuration(policy.MaxAge); err != nil {
+			return nil, fmt.Errorf("invalid max_age: %w", err)
+		}
+		if policy.Now.IsZero() {
+			return nil, fmt.Errorf("max_age needs now, as the verifier reads no clock")
+		}
+	}
+	if policy.MaxProofBytes < 0 {
+		return nil, fmt.Errorf("negative max_proof_bytes %d", policy.MaxProofBytes)
+	}
+	if policy.MaxProofBytes == 0 {
+		policy.MaxProofBytes = DefaultEmbeddedMaxProofBytes
+	}
+	return &policy, nil
+}
+
+// context returns the context proofs must have been made under.
+func (p *EmbeddedPolicy) context() Context {
+	return Context{Version: p.ContextVersion, Application: p.Application, Epoch: p.Epoch}
+}
+
+// verifier returns a verify-only instance for the policy's parameters and
+// context that checks signatures against pubKey. Unlike the constructors,
+// it generates no signing key, and it refuses every host-bound plug-in.
+func (p *EmbeddedPolicy) verifier(pubKey []byte) (*SecureQuantumZKP, error) {
+	c := p.context()
+	signer, err := NewVerificationScheme(pubKey, c.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	sq := &SecureQuantumZKP{
+		QuantumZKP: &QuantumZKP{
+			Dimensions:    p.Dimensions,
+			SecurityLevel: p.SecurityLevel,
+			Cache:         NewResultCache(),
+			Signer:        signer,
+		},
+		SecurityParameter: p.SoundnessBits,
+		ChallengeSpace:    DefaultChallengeSpace,
+		NumericEncoding:   DefaultNumericEncoding(),
+		InMemory:          true,
+	}
+	sq.DigestLengths = DigestLengthsFor(sq.EffectiveSoundness())
+	sq.setContext(c)
+	// Policies without max_age may leave now unset; a zero clock would
+	// date every proof into the future, so only a given now is injected
+	if now := p.Now; !now.IsZero() {
+		sq.Clock = func() time.Time { return now }
+	}
+	return sq, nil
+}
+
+// EmbeddedVerifierFuncs returns VerifyBytes as template functions, for
+// policy engines configured with Go templates:
+//
+//	{{ if qzkpVerify .Proof .PublicKey .Policy }}allow{{ end }}
+//	{{ qzkpVerifyReason .Proof .PublicKey .Policy }}
+//
+// The proof and policy are JSON strings and the public key is hex.
+func EmbeddedVerifierFuncs() template.FuncMap {
+	verify := func(proof, pubKeyHex, policy string) ReasonCode {
+		pubKey, err := hex.DecodeString(pubKeyHex)
+		if err != nil {
+			return ReasonInvalidKey
+		}
+		_, reason := VerifyBytes([]byte(proof), pubKey, []byte(policy))
+		return reason
+	}
+	return template.FuncMap{
+		"qzkpVerify": func(proof, pubKeyHex, policy string) bool {
+			return verify(proof, pubKeyHex, policy) == ReasonOK
+		},
+		"qzkpVerifyReason": func(proof, pubKeyHex, policy string) string {
+			return string(verify(proof, pubKeyHex, policy))
+		},
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// executionProvenanceDomain separates provenance signatures from proof,
+// receipt and log signatures made by the same key.
+const executionProvenanceDomain = "qzkp/execution-provenance/v1"
+
+// provenanceOmittedHeaders are response headers never recorded, as they
+// may carry session credentials.
+var provenanceOmittedHeaders = []string{"Set-Cookie", "Authorization", "Proxy-Authorization", "WWW-Authenticate"}
+
+// ExecutionProvenance is a signed record of the backend exchange an
+// ExecutionResult came from: what was requested, what the backend
+// answered, and when. Results and cached states that claim to come from
+// hardware carry it so that the claim can be checked later against the
+// recorder's key, and against the archived raw response if one was kept.
+type ExecutionProvenance struct {
+	Backend     string         `json:"backend"`
+	Device      *BackendDevice `json:"device,omitempty"` // see BackendPolicy
+	JobID       string         `json:"job_id,omitempty"`
+	Endpoint    string         `json:"endpoint"` // request URL without credentials or query
+	CircuitHash string         `json:"circuit_hash"`
+	Shots       int            `json:"shots"`
+	// Parameters are the request parameters the caller chose to record,
+	// such as the optimization level or backend options
+	Parameters      map[string]string   `json:"parameters,omitempty"`
+	StatusCode      int                 `json:"status_code"`
+	ResponseHeaders map[string][]string `json:"response_headers,omitempty"`
+	BodySHA256      string              `json:"body_sha256"`
+	ResultDigest    string              `json:"result_digest"` // see executionResultDigest
+	ReceivedAt      time.Time           `json:"received_at"`   // recorder's clock
+	// TimeAttestation, when the recorder has a time authority, attests
+	// the time the record was made independently of the recorder's clock
+	TimeAttestation *TimeAttestation `json:"time_attestation,omitempty"`
+	RecorderID      string           `json:"recorder"` // KeyFingerprint of the recording key
+	Signature       string           `json:"signature"`
+}
+
+// BackendExchange is one request to a remote backend and its response, as
+// seen by the RemoteExecutor that made it.
+type BackendExchange struct {
+	JobID      string
+	Device     *BackendDevice // optional; the device as the provider reported it
+	Parameters map[string]string
+	Response   *http.Response // its Request gives the endpoint
+	Body       []byte         // the raw response body
+}
+
+// ProvenanceRecorder signs ExecutionProvenance records with a local key.
+type ProvenanceRecorder struct {
+	Signer *SignatureScheme
+	// TimeAuthority optionally attests each record's time; nil leaves
+	// records on the recorder's clock
+	TimeAuthority TimeAuthority
+	Clock         func() time.Time // optional; nil uses time.Now
+}
+
+// NewProvenanceRecorder creates a recorder that signs with signer.
+func NewProvenanceRecorder(signer *SignatureScheme) (*ProvenanceRecorder, error) {
+	if signer == nil || signer.Priv == nil {
+		return nil, errors.New("provenance signing key is required")
+	}
+	return &ProvenanceRecorder{Signer: signer}, nil
+}
+
+// Record attaches a signed provenance record of exchange to result, which
+// circuit produced on result.Backend. It is meant to be called by a
+// RemoteExecutor once it has parsed the backend's response.
+func (r *ProvenanceRecorder) Record(result *ExecutionResult, circuit *QuantumCircuit, exchange *BackendExchange) error {
+	if result == nil || exchange == nil || exchange.Response == nil {
+		return errors.New("result and backend exchange are required")
+	}
+	circuitHash, err := CircuitHash(circuit)
+	if err != nil {
+		return err
+	}
+	resultDigest, err := executionResultDigest(result)
+	if err != nil {
+		return err
+	}
+	body := sha256.Sum256(exchange.Body)
+	record := &ExecutionProvenance{
+		Backend:         result.Backend,
+		Device:          exchange.Device,
+		JobID:           exchange.JobID,
+		CircuitHash:     circuitHash,
+		Shots:           result.Shots,
+		Parameters:      exchange.Parameters,
+		StatusCode:      exchange.Response.StatusCode,
+		ResponseHeaders: recordedHeaders(exchange.Response.Header),
+		BodySHA256:      hex.EncodeToString(body[:]),
+		ResultDigest:    resultDigest,
+		ReceivedAt:      r.now().UTC(),
+		RecorderID:      KeyFingerprint(r.Signer.PublicKeyBytes()),
+	}
+	if req := exchange.Response.Request; req != nil && req.URL != nil {
+		endpoint := *req.URL
+		endpoint.User, endpoint.RawQuery, endpoint.Fragment = nil, "", ""
+		record.Endpoint = endpoint.String()
+	}
+
+	if r.TimeAuthority != nil {
+		nonce, err := record.attestationNonce()
+		if err != nil {
+			return err
+		}
+		if record.TimeAttestation, err = r.TimeAuthority.Attest(nonce); err != nil {
+			return fmt.Errorf("failed to obtain time attestation: %w", err)
+		}
+	}
+	msg, err := record.signingMessage()
+	if err != nil {
+		return err
+	}
+	sig, err := r.Signer.Sign(msg)
+	if err != nil {
+		return fmt.Errorf("failed to sign provenance: %w", err)
+	}
+	record.Signature = hex.EncodeToString(sig)
+	result.ProvenanceRecord = record
+	return nil
+}
+
+func (r *ProvenanceRecorder) now() time.Time {
+	if r.Clock != nil {
+		return r.Clock()
+	}
+	return time.Now()
+}
+
+// VerifyExecutionProvenance checks that result carries a provenance record
+// signed by recorder that matches its counts and, when circuit is not nil,
+// the circuit. A record with a time attestation is rejected unless
+// authority is given to check it.
+func VerifyExecutionProvenance(result *ExecutionResult, circuit *QuantumCircuit, recorder *SignatureScheme, authority TimeAuthority) error {
+	if result == nil || result.ProvenanceRecord == nil {
+		return errors.New("result has no provenance record")
+	}
+	record := result.ProvenanceRecord
+	if err := record.Verify(recorder, authority); err != nil {
+		return err
+	}
+	if record.Backend != result.Backend || record.Shots != result.Shots {
+		return errors.New("provenance record is for another backend or shot count")
+	}
+	digest, err := executionResultDigest(result)
+	if err != nil {
+		return err
+	}
+	if digest != record.ResultDigest {
+		return errors.New("result counts differ from the recorded response")
+	}
+	if circuit != nil {
+		hash, err := CircuitHash(circuit)
+		if err != nil {
+			return err
+		}
+		if hash != record.CircuitHash {
+			return errors.New("provenance record is for another circuit")
+		}
+	}
+	return nil
+}
+
+// VerifyCachedStateProvenance checks that a cached hardware state carries
+// a provenance record signed by recorder for its own backend and job.
+func VerifyCachedStateProvenance(state *CachedQuantumState, recorder *SignatureScheme, authority TimeAuthority) error {
+	if state == nil || state.ProvenanceRecord == nil {
+		return errors.New("state has no provenance record")
+	}
+	record := state.ProvenanceRecord
+	if err := record.Verify(recorder, authority); err != nil {
+		return err
+	}
+	if record.Backend != state.Backend || record.JobID != state.JobID {
+		return fmt.Errorf("provenance record is for job %q on %s, state claims %q on %s",
+			record.JobID, record.Backend, state.JobID, state.Backend)
+	}
+	return nil
+}
+
+// Verify checks the record's signature against recorder and its time
+// attestation, if any, against authority.
+func (p *ExecutionProvenance) Verify(recorder *SignatureScheme, authority TimeAuthority) error {
+	if recorder == nil {
+		return errors.New("recorder key is required")
+	}
+	if p.RecorderID != KeyFingerprint(recorder.PublicKeyBytes()) {
+		return errors.New("provenance was recorded by a different key")
+	}
+	msg, err := p.signingMessage()
+	if err != nil {
+		return err
+	}
+	sig, err := hex.DecodeString(p.Signature)
+	if err != nil || !recorder.Verify(msg, sig) {
+		return errors.New("provenance signature is invalid")
+	}
+	if p.TimeAttestation != nil {
+		if authority == nil {
+			return errors.New("provenance has a time attestation but no authority to check it")
+		}
+		nonce, err := p.attestationNonce()
+		if err != nil {
+			return err
+		}
+		if !authority.VerifyAttestation(p.TimeAttestation, nonce) {
+			return errors.New("provenance time attestation is invalid")
+		}
+	}
+	return nil
+}
+
+// MatchesBody reports whether body is the response the record was made
+// from, for deployments that archive raw backend responses.
+func (p *ExecutionProvenance) MatchesBody(body []byte) bool {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]) == p.BodySHA256
+}
+
+// signingMessage is the byte string the recorder signs.
+func (p *ExecutionProvenance) signingMessage() ([]byte, error) {
+	unsigned := *p
+	unsigned.Signature = ""
+	canonical, err := CanonicalizeValue(&unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize provenance: %w", err)
+	}
+	return append([]byte(executionProvenanceDomain), canonical...), nil
+}
+
+// attestationNonce binds a time attestation to everything else in the
+// record.
+func (p *ExecutionProvenance) attestationNonce() ([]byte, error) {
+	unattested := *p
+	unattested.TimeAttestation = nil
+	msg, err := unattested.signingMessage()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(msg)
+	return sum[:], nil
+}
+
+// executionResultDigest hashes the parts of result read from the backend's
+// response.
+func executionResultDigest(result *ExecutionResult) (string, error) {
+	canonical, err := CanonicalizeValue(struct {
+		Backend string         `json:"backend"`
+		Shots   int            `json:"shots"`
+		Counts  map[string]int `json:"counts"`
+	}{result.Backend, result.Shots, result.Counts})
+	if err != nil {
+		return "", fmt.Errorf("failed to canonicalize result: %w", err)
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// recordedHeaders copies h without the headers that may carry credentials.
+func recordedHeaders(h http.Header) map[string][]string {
+	recorded := h.Clone()
+	for _, name := range provenanceOmittedHeaders {
+		recorded.Del(name)
+	}
+	if len(recorded) == 0 {
+		return nil
+	}
+	return recorded
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Feature names a risky capability that operators can switch off at
+// runtime, without a rebuild or a restart.
+type Feature string
+
+const (
+	// FeatureLegacyVerification accepts proofs in deprecated formats
+	// through VerifySecureProofVersioned and LegacyZKP
+	FeatureLegacyVerification Feature = "legacy_verification"
+	// FeatureInsecureProver allows LegacyZKP to issue proofs that reveal
+	// their state
+	FeatureInsecureProver Feature = "insecure_prover"
+	// FeatureHardwareBackends allows measurement backends other than the
+	// CPU; proofs fall back to the CPU backend while it is off
+	FeatureHardwareBackends Feature = "hardware_backends"
+	// FeatureDebugEnvelopes allows ProofDebug envelopes; proofs are made
+	// without one while it is off, whatever SecureQuantumZKP.Debug says
+	FeatureDebugEnvelopes Feature = "debug_envelopes"
+)
+
+// Features lists every feature that can be switched.
+var Features = []Feature{
+	FeatureLegacyVerification,
+	FeatureInsecureProver,
+	FeatureHardwareBackends,
+	FeatureDebugEnvelopes,
+}
+
+// FeatureFlagsEnv overrides configured feature settings, as a comma
+// separated list such as "legacy_verification=off,debug_envelopes=on".
+const FeatureFlagsEnv = "QZKP_FEATURES"
+
+var (
+	// ErrFeatureDisabled is returned when a switched-off feature is used.
+	ErrFeatureDisabled = errors.New("feature is disabled")
+	// ErrIssuanceDisabled is returned for proofs requested while a
+	// KillSwitch is tripped.
+	ErrIssuanceDisabled = errors.New("proof issuance is disabled by the kill switch")
+)
+
+// FeatureFlags holds the runtime settings of Features. Every feature is
+// enabled unless switched off, so a nil *FeatureFlags, like a fresh one,
+// leaves behavior unchanged. FeatureFlags is safe for concurrent use, and
+// a change is seen by the next operation that checks it.
+type FeatureFlags struct {
+	mu       sync.RWMutex
+	disabled map[Feature]bool
+}
+
+// NewFeatureFlags returns flags with the given settings; features it
+// does not mention are enabled.
+func NewFeatureFlags(settings map[Feature]bool) (*FeatureFlags, error) {
+	f := &FeatureFlags{}
+	if err := f.Set(settings); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Enabled reports whether feature is enabled.
+func (f *FeatureFlags) Enabled(feature Feature) bool {
+	if f == nil {
+		return true
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return !f.disabled[feature]
+}
+
+// Set replaces every setting at once: features settings does not mention
+// are enabled again. Unknown features are refused and nothing is changed.
+func (f *FeatureFlags) Set(settings map[Feature]bool) error {
+	disabled := make(map[Feature]bool)
+	for feature, enabled := range settings {
+		if !isFeature(feature) {
+			return fmt.Errorf("unknown feature %q (available: %v)", feature, Features)
+		}
+		if !enabled {
+			disabled[feature] = true
+		}
+	}
+	f.mu.Lock()
+	f.disabled = disabled
+	f.mu.Unlock()
+	return nil
+}
+
+// Settings returns the current setting of every feature.
+func (f *FeatureFlags) Settings() map[Feature]bool {
+	settings := make(map[Feature]bool, len(Features))
+	for _, feature := range Features {
+		settings[feature] = f.Enabled(feature)
+	}
+	return settings
+}
+
+// require fails with ErrFeatureDisabled when feature is switched off.
+func (f *FeatureFlags) require(feature Feature) error {
+	if !f.Enabled(feature) {
+		return fmt.Errorf("%w: %s", ErrFeatureDisabled, feature)
+	}
+	return nil
+}
+
+func isFeature(feature Feature) bool {
+	for _, known := range Features {
+		if feature == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFeatureFlags parses the FeatureFlagsEnv format: comma separated
+// name=value pairs, the value one of on, off, true, false, 1 or 0.
+func ParseFeatureFlags(s string) (map[Feature]bool, error) {
+	settings := make(map[Feature]bool)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("feature setting %q is not name=value", item)
+		}
+		feature := Feature(strings.TrimSpace(name))
+		if !isFeature(feature) {
+			return nil, fmt.Errorf("unknown feature %q (available: %v)", feature, Features)
+		}
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "on", "true", "1":
+			settings[feature] = true
+		case "off", "false", "0":
+			settings[feature] = false
+		default:
+			return nil, fmt.Errorf("feature %s: invalid value %q", feature, value)
+		}
+	}
+	return settings, nil
+}
+
+// featureSettings layers the configured settings under those of
+// FeatureFlagsEnv and then overrides, later layers winning per feature.
+func featureSettings(configured, overrides map[Feature]bool) (map[Feature]bool, error) {
+	env, err := ParseFeatureFlags(os.Getenv(FeatureFlagsEnv))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", FeatureFlagsEnv, err)
+	}
+	settings := make(map[Feature]bool)
+	for _, layer := range []map[Feature]bool{configured, env, overrides} {
+		for feature, enabled := range layer {
+			if !isFeature(feature) {
+				return nil, fmt.Errorf("unknown feature %q (available: %v)", feature, Features)
+			}
+			settings[feature] = enabled
+		}
+	}
+	return settings, nil
+}
+
+// KillSwitchState reports whether a KillSwitch is tripped, since when
+// and why.
+type KillSwitchState struct {
+	Tripped bool      `json:"tripped"`
+	Reason  string    `json:"reason,omitempty"`
+	Since   time.Time `json:"since,omitempty"`
+}
+
+// KillSwitch stops proof issuance in an emergency, such as a suspected
+// key compromise or a prover bug, while verification stays online.
+// Services refuse proofs with ErrIssuanceDisabled from the moment it is
+// tripped until it is reset. The zero value is an untripped switch, safe
+// for concurrent use.
+type KillSwitch struct {
+	mu    sync.RWMutex
+	state KillSwitchState
+}
+
+// Trip disables issuance, recording reason. Tripping a tripped switch
+// updates the reason but keeps the time it was first tripped.
+func (k *KillSwitch) Trip(reason string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.state.Tripped {
+		k.state.Since = time.Now()
+	}
+	k.state.Tripped = true
+	k.state.Reason = reason
+}
+
+// Reset enables issuance again.
+func (k *KillSwitch) Reset() {
+	k.mu.Lock()
+	k.state = KillSwitchState{}
+	k.mu.Unlock()
+}
+
+// State returns the current state of the switch.
+func (k *KillSwitch) State() KillSwitchState {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.state
+}
+
+// check fails with ErrIssuanceDisabled while the switch is tripped.
+func (k *KillSwitch) check() error {
+	state := k.State()
+	if !state.Tripped {
+		return nil
+	}
+	if state.Reason == "" {
+		return ErrIssuanceDisabled
+	}
+	return fmt.Errorf("%w: %s", ErrIssuanceDisabled, state.Reason)
+}
+
+// FeatureFile is the format of a daemon's feature file, which is watched
+// for changes so that operators can switch features, or trip the kill
+// switch by setting KillSwitch to the reason, without a reload.
+type FeatureFile struct {
+	Features   map[Feature]bool `json:"features,omitempty"`
+	KillSwitch string           `json:"kill_switch,omitempty"`
+}
+
+// disabledFeatures returns the features of settings that are switched off,
+// in name order, for logging.
+func disabledFeatures(settings map[Feature]bool) []string {
+	var names []string
+	for feature, enabled := range settings {
+		if !enabled {
+			names = append(names, string(feature))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
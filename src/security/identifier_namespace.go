@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultMaxIdentifierLength bounds identifiers, in bytes, when a
+// namespace sets no limit.
+const DefaultMaxIdentifierLength = 256
+
+// IdentifierCharsetPortable is a charset that is safe in file names, URLs
+// and log lines.
+const IdentifierCharsetPortable = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_.:/@#"
+
+// ErrInvalidIdentifier is wrapped by every IdentifierError.
+var ErrInvalidIdentifier = errors.New("invalid identifier")
+
+// IdentifierViolation names the rule an identifier broke.
+type IdentifierViolation string
+
+const (
+	IdentifierEmpty               IdentifierViolation = "empty"
+	IdentifierTooLong             IdentifierViolation = "too_long"
+	IdentifierInvalidUTF8         IdentifierViolation = "invalid_utf8"
+	IdentifierControlCharacter    IdentifierViolation = "control_character"
+	IdentifierDisallowedCharacter IdentifierViolation = "disallowed_character"
+	IdentifierMissingPrefix       IdentifierViolation = "missing_prefix"
+	IdentifierMalformedPseudonym  IdentifierViolation = "malformed_pseudonym"
+)
+
+// IdentifierError reports an identifier rejected when proving or
+// verifying. The identifier is quoted when formatted, so the error is safe
+// to log.
+type IdentifierError struct {
+	Violation  IdentifierViolation
+	Identifier string
+	Offset     int // byte offset of the offending character, or -1
+}
+
+// Error implements error.
+func (e *IdentifierError) Error() string {
+	if e.Offset >= 0 {
+		return fmt.Sprintf("invalid identifier %q: %s at byte %d", e.Identifier, e.Violation, e.Offset)
+	}
+	return fmt.Sprintf("invalid identifier %q: %s", e.Identifier, e.Violation)
+}
+
+// Unwrap makes errors.Is(err, ErrInvalidIdentifier) hold.
+func (e *IdentifierError) Unwrap() error { return ErrInvalidIdentifier }
+
+// IdentifierNamespace restricts the identifiers an application proves
+// under. Without one, identifiers only have to be valid UTF-8 free of
+// control and formatting characters, which could otherwise rewrite audit
+// log lines.
+type IdentifierNamespace struct {
+	// Prefix every identifier must start with, e.g. "billing/", so that
+	// applications sharing a key or log cannot collide
+	Prefix string `json:"prefix,omitempty"`
+	// MaxLength in bytes; DefaultMaxIdentifierLength if zero
+	MaxLength int `json:"max_length,omitempty"`
+	// Charset lists every allowed character; empty allows any printable
+	// character
+	Charset string `json:"charset,omitempty"`
+}
+
+// ContextIdentifierNamespace returns the namespace that prefixes
+// identifiers with the context's application name and allows the portable
+// charset.
+func ContextIdentifierNamespace(ctx Context) *IdentifierNamespace {
+	return &IdentifierNamespace{Prefix: ctx.Application + "/", Charset: IdentifierCharsetPortable}
+}
+
+// Validate checks the namespace can admit identifiers at all.
+func (ns *IdentifierNamespace) Validate() error {
+	if ns.MaxLength < 0 {
+		return fmt.Errorf("identifier max length %d is negative", ns.MaxLength)
+	}
+	if len(ns.Prefix) >= ns.maxLength() {
+		return fmt.Errorf("identifier prefix %q leaves no room under the %d byte limit", ns.Prefix, ns.maxLength())
+	}
+	for _, r := range ns.Charset {
+		if !printableIdentifierRune(r) {
+			return fmt.Errorf("identifier charset contains %q", r)
+		}
+	}
+	if ns.Prefix != "" {
+		unprefixed := IdentifierNamespace{MaxLength: ns.MaxLength, Charset: ns.Charset}
+		if err := unprefixed.Check(ns.Prefix); err != nil {
+			return fmt.Errorf("identifier prefix is not in the namespace: %w", err)
+		}
+	}
+	return nil
+}
+
+func (ns *IdentifierNamespace) maxLength() int {
+	if ns.MaxLength > 0 {
+		return ns.MaxLength
+	}
+	return DefaultMaxIdentifierLength
+}
+
+// Check reports whether identifier belongs to the namespace.
+func (ns *IdentifierNamespace) Check(identifier string) error {
+	fail := func(violation IdentifierViolation, offset int) error {
+		return &IdentifierError{Violation: violation, Identifier: identifier, Offset: offset}
+	}
+	if err := checkIdentifierCharacters(identifier); err != nil {
+		return err
+	}
+	if len(identifier) > ns.maxLength() {
+		return fail(IdentifierTooLong, -1)
+	}
+	if ns.Charset != "" {
+		for i, r := range identifier {
+			if !strings.ContainsRune(ns.Charset, r) {
+				return fail(IdentifierDisallowedCharacter, i)
+			}
+		}
+	}
+	if !strings.HasPrefix(identifier, ns.Prefix) {
+		return fail(IdentifierMissingPrefix, -1)
+	}
+	return nil
+}
+
+// checkIdentifierCharacters applies the rules every identifier follows.
+func checkIdentifierCharacters(identifier string) error {
+	if identifier == "" {
+		return &IdentifierError{Violation: IdentifierEmpty, Offset: -1}
+	}
+	for i, r := range identifier {
+		if r == utf8.RuneError && !strings.HasPrefix(identifier[i:], string(utf8.RuneError)) {
+			return &IdentifierError{Violation: IdentifierInvalidUTF8, Identifier: identifier, Offset: i}
+		}
+		if !printableIdentifierRune(r) {
+			return &IdentifierError{Violation: IdentifierControlCharacter, Identifier: identifier, Offset: i}
+		}
+	}
+	return nil
+}
+
+// printableIdentifierRune rejects control characters and invisible
+// formatting characters such as bidirectional overrides.
+func printableIdentifierRune(r rune) bool {
+	return !unicode.IsControl(r) && !unicode.Is(unicode.Cf, r) && r != ' ' && r != ' '
+}
+
+// checkIdentifier applies sq's identifier rules to an identifier being
+// proven.
+func (sq *SecureQuantumZKP) checkIdentifier(identifier string) error {
+	if sq.IdentifierNamespace != nil {
+		if err := sq.IdentifierNamespace.Validate(); err != nil {
+			return err
+		}
+		return sq.IdentifierNamespace.Check(identifier)
+	}
+	return checkIdentifierCharacters(identifier)
+}
+
+// checkPublishedIdentifier applies sq's identifier rules to the identifier
+// a proof carries. Pseudonymized identifiers can only be checked for
+// their form.
+func (sq *SecureQuantumZKP) checkPublishedIdentifier(published, scheme string) error {
+	switch scheme {
+	case "":
+		return sq.checkIdentifier(published)
+	case IdentifierSchemeHMACSHA256:
+		if len(published) != 64 || strings.Trim(published, "0123456789abcdef") != "" {
+			return &IdentifierError{Violation: IdentifierMalformedPseudonym, Identifier: published, Offset: -1}
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown identifier scheme %q", ErrInvalidIdentifier, scheme)
+	}
+}
+
+// identifierPrefix is the prefix of identifiers sq derives itself.
+func (sq *SecureQuantumZKP) identifierPrefix() string {
+	if sq.IdentifierNamespace == nil {
+		return ""
+	}
+	return sq.IdentifierNamespace.Prefix
+}
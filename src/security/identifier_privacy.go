@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// IdentifierSchemeHMACSHA256 marks identifiers published as
+// HMAC-SHA256(disclosureKey, salt ‖ identifier).
+const IdentifierSchemeHMACSHA256 = "hmac-sha256"
+
+// identifierSaltSize is the per-proof salt length; a fresh salt keeps two
+// proofs for the same identifier unlinkable.
+const identifierSaltSize = 16
+
+// publishedIdentifier is the identifier as it appears in a proof.
+type publishedIdentifier struct {
+	Identifier string
+	Salt       string
+	Scheme     string
+}
+
+// publishIdentifier returns identifier unchanged, or pseudonymized under a
+// fresh salt when privacy mode is enabled (IdentifierKey set). Identifiers
+// outside sq's namespace are rejected with an *IdentifierError.
+func (sq *SecureQuantumZKP) publishIdentifier(identifier string) (publishedIdentifier, error) {
+	if err := sq.checkIdentifier(identifier); err != nil {
+		return publishedIdentifier{}, err
+	}
+	if len(sq.IdentifierKey) == 0 {
+		return publishedIdentifier{Identifier: identifier}, nil
+	}
+
+	salt := make([]byte, identifierSaltSize)
+	if err := sq.readRandom(salt); err != nil {
+		return publishedIdentifier{}, err
+	}
+	return publishedIdentifier{
+		Identifier: pseudonymizeIdentifier(sq.IdentifierKey, salt, identifier),
+		Salt:       hex.EncodeToString(salt),
+		Scheme:     IdentifierSchemeHMACSHA256,
+	}, nil
+}
+
+// pseudonymizeIdentifier computes the published form of identifier.
+func pseudonymizeIdentifier(disclosureKey, salt []byte, identifier string) string {
+	mac := hmac.New(sha256.New, disclosureKey)
+	mac.Write(salt)
+	mac.Write([]byte(identifier))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ConfirmIdentifier reports whether proof was created for expected. Plain
+// identifiers are compared directly; pseudonymized ones require the
+// disclosure key used by the prover.
+func ConfirmIdentifier(proof *SecureProof, expected string, disclosureKey []byte) bool {
+	if proof == nil {
+		return false
+	}
+	return confirmPublishedIdentifier(proof.Identifier, proof.IdentifierSalt, proof.IdentifierScheme, expected, disclosureKey)
+}
+
+// confirmPublishedIdentifier checks one published identifier.
+func confirmPublishedIdentifier(published, saltHex, scheme, expected string, disclosureKey []byte) bool {
+	switch scheme {
+	case "":
+		return published == expected
+	case IdentifierSchemeHMACSHA256:
+		if len(disclosureKey) == 0 {
+			return false
+		}
+		salt, err := hex.DecodeString(saltHex)
+		if err != nil || len(salt) != identifierSaltSize {
+			return false
+		}
+		want := pseudonymizeIdentifier(disclosureKey, salt, expected)
+		return hmac.Equal([]byte(want), []byte(published))
+	default:
+		return false
+	}
+}
+
+// matchesIdentifier checks proof against expected using this instance's
+// disclosure key, if any.
+func (sq *SecureQuantumZKP) matchesIdentifier(proof *SecureProof, expected string) bool {
+	return ConfirmIdentifier(proof, expected, sq.IdentifierKey)
+}
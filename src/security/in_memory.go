@@ -0,0 +1,16 @@
+package main
+
+import "fmt"
+
+// checkHostAccess refuses plugin when sq is in-memory and the plug-in
+// reaches the filesystem or the network. Plug-ins that do not implement
+// HostBound are taken to be in-memory.
+func (sq *SecureQuantumZKP) checkHostAccess(plugin interface{}) error {
+	if !sq.InMemory {
+		return nil
+	}
+	if bound, ok := plugin.(HostBound); ok {
+		return fmt.Errorf("%w: %T uses %s", ErrInMemory, plugin, bound.HostAccess())
+	}
+	return nil
+}
@@ -0,0 +1,498 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+// responseMACDomain separates per-response MAC keys from other uses of the
+// session key.
+const responseMACDomain = "qzkp/response-mac/v1"
+
+// SessionOffer opens an interactive session. It is sent by the verifier to
+// the prover over an authenticated, confidential channel.
+type SessionOffer struct {
+	SessionID  string         `json:"session_id"`
+	SessionKey []byte         `json:"session_key"`
+	Policy     *SessionPolicy `json:"policy,omitempty"` // verifier's policy; nil without negotiation
+}
+
+// SessionCommitment is the prover's first message: a commitment to the
+// state made before any challenge is known.
+type SessionCommitment struct {
+	SessionID      string             `json:"session_id"`
+	CommitmentHash string             `json:"commitment_hash"`
+	Dimension      int                `json:"dimension"`
+	Policy         *SessionPolicy     `json:"policy,omitempty"`     // prover's policy
+	Parameters     *SessionParameters `json:"parameters,omitempty"` // what the prover negotiated
+}
+
+// SessionBinding records which interactive session a proof was produced in.
+// Auditors holding the session key can re-check every response MAC.
+type SessionBinding struct {
+	SessionID       string `json:"session_id"`
+	KeyCommitment   string `json:"key_commitment"`
+	ChallengeDigest string `json:"challenge_digest"`
+	// Parameters records what a negotiated session agreed on
+	Parameters *SessionParameters `json:"parameters,omitempty"`
+}
+
+// VerifierSession is the verifier side of one interactive proof. It is
+// safe for concurrent use, so a prover racing its own requests still gets
+// one set of challenges.
+type VerifierSession struct {
+	mu         sync.Mutex
+	sq         *SecureQuantumZKP
+	offer      SessionOffer
+	commitment *SessionCommitment
+	challenges []Challenge
+	params     *SessionParameters
+}
+
+// ProverSession is the prover side of one interactive proof.
+type ProverSession struct {
+	sq         *SecureQuantumZKP
+	offer      SessionOffer
+	state      *StateVector
+	identifier string
+	key        []byte
+	commitment *SessionCommitment
+	responded  bool
+	policy     *SessionPolicy
+	params     *SessionParameters
+	randomness *randomnessTrace
+}
+
+// NewVerifierSession opens a session with a fresh random session key.
+func (sq *SecureQuantumZKP) NewVerifierSession() (*VerifierSession, error) {
+	id := make([]byte, 16)
+	if err := sq.readRandom(id); err != nil {
+		return nil, fmt.Errorf("failed to generate session id: %w", err)
+	}
+	sessionKey := make([]byte, 32)
+	if err := sq.readRandom(sessionKey); err != nil {
+		return nil, fmt.Errorf("failed to generate session key: %w", err)
+	}
+
+	return &VerifierSession{
+		sq: sq,
+		offer: SessionOffer{
+			SessionID:  EncodeHexField(id),
+			SessionKey: sessionKey,
+		},
+	}, nil
+}
+
+// NewVerifierSessionWithPolicy opens a session that negotiates its
+// parameters with the prover, requiring at least policy. Zero fields of
+// policy default to sq's configuration.
+func (sq *SecureQuantumZKP) NewVerifierSessionWithPolicy(policy SessionPolicy) (*VerifierSession, error) {
+	vs, err := sq.NewVerifierSession()
+	if err != nil {
+		return nil, err
+	}
+	policy = sq.sessionPolicy(policy)
+	vs.offer.Policy = &policy
+	return vs, nil
+}
+
+// Offer returns the message that starts the session on the prover side.
+func (vs *VerifierSession) Offer() SessionOffer {
+	return vs.offer
+}
+
+// Challenge records the prover's commitment and returns fresh random
+// challenges for it. It may only be called once per session.
+func (vs *VerifierSession) Challenge(commitment *SessionCommitment) ([]Challenge, error) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if vs.commitment != nil {
+		return nil, errors.New("session already challenged")
+	}
+	if commitment == nil || commitment.SessionID != vs.offer.SessionID {
+		return nil, errors.New("commitment belongs to a different session")
+	}
+	if commitment.Dimension <= 0 {
+		return nil, fmt.Errorf("invalid dimension: %d", commitment.Dimension)
+	}
+	sq := vs.sq
+	if vs.offer.Policy != nil {
+		params, err := negotiatedWith(*vs.offer.Policy, commitment)
+		if err != nil {
+			return nil, fmt.Errorf("parameter negotiation failed: %w", err)
+		}
+		sq = sq.forSession(params)
+		vs.params = &params
+	} else if commitment.Parameters != nil {
+		return nil, errors.New("session was offered without parameter negotiation")
+	}
+
+	challenges, err := sq.randomChallenges(commitment.Dimension, sq.ChallengeCount())
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate challenges: %w", err)
+	}
+	vs.sq = sq
+	c := *commitment
+	vs.commitment = &c
+	vs.challenges = challenges
+	return challenges, nil
+}
+
+// Verify checks a proof returned by the prover for this session: the
+// regular proof checks, the session binding, the negotiated parameters,
+// that every response answers the challenge this verifier issued and that
+// every response MAC is valid.
+func (vs *VerifierSession) Verify(proof *SecureProof, key []byte) bool {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	if vs.commitment == nil || proof == nil {
+		return false
+	}
+	if proof.CommitmentHash != vs.commitment.CommitmentHash ||
+		proof.StateMetadata.Dimension != vs.commitment.Dimension {
+		return false
+	}
+	if proof.Session == nil || proof.Session.ChallengeDigest != challengeDigest(vs.challenges) {
+		return false
+	}
+	if (vs.params == nil) != (proof.Session.Parameters == nil) ||
+		vs.params != nil && *vs.params != *proof.Session.Parameters {
+		return false
+	}
+	if len(proof.ChallengeResponse) != len(vs.challenges) {
+		return false
+	}
+	// Only this verifier knows the challenge nonces, so the binding is
+	// required here and cannot be checked by an auditor
+	commitment, err := decodeDigestField(proof.CommitmentHash, 1)
+	if err != nil || proof.ChallengeBinding == "" ||
+		!matchChallenges(proof.ChallengeResponse, vs.challenges, commitment, proof.ChallengeBinding) {
+		return false
+	}
+	return vs.sq.AuditSessionProof(proof, key, vs.offer.SessionKey)
+}
+
+// NewProverSession starts the prover side of a session offered by a
+// verifier.
+func (sq *SecureQuantumZKP) NewProverSession(
+	offer SessionOffer,
+	vector []complex128,
+	identifier string,
+	key []byte,
+) (*ProverSession, error) {
+	state, err := NewStateVector(vector)
+	if err != nil {
+		return nil, err
+	}
+	return sq.NewProverSessionForState(offer, state, identifier, key)
+}
+
+// NewProverSessionForState starts the prover side of a session proving
+// knowledge of state.
+func (sq *SecureQuantumZKP) NewProverSessionForState(
+	offer SessionOffer,
+	state *StateVector,
+	identifier string,
+	key []byte,
+) (*ProverSession, error) {
+	return sq.newProverSession(offer, state, identifier, key, nil)
+}
+
+// NewProverSessionWithPolicy starts the prover side of a session proving
+// knowledge of state, requiring at least policy. The offer must ask for
+// negotiation. Zero fields of policy default to sq's configuration.
+func (sq *SecureQuantumZKP) NewProverSessionWithPolicy(
+	offer SessionOffer,
+	state *StateVector,
+	identifier string,
+	key []byte,
+	policy SessionPolicy,
+) (*ProverSession, error) {
+	if offer.Policy == nil {
+		return nil, errors.New("session offer does not negotiate parameters")
+	}
+	return sq.newProverSession(offer, state, identifier, key, &policy)
+}
+
+// newProverSession starts a prover session. When the offer carries a
+// policy the parameters are negotiated against policy, or sq's defaults
+// when policy is nil, and the session proves at the agreed parameters.
+func (sq *SecureQuantumZKP) newProverSession(
+	offer SessionOffer,
+	state *StateVector,
+	identifier string,
+	key []byte,
+	policy *SessionPolicy,
+) (*ProverSession, error) {
+	if state == nil {
+		return nil, errors.New("state vector cannot be nil")
+	}
+	if offer.SessionID == "" || len(offer.SessionKey) == 0 {
+		return nil, errors.New("invalid session offer")
+	}
+
+	ps := &ProverSession{
+		sq:         sq,
+		offer:      offer,
+		state:      state,
+		identifier: identifier,
+		key:        key,
+	}
+	if offer.Policy != nil {
+		if policy == nil {
+			policy = &SessionPolicy{}
+		}
+		filled := sq.sessionPolicy(*policy)
+		params, err := NegotiateSession(*offer.Policy, filled)
+		if err != nil {
+			return nil, fmt.Errorf("parameter negotiation failed: %w", err)
+		}
+		ps.sq = sq.forSession(params)
+		ps.policy = &filled
+		ps.params = &params
+	}
+	ps.randomness = &randomnessTrace{}
+	return ps, nil
+}
+
+// tracedSQ returns the session's prover recording its randomness in the
+// session's trace.
+func (ps *ProverSession) tracedSQ() *SecureQuantumZKP {
+	traced := *ps.sq
+	traced.randomnessTrace = ps.randomness
+	return &traced
+}
+
+// Commit produces the prover's commitment message.
+func (ps *ProverSession) Commit() (*SessionCommitment, error) {
+	sq := ps.tracedSQ()
+	if ps.commitment != nil {
+		return nil, errors.New("session already committed")
+	}
+
+	sq.reportProgress(ProgressStageCommitment, 0, 1)
+	stateCommitment, err := sq.generateStateCommitment(ps.state, ps.identifier, ps.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate state commitment: %w", err)
+	}
+	sq.reportProgress(ProgressStageCommitment, 1, 1)
+
+	ps.commitment = &SessionCommitment{
+		SessionID:      ps.offer.SessionID,
+		CommitmentHash: EncodeHexField(stateCommitment[:sq.DigestLengths.Commitment]),
+		Dimension:      ps.state.Dimension(),
+		Policy:         ps.policy,
+		Parameters:     ps.params,
+	}
+	c := *ps.commitment
+	// The negotiated values stay the session's own
+	if ps.params != nil {
+		policy, params := *ps.policy, *ps.params
+		c.Policy, c.Parameters = &policy, &params
+	}
+	return &c, nil
+}
+
+// Respond answers the verifier's challenges and returns the signed proof
+// with one MAC per response.
+func (ps *ProverSession) Respond(challenges []Challenge) (*SecureProof, error) {
+	sq := ps.tracedSQ()
+	if ps.commitment == nil {
+		return nil, errors.New("session not committed")
+	}
+	if ps.responded {
+		return nil, errors.New("session already answered")
+	}
+	if len(challenges) == 0 {
+		return nil, errors.New("no challenges to answer")
+	}
+	// Fewer challenges than agreed would make a weaker proof than either
+	// party accepted
+	if ps.params != nil && len(challenges) < sq.ChallengeCount() {
+		return nil, fmt.Errorf("%d challenges do not reach the negotiated %d bits (%d needed)",
+			len(challenges), ps.params.SoundnessBits, sq.ChallengeCount())
+	}
+	for i, challenge := range challenges {
+		if challenge.Index < 0 || challenge.Index >= ps.state.Dimension() {
+			return nil, fmt.Errorf("challenge %d index %d out of range", i, challenge.Index)
+		}
+		if !validBasis(challenge.BasisType) {
+			return nil, fmt.Errorf("challenge %d has unknown basis %q", i, challenge.BasisType)
+		}
+		if len(challenge.Nonce) != len(challenges[0].Nonce) {
+			return nil, fmt.Errorf("challenge %d nonce is %d bytes, challenge 0's %d", i, len(challenge.Nonce), len(challenges[0].Nonce))
+		}
+	}
+	// The proof records the nonce length the verifier chose
+	if err := validateChallengeNonceLength(len(challenges[0].Nonce)); err != nil {
+		return nil, err
+	}
+	sq.ChallengeNonceLength = len(challenges[0].Nonce)
+	// The verifier chose the challenges, so the memory budget is checked
+	// against their number and this stage completes on receipt
+	memory, err := sq.planProofMemory(ps.state.Dimension(), len(challenges))
+	if err != nil {
+		return nil, err
+	}
+	sq.reportProgress(ProgressStageChallenges, len(challenges), len(challenges))
+
+	responses, err := sq.respondToChallenges(ps.state, challenges, ps.key, memory.Streaming)
+	if err != nil {
+		return nil, err
+	}
+	for i := range responses {
+		responses[i].MAC = EncodeHexField(responseMAC(ps.offer.SessionKey, ps.offer.SessionID, i, responses[i]))
+	}
+
+	commitmentHash, err := decodeDigestField(ps.commitment.CommitmentHash, 1)
+	if err != nil {
+		return nil, err
+	}
+	published, err := sq.publishIdentifier(ps.identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish identifier: %w", err)
+	}
+	proof, err := sq.assembleSecureProof(ps.state.Dimension(), commitmentHash, responses,
+		published, sq.now())
+	if err != nil {
+		return nil, err
+	}
+
+	keyCommitment := sha256.Sum256(ps.offer.SessionKey)
+	proof.Session = &SessionBinding{
+		SessionID:       ps.offer.SessionID,
+		KeyCommitment:   EncodeHexField(keyCommitment[:]),
+		ChallengeDigest: challengeDigest(challenges),
+		Parameters:      ps.params,
+	}
+	if proof.ChallengeBinding, err = challengeBinding(commitmentHash, challenges, responses); err != nil {
+		return nil, err
+	}
+	if err := sq.attachLinkageTag(proof, ps.state); err != nil {
+		return nil, fmt.Errorf("failed to attach linkage tag: %w", err)
+	}
+
+	proof.Randomness = ps.randomness.Classes()
+	if err := sq.signSecureProof(proof, ps.key); err != nil {
+		return nil, fmt.Errorf("failed to sign proof: %w", err)
+	}
+	ps.responded = true
+	return proof, nil
+}
+
+// AuditSessionProof re-checks an interactive proof after the fact given the
+// session key: signature, common proof checks, the session key commitment,
+// the challenge digest, every per-response MAC and, for negotiated
+// sessions, that the proof meets the recorded agreement.
+func (sq *SecureQuantumZKP) AuditSessionProof(proof *SecureProof, key, sessionKey []byte) bool {
+	if proof == nil || proof.Session == nil {
+		return false
+	}
+	if params := proof.Session.Parameters; params != nil {
+		sq = sq.forSession(*params)
+		if sq.checkSessionParameters(proof) != nil {
+			return false
+		}
+	}
+	if !sq.verifySecureProofSignature(proof) {
+		return false
+	}
+	if !sq.verifySecureProofCommon(proof, key) {
+		return false
+	}
+
+	keyCommitment := sha256.Sum256(sessionKey)
+	if proof.Session.KeyCommitment != EncodeHexField(keyCommitment[:]) {
+		return false
+	}
+
+	challenges := make([]Challenge, len(proof.ChallengeResponse))
+	for i, response := range proof.ChallengeResponse {
+		challenges[i] = Challenge{Index: response.ChallengeIndex, BasisType: response.BasisChoice}
+	}
+	if proof.Session.ChallengeDigest != challengeDigest(challenges) {
+		return false
+	}
+
+	for i, response := range proof.ChallengeResponse {
+		mac, err := decodeDigestField(response.MAC, sha256.Size)
+		if err != nil {
+			return false
+		}
+		if !hmac.Equal(mac, responseMAC(sessionKey, proof.Session.SessionID, i, response)) {
+			return false
+		}
+	}
+	return true
+}
+
+// responseMAC authenticates one response under a key derived from the
+// session key and the challenge position, so responses cannot be moved
+// between sessions or reordered within one.
+func responseMAC(sessionKey []byte, sessionID string, index int, response ChallengeResponse) []byte {
+	var position [8]byte
+	binary.BigEndian.PutUint64(position[:], uint64(index))
+
+	keyMAC := hmac.New(sha256.New, sessionKey)
+	keyMAC.Write([]byte(responseMACDomain))
+	keyMAC.Write(position[:])
+	responseKey := keyMAC.Sum(nil)
+
+	mac := hmac.New(sha256.New, responseKey)
+	mac.Write([]byte(sessionID))
+	mac.Write(position[:])
+	fmt.Fprintf(mac, "%d|%s|%s|%s|%s", response.ChallengeIndex, response.BasisChoice,
+		response.Response, response.Commitment, response.Proof)
+	return mac.Sum(nil)
+}
+
+// challengeDigest hashes the (index, basis) sequence of a challenge set.
+// Nonces are left out because the proof does not carry them.
+func challengeDigest(challenges []Challenge) string {
+	var buf bytes.Buffer
+	for _, c := range challenges {
+		fmt.Fprintf(&buf, "%d:%s;", c.Index, c.BasisType)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return EncodeHexField(sum[:])
+}
+
+// randomChallenges draws verifier-side challenges uniformly at random,
+// each with a fresh nonce, all from sq's randomness source.
+func (sq *SecureQuantumZKP) randomChallenges(dimension, numChallenges int) ([]Challenge, error) {
+	if err := validateChallengeNonceLength(sq.challengeNonceLength()); err != nil {
+		return nil, err
+	}
+	source := randomReader{sq}
+	challenges := make([]Challenge, numChallenges)
+	for i := range challenges {
+		randBit, err := rand.Int(source, big.NewInt(2))
+		if err != nil {
+			return nil, err
+		}
+
+		randIndex, err := rand.Int(source, big.NewInt(int64(dimension)))
+		if err != nil {
+			return nil, err
+		}
+
+		nonce := make([]byte, sq.challengeNonceLength())
+		if err := sq.readRandom(nonce); err != nil {
+			return nil, err
+		}
+
+		challenges[i] = Challenge{
+			Index:     int(randIndex.Int64()),
+			BasisType: challengeBasis(byte(randBit.Int64())),
+			Nonce:     nonce,
+		}
+	}
+	return challenges, nil
+}
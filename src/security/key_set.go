@@ -0,0 +1,317 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Key set entry types, following the JOSE "AKP" (algorithm key pair)
+// representation of ML-DSA public keys.
+const (
+	KeySetKeyType   = "AKP"
+	KeySetAlgorithm = "ML-DSA-87"
+)
+
+// KeySetPolicy says how many keys of a set must have signed a proof.
+type KeySetPolicy int
+
+const (
+	// KeySetAnyOf accepts a proof signed by any key of the set, e.g. by
+	// either key during a rotation window.
+	KeySetAnyOf KeySetPolicy = iota
+	// KeySetAllOf requires a signature from every key of the set; the
+	// extra signatures are added with CosignProof.
+	KeySetAllOf
+)
+
+// String returns the policy name.
+func (p KeySetPolicy) String() string {
+	switch p {
+	case KeySetAnyOf:
+		return "any-of"
+	case KeySetAllOf:
+		return "all-of"
+	default:
+		return fmt.Sprintf("KeySetPolicy(%d)", int(p))
+	}
+}
+
+// KeyFingerprint returns the SHA-256 fingerprint of an encoded public key,
+// which identifies the key in key sets, cosignatures and receipts.
+func KeyFingerprint(publicKey []byte) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:])
+}
+
+// KeySetKey is one published verification key. The key ID is the key's
+// fingerprint. NotBefore and NotAfter, when set, bound the proof
+// timestamps the key is accepted for.
+type KeySetKey struct {
+	KeyType   string     `json:"kty"`
+	Algorithm string     `json:"alg"`
+	KeyID     string     `json:"kid"`
+	Public    string     `json:"pub"` // unpadded base64url
+	NotBefore *time.Time `json:"not_before,omitempty"`
+	NotAfter  *time.Time `json:"not_after,omitempty"`
+}
+
+// publicKey decodes and checks the key, including that its ID is its
+// fingerprint.
+func (k KeySetKey) publicKey() ([]byte, error) {
+	if k.KeyType != KeySetKeyType || k.Algorithm != KeySetAlgorithm {
+		return nil, fmt.Errorf("key %s: unsupported key type %q/%q", k.KeyID, k.KeyType, k.Algorithm)
+	}
+	pub, err := base64.RawURLEncoding.DecodeString(k.Public)
+	if err != nil {
+		return nil, fmt.Errorf("key %s: malformed public key: %v", k.KeyID, err)
+	}
+	if _, err := NewVerificationScheme(pub, nil); err != nil {
+		return nil, fmt.Errorf("key %s: %v", k.KeyID, err)
+	}
+	if KeyFingerprint(pub) != k.KeyID {
+		return nil, fmt.Errorf("key %s: key ID is not the fingerprint of the key", k.KeyID)
+	}
+	if k.NotBefore != nil && k.NotAfter != nil && k.NotAfter.Before(*k.NotBefore) {
+		return nil, fmt.Errorf("key %s: validity window ends before it starts", k.KeyID)
+	}
+	return pub, nil
+}
+
+// validAt reports whether the key is accepted for a proof made at t.
+func (k KeySetKey) validAt(t time.Time) bool {
+	if k.NotBefore != nil && t.Before(*k.NotBefore) {
+		return false
+	}
+	return k.NotAfter == nil || !t.After(*k.NotAfter)
+}
+
+// KeySet is a published set of proof verification keys, serialized like a
+// JSON Web Key Set: {"keys": [...]}.
+type KeySet struct {
+	Keys []KeySetKey `json:"keys"`
+}
+
+// Add appends an encoded public key to the set and returns its key ID.
+// Zero times leave that end of the validity window open.
+func (ks *KeySet) Add(publicKey []byte, notBefore, notAfter time.Time) (string, error) {
+	key := KeySetKey{
+		KeyType:   KeySetKeyType,
+		Algorithm: KeySetAlgorithm,
+		KeyID:     KeyFingerprint(publicKey),
+		Public:    base64.RawURLEncoding.EncodeToString(publicKey),
+	}
+	if !notBefore.IsZero() {
+		key.NotBefore = &notBefore
+	}
+	if !notAfter.IsZero() {
+		key.NotAfter = &notAfter
+	}
+	if _, err := key.publicKey(); err != nil {
+		return "", err
+	}
+	for _, existing := range ks.Keys {
+		if existing.KeyID == key.KeyID {
+			return "", fmt.Errorf("key %s is already in the set", key.KeyID)
+		}
+	}
+	ks.Keys = append(ks.Keys, key)
+	return key.KeyID, nil
+}
+
+// Validate checks every key of the set and rejects duplicates.
+func (ks *KeySet) Validate() error {
+	if len(ks.Keys) == 0 {
+		return errors.New("key set is empty")
+	}
+	seen := make(map[string]bool, len(ks.Keys))
+	for _, key := range ks.Keys {
+		if _, err := key.publicKey(); err != nil {
+			return err
+		}
+		if seen[key.KeyID] {
+			return fmt.Errorf("key %s appears twice", key.KeyID)
+		}
+		seen[key.KeyID] = true
+	}
+	return nil
+}
+
+// Pin checks that every key of the set has one of the pinned fingerprints,
+// so a tampered key set distribution cannot introduce a key the verifier
+// was not configured to trust.
+func (ks *KeySet) Pin(fingerprints ...string) error {
+	pinned := make(map[string]bool, len(fingerprints))
+	for _, fp := range fingerprints {
+		pinned[fp] = true
+	}
+	for _, key := range ks.Keys {
+		if !pinned[key.KeyID] {
+			return fmt.Errorf("key %s is not pinned", key.KeyID)
+		}
+	}
+	return nil
+}
+
+// ParseKeySet decodes and validates a serialized key set. When pins are
+// given, every key must match one of them (see Pin).
+func ParseKeySet(data []byte, pins ...string) (*KeySet, error) {
+	var ks KeySet
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, fmt.Errorf("failed to decode key set: %v", err)
+	}
+	if err := ks.Validate(); err != nil {
+		return nil, err
+	}
+	if len(pins) > 0 {
+		if err := ks.Pin(pins...); err != nil {
+			return nil, err
+		}
+	}
+	return &ks, nil
+}
+
+// Cosignature is an additional signature over a proof. It signs the same
+// message as the proof's own signature, which excludes all signatures.
+type Cosignature struct {
+	KeyID     string `json:"kid"`
+	Signature string `json:"signature"`
+}
+
+// CosignProof adds signer's signature to a signed proof, for verifiers
+// that require several keys under KeySetAllOf.
+func (sq *SecureQuantumZKP) CosignProof(proof *SecureProof, signer *SignatureScheme) error {
+	if proof == nil || proof.Signature == "" {
+		return errors.New("only signed proofs can be cosigned")
+	}
+	if proof.Version != CurrentProofVersion {
+		return fmt.Errorf("version %d proofs cannot be cosigned", proof.Version)
+	}
+	if signer == nil || signer.Priv == nil {
+		return errors.New("cosigning key is required")
+	}
+	kid := KeyFingerprint(signer.PublicKeyBytes())
+	for _, cosig := range proof.Cosignatures {
+		if cosig.KeyID == kid {
+			return fmt.Errorf("proof is already cosigned by %s", kid)
+		}
+	}
+
+	msg, err := secureProofSigningMessage(proof)
+	if err != nil {
+		return err
+	}
+	cosigner := *signer
+	cosigner.Ctx = sq.Signer.Ctx
+	sig, err := cosigner.Sign(msg)
+	if err != nil {
+		return fmt.Errorf("failed to cosign proof: %w", err)
+	}
+	proof.Cosignatures = append(proof.Cosignatures, Cosignature{KeyID: kid, Signature: EncodeHexField(sig)})
+	return nil
+}
+
+// VerifyWithKeySet verifies proof against a set of keys instead of
+// sq.Signer. Under KeySetAnyOf the proof must verify with at least one key
+// of the set; under KeySetAllOf with every key, through the proof's own
+// signature or its cosignatures. A key only counts for proofs timestamped
+// within its validity window. Result.Signers lists the key IDs that
+// verified.
+func (sq *SecureQuantumZKP) VerifyWithKeySet(proof *SecureProof, key []byte, keys *KeySet, policy KeySetPolicy) *VerificationResult {
+	if proof == nil || keys == nil {
+		return &VerificationResult{}
+	}
+	schemes, err := sq.keySetSchemes(keys)
+	if err != nil {
+		return &VerificationResult{Reasons: []string{err.Error()}}
+	}
+	return sq.verifyWithKeySchemes(proof, key, schemes, policy)
+}
+
+// keySetScheme is a key set entry with its public key already parsed.
+type keySetScheme struct {
+	KeySetKey
+	scheme *SignatureScheme
+}
+
+// keySetSchemes validates keys and parses every public key under sq's
+// signature context.
+func (sq *SecureQuantumZKP) keySetSchemes(keys *KeySet) ([]keySetScheme, error) {
+	if err := keys.Validate(); err != nil {
+		return nil, err
+	}
+	schemes := make([]keySetScheme, len(keys.Keys))
+	for i, k := range keys.Keys {
+		pub, _ := k.publicKey() // checked by Validate
+		scheme, err := NewVerificationScheme(pub, sq.Signer.Ctx)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %v", k.KeyID, err)
+		}
+		schemes[i] = keySetScheme{KeySetKey: k, scheme: scheme}
+	}
+	return schemes, nil
+}
+
+// verifyWithKeySchemes is VerifyWithKeySet for an already parsed key set.
+func (sq *SecureQuantumZKP) verifyWithKeySchemes(proof *SecureProof, key []byte, keys []keySetScheme, policy KeySetPolicy) *VerificationResult {
+	result := &VerificationResult{}
+	if policy != KeySetAnyOf && policy != KeySetAllOf {
+		result.Reasons = append(result.Reasons, fmt.Sprintf("unknown key set policy %v", policy))
+		return result
+	}
+
+	var verified *VerificationResult
+	for _, k := range keys {
+		if !k.validAt(proof.Timestamp) {
+			result.Reasons = append(result.Reasons, fmt.Sprintf("key %s is not valid at the proof timestamp", k.KeyID))
+			continue
+		}
+		// The key's cosignature, if any, is tried in place of the proof's
+		// own signature, so every key runs through the same version-aware
+		// verification sq.Signer would. Cosignatures sign the proof alone,
+		// never a batch root
+		candidates := []SecureProof{*proof}
+		for _, cosig := range proof.Cosignatures {
+			if cosig.KeyID == k.KeyID && proof.Version == CurrentProofVersion {
+				candidate := *proof
+				candidate.Signature = cosig.Signature
+				candidate.Batch = nil
+				candidates = append(candidates, candidate)
+			}
+		}
+		keyed := sq.withSigner(k.scheme)
+
+		signed := false
+		for _, candidate := range candidates {
+			candidate.Cosignatures = nil
+			if r := keyed.VerifySecureProofVersioned(&candidate, key); r.Valid {
+				verified, signed = r, true
+				break
+			}
+		}
+		if signed {
+			result.Signers = append(result.Signers, k.KeyID)
+		} else {
+			result.Reasons = append(result.Reasons, fmt.Sprintf("proof is not signed by key %s", k.KeyID))
+		}
+	}
+
+	if verified != nil {
+		result.Version = verified.Version
+		result.Caveats = verified.Caveats
+	}
+	switch policy {
+	case KeySetAnyOf:
+		result.Valid = len(result.Signers) > 0
+	case KeySetAllOf:
+		result.Valid = len(result.Signers) == len(keys)
+	}
+	if result.Valid {
+		result.Reasons = nil
+	}
+	return result
+}
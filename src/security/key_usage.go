@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// ErrKeyRotationRequired is returned by Keystore.Use once a key has
+// exceeded a hard limit; the key must be replaced before it signs again.
+var ErrKeyRotationRequired = errors.New("key exceeded its usage limits and must be rotated")
+
+// Key usage statuses, in increasing severity.
+const (
+	KeyStatusOK       = "ok"
+	KeyStatusWarning  = "warning"
+	KeyStatusExceeded = "exceeded"
+)
+
+// KeyUsageLimits are the rotation thresholds applied to every key. A zero
+// field disables that threshold. Warn thresholds are reported; Max
+// thresholds make Keystore.Use refuse the key.
+type KeyUsageLimits struct {
+	WarnProofs uint64        `json:"warn_proofs"`
+	MaxProofs  uint64        `json:"max_proofs"`
+	WarnAge    time.Duration `json:"warn_age"`
+	MaxAge     time.Duration `json:"max_age"`
+}
+
+// DefaultKeyUsageLimits refuse a key after 2^28 proofs, far below the
+// 2^32 uses at which collisions among truncated digests start to matter,
+// and rotate keys yearly. Warnings start well before either limit.
+func DefaultKeyUsageLimits() KeyUsageLimits {
+	return KeyUsageLimits{
+		WarnProofs: 1 << 24,
+		MaxProofs:  1 << 28,
+		WarnAge:    300 * 24 * time.Hour,
+		MaxAge:     365 * 24 * time.Hour,
+	}
+}
+
+// KeyUsage is the persisted usage record of one key. Status and Warnings
+// are computed against the keystore limits when the record is read.
+type KeyUsage struct {
+	Name     string    `json:"name"`
+	Created  time.Time `json:"created"`
+	LastUsed time.Time `json:"last_used"`
+	Proofs   uint64    `json:"proofs"`
+
+	Status   string   `json:"status,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Age returns how long ago the key was stored.
+func (u *KeyUsage) Age(now time.Time) time.Duration {
+	return now.Sub(u.Created)
+}
+
+// evaluate sets Status and Warnings from limits.
+func (u *KeyUsage) evaluate(limits KeyUsageLimits, now time.Time) {
+	u.Status = KeyStatusOK
+	u.Warnings = nil
+	flag := func(status, format string, args ...interface{}) {
+		if status == KeyStatusExceeded || u.Status == KeyStatusOK {
+			u.Status = status
+		}
+		u.Warnings = append(u.Warnings, fmt.Sprintf(format, args...))
+	}
+
+	switch {
+	case limits.MaxProofs > 0 && u.Proofs >= limits.MaxProofs:
+		flag(KeyStatusExceeded, "produced %d proofs, limit is %d", u.Proofs, limits.MaxProofs)
+	case limits.WarnProofs > 0 && u.Proofs >= limits.WarnProofs:
+		flag(KeyStatusWarning, "produced %d proofs, warning threshold is %d", u.Proofs, limits.WarnProofs)
+	}
+	age := u.Age(now)
+	switch {
+	case limits.MaxAge > 0 && age >= limits.MaxAge:
+		flag(KeyStatusExceeded, "age %s exceeds the maximum %s", age.Round(time.Hour), limits.MaxAge)
+	case limits.WarnAge > 0 && age >= limits.WarnAge:
+		flag(KeyStatusWarning, "age %s exceeds the warning threshold %s", age.Round(time.Hour), limits.WarnAge)
+	}
+}
+
+// Use returns the key stored under name and records one proof against it.
+// The returned usage carries any rotation warnings; once a hard limit is
+// reached the key is refused with ErrKeyRotationRequired.
+func (ks *Keystore) Use(name string) ([]byte, *KeyUsage, error) {
+	key, err := ks.Get(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	usage, err := ks.readUsage(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now().UTC()
+	usage.evaluate(ks.Limits, now)
+	if usage.Status == KeyStatusExceeded {
+		return nil, usage, fmt.Errorf("key %q: %w", name, ErrKeyRotationRequired)
+	}
+
+	usage.Proofs++
+	usage.LastUsed = now
+	if err := ks.writeUsage(usage); err != nil {
+		return nil, nil, err
+	}
+	usage.evaluate(ks.Limits, now)
+	return key, usage, nil
+}
+
+// Usage returns the usage record of the key stored under name.
+func (ks *Keystore) Usage(name string) (*KeyUsage, error) {
+	if err := validateStoreName(name); err != nil {
+		return nil, err
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	usage, err := ks.readUsage(name)
+	if err != nil {
+		return nil, err
+	}
+	usage.evaluate(ks.Limits, time.Now().UTC())
+	return usage, nil
+}
+
+// Key inventory orders for Keystore.Inventory.
+const (
+	KeyOrderName  = "name"
+	KeyOrderAge   = "age"   // oldest first
+	KeyOrderUsage = "usage" // most used first
+)
+
+// Inventory returns the usage of every stored key in the given order, so
+// operators can schedule rotations before limits are reached.
+func (ks *Keystore) Inventory(order string) ([]*KeyUsage, error) {
+	names, err := ks.List()
+	if err != nil {
+		return nil, err
+	}
+	inventory := make([]*KeyUsage, 0, len(names))
+	for _, name := range names {
+		usage, err := ks.Usage(name)
+		if err != nil {
+			return nil, err
+		}
+		inventory = append(inventory, usage)
+	}
+
+	switch order {
+	case KeyOrderName, "":
+	case KeyOrderAge:
+		sort.SliceStable(inventory, func(i, j int) bool { return inventory[i].Created.Before(inventory[j].Created) })
+	case KeyOrderUsage:
+		sort.SliceStable(inventory, func(i, j int) bool { return inventory[i].Proofs > inventory[j].Proofs })
+	default:
+		return nil, fmt.Errorf("unknown key order %q", order)
+	}
+	return inventory, nil
+}
+
+// readUsage loads the usage record of name. Keys stored before usage was
+// tracked get a fresh record dated from the key file's modification time.
+func (ks *Keystore) readUsage(name string) (*KeyUsage, error) {
+	data, err := hostFS.ReadFile(ks.usagePath(name))
+	if os.IsNotExist(err) {
+		info, err := hostFS.Stat(ks.path(name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key %q: %w", name, err)
+		}
+		return &KeyUsage{Name: name, Created: info.ModTime().UTC()}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage of key %q: %w", name, err)
+	}
+	if data, err = KeyUsageSchema.UpgradeJSON(data); err != nil {
+		return nil, fmt.Errorf("usage record of key %q: %w", name, err)
+	}
+	var usage KeyUsage
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return nil, fmt.Errorf("usage record of key %q is corrupted: %w", name, err)
+	}
+	usage.Name = name
+	return &usage, nil
+}
+
+// writeUsage persists the counters of usage in the current KeyUsageSchema;
+// computed fields are omitted.
+func (ks *Keystore) writeUsage(usage *KeyUsage) error {
+	record := struct {
+		SchemaVersion int `json:"schema_version"`
+		KeyUsage
+	}{KeyUsageSchema.Current(), *usage}
+	record.Status = ""
+	record.Warnings = nil
+	data, err := json.MarshalIndent(&record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(ks.usagePath(usage.Name), data, 0600)
+}
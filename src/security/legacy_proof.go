@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultLegacySunset is the date from which LegacyZKP refuses to verify
+// legacy proofs unless its LegacyPolicy sets another.
+var DefaultLegacySunset = time.Date(2027, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+var (
+	// ErrLegacyUnsupported is returned by NewLegacyZKP in builds with the
+	// qzkp_nolegacy tag.
+	ErrLegacyUnsupported = errors.New("legacy proof support is not built in (qzkp_nolegacy)")
+	// ErrLegacySunset is returned when legacy verification is asked for
+	// after the sunset date.
+	ErrLegacySunset = errors.New("legacy proof verification is past its sunset date")
+	// ErrLegacyProofInvalid is returned when a legacy proof to convert does
+	// not verify.
+	ErrLegacyProofInvalid = errors.New("legacy proof does not verify")
+)
+
+// LegacyPolicy configures the deprecation of the legacy Proof API.
+type LegacyPolicy struct {
+	// Sunset is the date from which legacy verification refuses to run;
+	// zero uses DefaultLegacySunset
+	Sunset time.Time
+	Logger *slog.Logger     // receives deprecation warnings; nil uses slog.Default()
+	Clock  func() time.Time // optional; nil uses time.Now
+	// Features switches proving off with FeatureInsecureProver, and
+	// verification and conversion with FeatureLegacyVerification; nil
+	// enables both
+	Features *FeatureFlags
+}
+
+// LegacyZKP wraps the legacy Proof API of a QuantumZKP, whose proofs
+// reveal the state they prove, for callers that cannot move to
+// SecureQuantumZKP yet. Each legacy method logs a structured warning the
+// first time it is used, naming its replacement and the sunset date;
+// verification and conversion fail with ErrLegacySunset from the sunset
+// date on. A LegacyZKP is safe for concurrent use.
+type LegacyZKP struct {
+	zkp    *QuantumZKP
+	policy LegacyPolicy
+	warned sync.Map // legacy API name to struct{}
+}
+
+// NewLegacyZKP wraps q under policy.
+func NewLegacyZKP(q *QuantumZKP, policy LegacyPolicy) (*LegacyZKP, error) {
+	if !LegacySupport {
+		return nil, ErrLegacyUnsupported
+	}
+	if q == nil || q.Signer == nil {
+		return nil, errors.New("legacy API requires a configured QuantumZKP")
+	}
+	if policy.Sunset.IsZero() {
+		policy.Sunset = DefaultLegacySunset
+	}
+	if policy.Logger == nil {
+		policy.Logger = slog.Default()
+	}
+	return &LegacyZKP{zkp: q, policy: policy}, nil
+}
+
+// Sunset returns the date from which legacy verification refuses to run.
+func (l *LegacyZKP) Sunset() time.Time {
+	return l.policy.Sunset
+}
+
+// Prove creates a legacy proof of states.
+//
+// Deprecated: legacy proofs reveal the state. Use
+// SecureQuantumZKP.SecureProveVectorKnowledge.
+func (l *LegacyZKP) Prove(states []complex128, identifier string, key []byte) (*Proof, error) {
+	l.warn("Prove", "SecureQuantumZKP.SecureProveVectorKnowledge")
+	if err := l.policy.Features.require(FeatureInsecureProver); err != nil {
+		return nil, err
+	}
+	return l.zkp.Prove(states, identifier, key)
+}
+
+// ProveFromBytes creates a legacy proof of data.
+//
+// Deprecated: legacy proofs reveal the state. Use
+// SecureQuantumZKP.SecureProveFromBytes.
+func (l *LegacyZKP) ProveFromBytes(data []byte, identifier string, key []byte) (*Proof, error) {
+	l.warn("ProveFromBytes", "SecureQuantumZKP.SecureProveFromBytes")
+	if err := l.policy.Features.require(FeatureInsecureProver); err != nil {
+		return nil, err
+	}
+	return l.zkp.ProveFromBytes(data, identifier, key)
+}
+
+// VerifyProof verifies a legacy proof, or fails with ErrLegacySunset from
+// the sunset date on.
+//
+// Deprecated: use SecureQuantumZKP.VerifySecureProof on proofs converted
+// with ConvertProof.
+func (l *LegacyZKP) VerifyProof(proof *Proof, key []byte) (bool, error) {
+	l.warn("VerifyProof", "SecureQuantumZKP.VerifySecureProof")
+	if err := l.checkSunset("VerifyProof"); err != nil {
+		return false, err
+	}
+	return proof != nil && l.zkp.VerifyProof(proof, key), nil
+}
+
+// ConvertProof verifies a legacy proof and proves the state it reveals
+// again with sq, under the same identifier, returning the secure proof.
+// The state has been public since the legacy proof was shared; the
+// secure proof stops it from spreading further but cannot make it secret
+// again.
+func (l *LegacyZKP) ConvertProof(sq *SecureQuantumZKP, proof *Proof, key []byte) (*SecureProof, error) {
+	l.warn("ConvertProof", "")
+	if err := l.checkSunset("ConvertProof"); err != nil {
+		return nil, err
+	}
+	if proof == nil || !l.zkp.VerifyProof(proof, key) {
+		return nil, ErrLegacyProofInvalid
+	}
+	state, err := LegacyProofState(proof)
+	if err != nil {
+		return nil, err
+	}
+	return sq.SecureProveState(state, proof.Identifier, key)
+}
+
+// LegacyProofState returns the state a legacy proof reveals.
+func LegacyProofState(proof *Proof) (*StateVector, error) {
+	if proof == nil {
+		return nil, errors.New("legacy proof cannot be nil")
+	}
+	state, err := NewStateVector(append([]complex128(nil), proof.BasisCoefficients...))
+	if err != nil {
+		return nil, fmt.Errorf("legacy proof state: %w", err)
+	}
+	return state, nil
+}
+
+// checkSunset fails from the sunset date on, or while
+// FeatureLegacyVerification is switched off.
+func (l *LegacyZKP) checkSunset(api string) error {
+	if err := l.policy.Features.require(FeatureLegacyVerification); err != nil {
+		return err
+	}
+	now := time.Now()
+	if l.policy.Clock != nil {
+		now = l.policy.Clock()
+	}
+	if now.Before(l.policy.Sunset) {
+		return nil
+	}
+	l.policy.Logger.Error("legacy proof API refused after sunset",
+		slog.String("api", api),
+		slog.Time("sunset", l.policy.Sunset))
+	return fmt.Errorf("%w (%s)", ErrLegacySunset, l.policy.Sunset.Format(time.DateOnly))
+}
+
+// warn logs the deprecation of api the first time it is used.
+func (l *LegacyZKP) warn(api, replacement string) {
+	if _, seen := l.warned.LoadOrStore(api, struct{}{}); seen {
+		return
+	}
+	attrs := []any{slog.String("api", api), slog.Time("sunset", l.policy.Sunset)}
+	if replacement != "" {
+		attrs = append(attrs, slog.String("replacement", replacement))
+	}
+	l.policy.Logger.Warn("deprecated legacy proof API", attrs...)
+}
@@ -0,0 +1,10 @@
+// Legacy Proof support is built in unless the qzkp_nolegacy tag is set;
+// see legacy_support_off.go.
+
+//go:build !qzkp_nolegacy
+
+package main
+
+// LegacySupport reports whether the legacy Proof API can be used through
+// LegacyZKP. Builds with the qzkp_nolegacy tag leave it out.
+const LegacySupport = true
@@ -0,0 +1,10 @@
+// Builds with the qzkp_nolegacy tag refuse the legacy Proof API; see
+// legacy_support.go.
+
+//go:build qzkp_nolegacy
+
+package main
+
+// LegacySupport reports whether the legacy Proof API can be used through
+// LegacyZKP. Builds with the qzkp_nolegacy tag leave it out.
+const LegacySupport = false
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Domain separators for values derived from a linkage detection key.
+const (
+	linkagePRFDomain = "qzkp/linkage/prf/v1"
+	linkageEncDomain = "qzkp/linkage/seal/v1"
+	linkageIDDomain  = "qzkp/linkage/key-id/v1"
+)
+
+// MinLinkageKeyLength is the minimum detection key length in bytes.
+const MinLinkageKeyLength = 32
+
+// Published lengths of the linkage tag fields: the detection key ID, the
+// AES-GCM nonce and the sealed HMAC-SHA256 tag with its GCM tag.
+const (
+	linkageKeyIDLength  = 8
+	linkageNonceLength  = 12
+	linkageSealedLength = sha256.Size + 16
+)
+
+// LinkageTag lets the holder of a detection key tell whether two proofs
+// were made about the same secret. Sealed is an AES-256-GCM encryption,
+// under a fresh nonce, of a keyed PRF of the canonical state, so tags from
+// the same secret look unrelated to anyone without the key.
+type LinkageTag struct {
+	KeyID  string `json:"key_id"`
+	Nonce  string `json:"nonce"`
+	Sealed string `json:"sealed"`
+}
+
+// linkageKeys are the subkeys derived from one detection key.
+type linkageKeys struct {
+	prf   []byte
+	aead  cipher.AEAD
+	keyID string
+}
+
+func deriveLinkageKeys(detectionKey []byte) (*linkageKeys, error) {
+	if len(detectionKey) < MinLinkageKeyLength {
+		return nil, fmt.Errorf("linkage key must be at least %d bytes", MinLinkageKeyLength)
+	}
+	derive := func(domain string) []byte {
+		mac := hmac.New(sha256.New, detectionKey)
+		mac.Write([]byte(domain))
+		return mac.Sum(nil)
+	}
+	block, err := aes.NewCipher(derive(linkageEncDomain))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &linkageKeys{
+		prf:   derive(linkagePRFDomain),
+		aead:  aead,
+		keyID: EncodeHexField(derive(linkageIDDomain)[:linkageKeyIDLength]),
+	}, nil
+}
+
+// attachLinkageTag adds a same-secret tag for state when a linkage key is
+// configured. Tags are off unless LinkageKey is set.
+func (sq *SecureQuantumZKP) attachLinkageTag(proof *SecureProof, state *StateVector) error {
+	if len(sq.LinkageKey) == 0 {
+		return nil
+	}
+	keys, err := deriveLinkageKeys(sq.LinkageKey)
+	if err != nil {
+		return err
+	}
+	canonical, err := state.CanonicalHash(sq.NumericEncoding)
+	if err != nil {
+		return err
+	}
+	mac := hmac.New(sha256.New, keys.prf)
+	mac.Write(canonical)
+	tag := mac.Sum(nil)
+
+	nonce := make([]byte, keys.aead.NonceSize())
+	if err := sq.readRandom(nonce); err != nil {
+		return err
+	}
+	proof.Linkage = &LinkageTag{
+		KeyID:  keys.keyID,
+		Nonce:  EncodeHexField(nonce),
+		Sealed: EncodeHexField(keys.aead.Seal(nil, nonce, tag, []byte(keys.keyID))),
+	}
+	return nil
+}
+
+// validate checks the tag is well-formed; only the key holder can check
+// more.
+func (t *LinkageTag) validate() error {
+	_, _, err := t.decode()
+	return err
+}
+
+// decode checks the tag's fields and returns its nonce and sealed tag.
+func (t *LinkageTag) decode() (nonce, sealed []byte, err error) {
+	if _, err := DecodeHexField(t.KeyID, linkageKeyIDLength, linkageKeyIDLength); err != nil {
+		return nil, nil, fmt.Errorf("linkage key ID: %w", err)
+	}
+	if nonce, err = DecodeHexField(t.Nonce, linkageNonceLength, linkageNonceLength); err != nil {
+		return nil, nil, fmt.Errorf("linkage nonce: %w", err)
+	}
+	if sealed, err = DecodeHexField(t.Sealed, linkageSealedLength, linkageSealedLength); err != nil {
+		return nil, nil, fmt.Errorf("sealed linkage tag: %w", err)
+	}
+	return nonce, sealed, nil
+}
+
+// LinkageDetector is the auditor side: it opens the linkage tags of the
+// proofs it observes and reports which ones share a secret. It never sees
+// the secrets, only their keyed tags.
+type LinkageDetector struct {
+	keys *linkageKeys
+
+	mu   sync.Mutex
+	seen map[string][]string // opened tag -> proof IDs in observation order
+}
+
+// NewLinkageDetector returns a detector for tags made under detectionKey.
+func NewLinkageDetector(detectionKey []byte) (*LinkageDetector, error) {
+	keys, err := deriveLinkageKeys(detectionKey)
+	if err != nil {
+		return nil, err
+	}
+	return &LinkageDetector{keys: keys, seen: make(map[string][]string)}, nil
+}
+
+// Open returns the same-secret tag of proof. Two proofs were made about
+// the same secret exactly when their opened tags are equal.
+func (d *LinkageDetector) Open(proof *SecureProof) ([]byte, error) {
+	if proof == nil || proof.Linkage == nil {
+		return nil, errors.New("proof carries no linkage tag")
+	}
+	nonce, sealed, err := proof.Linkage.decode()
+	if err != nil {
+		return nil, err
+	}
+	if proof.Linkage.KeyID != d.keys.keyID {
+		return nil, errors.New("linkage tag was made under a different key")
+	}
+	tag, err := d.keys.aead.Open(nil, nonce, sealed, []byte(d.keys.keyID))
+	if err != nil {
+		return nil, errors.New("linkage tag failed authentication")
+	}
+	return tag, nil
+}
+
+// Observe records proof under proofID and returns the IDs of previously
+// observed proofs made about the same secret.
+func (d *LinkageDetector) Observe(proofID string, proof *SecureProof) ([]string, error) {
+	tag, err := d.Open(proof)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := string(tag)
+	matches := append([]string(nil), d.seen[key]...)
+	d.seen[key] = append(d.seen[key], proofID)
+	return matches, nil
+}
+
+// Duplicates returns every group of observed proof IDs that share a
+// secret, each group in observation order, ordered by its first ID.
+func (d *LinkageDetector) Duplicates() [][]string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var groups [][]string
+	for _, ids := range d.seen {
+		if len(ids) > 1 {
+			groups = append(groups, append([]string(nil), ids...))
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+	return groups
+}
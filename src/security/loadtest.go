@@ -0,0 +1,459 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Load test operations.
+const (
+	LoadOpProve  = "prove"
+	LoadOpVerify = "verify"
+)
+
+// LoadTarget is a qzkpd deployment a LoadScenario drives. A service proves
+// at the one security level of its configuration, so a soundness mix is a
+// set of targets, one per level, each drawn with its Weight.
+type LoadTarget struct {
+	URL    string `json:"url"`
+	Weight int    `json:"weight"` // 1 if zero
+}
+
+// LoadProofSize is a proof input size in bytes, drawn with Weight.
+type LoadProofSize struct {
+	Bytes  int `json:"bytes"`
+	Weight int `json:"weight"` // 1 if zero
+}
+
+// LoadBurst multiplies the request rate by Multiplier for the first Length
+// of every Every, both in time.ParseDuration format.
+type LoadBurst struct {
+	Every      string  `json:"every"`
+	Length     string  `json:"length"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// LoadScenario describes the traffic of one load test. Requests arrive at
+// Rate per second, whatever the service's latency, so that an overloaded
+// service shows up as queueing and errors rather than as a slower client.
+type LoadScenario struct {
+	Name       string          `json:"name"`
+	Targets    []LoadTarget    `json:"targets"`
+	ProofSizes []LoadProofSize `json:"proof_sizes"` // 1 KiB if empty
+	// VerifyRatio is how many verifications are sent per proof request;
+	// they verify proofs the scenario has already obtained
+	VerifyRatio float64     `json:"verify_ratio"`
+	Rate        float64     `json:"rate"`        // requests per second outside bursts
+	Duration    string      `json:"duration"`    // time.ParseDuration format
+	Concurrency int         `json:"concurrency"` // requests in flight before arrivals are dropped; 16 if zero
+	Timeout     string      `json:"timeout"`     // per request; "30s" if empty
+	Bursts      []LoadBurst `json:"bursts,omitempty"`
+	Seed        int64       `json:"seed"` // seeds the request mix and proof inputs
+}
+
+// ReadLoadScenarios reads a JSON array of scenarios from path.
+func ReadLoadScenarios(path string) ([]LoadScenario, error) {
+	data, err := hostFS.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenarios: %w", err)
+	}
+	var scenarios []LoadScenario
+	if err := json.Unmarshal(data, &scenarios); err != nil {
+		return nil, fmt.Errorf("failed to parse scenarios: %w", err)
+	}
+	return scenarios, nil
+}
+
+// loadPlan is a validated LoadScenario.
+type loadPlan struct {
+	duration, timeout time.Duration
+	bursts            []loadBurstPlan
+}
+
+type loadBurstPlan struct {
+	every, length time.Duration
+	multiplier    float64
+}
+
+// plan checks the scenario and parses its durations.
+func (s *LoadScenario) plan() (*loadPlan, error) {
+	if len(s.Targets) == 0 {
+		return nil, errors.New("scenario has no targets")
+	}
+	if s.Rate <= 0 || s.VerifyRatio < 0 {
+		return nil, fmt.Errorf("rate %g must be positive and verify ratio %g not negative", s.Rate, s.VerifyRatio)
+	}
+	for _, size := range s.ProofSizes {
+		if size.Bytes <= 0 || size.Weight < 0 {
+			return nil, fmt.Errorf("invalid proof size %+v", size)
+		}
+	}
+	for _, target := range s.Targets {
+		if target.URL == "" || target.Weight < 0 {
+			return nil, fmt.Errorf("invalid target %+v", target)
+		}
+	}
+	p := &loadPlan{}
+	var err error
+	if p.duration, err = time.ParseDuration(s.Duration); err != nil || p.duration <= 0 {
+		return nil, fmt.Errorf("invalid duration %q", s.Duration)
+	}
+	p.timeout = 30 * time.Second
+	if s.Timeout != "" {
+		if p.timeout, err = time.ParseDuration(s.Timeout); err != nil || p.timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout %q", s.Timeout)
+		}
+	}
+	for _, burst := range s.Bursts {
+		every, err1 := time.ParseDuration(burst.Every)
+		length, err2 := time.ParseDuration(burst.Length)
+		if err1 != nil || err2 != nil || every <= 0 || length <= 0 || length > every || burst.Multiplier <= 0 {
+			return nil, fmt.Errorf("invalid burst %+v", burst)
+		}
+		p.bursts = append(p.bursts, loadBurstPlan{every: every, length: length, multiplier: burst.Multiplier})
+	}
+	return p, nil
+}
+
+// rate is the arrival rate elapsed into the test.
+func (p *loadPlan) rate(base float64, elapsed time.Duration) float64 {
+	for _, burst := range p.bursts {
+		if elapsed%burst.every < burst.length {
+			base *= burst.multiplier
+		}
+	}
+	return base
+}
+
+// LoadOpStats summarizes one operation against one target, or against all
+// of them when Target is empty.
+type LoadOpStats struct {
+	Target    string `json:"target,omitempty"`
+	Operation string `json:"operation"`
+	Requests  int    `json:"requests"`
+	Errors    int    `json:"errors"`
+	// ErrorKinds counts errors by HTTP status, "timeout", "invalid" for a
+	// proof the service rejected or returned unverifiable, or "transport"
+	ErrorKinds map[string]int `json:"error_kinds,omitempty"`
+	ErrorRate  float64        `json:"error_rate"`
+	Throughput float64        `json:"throughput"` // successful requests per second
+	// Latency percentiles of successful requests
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P99 time.Duration `json:"p99"`
+	Max time.Duration `json:"max"`
+
+	latencies []time.Duration
+}
+
+// LoadTestReport is the outcome of RunLoadScenario.
+type LoadTestReport struct {
+	Scenario string        `json:"scenario"`
+	Started  time.Time     `json:"started"`
+	Wall     time.Duration `json:"wall"`
+	Sent     int           `json:"sent"`
+	// Dropped counts arrivals not sent because Concurrency requests were
+	// already in flight: the service fell behind the offered rate
+	Dropped    int           `json:"dropped"`
+	Totals     []LoadOpStats `json:"totals"`
+	Operations []LoadOpStats `json:"operations"` // per target
+}
+
+// loadRun is the shared state of a running scenario.
+type loadRun struct {
+	scenario *LoadScenario
+	plan     *loadPlan
+	clients  []*Client
+
+	mu     sync.Mutex
+	rng    *rand.Rand
+	proofs [][]*SecureProof // recent proofs per target, for verification
+	stats  map[[2]string]*LoadOpStats
+}
+
+// errLoadProofRejected is recorded when a service rejects a proof it
+// issued.
+var errLoadProofRejected = errors.New("service rejected its own proof")
+
+// maxLoadProofPool bounds the proofs kept per target for verification.
+const maxLoadProofPool = 64
+
+// RunLoadScenario drives scenario's targets until its duration elapses or
+// ctx is done, then waits for requests in flight. Clients do not retry,
+// so every rejection is counted.
+func RunLoadScenario(ctx context.Context, scenario LoadScenario) (*LoadTestReport, error) {
+	plan, err := scenario.plan()
+	if err != nil {
+		return nil, err
+	}
+	concurrency := scenario.Concurrency
+	if concurrency <= 0 {
+		concurrency = 16
+	}
+	run := &loadRun{
+		scenario: &scenario,
+		plan:     plan,
+		rng:      rand.New(rand.NewSource(scenario.Seed)),
+		proofs:   make([][]*SecureProof, len(scenario.Targets)),
+		stats:    make(map[[2]string]*LoadOpStats),
+	}
+	for _, target := range scenario.Targets {
+		client := NewClient(target.URL)
+		client.MaxRetries = 0
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConnsPerHost = concurrency
+		client.HTTPClient = &http.Client{Transport: transport}
+		// Fetch the service key up front, so that it is not timed
+		if _, err := client.verifierFor(ctx); err != nil {
+			return nil, fmt.Errorf("target %s: %w", target.URL, err)
+		}
+		run.clients = append(run.clients, client)
+	}
+
+	report := &LoadTestReport{Scenario: scenario.Name, Started: time.Now()}
+	slots := make(chan struct{}, concurrency)
+	var inFlight sync.WaitGroup
+	deadline := time.NewTimer(plan.duration)
+	defer deadline.Stop()
+
+	// Arrivals are spaced 1/rate apart on a fixed schedule, which catches
+	// up after a late wakeup instead of drifting
+	next := report.Started
+arrivals:
+	for {
+		elapsed := next.Sub(report.Started)
+		if elapsed >= plan.duration {
+			break
+		}
+		next = next.Add(time.Duration(float64(time.Second) / plan.rate(scenario.Rate, elapsed)))
+		if wait := time.Until(next); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-deadline.C:
+				break arrivals
+			case <-ctx.Done():
+				break arrivals
+			}
+		}
+		select {
+		case slots <- struct{}{}:
+		default:
+			report.Dropped++
+			continue
+		}
+		report.Sent++
+		target, op, size := run.draw()
+		inFlight.Add(1)
+		go func() {
+			defer inFlight.Done()
+			defer func() { <-slots }()
+			run.request(ctx, target, op, size)
+		}()
+	}
+	inFlight.Wait()
+	report.Wall = time.Since(report.Started)
+	run.summarize(report)
+	return report, nil
+}
+
+// draw picks the target, operation and input size of the next request.
+func (r *loadRun) draw() (target int, op string, size int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	target = weightedIndex(r.rng, len(r.scenario.Targets), func(i int) int { return r.scenario.Targets[i].Weight })
+	op = LoadOpProve
+	// Verify with probability ratio/(1+ratio), once there is a proof to verify
+	if len(r.proofs[target]) > 0 && r.rng.Float64()*(1+r.scenario.VerifyRatio) >= 1 {
+		op = LoadOpVerify
+	}
+	size = 1 << 10
+	if len(r.scenario.ProofSizes) > 0 {
+		size = r.scenario.ProofSizes[weightedIndex(r.rng, len(r.scenario.ProofSizes), func(i int) int { return r.scenario.ProofSizes[i].Weight })].Bytes
+	}
+	return target, op, size
+}
+
+// weightedIndex draws an index in [0, n) with the given weights, zero
+// weights counting as 1.
+func weightedIndex(rng *rand.Rand, n int, weight func(int) int) int {
+	w := func(i int) int { return max(weight(i), 1) }
+	total := 0
+	for i := 0; i < n; i++ {
+		total += w(i)
+	}
+	pick := rng.Intn(total)
+	for i := 0; i < n; i++ {
+		if pick -= w(i); pick < 0 {
+			return i
+		}
+	}
+	return n - 1
+}
+
+// request sends one request and records its outcome.
+func (r *loadRun) request(ctx context.Context, target int, op string, size int) {
+	ctx, cancel := context.WithTimeout(ctx, r.plan.timeout)
+	defer cancel()
+	client := r.clients[target]
+
+	var (
+		err     error
+		proof   *SecureProof
+		started time.Time
+	)
+	switch op {
+	case LoadOpProve:
+		data := make([]byte, size)
+		r.mu.Lock()
+		r.rng.Read(data)
+		identifier := "loadtest-" + strconv.Itoa(r.rng.Int())
+		r.mu.Unlock()
+		started = time.Now()
+		proof, err = client.Prove(ctx, identifier, data)
+	case LoadOpVerify:
+		r.mu.Lock()
+		pool := r.proofs[target]
+		proof = pool[r.rng.Intn(len(pool))]
+		r.mu.Unlock()
+		started = time.Now()
+		var valid bool
+		if valid, err = client.Verify(ctx, proof); err == nil && !valid {
+			err = errLoadProofRejected
+		}
+	}
+	latency := time.Since(started)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if op == LoadOpProve && err == nil {
+		if pool := r.proofs[target]; len(pool) < maxLoadProofPool {
+			r.proofs[target] = append(pool, proof)
+		} else {
+			pool[r.rng.Intn(len(pool))] = proof
+		}
+	}
+	for _, key := range [][2]string{{r.scenario.Targets[target].URL, op}, {"", op}} {
+		stats := r.stats[key]
+		if stats == nil {
+			stats = &LoadOpStats{Target: key[0], Operation: op}
+			r.stats[key] = stats
+		}
+		stats.Requests++
+		if err == nil {
+			stats.latencies = append(stats.latencies, latency)
+			continue
+		}
+		stats.Errors++
+		if stats.ErrorKinds == nil {
+			stats.ErrorKinds = make(map[string]int)
+		}
+		stats.ErrorKinds[loadErrorKind(err)]++
+	}
+}
+
+// loadErrorKind classifies a failed request.
+func loadErrorKind(err error) string {
+	var apiErr *APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return strconv.Itoa(apiErr.StatusCode)
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, errLoadProofRejected), errors.Is(err, ErrProofSignatureInvalid):
+		return "invalid"
+	}
+	return "transport"
+}
+
+// summarize computes the statistics of every target and operation, in
+// target order, proving before verification.
+func (r *loadRun) summarize(report *LoadTestReport) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seconds := report.Wall.Seconds()
+	for _, op := range []string{LoadOpProve, LoadOpVerify} {
+		if stats := r.stats[[2]string{"", op}]; stats != nil {
+			report.Totals = append(report.Totals, stats.summarize(seconds))
+		}
+		for _, target := range r.scenario.Targets {
+			if stats := r.stats[[2]string{target.URL, op}]; stats != nil {
+				report.Operations = append(report.Operations, stats.summarize(seconds))
+			}
+		}
+	}
+}
+
+// summarize returns the stats with rates and percentiles filled.
+func (s *LoadOpStats) summarize(seconds float64) LoadOpStats {
+	out := *s
+	out.latencies = nil
+	out.ErrorRate = float64(s.Errors) / float64(s.Requests)
+	if seconds > 0 {
+		out.Throughput = float64(len(s.latencies)) / seconds
+	}
+	if len(s.latencies) == 0 {
+		return out
+	}
+	latencies := slices.Clone(s.latencies)
+	slices.Sort(latencies)
+	// Nearest-rank percentiles
+	rank := func(p int) time.Duration { return latencies[(p*len(latencies)+99)/100-1] }
+	out.P50, out.P90, out.P99, out.Max = rank(50), rank(90), rank(99), latencies[len(latencies)-1]
+	return out
+}
+
+// WriteMarkdown writes the totals and the per-target statistics.
+func (r *LoadTestReport) WriteMarkdown(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "# Load test %s (%s)\n\nSent %d requests in %s; %d arrivals dropped at the concurrency limit.\n",
+		r.Scenario, r.Started.Format(time.RFC3339), r.Sent, r.Wall.Round(time.Millisecond), r.Dropped); err != nil {
+		return err
+	}
+	for _, section := range []struct {
+		title string
+		stats []LoadOpStats
+	}{{"totals", r.Totals}, {"per target", r.Operations}} {
+		table := PaperTable{Columns: []string{"target", "operation", "requests", "errors", "error rate", "throughput/s", "p50", "p90", "p99", "max"}}
+		for _, s := range section.stats {
+			target := s.Target
+			if target == "" {
+				target = "all"
+			}
+			table.Rows = append(table.Rows, []string{target, s.Operation, strconv.Itoa(s.Requests), formatErrorKinds(s.ErrorKinds),
+				formatPercent(100 * s.ErrorRate), strconv.FormatFloat(s.Throughput, 'f', 1, 64),
+				s.P50.Round(time.Microsecond).String(), s.P90.Round(time.Microsecond).String(),
+				s.P99.Round(time.Microsecond).String(), s.Max.Round(time.Microsecond).String()})
+		}
+		if _, err := fmt.Fprintf(w, "\n## %s\n\n", section.title); err != nil {
+			return err
+		}
+		if err := table.WriteMarkdown(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatErrorKinds lists error counts by kind, e.g. "3 (429: 2, timeout: 1)".
+func formatErrorKinds(kinds map[string]int) string {
+	total := 0
+	var parts []string
+	for _, kind := range slices.Sorted(maps.Keys(kinds)) {
+		total += kinds[kind]
+		parts = append(parts, fmt.Sprintf("%s: %d", kind, kinds[kind]))
+	}
+	if total == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%d (%s)", total, strings.Join(parts, ", "))
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// File extensions used by the local stores.
+const (
+	keyFileExt   = ".key"
+	usageFileExt = ".usage.json"
+	proofFileExt = ".proof.json"
+)
+
+// maxStoreNameLength bounds entry names so paths stay portable.
+const maxStoreNameLength = 128
+
+// Keystore keeps commitment keys as hex files in the keystore directory,
+// each with a usage record checked against Limits. Files are readable by
+// the owner only.
+type Keystore struct {
+	Dir    string
+	Limits KeyUsageLimits
+
+	mu sync.Mutex // serializes usage record updates
+}
+
+// NewKeystore opens the keystore in dirs, creating it if needed.
+func NewKeystore(dirs *DataDirs) (*Keystore, error) {
+	dir := dirs.KeystoreDir()
+	if err := hostFS.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create keystore: %w", err)
+	}
+	return &Keystore{Dir: dir, Limits: DefaultKeyUsageLimits()}, nil
+}
+
+// Put stores key under name, replacing any existing key. Replacing a key
+// is a rotation, so its usage record starts over.
+func (ks *Keystore) Put(name string, key []byte) error {
+	if err := validateStoreName(name); err != nil {
+		return err
+	}
+	if len(key) < 32 {
+		return fmt.Errorf("key must be at least 32 bytes, got %d", len(key))
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if err := writeFileAtomic(ks.path(name), []byte(hex.EncodeToString(key)), 0600); err != nil {
+		return err
+	}
+	return ks.writeUsage(&KeyUsage{Name: name, Created: time.Now().UTC()})
+}
+
+// Get returns the key stored under name.
+func (ks *Keystore) Get(name string) ([]byte, error) {
+	if err := validateStoreName(name); err != nil {
+		return nil, err
+	}
+	data, err := hostFS.ReadFile(ks.path(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %q: %w", name, err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("key %q is corrupted: %w", name, err)
+	}
+	return key, nil
+}
+
+// Delete removes the key stored under name and its usage record.
+func (ks *Keystore) Delete(name string) error {
+	if err := validateStoreName(name); err != nil {
+		return err
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if err := hostFS.Remove(ks.path(name)); err != nil {
+		return err
+	}
+	if err := hostFS.Remove(ks.usagePath(name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// List returns the names of all stored keys.
+func (ks *Keystore) List() ([]string, error) {
+	return listStoreEntries(ks.Dir, keyFileExt)
+}
+
+func (ks *Keystore) path(name string) string {
+	return filepath.Join(ks.Dir, name+keyFileExt)
+}
+
+func (ks *Keystore) usagePath(name string) string {
+	return filepath.Join(ks.Dir, name+usageFileExt)
+}
+
+// ProofStore keeps proofs as JSON files in the proof store directory, or
+// as objects in Objects when it is set.
+type ProofStore struct {
+	Dir     string
+	Objects ObjectStore
+}
+
+// NewProofStore opens the proof store in dirs, creating it if needed.
+func NewProofStore(dirs *DataDirs) (*ProofStore, error) {
+	dir := dirs.ProofStoreDir()
+	if err := hostFS.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create proof store: %w", err)
+	}
+	return &ProofStore{Dir: dir}, nil
+}
+
+// NewObjectProofStore opens a proof store kept in objects, such as an
+// S3ObjectStore shared by a fleet of provers.
+func NewObjectProofStore(objects ObjectStore) *ProofStore {
+	return &ProofStore{Objects: objects}
+}
+
+// Save stores proof under name, replacing any existing proof.
+func (ps *ProofStore) Save(name string, proof *SecureProof) error {
+	if err := validateStoreName(name); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(proof, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal proof: %w", err)
+	}
+	return ps.writeEntry(name+proofFileExt, data, 0644)
+}
+
+// Load reads the proof stored under name with ParseSecureProof, so proofs
+// saved by older releases still load.
+func (ps *ProofStore) Load(name string) (*SecureProof, error) {
+	if err := validateStoreName(name); err != nil {
+		return nil, err
+	}
+	data, err := ps.readEntry(name + proofFileExt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proof %q: %w", name, err)
+	}
+	return ParseSecureProof(data)
+}
+
+// Delete removes the proof stored under name.
+func (ps *ProofStore) Delete(name string) error {
+	if err := validateStoreName(name); err != nil {
+		return err
+	}
+	if ps.Objects != nil {
+		return ps.Objects.Delete(name + proofFileExt)
+	}
+	return hostFS.Remove(ps.path(name))
+}
+
+// List returns the sorted names of all stored proofs. Use IterProofs to
+// process large stores without materializing them.
+func (ps *ProofStore) List() ([]string, error) {
+	var names []string
+	for name, err := range ps.entries(proofFileExt) {
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (ps *ProofStore) path(name string) string {
+	return filepath.Join(ps.Dir, name+proofFileExt)
+}
+
+// writeEntry writes the named file of the store.
+func (ps *ProofStore) writeEntry(file string, data []byte, perm os.FileMode) error {
+	if ps.Objects != nil {
+		_, err := ps.Objects.Put(file, data, PutCondition{})
+		return err
+	}
+	return writeFileAtomic(filepath.Join(ps.Dir, file), data, perm)
+}
+
+// readEntry reads the named file of the store.
+func (ps *ProofStore) readEntry(file string) ([]byte, error) {
+	if ps.Objects != nil {
+		data, _, err := ps.Objects.Get(file)
+		return data, err
+	}
+	return hostFS.ReadFile(filepath.Join(ps.Dir, file))
+}
+
+// entries yields the names of the store's files with ext, without it.
+// Object keys containing a slash are skipped, as subdirectories are.
+func (ps *ProofStore) entries(ext string) iter.Seq2[string, error] {
+	if ps.Objects == nil {
+		return iterStoreEntries(ps.Dir, ext)
+	}
+	return func(yield func(string, error) bool) {
+		for key, err := range ps.Objects.List("") {
+			if err != nil {
+				yield("", err)
+				return
+			}
+			if strings.HasSuffix(key, ext) && !strings.Contains(key, "/") {
+				if !yield(strings.TrimSuffix(key, ext), nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// validateStoreName accepts names that are valid file names on every
+// supported OS and cannot escape the store directory.
+func validateStoreName(name string) error {
+	if name == "" || len(name) > maxStoreNameLength {
+		return fmt.Errorf("name must be 1 to %d characters", maxStoreNameLength)
+	}
+	if name[0] == '.' {
+		return fmt.Errorf("name %q must not start with a dot", name)
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-' || r == '_' || r == '.':
+		default:
+			return fmt.Errorf("name %q contains %q; use letters, digits, '-', '_' or '.'", name, r)
+		}
+	}
+	return nil
+}
+
+// listStoreEntries returns the sorted names of files in dir with ext.
+func listStoreEntries(dir, ext string) ([]string, error) {
+	var names []string
+	for name, err := range iterStoreEntries(dir, ext) {
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+)
+
+// AmplitudeMeasurement is the outcome a challenge response commits to:
+// the squared magnitude and the phase of one amplitude in the challenged
+// basis.
+type AmplitudeMeasurement struct {
+	Probability float64
+	Phase       float64
+}
+
+// MeasurementRequest asks for amplitude Index of state State in the Z
+// basis, or in the X basis if XBasis is set.
+type MeasurementRequest struct {
+	State  int
+	Index  int
+	XBasis bool
+}
+
+// MeasurementBatch is the per-challenge measurement math of many proofs.
+// Every state's length is a power of two.
+type MeasurementBatch struct {
+	States   [][]complex128
+	Requests []MeasurementRequest
+	// Streaming asks for X-basis amplitudes to be computed one at a time
+	// instead of transforming whole states (see MemoryBudget)
+	Streaming bool
+}
+
+// MeasurementBackend computes the measurements of a batch, one per
+// request, in request order. Backends other than the CPU one must agree
+// with it to well within the numeric encoding's resolution; proofs made
+// with them remain valid but need not be byte-identical. Implementations
+// must be safe for concurrent use.
+type MeasurementBackend interface {
+	Name() string
+	Measure(batch *MeasurementBatch) ([]AmplitudeMeasurement, error)
+}
+
+// measurementBackends holds the backends that can be selected by name.
+// Optional backends register themselves from files behind build tags.
+var measurementBackends = map[string]func() (MeasurementBackend, error){
+	"cpu": func() (MeasurementBackend, error) { return CPUMeasurementBackend{}, nil },
+}
+
+// registerMeasurementBackend makes a backend available to
+// NewMeasurementBackend.
+func registerMeasurementBackend(name string, open func() (MeasurementBackend, error)) {
+	measurementBackends[name] = open
+}
+
+// MeasurementBackends lists the backends compiled into this build.
+func MeasurementBackends() []string {
+	names := make([]string, 0, len(measurementBackends))
+	for name := range measurementBackends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewMeasurementBackend opens the named backend.
+func NewMeasurementBackend(name string) (MeasurementBackend, error) {
+	open, ok := measurementBackends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown measurement backend %q (available: %v)", name, MeasurementBackends())
+	}
+	return open()
+}
+
+// measurementBackend returns sq's backend, the CPU one by default or
+// while FeatureHardwareBackends is switched off.
+func (sq *SecureQuantumZKP) measurementBackend() (MeasurementBackend, error) {
+	if sq.MeasurementBackend != nil && sq.Features.Enabled(FeatureHardwareBackends) {
+		return sq.MeasurementBackend, sq.checkHostAccess(sq.MeasurementBackend)
+	}
+	return CPUMeasurementBackend{}, nil
+}
+
+// CPUMeasurementBackend is the reference backend. States are measured in
+// parallel across Workers goroutines, each state's Hadamard transform
+// computed once and shared by its requests unless the batch is streaming.
+type CPUMeasurementBackend struct {
+	Workers int // 0 means runtime.GOMAXPROCS
+}
+
+// Name implements MeasurementBackend.
+func (CPUMeasurementBackend) Name() string { return "cpu" }
+
+// Measure implements MeasurementBackend.
+func (b CPUMeasurementBackend) Measure(batch *MeasurementBatch) ([]AmplitudeMeasurement, error) {
+	if err := batch.validate(); err != nil {
+		return nil, err
+	}
+	byState := make(map[int][]int)
+	var order []int
+	for i, r := range batch.Requests {
+		if _, ok := byState[r.State]; !ok {
+			order = append(order, r.State)
+		}
+		byState[r.State] = append(byState[r.State], i)
+	}
+
+	workers := b.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	out := make([]AmplitudeMeasurement, len(batch.Requests))
+	err := forEachParallel(len(order), workers, func(j int) error {
+		state := order[j]
+		xBasis := &xBasisAmplitudes{state: batch.States[state], streaming: batch.Streaming}
+		for _, i := range byState[state] {
+			m, err := measureAmplitude(batch.States[state], xBasis, batch.Requests[i])
+			if err != nil {
+				return fmt.Errorf("state %d: %w", state, err)
+			}
+			out[i] = m
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// measureAmplitude measures one request.
+func measureAmplitude(state []complex128, xBasis *xBasisAmplitudes, r MeasurementRequest) (AmplitudeMeasurement, error) {
+	c := state[r.Index]
+	if r.XBasis {
+		x, err := xBasis.at(r.Index)
+		if err != nil {
+			return AmplitudeMeasurement{}, err
+		}
+		c = x
+	}
+	return AmplitudeMeasurement{
+		Probability: real(c)*real(c) + imag(c)*imag(c),
+		Phase:       math.Atan2(imag(c), real(c)),
+	}, nil
+}
+
+// validate checks every request refers to an amplitude of a state.
+func (batch *MeasurementBatch) validate() error {
+	for i, state := range batch.States {
+		if n := len(state); n == 0 || n&(n-1) != 0 {
+			return fmt.Errorf("state %d length %d is not a power of two", i, n)
+		}
+	}
+	for i, r := range batch.Requests {
+		if r.State < 0 || r.State >= len(batch.States) {
+			return fmt.Errorf("request %d refers to state %d of %d", i, r.State, len(batch.States))
+		}
+		if r.Index < 0 || r.Index >= len(batch.States[r.State]) {
+			return fmt.Errorf("request %d index %d out of range", i, r.Index)
+		}
+	}
+	return nil
+}
+
+// measurementRequest maps a challenge to the request measuring it.
+func measurementRequest(state int, c Challenge) (MeasurementRequest, error) {
+	switch c.BasisType {
+	case basisZ:
+		return MeasurementRequest{State: state, Index: c.Index}, nil
+	case basisX:
+		return MeasurementRequest{State: state, Index: c.Index, XBasis: true}, nil
+	default:
+		return MeasurementRequest{}, fmt.Errorf("unknown basis %q", c.BasisType)
+	}
+}
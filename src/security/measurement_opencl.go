@@ -0,0 +1,231 @@
+// The OpenCL measurement backend is experimental and needs cgo, an OpenCL
+// 1.2 runtime and a device with double precision. Build with
+// -tags qzkp_opencl to include it; it registers as "opencl".
+
+//go:build qzkp_opencl && cgo
+
+package main
+
+/*
+#cgo darwin LDFLAGS: -framework OpenCL
+#cgo !darwin LDFLAGS: -lOpenCL
+#define CL_TARGET_OPENCL_VERSION 120
+#ifdef __APPLE__
+#include <OpenCL/opencl.h>
+#else
+#include <CL/cl.h>
+#endif
+*/
+import "C"
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// openCLMeasureSource computes one request per work item. X-basis
+// amplitudes are summed directly, (1/√n) Σ_j (-1)^{popcount(j&k)} ψ_j, so
+// no transformed state is kept on the device.
+const openCLMeasureSource = `
+#pragma OPENCL EXTENSION cl_khr_fp64 : enable
+__kernel void measure(__global const double2 *amps, __global const int *offsets,
+                      __global const int *dims, __global const int4 *reqs,
+                      __global double2 *out) {
+	int r = get_global_id(0);
+	int4 q = reqs[r];
+	int off = offsets[q.x];
+	int n = dims[q.x];
+	double2 c = amps[off + q.y];
+	if (q.z != 0) {
+		c = (double2)(0.0, 0.0);
+		for (int j = 0; j < n; j++) {
+			if (popcount(j & q.y) & 1) c -= amps[off + j]; else c += amps[off + j];
+		}
+		c *= 1.0 / sqrt((double)n);
+	}
+	out[r] = (double2)(c.x * c.x + c.y * c.y, atan2(c.y, c.x));
+}
+`
+
+func init() {
+	registerMeasurementBackend("opencl", func() (MeasurementBackend, error) { return newOpenCLBackend() })
+}
+
+// openCLBackend runs the measurement kernel on the first GPU, or the
+// first device of any kind when there is no GPU.
+type openCLBackend struct {
+	mu      sync.Mutex // command queues and kernel arguments are not shared safely
+	device  C.cl_device_id
+	context C.cl_context
+	queue   C.cl_command_queue
+	kernel  C.cl_kernel
+}
+
+func newOpenCLBackend() (*openCLBackend, error) {
+	var platform C.cl_platform_id
+	if status := C.clGetPlatformIDs(1, &platform, nil); status != C.CL_SUCCESS {
+		return nil, openCLError("clGetPlatformIDs", status)
+	}
+	b := &openCLBackend{}
+	if status := C.clGetDeviceIDs(platform, C.CL_DEVICE_TYPE_GPU, 1, &b.device, nil); status != C.CL_SUCCESS {
+		if status := C.clGetDeviceIDs(platform, C.CL_DEVICE_TYPE_ALL, 1, &b.device, nil); status != C.CL_SUCCESS {
+			return nil, openCLError("clGetDeviceIDs", status)
+		}
+	}
+
+	var status C.cl_int
+	b.context = C.clCreateContext(nil, 1, &b.device, nil, nil, &status)
+	if status != C.CL_SUCCESS {
+		return nil, openCLError("clCreateContext", status)
+	}
+	b.queue = C.clCreateCommandQueue(b.context, b.device, 0, &status)
+	if status != C.CL_SUCCESS {
+		b.Close()
+		return nil, openCLError("clCreateCommandQueue", status)
+	}
+
+	source := C.CString(openCLMeasureSource)
+	defer C.free(unsafe.Pointer(source))
+	program := C.clCreateProgramWithSource(b.context, 1, &source, nil, &status)
+	if status != C.CL_SUCCESS {
+		b.Close()
+		return nil, openCLError("clCreateProgramWithSource", status)
+	}
+	defer C.clReleaseProgram(program)
+	if status := C.clBuildProgram(program, 1, &b.device, nil, nil, nil); status != C.CL_SUCCESS {
+		b.Close()
+		return nil, openCLError("clBuildProgram", status)
+	}
+	name := C.CString("measure")
+	defer C.free(unsafe.Pointer(name))
+	b.kernel = C.clCreateKernel(program, name, &status)
+	if status != C.CL_SUCCESS {
+		b.Close()
+		return nil, openCLError("clCreateKernel", status)
+	}
+	return b, nil
+}
+
+// Name implements MeasurementBackend.
+func (b *openCLBackend) Name() string { return "opencl" }
+
+// Measure implements MeasurementBackend. The whole batch is copied to the
+// device and measured by one kernel launch.
+func (b *openCLBackend) Measure(batch *MeasurementBatch) ([]AmplitudeMeasurement, error) {
+	if err := batch.validate(); err != nil {
+		return nil, err
+	}
+	if len(batch.Requests) == 0 {
+		return nil, nil
+	}
+
+	var amps []complex128
+	offsets := make([]C.cl_int, len(batch.States))
+	dims := make([]C.cl_int, len(batch.States))
+	for i, state := range batch.States {
+		offsets[i] = C.cl_int(len(amps))
+		dims[i] = C.cl_int(len(state))
+		amps = append(amps, state...)
+	}
+	requests := make([]C.cl_int, 4*len(batch.Requests))
+	for i, r := range batch.Requests {
+		requests[4*i] = C.cl_int(r.State)
+		requests[4*i+1] = C.cl_int(r.Index)
+		if r.XBasis {
+			requests[4*i+2] = 1
+		}
+	}
+	results := make([]float64, 2*len(batch.Requests))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var buffers []C.cl_mem
+	defer func() {
+		for _, buffer := range buffers {
+			C.clReleaseMemObject(buffer)
+		}
+	}()
+	input := func(data unsafe.Pointer, size int) (C.cl_mem, error) {
+		var status C.cl_int
+		buffer := C.clCreateBuffer(b.context, C.CL_MEM_READ_ONLY|C.CL_MEM_COPY_HOST_PTR, C.size_t(size), data, &status)
+		if status != C.CL_SUCCESS {
+			return nil, openCLError("clCreateBuffer", status)
+		}
+		buffers = append(buffers, buffer)
+		return buffer, nil
+	}
+	ampsBuffer, err := input(unsafe.Pointer(&amps[0]), len(amps)*16)
+	if err != nil {
+		return nil, err
+	}
+	offsetsBuffer, err := input(unsafe.Pointer(&offsets[0]), len(offsets)*4)
+	if err != nil {
+		return nil, err
+	}
+	dimsBuffer, err := input(unsafe.Pointer(&dims[0]), len(dims)*4)
+	if err != nil {
+		return nil, err
+	}
+	requestsBuffer, err := input(unsafe.Pointer(&requests[0]), len(requests)*4)
+	if err != nil {
+		return nil, err
+	}
+	var status C.cl_int
+	outBuffer := C.clCreateBuffer(b.context, C.CL_MEM_WRITE_ONLY, C.size_t(len(results)*8), nil, &status)
+	if status != C.CL_SUCCESS {
+		return nil, openCLError("clCreateBuffer", status)
+	}
+	buffers = append(buffers, outBuffer)
+
+	memSize := C.size_t(unsafe.Sizeof(ampsBuffer))
+	args := []struct {
+		size  C.size_t
+		value unsafe.Pointer
+	}{
+		{memSize, unsafe.Pointer(&ampsBuffer)},
+		{memSize, unsafe.Pointer(&offsetsBuffer)},
+		{memSize, unsafe.Pointer(&dimsBuffer)},
+		{memSize, unsafe.Pointer(&requestsBuffer)},
+		{memSize, unsafe.Pointer(&outBuffer)},
+	}
+	for i, arg := range args {
+		if status := C.clSetKernelArg(b.kernel, C.cl_uint(i), arg.size, arg.value); status != C.CL_SUCCESS {
+			return nil, openCLError("clSetKernelArg", status)
+		}
+	}
+
+	global := C.size_t(len(batch.Requests))
+	if status := C.clEnqueueNDRangeKernel(b.queue, b.kernel, 1, nil, &global, nil, 0, nil, nil); status != C.CL_SUCCESS {
+		return nil, openCLError("clEnqueueNDRangeKernel", status)
+	}
+	if status := C.clEnqueueReadBuffer(b.queue, outBuffer, C.CL_TRUE, 0, C.size_t(len(results)*8),
+		unsafe.Pointer(&results[0]), 0, nil, nil); status != C.CL_SUCCESS {
+		return nil, openCLError("clEnqueueReadBuffer", status)
+	}
+
+	out := make([]AmplitudeMeasurement, len(batch.Requests))
+	for i := range out {
+		out[i] = AmplitudeMeasurement{Probability: results[2*i], Phase: results[2*i+1]}
+	}
+	return out, nil
+}
+
+// Close releases the device resources.
+func (b *openCLBackend) Close() error {
+	if b.kernel != nil {
+		C.clReleaseKernel(b.kernel)
+	}
+	if b.queue != nil {
+		C.clReleaseCommandQueue(b.queue)
+	}
+	if b.context != nil {
+		C.clReleaseContext(b.context)
+	}
+	return nil
+}
+
+func openCLError(call string, status C.cl_int) error {
+	return fmt.Errorf("%s failed with OpenCL status %d", call, int(status))
+}
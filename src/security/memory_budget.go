@@ -0,0 +1,174 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+)
+
+// ErrMemoryBudgetExceeded is returned before proof generation starts when
+// even streaming generation would exceed the hard memory limit.
+var ErrMemoryBudgetExceeded = errors.New("proof generation would exceed the memory budget")
+
+// Allocation model used by EstimateProofMemory, in bytes. The figures
+// are measured with runtime.MemStats; estimateHeadroom is added on top.
+const (
+	amplitudeBytes    = 16       // one complex128
+	fixedPointBytes   = 8        // one encoded number of the commitment
+	responseWorkBytes = 6 << 10  // hashers, formatted inputs and Merkle leaf of one response
+	responseJSONBytes = 128      // JSON of one response besides its digests
+	proofJSONBytes    = 16 << 10 // JSON of everything but the responses
+	proofFixedBytes   = 64 << 10 // challenge derivation, parameters hash, Merkle levels, signing
+	gzipWorkBytes     = 3 << 19  // compressor state
+)
+
+// estimateHeadroom is the share, in percent, added to every item of an
+// estimate. Measured proofs come to 70-85% of the model's figures, so
+// with the headroom they stay under 70% of the estimate and allocator or
+// runtime changes do not turn it into an underestimate.
+const estimateHeadroom = 25
+
+// MemoryBudget bounds the memory proof generation may use. A zero limit
+// is disabled.
+type MemoryBudget struct {
+	// Soft is the estimate above which X-basis amplitudes are computed
+	// one at a time instead of from a materialized Hadamard transform,
+	// trading time for a state-sized buffer.
+	Soft int64
+	// Hard is the estimate above which generation is refused with
+	// ErrMemoryBudgetExceeded.
+	Hard int64
+	// Codec is the proof codec the caller encodes the proof with (see
+	// ProofCodecs); empty means plain JSON.
+	Codec string
+}
+
+// MemoryEstimate itemizes the estimated allocation of one proof.
+type MemoryEstimate struct {
+	State     int64 `json:"state"`     // the normalized state and its commitment encoding
+	Transform int64 `json:"transform"` // the X-basis transform; 0 when streaming
+	Responses int64 `json:"responses"` // challenges, responses and Merkle leaves
+	Encoding  int64 `json:"encoding"`  // signing and encoding the proof
+	Streaming bool  `json:"streaming"`
+}
+
+// Total returns the estimated allocation in bytes.
+func (e MemoryEstimate) Total() int64 {
+	return e.State + e.Transform + e.Responses + e.Encoding
+}
+
+// EstimateProofMemory estimates, before anything is allocated, how much
+// memory a proof of a dimension-d state with the given number of
+// challenges allocates, up to and including encoding it with codec. The
+// estimate assumes the Hadamard transform is materialized; see
+// MemoryBudget.
+func EstimateProofMemory(dimension, challenges int, lengths DigestLengths, codec string) (MemoryEstimate, error) {
+	if dimension <= 0 || challenges <= 0 {
+		return MemoryEstimate{}, fmt.Errorf("invalid proof shape: dimension %d, %d challenges", dimension, challenges)
+	}
+	if err := lengths.Validate(); err != nil {
+		return MemoryEstimate{}, err
+	}
+	copies, work, err := proofCodecCost(codec)
+	if err != nil {
+		return MemoryEstimate{}, err
+	}
+
+	// Each response carries three hex digests. The proof JSON is built
+	// once to sign and once to encode, then copied by the codec.
+	responseJSON := int64(responseJSONBytes + 3*2*lengths.Response)
+	// Every step is checked: a wrapped estimate would pass any budget
+	mul := func(a, b int64) int64 {
+		product, mulErr := checkedMul(a, b)
+		err = cmp.Or(err, mulErr)
+		return product
+	}
+	add := func(a, b int64) int64 {
+		sum, addErr := checkedAdd(a, b)
+		err = cmp.Or(err, addErr)
+		return sum
+	}
+	headroom := func(n int64) int64 {
+		return add(n, mul(n/100, estimateHeadroom))
+	}
+	proofJSON := add(proofJSONBytes, mul(int64(challenges), responseJSON))
+	estimate := MemoryEstimate{
+		State:     headroom(mul(int64(dimension), amplitudeBytes+2*fixedPointBytes)),
+		Transform: headroom(mul(int64(dimension), amplitudeBytes)),
+		Responses: headroom(mul(int64(challenges), responseWorkBytes)),
+		Encoding:  headroom(add(proofFixedBytes+work, mul(proofJSON, 2+copies))),
+	}
+	add(add(estimate.State, estimate.Transform), add(estimate.Responses, estimate.Encoding)) // Total
+	if err != nil {
+		return MemoryEstimate{}, fmt.Errorf("cannot estimate a proof of dimension %d with %d challenges: %w", dimension, challenges, err)
+	}
+	return estimate, nil
+}
+
+// proofCodecCost returns how many proof-sized copies codec allocates and
+// its fixed working memory.
+func proofCodecCost(codec string) (copies, work int64, err error) {
+	switch codec {
+	case "", "json":
+		return 0, 0, nil
+	case "base64+json", "base64url+json":
+		return 4, 0, nil // 4/3 of the proof, three times
+	case "hex+json":
+		return 6, 0, nil // twice the proof, three times
+	case "gzip+json":
+		return 1, gzipWorkBytes, nil
+	case "base64+gzip+json":
+		return 5, gzipWorkBytes, nil
+	default:
+		return 0, 0, fmt.Errorf("unknown proof codec %q", codec)
+	}
+}
+
+// planProofMemory estimates a proof of the given shape against
+// sq.MemoryBudget and decides whether to stream X-basis amplitudes. It
+// refuses proofs that exceed the hard limit even when streamed.
+func (sq *SecureQuantumZKP) planProofMemory(dimension, challenges int) (MemoryEstimate, error) {
+	budget := sq.MemoryBudget
+	estimate, err := EstimateProofMemory(dimension, challenges, sq.DigestLengths, budget.Codec)
+	if err != nil {
+		return MemoryEstimate{}, err
+	}
+	if (budget.Soft > 0 && estimate.Total() > budget.Soft) || (budget.Hard > 0 && estimate.Total() > budget.Hard) {
+		estimate.Transform = 0
+		estimate.Streaming = true
+	}
+	if budget.Hard > 0 && estimate.Total() > budget.Hard {
+		return estimate, fmt.Errorf("%w: estimated %d bytes, hard limit %d", ErrMemoryBudgetExceeded, estimate.Total(), budget.Hard)
+	}
+	return estimate, nil
+}
+
+// EstimateProofMemory returns the estimate and streaming decision for a
+// proof of a dimension-d state under sq's configuration and memory budget.
+func (sq *SecureQuantumZKP) EstimateProofMemory(dimension int) (MemoryEstimate, error) {
+	return sq.planProofMemory(dimension, sq.ChallengeCount())
+}
+
+// xBasisAmplitudes supplies the X-basis amplitudes of a state. Unless
+// streaming, the Hadamard transform is computed on the first X-basis
+// challenge and shared by the rest.
+type xBasisAmplitudes struct {
+	state     []complex128
+	streaming bool
+	transform []complex128
+}
+
+// at returns X-basis amplitude index.
+func (x *xBasisAmplitudes) at(index int) (complex128, error) {
+	if x.streaming {
+		return HadamardAmplitude(x.state, index)
+	}
+	if x.transform == nil {
+		transform, err := ApplyHadamard(x.state)
+		if err != nil {
+			return 0, err
+		}
+		x.transform = transform
+	}
+	return x.transform[index], nil
+}
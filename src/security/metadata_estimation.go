@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// TightenedMetadata builds proof metadata whose bounds are the upper ends
+// of the given estimates instead of the theoretical maxima for the
+// dimension. Tighter bounds disclose coarse information about the state, so
+// callers should only publish them when that is acceptable.
+func TightenedMetadata(dimension, securityLevel int, entropy, coherence *Estimate) (SecureStateMetadata, error) {
+	if dimension <= 0 {
+		return SecureStateMetadata{}, errors.New("dimension must be positive")
+	}
+	if entropy == nil || coherence == nil {
+		return SecureStateMetadata{}, errors.New("entropy and coherence estimates are required")
+	}
+
+	maxEntropy := math.Log2(float64(dimension))
+	maxCoherence := float64(dimension)
+	if entropy.Lower > maxEntropy || coherence.Lower > maxCoherence {
+		return SecureStateMetadata{}, fmt.Errorf("estimates exceed the theoretical maxima for dimension %d", dimension)
+	}
+
+	return SecureStateMetadata{
+		Dimension:      dimension,
+		EntropyBound:   math.Min(math.Max(0, entropy.Upper), maxEntropy),
+		CoherenceBound: math.Min(math.Max(0, coherence.Upper), maxCoherence),
+		Timestamp:      time.Now(),
+		SecurityLevel:  securityLevel,
+	}, nil
+}
+
+// MetadataClaimCheck reports whether independent estimates are consistent
+// with the bounds a proof claims.
+type MetadataClaimCheck struct {
+	EntropyConsistent   bool `json:"entropy_consistent"`
+	CoherenceConsistent bool `json:"coherence_consistent"`
+}
+
+// Consistent reports whether both claims are consistent.
+func (c MetadataClaimCheck) Consistent() bool {
+	return c.EntropyConsistent && c.CoherenceConsistent
+}
+
+// CheckMetadataClaims lets an auditor with measurement data for the state
+// test a proof's metadata. A bound is refuted when the estimate's whole
+// interval lies above it.
+func CheckMetadataClaims(metadata SecureStateMetadata, entropy, coherence *Estimate) MetadataClaimCheck {
+	check := MetadataClaimCheck{EntropyConsistent: true, CoherenceConsistent: true}
+	if entropy != nil && entropy.Lower > metadata.EntropyBound {
+		check.EntropyConsistent = false
+	}
+	if coherence != nil && coherence.Lower > metadata.CoherenceBound {
+		check.CoherenceConsistent = false
+	}
+	return check
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrNonceReplayed is returned for a nonce that was already accepted and
+// has not expired.
+var ErrNonceReplayed = errors.New("nonce was already accepted")
+
+// DefaultReplayWindow is how long after its creation a proof is accepted
+// when replay protection is enabled, and so how long its nonce is kept.
+const DefaultReplayWindow = 24 * time.Hour
+
+// replayClockSkew is how far in the future a proof's timestamp may lie.
+const replayClockSkew = 5 * time.Minute
+
+// nonceCompactionMinimum is the log size below which FileNonceStore never
+// compacts on its own.
+const nonceCompactionMinimum = 1024
+
+// NonceStore records the nonces a verifier has accepted, each until it
+// expires. Once a nonce expires, the proof it belongs to is refused as
+// stale anyway, so the store only has to remember nonces for the replay
+// window. Implementations must be safe for concurrent use.
+type NonceStore interface {
+	// Consume records nonce until expires, or returns ErrNonceReplayed if
+	// it is already recorded and unexpired. A nil error means the nonce
+	// is recorded durably for the store's kind.
+	Consume(nonce string, expires time.Time) error
+	// Sweep forgets nonces that expired by now and returns how many.
+	Sweep(now time.Time) (int, error)
+	Close() error
+}
+
+// nonceTable is the in-memory state shared by the nonce stores.
+type nonceTable struct {
+	entries map[string]time.Time
+	clock   func() time.Time
+}
+
+func newNonceTable() nonceTable {
+	return nonceTable{entries: make(map[string]time.Time), clock: time.Now}
+}
+
+// replayed reports whether nonce is recorded and unexpired.
+func (t *nonceTable) replayed(nonce string) bool {
+	expires, ok := t.entries[nonce]
+	return ok && expires.After(t.clock())
+}
+
+// sweep removes the entries that expired by now.
+func (t *nonceTable) sweep(now time.Time) int {
+	removed := 0
+	for nonce, expires := range t.entries {
+		if !expires.After(now) {
+			delete(t.entries, nonce)
+			removed++
+		}
+	}
+	return removed
+}
+
+// MemoryNonceStore keeps nonces in memory. It protects a single verifier
+// process; a restart forgets every nonce. Use FileNonceStore to survive
+// restarts.
+type MemoryNonceStore struct {
+	mu    sync.Mutex
+	table nonceTable
+}
+
+// NewMemoryNonceStore creates an empty in-memory store.
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{table: newNonceTable()}
+}
+
+// SetClock replaces the clock expiry is judged by; nil restores time.Now.
+func (s *MemoryNonceStore) SetClock(clock func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.table.clock = clockOrNow(clock)
+}
+
+// Consume implements NonceStore.
+func (s *MemoryNonceStore) Consume(nonce string, expires time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.table.replayed(nonce) {
+		return ErrNonceReplayed
+	}
+	s.table.entries[nonce] = expires
+	return nil
+}
+
+// Sweep implements NonceStore.
+func (s *MemoryNonceStore) Sweep(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.table.sweep(now), nil
+}
+
+// Close implements NonceStore.
+func (s *MemoryNonceStore) Close() error { return nil }
+
+// FileNonceStore keeps nonces in an append-only log that is synced to disk
+// before Consume returns, so an accepted nonce survives a crash or restart.
+// A record torn by a crash was never acknowledged and is discarded on
+// open, and one left by a failed write is cut off before Consume returns.
+// Expired records are dropped by Sweep, and automatically once they
+// outnumber the live ones, by atomically replacing the log. A log must be
+// opened by one process at a time.
+type FileNonceStore struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	table   nonceTable
+	records int   // records in the log, live or expired
+	size    int64 // length of the log's complete records
+	failed  error // set once the log may hold a partial record
+}
+
+// OpenFileNonceStore opens or creates the nonce log at path.
+func OpenFileNonceStore(path string) (*FileNonceStore, error) {
+	if err := hostFS.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	s := &FileNonceStore{path: path, table: newNonceTable()}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	file, err := hostFS.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	s.file = file
+	return s, nil
+}
+
+// load replays the log, truncating a torn final record.
+func (s *FileNonceStore) load() error {
+	data, err := hostFS.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read nonce log: %w", err)
+	}
+
+	complete := bytes.LastIndexByte(data, '\n') + 1
+	scanner := bufio.NewScanner(bytes.NewReader(data[:complete]))
+	for line := 1; scanner.Scan(); line++ {
+		nonce, expires, err := parseNonceRecord(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("nonce log %s line %d: %w", s.path, line, err)
+		}
+		s.table.entries[nonce] = expires
+		s.records++
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read nonce log: %w", err)
+	}
+	if complete < len(data) {
+		if err := hostFS.Truncate(s.path, int64(complete)); err != nil {
+			return fmt.Errorf("failed to discard torn nonce record: %w", err)
+		}
+	}
+	s.size = int64(complete)
+	return nil
+}
+
+// formatNonceRecord encodes one log record: the hex nonce and its expiry
+// in Unix nanoseconds.
+func formatNonceRecord(nonce string, expires time.Time) string {
+	return hex.EncodeToString([]byte(nonce)) + " " + strconv.FormatInt(expires.UnixNano(), 10) + "\n"
+}
+
+// parseNonceRecord decodes a record without its newline.
+func parseNonceRecord(record string) (string, time.Time, error) {
+	var encoded string
+	var expires int64
+	if _, err := fmt.Sscanf(record, "%s %d", &encoded, &expires); err != nil {
+		return "", time.Time{}, fmt.Errorf("malformed record %q", record)
+	}
+	nonce, err := hex.DecodeString(encoded)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("malformed nonce %q", encoded)
+	}
+	return string(nonce), time.Unix(0, expires), nil
+}
+
+// SetClock replaces the clock expiry is judged by; nil restores time.Now.
+func (s *FileNonceStore) SetClock(clock func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.table.clock = clockOrNow(clock)
+}
+
+// Consume implements NonceStore. The record is on disk before it returns.
+func (s *FileNonceStore) Consume(nonce string, expires time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return errors.New("nonce store is closed")
+	}
+	if s.failed != nil {
+		return s.failed
+	}
+	if s.table.replayed(nonce) {
+		return ErrNonceReplayed
+	}
+	record := formatNonceRecord(nonce, expires)
+	if _, err := io.WriteString(s.file, record); err != nil {
+		return s.rollback(fmt.Errorf("failed to record nonce: %w", err))
+	}
+	if err := s.file.Sync(); err != nil {
+		return s.rollback(fmt.Errorf("failed to sync nonce log: %w", err))
+	}
+	s.table.entries[nonce] = expires
+	s.records++
+	s.size += int64(len(record))
+
+	if s.records >= nonceCompactionMinimum && s.records > 2*len(s.table.entries) {
+		s.table.sweep(s.table.clock())
+		if err := s.compact(); err != nil {
+			return fmt.Errorf("nonce recorded, but compaction failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// rollback cuts the log back to its last complete record after a failed
+// append, so that a partially written record cannot merge with the next
+// one. If the log cannot be cut back, the store refuses further use.
+func (s *FileNonceStore) rollback(cause error) error {
+	err := hostFS.Truncate(s.path, s.size)
+	if err == nil {
+		err = s.file.Sync()
+	}
+	if err != nil {
+		s.failed = fmt.Errorf("nonce log %s may end in a partial record and must be reopened: %w", s.path, err)
+		return errors.Join(cause, s.failed)
+	}
+	return cause
+}
+
+// Sweep implements NonceStore, rewriting the log without expired records.
+func (s *FileNonceStore) Sweep(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return 0, errors.New("nonce store is closed")
+	}
+	if s.failed != nil {
+		return 0, s.failed
+	}
+	removed := s.table.sweep(now)
+	if removed == 0 && s.records == len(s.table.entries) {
+		return 0, nil
+	}
+	return removed, s.compact()
+}
+
+// compact replaces the log with one record per live entry. The new log is
+// synced before it is renamed into place, so a crash leaves either the old
+// or the new log, and both hold every live nonce.
+func (s *FileNonceStore) compact() error {
+	dir := filepath.Dir(s.path)
+	tmp, err := hostFS.CreateTemp(dir, "."+filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer hostFS.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	var size int64
+	for nonce, expires := range s.table.entries {
+		n, _ := w.WriteString(formatNonceRecord(nonce, expires))
+		size += int64(n)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := hostFS.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	syncDir(dir)
+
+	file, err := hostFS.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	s.file.Close()
+	s.file = file
+	s.records = len(s.table.entries)
+	s.size = size
+	return nil
+}
+
+// Close implements NonceStore.
+func (s *FileNonceStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// HostAccess implements HostBound.
+func (s *FileNonceStore) HostAccess() string { return s.path }
+
+// syncDir makes a rename in dir durable where the platform supports it.
+func syncDir(dir string) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if d, err := hostFS.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// clockOrNow returns clock, or time.Now if it is nil.
+func clockOrNow(clock func() time.Time) func() time.Time {
+	if clock == nil {
+		return time.Now
+	}
+	return clock
+}
+
+// consumeProofNonce refuses proof if it falls outside the replay window or
+// its commitment was already accepted, and otherwise records the
+// commitment until the window closes. The commitment hash commits to a
+// fresh random nonce, so it identifies the proof.
+func (sq *SecureQuantumZKP) consumeProofNonce(proof *SecureProof, store NonceStore, window time.Duration) error {
+	if err := sq.checkHostAccess(store); err != nil {
+		return err
+	}
+	if window <= 0 {
+		window = DefaultReplayWindow
+	}
+	now := sq.now()
+	expires := proof.Timestamp.Add(window)
+	if !expires.After(now) {
+		return fmt.Errorf("proof is older than the %v replay window", window)
+	}
+	if proof.Timestamp.After(now.Add(replayClockSkew)) {
+		return errors.New("proof timestamp is in the future")
+	}
+	if err := store.Consume(proof.CommitmentHash, expires); err != nil {
+		if errors.Is(err, ErrNonceReplayed) {
+			return errors.New("proof was already accepted (replay)")
+		}
+		return fmt.Errorf("replay check failed: %w", err)
+	}
+	return nil
+}
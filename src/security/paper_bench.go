@@ -0,0 +1,435 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PaperLevelClaim is the published bound on proving at one soundness
+// level (paper Appendix C.1).
+type PaperLevelClaim struct {
+	SoundnessBits     int
+	MaxGenerationTime time.Duration
+	MaxVerifyTime     time.Duration
+	MaxProofSize      int // bytes of JSON
+}
+
+// PaperClaims are the published results the reference benchmark checks
+// measurements against.
+type PaperClaims struct {
+	// MinInsecureLeakage and MaxSecureLeakage bound the share of test
+	// vectors whose amplitudes appear in the proof, in percent (Section 3.2)
+	MinInsecureLeakage float64
+	MaxSecureLeakage   float64
+	Levels             []PaperLevelClaim
+	// MaxScalingGenerationTime and MaxScalingProofSize bound a proof at
+	// every benchmarked dimension (Appendix C.2)
+	MaxScalingGenerationTime time.Duration
+	MaxScalingProofSize      int
+}
+
+// DefaultPaperClaims returns the claims as published and as asserted by
+// the scientific tests.
+func DefaultPaperClaims() PaperClaims {
+	return PaperClaims{
+		MinInsecureLeakage: 80,
+		MaxSecureLeakage:   5,
+		Levels: []PaperLevelClaim{
+			{32, 2 * time.Millisecond, 500 * time.Microsecond, 15000},
+			{64, 2 * time.Millisecond, 500 * time.Microsecond, 20000},
+			{80, 2 * time.Millisecond, 500 * time.Microsecond, 22000},
+			{96, 2 * time.Millisecond, 500 * time.Microsecond, 24000},
+			{128, 2 * time.Millisecond, 500 * time.Microsecond, 28000},
+			{256, 3 * time.Millisecond, time.Millisecond, 45000},
+		},
+		MaxScalingGenerationTime: 10 * time.Millisecond,
+		MaxScalingProofSize:      50000,
+	}
+}
+
+// PaperBenchOptions configures RunPaperBench.
+type PaperBenchOptions struct {
+	LeakageVectors int   // test vectors in the leakage experiment
+	Runs           int   // proofs averaged per soundness level
+	Dimensions     []int // vector dimensions of the scalability experiment
+	Claims         PaperClaims
+}
+
+// DefaultPaperBenchOptions returns the experiment sizes used in the paper.
+func DefaultPaperBenchOptions() PaperBenchOptions {
+	return PaperBenchOptions{
+		LeakageVectors: 1000,
+		Runs:           10,
+		Dimensions:     []int{4, 8, 16},
+		Claims:         DefaultPaperClaims(),
+	}
+}
+
+// MachineSpec describes the machine a benchmark ran on.
+type MachineSpec struct {
+	GoVersion  string
+	OS         string
+	Arch       string
+	CPUs       int
+	GOMAXPROCS int
+}
+
+// CurrentMachine describes the running machine.
+func CurrentMachine() MachineSpec {
+	return MachineSpec{
+		GoVersion:  runtime.Version(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		CPUs:       runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+	}
+}
+
+// PaperTable is one table of the paper's results as reproduced.
+type PaperTable struct {
+	Name    string
+	Columns []string
+	Rows    [][]string
+}
+
+// PaperDeviation is a measurement outside its published claim.
+type PaperDeviation struct {
+	Experiment string
+	Metric     string
+	Measured   string
+	Claimed    string
+}
+
+// PaperBenchReport is the outcome of RunPaperBench.
+type PaperBenchReport struct {
+	Machine    MachineSpec
+	Started    time.Time
+	Tables     []PaperTable
+	Deviations []PaperDeviation
+}
+
+// paperBenchKey is the proof key of every experiment, as in the
+// scientific tests.
+var paperBenchKey = []byte("paper-benchmark-key-32-bytes!!!!")
+
+// RunPaperBench runs the experiments behind the paper's tables, the same
+// ones the scientific tests assert: information leakage, generation and
+// verification latency and proof size per soundness level, and scaling
+// across dimensions. Every measurement outside opts.Claims is listed as a
+// deviation.
+func RunPaperBench(opts PaperBenchOptions) (*PaperBenchReport, error) {
+	if opts.LeakageVectors <= 0 || opts.Runs <= 0 {
+		return nil, fmt.Errorf("leakage vectors and runs must be positive")
+	}
+	report := &PaperBenchReport{Machine: CurrentMachine(), Started: time.Now().UTC()}
+	for _, run := range []func(*PaperBenchOptions, *PaperBenchReport) error{
+		runLeakageExperiment, runLatencyExperiment, runScalabilityExperiment,
+	} {
+		if err := run(&opts, report); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// runLeakageExperiment reproduces the quantitative leakage analysis
+// (Section 3.2).
+func runLeakageExperiment(opts *PaperBenchOptions, report *PaperBenchReport) error {
+	ctx := []byte("scientific-test-context")
+	q, err := NewQuantumZKP(3, 128, ctx)
+	if err != nil {
+		return err
+	}
+	sq, err := NewSecureQuantumZKP(3, 128, ctx)
+	if err != nil {
+		return err
+	}
+
+	vectors := generateRandomTestVectors(opts.LeakageVectors)
+	var insecure, secure int
+	for i, vector := range vectors {
+		identifier := fmt.Sprintf("scientific-test-%d", i)
+		insecureProof, err := q.Prove(vector, identifier, paperBenchKey)
+		if err != nil {
+			return fmt.Errorf("insecure proof %d: %w", i, err)
+		}
+		if leaks, err := proofLeaksVector(vector, insecureProof); err != nil {
+			return err
+		} else if leaks {
+			insecure++
+		}
+		secureProof, err := sq.SecureProveVectorKnowledge(vector, identifier, paperBenchKey)
+		if err != nil {
+			return fmt.Errorf("secure proof %d: %w", i, err)
+		}
+		if leaks, err := proofLeaksVector(vector, secureProof); err != nil {
+			return err
+		} else if leaks {
+			secure++
+		}
+	}
+
+	percent := func(n int) float64 { return float64(n) / float64(len(vectors)) * 100 }
+	table := PaperTable{
+		Name:    "leakage",
+		Columns: []string{"implementation", "vectors", "leaking", "leakage_percent", "claim"},
+		Rows: [][]string{
+			{"insecure", strconv.Itoa(len(vectors)), strconv.Itoa(insecure), formatPercent(percent(insecure)),
+				"≥ " + formatPercent(opts.Claims.MinInsecureLeakage)},
+			{"secure", strconv.Itoa(len(vectors)), strconv.Itoa(secure), formatPercent(percent(secure)),
+				"≤ " + formatPercent(opts.Claims.MaxSecureLeakage)},
+		},
+	}
+	report.Tables = append(report.Tables, table)
+	if percent(insecure) < opts.Claims.MinInsecureLeakage {
+		report.deviate("leakage", "insecure leakage", formatPercent(percent(insecure)), table.Rows[0][4])
+	}
+	if percent(secure) > opts.Claims.MaxSecureLeakage {
+		report.deviate("leakage", "secure leakage", formatPercent(percent(secure)), table.Rows[1][4])
+	}
+	return nil
+}
+
+// runLatencyExperiment reproduces the per-level performance table
+// (Appendix C.1).
+func runLatencyExperiment(opts *PaperBenchOptions, report *PaperBenchReport) error {
+	ctx := []byte("performance-test-context")
+	vector := []complex128{complex(0.7071, 0), complex(0.7071, 0), 0, 0}
+	table := PaperTable{
+		Name: "latency",
+		Columns: []string{"soundness_bits", "challenges", "generation_us", "verification_us", "proof_bytes",
+			"claim_generation_us", "claim_verification_us", "claim_proof_bytes"},
+	}
+
+	for _, claim := range opts.Claims.Levels {
+		sq, err := NewSecureQuantumZKPWithSoundness(3, 128, claim.SoundnessBits, ctx)
+		if err != nil {
+			return err
+		}
+		var generation, verification time.Duration
+		var size int
+		for run := 0; run < opts.Runs; run++ {
+			start := time.Now()
+			proof, err := sq.SecureProveVectorKnowledge(vector, fmt.Sprintf("perf-test-%d-%d", claim.SoundnessBits, run), paperBenchKey)
+			if err != nil {
+				return fmt.Errorf("%d-bit proof: %w", claim.SoundnessBits, err)
+			}
+			generation += time.Since(start)
+			start = time.Now()
+			if !sq.VerifySecureProof(proof, paperBenchKey) {
+				return fmt.Errorf("%d-bit proof did not verify", claim.SoundnessBits)
+			}
+			verification += time.Since(start)
+			encoded, err := json.Marshal(proof)
+			if err != nil {
+				return err
+			}
+			size += len(encoded)
+		}
+		generation /= time.Duration(opts.Runs)
+		verification /= time.Duration(opts.Runs)
+		size /= opts.Runs
+
+		table.Rows = append(table.Rows, []string{
+			strconv.Itoa(claim.SoundnessBits), strconv.Itoa(sq.ChallengeCount()),
+			formatMicros(generation), formatMicros(verification), strconv.Itoa(size),
+			formatMicros(claim.MaxGenerationTime), formatMicros(claim.MaxVerifyTime), strconv.Itoa(claim.MaxProofSize),
+		})
+		experiment := fmt.Sprintf("latency %d-bit", claim.SoundnessBits)
+		if generation > claim.MaxGenerationTime {
+			report.deviate(experiment, "generation time", generation.String(), "≤ "+claim.MaxGenerationTime.String())
+		}
+		if verification > claim.MaxVerifyTime {
+			report.deviate(experiment, "verification time", verification.String(), "≤ "+claim.MaxVerifyTime.String())
+		}
+		if size > claim.MaxProofSize {
+			report.deviate(experiment, "proof size", fmt.Sprintf("%d bytes", size), fmt.Sprintf("≤ %d bytes", claim.MaxProofSize))
+		}
+	}
+	report.Tables = append(report.Tables, table)
+	return nil
+}
+
+// runScalabilityExperiment reproduces the scaling table (Appendix C.2).
+func runScalabilityExperiment(opts *PaperBenchOptions, report *PaperBenchReport) error {
+	ctx := []byte("scalability-test-context")
+	sq, err := NewSecureQuantumZKP(3, 128, ctx)
+	if err != nil {
+		return err
+	}
+	table := PaperTable{
+		Name:    "scalability",
+		Columns: []string{"dimension", "generation_us", "proof_bytes", "bytes_per_dimension"},
+	}
+
+	for _, dim := range opts.Dimensions {
+		vector := make([]complex128, dim)
+		for i := range vector {
+			vector[i] = complex(1/math.Sqrt(float64(dim)), 0)
+		}
+		start := time.Now()
+		proof, err := sq.SecureProveVectorKnowledge(vector, fmt.Sprintf("scale-test-%d", dim), paperBenchKey)
+		if err != nil {
+			return fmt.Errorf("dimension %d: %w", dim, err)
+		}
+		generation := time.Since(start)
+		encoded, err := json.Marshal(proof)
+		if err != nil {
+			return err
+		}
+
+		table.Rows = append(table.Rows, []string{
+			strconv.Itoa(dim), formatMicros(generation), strconv.Itoa(len(encoded)),
+			strconv.FormatFloat(float64(len(encoded))/float64(dim), 'f', 2, 64),
+		})
+		experiment := fmt.Sprintf("scalability dimension %d", dim)
+		if generation > opts.Claims.MaxScalingGenerationTime {
+			report.deviate(experiment, "generation time", generation.String(), "≤ "+opts.Claims.MaxScalingGenerationTime.String())
+		}
+		if len(encoded) > opts.Claims.MaxScalingProofSize {
+			report.deviate(experiment, "proof size", fmt.Sprintf("%d bytes", len(encoded)), fmt.Sprintf("≤ %d bytes", opts.Claims.MaxScalingProofSize))
+		}
+	}
+	report.Tables = append(report.Tables, table)
+	return nil
+}
+
+func (r *PaperBenchReport) deviate(experiment, metric, measured, claimed string) {
+	r.Deviations = append(r.Deviations, PaperDeviation{experiment, metric, measured, claimed})
+}
+
+// Table returns the machine description as a two-column table.
+func (m MachineSpec) Table() PaperTable {
+	return PaperTable{
+		Name:    "machine",
+		Columns: []string{"property", "value"},
+		Rows: [][]string{
+			{"go", m.GoVersion},
+			{"os", m.OS},
+			{"arch", m.Arch},
+			{"cpus", strconv.Itoa(m.CPUs)},
+			{"gomaxprocs", strconv.Itoa(m.GOMAXPROCS)},
+		},
+	}
+}
+
+// WriteCSV writes the table with a header row.
+func (t *PaperTable) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.Columns); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(t.Rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// WriteMarkdown writes the table as a GitHub-flavored Markdown table.
+func (t *PaperTable) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("| " + strings.Join(t.Columns, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(t.Columns)) + "\n")
+	for _, row := range t.Rows {
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMarkdown writes the machine specs, every table and the deviations
+// from the published claims.
+func (r *PaperBenchReport) WriteMarkdown(w io.Writer) error {
+	machine := r.Machine.Table()
+	if _, err := fmt.Fprintf(w, "# Paper benchmark (%s)\n\n## machine\n\n", r.Started.Format(time.RFC3339)); err != nil {
+		return err
+	}
+	if err := machine.WriteMarkdown(w); err != nil {
+		return err
+	}
+	for i := range r.Tables {
+		if _, err := fmt.Fprintf(w, "\n## %s\n\n", r.Tables[i].Name); err != nil {
+			return err
+		}
+		if err := r.Tables[i].WriteMarkdown(w); err != nil {
+			return err
+		}
+	}
+
+	deviations := PaperTable{Columns: []string{"experiment", "metric", "measured", "claimed"}}
+	for _, d := range r.Deviations {
+		deviations.Rows = append(deviations.Rows, []string{d.Experiment, d.Metric, d.Measured, d.Claimed})
+	}
+	if _, err := fmt.Fprintf(w, "\n## deviations from published claims\n\n"); err != nil {
+		return err
+	}
+	if len(deviations.Rows) == 0 {
+		_, err := io.WriteString(w, "None.\n")
+		return err
+	}
+	return deviations.WriteMarkdown(w)
+}
+
+func formatPercent(p float64) string { return strconv.FormatFloat(p, 'f', 1, 64) + "%" }
+
+func formatMicros(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Microsecond), 'f', 1, 64)
+}
+
+// proofLeaksVector reports whether any amplitude of vector appears, to
+// three decimals, in the JSON encoding of proof.
+func proofLeaksVector(vector []complex128, proof interface{}) (bool, error) {
+	encoded, err := json.Marshal(proof)
+	if err != nil {
+		return false, err
+	}
+	return detectVectorLeakage(vector, string(encoded)), nil
+}
+
+// generateRandomTestVectors returns count deterministic, varied, normalized
+// 4-dimensional states.
+func generateRandomTestVectors(count int) [][]complex128 {
+	vectors := make([][]complex128, count)
+	for i := 0; i < count; i++ {
+		// Generate random 4-dimensional quantum state
+		vector := make([]complex128, 4)
+		var norm float64
+
+		for j := 0; j < 4; j++ {
+			real := (float64(i*4+j) + 1.0) / float64(count*4) // Deterministic but varied
+			imag := (float64(i*4+j) + 0.5) / float64(count*4)
+			vector[j] = complex(real, imag)
+			norm += real*real + imag*imag
+		}
+
+		// Normalize
+		norm = math.Sqrt(norm)
+		for j := 0; j < 4; j++ {
+			vector[j] = complex(real(vector[j])/norm, imag(vector[j])/norm)
+		}
+
+		vectors[i] = vector
+	}
+	return vectors
+}
+
+// detectVectorLeakage reports whether any amplitude of vector appears, to
+// three decimals, in proofJSON.
+func detectVectorLeakage(vector []complex128, proofJSON string) bool {
+	for _, c := range vector {
+		realStr := fmt.Sprintf("%.3f", real(c))
+		imagStr := fmt.Sprintf("%.3f", imag(c))
+
+		if strings.Contains(proofJSON, realStr) || strings.Contains(proofJSON, imagStr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+)
+
+// DefaultChallengeSpace is the value SecureQuantumZKP.ChallengeSpace is
+// set to.
+//
+// Deprecated: ChallengeSpace has no effect and is no longer reported by
+// Parameters. The parameters hash still includes this value, so proofs
+// made while it was published keep verifying.
+const DefaultChallengeSpace = 1024
+
+// Protocol constants. Every value here is reported by Parameters and bound
+// into proofs through the parameters hash.
+const (
+	// commitmentNonceLength is the random nonce mixed into the commitment
+	commitmentNonceLength = 32
+	// maxProofDimension bounds the state dimension a proof may claim
+	maxProofDimension = 1024
+	// Accepted range of the metadata security level
+	minMetadataSecurityLevel = 64
+	maxMetadataSecurityLevel = 512
+)
+
+// parametersDomain separates the parameters hash from other digests.
+const parametersDomain = "qzkp/parameters/v1"
+
+// Parameters gathers every public constant a secure proof depends on.
+// Nothing is generated in a setup ceremony: each value is either fixed by
+// the protocol or chosen by the prover's configuration and visible here.
+type Parameters struct {
+	ProofVersion           int             `json:"proof_version"`
+	SoundnessBits          int             `json:"soundness_bits"`
+	EffectiveSoundnessBits int             `json:"effective_soundness_bits"`
+	ChallengeCount         int             `json:"challenge_count"`
+	ChallengeBases         []string        `json:"challenge_bases"`
+	ChallengeDerivation    string          `json:"challenge_derivation"`
+	ChallengeDomain        string          `json:"challenge_domain"`
+	ChallengeEpochSeconds  int64           `json:"challenge_epoch_seconds"`
+	ChallengeNonceLength   int             `json:"challenge_nonce_length"`
+	CommitmentHash         string          `json:"commitment_hash"`
+	CommitmentHashLength   int             `json:"commitment_hash_length"`
+	CommitmentNonceLength  int             `json:"commitment_nonce_length"`
+	ResponseDigestLength   int             `json:"response_digest_length"`
+	MerkleHash             string          `json:"merkle_hash"`
+	HashSuite              string          `json:"hash_suite"`
+	SignatureAlgorithm     string          `json:"signature_algorithm"`
+	NumericEncoding        NumericEncoding `json:"numeric_encoding"`
+	MaxDimension           int             `json:"max_dimension"`
+	MinSecurityLevel       int             `json:"min_security_level"`
+	MaxSecurityLevel       int             `json:"max_security_level"`
+}
+
+// Parameters returns the public parameters of proofs made by sq.
+func (sq *SecureQuantumZKP) Parameters() Parameters {
+	return Parameters{
+		ProofVersion:           CurrentProofVersion,
+		SoundnessBits:          sq.SecurityParameter,
+		EffectiveSoundnessBits: sq.EffectiveSoundness(),
+		ChallengeCount:         sq.ChallengeCount(),
+		ChallengeBases:         challengeBases,
+		ChallengeDerivation:    "blake3-xof",
+		ChallengeDomain:        challengeDomain,
+		ChallengeEpochSeconds:  int64(ChallengeEpochDuration.Seconds()),
+		ChallengeNonceLength:   sq.challengeNonceLength(),
+		CommitmentHash:         HashSuiteSHA256,
+		CommitmentHashLength:   sq.DigestLengths.Commitment,
+		CommitmentNonceLength:  commitmentNonceLength,
+		ResponseDigestLength:   sq.DigestLengths.Response,
+		MerkleHash:             HashSuiteSHA256,
+		HashSuite:              HashSuiteSHA256,
+		SignatureAlgorithm:     SignatureAlgorithmMLDSA87,
+		NumericEncoding:        sq.NumericEncoding,
+		MaxDimension:           maxProofDimension,
+		MinSecurityLevel:       minMetadataSecurityLevel,
+		MaxSecurityLevel:       maxMetadataSecurityLevel,
+	}
+}
+
+// Hash returns the hex SHA-256 of the canonical JSON encoding of p. Proofs
+// carry this value, so any change to a parameter changes every proof.
+func (p Parameters) Hash() (string, error) {
+	input, err := p.hashInput()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(input)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// hashInput is the byte string Hash digests: parametersDomain followed by
+// the canonical JSON encoding of p. The withdrawn challenge_space
+// parameter is still encoded, with its default value, so that hashes match
+// those of earlier releases.
+func (p Parameters) hashInput() ([]byte, error) {
+	tree, err := canonicalTree(reflect.ValueOf(p))
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize parameters: %w", err)
+	}
+	fields := tree.(map[string]interface{})
+	fields["challenge_space"] = DefaultChallengeSpace
+	canonical, err := CanonicalizeValue(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize parameters: %w", err)
+	}
+	return append([]byte(parametersDomain), canonical...), nil
+}
+
+// ParameterNote explains one parameter for auditors.
+type ParameterNote struct {
+	Name      string      `json:"name"`
+	Value     interface{} `json:"value"`
+	Rationale string      `json:"rationale"`
+}
+
+// TransparencyReport is the machine-readable output of ExplainParameters.
+type TransparencyReport struct {
+	Parameters     Parameters `json:"parameters"`
+	ParametersHash string     `json:"parameters_hash"`
+	TrustedSetup   bool       `json:"trusted_setup"`
+	// SoundnessError is log2 of the probability that a prover without the
+	// witness answers every derived challenge
+	SoundnessError float64         `json:"soundness_error_log2"`
+	Notes          []ParameterNote `json:"notes"`
+	// Limitations lists properties auditors should weigh against the
+	// nominal soundness
+	Limitations []string `json:"limitations,omitempty"`
+}
+
+// ExplainParameters reports every parameter sq uses, why it has its value
+// and the resulting security claims, so audits can confirm no hidden choice
+// weakens soundness.
+func (sq *SecureQuantumZKP) ExplainParameters() (*TransparencyReport, error) {
+	params := sq.Parameters()
+	hash, err := params.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TransparencyReport{
+		Parameters:     params,
+		ParametersHash: hash,
+		TrustedSetup:   false,
+		SoundnessError: -float64(params.EffectiveSoundnessBits),
+		Notes: []ParameterNote{
+			{"soundness_bits", params.SoundnessBits,
+				"one derived challenge per bit; a cheating prover passes each with probability at most 1/2"},
+			{"challenge_derivation", params.ChallengeDerivation,
+				"challenges are expanded from the published commitment, context and epoch, so the prover cannot choose them"},
+			{"challenge_epoch_seconds", params.ChallengeEpochSeconds,
+				"limits how long one commitment's challenge set stays valid"},
+			{"challenge_nonce_length", params.ChallengeNonceLength,
+				"per-challenge nonce drawn from the same XOF, so it inherits the commitment nonce's entropy; public, not a source of secrecy"},
+			{"commitment_hash_length", params.CommitmentHashLength,
+				"published commitment length; a b-byte digest resists collisions up to 2^(4b) work"},
+			{"commitment_nonce_length", params.CommitmentNonceLength,
+				"fresh randomness hides the committed state even for low-entropy vectors"},
+			{"response_digest_length", params.ResponseDigestLength,
+				"published length of each response digest; full length from 128-bit security up"},
+			{"numeric_encoding", params.NumericEncoding,
+				"platform-independent fixed-point encoding of hashed amplitudes"},
+			{"signature_algorithm", params.SignatureAlgorithm,
+				"post-quantum signature binding every field, including the parameters hash"},
+			{"trusted_setup", false,
+				"all parameters are public constants; no setup ceremony or structured reference string is used"},
+		},
+	}
+
+	if bits := 8 * params.ResponseDigestLength / 2; bits < params.SoundnessBits {
+		report.Limitations = append(report.Limitations, fmt.Sprintf(
+			"response digests are truncated to %d bytes, giving %d-bit collision resistance per response",
+			params.ResponseDigestLength, bits))
+	}
+	if bits := 8 * params.CommitmentHashLength / 2; bits < params.SoundnessBits {
+		report.Limitations = append(report.Limitations, fmt.Sprintf(
+			"the published commitment is %d bytes, giving %d-bit collision resistance, below the %d-bit soundness target",
+			params.CommitmentHashLength, bits, params.SoundnessBits))
+	}
+	report.Limitations = append(report.Limitations, fmt.Sprintf(
+		"challenge indices range over the state dimension, at most %d", params.MaxDimension))
+	report.Limitations = append(report.Limitations,
+		"responses are checked structurally; the verifier cannot recompute them without the witness")
+
+	return report, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ConfigSchema is the format of ServiceConfig and DaemonConfig files.
+// LoadServiceConfig and LoadDaemonConfig read files of any older version
+// without rewriting them; MigrateFile upgrades them on disk.
+var ConfigSchema = &Schema{
+	Name: "configuration",
+	Migrations: []Migration{
+		{Description: "record the schema version"},
+	},
+}
+
+// KeyUsageSchema is the format of the keystore's usage records. Records
+// of older versions are read as they are and upgraded on their next
+// update; MigrateDataDirs upgrades them all at once.
+var KeyUsageSchema = &Schema{
+	Name: "key usage record",
+	Migrations: []Migration{
+		{Description: "record the schema version"},
+	},
+}
+
+// MigrateDataDirs upgrades the versioned files in dirs to the schemas of
+// this release, backing up every file it rewrites, and returns a report
+// per file examined. It stops at the first file it cannot migrate, such
+// as one written by a newer release. It must not run while a Keystore is
+// open on dirs.
+//
+// Stored proofs are not rewritten: they are signed, and ParseSecureProof
+// reads every proof version this release supports. The quantum state
+// cache migrates itself when opened and can be regenerated.
+func MigrateDataDirs(dirs *DataDirs, opts MigrateOptions) ([]*MigrationReport, error) {
+	var reports []*MigrationReport
+	for name, err := range iterStoreEntries(dirs.KeystoreDir(), usageFileExt) {
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return reports, fmt.Errorf("failed to list keystore: %w", err)
+		}
+		report, err := KeyUsageSchema.MigrateFile(filepath.Join(dirs.KeystoreDir(), name+usageFileExt), opts)
+		if err != nil {
+			return reports, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
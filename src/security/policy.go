@@ -0,0 +1,238 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Rule is a single verifier requirement evaluated against a proof header
+// before any cryptographic check.
+type Rule interface {
+	Name() string
+	Check(proof *SecureProof) error
+}
+
+// funcRule adapts a function to the Rule interface. params is the JSON
+// encoding of the parameters of a built-in rule, part of its identity in
+// Policy.Hash.
+type funcRule struct {
+	name   string
+	params string
+	check  func(proof *SecureProof) error
+}
+
+func (r funcRule) Name() string                   { return r.name }
+func (r funcRule) Check(proof *SecureProof) error { return r.check(proof) }
+
+// NewRule creates a named rule from a check function.
+func NewRule(name string, check func(proof *SecureProof) error) Rule {
+	return funcRule{name: name, check: check}
+}
+
+// parameterizedRule creates a named rule whose parameters take part in
+// Policy.Hash, so that tightening them changes the hash.
+func parameterizedRule(name string, params interface{}, check func(proof *SecureProof) error) Rule {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		encoded = []byte(fmt.Sprintf("%#v", params))
+	}
+	return funcRule{name: name, params: string(encoded), check: check}
+}
+
+// MinSoundness requires the proof's challenges to reach at least bits of
+// soundness, i.e. a soundness error of at most 2^-bits.
+func MinSoundness(bits int) Rule {
+	return parameterizedRule("min_soundness", bits, func(proof *SecureProof) error {
+		if got := proofSoundness(proof); got < bits {
+			return fmt.Errorf("soundness %d bits is below the required %d", got, bits)
+		}
+		return nil
+	})
+}
+
+// MinDigestLengths requires the proof to publish digests at least as long
+// as min. Formats that did not record lengths are judged by the legacy
+// truncated lengths.
+func MinDigestLengths(min DigestLengths) Rule {
+	return parameterizedRule("min_digest_lengths", min, func(proof *SecureProof) error {
+		if got := proof.digestLengths(); !got.AtLeast(min) {
+			return fmt.Errorf("digest lengths %d/%d are below the required %d/%d",
+				got.Commitment, got.Response, min.Commitment, min.Response)
+		}
+		return nil
+	})
+}
+
+// AllowedHashSuites restricts the hash suite declared by the proof.
+func AllowedHashSuites(suites ...string) Rule {
+	return parameterizedRule("allowed_hash_suites", suites, func(proof *SecureProof) error {
+		return requireOneOf("hash suite", proof.HashSuite, suites)
+	})
+}
+
+// AllowedSignatureAlgorithms restricts the signature algorithm declared by
+// the proof.
+func AllowedSignatureAlgorithms(algorithms ...string) Rule {
+	return parameterizedRule("allowed_signature_algorithms", algorithms, func(proof *SecureProof) error {
+		return requireOneOf("signature algorithm", proof.SignatureAlgorithm, algorithms)
+	})
+}
+
+// RequireProfile requires the proof to declare the given profile.
+func RequireProfile(profile string) Rule {
+	return parameterizedRule("required_profile", profile, func(proof *SecureProof) error {
+		if proof.Profile != profile {
+			return fmt.Errorf("profile %q does not match required %q", proof.Profile, profile)
+		}
+		return nil
+	})
+}
+
+// AllowedContexts restricts the context the proof was created under.
+func AllowedContexts(contexts ...Context) Rule {
+	return parameterizedRule("allowed_contexts", contexts, func(proof *SecureProof) error {
+		for _, c := range contexts {
+			if proof.Context.Equal(c) {
+				return nil
+			}
+		}
+		return fmt.Errorf("context %s is not allowed", proof.Context)
+	})
+}
+
+// IdentifierInNamespace requires the proof's identifier to belong to ns.
+// Pseudonymized identifiers are only checked for their form; the reason
+// wraps an *IdentifierError.
+func IdentifierInNamespace(ns *IdentifierNamespace) Rule {
+	checker := &SecureQuantumZKP{IdentifierNamespace: ns}
+	return parameterizedRule("identifier_namespace", ns, func(proof *SecureProof) error {
+		return checker.checkPublishedIdentifier(proof.Identifier, proof.IdentifierScheme)
+	})
+}
+
+// MaxAge rejects proofs created more than maxAge before now. The attested
+// creation time is used when present, otherwise the proof timestamp. A nil
+// now uses time.Now.
+func MaxAge(maxAge time.Duration, now func() time.Time) Rule {
+	if now == nil {
+		now = time.Now
+	}
+	return parameterizedRule("max_age", maxAge, func(proof *SecureProof) error {
+		created := proof.Timestamp
+		if proof.TimeAttestation != nil {
+			created = proof.TimeAttestation.Midpoint
+		}
+		if age := now().Sub(created); age > maxAge {
+			return fmt.Errorf("proof age %s exceeds %s", age.Round(time.Second), maxAge)
+		}
+		return nil
+	})
+}
+
+// requireOneOf checks that value is one of allowed.
+func requireOneOf(what, value string, allowed []string) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s %q is not in the allowed set %v", what, value, allowed)
+}
+
+// PolicyViolation records one failed rule.
+type PolicyViolation struct {
+	Rule   string `json:"rule"`
+	Reason string `json:"reason"`
+}
+
+// PolicyReport aggregates the outcome of every rule in a policy.
+type PolicyReport struct {
+	Evaluated  int               `json:"evaluated"`
+	Violations []PolicyViolation `json:"violations,omitempty"`
+}
+
+// Allowed reports whether every rule passed.
+func (r *PolicyReport) Allowed() bool {
+	return len(r.Violations) == 0
+}
+
+// Err returns nil when the policy passed, otherwise an error listing every
+// violation.
+func (r *PolicyReport) Err() error {
+	if r.Allowed() {
+		return nil
+	}
+	parts := make([]string, len(r.Violations))
+	for i, v := range r.Violations {
+		parts[i] = fmt.Sprintf("%s: %s", v.Rule, v.Reason)
+	}
+	return fmt.Errorf("policy violated (%d of %d rules): %s", len(r.Violations), r.Evaluated, strings.Join(parts, "; "))
+}
+
+// Policy is an ordered set of rules. Every rule is evaluated so the report
+// lists all violations, not just the first.
+type Policy struct {
+	Rules []Rule
+}
+
+// policyHashDomain separates policy hashes from other SHA-256 digests.
+const policyHashDomain = "qzkp/policy-hash/v1"
+
+// Hash identifies the policy by the names of its rules, in order, and the
+// parameters of the built-in ones, as a hex SHA-256 digest. Tightening a
+// built-in rule changes the hash; a rule created with NewRule is known by
+// its name only, so give it a new name when its check changes.
+func (p *Policy) Hash() string {
+	hasher := sha256.New()
+	hasher.Write([]byte(policyHashDomain))
+	for _, rule := range p.Rules {
+		identity := rule.Name()
+		if r, ok := rule.(funcRule); ok && r.params != "" {
+			identity += r.params
+		}
+		writeLengthPrefixed(hasher, []byte(identity))
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// NewPolicy creates a policy from rules.
+func NewPolicy(rules ...Rule) *Policy {
+	return &Policy{Rules: rules}
+}
+
+// Evaluate checks proof against every rule.
+func (p *Policy) Evaluate(proof *SecureProof) *PolicyReport {
+	report := &PolicyReport{Evaluated: len(p.Rules)}
+	if proof == nil {
+		report.Violations = append(report.Violations, PolicyViolation{Rule: "proof", Reason: "proof is nil"})
+		return report
+	}
+	for _, rule := range p.Rules {
+		if err := rule.Check(proof); err != nil {
+			report.Violations = append(report.Violations, PolicyViolation{Rule: rule.Name(), Reason: err.Error()})
+		}
+	}
+	return report
+}
+
+// VerifySecureProofWithPolicy evaluates policy against the proof header and
+// runs cryptographic verification only if the policy passes. The returned
+// error is the aggregated policy violation or a verification failure.
+func (sq *SecureQuantumZKP) VerifySecureProofWithPolicy(proof *SecureProof, key []byte, policy *Policy) (*PolicyReport, error) {
+	if policy == nil {
+		return nil, errors.New("policy cannot be nil")
+	}
+	report := policy.Evaluate(proof)
+	if err := report.Err(); err != nil {
+		return report, err
+	}
+	if !sq.VerifySecureProof(proof, key) {
+		return report, errors.New("proof verification failed")
+	}
+	return report, nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+
+	"lukechampine.com/blake3"
+)
+
+// Hash algorithms whose preimages ProvePreimageKnowledge can prove.
+const (
+	PreimageHashSHA256   = HashSuiteSHA256
+	PreimageHashSHA512   = "sha512"
+	PreimageHashSHA3_256 = "sha3-256"
+	PreimageHashBLAKE3   = "blake3"
+)
+
+// Claims recording the published digest a preimage proof is bound to.
+const (
+	ClaimPreimageHash   = "preimage_hash"
+	ClaimPreimageDigest = "preimage_digest"
+)
+
+// preimageHashes maps the supported algorithms to their hash.
+var preimageHashes = map[string]func() hash.Hash{
+	PreimageHashSHA256:   sha256.New,
+	PreimageHashSHA512:   sha512.New,
+	PreimageHashSHA3_256: func() hash.Hash { return sha3.New256() },
+	PreimageHashBLAKE3:   func() hash.Hash { return blake3.New(32, nil) },
+}
+
+// ErrPreimageMismatch is returned when the preimage does not hash to the
+// digest it is meant to be proven against.
+var ErrPreimageMismatch = errors.New("preimage does not hash to the digest")
+
+// ProvePreimageKnowledge proves knowledge of a preimage of a published
+// digest. The preimage is proven exactly as SecureProveFromBytes would, so
+// the committed state encodes it; the digest is bound twice, into the
+// committed identifier and into signed claims, so the proof only verifies
+// against that digest. The prover refuses a preimage that does not hash to
+// digest: the signature vouches for that check, which the state commitment
+// alone cannot show.
+func (sq *SecureQuantumZKP) ProvePreimageKnowledge(
+	hashAlgo string,
+	digest []byte,
+	preimage []byte,
+	key []byte,
+) (*SecureProof, error) {
+	newHash, ok := preimageHashes[hashAlgo]
+	if !ok {
+		return nil, fmt.Errorf("unsupported preimage hash %q", hashAlgo)
+	}
+	h := newHash()
+	if len(digest) != h.Size() {
+		return nil, fmt.Errorf("%s digest is %d bytes, expected %d", hashAlgo, len(digest), h.Size())
+	}
+	h.Write(preimage)
+	if subtle.ConstantTimeCompare(h.Sum(nil), digest) != 1 {
+		return nil, ErrPreimageMismatch
+	}
+
+	claims := copyClaims(sq.Claims)
+	if claims == nil {
+		claims = make(map[string]string, 2)
+	}
+	for _, reserved := range []string{ClaimPreimageHash, ClaimPreimageDigest} {
+		if _, ok := claims[reserved]; ok {
+			return nil, fmt.Errorf("%w: claim %q is reserved for preimage proofs", ErrInvalidClaims, reserved)
+		}
+	}
+	claims[ClaimPreimageHash] = hashAlgo
+	claims[ClaimPreimageDigest] = hex.EncodeToString(digest)
+
+	identifier := sq.identifierPrefix() + preimageIdentifier(hashAlgo, digest)
+	proof, err := sq.WithClaims(claims).SecureProveFromBytes(preimage, identifier, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prove preimage knowledge: %w", err)
+	}
+	return proof, nil
+}
+
+// VerifyPreimageKnowledge verifies a proof from ProvePreimageKnowledge
+// against the published digest.
+func (sq *SecureQuantumZKP) VerifyPreimageKnowledge(
+	proof *SecureProof,
+	hashAlgo string,
+	digest []byte,
+	key []byte,
+) bool {
+	if proof == nil {
+		return false
+	}
+	if _, ok := preimageHashes[hashAlgo]; !ok {
+		return false
+	}
+	if proof.Claims[ClaimPreimageHash] != hashAlgo || proof.Claims[ClaimPreimageDigest] != hex.EncodeToString(digest) {
+		return false
+	}
+	if !sq.matchesIdentifier(proof, sq.identifierPrefix()+preimageIdentifier(hashAlgo, digest)) {
+		return false
+	}
+	return sq.VerifySecureProof(proof, key)
+}
+
+// preimageIdentifier names a published digest in the identifier of its
+// preimage proof.
+func preimageIdentifier(hashAlgo string, digest []byte) string {
+	return fmt.Sprintf("preimage:%s:%x", hashAlgo, digest)
+}
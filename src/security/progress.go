@@ -0,0 +1,81 @@
+package main
+
+import "sync"
+
+// ProgressFunc receives proof generation progress: done of total units of
+// work are complete in stage. It is called synchronously from the proving
+// goroutine, so it should return quickly.
+type ProgressFunc func(stage string, done, total int)
+
+// Proof generation stages in the order they run. Composed and vector set
+// proofs repeat the commitment, challenge and response stages per round or
+// member.
+const (
+	ProgressStageCommitment = "commitment"
+	ProgressStageChallenges = "challenges"
+	ProgressStageResponses  = "responses"
+	ProgressStageMerkle     = "merkle"
+	ProgressStageSigning    = "signing"
+)
+
+// ProgressStages lists the stages in execution order.
+var ProgressStages = []string{
+	ProgressStageCommitment,
+	ProgressStageChallenges,
+	ProgressStageResponses,
+	ProgressStageMerkle,
+	ProgressStageSigning,
+}
+
+// reportProgress forwards to sq.Progress when one is set, and to the
+// proof's debug trace.
+func (sq *SecureQuantumZKP) reportProgress(stage string, done, total int) {
+	sq.debugTrace.record(stage, done, total)
+	if sq.Progress != nil {
+		sq.Progress(stage, done, total)
+	}
+}
+
+// StageProgress aggregates the progress events of one stage.
+type StageProgress struct {
+	Updates   uint64 `json:"updates"`
+	Completed uint64 `json:"completed"` // updates with done == total
+}
+
+// ProgressMetrics aggregates progress across concurrent proofs for export
+// by services. Record is safe for concurrent use.
+type ProgressMetrics struct {
+	mu     sync.Mutex
+	stages map[string]*StageProgress
+}
+
+// NewProgressMetrics creates an empty aggregator.
+func NewProgressMetrics() *ProgressMetrics {
+	return &ProgressMetrics{stages: make(map[string]*StageProgress)}
+}
+
+// Record is a ProgressFunc that counts the event.
+func (m *ProgressMetrics) Record(stage string, done, total int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.stages[stage]
+	if !ok {
+		s = &StageProgress{}
+		m.stages[stage] = s
+	}
+	s.Updates++
+	if done == total {
+		s.Completed++
+	}
+}
+
+// Snapshot returns a copy of the per-stage counters.
+func (m *ProgressMetrics) Snapshot() map[string]StageProgress {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := make(map[string]StageProgress, len(m.stages))
+	for stage, s := range m.stages {
+		snapshot[stage] = *s
+	}
+	return snapshot
+}
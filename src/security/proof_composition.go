@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"lukechampine.com/blake3"
+)
+
+// MaxCompositionRounds caps how many independent rounds a composed proof may
+// contain, which bounds both proof size and verification cost.
+const MaxCompositionRounds = 16
+
+// ComposedProof aggregates several independent SecureProof rounds under a
+// single signature. Soundness errors multiply across rounds, so R rounds of
+// k-bit soundness give R×k bits overall.
+type ComposedProof struct {
+	Rounds             []*SecureProof `json:"rounds"`
+	CombinedRoot       string         `json:"combined_root"`
+	EffectiveSoundness int            `json:"effective_soundness"`
+	Identifier         string         `json:"identifier"`
+	IdentifierSalt     string         `json:"identifier_salt,omitempty"`
+	IdentifierScheme   string         `json:"identifier_scheme,omitempty"`
+	Signature          string         `json:"signature"`
+	Timestamp          time.Time      `json:"timestamp"`
+}
+
+// CompositionRounds returns the number of rounds needed to reach
+// targetSoundness bits with this instance's per-round security parameter.
+func (sq *SecureQuantumZKP) CompositionRounds(targetSoundness int) (int, error) {
+	if targetSoundness <= 0 {
+		return 0, fmt.Errorf("invalid target soundness: %d bits", targetSoundness)
+	}
+	if sq.SecurityParameter <= 0 {
+		return 0, errors.New("security parameter must be positive")
+	}
+
+	rounds, err := checkedCeilDiv(targetSoundness, sq.SecurityParameter)
+	if err != nil {
+		return 0, err
+	}
+	if rounds > MaxCompositionRounds {
+		return 0, fmt.Errorf("target soundness %d bits needs %d rounds (maximum %d)",
+			targetSoundness, rounds, MaxCompositionRounds)
+	}
+	return rounds, nil
+}
+
+// SecureProveComposed generates a composed proof with at least
+// targetSoundness bits of soundness. Each round runs the full protocol under
+// its own sub-key derived from key, so rounds are independent of each other.
+func (sq *SecureQuantumZKP) SecureProveComposed(
+	vector []complex128,
+	identifier string,
+	key []byte,
+	targetSoundness int,
+) (*ComposedProof, error) {
+	rounds, err := sq.CompositionRounds(targetSoundness)
+	if err != nil {
+		return nil, err
+	}
+	state, err := NewStateVector(vector)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every round shares one published identifier so rounds stay linked
+	sq, randomness := sq.withRandomnessTrace()
+	published, err := sq.publishIdentifier(identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish identifier: %w", err)
+	}
+
+	proof := &ComposedProof{
+		Rounds:             make([]*SecureProof, rounds),
+		EffectiveSoundness: rounds * sq.SecurityParameter,
+		Identifier:         published.Identifier,
+		IdentifierSalt:     published.Salt,
+		IdentifierScheme:   published.Scheme,
+		Timestamp:          sq.now(),
+	}
+
+	for r := 0; r < rounds; r++ {
+		round, err := sq.buildSecureProof(state, identifier, deriveRoundKey(key, r), published)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build round %d: %w", r, err)
+		}
+		proof.Rounds[r] = round
+	}
+	for _, round := range proof.Rounds {
+		round.Randomness = randomness.Classes()
+	}
+
+	proof.CombinedRoot, err = combinedRoundRoot(proof.Rounds)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := composedSigningMessage(proof)
+	if err != nil {
+		return nil, err
+	}
+	sq.reportProgress(ProgressStageSigning, 0, 1)
+	sigBytes, err := sq.Signer.Sign(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign composed proof: %w", err)
+	}
+	proof.Signature = EncodeHexField(sigBytes)
+	sq.reportProgress(ProgressStageSigning, 1, 1)
+
+	return proof, nil
+}
+
+// VerifyComposedProof checks the shared signature, the combined root and
+// every individual round.
+func (sq *SecureQuantumZKP) VerifyComposedProof(proof *ComposedProof, key []byte) bool {
+	if proof == nil || len(proof.Rounds) == 0 || len(proof.Rounds) > MaxCompositionRounds {
+		return false
+	}
+	if proof.EffectiveSoundness != len(proof.Rounds)*sq.SecurityParameter {
+		return false
+	}
+
+	// 1. Verify the shared signature
+	msg, err := composedSigningMessage(proof)
+	if err != nil {
+		return false
+	}
+	sigBytes, err := decodeSignatureField(proof.Signature)
+	if err != nil {
+		return false
+	}
+	if !sq.Signer.Verify(msg, sigBytes) {
+		return false
+	}
+
+	// 2. Verify the combined root
+	combined, err := combinedRoundRoot(proof.Rounds)
+	if err != nil || combined != proof.CombinedRoot {
+		return false
+	}
+
+	// 3. Verify each round; a repeated commitment would mean a round was
+	// replayed rather than run independently
+	seen := make(map[string]bool, len(proof.Rounds))
+	for r, round := range proof.Rounds {
+		if round == nil || round.Identifier != proof.Identifier || round.IdentifierSalt != proof.IdentifierSalt ||
+			seen[round.CommitmentHash] {
+			return false
+		}
+		seen[round.CommitmentHash] = true
+
+		if !sq.verifySecureProofBody(round, deriveRoundKey(key, r)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// deriveRoundKey derives the sub-key for a single composition round.
+func deriveRoundKey(key []byte, round int) []byte {
+	subKey := make([]byte, 32)
+	blake3.DeriveKey(subKey, fmt.Sprintf("qzkp/composition-round/v1/%d", round), key)
+	return subKey
+}
+
+// combinedRoundRoot builds a Merkle root over the per-round Merkle roots.
+func combinedRoundRoot(rounds []*SecureProof) (string, error) {
+	leaves := make([][]byte, len(rounds))
+	for i, round := range rounds {
+		if round == nil {
+			return "", fmt.Errorf("round %d is missing", i)
+		}
+		leaf, err := decodeDigestField(round.MerkleRoot, 1)
+		if err != nil {
+			return "", fmt.Errorf("round %d has malformed Merkle root: %w", i, err)
+		}
+		leaves[i] = leaf
+	}
+	return EncodeHexField(merkleRootOfLeaves(leaves)), nil
+}
+
+// composedSigningMessage serializes the composed proof without its signature.
+func composedSigningMessage(proof *ComposedProof) ([]byte, error) {
+	temp := *proof
+	temp.Signature = ""
+	return json.Marshal(&temp)
+}
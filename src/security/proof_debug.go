@@ -0,0 +1,170 @@
+package main
+
+import (
+	"runtime"
+	"runtime/metrics"
+	"time"
+)
+
+// debugStageMeasurement is the debug envelope's name for measuring the
+// challenges on the measurement backend, which has no progress stage.
+const debugStageMeasurement = "measurement"
+
+// ProofDebug is a diagnostic envelope attached to proofs when
+// SecureQuantumZKP.Debug is set, so that a slow prover can be diagnosed
+// from the proof alone. It is outside the signed transcript: it is not
+// signed, does not change ProofDigest, is ignored by verification and can
+// be removed or altered without invalidating the proof, so nothing in it
+// should be trusted.
+//
+// The envelope is built from an allow-list of public parameters and
+// measurements. It never contains the state, the proof key, the
+// identifier, claim values, or the identifier and linkage keys.
+type ProofDebug struct {
+	Total          time.Duration `json:"total"`
+	Allocations    uint64        `json:"allocations"`
+	AllocatedBytes uint64        `json:"allocated_bytes"`
+	Stages         []DebugStage  `json:"stages"` // in the order they ran
+	Config         DebugConfig   `json:"config"`
+}
+
+// DebugStage is the timing of one proof generation stage: one of
+// ProgressStages, or "measurement". Allocations are read from process-wide
+// counters, so they include those of anything running concurrently.
+type DebugStage struct {
+	Name           string        `json:"name"`
+	Duration       time.Duration `json:"duration"`
+	Allocations    uint64        `json:"allocations"`
+	AllocatedBytes uint64        `json:"allocated_bytes"`
+}
+
+// DebugConfig is a snapshot of the configuration that made a proof and of
+// the process it ran in.
+type DebugConfig struct {
+	Library            string         `json:"library"`
+	GoVersion          string         `json:"go_version"`
+	Platform           string         `json:"platform"` // GOOS/GOARCH
+	GOMAXPROCS         int            `json:"gomaxprocs"`
+	NumCPU             int            `json:"num_cpu"`
+	Dimensions         int            `json:"dimensions"`
+	SecurityLevel      int            `json:"security_level"`
+	Context            string         `json:"context"`
+	Profile            string         `json:"profile,omitempty"`
+	Parameters         Parameters     `json:"parameters"`
+	MeasurementBackend string         `json:"measurement_backend"`
+	MemoryEstimate     MemoryEstimate `json:"memory_estimate"`
+	MemorySoftLimit    int64          `json:"memory_soft_limit,omitempty"`
+	MemoryHardLimit    int64          `json:"memory_hard_limit,omitempty"`
+	Claims             int            `json:"claims,omitempty"` // how many; their values are not recorded
+	IdentifierPrivacy  bool           `json:"identifier_privacy,omitempty"`
+	Linkage            bool           `json:"linkage,omitempty"`
+	TimeAuthority      bool           `json:"time_authority,omitempty"`
+}
+
+// debugTrace times the stages of one proof as they report progress.
+type debugTrace struct {
+	start   debugMark
+	stages  []DebugStage
+	running map[string]debugMark // stages started but not finished
+}
+
+// debugMark is a point in time and the process's allocation counters then.
+type debugMark struct {
+	at             time.Time
+	allocations    uint64
+	allocatedBytes uint64
+}
+
+// newDebugMark reads the clock and the allocation counters.
+func newDebugMark() debugMark {
+	samples := []metrics.Sample{{Name: "/gc/heap/allocs:objects"}, {Name: "/gc/heap/allocs:bytes"}}
+	metrics.Read(samples)
+	mark := debugMark{at: time.Now()}
+	if samples[0].Value.Kind() == metrics.KindUint64 {
+		mark.allocations = samples[0].Value.Uint64()
+	}
+	if samples[1].Value.Kind() == metrics.KindUint64 {
+		mark.allocatedBytes = samples[1].Value.Uint64()
+	}
+	return mark
+}
+
+// since returns the time and allocations from m to now.
+func (m debugMark) since(name string, now debugMark) DebugStage {
+	return DebugStage{
+		Name:           name,
+		Duration:       now.at.Sub(m.at),
+		Allocations:    now.allocations - m.allocations,
+		AllocatedBytes: now.allocatedBytes - m.allocatedBytes,
+	}
+}
+
+// record is called with every progress report: a stage starts when it
+// reports no work done and finishes when it reports all of it. A nil
+// trace records nothing.
+func (t *debugTrace) record(stage string, done, total int) {
+	if t == nil {
+		return
+	}
+	if done == 0 {
+		t.running[stage] = newDebugMark()
+	}
+	if done == total {
+		if started, ok := t.running[stage]; ok {
+			t.stages = append(t.stages, started.since(stage, newDebugMark()))
+			delete(t.running, stage)
+		}
+	}
+}
+
+// withDebugTrace returns a copy of sq that times its stages when sq.Debug
+// is set and FeatureDebugEnvelopes enabled, and sq and nil otherwise.
+func (sq *SecureQuantumZKP) withDebugTrace() (*SecureQuantumZKP, *debugTrace) {
+	if !sq.Debug || !sq.Features.Enabled(FeatureDebugEnvelopes) {
+		return sq, nil
+	}
+	copied := *sq
+	copied.debugTrace = &debugTrace{start: newDebugMark(), running: make(map[string]debugMark)}
+	return &copied, copied.debugTrace
+}
+
+// envelope returns the debug envelope of a proof of a dimension-d state
+// made by sq, timed up to now. It is nil for a nil trace.
+func (t *debugTrace) envelope(sq *SecureQuantumZKP, dimension int) *ProofDebug {
+	if t == nil {
+		return nil
+	}
+	total := t.start.since("", newDebugMark())
+	backend := "cpu"
+	if b, err := sq.measurementBackend(); err == nil {
+		backend = b.Name()
+	}
+	// The estimate was already computed once for this proof
+	estimate, _ := sq.EstimateProofMemory(dimension)
+	return &ProofDebug{
+		Total:          total.Duration,
+		Allocations:    total.Allocations,
+		AllocatedBytes: total.AllocatedBytes,
+		Stages:         t.stages,
+		Config: DebugConfig{
+			Library:            LibraryVersion,
+			GoVersion:          runtime.Version(),
+			Platform:           runtime.GOOS + "/" + runtime.GOARCH,
+			GOMAXPROCS:         runtime.GOMAXPROCS(0),
+			NumCPU:             runtime.NumCPU(),
+			Dimensions:         sq.Dimensions,
+			SecurityLevel:      sq.SecurityLevel,
+			Context:            sq.Context.String(),
+			Profile:            sq.Profile,
+			Parameters:         sq.Parameters(),
+			MeasurementBackend: backend,
+			MemoryEstimate:     estimate,
+			MemorySoftLimit:    sq.MemoryBudget.Soft,
+			MemoryHardLimit:    sq.MemoryBudget.Hard,
+			Claims:             len(sq.Claims),
+			IdentifierPrivacy:  len(sq.IdentifierKey) > 0,
+			Linkage:            len(sq.LinkageKey) > 0,
+			TimeAuthority:      sq.TimeAuthority != nil,
+		},
+	}
+}
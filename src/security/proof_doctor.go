@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
+)
+
+// proofCodec is one way a proof may have been encoded for storage or
+// transfer.
+type proofCodec struct {
+	name   string
+	decode func([]byte) ([]byte, error)
+	encode func([]byte) ([]byte, error)
+}
+
+// proofCodecs are tried in order; the first one that yields a JSON object
+// is assumed to be the encoding the proof was written with.
+var proofCodecs = []proofCodec{
+	{"json", func(b []byte) ([]byte, error) { return b, nil }, func(b []byte) ([]byte, error) { return b, nil }},
+	{"gzip+json", gunzipPartial, gzipBytes},
+	{"base64+json",
+		func(b []byte) ([]byte, error) { return decodeBase64(base64.StdEncoding, b) },
+		func(b []byte) ([]byte, error) { return []byte(base64.StdEncoding.EncodeToString(b)), nil }},
+	{"base64url+json",
+		func(b []byte) ([]byte, error) { return decodeBase64(base64.URLEncoding, b) },
+		func(b []byte) ([]byte, error) { return []byte(base64.URLEncoding.EncodeToString(b)), nil }},
+	{"hex+json",
+		func(b []byte) ([]byte, error) { return hex.DecodeString(string(bytes.TrimSpace(b))) },
+		func(b []byte) ([]byte, error) { return []byte(hex.EncodeToString(b)), nil }},
+	{"base64+gzip+json",
+		func(b []byte) ([]byte, error) {
+			raw, err := decodeBase64(base64.StdEncoding, b)
+			if err != nil {
+				return nil, err
+			}
+			return gunzipPartial(raw)
+		},
+		func(b []byte) ([]byte, error) {
+			zipped, err := gzipBytes(b)
+			if err != nil {
+				return nil, err
+			}
+			return []byte(base64.StdEncoding.EncodeToString(zipped)), nil
+		}},
+}
+
+// ProofCodecs returns the names of the encodings DiagnoseProof recognizes.
+func ProofCodecs() []string {
+	names := make([]string, len(proofCodecs))
+	for i, codec := range proofCodecs {
+		names[i] = codec.name
+	}
+	return names
+}
+
+// EncodeProof encodes a JSON proof with the named codec.
+func EncodeProof(codec string, proofJSON []byte) ([]byte, error) {
+	for _, c := range proofCodecs {
+		if c.name == codec {
+			return c.encode(proofJSON)
+		}
+	}
+	return nil, fmt.Errorf("unknown proof codec %q", codec)
+}
+
+// SectionStatus reports whether one top-level field of a proof decoded.
+type SectionStatus struct {
+	Name     string `json:"name"`
+	Present  bool   `json:"present"`
+	Parsed   bool   `json:"parsed"`
+	Required bool   `json:"required"`
+	Error    string `json:"error,omitempty"`
+}
+
+// HashCheck reports whether a digest or signature in the proof is
+// consistent with the rest of the proof.
+type HashCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ProofDiagnosis is the report produced by DiagnoseProof.
+type ProofDiagnosis struct {
+	Codec     string          `json:"codec,omitempty"`
+	Version   int             `json:"version,omitempty"`
+	Sections  []SectionStatus `json:"sections,omitempty"`
+	Hashes    []HashCheck     `json:"hashes,omitempty"`
+	Truncated bool            `json:"truncated"`
+	// TruncatedAt is the byte offset into the decoded payload where input
+	// ended or became unparseable, and TruncatedIn the section being read
+	TruncatedAt        int64  `json:"truncated_at,omitempty"`
+	TruncatedIn        string `json:"truncated_in,omitempty"`
+	ResponsesRecovered int    `json:"responses_recovered"`
+	// Causes lists likely explanations, most likely first
+	Causes []string `json:"causes,omitempty"`
+}
+
+// Healthy reports whether no structural problem was found. A healthy proof
+// may still fail verification; DiagnoseProof has no access to keys.
+func (d *ProofDiagnosis) Healthy() bool {
+	return len(d.Causes) == 0
+}
+
+// DiagnoseProof inspects a proof that fails to load or verify and reports
+// which codec it appears to use, which sections parse, which hashes
+// recompute, where truncation occurred and the likely cause. It never
+// returns an error; an unrecognizable input produces a diagnosis saying so.
+func DiagnoseProof(raw []byte) *ProofDiagnosis {
+	d := &ProofDiagnosis{}
+
+	payload, codec, codecErr := detectProofCodec(raw)
+	if codec == "" {
+		d.Causes = append(d.Causes, "input is not a proof in any known encoding (wrong file or unsupported codec)")
+		return d
+	}
+	d.Codec = codec
+
+	sections, responses, err := salvageProofSections(payload, d)
+	d.ResponsesRecovered = len(responses)
+	d.diagnoseSections(sections)
+
+	switch {
+	case d.Truncated:
+		d.Causes = append(d.Causes, fmt.Sprintf(
+			"payload ends at byte %d inside %q; the transfer was likely truncated", d.TruncatedAt, d.TruncatedIn))
+	case err != nil:
+		d.Causes = append(d.Causes, fmt.Sprintf(
+			"payload is corrupted at byte %d inside %q: %v", d.TruncatedAt, d.TruncatedIn, err))
+	case codecErr != nil:
+		d.Causes = append(d.Causes, fmt.Sprintf("%s layer is damaged: %v", codec, codecErr))
+	}
+	if codec != "json" {
+		d.Causes = append(d.Causes, fmt.Sprintf(
+			"proof is %s-encoded; decode it before passing it to a verifier", codec))
+	}
+
+	d.diagnoseHashes(sections, responses)
+	return d
+}
+
+// detectProofCodec returns the decoded payload and the name of the first
+// codec that produces something shaped like a JSON object. A codec error
+// alongside a payload means the payload is partial.
+func detectProofCodec(raw []byte) ([]byte, string, error) {
+	for _, codec := range proofCodecs {
+		payload, err := codec.decode(raw)
+		trimmed := bytes.TrimSpace(payload)
+		if len(trimmed) > 0 && trimmed[0] == '{' {
+			return trimmed, codec.name, err
+		}
+	}
+	return nil, "", nil
+}
+
+// salvageProofSections walks the top-level object, keeping every section
+// that decodes in full and every complete challenge response, and records
+// where decoding stopped.
+func salvageProofSections(payload []byte, d *ProofDiagnosis) (map[string]json.RawMessage, []json.RawMessage, error) {
+	sections := make(map[string]json.RawMessage)
+	var responses []json.RawMessage
+
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	stop := func(section string, err error) (map[string]json.RawMessage, []json.RawMessage, error) {
+		d.TruncatedAt = dec.InputOffset()
+		d.TruncatedIn = section
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			d.Truncated = true
+			return sections, responses, nil
+		}
+		return sections, responses, err
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return stop("", err)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return stop("", err)
+		}
+		key, _ := tok.(string)
+
+		if key != "challenge_response" {
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				return stop(key, err)
+			}
+			sections[key] = value
+			continue
+		}
+
+		// Stream responses one at a time so a truncated array still
+		// yields the responses before the cut
+		if tok, err := dec.Token(); err != nil {
+			return stop(key, err)
+		} else if tok == nil {
+			sections[key] = json.RawMessage("null")
+			continue
+		}
+		for dec.More() {
+			var response json.RawMessage
+			if err := dec.Decode(&response); err != nil {
+				return stop(key, err)
+			}
+			responses = append(responses, response)
+		}
+		if _, err := dec.Token(); err != nil {
+			return stop(key, err)
+		}
+		sections[key], _ = json.Marshal(responses)
+	}
+	if _, err := dec.Token(); err != nil {
+		return stop("", err)
+	}
+	return sections, responses, nil
+}
+
+// diagnoseSections determines the format version and decodes each
+// SecureProof field from its section.
+func (d *ProofDiagnosis) diagnoseSections(sections map[string]json.RawMessage) {
+	if value, ok := sections["version"]; ok {
+		json.Unmarshal(value, &d.Version)
+	}
+	if d.Version == 0 {
+		d.Version = ProofVersionLegacy
+	}
+	if _, ok := proofFormats[d.Version]; !ok {
+		d.Causes = append(d.Causes, fmt.Sprintf(
+			"proof version %d is not supported by this build; it may come from a newer release", d.Version))
+	}
+
+	// Older formats lack later fields, so only current proofs are checked
+	// for missing sections
+	checkMissing := d.Version == CurrentProofVersion && !d.Truncated
+	proofType := reflect.TypeOf(SecureProof{})
+	for i := 0; i < proofType.NumField(); i++ {
+		field := proofType.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		status := SectionStatus{
+			Name:     tag[0],
+			Required: !hasTagOption(field.Tag.Get("json"), "omitempty"),
+		}
+
+		value, ok := sections[status.Name]
+		status.Present = ok
+		if ok {
+			if err := json.Unmarshal(value, reflect.New(field.Type).Interface()); err != nil {
+				status.Error = err.Error()
+			} else {
+				status.Parsed = true
+			}
+		}
+		d.Sections = append(d.Sections, status)
+
+		switch {
+		case status.Error != "":
+			d.Causes = append(d.Causes, fmt.Sprintf("section %q does not decode: %s", status.Name, status.Error))
+		case status.Required && !status.Present && checkMissing:
+			d.Causes = append(d.Causes, fmt.Sprintf("required section %q is missing", status.Name))
+		}
+	}
+}
+
+// diagnoseHashes recomputes what can be recomputed without keys: the
+// Merkle root over the responses and the shape of every digest.
+func (d *ProofDiagnosis) diagnoseHashes(sections map[string]json.RawMessage, responses []json.RawMessage) {
+	tampered := false
+	check := func(name string, ok bool, detail string) {
+		d.Hashes = append(d.Hashes, HashCheck{Name: name, OK: ok, Detail: detail})
+		if !ok {
+			tampered = true
+		}
+	}
+
+	var merkleRoot, commitment, signature string
+	lengths := LegacyDigestLengths
+	json.Unmarshal(sections["digest_lengths"], &lengths)
+	json.Unmarshal(sections["merkle_root"], &merkleRoot)
+	json.Unmarshal(sections["commitment_hash"], &commitment)
+	json.Unmarshal(sections["signature"], &signature)
+
+	if _, ok := sections["commitment_hash"]; ok {
+		digest, err := hex.DecodeString(commitment)
+		check("commitment_hash", err == nil && len(digest) == lengths.Commitment,
+			fmt.Sprintf("%d hex characters, expected %d", len(commitment), 2*lengths.Commitment))
+	}
+
+	if _, ok := sections["signature"]; ok {
+		sig, err := hex.DecodeString(signature)
+		check("signature", err == nil && len(sig) == mldsa87.SignatureSize,
+			fmt.Sprintf("%d bytes, expected %d", len(sig), mldsa87.SignatureSize))
+	}
+
+	// The Merkle root only recomputes over the complete response list
+	if _, ok := sections["challenge_response"]; ok && len(responses) > 0 {
+		decoded := make([]ChallengeResponse, 0, len(responses))
+		for i, raw := range responses {
+			var response ChallengeResponse
+			if err := json.Unmarshal(raw, &response); err != nil {
+				check(fmt.Sprintf("challenge_response[%d]", i), false, err.Error())
+				continue
+			}
+			decoded = append(decoded, response)
+		}
+		if len(decoded) == len(responses) {
+			recomputed := hex.EncodeToString(merkleRootOfLeaves(responseLeaves(decoded)))
+			check("merkle_root", recomputed == merkleRoot, "recomputed from challenge responses")
+		}
+	}
+
+	if tampered && !d.Truncated {
+		d.Causes = append(d.Causes,
+			"sections parse but are inconsistent; the proof was modified after signing (tampering or in-transit corruption)")
+	}
+}
+
+// gunzipPartial inflates as much of a gzip stream as is present, returning
+// the partial output together with the error for a damaged stream.
+// Output beyond ProofJSONLimits.MaxBytes is refused with ErrJSONLimit, so
+// that a small compressed proof cannot inflate without bound.
+func gunzipPartial(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	inflated, err := io.ReadAll(io.LimitReader(zr, ProofJSONLimits.MaxBytes+1))
+	if int64(len(inflated)) > ProofJSONLimits.MaxBytes {
+		return nil, fmt.Errorf("%w: gzip payload inflates past %d bytes", ErrJSONLimit, ProofJSONLimits.MaxBytes)
+	}
+	return inflated, err
+}
+
+// decodeBase64 decodes text that may be wrapped or carry trailing
+// whitespace.
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeBase64(enc *base64.Encoding, data []byte) ([]byte, error) {
+	text := strings.Join(strings.Fields(string(data)), "")
+	return enc.DecodeString(text)
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Envelope identifiers. ML-DSA-87 is named as in the JOSE and COSE ML-DSA
+// drafts; the qzkp header parameters are private-use names that existing
+// JWS/COSE processors pass through untouched.
+const (
+	EnvelopeContentType  = "application/qzkp-proof"
+	EnvelopeProfileParam = "qzkp-profile"
+	EnvelopeCodecParam   = "qzkp-codec"
+	EnvelopeVersionParam = "qzkp-version"
+
+	// COSEAlgorithmMLDSA87 is the COSE algorithm identifier of ML-DSA-87
+	COSEAlgorithmMLDSA87 = -50
+
+	coseSign1Tag        = 18
+	coseHeaderAlgorithm = 1
+	coseHeaderType      = 3
+	coseHeaderKeyID     = 4
+)
+
+// EnvelopeHeader is the protected header of a proof envelope. Gateways can
+// route and pre-validate on it and on the envelope signature without
+// decoding the proof.
+type EnvelopeHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"` // KeyFingerprint of the signing key
+	Type      string `json:"typ"`
+	Profile   string `json:"qzkp-profile,omitempty"`
+	Codec     string `json:"qzkp-codec"`
+	Version   int    `json:"qzkp-version"`
+}
+
+// envelopePayload encodes proof with codec and returns the header that
+// describes it.
+func (sq *SecureQuantumZKP) envelopePayload(proof *SecureProof, codec string) ([]byte, *EnvelopeHeader, error) {
+	if proof == nil {
+		return nil, nil, errors.New("proof cannot be nil")
+	}
+	proofJSON, err := json.Marshal(proof)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode proof: %w", err)
+	}
+	payload, err := EncodeProof(codec, proofJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	return payload, &EnvelopeHeader{
+		Algorithm: SignatureAlgorithmMLDSA87,
+		KeyID:     KeyFingerprint(sq.Signer.PublicKeyBytes()),
+		Type:      EnvelopeContentType,
+		Profile:   proof.Profile,
+		Codec:     codec,
+		Version:   proof.Version,
+	}, nil
+}
+
+// openEnvelopePayload decodes the proof an envelope carries and checks it
+// agrees with the envelope's header.
+func openEnvelopePayload(header *EnvelopeHeader, payload []byte) (*SecureProof, error) {
+	var decode func([]byte) ([]byte, error)
+	for _, c := range proofCodecs {
+		if c.name == header.Codec {
+			decode = c.decode
+		}
+	}
+	if decode == nil {
+		return nil, fmt.Errorf("unknown proof codec %q", header.Codec)
+	}
+	proofJSON, err := decode(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s payload: %w", header.Codec, err)
+	}
+	var proof SecureProof
+	if err := DecodeBoundedJSON(bytes.NewReader(proofJSON), ProofJSONLimits, &proof); err != nil {
+		return nil, fmt.Errorf("failed to decode proof: %w", err)
+	}
+	if proof.Profile != header.Profile || proof.Version != header.Version {
+		return nil, errors.New("envelope header does not match the proof it carries")
+	}
+	return &proof, nil
+}
+
+// checkEnvelopeHeader checks the header describes a proof signed by sq's
+// signer.
+func (sq *SecureQuantumZKP) checkEnvelopeHeader(header *EnvelopeHeader) error {
+	if header.Type != EnvelopeContentType {
+		return fmt.Errorf("envelope content type %q is not %q", header.Type, EnvelopeContentType)
+	}
+	if header.Algorithm != SignatureAlgorithmMLDSA87 {
+		return fmt.Errorf("unsupported envelope algorithm %q", header.Algorithm)
+	}
+	if header.KeyID != KeyFingerprint(sq.Signer.PublicKeyBytes()) {
+		return fmt.Errorf("envelope is signed by key %s, not the verifier's key", header.KeyID)
+	}
+	return nil
+}
+
+// EncodeProofJWS wraps proof as a JWS with a detached payload (RFC 7515
+// appendix F), signed with sq's signer. It returns the compact
+// serialization, whose payload part is empty, and the codec-encoded proof
+// to transport alongside it.
+func (sq *SecureQuantumZKP) EncodeProofJWS(proof *SecureProof, codec string) (string, []byte, error) {
+	payload, header, err := sq.envelopePayload(proof, codec)
+	if err != nil {
+		return "", nil, err
+	}
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", nil, err
+	}
+	encodedHeader := base64.RawURLEncoding.EncodeToString(headerJSON)
+	signature, err := sq.Signer.Sign(jwsSigningInput(encodedHeader, payload))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to sign envelope: %w", err)
+	}
+	return encodedHeader + ".." + EncodeBase64Field(signature), payload, nil
+}
+
+// DecodeProofJWS checks a detached JWS made by EncodeProofJWS against
+// payload and returns the proof it carries. Only the envelope is checked;
+// the proof still has to be verified.
+func (sq *SecureQuantumZKP) DecodeProofJWS(token string, payload []byte) (*SecureProof, *EnvelopeHeader, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, errors.New("JWS must have three parts")
+	}
+	if parts[1] != "" {
+		return nil, nil, errors.New("JWS payload must be detached")
+	}
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed JWS header: %w", err)
+	}
+	var header EnvelopeHeader
+	if err := DecodeBoundedJSON(bytes.NewReader(headerJSON), DefaultJSONLimits, &header); err != nil {
+		return nil, nil, fmt.Errorf("malformed JWS header: %w", err)
+	}
+	if err := sq.checkEnvelopeHeader(&header); err != nil {
+		return nil, nil, err
+	}
+	signature, err := DecodeBase64Field(parts[2], signatureSize, signatureSize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed JWS signature: %w", err)
+	}
+	if !sq.Signer.Verify(jwsSigningInput(parts[0], payload), signature) {
+		return nil, nil, errors.New("JWS signature is invalid")
+	}
+	proof, err := openEnvelopePayload(&header, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	return proof, &header, nil
+}
+
+// jwsSigningInput is ASCII(BASE64URL(header) || '.' || BASE64URL(payload)).
+func jwsSigningInput(encodedHeader string, payload []byte) []byte {
+	return []byte(encodedHeader + "." + base64.RawURLEncoding.EncodeToString(payload))
+}
+
+// EncodeProofCOSE wraps proof as a tagged COSE_Sign1 message (RFC 9052)
+// with an embedded payload, signed with sq's signer. The protected header
+// carries the algorithm, content type, key ID and the qzkp profile, codec
+// and version parameters.
+func (sq *SecureQuantumZKP) EncodeProofCOSE(proof *SecureProof, codec string) ([]byte, error) {
+	payload, header, err := sq.envelopePayload(proof, codec)
+	if err != nil {
+		return nil, err
+	}
+	keyID, _ := hex.DecodeString(header.KeyID)
+	protected, err := cborEncode(cborMap{
+		{int64(coseHeaderAlgorithm), int64(COSEAlgorithmMLDSA87)},
+		{int64(coseHeaderType), header.Type},
+		{int64(coseHeaderKeyID), keyID},
+		{EnvelopeProfileParam, header.Profile},
+		{EnvelopeCodecParam, header.Codec},
+		{EnvelopeVersionParam, int64(header.Version)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	toSign, err := coseSigStructure(protected, payload)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := sq.Signer.Sign(toSign)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign envelope: %w", err)
+	}
+	return cborEncode(cborTag{Number: coseSign1Tag, Value: []interface{}{protected, cborMap{}, payload, signature}})
+}
+
+// DecodeProofCOSE checks a COSE_Sign1 message made by EncodeProofCOSE and
+// returns the proof it carries. Untagged messages are accepted. Only the
+// envelope is checked; the proof still has to be verified.
+func (sq *SecureQuantumZKP) DecodeProofCOSE(data []byte) (*SecureProof, *EnvelopeHeader, error) {
+	message, err := cborDecode(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed COSE message: %w", err)
+	}
+	if tag, ok := message.(cborTag); ok {
+		if tag.Number != coseSign1Tag {
+			return nil, nil, fmt.Errorf("CBOR tag %d is not COSE_Sign1", tag.Number)
+		}
+		message = tag.Value
+	}
+	fields, ok := message.([]interface{})
+	if !ok || len(fields) != 4 {
+		return nil, nil, errors.New("COSE_Sign1 must be an array of four items")
+	}
+	protected, ok1 := fields[0].([]byte)
+	payload, ok2 := fields[2].([]byte)
+	signature, ok3 := fields[3].([]byte)
+	if !ok1 || !ok2 || !ok3 {
+		return nil, nil, errors.New("COSE_Sign1 must carry a protected header, an embedded payload and a signature")
+	}
+
+	header, err := parseCOSEHeader(protected)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := sq.checkEnvelopeHeader(header); err != nil {
+		return nil, nil, err
+	}
+	toSign, err := coseSigStructure(protected, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !sq.Signer.Verify(toSign, signature) {
+		return nil, nil, errors.New("COSE signature is invalid")
+	}
+	proof, err := openEnvelopePayload(header, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	return proof, header, nil
+}
+
+// parseCOSEHeader reads the protected header written by EncodeProofCOSE.
+func parseCOSEHeader(protected []byte) (*EnvelopeHeader, error) {
+	decoded, err := cborDecode(protected)
+	if err != nil {
+		return nil, fmt.Errorf("malformed COSE protected header: %w", err)
+	}
+	m, ok := decoded.(cborMap)
+	if !ok {
+		return nil, errors.New("COSE protected header must be a map")
+	}
+	header := &EnvelopeHeader{}
+	if alg, _ := m.get(int64(coseHeaderAlgorithm)); alg == int64(COSEAlgorithmMLDSA87) {
+		header.Algorithm = SignatureAlgorithmMLDSA87
+	} else {
+		return nil, fmt.Errorf("unsupported COSE algorithm %v", alg)
+	}
+	keyID, _ := m.get(int64(coseHeaderKeyID))
+	if kid, ok := keyID.([]byte); ok {
+		header.KeyID = hex.EncodeToString(kid)
+	}
+	typ, _ := m.get(int64(coseHeaderType))
+	header.Type, _ = typ.(string)
+	profile, _ := m.get(EnvelopeProfileParam)
+	header.Profile, _ = profile.(string)
+	codec, _ := m.get(EnvelopeCodecParam)
+	header.Codec, _ = codec.(string)
+	version, _ := m.get(EnvelopeVersionParam)
+	if v, ok := version.(int64); ok {
+		header.Version = int(v)
+	}
+	return header, nil
+}
+
+// coseSigStructure is the Sig_structure signed for a COSE_Sign1 message
+// without external data.
+func coseSigStructure(protected, payload []byte) ([]byte, error) {
+	return cborEncode([]interface{}{"Signature1", protected, []byte{}, payload})
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// jobFileExt is the extension of job records kept in a proof store.
+const jobFileExt = ".job.json"
+
+// Errors returned by ProofJobQueue.
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobPending  = errors.New("job has not finished")
+)
+
+// JobID identifies a submitted proof job. It is also the name the job's
+// proof is saved under in the proof store.
+type JobID string
+
+// JobState is the lifecycle stage of a proof job.
+type JobState string
+
+const (
+	JobPending   JobState = "pending"
+	JobRunning   JobState = "running"
+	JobSucceeded JobState = "succeeded"
+	JobFailed    JobState = "failed"
+)
+
+// ProofJobRequest is a ProveRequest run in the background. When
+// CallbackURL is set, the finished JobInfo is POSTed to it as JSON.
+type ProofJobRequest struct {
+	ProveRequest
+	CallbackURL string `json:"callback_url,omitempty"`
+}
+
+// JobInfo is the status of a proof job.
+type JobInfo struct {
+	ID            JobID     `json:"id"`
+	State         JobState  `json:"state"`
+	Identifier    string    `json:"identifier"`
+	Submitted     time.Time `json:"submitted"`
+	Started       time.Time `json:"started"`  // zero until a worker picks the job up
+	Finished      time.Time `json:"finished"` // zero until the job succeeds or fails
+	Error         string    `json:"error,omitempty"`
+	CallbackURL   string    `json:"callback_url,omitempty"`
+	CallbackError string    `json:"callback_error,omitempty"`
+}
+
+// jobRecord is the persisted form of a job. Data is kept only until the
+// job finishes, so a finished record holds no secret.
+type jobRecord struct {
+	JobInfo
+	Data []byte `json:"data,omitempty"`
+}
+
+// ProofJobOptions configures a ProofJobQueue.
+type ProofJobOptions struct {
+	Workers   int // 1 if zero
+	QueueSize int // 64 if zero; jobs beyond it are refused with ErrQueueFull
+	// OnComplete, when set, is called with every finished job
+	OnComplete func(JobInfo)
+	// HTTPClient delivers callbacks; a client with a 10 second timeout if
+	// nil
+	HTTPClient *http.Client
+}
+
+// ProofJobQueue proves submitted requests on a bounded worker pool, so
+// bulk provers are not blocked for the duration of high-soundness proofs.
+// Job records live in the proof store next to the proofs, written before
+// a job is acknowledged, so jobs still pending when the process stops are
+// run again by the next queue opened on the store. Records of unfinished
+// jobs hold the data to prove and are readable by the owner only.
+type ProofJobQueue struct {
+	Store *ProofStore
+
+	prove   func(*ProveRequest) (*ProveResponse, error)
+	opts    ProofJobOptions
+	queue   chan JobID
+	workers sync.WaitGroup
+
+	mu      sync.Mutex // guards jobs, closing and record writes
+	jobs    map[JobID]*jobRecord
+	closing bool
+}
+
+// NewProofJobQueue opens a job queue on store that proves with prove,
+// typically ProverService.Prove, and starts its workers. Jobs left pending
+// or running by a previous queue are resumed.
+func NewProofJobQueue(store *ProofStore, prove func(*ProveRequest) (*ProveResponse, error), opts ProofJobOptions) (*ProofJobQueue, error) {
+	if store == nil || prove == nil {
+		return nil, errors.New("proof store and prove function are required")
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 64
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	q := &ProofJobQueue{Store: store, prove: prove, opts: opts, jobs: make(map[JobID]*jobRecord)}
+	var resumed []JobID
+	for name, err := range store.entries(jobFileExt) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list jobs: %w", err)
+		}
+		record, err := q.readRecord(name)
+		if err != nil {
+			return nil, err
+		}
+		if record.State == JobPending || record.State == JobRunning {
+			record.State, record.Started = JobPending, time.Time{}
+			resumed = append(resumed, record.ID)
+		}
+		q.jobs[record.ID] = record
+	}
+
+	// Resumed jobs must fit in the queue alongside a full set of new ones
+	q.queue = make(chan JobID, opts.QueueSize+len(resumed))
+	for _, id := range resumed {
+		q.queue <- id
+	}
+	for i := 0; i < opts.Workers; i++ {
+		q.workers.Add(1)
+		go q.worker()
+	}
+	return q, nil
+}
+
+// SubmitProofJob records req and queues it, returning without waiting for
+// the proof. It fails with ErrQueueFull when QueueSize jobs are waiting
+// and with ErrDraining after Close.
+func (q *ProofJobQueue) SubmitProofJob(req *ProofJobRequest) (JobID, error) {
+	if req == nil || len(req.Data) == 0 {
+		return "", errors.New("data cannot be empty")
+	}
+	if req.Identifier == "" {
+		return "", errors.New("identifier cannot be empty")
+	}
+	id, err := newJobID()
+	if err != nil {
+		return "", err
+	}
+	record := &jobRecord{
+		JobInfo: JobInfo{
+			ID:          id,
+			State:       JobPending,
+			Identifier:  req.Identifier,
+			Submitted:   time.Now().UTC(),
+			CallbackURL: req.CallbackURL,
+		},
+		Data: append([]byte(nil), req.Data...),
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closing {
+		return "", ErrDraining
+	}
+	if len(q.queue) >= q.opts.QueueSize {
+		return "", ErrQueueFull
+	}
+	if err := q.writeRecord(record); err != nil {
+		return "", fmt.Errorf("failed to record job: %w", err)
+	}
+	q.jobs[id] = record
+	q.queue <- id
+	return id, nil
+}
+
+// JobStatus returns the status of job id.
+func (q *ProofJobQueue) JobStatus(id JobID) (*JobInfo, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	record, ok := q.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	info := record.JobInfo
+	return &info, nil
+}
+
+// JobResult returns the proof of a succeeded job. It returns ErrJobPending
+// while the job is queued or running, and the job's error if it failed.
+func (q *ProofJobQueue) JobResult(id JobID) (*SecureProof, error) {
+	info, err := q.JobStatus(id)
+	if err != nil {
+		return nil, err
+	}
+	switch info.State {
+	case JobSucceeded:
+		return q.Store.Load(string(id))
+	case JobFailed:
+		return nil, fmt.Errorf("job %s failed: %s", id, info.Error)
+	default:
+		return nil, fmt.Errorf("%w: %s is %s", ErrJobPending, id, info.State)
+	}
+}
+
+// Close stops taking jobs and waits for running jobs to finish or ctx to
+// expire. Jobs still queued stay pending in the store and are resumed by
+// the next queue.
+func (q *ProofJobQueue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closing {
+		q.closing = true
+		close(q.queue)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("drain incomplete: %w", ctx.Err())
+	}
+}
+
+// worker runs queued jobs until the queue is closed.
+func (q *ProofJobQueue) worker() {
+	defer q.workers.Done()
+	for id := range q.queue {
+		if !q.start(id) {
+			continue
+		}
+		q.finish(id, q.run(id))
+	}
+}
+
+// start marks job id running, or reports false once the queue is closing
+// so the job is left pending for the next queue.
+func (q *ProofJobQueue) start(id JobID) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closing {
+		return false
+	}
+	record := q.jobs[id]
+	record.State, record.Started = JobRunning, time.Now().UTC()
+	// A lost running state only means the job is retried as pending
+	q.writeRecord(record)
+	return true
+}
+
+// run proves job id and saves the proof under the job's ID.
+func (q *ProofJobQueue) run(id JobID) error {
+	q.mu.Lock()
+	record := q.jobs[id]
+	req := &ProveRequest{Identifier: record.Identifier, Data: record.Data}
+	q.mu.Unlock()
+
+	resp, err := q.prove(req)
+	if err != nil {
+		return err
+	}
+	if err := q.Store.Save(string(id), resp.Proof); err != nil {
+		return fmt.Errorf("failed to save proof: %w", err)
+	}
+	return nil
+}
+
+// finish records the outcome of job id and reports it.
+func (q *ProofJobQueue) finish(id JobID, jobErr error) {
+	q.mu.Lock()
+	record := q.jobs[id]
+	record.State, record.Finished, record.Data = JobSucceeded, time.Now().UTC(), nil
+	if jobErr != nil {
+		record.State, record.Error = JobFailed, jobErr.Error()
+	}
+	q.writeRecord(record)
+	info := record.JobInfo
+	q.mu.Unlock()
+
+	if info.CallbackURL != "" {
+		if err := q.deliverCallback(info); err != nil {
+			q.mu.Lock()
+			record.CallbackError = err.Error()
+			q.writeRecord(record)
+			info = record.JobInfo
+			q.mu.Unlock()
+		}
+	}
+	if q.opts.OnComplete != nil {
+		q.opts.OnComplete(info)
+	}
+}
+
+// deliverCallback POSTs info to its callback URL.
+func (q *ProofJobQueue) deliverCallback(info JobInfo) error {
+	body, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	resp, err := q.opts.HTTPClient.Post(info.CallbackURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("callback failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("callback returned %s", resp.Status)
+	}
+	return nil
+}
+
+// writeRecord persists record; q.mu must be held.
+func (q *ProofJobQueue) writeRecord(record *jobRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return q.Store.writeEntry(string(record.ID)+jobFileExt, data, 0600)
+}
+
+// readRecord loads the job record stored under name.
+func (q *ProofJobQueue) readRecord(name string) (*jobRecord, error) {
+	data, err := q.Store.readEntry(name + jobFileExt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job %q: %w", name, err)
+	}
+	var record jobRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("job %q is corrupted: %w", name, err)
+	}
+	if record.ID != JobID(name) {
+		return nil, fmt.Errorf("job %q records ID %q", name, record.ID)
+	}
+	return &record, nil
+}
+
+// newJobID returns a random job ID, which is also a valid store name.
+func newJobID() (JobID, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+	return JobID(hex.EncodeToString(b[:])), nil
+}
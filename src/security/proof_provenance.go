@@ -0,0 +1,254 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// LibraryVersion is this release of the library, recorded in the
+// provenance of every proof it signs.
+const LibraryVersion = "0.4.0"
+
+// Protocol features a proof may use beyond those its format version
+// implies. ProofFeatures detects them from the proof itself.
+const (
+	FeatureClaims               = "claims"
+	FeatureTimeAttestation      = "time_attestation"
+	FeatureIdentifierPrivacy    = "identifier_privacy"
+	FeatureInteractiveSession   = "interactive_session"
+	FeatureDataEncoding         = "data_encoding"
+	FeatureChallengeBinding     = "challenge_binding"
+	FeatureLinkage              = "linkage"
+	FeatureFamilyWitness        = "family_witness"
+	FeatureRandomnessProvenance = "randomness_provenance"
+	FeatureCosignatures         = "cosignatures"
+	FeatureBatchSignature       = "batch_signature"
+	FeatureProvenance           = "provenance"
+)
+
+// ProofProvenance records which library release signed a proof and the
+// protocol features it used, so that a verifier elsewhere can tell
+// whether it is able to check the proof before trying.
+type ProofProvenance struct {
+	Library  string   `json:"library"`
+	Features []string `json:"features,omitempty"` // sorted; cosignatures and batch signatures come after signing
+}
+
+// LibraryRelease is one entry of the version matrix: a library release,
+// the proof versions it verifies and the features it understands.
+type LibraryRelease struct {
+	Version       string   `json:"version"`
+	ProofVersions []int    `json:"proof_versions"`
+	Features      []string `json:"features,omitempty"`
+}
+
+// libraryReleases is the version matrix, oldest release first.
+var libraryReleases = []LibraryRelease{
+	{Version: "0.1.0", ProofVersions: []int{ProofVersionLegacy}},
+	{
+		Version:       "0.2.0",
+		ProofVersions: []int{ProofVersionLegacy, ProofVersionUnboundParameters},
+		Features:      []string{FeatureClaims, FeatureTimeAttestation, FeatureIdentifierPrivacy},
+	},
+	{
+		Version:       "0.3.0",
+		ProofVersions: []int{ProofVersionLegacy, ProofVersionUnboundParameters, CurrentProofVersion},
+		Features: []string{FeatureClaims, FeatureTimeAttestation, FeatureIdentifierPrivacy,
+			FeatureInteractiveSession, FeatureDataEncoding, FeatureChallengeBinding},
+	},
+	{
+		Version:       LibraryVersion,
+		ProofVersions: []int{ProofVersionLegacy, ProofVersionUnboundParameters, CurrentProofVersion},
+		Features: []string{FeatureClaims, FeatureTimeAttestation, FeatureIdentifierPrivacy,
+			FeatureInteractiveSession, FeatureDataEncoding, FeatureChallengeBinding,
+			FeatureLinkage, FeatureFamilyWitness, FeatureRandomnessProvenance, FeatureCosignatures,
+			FeatureBatchSignature, FeatureProvenance},
+	},
+}
+
+// LibraryReleases returns the version matrix, oldest release first.
+func LibraryReleases() []LibraryRelease {
+	return slices.Clone(libraryReleases)
+}
+
+// Known issue severities, from most to least serious.
+const (
+	IssueCritical = "critical" // the proof does not establish what it claims
+	IssueWarning  = "warning"  // the proof is weaker than a current one
+	IssueInfo     = "info"
+)
+
+// KnownIssue is a documented weakness that applies to some proofs.
+type KnownIssue struct {
+	ID       string `json:"id"`
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+
+	applies func(proof *SecureProof) bool
+}
+
+// knownIssues are checked against every proof by CheckCompatibility.
+var knownIssues = []KnownIssue{
+	{
+		ID:       "pre-fiat-shamir",
+		Severity: IssueCritical,
+		Summary:  "challenges were chosen by the prover rather than derived from the commitment, so a cheating prover can pick challenges it can answer",
+		applies:  func(proof *SecureProof) bool { return proofVersion(proof) == ProofVersionLegacy },
+	},
+	{
+		ID:       "unbound-context",
+		Severity: IssueCritical,
+		Summary:  "proof is not bound to an application context and can be replayed to another application",
+		applies:  func(proof *SecureProof) bool { return proofVersion(proof) == ProofVersionLegacy },
+	},
+	{
+		ID:       "unbound-parameters",
+		Severity: IssueWarning,
+		Summary:  "public parameters are not signed, so a verifier cannot tell which it was made under",
+		applies:  func(proof *SecureProof) bool { return proofVersion(proof) < CurrentProofVersion },
+	},
+	{
+		ID:       "truncated-digests",
+		Severity: IssueWarning,
+		Summary:  "commitment and response digests are shorter than the soundness level calls for",
+		applies: func(proof *SecureProof) bool {
+			return !proof.digestLengths().AtLeast(DigestLengthsFor(proof.StateMetadata.SoundnessBits))
+		},
+	},
+	{
+		ID:       "unrecorded-provenance",
+		Severity: IssueInfo,
+		Summary:  "proof does not record the library release that made it",
+		applies:  func(proof *SecureProof) bool { return proof.Provenance == nil },
+	},
+	{
+		ID:       "provenance-mismatch",
+		Severity: IssueWarning,
+		Summary:  "features recorded in the provenance differ from those the proof uses",
+		applies: func(proof *SecureProof) bool {
+			return proof.Provenance != nil && !slices.Equal(proof.Provenance.Features, signedProofFeatures(proof))
+		},
+	},
+}
+
+// CompatibilityGap says why one library release cannot verify a proof.
+type CompatibilityGap struct {
+	Library string   `json:"library"`
+	Reasons []string `json:"reasons"`
+}
+
+// CompatibilityReport is the result of CheckCompatibility.
+type CompatibilityReport struct {
+	ProofVersion int      `json:"proof_version"`
+	Library      string   `json:"library,omitempty"` // the release that made the proof, when recorded
+	Features     []string `json:"features,omitempty"`
+	// Verifiers lists the releases able to verify the proof, oldest first
+	Verifiers []string           `json:"verifiers"`
+	Gaps      []CompatibilityGap `json:"gaps,omitempty"`
+	Issues    []KnownIssue       `json:"issues,omitempty"`
+}
+
+// Compatible reports whether the release with version can verify the
+// proof.
+func (r *CompatibilityReport) Compatible(version string) bool {
+	return slices.Contains(r.Verifiers, version)
+}
+
+// CheckCompatibility compares a proof against the version matrix: which
+// releases can verify it, why the others cannot, and which known issues
+// apply to it. The proof is not verified; the features are those it uses
+// together with any more its provenance records.
+func CheckCompatibility(proof *SecureProof) (*CompatibilityReport, error) {
+	if proof == nil {
+		return nil, errors.New("proof is nil")
+	}
+	report := &CompatibilityReport{ProofVersion: proofVersion(proof), Features: ProofFeatures(proof), Verifiers: []string{}}
+	if proof.Provenance != nil {
+		// Features recorded by a newer release may not be recognizable
+		// in the proof itself
+		report.Library = proof.Provenance.Library
+		for _, feature := range proof.Provenance.Features {
+			if !slices.Contains(report.Features, feature) {
+				report.Features = append(report.Features, feature)
+			}
+		}
+		slices.Sort(report.Features)
+	}
+
+	for _, release := range libraryReleases {
+		var reasons []string
+		if !slices.Contains(release.ProofVersions, report.ProofVersion) {
+			reasons = append(reasons, fmt.Sprintf("does not verify version %d proofs", report.ProofVersion))
+		}
+		for _, feature := range report.Features {
+			if !slices.Contains(release.Features, feature) {
+				reasons = append(reasons, "does not support "+feature)
+			}
+		}
+		if len(reasons) > 0 {
+			report.Gaps = append(report.Gaps, CompatibilityGap{Library: release.Version, Reasons: reasons})
+		} else {
+			report.Verifiers = append(report.Verifiers, release.Version)
+		}
+	}
+
+	for _, issue := range knownIssues {
+		if issue.applies(proof) {
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+	return report, nil
+}
+
+// ProofFeatures returns the protocol features proof uses, sorted.
+func ProofFeatures(proof *SecureProof) []string {
+	features := signedProofFeatures(proof)
+	if proof.Provenance != nil {
+		features = append(features, FeatureProvenance)
+	}
+	if len(proof.Cosignatures) > 0 {
+		features = append(features, FeatureCosignatures)
+	}
+	if proof.Batch != nil {
+		features = append(features, FeatureBatchSignature)
+	}
+	slices.Sort(features)
+	return features
+}
+
+// signedProofFeatures returns the sorted features a proof's provenance
+// records: all but the provenance itself and what is added after signing.
+func signedProofFeatures(proof *SecureProof) []string {
+	var features []string
+	add := func(feature string, used bool) {
+		if used {
+			features = append(features, feature)
+		}
+	}
+	add(FeatureClaims, len(proof.Claims) > 0)
+	add(FeatureTimeAttestation, proof.TimeAttestation != nil)
+	add(FeatureIdentifierPrivacy, proof.IdentifierScheme != "")
+	add(FeatureInteractiveSession, proof.Session != nil)
+	add(FeatureDataEncoding, proof.DataEncoding != nil)
+	add(FeatureChallengeBinding, proof.ChallengeBinding != "")
+	add(FeatureLinkage, proof.Linkage != nil)
+	add(FeatureFamilyWitness, proof.Witness != nil)
+	add(FeatureRandomnessProvenance, len(proof.Randomness) > 0)
+	slices.Sort(features)
+	return features
+}
+
+// newProofProvenance describes proof as signed by this release.
+func newProofProvenance(proof *SecureProof) *ProofProvenance {
+	return &ProofProvenance{Library: LibraryVersion, Features: signedProofFeatures(proof)}
+}
+
+// proofVersion returns the format version of proof, treating an unset
+// version as the legacy one as ParseSecureProof does.
+func proofVersion(proof *SecureProof) int {
+	if proof.Version == 0 {
+		return ProofVersionLegacy
+	}
+	return proof.Version
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Proof format versions. Version 1 is the original format, which had no
+// version field; any proof without one is treated as version 1.
+const (
+	ProofVersionLegacy            = 1
+	ProofVersionUnboundParameters = 2
+	CurrentProofVersion           = 3
+)
+
+// ProofFormat describes one supported proof format version.
+type ProofFormat struct {
+	Version     int
+	Description string
+	// Caveats lists the security properties this format lacks compared to
+	// the current one; they are surfaced in every VerificationResult
+	Caveats []string
+
+	validate func(proof *SecureProof) error
+	migrate  func(proof *SecureProof)
+	verify   func(sq *SecureQuantumZKP, proof *SecureProof, key []byte) bool
+}
+
+// proofFormats is the registry of format versions the parser accepts.
+var proofFormats = map[int]*ProofFormat{
+	ProofVersionLegacy: {
+		Version:     ProofVersionLegacy,
+		Description: "original format: random challenges, no context, decimal-text commitments",
+		Caveats: []string{
+			"challenges were chosen by the prover rather than derived from the commitment",
+			"proof is not bound to an application context",
+			"commitments use platform-dependent decimal text encoding",
+			"signature was made without an ML-DSA context string",
+			"digests are truncated to 16-byte commitments and 8-byte responses",
+		},
+		validate: validateProofStructure,
+		migrate: func(proof *SecureProof) {
+			proof.Version = ProofVersionLegacy
+			proof.HashSuite = HashSuiteSHA256
+			proof.SignatureAlgorithm = SignatureAlgorithmMLDSA87
+		},
+		verify: verifyLegacySecureProof,
+	},
+	ProofVersionUnboundParameters: {
+		Version:     ProofVersionUnboundParameters,
+		Description: "commitment-derived challenges, context binding, fixed-point encoding",
+		Caveats: []string{
+			"public parameters are not bound to the proof",
+			"digests are truncated to 16-byte commitments and 8-byte responses",
+		},
+		validate: validateProofStructure,
+		migrate:  func(proof *SecureProof) {},
+		verify:   verifyUnboundParametersProof,
+	},
+	CurrentProofVersion: {
+		Version:     CurrentProofVersion,
+		Description: "version 2 plus signed public parameters and configurable digest lengths",
+		validate:    validateProofStructure,
+		migrate:     func(proof *SecureProof) {},
+		verify: func(sq *SecureQuantumZKP, proof *SecureProof, key []byte) bool {
+			return sq.VerifySecureProof(proof, key)
+		},
+	},
+}
+
+// ProofFormats returns the supported formats in version order.
+func ProofFormats() []*ProofFormat {
+	formats := make([]*ProofFormat, 0, len(proofFormats))
+	for _, f := range proofFormats {
+		formats = append(formats, f)
+	}
+	sort.Slice(formats, func(i, j int) bool { return formats[i].Version < formats[j].Version })
+	return formats
+}
+
+// ProofJSONLimits bounds proofs, and requests carrying one, received
+// from untrusted sources. The largest proofs, at 256 bits of soundness
+// with every optional section, stay well within them.
+var ProofJSONLimits = JSONLimits{
+	MaxBytes:        4 << 20,
+	MaxDepth:        16,
+	MaxStringBytes:  16 << 10, // an ML-DSA-87 signature is 9,254 hex digits
+	MaxArrayLength:  4096,
+	MaxObjectFields: 64,
+}
+
+// ParseSecureProof decodes a JSON proof of any supported version, validates
+// its structure and migrates it to the in-memory representation.
+func ParseSecureProof(data []byte) (*SecureProof, error) {
+	return ReadSecureProof(bytes.NewReader(data))
+}
+
+// ReadSecureProof is ParseSecureProof for a stream. The proof is decoded
+// within ProofJSONLimits, failing with ErrJSONLimit as soon as it exceeds
+// them.
+func ReadSecureProof(r io.Reader) (*SecureProof, error) {
+	var proof SecureProof
+	if err := DecodeBoundedJSON(r, ProofJSONLimits, &proof); err != nil {
+		return nil, fmt.Errorf("failed to decode proof: %w", err)
+	}
+	if proof.Version == 0 {
+		proof.Version = ProofVersionLegacy
+	}
+
+	format, ok := proofFormats[proof.Version]
+	if !ok {
+		return nil, fmt.Errorf("unsupported proof version: %d", proof.Version)
+	}
+	if err := format.validate(&proof); err != nil {
+		return nil, fmt.Errorf("invalid version %d proof: %w", proof.Version, err)
+	}
+	format.migrate(&proof)
+	return &proof, nil
+}
+
+// VerificationResult is the outcome of version-aware verification.
+type VerificationResult struct {
+	Valid   bool     `json:"valid"`
+	Version int      `json:"version"`
+	Caveats []string `json:"caveats,omitempty"`
+	// Reasons lists why strict mode or a key set rejected the proof
+	Reasons []string `json:"reasons,omitempty"`
+	// Signers lists the key set keys that verified the proof
+	Signers []string `json:"signers,omitempty"`
+}
+
+// VerifySecureProofVersioned verifies a proof of any supported version and
+// reports the caveats of its format. Callers that cannot accept caveats
+// should require len(result.Caveats) == 0 or use VerifySecureProof.
+func (sq *SecureQuantumZKP) VerifySecureProofVersioned(proof *SecureProof, key []byte) *VerificationResult {
+	if proof == nil {
+		return &VerificationResult{}
+	}
+	version := proof.Version
+	if version == 0 {
+		version = ProofVersionLegacy
+	}
+	result := &VerificationResult{Version: version}
+	sq, arena := sq.withVerificationArena()
+	defer arena.release()
+
+	format, ok := proofFormats[version]
+	if !ok || format.validate(proof) != nil {
+		return result
+	}
+	result.Caveats = format.Caveats
+	if version != CurrentProofVersion {
+		if err := sq.Features.require(FeatureLegacyVerification); err != nil {
+			result.Reasons = append(result.Reasons, err.Error())
+			return result
+		}
+	}
+	sq, err := sq.epochVerifier(proof)
+	if err != nil {
+		result.Reasons = append(result.Reasons, err.Error())
+		return result
+	}
+	if err := sq.CheckChallengeConsistency(proof); err != nil {
+		result.Reasons = append(result.Reasons, err.Error())
+		return result
+	}
+	result.Valid = format.verify(sq, proof, key)
+	return result
+}
+
+// validateProofStructure checks the fields every format requires.
+func validateProofStructure(proof *SecureProof) error {
+	if len(proof.ChallengeResponse) == 0 {
+		return errors.New("no challenge responses")
+	}
+	a := getVerificationArena()
+	defer a.release()
+	if _, err := a.decodeDigest(proof.CommitmentHash, 1); err != nil {
+		return errors.New("malformed commitment hash")
+	}
+	if _, err := a.decodeDigest(proof.MerkleRoot, 1); err != nil {
+		return errors.New("malformed Merkle root")
+	}
+	if _, err := a.decodeSignature(proof.Signature); err != nil {
+		return errors.New("malformed signature")
+	}
+	return nil
+}
+
+// minResponseFieldLength is the shortest response, measurement commitment
+// or per-challenge proof digest any proof version published.
+const minResponseFieldLength = 4
+
+// decodeDigestField decodes a published digest of at least minLen bytes:
+// a commitment, response, Merkle root or MAC. Proof fields are decoded
+// with the field codecs (see DecodeHexField), whose timing does not depend
+// on their content.
+func decodeDigestField(s string, minLen int) ([]byte, error) {
+	return DecodeHexField(s, minLen, FullDigestLength)
+}
+
+// verifyUnboundParametersProof verifies a version 2 proof, which is the
+// current format without the parameters hash.
+func verifyUnboundParametersProof(sq *SecureQuantumZKP, proof *SecureProof, key []byte) bool {
+	if proof.ParametersHash != "" || proof.DigestLengths != nil || proof.ChallengeNonceLength != 0 || proof.DataEncoding != nil || proof.Session != nil ||
+		proof.Linkage != nil || proof.Cosignatures != nil || proof.Batch != nil || proof.Witness != nil || proof.Provenance != nil {
+		return false
+	}
+	if !sq.verifySecureProofSignature(proof) {
+		return false
+	}
+	return sq.verifySecureProofFields(proof, key) && sq.verifyDerivedChallenges(proof)
+}
+
+// secureProofV1 is the exact field layout signed by version 1 provers.
+type secureProofV1 struct {
+	QuantumDimensions int                 `json:"quantum_dimensions"`
+	CommitmentHash    string              `json:"commitment_hash"`
+	ChallengeResponse []ChallengeResponse `json:"challenge_response"`
+	MerkleRoot        string              `json:"merkle_root"`
+	StateMetadata     SecureStateMetadata `json:"state_metadata"`
+	Identifier        string              `json:"identifier"`
+	Signature         string              `json:"signature"`
+	Timestamp         time.Time           `json:"timestamp"`
+}
+
+// verifyLegacySecureProof runs the checks version 1 proofs support: the
+// signature over the v1 layout, the Merkle root, response structure and
+// metadata bounds.
+func verifyLegacySecureProof(sq *SecureQuantumZKP, proof *SecureProof, key []byte) bool {
+	v1 := secureProofV1{
+		QuantumDimensions: proof.QuantumDimensions,
+		CommitmentHash:    proof.CommitmentHash,
+		ChallengeResponse: proof.ChallengeResponse,
+		MerkleRoot:        proof.MerkleRoot,
+		StateMetadata:     proof.StateMetadata,
+		Identifier:        proof.Identifier,
+		Timestamp:         proof.Timestamp,
+	}
+	msg, err := json.Marshal(&v1)
+	if err != nil {
+		return false
+	}
+	sig, err := decodeSignatureField(proof.Signature)
+	if err != nil {
+		return false
+	}
+	legacySigner := *sq.Signer
+	legacySigner.Ctx = nil
+	if !legacySigner.Verify(msg, sig) {
+		return false
+	}
+
+	root, err := sq.generateMerkleRoot(proof.ChallengeResponse)
+	if err != nil || root != proof.MerkleRoot {
+		return false
+	}
+	for _, response := range proof.ChallengeResponse {
+		if response.MAC != "" || !sq.verifyChallengeResponse(response, key) {
+			return false
+		}
+	}
+	if !verifyDigestLengths(proof.CommitmentHash, proof.ChallengeResponse, LegacyDigestLengths) {
+		return false
+	}
+	return sq.verifyMetadataBounds(proof.StateMetadata)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+)
+
+// SecureProveFromValue generates a secure zero-knowledge proof of knowledge
+// of a structured value. The value is canonicalized with CanonicalizeValue
+// (sorted keys, `qzkp:"redact"` fields removed) and the resulting bytes are
+// proven exactly as SecureProveFromBytes would.
+func (sq *SecureQuantumZKP) SecureProveFromValue(
+	v interface{},
+	identifier string,
+	key []byte,
+) (*SecureProof, error) {
+	data, err := CanonicalizeValue(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize value: %w", err)
+	}
+
+	return sq.SecureProveFromBytes(data, identifier, key)
+}
+
+// VerifySecureProofFromValue verifies a proof that was generated from a
+// structured value. This is equivalent to VerifySecureProof but provides a
+// clearer API for value-based proofs.
+func (sq *SecureQuantumZKP) VerifySecureProofFromValue(
+	proof *SecureProof,
+	key []byte,
+) bool {
+	return sq.VerifySecureProof(proof, key)
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ServiceConfig configures a ProverService. Key is the hex-encoded proof
+// key shared by prover and verifier.
+type ServiceConfig struct {
+	Dimensions     int    `json:"dimensions"`
+	SecurityLevel  int    `json:"security_level"`
+	Application    string `json:"application"`
+	ContextVersion int    `json:"context_version"`
+	Key            string `json:"key"`
+	// NonceStore is the path of a FileNonceStore. When set, Verify
+	// refuses replayed proofs, across restarts, for DefaultReplayWindow.
+	// Changing it needs a restart.
+	NonceStore string `json:"nonce_store,omitempty"`
+	// InMemory guarantees the service never reaches the filesystem or the
+	// network, for sandboxed and serverless deployments. Settings that
+	// would, such as NonceStore, are rejected. Changing it needs a restart
+	InMemory bool `json:"in_memory,omitempty"`
+	// ChallengeNonceLength is the per-challenge nonce length in bytes,
+	// from MinChallengeNonceLength to MaxChallengeNonceLength, or
+	// LegacyChallengeNonceLength for verifiers of earlier releases;
+	// DefaultChallengeNonceLength if zero
+	ChallengeNonceLength int `json:"challenge_nonce_length,omitempty"`
+	// Features switches risky features on or off by name; features it
+	// does not mention are enabled. QZKP_FEATURES overrides it, see
+	// FeatureFlagsEnv
+	Features map[Feature]bool `json:"features,omitempty"`
+	// SchemaVersion is the ConfigSchema version of the file; files of
+	// older versions are upgraded when loaded
+	SchemaVersion int `json:"schema_version,omitempty"`
+}
+
+// LoadServiceConfig reads a JSON ServiceConfig from path, refusing files
+// written for a newer release with ErrSchemaTooNew.
+func LoadServiceConfig(path string) (ServiceConfig, error) {
+	var cfg ServiceConfig
+	data, err := hostFS.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config: %w", err)
+	}
+	if data, err = ConfigSchema.UpgradeJSON(data); err != nil {
+		return cfg, fmt.Errorf("failed to load config %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return cfg, nil
+}
+
+// ProveRequest asks the service to prove knowledge of Data.
+type ProveRequest struct {
+	Identifier string `json:"identifier"`
+	Data       []byte `json:"data"`
+}
+
+// ProveResponse carries the generated proof.
+type ProveResponse struct {
+	Proof *SecureProof `json:"proof"`
+}
+
+// VerifyRequest asks the service to verify Proof.
+type VerifyRequest struct {
+	Proof *SecureProof `json:"proof"`
+}
+
+// VerifyResponse reports the verification outcome.
+type VerifyResponse struct {
+	Valid bool `json:"valid"`
+}
+
+// FeatureStatus reports a service's feature settings and kill switch.
+type FeatureStatus struct {
+	Features   map[Feature]bool `json:"features"`
+	KillSwitch KillSwitchState  `json:"kill_switch"`
+}
+
+// ServiceInfo describes what clients need to pre-validate proofs locally.
+type ServiceInfo struct {
+	PublicKey string  `json:"public_key"` // hex-encoded ML-DSA-87 key
+	Context   Context `json:"context"`
+}
+
+// ProverService is the transport-independent proving and verification
+// layer used by the daemon and the network front ends.
+type ProverService struct {
+	Config  ServiceConfig
+	Metrics *ProgressMetrics // proof generation progress across requests
+	// Features holds the effective feature settings and KillSwitch stops
+	// Prove; verification is unaffected by either. Both are shared by the
+	// services that replace this one on reload
+	Features   *FeatureFlags
+	KillSwitch *KillSwitch
+	sq         *SecureQuantumZKP
+	key        []byte
+	nonces     NonceStore       // nil without replay protection
+	overrides  map[Feature]bool // feature settings layered over the config's; see applyFeatureOverrides
+	selfTest   *SelfTestResult  // run when the service was created
+}
+
+// NewProverService validates cfg and creates a service with a fresh
+// signing key.
+func NewProverService(cfg ServiceConfig) (*ProverService, error) {
+	return newProverService(cfg, nil)
+}
+
+// newProverService creates a service, reusing the signing key of previous
+// when its context is unchanged so proofs issued before a reload still
+// verify afterwards. Progress metrics, the nonce store, the feature flags
+// and the kill switch always carry over. A service whose self-test fails
+// is not created.
+func newProverService(cfg ServiceConfig, previous *ProverService) (*ProverService, error) {
+	key, err := hex.DecodeString(cfg.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encoding: %w", err)
+	}
+	if len(key) < 32 {
+		return nil, fmt.Errorf("key too short: %d bytes (minimum 32)", len(key))
+	}
+	if cfg.ContextVersion == 0 {
+		cfg.ContextVersion = DefaultContextVersion
+	}
+	proofContext, err := NewContext(cfg.Application, cfg.ContextVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	sq, err := NewSecureQuantumZKPWithContext(cfg.Dimensions, cfg.SecurityLevel, proofContext)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ChallengeNonceLength != 0 {
+		if err := validateChallengeNonceLength(cfg.ChallengeNonceLength); err != nil {
+			return nil, err
+		}
+		sq.ChallengeNonceLength = cfg.ChallengeNonceLength
+	}
+	var overrides map[Feature]bool
+	if previous != nil {
+		overrides = previous.overrides
+	}
+	settings, err := featureSettings(cfg.Features, overrides)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil && previous.sq.Context.Equal(proofContext) {
+		sq.Signer = previous.sq.Signer
+	}
+	metrics := NewProgressMetrics()
+	if previous != nil {
+		metrics = previous.Metrics
+	}
+	selfTest := sq.SelfTest()
+	if err := selfTest.Err(); err != nil {
+		return nil, err
+	}
+	sq.Progress = metrics.Record
+
+	var nonces NonceStore
+	if previous != nil {
+		nonces = previous.nonces
+		cfg.NonceStore = previous.Config.NonceStore
+		cfg.InMemory = previous.Config.InMemory
+	} else if cfg.InMemory && cfg.NonceStore != "" {
+		return nil, fmt.Errorf("%w: nonce_store needs the filesystem", ErrInMemory)
+	} else if cfg.NonceStore != "" {
+		if nonces, err = OpenFileNonceStore(cfg.NonceStore); err != nil {
+			return nil, fmt.Errorf("failed to open nonce store: %w", err)
+		}
+	}
+
+	sq.InMemory = cfg.InMemory
+
+	// The flags are shared with the running service, so they only change
+	// once nothing else can fail
+	features, killSwitch := &FeatureFlags{}, &KillSwitch{}
+	if previous != nil {
+		features, killSwitch = previous.Features, previous.KillSwitch
+	}
+	features.Set(settings)
+	sq.Features = features
+
+	return &ProverService{
+		Config: cfg, Metrics: metrics, Features: features, KillSwitch: killSwitch,
+		sq: sq, key: key, nonces: nonces, overrides: overrides, selfTest: selfTest,
+	}, nil
+}
+
+// applyFeatureOverrides layers overrides over the configured and
+// QZKP_FEATURES settings and applies the result. Services created from
+// s on reload keep the overrides.
+func (s *ProverService) applyFeatureOverrides(overrides map[Feature]bool) error {
+	settings, err := featureSettings(s.Config.Features, overrides)
+	if err != nil {
+		return err
+	}
+	if err := s.Features.Set(settings); err != nil {
+		return err
+	}
+	s.overrides = overrides
+	return nil
+}
+
+// Prove generates a secure proof for the request, failing with
+// ErrIssuanceDisabled while the kill switch is tripped.
+func (s *ProverService) Prove(req *ProveRequest) (*ProveResponse, error) {
+	if err := s.KillSwitch.check(); err != nil {
+		return nil, err
+	}
+	if req == nil || len(req.Data) == 0 {
+		return nil, errors.New("data cannot be empty")
+	}
+	if req.Identifier == "" {
+		return nil, errors.New("identifier cannot be empty")
+	}
+	proof, err := s.sq.SecureProveFromBytes(req.Data, req.Identifier, s.key)
+	if err != nil {
+		return nil, err
+	}
+	return &ProveResponse{Proof: proof}, nil
+}
+
+// Verify checks a proof produced by this service.
+func (s *ProverService) Verify(req *VerifyRequest) (*VerifyResponse, error) {
+	response, _, err := s.verify(req)
+	return response, err
+}
+
+// verify is Verify, also reporting whether a rejected proof was a replay.
+func (s *ProverService) verify(req *VerifyRequest) (*VerifyResponse, VerificationDecision, error) {
+	if req == nil || req.Proof == nil {
+		return nil, DecisionRejected, errors.New("proof cannot be empty")
+	}
+	if !s.sq.VerifySecureProof(req.Proof, s.key) {
+		return &VerifyResponse{Valid: false}, DecisionRejected, nil
+	}
+	if s.nonces != nil && s.sq.consumeProofNonce(req.Proof, s.nonces, DefaultReplayWindow) != nil {
+		return &VerifyResponse{Valid: false}, DecisionReplayed, nil
+	}
+	return &VerifyResponse{Valid: true}, DecisionAccepted, nil
+}
+
+// Info returns the service's public verification parameters.
+func (s *ProverService) Info() *ServiceInfo {
+	return &ServiceInfo{
+		PublicKey: hex.EncodeToString(s.sq.Signer.PublicKeyBytes()),
+		Context:   s.sq.Context,
+	}
+}
+
+// FeatureStatus returns the service's feature settings and the state of
+// its kill switch.
+func (s *ProverService) FeatureStatus() *FeatureStatus {
+	return &FeatureStatus{Features: s.Features.Settings(), KillSwitch: s.KillSwitch.State()}
+}
+
+// SelfTest returns the result of the prover self-test the service ran
+// when it was created, at startup or on reload; see
+// SecureQuantumZKP.SelfTest.
+func (s *ProverService) SelfTest() *SelfTestResult {
+	return s.selfTest
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Randomness provenance classes recorded in proofs.
+const (
+	RandomnessKyberStream = "kyber-blake2xb" // QuantumSafeRandomReader
+	RandomnessQRNG        = "qrng"           // an external quantum random number generator
+	RandomnessSystem      = "system"         // crypto/rand
+	RandomnessCaller      = "caller"         // sq.Rand, e.g. a deterministic stream
+	RandomnessHybrid      = "hybrid"         // HybridRandomGenerator: the Kyber stream XORed with crypto/rand
+)
+
+// MaxRandomnessClasses bounds the provenance classes a proof may record.
+const MaxRandomnessClasses = 8
+
+// randomnessRecheckBytes is how many bytes a source serves between health
+// tests.
+const randomnessRecheckBytes = 1 << 20
+
+// ErrNoHealthyRandomness is returned when every source of a
+// RandomnessChain has failed.
+var ErrNoHealthyRandomness = errors.New("no healthy randomness source")
+
+// RandomnessSource is one entry of a RandomnessChain.
+type RandomnessSource struct {
+	Class  string // provenance class recorded in proofs
+	Reader io.Reader
+}
+
+// RandomnessSourceStatus reports the health of one source of a chain.
+type RandomnessSourceStatus struct {
+	Class   string `json:"class"`
+	Healthy bool   `json:"healthy"`
+	Served  int64  `json:"served_bytes"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RandomnessChain serves randomness from the first healthy source of an
+// ordered list, so a prover degrades predictably when a preferred source
+// is unavailable. A source is health tested (see checkEntropyHealth) on a
+// sample before it first serves, and again every randomnessRecheckBytes;
+// a source whose read or health test fails is skipped from then on, until
+// Reset. A RandomnessChain is safe for concurrent use.
+type RandomnessChain struct {
+	mu      sync.Mutex
+	sources []*chainedSource
+}
+
+// chainedSource is a source and its health.
+type chainedSource struct {
+	RandomnessSource
+	served      int64 // bytes served in total
+	sinceTested int64 // bytes served since the last health test; -1 before the first
+	err         error // why the source was taken out of the chain
+}
+
+// NewRandomnessChain creates a chain trying sources in order.
+func NewRandomnessChain(sources ...RandomnessSource) (*RandomnessChain, error) {
+	if len(sources) == 0 {
+		return nil, errors.New("randomness chain needs at least one source")
+	}
+	if len(sources) > MaxRandomnessClasses {
+		return nil, fmt.Errorf("%d randomness sources exceed the limit of %d", len(sources), MaxRandomnessClasses)
+	}
+	c := &RandomnessChain{}
+	for i, source := range sources {
+		if source.Reader == nil {
+			return nil, fmt.Errorf("randomness source %d has no reader", i)
+		}
+		if err := validateRandomnessClass(source.Class); err != nil {
+			return nil, fmt.Errorf("randomness source %d: %w", i, err)
+		}
+		c.sources = append(c.sources, &chainedSource{RandomnessSource: source, sinceTested: -1})
+	}
+	return c, nil
+}
+
+// SystemRandomnessSource is the operating system RNG, the usual last
+// entry of a chain.
+func SystemRandomnessSource() RandomnessSource {
+	return RandomnessSource{Class: RandomnessSystem, Reader: rand.Reader}
+}
+
+// read fills b from the first healthy source and returns its class.
+func (c *RandomnessChain) read(b []byte) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var failures []string
+	for _, s := range c.sources {
+		if s.err == nil && (s.sinceTested < 0 || s.sinceTested >= randomnessRecheckBytes) {
+			s.err = s.healthTest()
+		}
+		if s.err == nil {
+			if _, err := io.ReadFull(s.Reader, b); err != nil {
+				s.err = fmt.Errorf("read failed: %w", err)
+			}
+		}
+		if s.err != nil {
+			failures = append(failures, s.Class+": "+s.err.Error())
+			continue
+		}
+		s.served += int64(len(b))
+		s.sinceTested += int64(len(b))
+		return s.Class, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrNoHealthyRandomness, strings.Join(failures, "; "))
+}
+
+// healthTest reads a sample from the source and runs the entropy health
+// tests over it.
+func (s *chainedSource) healthTest() error {
+	sample := make([]byte, entropySampleBytes)
+	if _, err := io.ReadFull(s.Reader, sample); err != nil {
+		return fmt.Errorf("read failed: %w", err)
+	}
+	if err := checkEntropyHealth(sample); err != nil {
+		return err
+	}
+	s.sinceTested = 0
+	return nil
+}
+
+// Status reports every source in chain order.
+func (c *RandomnessChain) Status() []RandomnessSourceStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := make([]RandomnessSourceStatus, len(c.sources))
+	for i, s := range c.sources {
+		status[i] = RandomnessSourceStatus{Class: s.Class, Healthy: s.err == nil, Served: s.served}
+		if s.err != nil {
+			status[i].Error = s.err.Error()
+		}
+	}
+	return status
+}
+
+// Reset returns every failed source to the chain; each is health tested
+// again before it serves.
+func (c *RandomnessChain) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range c.sources {
+		s.err, s.sinceTested = nil, -1
+	}
+}
+
+// randomnessTrace collects the provenance classes of the randomness read
+// for one proof, or one batch, in the order they were first used.
+type randomnessTrace struct {
+	mu      sync.Mutex
+	classes []string
+}
+
+func (t *randomnessTrace) add(class string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, c := range t.classes {
+		if c == class {
+			return
+		}
+	}
+	t.classes = append(t.classes, class)
+}
+
+// Classes returns the classes recorded so far.
+func (t *randomnessTrace) Classes() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.classes...)
+}
+
+// withRandomnessTrace returns a copy of sq that records the class of every
+// randomness read in the returned trace.
+func (sq *SecureQuantumZKP) withRandomnessTrace() (*SecureQuantumZKP, *randomnessTrace) {
+	copied := *sq
+	copied.randomnessTrace = &randomnessTrace{}
+	return &copied, copied.randomnessTrace
+}
+
+// readRandomClass fills b from sq.Rand, sq.Randomness, sq.HybridRandom or
+// the system RNG, in that order of precedence, and returns the provenance
+// class used.
+func (sq *SecureQuantumZKP) readRandomClass(b []byte) (string, error) {
+	if sq.Rand != nil {
+		_, err := io.ReadFull(sq.Rand, b)
+		return RandomnessCaller, err
+	}
+	if sq.Randomness != nil {
+		return sq.Randomness.read(b)
+	}
+	if sq.HybridRandom != nil {
+		_, err := io.ReadFull(sq.HybridRandom, b)
+		return RandomnessHybrid, err
+	}
+	_, err := rand.Read(b)
+	return RandomnessSystem, err
+}
+
+// randomReader reads from sq's randomness source through readRandom, for
+// functions that take an io.Reader.
+type randomReader struct {
+	sq *SecureQuantumZKP
+}
+
+func (r randomReader) Read(b []byte) (int, error) {
+	if err := r.sq.readRandom(b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// validateRandomnessClass checks that class can be recorded in a proof.
+func validateRandomnessClass(class string) error {
+	if class == "" || len(class) > MaxClaimKeyLength || strings.Trim(class, claimKeyCharset) != "" {
+		return fmt.Errorf("randomness class %q must be 1 to %d characters of %q", class, MaxClaimKeyLength, claimKeyCharset)
+	}
+	return nil
+}
+
+// validateRandomnessClasses checks the provenance classes of a proof.
+func validateRandomnessClasses(classes []string) error {
+	if len(classes) > MaxRandomnessClasses {
+		return fmt.Errorf("%d randomness classes exceed the limit of %d", len(classes), MaxRandomnessClasses)
+	}
+	seen := make(map[string]bool, len(classes))
+	for _, class := range classes {
+		if err := validateRandomnessClass(class); err != nil {
+			return err
+		}
+		if seen[class] {
+			return fmt.Errorf("randomness class %q is recorded twice", class)
+		}
+		seen[class] = true
+	}
+	return nil
+}
+
+// AllowedRandomness requires the proof to record its randomness
+// provenance, using only the given classes; for example, allowing only
+// RandomnessKyberStream and RandomnessQRNG rejects system-only proofs.
+func AllowedRandomness(classes ...string) Rule {
+	return parameterizedRule("allowed_randomness", classes, func(proof *SecureProof) error {
+		if len(proof.Randomness) == 0 {
+			return errors.New("proof does not record its randomness provenance")
+		}
+		for _, class := range proof.Randomness {
+			if err := requireOneOf("randomness class", class, classes); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
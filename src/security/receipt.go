@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Domain separators for receipts, so a receipt signature can never be
+// confused with a proof signature made by the same key.
+const (
+	proofDigestDomain = "qzkp/proof-digest/v1"
+	receiptDomain     = "qzkp/verification-receipt/v1"
+)
+
+// Receipt decisions.
+const (
+	ReceiptAccepted = "accepted"
+	ReceiptRejected = "rejected"
+)
+
+// Receipt is a verifier's signed statement that it checked a proof and
+// what it decided. The prover can store it and present it, with the proof,
+// to third parties who trust the verifier's public key.
+type Receipt struct {
+	ProofDigest string    `json:"proof_digest"`
+	Profile     string    `json:"profile,omitempty"`
+	Context     Context   `json:"context"`
+	Decision    string    `json:"decision"`
+	VerifiedAt  time.Time `json:"verified_at"`
+	Verifier    string    `json:"verifier"` // SHA-256 fingerprint of the verifier's public key
+	Signature   string    `json:"signature"`
+}
+
+// ProofDigest returns the SHA-256 digest of the canonical encoding of
+// proof, including its signature.
+func ProofDigest(proof *SecureProof) (string, error) {
+	if proof == nil {
+		return "", errors.New("proof cannot be nil")
+	}
+	canonical, err := CanonicalizeValue(proof)
+	if err != nil {
+		return "", fmt.Errorf("failed to canonicalize proof: %w", err)
+	}
+	hasher := sha256.New()
+	hasher.Write([]byte(proofDigestDomain))
+	hasher.Write(canonical)
+	return EncodeHexField(hasher.Sum(nil)), nil
+}
+
+// verifierFingerprint identifies a verifier key in receipts without
+// embedding the full ML-DSA public key.
+func verifierFingerprint(verifier *SignatureScheme) string {
+	return KeyFingerprint(verifier.PublicKeyBytes())
+}
+
+// signingMessage is the byte string the verifier signs.
+func (r *Receipt) signingMessage() ([]byte, error) {
+	unsigned := *r
+	unsigned.Signature = ""
+	canonical, err := CanonicalizeValue(&unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize receipt: %w", err)
+	}
+	return append([]byte(receiptDomain), canonical...), nil
+}
+
+// IssueReceipt verifies proof and returns a receipt for the decision signed
+// with verifierKey. Rejections are receipted too, so a verifier can attest
+// to either outcome; callers that only hand out acceptances should check
+// Decision.
+func (sq *SecureQuantumZKP) IssueReceipt(proof *SecureProof, key []byte, verifierKey *SignatureScheme) (*Receipt, error) {
+	if verifierKey == nil || verifierKey.Priv == nil {
+		return nil, errors.New("verifier signing key is required")
+	}
+	digest, err := ProofDigest(proof)
+	if err != nil {
+		return nil, err
+	}
+
+	decision := ReceiptRejected
+	if sq.VerifySecureProof(proof, key) {
+		decision = ReceiptAccepted
+	}
+	receipt := &Receipt{
+		ProofDigest: digest,
+		Profile:     proof.Profile,
+		Context:     proof.Context,
+		Decision:    decision,
+		VerifiedAt:  time.Now().UTC(),
+		Verifier:    verifierFingerprint(verifierKey),
+	}
+
+	msg, err := receipt.signingMessage()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := verifierKey.Sign(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign receipt: %w", err)
+	}
+	receipt.Signature = EncodeHexField(sig)
+	return receipt, nil
+}
+
+// VerifyReceipt checks that receipt was signed by verifier and, when proof
+// is given, that it refers to that proof. It does not re-verify the proof
+// itself; that is what the receipt vouches for.
+func VerifyReceipt(receipt *Receipt, proof *SecureProof, verifier *SignatureScheme) error {
+	if receipt == nil || verifier == nil {
+		return errors.New("receipt and verifier key are required")
+	}
+	if receipt.Decision != ReceiptAccepted && receipt.Decision != ReceiptRejected {
+		return fmt.Errorf("unknown receipt decision %q", receipt.Decision)
+	}
+	if receipt.Verifier != verifierFingerprint(verifier) {
+		return errors.New("receipt was issued by a different verifier")
+	}
+
+	msg, err := receipt.signingMessage()
+	if err != nil {
+		return err
+	}
+	sig, err := decodeSignatureField(receipt.Signature)
+	if err != nil {
+		return fmt.Errorf("receipt signature: %w", err)
+	}
+	if !verifier.Verify(msg, sig) {
+		return errors.New("receipt signature is invalid")
+	}
+
+	if proof != nil {
+		digest, err := ProofDigest(proof)
+		if err != nil {
+			return err
+		}
+		if digest != receipt.ProofDigest {
+			return errors.New("receipt does not refer to this proof")
+		}
+	}
+	return nil
+}
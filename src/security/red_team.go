@@ -0,0 +1,466 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	mrand "math/rand"
+	"strings"
+	"sync"
+)
+
+// Red-team strategies: the ways a malicious prover holding the signing key
+// tries to get a proof accepted for something it does not know.
+const (
+	RedTeamWrongState        = "wrong_state"
+	RedTeamMixedProofKeys    = "mixed_proof_keys"
+	RedTeamChosenChallenges  = "chosen_challenges"
+	RedTeamMerkleCorruption  = "merkle_leaf_corruption"
+	RedTeamForeignSigningKey = "foreign_signing_key"
+	RedTeamNonceReuse        = "nonce_reuse"
+	RedTeamSessionSplice     = "session_splice"
+	RedTeamChallengeRace     = "challenge_race"
+)
+
+// redTeamIdentifier is the identifier red-team proofs are made for.
+const redTeamIdentifier = "red-team"
+
+// redTeamDimension is the dimension of red-team states.
+const redTeamDimension = 8
+
+// redTeamRacers is how many concurrent challenge requests a racing prover
+// sends to one session.
+const redTeamRacers = 8
+
+// redTeamStrategy is one malicious prover. attempt makes one try and
+// reports whether the verifier accepted it.
+type redTeamStrategy struct {
+	name        string
+	description string
+	// detectable reports whether v is expected to reject every attempt;
+	// nil means it always is
+	detectable func(v *Verifier) bool
+	attempt    func(rt *redTeamRun, trial int) (bool, error)
+}
+
+// redTeamStrategies are the built-in malicious provers.
+var redTeamStrategies = []redTeamStrategy{
+	{RedTeamWrongState, "commits to one state and answers the challenges from another",
+		func(*Verifier) bool { return false },
+		func(rt *redTeamRun, _ int) (bool, error) {
+			committed, err := rt.state()
+			if err != nil {
+				return false, err
+			}
+			answered, err := rt.state()
+			if err != nil {
+				return false, err
+			}
+			p, err := rt.forge(committed, answered, rt.key, nil)
+			if err != nil {
+				return false, err
+			}
+			return rt.accepts(p), nil
+		}},
+	{RedTeamMixedProofKeys, "commits under the proof key and answers under another",
+		func(*Verifier) bool { return false },
+		func(rt *redTeamRun, _ int) (bool, error) {
+			other := make([]byte, 32)
+			if _, err := rand.Read(other); err != nil {
+				return false, err
+			}
+			state, err := rt.state()
+			if err != nil {
+				return false, err
+			}
+			p, err := rt.forge(state, state, other, nil)
+			if err != nil {
+				return false, err
+			}
+			return rt.accepts(p), nil
+		}},
+	{RedTeamChosenChallenges, "answers challenges of its own choosing instead of those derived from the commitment",
+		nil,
+		func(rt *redTeamRun, trial int) (bool, error) {
+			// Distinct indices and computational-basis measurements, so
+			// only the derivation can give the choice away
+			choose := func(derived []Challenge) []Challenge {
+				chosen := make([]Challenge, len(derived))
+				for i, c := range derived {
+					chosen[i] = Challenge{Index: (i + trial) % redTeamDimension, BasisType: basisZ, Nonce: c.Nonce}
+				}
+				return chosen
+			}
+			state, err := rt.state()
+			if err != nil {
+				return false, err
+			}
+			p, err := rt.forge(state, state, rt.key, choose)
+			if err != nil {
+				return false, err
+			}
+			return rt.accepts(p), nil
+		}},
+	{RedTeamMerkleCorruption, "alters one response after the fact and re-signs, recomputing the Merkle root on odd trials",
+		nil,
+		func(rt *redTeamRun, trial int) (bool, error) {
+			p, err := rt.prove(rt.prover)
+			if err != nil {
+				return false, err
+			}
+			leaf := &p.ChallengeResponse[trial%len(p.ChallengeResponse)]
+			leaf.Response = flipHexDigit(leaf.Response, trial)
+			if trial%2 == 1 {
+				if err := remerkle(rt.prover, p); err != nil {
+					return false, err
+				}
+			}
+			if err := rt.prover.signSecureProof(p, rt.key); err != nil {
+				return false, err
+			}
+			return rt.accepts(p), nil
+		}},
+	{RedTeamForeignSigningKey, "signs an otherwise valid proof with a signing key the verifier does not trust",
+		nil,
+		func(rt *redTeamRun, _ int) (bool, error) {
+			signer, err := NewSignatureScheme(rt.prover.Signer.Ctx)
+			if err != nil {
+				return false, err
+			}
+			p, err := rt.prove(rt.prover.withSigner(signer))
+			if err != nil {
+				return false, err
+			}
+			return rt.accepts(p), nil
+		}},
+	{RedTeamNonceReuse, "proves the same state twice with a stuck nonce source, presenting the same commitment again",
+		func(v *Verifier) bool { return v.options.NonceStore != nil },
+		func(rt *redTeamRun, _ int) (bool, error) {
+			stuck := *rt.prover
+			stuck.Rand, stuck.Randomness = stuckReader{}, nil
+			state, err := rt.state()
+			if err != nil {
+				return false, err
+			}
+			first, err := stuck.SecureProveState(state, redTeamIdentifier, rt.key)
+			if err != nil {
+				return false, err
+			}
+			second, err := stuck.SecureProveState(state, redTeamIdentifier, rt.key)
+			if err != nil {
+				return false, err
+			}
+			return rt.accepts(first, second), nil
+		}},
+	{RedTeamSessionSplice, "answers an interactive session with the proof of another session",
+		nil,
+		func(rt *redTeamRun, _ int) (bool, error) {
+			state, err := rt.state()
+			if err != nil {
+				return false, err
+			}
+			sessions := make([]*VerifierSession, 2)
+			proofs := make([]*SecureProof, 2)
+			for i := range sessions {
+				vs, ps, challenges, err := rt.openSession(state)
+				if err != nil {
+					return false, err
+				}
+				if proofs[i], err = ps.Respond(challenges); err != nil {
+					return false, err
+				}
+				sessions[i] = vs
+			}
+			return sessions[1].Verify(proofs[0], rt.key), nil
+		}},
+	{RedTeamChallengeRace, fmt.Sprintf("requests challenges for one commitment %d times concurrently to pick the easiest set", redTeamRacers),
+		nil,
+		func(rt *redTeamRun, _ int) (bool, error) {
+			state, err := rt.state()
+			if err != nil {
+				return false, err
+			}
+			vs, err := rt.prover.NewVerifierSession()
+			if err != nil {
+				return false, err
+			}
+			ps, err := rt.prover.NewProverSessionForState(vs.Offer(), state, redTeamIdentifier, rt.key)
+			if err != nil {
+				return false, err
+			}
+			commitment, err := ps.Commit()
+			if err != nil {
+				return false, err
+			}
+			var wg sync.WaitGroup
+			issued := make(chan []Challenge, redTeamRacers)
+			for range redTeamRacers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if challenges, err := vs.Challenge(commitment); err == nil {
+						issued <- challenges
+					}
+				}()
+			}
+			wg.Wait()
+			close(issued)
+			// More than one set lets the prover answer whichever suits it
+			if len(issued) != 1 {
+				return len(issued) > 1, nil
+			}
+			p, err := ps.Respond(<-issued)
+			if err != nil {
+				return false, err
+			}
+			// The one issued set is answered honestly, which is no attack
+			if !vs.Verify(p, rt.key) {
+				return false, errors.New("honest answer to the only challenge set was rejected")
+			}
+			return false, nil
+		}},
+}
+
+// RedTeamStrategies lists the malicious provers RunRedTeam can run.
+func RedTeamStrategies() []string {
+	names := make([]string, len(redTeamStrategies))
+	for i, s := range redTeamStrategies {
+		names[i] = s.name
+	}
+	return names
+}
+
+// RedTeamOptions configures RunRedTeam.
+type RedTeamOptions struct {
+	Trials     int      // attempts per strategy; 16 if zero
+	Strategies []string // all of RedTeamStrategies if empty
+}
+
+// RedTeamResult is the outcome of one strategy. Detectable strategies
+// pass only if no attempt was accepted; the others are measured and
+// reported but cannot fail.
+type RedTeamResult struct {
+	Name           string  `json:"name"`
+	Description    string  `json:"description"`
+	Detectable     bool    `json:"detectable"`
+	Trials         int     `json:"trials"`
+	Accepted       int     `json:"accepted"`
+	AcceptanceRate float64 `json:"acceptance_rate"`
+	Passed         bool    `json:"passed"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// RedTeamReport is the outcome of RunRedTeam. Passed is true only if
+// every strategy passed.
+type RedTeamReport struct {
+	Passed     bool            `json:"passed"`
+	Strategies []RedTeamResult `json:"strategies"`
+}
+
+// Err returns nil if every strategy passed and otherwise an error naming
+// the strategies that got proofs accepted or could not be run.
+func (r *RedTeamReport) Err() error {
+	if r.Passed {
+		return nil
+	}
+	var failed []string
+	for _, s := range r.Strategies {
+		switch {
+		case s.Error != "":
+			failed = append(failed, s.Name+": "+s.Error)
+		case !s.Passed:
+			failed = append(failed, fmt.Sprintf("%s: %d of %d attempts accepted", s.Name, s.Accepted, s.Trials))
+		}
+	}
+	return fmt.Errorf("red team got past the verifier: %s", strings.Join(failed, "; "))
+}
+
+// RunRedTeam runs malicious provers against v and reports how often each
+// got a proof accepted. Like RunVerifierConformance it uses an ephemeral
+// signing key standing in for v's, and v's parameters, policy and options
+// as they are; every attempt gets a fresh nonce store when v has one.
+//
+// Strategies the protocol can catch must never succeed. The non-interactive
+// verifier checks that responses are well formed, derived from the
+// commitment and bound together, but cannot open them, so a prover that
+// answers from the wrong state or under the wrong proof key is not
+// detectable. Those strategies are run to measure that gap: a verifier
+// change that starts rejecting them shows in their acceptance rate, and
+// one that admits a detectable strategy fails the report.
+func RunRedTeam(v *Verifier, opts RedTeamOptions) (*RedTeamReport, error) {
+	trials := opts.Trials
+	if trials == 0 {
+		trials = 16
+	}
+	if trials < 0 {
+		return nil, fmt.Errorf("negative trial count %d", trials)
+	}
+	strategies := redTeamStrategies
+	if len(opts.Strategies) > 0 {
+		strategies = nil
+		for _, name := range opts.Strategies {
+			i := 0
+			for i < len(redTeamStrategies) && redTeamStrategies[i].name != name {
+				i++
+			}
+			if i == len(redTeamStrategies) {
+				return nil, fmt.Errorf("unknown red-team strategy %q", name)
+			}
+			strategies = append(strategies, redTeamStrategies[i])
+		}
+	}
+
+	test, prover, err := v.conformanceCopy()
+	if err != nil {
+		return nil, err
+	}
+	rt := &redTeamRun{verifier: test, prover: prover, key: make([]byte, 32)}
+	if _, err := rand.Read(rt.key); err != nil {
+		return nil, err
+	}
+	// Rejections only mean something if honest proofs are accepted
+	control, err := rt.prove(prover)
+	if err != nil {
+		return nil, err
+	}
+	if !rt.accepts(control) {
+		return nil, errors.New("verifier rejects honest proofs; red-team results would be meaningless")
+	}
+
+	report := &RedTeamReport{Passed: true}
+	for _, s := range strategies {
+		result := RedTeamResult{Name: s.name, Description: s.description, Detectable: s.detectable == nil || s.detectable(v)}
+		for trial := range trials {
+			accepted, err := s.attempt(rt, trial)
+			if err != nil {
+				result.Error = fmt.Sprintf("trial %d: %v", trial, err)
+				break
+			}
+			result.Trials++
+			if accepted {
+				result.Accepted++
+			}
+		}
+		if result.Trials > 0 {
+			result.AcceptanceRate = float64(result.Accepted) / float64(result.Trials)
+		}
+		result.Passed = result.Error == "" && (!result.Detectable || result.Accepted == 0)
+		report.Passed = report.Passed && result.Passed
+		report.Strategies = append(report.Strategies, result)
+	}
+	return report, nil
+}
+
+// redTeamRun is the verifier and prover strategies run against.
+type redTeamRun struct {
+	verifier *Verifier
+	prover   *SecureQuantumZKP // signs with the key the verifier trusts
+	key      []byte
+}
+
+// state returns a fresh random state to prove. No amplitude is zero.
+func (rt *redTeamRun) state() (*StateVector, error) {
+	amplitudes := make([]complex128, redTeamDimension)
+	for i := range amplitudes {
+		amplitudes[i] = complex(0.5+mrand.Float64(), mrand.Float64())
+	}
+	return NewStateVector(amplitudes)
+}
+
+// prove makes an honest proof of a fresh state with sq.
+func (rt *redTeamRun) prove(sq *SecureQuantumZKP) (*SecureProof, error) {
+	state, err := rt.state()
+	if err != nil {
+		return nil, err
+	}
+	return sq.SecureProveState(state, redTeamIdentifier, rt.key)
+}
+
+// accepts reports whether the verifier accepts every proof, presented in
+// order with a fresh nonce store.
+func (rt *redTeamRun) accepts(proofs ...*SecureProof) bool {
+	if rt.verifier.options.NonceStore != nil {
+		rt.verifier.options.NonceStore = NewMemoryNonceStore()
+	}
+	for _, p := range proofs {
+		if !rt.verifier.Verify(p, rt.key).Valid {
+			return false
+		}
+	}
+	return true
+}
+
+// forge makes a signed proof whose commitment is to committed under
+// rt.key, but whose responses answer from answered under answerKey. When
+// choose is set, it replaces the challenges derived from the commitment.
+func (rt *redTeamRun) forge(committed, answered *StateVector, answerKey []byte, choose func([]Challenge) []Challenge) (*SecureProof, error) {
+	sq := rt.prover
+	published, err := sq.publishIdentifier(redTeamIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := sq.prepareSecureProof(committed, redTeamIdentifier, rt.key, published)
+	if err != nil {
+		return nil, err
+	}
+	if choose != nil {
+		pending.challenges = choose(pending.challenges)
+	}
+	batch := &MeasurementBatch{States: [][]complex128{answered.amplitudes}}
+	if pending.challenges, err = sq.addMeasurementRequests(batch, 0, pending.challenges); err != nil {
+		return nil, err
+	}
+	measurements, err := CPUMeasurementBackend{}.Measure(batch)
+	if err != nil {
+		return nil, err
+	}
+	p, err := sq.finishSecureProof(pending, measurements, answerKey)
+	if err != nil {
+		return nil, err
+	}
+	return p, sq.signSecureProof(p, rt.key)
+}
+
+// openSession runs an interactive session up to the challenges, returning
+// both sides and the challenges issued.
+func (rt *redTeamRun) openSession(state *StateVector) (*VerifierSession, *ProverSession, []Challenge, error) {
+	vs, err := rt.prover.NewVerifierSession()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ps, err := rt.prover.NewProverSessionForState(vs.Offer(), state, redTeamIdentifier, rt.key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	commitment, err := ps.Commit()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	challenges, err := vs.Challenge(commitment)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return vs, ps, challenges, nil
+}
+
+// flipHexDigit changes the digit of hex string s at position i, modulo
+// its length, to another hex digit.
+func flipHexDigit(s string, i int) string {
+	b := []byte(s)
+	i %= len(b)
+	if b[i] == '0' {
+		b[i] = '1'
+	} else {
+		b[i] = '0'
+	}
+	return string(b)
+}
+
+// stuckReader is a randomness source that only ever returns zeros.
+type stuckReader struct{}
+
+// Read implements io.Reader.
+func (stuckReader) Read(b []byte) (int, error) {
+	clear(b)
+	return len(b), nil
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"lukechampine.com/blake3"
+)
+
+// reproductionDomain separates the nonce stream of reproducible proofs
+// from other blake3 streams.
+const reproductionDomain = "qzkp/reproduce/v1"
+
+// ReproductionLibraryVersion identifies the proof format and byte encoder a
+// reproducible proof depends on; a manifest written under another one
+// cannot be expected to reproduce byte for byte.
+var ReproductionLibraryVersion = fmt.Sprintf("proof-v%d/bytes-encoder-v%d", CurrentProofVersion, BytesEncoderVersion)
+
+// Reproduction stages, in the order ReproduceProof checks them.
+const (
+	StageLibrary       = "library"
+	StageInput         = "input"
+	StageKeys          = "keys"
+	StageArtifact      = "artifact"
+	StageRegenerate    = "regenerate"
+	StageParameters    = "parameters"
+	StageEncoding      = "encoding"
+	StageCommitment    = "commitment"
+	StageChallenges    = "challenges"
+	StageResponses     = "responses"
+	StageSignature     = "signature"
+	StageSerialization = "serialization"
+)
+
+// ReproductionConfig is the prover configuration profile of a
+// reproducible proof.
+type ReproductionConfig struct {
+	Dimensions        int    `json:"dimensions"`
+	SecurityLevel     int    `json:"security_level"`
+	SoundnessBits     int    `json:"soundness_bits"`
+	Application       string `json:"application"`
+	Profile           string `json:"profile,omitempty"`
+	EncodingDimension int    `json:"encoding_dimension,omitempty"` // see BytesProofOptions.Dimension
+	Codec             string `json:"codec,omitempty"`              // artifact encoding; "json" if empty
+}
+
+// ReproductionManifest records everything besides the secrets that a
+// deterministic proof was produced from, so that an auditor given the
+// secrets can regenerate it and compare it with a published artifact.
+type ReproductionManifest struct {
+	LibraryVersion string             `json:"library_version"`
+	Config         ReproductionConfig `json:"config"`
+	Identifier     string             `json:"identifier"`
+	DataDigest     string             `json:"data_digest"`     // SHA-256 of the witness bytes, hex
+	KeyFingerprint string             `json:"key_fingerprint"` // of the ML-DSA public key; see KeyFingerprint
+	ProofKeyDigest string             `json:"proof_key_digest"`
+	Nonce          string             `json:"nonce"` // seeds every random choice, hex
+	Timestamp      time.Time          `json:"timestamp"`
+	// Data and Artifact locate the witness bytes and the published proof,
+	// relative to the manifest
+	Data     string `json:"data,omitempty"`
+	Artifact string `json:"artifact,omitempty"`
+}
+
+// ReproductionSecrets are the inputs of a reproducible proof that are not
+// published in its manifest.
+type ReproductionSecrets struct {
+	Data        []byte // witness bytes
+	Key         []byte // proof key
+	SigningSeed []byte // ML-DSA-87 seed
+}
+
+// ReproductionStage is the outcome of one stage of ReproduceProof.
+type ReproductionStage struct {
+	Stage  string `json:"stage"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ReproductionReport is the outcome of ReproduceProof. Diverged names the
+// first stage that failed; later stages are not run.
+type ReproductionReport struct {
+	Reproduced bool                `json:"reproduced"`
+	Diverged   string              `json:"diverged,omitempty"`
+	Stages     []ReproductionStage `json:"stages"`
+}
+
+// ProveReproducibly proves knowledge of secrets.Data in deterministic mode
+// under the configuration, identifier and timestamp of m, with every
+// random choice drawn from m.Nonce, and fills in m's library version and
+// digests. The manifest and the encoded proof can then be published;
+// ReproduceProof regenerates the same bytes from them and the secrets.
+func ProveReproducibly(m *ReproductionManifest, secrets ReproductionSecrets) (*SecureProof, []byte, error) {
+	sq, err := reproducibleProver(m, secrets)
+	if err != nil {
+		return nil, nil, err
+	}
+	proof, encoded, err := proveReproducible(sq, m, secrets)
+	if err != nil {
+		return nil, nil, err
+	}
+	m.LibraryVersion = ReproductionLibraryVersion
+	m.DataDigest = sha256Hex(secrets.Data)
+	m.KeyFingerprint = KeyFingerprint(sq.Signer.PublicKeyBytes())
+	m.ProofKeyDigest = sha256Hex(secrets.Key)
+	return proof, encoded, nil
+}
+
+// ReproduceProof regenerates the proof described by m from secrets and
+// compares it with artifact, the published encoded proof, stage by stage:
+// the library version, the input and key digests, then the proof's
+// parameters, byte encoding, commitments, challenges, responses and
+// signature, and finally the encoded bytes. The report names the first
+// stage that diverges.
+func ReproduceProof(m *ReproductionManifest, secrets ReproductionSecrets, artifact []byte) *ReproductionReport {
+	report := &ReproductionReport{}
+	pass := func(stage string) { report.Stages = append(report.Stages, ReproductionStage{Stage: stage, OK: true}) }
+	fail := func(stage, format string, args ...interface{}) *ReproductionReport {
+		report.Stages = append(report.Stages, ReproductionStage{Stage: stage, Detail: fmt.Sprintf(format, args...)})
+		report.Diverged = stage
+		return report
+	}
+
+	if m.LibraryVersion != ReproductionLibraryVersion {
+		return fail(StageLibrary, "manifest was produced by %q, this is %q", m.LibraryVersion, ReproductionLibraryVersion)
+	}
+	pass(StageLibrary)
+	if got := sha256Hex(secrets.Data); got != m.DataDigest {
+		return fail(StageInput, "data digest %s does not match the manifest's %s", got, m.DataDigest)
+	}
+	pass(StageInput)
+	sq, err := reproducibleProver(m, secrets)
+	if err != nil {
+		return fail(StageKeys, "%v", err)
+	}
+	if got := KeyFingerprint(sq.Signer.PublicKeyBytes()); got != m.KeyFingerprint {
+		return fail(StageKeys, "signing key fingerprint %s does not match the manifest's %s", got, m.KeyFingerprint)
+	}
+	if got := sha256Hex(secrets.Key); got != m.ProofKeyDigest {
+		return fail(StageKeys, "proof key digest %s does not match the manifest's %s", got, m.ProofKeyDigest)
+	}
+	pass(StageKeys)
+
+	payload, codec, err := detectProofCodec(artifact)
+	if payload == nil || err != nil {
+		return fail(StageArtifact, "artifact is not a readable proof (codec %q): %v", codec, err)
+	}
+	if want := reproductionCodec(m); codec != want {
+		return fail(StageArtifact, "artifact is encoded as %s, the manifest says %s", codec, want)
+	}
+	published, err := proofSections(payload)
+	if err != nil {
+		return fail(StageArtifact, "%v", err)
+	}
+	pass(StageArtifact)
+
+	proof, encoded, err := proveReproducible(sq, m, secrets)
+	if err != nil {
+		return fail(StageRegenerate, "%v", err)
+	}
+	regeneratedJSON, err := json.Marshal(proof)
+	if err != nil {
+		return fail(StageRegenerate, "%v", err)
+	}
+	regenerated, err := proofSections(regeneratedJSON)
+	if err != nil {
+		return fail(StageRegenerate, "%v", err)
+	}
+	pass(StageRegenerate)
+
+	for _, stage := range reproductionFieldStages {
+		if diff := diffProofSections(published, regenerated, stage.fields, stage.responseFields); diff != "" {
+			return fail(stage.name, "%s", diff)
+		}
+		pass(stage.name)
+	}
+	if !bytes.Equal(encoded, artifact) {
+		return fail(StageSerialization, "regenerated %d bytes differ from the artifact's %d", len(encoded), len(artifact))
+	}
+	pass(StageSerialization)
+	report.Reproduced = true
+	return report
+}
+
+// reproductionFieldStages assigns the proof's JSON fields, and those of its
+// challenge responses, to the stage of proof generation that produces them.
+var reproductionFieldStages = []struct {
+	name           string
+	fields         []string
+	responseFields []string
+}{
+	{StageParameters, []string{"version", "quantum_dimensions", "state_metadata", "identifier", "identifier_salt",
+		"identifier_scheme", "timestamp", "epoch", "context", "numeric_encoding", "hash_suite", "signature_algorithm",
+		"profile", "key_derivation", "claims", "parameters_hash", "digest_lengths", "randomness", "provenance"}, nil},
+	{StageEncoding, []string{"data_encoding"}, nil},
+	{StageCommitment, []string{"commitment_hash", "merkle_root"}, nil},
+	{StageChallenges, []string{"challenge_binding"}, []string{"challenge_index", "basis_choice", "basis_angle"}},
+	{StageResponses, nil, []string{"response", "commitment", "proof"}},
+	{StageSignature, []string{"signature"}, nil},
+}
+
+// proofSectionMap holds the top-level fields of a JSON proof and those of
+// each of its challenge responses.
+type proofSectionMap struct {
+	fields    map[string]json.RawMessage
+	responses []map[string]json.RawMessage
+}
+
+// proofSections splits a JSON proof into its sections.
+func proofSections(payload []byte) (*proofSectionMap, error) {
+	sections := &proofSectionMap{}
+	if err := DecodeBoundedJSON(bytes.NewReader(payload), ProofJSONLimits, &sections.fields); err != nil {
+		return nil, fmt.Errorf("failed to parse proof: %w", err)
+	}
+	if raw, ok := sections.fields["challenge_response"]; ok {
+		if err := json.Unmarshal(raw, &sections.responses); err != nil {
+			return nil, fmt.Errorf("failed to parse challenge responses: %w", err)
+		}
+	}
+	return sections, nil
+}
+
+// diffProofSections describes the first of fields, then of responseFields
+// in any challenge response, that differs between a and b, or returns "".
+func diffProofSections(a, b *proofSectionMap, fields, responseFields []string) string {
+	for _, field := range fields {
+		if !bytes.Equal(a.fields[field], b.fields[field]) {
+			return fmt.Sprintf("field %s differs: published %s, regenerated %s", field, truncateRaw(a.fields[field]), truncateRaw(b.fields[field]))
+		}
+	}
+	if len(responseFields) == 0 {
+		return ""
+	}
+	if len(a.responses) != len(b.responses) {
+		return fmt.Sprintf("published %d challenge responses, regenerated %d", len(a.responses), len(b.responses))
+	}
+	for i := range a.responses {
+		for _, field := range responseFields {
+			if !bytes.Equal(a.responses[i][field], b.responses[i][field]) {
+				return fmt.Sprintf("challenge response %d field %s differs: published %s, regenerated %s",
+					i, field, truncateRaw(a.responses[i][field]), truncateRaw(b.responses[i][field]))
+			}
+		}
+	}
+	return ""
+}
+
+// truncateRaw shortens a JSON value for a report.
+func truncateRaw(raw json.RawMessage) string {
+	if raw == nil {
+		return "(absent)"
+	}
+	if len(raw) > 48 {
+		return string(raw[:45]) + "..."
+	}
+	return string(raw)
+}
+
+// reproducibleProver configures a deterministic prover for m.
+func reproducibleProver(m *ReproductionManifest, secrets ReproductionSecrets) (*SecureQuantumZKP, error) {
+	nonce, err := hex.DecodeString(m.Nonce)
+	if err != nil || len(nonce) < 16 {
+		return nil, errors.New("manifest nonce must be at least 16 hex-encoded bytes")
+	}
+	if m.Timestamp.IsZero() {
+		return nil, errors.New("manifest timestamp is required")
+	}
+	if len(secrets.Data) == 0 || len(secrets.Key) == 0 {
+		return nil, errors.New("reproduction needs the data and the proof key")
+	}
+	cfg := m.Config
+	sq, err := NewSecureQuantumZKPWithSoundness(cfg.Dimensions, cfg.SecurityLevel, cfg.SoundnessBits, []byte(cfg.Application))
+	if err != nil {
+		return nil, err
+	}
+	if sq.Signer, err = NewSignatureSchemeFromSeed(secrets.SigningSeed, []byte(cfg.Application)); err != nil {
+		return nil, err
+	}
+	sq.Signer.Deterministic = true
+	sq.Profile = cfg.Profile
+
+	hasher := blake3.New(32, nil)
+	hasher.Write([]byte(reproductionDomain))
+	writeLengthPrefixed(hasher, nonce)
+	sq.Rand = hasher.XOF()
+	timestamp := m.Timestamp.UTC()
+	sq.Clock = func() time.Time { return timestamp }
+	return sq, nil
+}
+
+// proveReproducible proves secrets.Data with a prover from
+// reproducibleProver and encodes the proof with m's codec.
+func proveReproducible(sq *SecureQuantumZKP, m *ReproductionManifest, secrets ReproductionSecrets) (*SecureProof, []byte, error) {
+	proof, err := sq.SecureProveFromBytesWithOptions(secrets.Data, m.Identifier, secrets.Key, BytesProofOptions{Dimension: m.Config.EncodingDimension})
+	if err != nil {
+		return nil, nil, err
+	}
+	proofJSON, err := json.Marshal(proof)
+	if err != nil {
+		return nil, nil, err
+	}
+	encoded, err := EncodeProof(reproductionCodec(m), proofJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	return proof, encoded, nil
+}
+
+// reproductionCodec returns the artifact codec of m.
+func reproductionCodec(m *ReproductionManifest) string {
+	if m.Config.Codec == "" {
+		return "json"
+	}
+	return m.Config.Codec
+}
+
+// sha256Hex returns the hex SHA-256 digest of data.
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
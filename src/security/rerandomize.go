@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ProofWitness is what a prover keeps to present the same statement to
+// several verifiers: the proven state and the identifier its proofs commit
+// to. It never leaves the prover.
+type ProofWitness struct {
+	sq         *SecureQuantumZKP
+	state      *StateVector
+	identifier string
+}
+
+// NewProofWitness keeps vector and identifier for re-randomizing proofs.
+func (sq *SecureQuantumZKP) NewProofWitness(vector []complex128, identifier string) (*ProofWitness, error) {
+	state, err := NewStateVector(vector)
+	if err != nil {
+		return nil, err
+	}
+	return sq.NewProofWitnessForState(state, identifier)
+}
+
+// NewProofWitnessForState keeps state and identifier for re-randomizing
+// proofs, e.g. a state derived from bytes.
+func (sq *SecureQuantumZKP) NewProofWitnessForState(state *StateVector, identifier string) (*ProofWitness, error) {
+	if state == nil {
+		return nil, errors.New("state vector cannot be nil")
+	}
+	return &ProofWitness{sq: sq, state: state, identifier: identifier}, nil
+}
+
+// RerandomizeProof returns a fresh proof of the statement proof makes, so
+// the prover can show the same evidence to several verifiers without them
+// correlating submissions by proof bytes. The new proof commits under a
+// new nonce, answers challenges derived from that commitment in the
+// current epoch, publishes the identifier under a new salt and is signed
+// anew; nothing but the statement (signer, context, dimension, encoding
+// and parameters) carries over. Linkage tags, when enabled, still let the
+// auditor holding the detection key link the proofs.
+//
+// proof must verify under the witness's prover and be for the witness's
+// identifier, dimension and encoding. The commitment hides the state, so
+// the witness cannot be checked against it. Identifier privacy mode
+// (IdentifierKey) is required, since a plain identifier links every proof
+// of the statement; interactive proofs are bound to their verifier and
+// cannot be re-randomized.
+func (w *ProofWitness) RerandomizeProof(proof *SecureProof, key []byte) (*SecureProof, error) {
+	sq := w.sq
+	if proof == nil {
+		return nil, errors.New("proof cannot be nil")
+	}
+	if proof.Session != nil {
+		return nil, errors.New("interactive proofs cannot be re-randomized")
+	}
+	if len(sq.IdentifierKey) == 0 {
+		return nil, errors.New("re-randomized proofs need identifier privacy mode; a plain identifier links them")
+	}
+	if !sq.VerifySecureProof(proof, key) {
+		return nil, errors.New("proof does not verify")
+	}
+	if !sq.matchesIdentifier(proof, w.identifier) {
+		return nil, errors.New("proof is for a different identifier")
+	}
+	if proof.StateMetadata.Dimension != w.state.Dimension() {
+		return nil, fmt.Errorf("proof is for a dimension %d state, witness has dimension %d",
+			proof.StateMetadata.Dimension, w.state.Dimension())
+	}
+	if encoding := w.state.Encoding(); (encoding == nil) != (proof.DataEncoding == nil) ||
+		encoding != nil && *encoding != *proof.DataEncoding {
+		return nil, errors.New("proof and witness use different data encodings")
+	}
+
+	return sq.SecureProveState(w.state, w.identifier, key)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"lukechampine.com/blake3"
+)
+
+// sampledHeaderDomain separates header signatures from full-proof
+// signatures made with the same key.
+const sampledHeaderDomain = "qzkp/sampled-header/v1"
+
+// SampledOpening lets a constrained verifier check a proof without
+// downloading every challenge response. The header is the proof with its
+// responses removed; each opened response carries a Merkle inclusion path
+// to the header's Merkle root.
+type SampledOpening struct {
+	Header          *SecureProof        `json:"header"`
+	HeaderSignature string              `json:"header_signature"`
+	Positions       []int               `json:"positions"`
+	Responses       []ChallengeResponse `json:"responses"`
+	Paths           [][]string          `json:"paths"`
+}
+
+// SampledVerificationResult reports the outcome of a sampled check.
+type SampledVerificationResult struct {
+	Valid   bool   `json:"valid"`
+	Sampled int    `json:"sampled"`
+	Total   int    `json:"total"`
+	Reason  string `json:"reason,omitempty"`
+	// EffectiveSoundness is the soundness, in bits, of the responses that
+	// were actually checked, one bit per response. A prover that
+	// cheats on a fraction f of the responses escapes with probability
+	// (1−f)^Sampled.
+	EffectiveSoundness int `json:"effective_soundness"`
+}
+
+// OpenSampledResponses answers a verifier's sampling request for proof.
+// Positions are derived from the verifier's seed and the proof's Merkle
+// root, so the prover cannot choose which responses are opened.
+func (sq *SecureQuantumZKP) OpenSampledResponses(proof *SecureProof, seed []byte, t int) (*SampledOpening, error) {
+	if proof == nil || len(proof.ChallengeResponse) == 0 {
+		return nil, errors.New("proof has no responses")
+	}
+	if proof.Session != nil {
+		return nil, errors.New("interactive proofs cannot be opened for sampling")
+	}
+
+	positions, err := samplePositions(seed, proof.MerkleRoot, len(proof.ChallengeResponse), t)
+	if err != nil {
+		return nil, err
+	}
+
+	header := sampledHeader(proof)
+	sig, err := sq.Signer.Sign(sampledHeaderMessage(header))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign header: %w", err)
+	}
+
+	leaves := responseLeaves(proof.ChallengeResponse)
+	opening := &SampledOpening{
+		Header:          header,
+		HeaderSignature: EncodeHexField(sig),
+		Positions:       positions,
+		Responses:       make([]ChallengeResponse, len(positions)),
+		Paths:           make([][]string, len(positions)),
+	}
+	for i, pos := range positions {
+		opening.Responses[i] = proof.ChallengeResponse[pos]
+		opening.Paths[i] = encodeMerklePath(merkleInclusionPath(leaves, pos))
+	}
+	return opening, nil
+}
+
+// VerifySampledOpening checks t responses selected by seed. It verifies the
+// header signature and the same header checks as full verification, then
+// for each sampled response its well-formedness, its derived challenge and
+// its inclusion under the signed Merkle root.
+func (sq *SecureQuantumZKP) VerifySampledOpening(opening *SampledOpening, seed []byte, t int, key []byte) *SampledVerificationResult {
+	result := &SampledVerificationResult{Total: sq.ChallengeCount()}
+	fail := func(reason string) *SampledVerificationResult {
+		result.Reason = reason
+		return result
+	}
+
+	if opening == nil || opening.Header == nil {
+		return fail("missing header")
+	}
+	header := opening.Header
+	if len(header.ChallengeResponse) != 0 || header.Session != nil {
+		return fail("header is not a sampled header")
+	}
+
+	sig, err := decodeSignatureField(opening.HeaderSignature)
+	if err != nil || !sq.Signer.Verify(sampledHeaderMessage(header), sig) {
+		return fail("invalid header signature")
+	}
+	if header.Version != CurrentProofVersion || !sq.matchesParameters(header) || !header.Context.Equal(sq.Context) || header.NumericEncoding.Validate() != nil ||
+		header.HashSuite != HashSuiteSHA256 || header.SignatureAlgorithm != SignatureAlgorithmMLDSA87 {
+		return fail("header parameters rejected")
+	}
+	if header.DigestLengths == nil || !header.DigestLengths.AtLeast(sq.DigestLengths) ||
+		!verifyDigestLengths(header.CommitmentHash, opening.Responses, *header.DigestLengths) {
+		return fail("header digest lengths rejected")
+	}
+	if !sq.verifyMetadataBounds(header.StateMetadata) || !sq.verifyTimeAttestation(header) {
+		return fail("header metadata rejected")
+	}
+
+	positions, err := samplePositions(seed, header.MerkleRoot, sq.ChallengeCount(), t)
+	if err != nil {
+		return fail(err.Error())
+	}
+	if len(opening.Positions) != len(positions) || len(opening.Responses) != len(positions) ||
+		len(opening.Paths) != len(positions) {
+		return fail("opening does not match the requested sample")
+	}
+
+	commitment, err := decodeDigestField(header.CommitmentHash, 1)
+	if err != nil {
+		return fail("malformed commitment")
+	}
+	challenges, err := sq.deriveChallenges(commitment, header.Epoch, header.StateMetadata.Dimension, sq.ChallengeCount(), header.challengeNonceLength())
+	if err != nil {
+		return fail("challenge derivation failed")
+	}
+	root, err := decodeDigestField(header.MerkleRoot, 1)
+	if err != nil {
+		return fail("malformed Merkle root")
+	}
+
+	for i, pos := range positions {
+		response := opening.Responses[i]
+		if opening.Positions[i] != pos {
+			return fail(fmt.Sprintf("position %d was not requested", opening.Positions[i]))
+		}
+		if !sq.verifyChallengeResponse(response, key) {
+			return fail(fmt.Sprintf("response %d is malformed", pos))
+		}
+		if !response.answers(challenges[pos]) {
+			return fail(fmt.Sprintf("response %d answers the wrong challenge", pos))
+		}
+		path, err := decodeMerklePath(opening.Paths[i])
+		if err != nil || !verifyMerklePath(responseLeaf(response), pos, path, root) {
+			return fail(fmt.Sprintf("response %d is not included under the Merkle root", pos))
+		}
+	}
+
+	result.Valid = true
+	result.Sampled = len(positions)
+	result.EffectiveSoundness = len(positions)
+	return result
+}
+
+// sampledHeader copies proof without responses or signature.
+func sampledHeader(proof *SecureProof) *SecureProof {
+	header := *proof
+	header.ChallengeResponse = nil
+	header.Signature = ""
+	return &header
+}
+
+// sampledHeaderMessage is the domain-separated message signed for a header.
+func sampledHeaderMessage(header *SecureProof) []byte {
+	temp := *header
+	temp.Signature = ""
+	body, _ := json.Marshal(&temp)
+	return append([]byte(sampledHeaderDomain), body...)
+}
+
+// samplePositions derives t distinct positions in [0, n) from the
+// verifier's seed and the Merkle root.
+func samplePositions(seed []byte, merkleRoot string, n, t int) ([]int, error) {
+	if len(seed) < 16 {
+		return nil, errors.New("sampling seed must be at least 16 bytes")
+	}
+	if t <= 0 || t > n {
+		return nil, fmt.Errorf("invalid sample size %d for %d responses", t, n)
+	}
+
+	hasher := blake3.New(32, nil)
+	hasher.Write([]byte(sampledHeaderDomain))
+	writeLengthPrefixed(hasher, seed)
+	writeLengthPrefixed(hasher, []byte(merkleRoot))
+	xof := hasher.XOF()
+
+	seen := make(map[int]bool, t)
+	positions := make([]int, 0, t)
+	var word [8]byte
+	for len(positions) < t {
+		if _, err := xof.Read(word[:]); err != nil {
+			return nil, err
+		}
+		pos := int(binary.BigEndian.Uint64(word[:]) % uint64(n))
+		if !seen[pos] {
+			seen[pos] = true
+			positions = append(positions, pos)
+		}
+	}
+	return positions, nil
+}
@@ -215,7 +215,7 @@ func TestSoundnessErrorBounds(t *testing.T) {
 	key := []byte("soundness-test-key-32-bytes!!!")
 
 	soundnessLevels := []struct {
-		bits         int
+		bits          int
 		expectedError float64
 	}{
 		{32, math.Pow(2, -32)},   // 2^-32
@@ -369,44 +369,8 @@ func TestScalabilityAnalysis(t *testing.T) {
 	t.Logf("✅ Scalability analysis validates paper claims")
 }
 
-// Helper functions
-
-func generateRandomTestVectors(count int) [][]complex128 {
-	vectors := make([][]complex128, count)
-	for i := 0; i < count; i++ {
-		// Generate random 4-dimensional quantum state
-		vector := make([]complex128, 4)
-		var norm float64
-
-		for j := 0; j < 4; j++ {
-			real := (float64(i*4+j) + 1.0) / float64(count*4) // Deterministic but varied
-			imag := (float64(i*4+j) + 0.5) / float64(count*4)
-			vector[j] = complex(real, imag)
-			norm += real*real + imag*imag
-		}
-
-		// Normalize
-		norm = math.Sqrt(norm)
-		for j := 0; j < 4; j++ {
-			vector[j] = complex(real(vector[j])/norm, imag(vector[j])/norm)
-		}
-
-		vectors[i] = vector
-	}
-	return vectors
-}
-
-func detectVectorLeakage(vector []complex128, proofJSON string) bool {
-	for _, c := range vector {
-		realStr := fmt.Sprintf("%.3f", real(c))
-		imagStr := fmt.Sprintf("%.3f", imag(c))
-
-		if strings.Contains(proofJSON, realStr) || strings.Contains(proofJSON, imagStr) {
-			return true
-		}
-	}
-	return false
-}
+// generateRandomTestVectors and detectVectorLeakage are defined in
+// paper_bench.go, shared with the reference benchmark
 
 // mustMarshal is defined in examples.go
 
@@ -454,9 +418,9 @@ func TestCompetitiveAnalysis(t *testing.T) {
 		maxVerTime   time.Duration
 		maxProofSize int
 	}{
-		maxGenTime:   2 * time.Millisecond,  // Paper claims <2ms
-		maxVerTime:   1 * time.Millisecond,  // Paper claims <1ms
-		maxProofSize: 25000,                 // Paper claims ~20KB for 80-bit
+		maxGenTime:   2 * time.Millisecond, // Paper claims <2ms
+		maxVerTime:   1 * time.Millisecond, // Paper claims <1ms
+		maxProofSize: 25000,                // Paper claims ~20KB for 80-bit
 	}
 
 	if genTime > paperClaims.maxGenTime {
@@ -544,11 +508,13 @@ func TestZeroKnowledgeProperty(t *testing.T) {
 	for _, tc := range testCases {
 		t.Logf("Testing zero-knowledge for %s", tc.name)
 
-		// Normalize the vector
-		normalized := normalizeStateVector(tc.vector)
+		state, err := NewStateVector(tc.vector)
+		if err != nil {
+			t.Fatalf("Invalid test vector %s: %v", tc.name, err)
+		}
 
 		// Generate proof
-		proof, err := sq.SecureProveVectorKnowledge(normalized, tc.name, key)
+		proof, err := sq.SecureProveState(state, tc.name, key)
 		if err != nil {
 			t.Fatalf("Proof generation failed for %s: %v", tc.name, err)
 		}
@@ -563,7 +529,7 @@ func TestZeroKnowledgeProperty(t *testing.T) {
 
 		// Check for direct state vector leakage
 		leakageDetected := false
-		for i, c := range normalized {
+		for i, c := range state.Amplitudes() {
 			realStr := fmt.Sprintf("%.4f", real(c))
 			imagStr := fmt.Sprintf("%.4f", imag(c))
 
@@ -611,9 +577,9 @@ func TestMemoryUsageAnalysis(t *testing.T) {
 	key := []byte("memory-test-key-32-bytes-long!!")
 
 	securityLevels := []struct {
-		name         string
-		bits         int
-		maxMemoryMB  float64
+		name        string
+		bits        int
+		maxMemoryMB float64
 	}{
 		{"80-bit", 80, 5.0},   // Paper claims 1-5MB
 		{"128-bit", 128, 5.0}, // Paper claims 1-5MB
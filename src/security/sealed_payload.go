@@ -0,0 +1,185 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/cloudflare/circl/kem/mlkem/mlkem1024"
+)
+
+// PayloadAlgorithm names the sealing construction of SealedPayload.
+const PayloadAlgorithm = "ML-KEM-1024+HKDF-SHA256+AES-256-GCM"
+
+// sealedPayloadDomain separates payload keys and payload signatures from
+// every other derivation and signature made with the same keys.
+const sealedPayloadDomain = "qzkp/sealed-payload/v1"
+
+// ErrPayloadProofInvalid is returned by OpenPayload when the proof a
+// payload is bound to does not verify. The payload is not decrypted.
+var ErrPayloadProofInvalid = errors.New("proof does not verify; payload withheld")
+
+// SealedPayload is content encrypted to one designated verifier and bound
+// to one proof. The content key is encapsulated to the verifier's ML-KEM
+// key and derived together with the proof digest, the ciphertext is
+// authenticated with the digest as well, and the prover signs the whole
+// payload, so a payload can be neither moved to another proof nor replaced
+// by a third party.
+type SealedPayload struct {
+	Algorithm     string `json:"alg"`
+	RecipientID   string `json:"kid"`           // KeyFingerprint of the recipient's KEM key
+	ProofDigest   string `json:"proof_digest"`  // ProofDigest of the bound proof
+	Encapsulation string `json:"encapsulation"` // hex ML-KEM ciphertext
+	Ciphertext    string `json:"ciphertext"`    // hex nonce ‖ AES-GCM ciphertext
+	Signature     string `json:"signature"`
+}
+
+// ProofBoundPayload is a proof with the payload that is released only
+// once the proof verifies.
+type ProofBoundPayload struct {
+	Proof   *SecureProof   `json:"proof"`
+	Payload *SealedPayload `json:"payload"`
+}
+
+// SealPayload encrypts content to recipient and binds it to proof, which
+// must be signed by sq. Only the holder of recipient's private key can
+// open the payload, and OpenPayload does so only after the proof has
+// verified.
+func (sq *SecureQuantumZKP) SealPayload(proof *SecureProof, content []byte, recipient *PayloadKEM) (*ProofBoundPayload, error) {
+	if proof == nil || proof.Signature == "" {
+		return nil, errors.New("only signed proofs can carry a payload")
+	}
+	if recipient == nil || recipient.Pub == nil {
+		return nil, errors.New("recipient key is required")
+	}
+	digest, err := ProofDigest(proof)
+	if err != nil {
+		return nil, err
+	}
+	encapsulation, secret, err := recipient.Encapsulate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encapsulate payload key: %w", err)
+	}
+
+	payload := &SealedPayload{
+		Algorithm:     PayloadAlgorithm,
+		RecipientID:   KeyFingerprint(recipient.PublicKeyBytes()),
+		ProofDigest:   digest,
+		Encapsulation: EncodeHexField(encapsulation),
+	}
+	aead, err := payload.aead(secret)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if err := sq.readRandom(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	payload.Ciphertext = EncodeHexField(aead.Seal(nonce, nonce, content, []byte(digest)))
+
+	msg, err := payload.signingMessage()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := sq.Signer.Sign(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign payload: %w", err)
+	}
+	payload.Signature = EncodeHexField(sig)
+	return &ProofBoundPayload{Proof: proof, Payload: payload}, nil
+}
+
+// OpenPayload verifies the bound proof under key and, only if it verifies,
+// decrypts the payload with recipient's private key. A proof that does
+// not verify yields ErrPayloadProofInvalid before any decryption is
+// attempted. The payload must be signed by sq.Signer, the key the proof
+// is verified against.
+func (sq *SecureQuantumZKP) OpenPayload(bound *ProofBoundPayload, key []byte, recipient *PayloadKEM) ([]byte, error) {
+	if bound == nil || bound.Proof == nil || bound.Payload == nil {
+		return nil, errors.New("proof and payload are required")
+	}
+	if recipient == nil || recipient.Priv == nil {
+		return nil, errors.New("recipient private key is required")
+	}
+	payload := bound.Payload
+	if payload.Algorithm != PayloadAlgorithm {
+		return nil, fmt.Errorf("unsupported payload algorithm %q", payload.Algorithm)
+	}
+	digest, err := ProofDigest(bound.Proof)
+	if err != nil {
+		return nil, err
+	}
+	if payload.ProofDigest != digest {
+		return nil, errors.New("payload is bound to a different proof")
+	}
+
+	if !sq.VerifySecureProof(bound.Proof, key) {
+		return nil, ErrPayloadProofInvalid
+	}
+	msg, err := payload.signingMessage()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := decodeSignatureField(payload.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("payload signature: %w", err)
+	}
+	if !sq.Signer.Verify(msg, sig) {
+		return nil, errors.New("payload signature is invalid")
+	}
+
+	if payload.RecipientID != KeyFingerprint(recipient.PublicKeyBytes()) {
+		return nil, errors.New("payload is sealed to a different recipient")
+	}
+	encapsulation, err := DecodeHexField(payload.Encapsulation, mlkem1024.CiphertextSize, mlkem1024.CiphertextSize)
+	if err != nil {
+		return nil, fmt.Errorf("payload encapsulation: %w", err)
+	}
+	secret, err := recipient.Decapsulate(encapsulation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decapsulate payload key: %w", err)
+	}
+	aead, err := payload.aead(secret)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := DecodeHexField(payload.Ciphertext, aead.NonceSize()+aead.Overhead(), math.MaxInt)
+	if err != nil {
+		return nil, fmt.Errorf("payload ciphertext: %w", err)
+	}
+	n := aead.NonceSize()
+	content, err := aead.Open(nil, sealed[:n], sealed[n:], []byte(digest))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt payload: %w", err)
+	}
+	return content, nil
+}
+
+// aead derives the payload's AES-256-GCM key from the KEM shared secret,
+// the recipient and the bound proof.
+func (p *SealedPayload) aead(secret []byte) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, secret, []byte(sealedPayloadDomain), p.RecipientID+p.ProofDigest, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive payload key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// signingMessage is the byte string the prover signs.
+func (p *SealedPayload) signingMessage() ([]byte, error) {
+	unsigned := *p
+	unsigned.Signature = ""
+	canonical, err := CanonicalizeValue(&unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize payload: %w", err)
+	}
+	return append([]byte(sealedPayloadDomain), canonical...), nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Self-test check names.
+const (
+	SelfTestKnownAnswer = "known_answer"
+	SelfTestEntropy     = "entropy"
+	SelfTestSigner      = "signer"
+)
+
+// selfTestSeed fixes the key, secret and nonces of the known-answer test.
+var selfTestSeed = []byte("qzkp self-test known-answer seed")
+
+// Entropy health test parameters after NIST SP 800-90B section 4.4 for a
+// byte source claimed to carry full entropy: a byte repeated
+// entropyRepetitionCutoff times in a row, or one value seen
+// entropyProportionCutoff times within entropyProportionWindow bytes,
+// fails the source. The cutoffs are set for the whole sample rather than
+// per position: a full-entropy source fails the repetition test at one of
+// the sample's 1021 starting positions with probability about 2^-22 and
+// the proportion test in either window with probability about 2^-24, so
+// one self-test fails spuriously less than once in 2^20 runs.
+const (
+	entropySampleBytes      = 1024
+	entropyRepetitionCutoff = 5
+	entropyProportionWindow = 512
+	entropyProportionCutoff = 15
+)
+
+// SelfTestCheck is the outcome of one self-test check.
+type SelfTestCheck struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Duration time.Duration `json:"duration_ns"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// SelfTestResult reports a prover self-test. Passed is true only if every
+// check passed.
+type SelfTestResult struct {
+	Passed    bool            `json:"passed"`
+	Timestamp time.Time       `json:"timestamp"`
+	Duration  time.Duration   `json:"duration_ns"`
+	Checks    []SelfTestCheck `json:"checks"`
+}
+
+// Err returns nil if the self-test passed and otherwise an error naming
+// the failed checks.
+func (r *SelfTestResult) Err() error {
+	if r.Passed {
+		return nil
+	}
+	var failed []string
+	for _, c := range r.Checks {
+		if !c.Passed {
+			failed = append(failed, c.Name+": "+c.Error)
+		}
+	}
+	return fmt.Errorf("self-test failed: %v", failed)
+}
+
+// SelfTest checks that the prover works before it is trusted with
+// requests: a known-answer proof under sq's parameters must be
+// reproducible and verify, the randomness source must pass continuous
+// health tests, and sq's signer must round-trip a signature through its
+// encoded public key. It takes a few milliseconds and is meant to run at
+// service startup and on key reload; health checks should report the
+// stored result rather than rerun it.
+func (sq *SecureQuantumZKP) SelfTest() *SelfTestResult {
+	start := time.Now()
+	result := &SelfTestResult{Passed: true, Timestamp: start}
+	for _, check := range []struct {
+		name string
+		run  func() error
+	}{
+		{SelfTestKnownAnswer, sq.selfTestKnownAnswer},
+		{SelfTestEntropy, sq.selfTestEntropy},
+		{SelfTestSigner, sq.selfTestSigner},
+	} {
+		checkStart := time.Now()
+		err := check.run()
+		c := SelfTestCheck{Name: check.name, Passed: err == nil, Duration: time.Since(checkStart)}
+		if err != nil {
+			c.Error = err.Error()
+			result.Passed = false
+		}
+		result.Checks = append(result.Checks, c)
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+// selfTestKnownAnswer proves a fixed state twice with a seeded signer,
+// nonce stream and clock, and requires identical proofs that verify and
+// a tampered copy that does not.
+func (sq *SecureQuantumZKP) selfTestKnownAnswer() error {
+	signer, err := NewSignatureSchemeFromSeed(corpusBytes(selfTestSeed, "self-test/signing-key", 32), sq.Signer.Ctx)
+	if err != nil {
+		return err
+	}
+	signer.Deterministic = true
+	key := corpusBytes(selfTestSeed, "self-test/proof-key", 32)
+	vector := []complex128{complex(0.6, 0), complex(0, 0.8)}
+
+	prove := func() ([]byte, *SecureProof, error) {
+		kat := sq.withSigner(signer)
+		kat.Rand = corpusStream(selfTestSeed, "self-test/nonces")
+		kat.Clock = func() time.Time { return corpusClock }
+		kat.Progress = nil
+		kat.TimeAuthority = nil
+		kat.MemoryBudget = MemoryBudget{}
+		proof, err := kat.SecureProveVectorKnowledge(vector, "self_test", key)
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := json.Marshal(proof)
+		return data, proof, err
+	}
+	first, proof, err := prove()
+	if err != nil {
+		return fmt.Errorf("proof generation failed: %w", err)
+	}
+	second, _, err := prove()
+	if err != nil {
+		return fmt.Errorf("proof generation failed: %w", err)
+	}
+	if !bytes.Equal(first, second) {
+		return errors.New("proofs from identical inputs differ")
+	}
+
+	verifier := sq.withSigner(signer)
+	if r := verifier.VerifySecureProofVersioned(proof, key); !r.Valid {
+		return fmt.Errorf("known-answer proof does not verify: %v", r.Reasons)
+	}
+	tampered := *proof
+	tampered.Identifier = "self_test_tampered"
+	if verifier.VerifySecureProof(&tampered, key) {
+		return errors.New("tampered known-answer proof verifies")
+	}
+	return nil
+}
+
+// selfTestEntropy runs the entropy health tests over a sample of the
+// randomness source, and requires a second sample to differ from the
+// first.
+func (sq *SecureQuantumZKP) selfTestEntropy() error {
+	sample := make([]byte, entropySampleBytes)
+	if err := sq.readRandom(sample); err != nil {
+		return fmt.Errorf("randomness source failed: %w", err)
+	}
+	if err := checkEntropyHealth(sample); err != nil {
+		return err
+	}
+
+	next := make([]byte, len(sample))
+	if err := sq.readRandom(next); err != nil {
+		return fmt.Errorf("randomness source failed: %w", err)
+	}
+	if bytes.Equal(sample, next) {
+		return errors.New("randomness source repeated its output")
+	}
+	return nil
+}
+
+// checkEntropyHealth runs the repetition count and adaptive proportion
+// tests over sample.
+func checkEntropyHealth(sample []byte) error {
+	run := 1
+	for i := 1; i < len(sample); i++ {
+		if sample[i] != sample[i-1] {
+			run = 1
+			continue
+		}
+		if run++; run >= entropyRepetitionCutoff {
+			return fmt.Errorf("repetition count test failed: byte %#02x repeated %d times at offset %d", sample[i], run, i-run+1)
+		}
+	}
+	for start := 0; start+entropyProportionWindow <= len(sample); start += entropyProportionWindow {
+		window := sample[start : start+entropyProportionWindow]
+		if n := bytes.Count(window, window[:1]); n >= entropyProportionCutoff {
+			return fmt.Errorf("adaptive proportion test failed: byte %#02x seen %d times in %d bytes", window[0], n, len(window))
+		}
+	}
+	return nil
+}
+
+// selfTestSigner signs with sq.Signer and verifies through a scheme
+// rebuilt from the encoded public key, as remote verifiers do.
+func (sq *SecureQuantumZKP) selfTestSigner() error {
+	if sq.Signer == nil || sq.Signer.Priv == nil {
+		return errors.New("no signing key")
+	}
+	message := []byte("qzkp self-test signer round trip")
+	signature, err := sq.Signer.Sign(message)
+	if err != nil {
+		return fmt.Errorf("signing failed: %w", err)
+	}
+	verifier, err := NewVerificationScheme(sq.Signer.PublicKeyBytes(), sq.Signer.Ctx)
+	if err != nil {
+		return err
+	}
+	if !verifier.Verify(message, signature) {
+		return errors.New("signature does not verify under the encoded public key")
+	}
+	if verifier.Verify([]byte("qzkp self-test signer round trip!"), signature) {
+		return errors.New("signature verifies for a different message")
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Bounds on negotiated soundness, matching NewSecureQuantumZKPWithSoundness.
+const (
+	minSessionSoundness = 32
+	maxSessionSoundness = 256
+)
+
+// supportedHashSuites lists the hash suites proofs can be made with, in
+// preference order.
+var supportedHashSuites = []string{HashSuiteSHA256}
+
+// SessionPolicy is one party's minimum requirements for an interactive
+// session. The verifier sends its policy in the offer and the prover its
+// own with the commitment; each side negotiates independently and the
+// results must agree.
+type SessionPolicy struct {
+	MinSoundness int      `json:"min_soundness"`         // 0 means the party's SecurityParameter
+	HashSuites   []string `json:"hash_suites,omitempty"` // acceptable suites in preference order; nil means all supported
+	Profile      string   `json:"profile,omitempty"`     // required profile, if any
+}
+
+// SessionParameters is what the parties of an interactive session agreed
+// on. It is recorded in the signed session binding, so the proof itself
+// shows the agreement and neither side can later claim a weaker one.
+type SessionParameters struct {
+	SoundnessBits int    `json:"soundness_bits"`
+	HashSuite     string `json:"hash_suite"`
+	Profile       string `json:"profile,omitempty"`
+}
+
+// sessionPolicy fills the defaults of policy from sq's configuration.
+func (sq *SecureQuantumZKP) sessionPolicy(policy SessionPolicy) SessionPolicy {
+	if policy.MinSoundness == 0 {
+		policy.MinSoundness = sq.SecurityParameter
+	}
+	if len(policy.HashSuites) == 0 {
+		policy.HashSuites = supportedHashSuites
+	}
+	return policy
+}
+
+// NegotiateSession agrees on session parameters meeting both policies: the
+// higher of the two minimum soundness levels, the first hash suite in the
+// verifier's preference that the prover accepts and this implementation
+// supports, and the profile either party requires. It fails when the
+// policies cannot both be met.
+func NegotiateSession(verifier, prover SessionPolicy) (SessionParameters, error) {
+	var params SessionParameters
+
+	params.SoundnessBits = max(verifier.MinSoundness, prover.MinSoundness)
+	if params.SoundnessBits < minSessionSoundness || params.SoundnessBits > maxSessionSoundness {
+		return params, fmt.Errorf("negotiated soundness %d bits outside [%d, %d]",
+			params.SoundnessBits, minSessionSoundness, maxSessionSoundness)
+	}
+
+	for _, suite := range verifier.HashSuites {
+		if requireOneOf("hash suite", suite, prover.HashSuites) == nil &&
+			requireOneOf("hash suite", suite, supportedHashSuites) == nil {
+			params.HashSuite = suite
+			break
+		}
+	}
+	if params.HashSuite == "" {
+		return params, fmt.Errorf("no common hash suite: verifier accepts %v, prover accepts %v",
+			verifier.HashSuites, prover.HashSuites)
+	}
+
+	switch {
+	case verifier.Profile != "" && prover.Profile != "" && verifier.Profile != prover.Profile:
+		return params, fmt.Errorf("verifier requires profile %q, prover requires %q", verifier.Profile, prover.Profile)
+	case verifier.Profile != "":
+		params.Profile = verifier.Profile
+	default:
+		params.Profile = prover.Profile
+	}
+	return params, nil
+}
+
+// forSession returns a copy of sq that makes and checks proofs at the
+// negotiated parameters. Digests are sized for the negotiated soundness
+// alone so that both parties derive the same parameters hash.
+func (sq *SecureQuantumZKP) forSession(params SessionParameters) *SecureQuantumZKP {
+	session := *sq
+	session.SecurityParameter = params.SoundnessBits
+	session.Profile = params.Profile
+	session.DigestLengths = DigestLengthsFor(session.EffectiveSoundness())
+	session.precomputed = nil // computed for sq's parameters
+	return &session
+}
+
+// checkSessionParameters reports whether proof was made at the parameters
+// its session binding records.
+func (sq *SecureQuantumZKP) checkSessionParameters(proof *SecureProof) error {
+	params := proof.Session.Parameters
+	if proof.HashSuite != params.HashSuite {
+		return fmt.Errorf("proof uses hash suite %q, session agreed on %q", proof.HashSuite, params.HashSuite)
+	}
+	if proof.Profile != params.Profile {
+		return fmt.Errorf("proof declares profile %q, session agreed on %q", proof.Profile, params.Profile)
+	}
+	if len(proof.ChallengeResponse) < sq.ChallengeCount() {
+		return fmt.Errorf("proof answers %d challenges, session agreed on %d bits needing %d",
+			len(proof.ChallengeResponse), params.SoundnessBits, sq.ChallengeCount())
+	}
+	return nil
+}
+
+// negotiatedWith checks that the parameters a commitment claims are the
+// ones negotiation of both policies yields.
+func negotiatedWith(verifier SessionPolicy, commitment *SessionCommitment) (SessionParameters, error) {
+	if commitment.Policy == nil || commitment.Parameters == nil {
+		return SessionParameters{}, errors.New("commitment does not carry negotiated parameters")
+	}
+	params, err := NegotiateSession(verifier, *commitment.Policy)
+	if err != nil {
+		return params, err
+	}
+	if params != *commitment.Parameters {
+		return params, fmt.Errorf("commitment claims %+v, negotiation yields %+v", *commitment.Parameters, params)
+	}
+	return params, nil
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+// SNARKLayoutVersion identifies the rules SNARKLayout follows. Circuits
+// should check it, as a new version may reorder or resize fields.
+const SNARKLayoutVersion = "qzkp/snark-layout/v1"
+
+// SNARKLimbBytes is the size of the limbs digests are split into as
+// public inputs. 128-bit limbs are below the scalar field modulus of every
+// common SNARK curve (BN254, BLS12-381, Pallas), so no input is reduced.
+const SNARKLimbBytes = 16
+
+// Visibilities of a SNARKField.
+const (
+	SNARKPrivate  = "private"  // part of the witness; never published
+	SNARKConstant = "constant" // public and fixed by the layout; see SNARKField.Value
+	SNARKPublic   = "public"   // a public input of the circuit
+)
+
+// SNARKField is one field of a SNARKLayout. Preimage fields are byte
+// ranges of the commitment preimage; public inputs are ranges of the
+// public input vector, counted in field elements.
+type SNARKField struct {
+	Name       string `json:"name"`
+	Visibility string `json:"visibility"`
+	Offset     int    `json:"offset"`
+	Length     int    `json:"length"`
+	Encoding   string `json:"encoding"`
+	Value      string `json:"value,omitempty"` // hex, constants only
+}
+
+// SNARKLayout is the canonical layout of a state commitment for classical
+// ZK circuits that prove further predicates about the state a proof
+// commits to. Such a circuit takes the commitment preimage as witness,
+// recomputes SHA-256 over it, constrains the leading CommitmentBytes bytes
+// of the digest to the "commitment" public input and then constrains the
+// amplitudes as it needs. The layout depends on the dimension and the
+// identifier length, which a circuit fixes when it is compiled.
+type SNARKLayout struct {
+	Version         string          `json:"version"`
+	Hash            string          `json:"hash"`
+	CommitmentBytes int             `json:"commitment_bytes"`
+	PreimageBytes   int             `json:"preimage_bytes"`
+	NumericEncoding NumericEncoding `json:"numeric_encoding"`
+	Preimage        []SNARKField    `json:"preimage"`
+	PublicInputs    []SNARKField    `json:"public_inputs"`
+}
+
+// SNARKStatement is the public side of a composition: the layout and the
+// public inputs of a proof, as decimal field elements in the order of
+// Layout.PublicInputs. A verifier passes PublicInputs to the external
+// circuit's verifier after verifying the proof itself.
+type SNARKStatement struct {
+	Layout       *SNARKLayout `json:"layout"`
+	PublicInputs []string     `json:"public_inputs"`
+}
+
+// SNARKWitness is the private input of a circuit composed with a proof:
+// the commitment preimage, laid out as Layout describes. It holds the
+// state and a key derived from the proof key, so it must stay with the
+// prover.
+type SNARKWitness struct {
+	Layout   *SNARKLayout `json:"layout"`
+	Preimage string       `json:"preimage"` // hex
+}
+
+// SNARKLayout returns the layout of the commitments sq makes for states
+// of the given dimension and identifiers of identifierLength bytes.
+func (sq *SecureQuantumZKP) SNARKLayout(dimension, identifierLength int) (*SNARKLayout, error) {
+	return sq.snarkLayout(dimension, identifierLength, sq.DigestLengths.Commitment)
+}
+
+// snarkLayout is SNARKLayout for commitments published with
+// commitmentBytes bytes.
+func (sq *SecureQuantumZKP) snarkLayout(dimension, identifierLength, commitmentBytes int) (*SNARKLayout, error) {
+	if dimension <= 0 || dimension > maxProofDimension {
+		return nil, fmt.Errorf("dimension %d outside [1, %d]", dimension, maxProofDimension)
+	}
+	if identifierLength < 0 {
+		return nil, fmt.Errorf("negative identifier length %d", identifierLength)
+	}
+	if err := sq.NumericEncoding.Validate(); err != nil {
+		return nil, err
+	}
+	if commitmentBytes < MinDigestLength || commitmentBytes > FullDigestLength {
+		return nil, fmt.Errorf("commitment length %d outside [%d, %d]", commitmentBytes, MinDigestLength, FullDigestLength)
+	}
+	contextBytes := sq.Context.Bytes()
+
+	layout := &SNARKLayout{
+		Version:         SNARKLayoutVersion,
+		Hash:            HashSuiteSHA256,
+		CommitmentBytes: commitmentBytes,
+		NumericEncoding: sq.NumericEncoding,
+	}
+	// In the order generateStateCommitment hashes them
+	for _, field := range []SNARKField{
+		{Name: "amplitudes", Visibility: SNARKPrivate, Length: 16 * dimension, Encoding: fmt.Sprintf(
+			"per amplitude, the real then the imaginary part as the 8-byte big-endian two's complement of round(x·10^%d)", sq.NumericEncoding.Precision)},
+		{Name: "identifier", Visibility: SNARKPrivate, Length: identifierLength,
+			Encoding: "the identifier as proven, before any pseudonymization"},
+		{Name: "context", Visibility: SNARKConstant, Length: len(contextBytes),
+			Encoding: "Context.Bytes()", Value: hex.EncodeToString(contextBytes)},
+		{Name: "context_key", Visibility: SNARKPrivate, Length: sha256.Size,
+			Encoding: fmt.Sprintf("HKDF-SHA256 of the proof key with salt %q and the context as info", contextKeyDomain)},
+		{Name: "nonce", Visibility: SNARKPrivate, Length: commitmentNonceLength, Encoding: "uniformly random"},
+	} {
+		field.Offset = layout.PreimageBytes
+		layout.PreimageBytes += field.Length
+		layout.Preimage = append(layout.Preimage, field)
+	}
+
+	offset := 0
+	for _, field := range []SNARKField{
+		{Name: "commitment", Length: snarkLimbCount(commitmentBytes),
+			Encoding: fmt.Sprintf("the leading commitment_bytes bytes of SHA-256(preimage), in big-endian %d-byte limbs", SNARKLimbBytes)},
+		{Name: "challenge_binding", Length: snarkLimbCount(sha256.Size),
+			Encoding: fmt.Sprintf("the proof's challenge binding, in big-endian %d-byte limbs", SNARKLimbBytes)},
+		{Name: "epoch", Length: 1, Encoding: "the proof's challenge epoch"},
+	} {
+		field.Visibility, field.Offset = SNARKPublic, offset
+		offset += field.Length
+		layout.PublicInputs = append(layout.PublicInputs, field)
+	}
+	return layout, nil
+}
+
+// snarkField returns the named field of fields.
+func snarkField(fields []SNARKField, name string) (SNARKField, error) {
+	for _, field := range fields {
+		if field.Name == name {
+			return field, nil
+		}
+	}
+	return SNARKField{}, fmt.Errorf("layout has no field %q", name)
+}
+
+// snarkLimbCount returns the number of limbs n bytes split into.
+func snarkLimbCount(n int) int {
+	return (n + SNARKLimbBytes - 1) / SNARKLimbBytes
+}
+
+// snarkLimbs splits b into big-endian SNARKLimbBytes-byte limbs, the last
+// one shorter when b is not a multiple, as decimal field elements.
+func snarkLimbs(b []byte) []string {
+	limbs := make([]string, 0, snarkLimbCount(len(b)))
+	for start := 0; start < len(b); start += SNARKLimbBytes {
+		limb := b[start:min(start+SNARKLimbBytes, len(b))]
+		limbs = append(limbs, new(big.Int).SetBytes(limb).String())
+	}
+	return limbs
+}
+
+// SNARKStatement returns the statement composing proof with a classical
+// ZK proof: its commitment, challenge binding and epoch as public inputs.
+// The binding ties the statement to this exact proof, not just to the
+// committed state. identifierLength is the length of the identifier the
+// proof was made for, len(proof.Identifier) unless it was pseudonymized.
+// It does not verify proof; verify it first.
+func (sq *SecureQuantumZKP) SNARKStatement(proof *SecureProof, identifierLength int) (*SNARKStatement, error) {
+	if proof == nil {
+		return nil, errors.New("proof cannot be nil")
+	}
+	if proof.Version != CurrentProofVersion || proof.ChallengeBinding == "" {
+		return nil, fmt.Errorf("version %d proofs without a challenge binding cannot be composed", proof.Version)
+	}
+	if !proof.Context.Equal(sq.Context) {
+		return nil, errors.New("proof was made in another context")
+	}
+	commitment, err := decodeDigestField(proof.CommitmentHash, MinDigestLength)
+	if err != nil {
+		return nil, fmt.Errorf("malformed commitment hash: %w", err)
+	}
+	binding, err := hex.DecodeString(proof.ChallengeBinding)
+	if err != nil || len(binding) != sha256.Size {
+		return nil, errors.New("malformed challenge binding")
+	}
+	layout, err := sq.snarkLayout(proof.StateMetadata.Dimension, identifierLength, len(commitment))
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := append(snarkLimbs(commitment), snarkLimbs(binding)...)
+	inputs = append(inputs, strconv.FormatUint(proof.Epoch, 10))
+	return &SNARKStatement{Layout: layout, PublicInputs: inputs}, nil
+}
+
+// SecureProveForSNARK is SecureProveState that also returns the statement
+// and witness for a classical ZK circuit proving more about the same
+// state, such as a range or an equality of amplitudes.
+func (sq *SecureQuantumZKP) SecureProveForSNARK(
+	state *StateVector,
+	identifier string,
+	key []byte,
+) (*SecureProof, *SNARKStatement, *SNARKWitness, error) {
+	capture := &openingCapture{}
+	captured := *sq
+	captured.openingCapture = capture
+	proof, err := captured.SecureProveState(state, identifier, key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	statement, err := sq.SNARKStatement(proof, len(identifier))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	witness := &SNARKWitness{Layout: statement.Layout, Preimage: hex.EncodeToString(capture.opening.bytes())}
+	if err := witness.Check(statement); err != nil {
+		return nil, nil, nil, fmt.Errorf("witness does not open the commitment: %w", err)
+	}
+	return proof, statement, witness, nil
+}
+
+// Field returns the named preimage field of w.
+func (w *SNARKWitness) Field(name string) ([]byte, error) {
+	field, err := snarkField(w.Layout.Preimage, name)
+	if err != nil {
+		return nil, err
+	}
+	preimage, err := hex.DecodeString(w.Preimage)
+	if err != nil || len(preimage) != w.Layout.PreimageBytes {
+		return nil, errors.New("malformed preimage")
+	}
+	return preimage[field.Offset : field.Offset+field.Length], nil
+}
+
+// Check reports whether w opens the commitment of statement under the
+// same layout and matches its constants, so circuit inputs can be tested
+// before they are proven.
+func (w *SNARKWitness) Check(statement *SNARKStatement) error {
+	if w.Layout == nil || statement == nil || !reflect.DeepEqual(w.Layout, statement.Layout) {
+		return errors.New("witness and statement layouts differ")
+	}
+	preimage, err := hex.DecodeString(w.Preimage)
+	if err != nil || len(preimage) != w.Layout.PreimageBytes {
+		return errors.New("malformed preimage")
+	}
+	for _, field := range w.Layout.Preimage {
+		if field.Visibility != SNARKConstant {
+			continue
+		}
+		value, _ := hex.DecodeString(field.Value)
+		if !bytes.Equal(preimage[field.Offset:field.Offset+field.Length], value) {
+			return fmt.Errorf("preimage field %s differs from the layout constant", field.Name)
+		}
+	}
+
+	field, err := snarkField(w.Layout.PublicInputs, "commitment")
+	if err != nil {
+		return err
+	}
+	if field.Offset+field.Length > len(statement.PublicInputs) {
+		return errors.New("statement has too few public inputs")
+	}
+	digest := sha256.Sum256(preimage)
+	expected := snarkLimbs(digest[:w.Layout.CommitmentBytes])
+	if !reflect.DeepEqual(statement.PublicInputs[field.Offset:field.Offset+field.Length], expected) {
+		return errors.New("preimage does not hash to the commitment")
+	}
+	return nil
+}
+
+// stateCommitmentOpening is the preimage of a state commitment, field by
+// field in the order generateStateCommitment hashes them.
+type stateCommitmentOpening struct {
+	Amplitudes []byte
+	Identifier []byte
+	Context    []byte
+	ContextKey []byte
+	Nonce      []byte
+}
+
+// bytes returns the concatenated preimage.
+func (o *stateCommitmentOpening) bytes() []byte {
+	return bytes.Join([][]byte{o.Amplitudes, o.Identifier, o.Context, o.ContextKey, o.Nonce}, nil)
+}
+
+// openingCapture keeps the opening of the state commitment of one proof.
+// Only proofs made for composition capture one, so the others do not
+// hold a second copy of the state.
+type openingCapture struct {
+	opening *stateCommitmentOpening
+}
+
+// capture records the opening of a commitment sq made to state.
+func (c *openingCapture) capture(state *StateVector, identifier string, sq *SecureQuantumZKP, contextKey, nonce []byte) error {
+	amplitudes, err := state.encodeAmplitudes(sq.NumericEncoding)
+	if err != nil {
+		return err
+	}
+	c.opening = &stateCommitmentOpening{
+		Amplitudes: amplitudes,
+		Identifier: []byte(identifier),
+		Context:    sq.Context.Bytes(),
+		ContextKey: contextKey,
+		Nonce:      nonce,
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+	"strings"
+
+	"lukechampine.com/blake3"
+)
+
+// witnessDomain separates witness challenge derivation from every other
+// use of the published commitment.
+const witnessDomain = "qzkp/state-family-witness/v1"
+
+// State families a proof can claim the possessed state belongs to.
+const (
+	StateFamilyBell = "bell" // (|00⟩ + |11⟩)/√2
+	StateFamilyGHZ  = "ghz"  // (|0…0⟩ + |1…1⟩)/√2 on 3 or more qubits
+)
+
+// WitnessFidelityBound is the fidelity with the family's ideal state a
+// prover must exceed. Above 1/2 the witness 1/2 − |ψ⟩⟨ψ| of the ideal
+// state ψ is negative, which no biseparable state achieves, so the state
+// is entangled the way the family is.
+const WitnessFidelityBound = 0.5
+
+// witnessChallengeCount is the number of witness challenges per proof.
+const witnessChallengeCount = 16
+
+// ErrNotInStateFamily is returned when a state does not witness the
+// family a proof would claim.
+var ErrNotInStateFamily = errors.New("state does not witness the claimed family")
+
+// FamilyWitness binds a claim that the proven state belongs to a state
+// family. Its challenges are drawn, from the proof's commitment and the
+// claim, among stabilizers of the family's ideal state; each response
+// commits to the state's expectation value of the drawn operator, which
+// is +1 for the ideal state. The prover only creates a witness for a state
+// whose fidelity with the ideal state exceeds FidelityBound.
+type FamilyWitness struct {
+	Family        string            `json:"family"`
+	Qubits        int               `json:"qubits"`
+	FidelityBound float64           `json:"fidelity_bound"`
+	Responses     []WitnessResponse `json:"responses"`
+}
+
+// WitnessResponse answers one witness challenge.
+type WitnessResponse struct {
+	// Operator is a Pauli string with a sign, e.g. "-YY"; character k acts
+	// on qubit k, which is bit k of the basis index
+	Operator   string `json:"operator"`
+	Commitment string `json:"commitment"` // to the expectation value
+	Response   string `json:"response"`
+}
+
+// ProveBellPairPossession proves knowledge of a two-qubit state close to
+// the Bell state (|00⟩ + |11⟩)/√2 and binds that claim into the proof.
+func (sq *SecureQuantumZKP) ProveBellPairPossession(vector []complex128, identifier string, key []byte) (*SecureProof, error) {
+	return sq.proveFamilyPossession(StateFamilyBell, 2, vector, identifier, key)
+}
+
+// ProveGHZPossession proves knowledge of an n-qubit state close to the GHZ
+// state (|0…0⟩ + |1…1⟩)/√2 and binds that claim into the proof.
+func (sq *SecureQuantumZKP) ProveGHZPossession(n int, vector []complex128, identifier string, key []byte) (*SecureProof, error) {
+	return sq.proveFamilyPossession(StateFamilyGHZ, n, vector, identifier, key)
+}
+
+// proveFamilyPossession builds a proof of knowledge of vector carrying a
+// FamilyWitness for family.
+func (sq *SecureQuantumZKP) proveFamilyPossession(family string, qubits int, vector []complex128, identifier string, key []byte) (*SecureProof, error) {
+	operators, err := witnessOperators(family, qubits)
+	if err != nil {
+		return nil, err
+	}
+	// witnessOperators has bounded qubits, so the dimension fits
+	if dimension, _ := checkedPow2(qubits); len(vector) != dimension {
+		return nil, fmt.Errorf("a %d-qubit %s state has %d amplitudes, got %d", qubits, family, dimension, len(vector))
+	}
+	state, err := NewStateVector(vector)
+	if err != nil {
+		return nil, err
+	}
+	if f := familyFidelity(state.amplitudes); f <= WitnessFidelityBound {
+		return nil, fmt.Errorf("%w: fidelity %.3f with the ideal %s state is not above %.1f", ErrNotInStateFamily, f, family, WitnessFidelityBound)
+	}
+
+	sq, randomness := sq.withRandomnessTrace()
+	published, err := sq.publishIdentifier(identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish identifier: %w", err)
+	}
+	proof, err := sq.buildSecureProof(state, identifier, key, published)
+	if err != nil {
+		return nil, err
+	}
+	witness := &FamilyWitness{Family: family, Qubits: qubits, FidelityBound: WitnessFidelityBound}
+	challenges, err := sq.deriveWitnessChallenges(proof, witness, operators)
+	if err != nil {
+		return nil, err
+	}
+	contextKey, err := sq.contextKey(key)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range challenges {
+		response, err := sq.respondToWitnessChallenge(c, pauliExpectation(state.amplitudes, c.operator), contextKey)
+		if err != nil {
+			return nil, err
+		}
+		witness.Responses = append(witness.Responses, response)
+	}
+	proof.Witness = witness
+	proof.Randomness = randomness.Classes()
+
+	if err := sq.signSecureProof(proof, key); err != nil {
+		return nil, fmt.Errorf("failed to sign proof: %w", err)
+	}
+	return proof, nil
+}
+
+// witnessChallenge is one derived witness challenge.
+type witnessChallenge struct {
+	operator string
+	nonce    []byte
+}
+
+// deriveWitnessChallenges expands (commitment ‖ ctx ‖ epoch ‖ family ‖
+// qubits) into witness challenges, each an operator drawn uniformly from
+// operators and a nonce. The claim is part of the seed, so a witness
+// cannot be moved to another family or register size.
+func (sq *SecureQuantumZKP) deriveWitnessChallenges(proof *SecureProof, witness *FamilyWitness, operators []string) ([]witnessChallenge, error) {
+	commitment, err := decodeDigestField(proof.CommitmentHash, 1)
+	if err != nil {
+		return nil, fmt.Errorf("commitment hash: %w", err)
+	}
+	hasher := blake3.New(32, nil)
+	hasher.Write([]byte(witnessDomain))
+	writeChallengeSeed(hasher, commitment, proof.Context.Bytes(), proof.Epoch, proof.StateMetadata.Dimension)
+	writeLengthPrefixed(hasher, []byte(witness.Family))
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(witness.Qubits))
+	hasher.Write(buf[:])
+	xof := hasher.XOF()
+
+	limit := ^uint64(0) - (^uint64(0) % uint64(len(operators)))
+	challenges := make([]witnessChallenge, witnessChallengeCount)
+	for i := range challenges {
+		var index uint64
+		for {
+			if _, err := xof.Read(buf[:]); err != nil {
+				return nil, err
+			}
+			if index = binary.BigEndian.Uint64(buf[:]); index < limit {
+				break
+			}
+		}
+		nonce := make([]byte, proof.challengeNonceLength())
+		if _, err := xof.Read(nonce); err != nil {
+			return nil, err
+		}
+		challenges[i] = witnessChallenge{operator: operators[index%uint64(len(operators))], nonce: nonce}
+	}
+	return challenges, nil
+}
+
+// respondToWitnessChallenge commits to the expectation value of the
+// challenge operator. The response binds the commitment to the challenge,
+// so the verifier can recompute it without learning the value.
+func (sq *SecureQuantumZKP) respondToWitnessChallenge(c witnessChallenge, expectation float64, key []byte) (WitnessResponse, error) {
+	hasher := sha256.New()
+	if err := sq.NumericEncoding.writeNumbers(hasher, expectation); err != nil {
+		return WitnessResponse{}, err
+	}
+	hasher.Write([]byte(c.operator))
+	hasher.Write(c.nonce)
+	hasher.Write(key)
+	commitment := hasher.Sum(nil)[:sq.DigestLengths.Response]
+	return WitnessResponse{
+		Operator:   c.operator,
+		Commitment: EncodeHexField(commitment),
+		Response:   EncodeHexField(witnessResponseDigest(c, commitment)[:sq.DigestLengths.Response]),
+	}, nil
+}
+
+// witnessResponseDigest is SHA-256(operator ‖ nonce ‖ commitment).
+func witnessResponseDigest(c witnessChallenge, commitment []byte) []byte {
+	hasher := sha256.New()
+	hasher.Write([]byte(c.operator))
+	hasher.Write(c.nonce)
+	hasher.Write(commitment)
+	return hasher.Sum(nil)
+}
+
+// verifyFamilyWitness checks that the witness is for a known family, that
+// its register matches the proof's dimension and that every response
+// answers the witness challenge derived from the proof.
+func (sq *SecureQuantumZKP) verifyFamilyWitness(proof *SecureProof) error {
+	witness := proof.Witness
+	operators, err := witnessOperators(witness.Family, witness.Qubits)
+	if err != nil {
+		return err
+	}
+	if dimension, _ := checkedPow2(witness.Qubits); proof.StateMetadata.Dimension != dimension {
+		return fmt.Errorf("a %d-qubit witness does not fit dimension %d", witness.Qubits, proof.StateMetadata.Dimension)
+	}
+	if witness.FidelityBound != WitnessFidelityBound {
+		return fmt.Errorf("fidelity bound %v, expected %v", witness.FidelityBound, WitnessFidelityBound)
+	}
+	if len(witness.Responses) != witnessChallengeCount {
+		return fmt.Errorf("%d witness responses, expected %d", len(witness.Responses), witnessChallengeCount)
+	}
+	challenges, err := sq.deriveWitnessChallenges(proof, witness, operators)
+	if err != nil {
+		return err
+	}
+	lengths := proof.digestLengths()
+	for i, c := range challenges {
+		response := witness.Responses[i]
+		if response.Operator != c.operator {
+			return fmt.Errorf("witness response %d answers %q, challenge was %q", i, response.Operator, c.operator)
+		}
+		commitment, err := DecodeHexField(response.Commitment, lengths.Response, lengths.Response)
+		if err != nil {
+			return fmt.Errorf("witness response %d commitment: %w", i, err)
+		}
+		if response.Response != EncodeHexField(witnessResponseDigest(c, commitment)[:lengths.Response]) {
+			return fmt.Errorf("witness response %d does not match its commitment", i)
+		}
+	}
+	return nil
+}
+
+// RequireStateFamily requires the proof to carry a witness for family,
+// with at least minQubits qubits.
+func RequireStateFamily(family string, minQubits int) Rule {
+	return parameterizedRule("required_state_family", []interface{}{family, minQubits}, func(proof *SecureProof) error {
+		if proof.Witness == nil {
+			return errors.New("proof carries no state family witness")
+		}
+		if proof.Witness.Family != family {
+			return fmt.Errorf("state family %q does not match required %q", proof.Witness.Family, family)
+		}
+		if proof.Witness.Qubits < minQubits {
+			return fmt.Errorf("%d-qubit witness, at least %d required", proof.Witness.Qubits, minQubits)
+		}
+		return nil
+	})
+}
+
+// witnessOperators returns the stabilizers of the family's ideal state the
+// witness challenges are drawn from: XX, −YY and ZZ for the Bell state;
+// X…X, −YYX…X and each neighbouring ZZ pair for GHZ.
+func witnessOperators(family string, qubits int) ([]string, error) {
+	switch family {
+	case StateFamilyBell:
+		if qubits != 2 {
+			return nil, fmt.Errorf("a Bell pair has 2 qubits, got %d", qubits)
+		}
+		return []string{"+XX", "-YY", "+ZZ"}, nil
+	case StateFamilyGHZ:
+		if dimension, err := checkedPow2(qubits); err != nil || qubits < 3 || dimension > maxProofDimension {
+			return nil, fmt.Errorf("a GHZ witness needs 3 to %d qubits, got %d", bits.Len(maxProofDimension)-1, qubits)
+		}
+		operators := []string{"+" + strings.Repeat("X", qubits), "-YY" + strings.Repeat("X", qubits-2)}
+		for q := 0; q+1 < qubits; q++ {
+			operators = append(operators, "+"+strings.Repeat("I", q)+"ZZ"+strings.Repeat("I", qubits-q-2))
+		}
+		return operators, nil
+	default:
+		return nil, fmt.Errorf("unknown state family %q", family)
+	}
+}
+
+// familyFidelity is |⟨ψ|state⟩|² for ψ = (|0…0⟩ + |1…1⟩)/√2, the ideal
+// state of both families.
+func familyFidelity(amplitudes []complex128) float64 {
+	overlap := (amplitudes[0] + amplitudes[len(amplitudes)-1]) / complex(math.Sqrt2, 0)
+	return real(overlap)*real(overlap) + imag(overlap)*imag(overlap)
+}
+
+// pauliExpectation is ⟨state|P|state⟩ for a signed Pauli string P.
+func pauliExpectation(amplitudes []complex128, operator string) float64 {
+	sign, paulis := 1.0, operator[1:]
+	if operator[0] == '-' {
+		sign = -1
+	}
+	var sum complex128
+	for x, amplitude := range amplitudes {
+		// P|x⟩ = phase·|y⟩
+		y, phase := x, complex(1, 0)
+		for q, p := range paulis {
+			bit := x >> q & 1
+			switch p {
+			case 'X':
+				y ^= 1 << q
+			case 'Y':
+				y ^= 1 << q
+				phase *= complex(0, 1-2*float64(bit))
+			case 'Z':
+				phase *= complex(1-2*float64(bit), 0)
+			}
+		}
+		sum += cmplx.Conj(amplitudes[y]) * phase * amplitude
+	}
+	return sign * real(sum)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"iter"
+	"strings"
+)
+
+// storeReadBatch is how many directory entries are read at a time while
+// iterating a store.
+const storeReadBatch = 256
+
+// StoredProof is one proof read from a ProofStore.
+type StoredProof struct {
+	Name  string
+	Proof *SecureProof // nil when the proof could not be loaded
+}
+
+// ProofFilter selects proofs during iteration; a nil filter selects all.
+type ProofFilter func(name string, proof *SecureProof) bool
+
+// iterStoreEntries yields the names of files in dir with ext in directory
+// order, reading the directory in batches.
+func iterStoreEntries(dir, ext string) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		f, err := hostFS.Open(dir)
+		if err != nil {
+			yield("", err)
+			return
+		}
+		defer f.Close()
+		for {
+			entries, err := f.ReadDir(storeReadBatch)
+			for _, entry := range entries {
+				if !entry.IsDir() && strings.HasSuffix(entry.Name(), ext) {
+					if !yield(strings.TrimSuffix(entry.Name(), ext), nil) {
+						return
+					}
+				}
+			}
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield("", err)
+				return
+			}
+		}
+	}
+}
+
+// IterProofs streams the stored proofs selected by filter, loading one at
+// a time, so stores of any size can be processed in constant memory.
+// Proofs come in directory order, not sorted. A proof that fails to load
+// is yielded with its error and iteration continues.
+func (ps *ProofStore) IterProofs(filter ProofFilter) iter.Seq2[StoredProof, error] {
+	return func(yield func(StoredProof, error) bool) {
+		for name, err := range ps.entries(proofFileExt) {
+			if err != nil {
+				yield(StoredProof{}, err)
+				return
+			}
+			if validateStoreName(name) != nil {
+				continue
+			}
+			proof, err := ps.Load(name)
+			if err != nil {
+				if !yield(StoredProof{Name: name}, err) {
+					return
+				}
+				continue
+			}
+			if filter != nil && !filter(name, proof) {
+				continue
+			}
+			if !yield(StoredProof{Name: name, Proof: proof}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// StoredVerification is the outcome for one proof verified by
+// VerifyStored.
+type StoredVerification struct {
+	Name   string
+	Result *VerificationResult // nil when Err is set
+	Err    error               // the proof could not be loaded
+}
+
+// VerifyStored verifies proofs as they are produced, e.g. by
+// ProofStore.IterProofs, yielding one outcome per proof. Nothing is
+// buffered, so stopping early stops loading.
+func (sq *SecureQuantumZKP) VerifyStored(proofs iter.Seq2[StoredProof, error], key []byte, opts VerifyOptions) iter.Seq[StoredVerification] {
+	return func(yield func(StoredVerification) bool) {
+		for stored, err := range proofs {
+			outcome := StoredVerification{Name: stored.Name, Err: err}
+			if err == nil {
+				outcome.Result = sq.VerifySecureProofWithOptions(stored.Proof, key, opts)
+			}
+			if !yield(outcome) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// VerifyOptions adjusts VerifySecureProofWithOptions.
+type VerifyOptions struct {
+	// StrictMode accepts only the hardened protocol: proofs that rely on
+	// a deprecated structure are rejected even where their format version
+	// is still supported, and every such structure is listed in the
+	// result's Reasons
+	StrictMode bool
+	// NonceStore, when set, refuses replayed proofs: a valid proof's
+	// commitment is recorded until ReplayWindow after the proof was made,
+	// and proofs older than that are refused as stale
+	NonceStore   NonceStore
+	ReplayWindow time.Duration // DefaultReplayWindow if zero
+}
+
+// VerifySecureProofWithOptions verifies a proof of any supported version
+// like VerifySecureProofVersioned, then applies opts.
+func (sq *SecureQuantumZKP) VerifySecureProofWithOptions(proof *SecureProof, key []byte, opts VerifyOptions) *VerificationResult {
+	result := sq.VerifySecureProofVersioned(proof, key)
+	sq.applyVerifyOptions(proof, result, opts)
+	return result
+}
+
+// applyVerifyOptions applies opts to the result of verifying proof.
+func (sq *SecureQuantumZKP) applyVerifyOptions(proof *SecureProof, result *VerificationResult, opts VerifyOptions) {
+	if opts.StrictMode {
+		if violations := sq.strictModeViolations(proof); len(violations) > 0 {
+			result.Reasons = append(result.Reasons, violations...)
+			result.Valid = false
+		}
+	}
+	// Only proofs that are otherwise valid consume a nonce
+	if opts.NonceStore != nil && result.Valid {
+		if err := sq.consumeProofNonce(proof, opts.NonceStore, opts.ReplayWindow); err != nil {
+			result.Reasons = append(result.Reasons, err.Error())
+			result.Valid = false
+		}
+	}
+}
+
+// strictModeViolations lists every deprecated or insecure structure proof
+// relies on. It checks structure only; signatures and responses are left
+// to the version's verifier.
+func (sq *SecureQuantumZKP) strictModeViolations(proof *SecureProof) []string {
+	if proof == nil {
+		return []string{"no proof"}
+	}
+	var reasons []string
+	version := proof.Version
+	if version == 0 {
+		version = ProofVersionLegacy
+	}
+	if version != CurrentProofVersion {
+		reasons = append(reasons, fmt.Sprintf("proof format version %d is deprecated; version %d is required", version, CurrentProofVersion))
+	}
+
+	// Deprecated digest truncation
+	if proof.DigestLengths == nil {
+		reasons = append(reasons, fmt.Sprintf("digests use the deprecated fixed truncation to %d-byte commitments and %d-byte responses",
+			LegacyDigestLengths.Commitment, LegacyDigestLengths.Response))
+	} else if soundness := proofSoundness(proof); !proof.DigestLengths.AtLeast(DigestLengthsFor(soundness)) {
+		required := DigestLengthsFor(soundness)
+		reasons = append(reasons, fmt.Sprintf("digests are truncated to %d-byte commitments and %d-byte responses; %d-bit soundness requires %d and %d",
+			proof.DigestLengths.Commitment, proof.DigestLengths.Response, soundness, required.Commitment, required.Response))
+	}
+
+	// Key binding: the signature must cover the context string and the
+	// public parameters the key signed for
+	if version == ProofVersionLegacy {
+		reasons = append(reasons, "signature was made without an ML-DSA context string")
+	}
+	if proof.ParametersHash == "" {
+		reasons = append(reasons, "public parameters are not bound to the proof")
+	}
+	if proof.KeyDerivation != KeyDerivationHKDFContext {
+		reasons = append(reasons, "prover secret was not derived for the proof context")
+	}
+
+	// Fiat–Shamir: challenges must be derived from the commitment.
+	// Interactive proofs are bound to a verifier session instead, and a
+	// soundness mismatch already fails verification
+	derivable := len(proof.ChallengeResponse) == sq.ChallengeCount()
+	if proof.Session == nil && (version == ProofVersionLegacy || derivable && !sq.verifyDerivedChallenges(proof)) {
+		reasons = append(reasons, "challenges were not derived from the commitment (Fiat–Shamir)")
+	}
+	if proof.ChallengeBinding == "" {
+		reasons = append(reasons, "responses are not bound to the full challenges they answer")
+	}
+
+	// Legacy codecs
+	if version == ProofVersionLegacy || proof.NumericEncoding.Validate() != nil {
+		reasons = append(reasons, "commitments do not use the fixed-point numeric encoding")
+	}
+	if proof.DataEncoding != nil && proof.DataEncoding.Version < BytesEncoderVersion {
+		reasons = append(reasons, fmt.Sprintf("byte encoder version %d is superseded by version %d",
+			proof.DataEncoding.Version, BytesEncoderVersion))
+	}
+	return reasons
+}
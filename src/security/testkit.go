@@ -0,0 +1,150 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+)
+
+// TestKitApplication is the context application of test kit proofs.
+const TestKitApplication = "qzkp-testkit"
+
+// TestKitDefect is one defect a test kit can mint a proof with.
+type TestKitDefect struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// TestKitInfo is what a test kit publishes about itself. The proof key is
+// published because the kit's keys are throwaway test material.
+type TestKitInfo struct {
+	ProofVersion int             `json:"proof_version"`
+	Context      Context         `json:"context"`
+	PublicKey    string          `json:"public_key"` // ML-DSA-87, hex
+	Key          string          `json:"key"`        // proof key, hex
+	Defects      []TestKitDefect `json:"defects"`
+}
+
+// TestKitProof is a proof minted by a test kit and whether a conforming
+// verifier accepts it.
+type TestKitProof struct {
+	Defect      string       `json:"defect"` // CorpusVariantValid for a valid proof
+	Description string       `json:"description"`
+	Valid       bool         `json:"valid"`
+	Proof       *SecureProof `json:"proof"`
+}
+
+// TestKit mints valid proofs, and proofs with a chosen conformance defect,
+// under throwaway keys, and verifies proofs against them. It lets projects
+// that produce, relay or check proofs test their error handling against
+// this implementation in CI without touching real keys.
+type TestKit struct {
+	sq  *SecureQuantumZKP
+	key []byte
+}
+
+// NewTestKit creates a test kit whose signing and proof keys are derived
+// from a 32-byte seed, so that CI runs can pin them, or are random if seed
+// is nil.
+func NewTestKit(seed []byte) (*TestKit, error) {
+	if seed == nil {
+		seed = make([]byte, 32)
+		if _, err := rand.Read(seed); err != nil {
+			return nil, err
+		}
+	}
+	if len(seed) != 32 {
+		return nil, fmt.Errorf("test kit seed must be 32 bytes, got %d", len(seed))
+	}
+	sq, err := NewSecureQuantumZKP(3, 128, []byte(TestKitApplication))
+	if err != nil {
+		return nil, err
+	}
+	if sq.Signer, err = NewSignatureSchemeFromSeed(corpusBytes(seed, "testkit/signing-key", 32), []byte(TestKitApplication)); err != nil {
+		return nil, err
+	}
+	return &TestKit{sq: sq, key: corpusBytes(seed, "testkit/proof-key", 32)}, nil
+}
+
+// Info describes the kit's keys and the defects it can mint.
+func (k *TestKit) Info() *TestKitInfo {
+	info := &TestKitInfo{
+		ProofVersion: CurrentProofVersion,
+		Context:      k.sq.Context,
+		PublicKey:    hex.EncodeToString(k.sq.Signer.PublicKeyBytes()),
+		Key:          hex.EncodeToString(k.key),
+	}
+	for _, name := range TestKitDefects() {
+		info.Defects = append(info.Defects, TestKitDefect{Name: name, Description: conformanceDescription(name)})
+	}
+	return info
+}
+
+// TestKitDefects lists the defects a test kit can mint: every conformance
+// vector except ConformanceReplay, which depends on the verifier's state
+// rather than on the proof.
+func TestKitDefects() []string {
+	var defects []string
+	for _, name := range ConformanceVectors() {
+		if name != ConformanceReplay {
+			defects = append(defects, name)
+		}
+	}
+	return defects
+}
+
+// Mint proves knowledge of a fixed vector, with fresh nonces each time,
+// and applies defect to the proof. An empty defect or CorpusVariantValid
+// mints a valid proof.
+func (k *TestKit) Mint(defect string) (*TestKitProof, error) {
+	proof, err := k.sq.SecureProveVectorKnowledge([]complex128{complex(0.6, 0), complex(0, 0.8)}, "testkit", k.key)
+	if err != nil {
+		return nil, err
+	}
+	if defect == "" || defect == CorpusVariantValid {
+		return &TestKitProof{Defect: CorpusVariantValid, Description: "untampered proof", Valid: true, Proof: proof}, nil
+	}
+	if defect == ConformanceReplay {
+		return nil, fmt.Errorf("defect %q cannot be minted", defect)
+	}
+	if proof, err = adversarialProof(k.sq, proof, k.key, defect); err != nil {
+		return nil, err
+	}
+	return &TestKitProof{Defect: defect, Description: conformanceDescription(defect), Proof: proof}, nil
+}
+
+// Verify checks proof against the kit's keys.
+func (k *TestKit) Verify(proof *SecureProof) *VerificationResult {
+	if proof == nil {
+		return &VerificationResult{Reasons: []string{"no proof"}}
+	}
+	return k.sq.VerifySecureProofVersioned(proof, k.key)
+}
+
+// Handler returns the kit's HTTP API: GET /v1/info returns its TestKitInfo,
+// GET /v1/mint?defect=name mints a TestKitProof, and POST /v1/verify takes
+// a VerifyRequest and answers with the VerificationResult.
+func (k *TestKit) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/info", func(w http.ResponseWriter, r *http.Request) {
+		writeJSONResponse(w, r, k.Info())
+	})
+	mux.HandleFunc("GET /v1/mint", func(w http.ResponseWriter, r *http.Request) {
+		minted, err := k.Mint(r.URL.Query().Get("defect"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSONResponse(w, r, minted)
+	})
+	mux.HandleFunc("POST /v1/verify", func(w http.ResponseWriter, r *http.Request) {
+		var req VerifyRequest
+		if err := DecodeBoundedJSON(r.Body, ProofJSONLimits, &req); err != nil {
+			http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSONResponse(w, r, k.Verify(req.Proof))
+	})
+	return mux
+}
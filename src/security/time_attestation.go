@@ -0,0 +1,257 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TimeAttestation is a signed statement from a time authority that the
+// current time was Midpoint ± Radius when it saw Nonce, in the style of
+// Roughtime responses.
+type TimeAttestation struct {
+	Authority string    `json:"authority"`
+	Nonce     string    `json:"nonce"`
+	Midpoint  time.Time `json:"midpoint"`
+	Radius    int64     `json:"radius_ms"`
+	Signature string    `json:"signature"`
+}
+
+// TimeAuthority produces and checks time attestations. Implementations are
+// plugged into SecureQuantumZKP.TimeAuthority; leaving it nil keeps proofs
+// on the prover's self-reported Timestamp.
+type TimeAuthority interface {
+	Name() string
+	Attest(nonce []byte) (*TimeAttestation, error)
+	VerifyAttestation(attestation *TimeAttestation, nonce []byte) bool
+}
+
+// attestationMessage is the byte string signed by a time authority.
+func attestationMessage(nonce []byte, midpoint time.Time, radius int64) []byte {
+	msg := []byte("qzkp/time-attestation/v1")
+	msg = append(msg, nonce...)
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(midpoint.UnixNano()))
+	msg = append(msg, buf[:]...)
+	binary.BigEndian.PutUint64(buf[:], uint64(radius))
+	return append(msg, buf[:]...)
+}
+
+// verifyEd25519Attestation checks an attestation against an authority's
+// public key.
+func verifyEd25519Attestation(publicKey ed25519.PublicKey, name string, attestation *TimeAttestation, nonce []byte) bool {
+	if attestation == nil || attestation.Authority != name || attestation.Radius < 0 {
+		return false
+	}
+	if attestation.Nonce != hex.EncodeToString(nonce) {
+		return false
+	}
+	sig, err := hex.DecodeString(attestation.Signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(publicKey, attestationMessage(nonce, attestation.Midpoint, attestation.Radius), sig)
+}
+
+// Ed25519TimeAuthority signs attestations with a local Ed25519 key and
+// clock. It is the server side of HTTPTimeAuthority and is also useful for
+// self-hosted deployments and tests.
+type Ed25519TimeAuthority struct {
+	AuthorityName string
+	PrivateKey    ed25519.PrivateKey
+	Radius        time.Duration
+	Clock         func() time.Time
+}
+
+// NewEd25519TimeAuthority creates an authority with a fresh key pair.
+func NewEd25519TimeAuthority(name string, radius time.Duration) (*Ed25519TimeAuthority, error) {
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil, fmt.Errorf("key generation failed: %w", err)
+	}
+	return &Ed25519TimeAuthority{
+		AuthorityName: name,
+		PrivateKey:    priv,
+		Radius:        radius,
+		Clock:         time.Now,
+	}, nil
+}
+
+// Name implements TimeAuthority.
+func (a *Ed25519TimeAuthority) Name() string {
+	return a.AuthorityName
+}
+
+// PublicKey returns the key clients use to verify attestations.
+func (a *Ed25519TimeAuthority) PublicKey() ed25519.PublicKey {
+	return a.PrivateKey.Public().(ed25519.PublicKey)
+}
+
+// Attest implements TimeAuthority.
+func (a *Ed25519TimeAuthority) Attest(nonce []byte) (*TimeAttestation, error) {
+	midpoint := a.Clock().UTC()
+	radius := a.Radius.Milliseconds()
+	sig := ed25519.Sign(a.PrivateKey, attestationMessage(nonce, midpoint, radius))
+	return &TimeAttestation{
+		Authority: a.AuthorityName,
+		Nonce:     hex.EncodeToString(nonce),
+		Midpoint:  midpoint,
+		Radius:    radius,
+		Signature: hex.EncodeToString(sig),
+	}, nil
+}
+
+// VerifyAttestation implements TimeAuthority.
+func (a *Ed25519TimeAuthority) VerifyAttestation(attestation *TimeAttestation, nonce []byte) bool {
+	return verifyEd25519Attestation(a.PublicKey(), a.AuthorityName, attestation, nonce)
+}
+
+// ServeHTTP answers GET ?nonce=<hex> with a JSON TimeAttestation.
+func (a *Ed25519TimeAuthority) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	nonce, err := hex.DecodeString(r.URL.Query().Get("nonce"))
+	if err != nil || len(nonce) == 0 {
+		http.Error(w, "invalid nonce", http.StatusBadRequest)
+		return
+	}
+	attestation, err := a.Attest(nonce)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(attestation)
+}
+
+// HTTPTimeAuthority fetches attestations from a remote Ed25519TimeAuthority
+// and checks them against its pinned public key.
+type HTTPTimeAuthority struct {
+	AuthorityName string
+	URL           string
+	PublicKey     ed25519.PublicKey
+	Client        *http.Client
+}
+
+// NewHTTPTimeAuthority creates a client for the authority served at rawURL.
+func NewHTTPTimeAuthority(name, rawURL string, publicKey ed25519.PublicKey) *HTTPTimeAuthority {
+	return &HTTPTimeAuthority{
+		AuthorityName: name,
+		URL:           rawURL,
+		PublicKey:     publicKey,
+		Client:        &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name implements TimeAuthority.
+func (a *HTTPTimeAuthority) Name() string {
+	return a.AuthorityName
+}
+
+// HostAccess implements HostBound.
+func (a *HTTPTimeAuthority) HostAccess() string {
+	return a.URL
+}
+
+// Attest implements TimeAuthority.
+func (a *HTTPTimeAuthority) Attest(nonce []byte) (*TimeAttestation, error) {
+	u, err := url.Parse(a.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authority URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("nonce", hex.EncodeToString(nonce))
+	u.RawQuery = q.Encode()
+
+	resp, err := a.Client.Get(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("time authority request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("time authority returned %s", resp.Status)
+	}
+
+	var attestation TimeAttestation
+	if err := json.NewDecoder(resp.Body).Decode(&attestation); err != nil {
+		return nil, fmt.Errorf("failed to decode attestation: %w", err)
+	}
+	if !a.VerifyAttestation(&attestation, nonce) {
+		return nil, errors.New("time authority returned an invalid attestation")
+	}
+	return &attestation, nil
+}
+
+// VerifyAttestation implements TimeAuthority.
+func (a *HTTPTimeAuthority) VerifyAttestation(attestation *TimeAttestation, nonce []byte) bool {
+	return verifyEd25519Attestation(a.PublicKey, a.AuthorityName, attestation, nonce)
+}
+
+// attestationNonce binds a time attestation to a specific proof.
+func attestationNonce(proof *SecureProof) []byte {
+	hasher := sha256.New()
+	hasher.Write([]byte(proof.CommitmentHash))
+	hasher.Write([]byte(proof.MerkleRoot))
+	hasher.Write([]byte(proof.Identifier))
+	return hasher.Sum(nil)
+}
+
+// attachTimeAttestation obtains an attestation for proof when a time
+// authority is configured.
+func (sq *SecureQuantumZKP) attachTimeAttestation(proof *SecureProof) error {
+	if sq.TimeAuthority == nil {
+		return nil
+	}
+	if err := sq.checkHostAccess(sq.TimeAuthority); err != nil {
+		return err
+	}
+	attestation, err := sq.TimeAuthority.Attest(attestationNonce(proof))
+	if err != nil {
+		return fmt.Errorf("failed to obtain time attestation: %w", err)
+	}
+	proof.TimeAttestation = attestation
+	return nil
+}
+
+// verifyTimeAttestation checks the attestation embedded in proof, if any.
+// A proof carrying an attestation is rejected when no authority is
+// configured to check it.
+func (sq *SecureQuantumZKP) verifyTimeAttestation(proof *SecureProof) bool {
+	if proof.TimeAttestation == nil {
+		return true
+	}
+	if sq.TimeAuthority == nil {
+		return false
+	}
+	return sq.TimeAuthority.VerifyAttestation(proof.TimeAttestation, attestationNonce(proof))
+}
+
+// VerifySecureProofWithin verifies proof and additionally requires an
+// attested creation time no older than maxAge at now, allowing for the
+// attestation's uncertainty radius.
+func (sq *SecureQuantumZKP) VerifySecureProofWithin(
+	proof *SecureProof,
+	key []byte,
+	now time.Time,
+	maxAge time.Duration,
+) bool {
+	if proof == nil || proof.TimeAttestation == nil {
+		return false
+	}
+	if !sq.VerifySecureProof(proof, key) {
+		return false
+	}
+
+	radius := time.Duration(proof.TimeAttestation.Radius) * time.Millisecond
+	earliest := proof.TimeAttestation.Midpoint.Add(-radius)
+	latest := proof.TimeAttestation.Midpoint.Add(radius)
+	if now.Before(earliest) {
+		return false // attested in the future
+	}
+	return now.Sub(latest) <= maxAge
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Transcript is the public record of how a proof was put together: every
+// byte string the prover hashed or signed whose inputs are public, in the
+// exact form it was hashed, with the resulting digest. It lets auditors
+// and other implementations recompute a proof's parameters hash,
+// challenges, Merkle root, challenge binding and signed message with
+// nothing but a hash library. Inputs that depend on the witness or the
+// key, such as the commitment preimage and the response digests, are never
+// included.
+type Transcript struct {
+	ProofVersion int `json:"proof_version"`
+	// Parameters is absent for version 2 proofs, which bind none
+	Parameters *TranscriptStep `json:"parameters,omitempty"`
+	// ChallengeDerivation seeds the BLAKE3 XOF that Challenges are read
+	// from, in order: an 8-byte big-endian index drawn by rejection
+	// sampling below the largest multiple of the dimension, then the basis
+	// angle in ⌈log2(space)/8⌉ bytes masked to the challenge space, then
+	// the challenge nonce. Absent for interactive proofs, whose challenges
+	// the verifier chose.
+	ChallengeDerivation *TranscriptStep `json:"challenge_derivation,omitempty"`
+	Challenges          []Challenge     `json:"challenges,omitempty"`
+	// ResponseLeaves are SHA-256 of each response's JSON encoding
+	ResponseLeaves []TranscriptStep `json:"response_leaves"`
+	// MerkleLevels lists the response tree from the leaves to the root,
+	// each node SHA-256(left ‖ right), an odd last node paired with itself
+	MerkleLevels     [][]string      `json:"merkle_levels"`
+	ChallengeBinding *TranscriptStep `json:"challenge_binding,omitempty"`
+	// Signature is the message the proof signature covers: the proof JSON
+	// without its signatures and batch inclusion. For batch-signed proofs
+	// BatchLeaf and BatchRoot follow it; see SignProofBatch.
+	Signature *TranscriptStep `json:"signature"`
+	BatchLeaf *TranscriptStep `json:"batch_leaf,omitempty"`
+	BatchRoot *TranscriptStep `json:"batch_root,omitempty"`
+}
+
+// TranscriptStep is one hash or signature computation: Algorithm applied
+// to Input gives Output. Claimed is the value the proof carries, when it
+// carries one; a step whose Output differs from it did not reproduce.
+type TranscriptStep struct {
+	Algorithm string `json:"algorithm"`
+	Input     string `json:"input"` // hex
+	Output    string `json:"output"`
+	Claimed   string `json:"claimed,omitempty"`
+	// SignatureContext is the ML-DSA context string of signature steps
+	SignatureContext string `json:"signature_context,omitempty"`
+}
+
+// ExtractTranscript recomputes the public steps of proof under sq's
+// parameters and returns them as a Transcript. It does not verify the
+// proof; Mismatches lists the steps that did not reproduce the proof's
+// values. Version 1 proofs, whose challenges were not derived, have no
+// transcript.
+func (sq *SecureQuantumZKP) ExtractTranscript(proof *SecureProof) (*Transcript, error) {
+	if proof == nil {
+		return nil, errors.New("proof cannot be nil")
+	}
+	if proof.Version < ProofVersionUnboundParameters || proof.Version > CurrentProofVersion {
+		return nil, fmt.Errorf("version %d proofs have no transcript", proof.Version)
+	}
+	if !proof.Context.Equal(sq.Context) {
+		return nil, errors.New("proof was made in another context")
+	}
+	if len(proof.ChallengeResponse) == 0 {
+		return nil, errors.New("no challenge responses")
+	}
+	commitment, err := decodeDigestField(proof.CommitmentHash, 1)
+	if err != nil || len(commitment) == 0 {
+		return nil, errors.New("malformed commitment hash")
+	}
+	t := &Transcript{ProofVersion: proof.Version}
+
+	if proof.ParametersHash != "" {
+		input, err := sq.parametersFor(proof).hashInput()
+		if err != nil {
+			return nil, err
+		}
+		t.Parameters = sha256Step(input, proof.ParametersHash)
+	}
+
+	if proof.Session == nil {
+		var seed bytes.Buffer
+		seed.WriteString(challengeDomain)
+		writeChallengeSeed(&seed, commitment, sq.Context.Bytes(), proof.Epoch, proof.StateMetadata.Dimension)
+		t.ChallengeDerivation = &TranscriptStep{Algorithm: "blake3-xof", Input: hex.EncodeToString(seed.Bytes())}
+		challenges, err := sq.deriveChallenges(
+			commitment, proof.Epoch, proof.StateMetadata.Dimension, len(proof.ChallengeResponse), proof.challengeNonceLength())
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive challenges: %w", err)
+		}
+		t.Challenges = challenges
+
+		if proof.ChallengeBinding != "" {
+			var binding bytes.Buffer
+			if err := writeChallengeBinding(&binding, commitment, challenges, proof.ChallengeResponse); err != nil {
+				return nil, err
+			}
+			t.ChallengeBinding = sha256Step(binding.Bytes(), proof.ChallengeBinding)
+		}
+	}
+
+	leaves := responseLeaves(proof.ChallengeResponse)
+	for _, response := range proof.ChallengeResponse {
+		encoded, err := json.Marshal(response)
+		if err != nil {
+			return nil, err
+		}
+		t.ResponseLeaves = append(t.ResponseLeaves, *sha256Step(encoded, ""))
+	}
+	for _, level := range merkleLevels(leaves) {
+		t.MerkleLevels = append(t.MerkleLevels, encodeMerklePath(level))
+	}
+
+	msg, err := secureProofSigningMessage(proof)
+	if err != nil {
+		return nil, err
+	}
+	signature := &TranscriptStep{
+		Algorithm:        SignatureAlgorithmMLDSA87,
+		Input:            hex.EncodeToString(msg),
+		Claimed:          proof.Signature,
+		SignatureContext: hex.EncodeToString(sq.Signer.Ctx),
+	}
+	t.Signature = signature
+	if batch := proof.Batch; batch != nil {
+		digest := sha256.Sum256(msg)
+		leaf, _ := logLeaf(hex.EncodeToString(digest[:]))
+		t.BatchLeaf = &TranscriptStep{
+			Algorithm: "sha256",
+			Input:     hex.EncodeToString(append([]byte{0x00}, digest[:]...)),
+			Output:    hex.EncodeToString(leaf),
+		}
+		root, err := decodeDigestField(batch.Root, sha256.Size)
+		if err != nil {
+			return nil, errors.New("malformed batch root")
+		}
+		t.BatchRoot = &TranscriptStep{
+			Algorithm:        SignatureAlgorithmMLDSA87,
+			Input:            hex.EncodeToString(batchRootMessage(root, batch.Size)),
+			Claimed:          proof.Signature,
+			SignatureContext: signature.SignatureContext,
+		}
+		signature.Claimed = ""
+	}
+	return t, nil
+}
+
+// Mismatches names the steps of t whose recomputed output differs from
+// what proof claims: its parameters hash, challenge binding, Merkle root,
+// or challenges its responses do not answer. Signatures are not checked.
+func (t *Transcript) Mismatches(proof *SecureProof) []string {
+	var mismatches []string
+	check := func(name string, step *TranscriptStep) {
+		if step != nil && step.Output != "" && step.Claimed != "" && step.Output != step.Claimed {
+			mismatches = append(mismatches, name)
+		}
+	}
+	check("parameters", t.Parameters)
+	check("challenge_binding", t.ChallengeBinding)
+	if t.ChallengeDerivation != nil {
+		for i, response := range proof.ChallengeResponse {
+			if i >= len(t.Challenges) || !response.answers(t.Challenges[i]) {
+				mismatches = append(mismatches, "challenges")
+				break
+			}
+		}
+	}
+	if levels := t.MerkleLevels; len(levels) == 0 || levels[len(levels)-1][0] != proof.MerkleRoot {
+		mismatches = append(mismatches, "merkle_root")
+	}
+	return mismatches
+}
+
+// sha256Step hashes input into a transcript step.
+func sha256Step(input []byte, claimed string) *TranscriptStep {
+	sum := sha256.Sum256(input)
+	return &TranscriptStep{
+		Algorithm: "sha256",
+		Input:     hex.EncodeToString(input),
+		Output:    hex.EncodeToString(sum[:]),
+		Claimed:   claimed,
+	}
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// logRootDomain separates log root signatures from proof and receipt
+// signatures made by the same key.
+const logRootDomain = "qzkp/log-root/v1"
+
+// TransparencyLog is an append-only Merkle log of proof digests. Each
+// issued proof is appended once; the log signs its root so that verifiers
+// can check a proof was logged, and monitors can check that every later
+// root extends the earlier ones, auditing the complete history of issued
+// proofs. The tree follows RFC 6962: leaves are H(0x00 ‖ digest) and
+// interior nodes H(0x01 ‖ left ‖ right), with no duplicated nodes, so
+// inclusion and consistency proofs interoperate with Certificate
+// Transparency style tooling.
+type TransparencyLog struct {
+	mu      sync.Mutex
+	signer  *SignatureScheme
+	leaves  [][]byte
+	indexOf map[string]int // proof digest to leaf index
+}
+
+// LogRoot is a signed tree head: the root of the first Size entries.
+type LogRoot struct {
+	Size      int       `json:"size"`
+	RootHash  string    `json:"root_hash"`
+	Timestamp time.Time `json:"timestamp"`
+	LogID     string    `json:"log_id"` // SHA-256 fingerprint of the log's public key
+	Signature string    `json:"signature"`
+}
+
+// InclusionProof shows that a proof digest is entry LeafIndex of the log
+// at TreeSize entries.
+type InclusionProof struct {
+	ProofDigest string   `json:"proof_digest"`
+	LeafIndex   int      `json:"leaf_index"`
+	TreeSize    int      `json:"tree_size"`
+	Path        []string `json:"path"`
+}
+
+// NewTransparencyLog creates an empty log that signs its roots with signer.
+func NewTransparencyLog(signer *SignatureScheme) (*TransparencyLog, error) {
+	if signer == nil || signer.Priv == nil {
+		return nil, errors.New("log signing key is required")
+	}
+	return &TransparencyLog{signer: signer, indexOf: make(map[string]int)}, nil
+}
+
+// Append logs proof and returns its leaf index. Appending a proof that is
+// already logged returns its existing index.
+func (l *TransparencyLog) Append(proof *SecureProof) (int, error) {
+	digest, err := ProofDigest(proof)
+	if err != nil {
+		return 0, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if index, ok := l.indexOf[digest]; ok {
+		return index, nil
+	}
+	leaf, err := logLeaf(digest)
+	if err != nil {
+		return 0, err
+	}
+	l.leaves = append(l.leaves, leaf)
+	l.indexOf[digest] = len(l.leaves) - 1
+	return len(l.leaves) - 1, nil
+}
+
+// Size returns the number of logged proofs.
+func (l *TransparencyLog) Size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.leaves)
+}
+
+// Root returns the signed root of the log as it is now.
+func (l *TransparencyLog) Root() (*LogRoot, error) {
+	l.mu.Lock()
+	leaves := l.leaves[:len(l.leaves):len(l.leaves)]
+	l.mu.Unlock()
+
+	root := &LogRoot{
+		Size:      len(leaves),
+		RootHash:  hex.EncodeToString(logTreeHash(leaves)),
+		Timestamp: time.Now().UTC(),
+		LogID:     verifierFingerprint(l.signer),
+	}
+	msg, err := root.signingMessage()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := l.signer.Sign(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign log root: %w", err)
+	}
+	root.Signature = hex.EncodeToString(sig)
+	return root, nil
+}
+
+// InclusionProof returns the inclusion proof of proof in the log at
+// treeSize entries, the size of a root the verifier holds.
+func (l *TransparencyLog) InclusionProof(proof *SecureProof, treeSize int) (*InclusionProof, error) {
+	digest, err := ProofDigest(proof)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	index, ok := l.indexOf[digest]
+	if !ok {
+		return nil, errors.New("proof is not in the log")
+	}
+	if treeSize <= index || treeSize > len(l.leaves) {
+		return nil, fmt.Errorf("tree size %d does not contain leaf %d of %d", treeSize, index, len(l.leaves))
+	}
+	return &InclusionProof{
+		ProofDigest: digest,
+		LeafIndex:   index,
+		TreeSize:    treeSize,
+		Path:        encodeMerklePath(logInclusionPath(index, l.leaves[:treeSize])),
+	}, nil
+}
+
+// ConsistencyProof returns the proof that the log at newSize entries
+// extends the log at oldSize entries.
+func (l *TransparencyLog) ConsistencyProof(oldSize, newSize int) ([]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if oldSize <= 0 || oldSize > newSize || newSize > len(l.leaves) {
+		return nil, fmt.Errorf("no consistency proof from size %d to %d of %d", oldSize, newSize, len(l.leaves))
+	}
+	return encodeMerklePath(logConsistencyPath(oldSize, l.leaves[:newSize], true)), nil
+}
+
+// signingMessage is the byte string the log signs.
+func (r *LogRoot) signingMessage() ([]byte, error) {
+	unsigned := *r
+	unsigned.Signature = ""
+	canonical, err := CanonicalizeValue(&unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize log root: %w", err)
+	}
+	return append([]byte(logRootDomain), canonical...), nil
+}
+
+// VerifyLogRoot checks that root was signed by logKey.
+func VerifyLogRoot(root *LogRoot, logKey *SignatureScheme) error {
+	if root == nil || logKey == nil {
+		return errors.New("log root and log key are required")
+	}
+	if root.LogID != verifierFingerprint(logKey) {
+		return errors.New("log root was signed by a different log")
+	}
+	msg, err := root.signingMessage()
+	if err != nil {
+		return err
+	}
+	sig, err := hex.DecodeString(root.Signature)
+	if err != nil || !logKey.Verify(msg, sig) {
+		return errors.New("log root signature is invalid")
+	}
+	return nil
+}
+
+// VerifyLogged checks that proof is included in the log whose root, signed
+// by logKey, is root. It does not verify the proof itself.
+func VerifyLogged(proof *SecureProof, inclusion *InclusionProof, root *LogRoot, logKey *SignatureScheme) error {
+	if inclusion == nil {
+		return errors.New("inclusion proof is required")
+	}
+	if err := VerifyLogRoot(root, logKey); err != nil {
+		return err
+	}
+	digest, err := ProofDigest(proof)
+	if err != nil {
+		return err
+	}
+	if inclusion.ProofDigest != digest {
+		return errors.New("inclusion proof refers to a different proof")
+	}
+	if inclusion.TreeSize != root.Size {
+		return fmt.Errorf("inclusion proof is for tree size %d, root has %d", inclusion.TreeSize, root.Size)
+	}
+	if inclusion.LeafIndex < 0 || inclusion.LeafIndex >= root.Size {
+		return fmt.Errorf("leaf index %d outside a tree of %d", inclusion.LeafIndex, root.Size)
+	}
+	rootHash, err := hex.DecodeString(root.RootHash)
+	if err != nil {
+		return errors.New("log root hash is malformed")
+	}
+	path, err := decodeMerklePath(inclusion.Path)
+	if err != nil {
+		return fmt.Errorf("inclusion path is malformed: %w", err)
+	}
+	leaf, err := logLeaf(digest)
+	if err != nil {
+		return err
+	}
+	if !verifyLogInclusion(leaf, inclusion.LeafIndex, root.Size, path, rootHash) {
+		return errors.New("proof is not included under the log root")
+	}
+	return nil
+}
+
+// VerifyLogConsistency checks that newRoot extends oldRoot, both signed by
+// logKey: every proof logged under oldRoot is still logged, in the same
+// place, under newRoot.
+func VerifyLogConsistency(oldRoot, newRoot *LogRoot, proof []string, logKey *SignatureScheme) error {
+	for _, root := range []*LogRoot{oldRoot, newRoot} {
+		if err := VerifyLogRoot(root, logKey); err != nil {
+			return err
+		}
+	}
+	if oldRoot.Size <= 0 || oldRoot.Size > newRoot.Size {
+		return fmt.Errorf("no consistency from size %d to %d", oldRoot.Size, newRoot.Size)
+	}
+	oldHash, err1 := hex.DecodeString(oldRoot.RootHash)
+	newHash, err2 := hex.DecodeString(newRoot.RootHash)
+	if err1 != nil || err2 != nil {
+		return errors.New("log root hash is malformed")
+	}
+	path, err := decodeMerklePath(proof)
+	if err != nil {
+		return fmt.Errorf("consistency proof is malformed: %w", err)
+	}
+	if !verifyLogConsistency(oldRoot.Size, newRoot.Size, oldHash, newHash, path) {
+		return errors.New("log roots are inconsistent")
+	}
+	return nil
+}
+
+// logLeaf computes H(0x00 ‖ digest) for a hex proof digest.
+func logLeaf(digest string) ([]byte, error) {
+	raw, err := hex.DecodeString(digest)
+	if err != nil {
+		return nil, fmt.Errorf("malformed proof digest: %w", err)
+	}
+	hasher := sha256.New()
+	hasher.Write([]byte{0x00})
+	hasher.Write(raw)
+	return hasher.Sum(nil), nil
+}
+
+// logNode computes H(0x01 ‖ left ‖ right).
+func logNode(left, right []byte) []byte {
+	hasher := sha256.New()
+	hasher.Write([]byte{0x01})
+	hasher.Write(left)
+	hasher.Write(right)
+	return hasher.Sum(nil)
+}
+
+// logSplit returns the largest power of two smaller than n, for n > 1.
+func logSplit(n int) int {
+	return 1 << (bits.Len(uint(n-1)) - 1)
+}
+
+// logTreeHash computes the RFC 6962 tree hash of leaves.
+func logTreeHash(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	case 1:
+		return leaves[0]
+	}
+	k := logSplit(len(leaves))
+	return logNode(logTreeHash(leaves[:k]), logTreeHash(leaves[k:]))
+}
+
+// logInclusionPath computes the RFC 6962 audit path of leaf index.
+func logInclusionPath(index int, leaves [][]byte) [][]byte {
+	if len(leaves) <= 1 {
+		return nil
+	}
+	k := logSplit(len(leaves))
+	if index < k {
+		return append(logInclusionPath(index, leaves[:k]), logTreeHash(leaves[k:]))
+	}
+	return append(logInclusionPath(index-k, leaves[k:]), logTreeHash(leaves[:k]))
+}
+
+// logConsistencyPath computes the RFC 6962 consistency proof between the
+// first oldSize leaves and all of leaves.
+func logConsistencyPath(oldSize int, leaves [][]byte, complete bool) [][]byte {
+	if oldSize == len(leaves) {
+		if complete {
+			return nil
+		}
+		return [][]byte{logTreeHash(leaves)}
+	}
+	k := logSplit(len(leaves))
+	if oldSize <= k {
+		return append(logConsistencyPath(oldSize, leaves[:k], complete), logTreeHash(leaves[k:]))
+	}
+	return append(logConsistencyPath(oldSize-k, leaves[k:], false), logTreeHash(leaves[:k]))
+}
+
+// verifyLogInclusion checks an audit path (RFC 9162 section 2.1.3.2).
+func verifyLogInclusion(leaf []byte, index, size int, path [][]byte, root []byte) bool {
+	fn, sn := index, size-1
+	current := leaf
+	for _, sibling := range path {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			current = logNode(sibling, current)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			current = logNode(current, sibling)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return sn == 0 && bytes.Equal(current, root)
+}
+
+// verifyLogConsistency checks a consistency proof (RFC 9162 section
+// 2.1.4.2).
+func verifyLogConsistency(oldSize, newSize int, oldRoot, newRoot []byte, path [][]byte) bool {
+	if oldSize == newSize {
+		return len(path) == 0 && bytes.Equal(oldRoot, newRoot)
+	}
+	if oldSize&(oldSize-1) == 0 {
+		path = append([][]byte{oldRoot}, path...)
+	}
+	if len(path) == 0 {
+		return false
+	}
+	fn, sn := oldSize-1, newSize-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+	fr, sr := path[0], path[0]
+	for _, node := range path[1:] {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = logNode(node, fr)
+			sr = logNode(node, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = logNode(sr, node)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	return sn == 0 && bytes.Equal(fr, oldRoot) && bytes.Equal(sr, newRoot)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// MaxVectorSetSize caps how many vectors a single VectorSetProof may cover.
+const MaxVectorSetSize = 1024
+
+// VectorSetProof proves knowledge of an ensemble of state vectors. Each
+// vector gets its own sub-proof; a Merkle root over the sub-proof roots and
+// a single signature tie them together.
+type VectorSetProof struct {
+	SubProofs        []*SecureProof `json:"sub_proofs"`
+	CombinedRoot     string         `json:"combined_root"`
+	Identifier       string         `json:"identifier"`
+	IdentifierSalt   string         `json:"identifier_salt,omitempty"`
+	IdentifierScheme string         `json:"identifier_scheme,omitempty"`
+	Signature        string         `json:"signature"`
+	Timestamp        time.Time      `json:"timestamp"`
+}
+
+// SecureProveVectors proves knowledge of every vector under one identifier.
+// Each sub-proof commits to the vector's position in the set.
+func (sq *SecureQuantumZKP) SecureProveVectors(
+	vectors [][]complex128,
+	identifier string,
+	key []byte,
+) (*VectorSetProof, error) {
+	if len(vectors) == 0 {
+		return nil, errors.New("at least one vector is required")
+	}
+	if len(vectors) > MaxVectorSetSize {
+		return nil, fmt.Errorf("too many vectors: %d (maximum %d)", len(vectors), MaxVectorSetSize)
+	}
+
+	// Validate every vector before any work is done
+	states := make([]*StateVector, len(vectors))
+	for i, vector := range vectors {
+		state, err := NewStateVector(vector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector %d: %w", i, err)
+		}
+		states[i] = state
+	}
+
+	published, err := sq.publishIdentifier(identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish identifier: %w", err)
+	}
+
+	proof := &VectorSetProof{
+		Identifier:       published.Identifier,
+		IdentifierSalt:   published.Salt,
+		IdentifierScheme: published.Scheme,
+		Timestamp:        sq.now(),
+	}
+	members := make([]string, len(states))
+	for i := range states {
+		members[i] = vectorSetMemberIdentifier(identifier, i)
+	}
+	proof.SubProofs, err = sq.buildSecureProofs(states, members, key, func(int) (publishedIdentifier, error) {
+		return published, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to prove vectors: %w", err)
+	}
+
+	proof.CombinedRoot, err = combinedRoundRoot(proof.SubProofs)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := vectorSetSigningMessage(proof)
+	if err != nil {
+		return nil, err
+	}
+	sq.reportProgress(ProgressStageSigning, 0, 1)
+	sigBytes, err := sq.Signer.Sign(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign vector set proof: %w", err)
+	}
+	proof.Signature = EncodeHexField(sigBytes)
+	sq.reportProgress(ProgressStageSigning, 1, 1)
+
+	return proof, nil
+}
+
+// VerifyVectorSetProof checks the signature, the combined root and every
+// sub-proof.
+func (sq *SecureQuantumZKP) VerifyVectorSetProof(proof *VectorSetProof, key []byte) bool {
+	if !sq.verifyVectorSetEnvelope(proof) {
+		return false
+	}
+	for _, sub := range proof.SubProofs {
+		if !sq.verifyVectorSetMember(proof, sub, key) {
+			return false
+		}
+	}
+	return true
+}
+
+// VerifyVectorSetProofSample checks the signature and combined root and a
+// uniformly random subset of sample sub-proofs. A set in which a fraction f
+// of sub-proofs is invalid escapes detection with probability about
+// (1−f)^sample.
+func (sq *SecureQuantumZKP) VerifyVectorSetProofSample(proof *VectorSetProof, key []byte, sample int) bool {
+	if !sq.verifyVectorSetEnvelope(proof) {
+		return false
+	}
+	if sample <= 0 {
+		return false
+	}
+	if sample >= len(proof.SubProofs) {
+		return sq.VerifyVectorSetProof(proof, key)
+	}
+
+	indices, err := sampleIndices(len(proof.SubProofs), sample)
+	if err != nil {
+		return false
+	}
+	for _, i := range indices {
+		if !sq.verifyVectorSetMember(proof, proof.SubProofs[i], key) {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyVectorSetEnvelope checks the shared signature and combined root.
+func (sq *SecureQuantumZKP) verifyVectorSetEnvelope(proof *VectorSetProof) bool {
+	if proof == nil || len(proof.SubProofs) == 0 || len(proof.SubProofs) > MaxVectorSetSize {
+		return false
+	}
+
+	msg, err := vectorSetSigningMessage(proof)
+	if err != nil {
+		return false
+	}
+	sigBytes, err := decodeSignatureField(proof.Signature)
+	if err != nil {
+		return false
+	}
+	if !sq.Signer.Verify(msg, sigBytes) {
+		return false
+	}
+
+	combined, err := combinedRoundRoot(proof.SubProofs)
+	return err == nil && combined == proof.CombinedRoot
+}
+
+// verifyVectorSetMember checks one sub-proof against the set.
+func (sq *SecureQuantumZKP) verifyVectorSetMember(proof *VectorSetProof, sub *SecureProof, key []byte) bool {
+	if sub.Identifier != proof.Identifier || sub.IdentifierSalt != proof.IdentifierSalt {
+		return false
+	}
+	return sq.verifySecureProofBody(sub, key)
+}
+
+// vectorSetMemberIdentifier is the identifier committed to by the i-th
+// sub-proof, which fixes each vector's position in the set.
+func vectorSetMemberIdentifier(identifier string, i int) string {
+	return fmt.Sprintf("%s#%d", identifier, i)
+}
+
+// vectorSetSigningMessage serializes the proof without its signature.
+func vectorSetSigningMessage(proof *VectorSetProof) ([]byte, error) {
+	temp := *proof
+	temp.Signature = ""
+	return json.Marshal(&temp)
+}
+
+// sampleIndices draws k distinct indices from [0, n) with a partial
+// Fisher–Yates shuffle.
+func sampleIndices(n, k int) ([]int, error) {
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	for i := 0; i < k; i++ {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(n-i)))
+		if err != nil {
+			return nil, err
+		}
+		swap := i + int(j.Int64())
+		perm[i], perm[swap] = perm[swap], perm[i]
+	}
+	return perm[:k], nil
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"slices"
+	"sync"
+
+	"lukechampine.com/blake3"
+)
+
+// Bounds on each buffer an arena keeps when it is recycled. Proofs at the
+// highest soundness need a few KiB of scratch and a few hundred
+// challenges; a buffer an unusually large proof grew past them is dropped
+// instead of being pinned in the pool.
+const (
+	maxArenaBytes    = 64 << 10
+	maxArenaElements = 4096
+)
+
+// verificationArena holds the scratch memory of one verification: the
+// encoded signing message and response leaves, decoded signature and
+// digest fields, the derived challenges and the Merkle nodes. Arenas are
+// recycled through verificationArenas, so a verifier under load stops
+// allocating them on every call.
+//
+// Nothing in an arena outlives the verification it serves: verification
+// results are booleans, and anything returned to callers is copied out
+// (as by EncodeHexField) before the arena is released. release clears
+// every buffer over its whole capacity, so one proof's material is never
+// visible to the next verification, and an arena is never shared by two
+// goroutines.
+type verificationArena struct {
+	verifier SecureQuantumZKP  // the verification's copy of sq; see withVerificationArena
+	proof    SecureProof       // the proof being encoded by signingMessage
+	response ChallengeResponse // the response being encoded by responseLeaf
+	encoded  bytes.Buffer      // JSON encodings
+	encoder  *json.Encoder     // writes to encoded
+	leafHash hash.Hash         // response leaves and Merkle nodes
+	bindHash hash.Hash         // challenge bindings
+
+	signature  []byte
+	digest     []byte
+	commitment []byte
+	nodes      [][sha256.Size]byte
+	challenges []Challenge
+	nonces     []byte
+	indices    []int
+	label      []byte
+	hex        []byte
+	length     [8]byte
+	sum        [sha256.Size]byte
+	xof        blake3.Hasher // challenge derivation state, continued from the precomputed one
+
+	busy bool // between getVerificationArena and release
+}
+
+var verificationArenas = sync.Pool{New: func() interface{} { return newVerificationArena() }}
+
+func newVerificationArena() *verificationArena {
+	a := &verificationArena{leafHash: sha256.New(), bindHash: sha256.New()}
+	a.encoder = json.NewEncoder(&a.encoded)
+	return a
+}
+
+// errArenaReleased reports scratch memory asked of a verification arena
+// that was already released. The arena of a verification belongs to the
+// copy of sq made for it, which never outlives the verification, so this
+// only follows from a mistake inside the library; verification fails
+// closed instead of reading buffers the pool may have handed to another
+// goroutine.
+var errArenaReleased = errors.New("verification arena already released")
+
+// getVerificationArena takes an arena from the pool.
+func getVerificationArena() *verificationArena {
+	a := verificationArenas.Get().(*verificationArena)
+	a.busy = true
+	return a
+}
+
+// release scrubs a and returns it to the pool; a must not be used
+// afterwards. Releasing a nil arena does nothing, and releasing one twice
+// returns errArenaReleased and leaves the pool alone.
+func (a *verificationArena) release() error {
+	if a == nil {
+		return nil
+	}
+	if !a.busy {
+		return errArenaReleased
+	}
+	a.verifier = SecureQuantumZKP{}
+	a.proof = SecureProof{}
+	a.response = ChallengeResponse{}
+	a.leafHash.Reset()
+	a.bindHash.Reset()
+	a.encoded.Reset()
+	clear(a.encoded.AvailableBuffer()[:a.encoded.Available()])
+	if a.encoded.Cap() > maxArenaBytes {
+		a.encoded = bytes.Buffer{}
+	}
+	a.signature = scrubArenaBuffer(a.signature, maxArenaBytes)
+	a.digest = scrubArenaBuffer(a.digest, maxArenaBytes)
+	a.commitment = scrubArenaBuffer(a.commitment, maxArenaBytes)
+	a.nonces = scrubArenaBuffer(a.nonces, maxArenaBytes)
+	a.label = scrubArenaBuffer(a.label, maxArenaBytes)
+	a.hex = scrubArenaBuffer(a.hex, maxArenaBytes)
+	a.nodes = scrubArenaBuffer(a.nodes, maxArenaElements)
+	a.challenges = scrubArenaBuffer(a.challenges, maxArenaElements)
+	a.indices = scrubArenaBuffer(a.indices, maxArenaElements)
+	clear(a.length[:])
+	clear(a.sum[:])
+	a.xof = blake3.Hasher{}
+	a.busy = false
+	verificationArenas.Put(a)
+	return nil
+}
+
+// scrubArenaBuffer zeroes s over its whole capacity and returns it empty,
+// or nil when it can hold more than maxLen elements.
+func scrubArenaBuffer[T any](s []T, maxLen int) []T {
+	clear(s[:cap(s)])
+	if cap(s) > maxLen {
+		return nil
+	}
+	return s[:0]
+}
+
+// withVerificationArena returns a copy of sq that verifies with scratch
+// memory from a pooled arena, and the arena, to be released once the
+// verification is over. When sq already has one, sq and a nil arena are
+// returned.
+func (sq *SecureQuantumZKP) withVerificationArena() (*SecureQuantumZKP, *verificationArena) {
+	if sq.arena != nil {
+		return sq, nil
+	}
+	a := getVerificationArena()
+	a.verifier = *sq
+	a.verifier.arena = a
+	return &a.verifier, a
+}
+
+// scratch returns the arena of sq's verification, or a pooled one outside
+// of a verification; either way it is handed back with releaseScratch.
+// It returns errArenaReleased when sq's verification is already over.
+func (sq *SecureQuantumZKP) scratch() (*verificationArena, error) {
+	if sq.arena != nil {
+		if !sq.arena.busy {
+			return nil, errArenaReleased
+		}
+		return sq.arena, nil
+	}
+	return getVerificationArena(), nil
+}
+
+// releaseScratch releases a unless it belongs to sq's verification.
+func (sq *SecureQuantumZKP) releaseScratch(a *verificationArena) {
+	if a != sq.arena {
+		a.release()
+	}
+}
+
+// encode is json.Marshal of v into a.encoded, valid until the next
+// encoding.
+func (a *verificationArena) encode(v interface{}) ([]byte, error) {
+	a.encoded.Reset()
+	if err := a.encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	// Encode terminates the value with a newline Marshal does not write
+	return bytes.TrimSuffix(a.encoded.Bytes(), []byte("\n")), nil
+}
+
+// signingMessage is secureProofSigningMessage in a's buffers.
+func (a *verificationArena) signingMessage(proof *SecureProof) ([]byte, error) {
+	a.proof = *proof
+	a.proof.Signature = ""
+	a.proof.Cosignatures = nil
+	a.proof.Batch = nil
+	a.proof.Debug = nil
+	msg, err := a.encode(&a.proof)
+	a.proof = SecureProof{}
+	return msg, err
+}
+
+// decodeSignature is decodeSignatureField in a's buffers.
+func (a *verificationArena) decodeSignature(s string) ([]byte, error) {
+	sig, err := decodeHexFieldInto(a.signature, s, signatureSize, signatureSize)
+	if err == nil {
+		a.signature = sig
+	}
+	return sig, err
+}
+
+// decodeDigest is decodeDigestField in a's buffers, valid until the next
+// digest is decoded.
+func (a *verificationArena) decodeDigest(s string, minLen int) ([]byte, error) {
+	digest, err := decodeHexFieldInto(a.digest, s, minLen, FullDigestLength)
+	if err == nil {
+		a.digest = digest
+	}
+	return digest, err
+}
+
+// responseLeaf writes the Merkle leaf of response to leaf; see
+// responseLeaf.
+func (a *verificationArena) responseLeaf(response *ChallengeResponse, leaf *[sha256.Size]byte) {
+	a.response = *response
+	encoded, _ := a.encode(&a.response)
+	a.leafHash.Reset()
+	a.leafHash.Write(encoded)
+	a.leafHash.Sum(leaf[:0])
+}
+
+// merkleRoot is the Merkle root of responses as generateMerkleRoot
+// computes it, folded in place over a's nodes; it is valid until the
+// next root is computed.
+func (a *verificationArena) merkleRoot(responses []ChallengeResponse) (*[sha256.Size]byte, error) {
+	if len(responses) == 0 {
+		return nil, errors.New("no responses to hash")
+	}
+	a.nodes = slices.Grow(a.nodes[:0], len(responses))[:len(responses)]
+	for i := range responses {
+		a.responseLeaf(&responses[i], &a.nodes[i])
+	}
+	for n := len(a.nodes); n > 1; n = (n + 1) / 2 {
+		for i := 0; i < n; i += 2 {
+			a.leafHash.Reset()
+			a.leafHash.Write(a.nodes[i][:])
+			if i+1 < n {
+				a.leafHash.Write(a.nodes[i+1][:])
+			} else {
+				a.leafHash.Write(a.nodes[i][:]) // Duplicate if odd number
+			}
+			a.leafHash.Sum(a.nodes[i/2][:0])
+		}
+	}
+	return &a.nodes[0], nil
+}
+
+// hexDigest returns the lower-case hex encoding of digest in a's buffer,
+// valid until the next encoding.
+func (a *verificationArena) hexDigest(digest []byte) []byte {
+	a.hex = slices.Grow(a.hex[:0], 2*len(digest))
+	for _, c := range digest {
+		a.hex = append(a.hex, hexDigit(int32(c>>4)), hexDigit(int32(c&0x0f)))
+	}
+	return a.hex
+}
+
+// deriveChallenges is deriveChallenges with the challenges and their
+// nonces in a's buffers, valid until the next derivation.
+func (a *verificationArena) deriveChallenges(sq *SecureQuantumZKP, commitment []byte, epoch uint64, dimension, numChallenges, nonceLength int) ([]Challenge, error) {
+	if numChallenges <= 0 {
+		return nil, errors.New("number of challenges must be positive")
+	}
+	if err := validateChallengeNonceLength(nonceLength); err != nil {
+		return nil, err
+	}
+	a.challenges = slices.Grow(a.challenges[:0], numChallenges)[:numChallenges]
+	a.nonces = slices.Grow(a.nonces[:0], numChallenges*nonceLength)[:numChallenges*nonceLength]
+	if err := sq.fillChallenges(a.challenges, a.nonces, commitment, epoch, dimension, nonceLength); err != nil {
+		return nil, err
+	}
+	return a.challenges, nil
+}
+
+// writeChallengeBinding writes the input hashed by challengeBinding.
+func (a *verificationArena) writeChallengeBinding(w io.Writer, commitment []byte, challenges []Challenge, responses []ChallengeResponse) error {
+	if len(challenges) != len(responses) {
+		return errors.New("challenge and response counts differ")
+	}
+	w.Write([]byte(challengeBindingDomain))
+	a.writeLengthPrefixed(w, commitment)
+	binary.BigEndian.PutUint64(a.length[:], uint64(len(challenges)))
+	w.Write(a.length[:])
+	for i, challenge := range challenges {
+		binary.BigEndian.PutUint64(a.length[:], uint64(challenge.Index))
+		w.Write(a.length[:])
+		a.label = append(a.label[:0], challenge.BasisType...)
+		a.writeLengthPrefixed(w, a.label)
+		a.writeLengthPrefixed(w, challenge.Nonce)
+		a.responseLeaf(&responses[i], &a.sum)
+		w.Write(a.sum[:])
+	}
+	return nil
+}
+
+// writeLengthPrefixed is writeLengthPrefixed without allocating the
+// length prefix.
+func (a *verificationArena) writeLengthPrefixed(w io.Writer, data []byte) {
+	binary.BigEndian.PutUint64(a.length[:], uint64(len(data)))
+	w.Write(a.length[:])
+	w.Write(data)
+}
+
+// challengeBinding is challengeBinding, hex-encoded in a's buffer.
+func (a *verificationArena) challengeBinding(commitment []byte, challenges []Challenge, responses []ChallengeResponse) ([]byte, error) {
+	a.bindHash.Reset()
+	if err := a.writeChallengeBinding(a.bindHash, commitment, challenges, responses); err != nil {
+		return nil, err
+	}
+	return a.hexDigest(a.bindHash.Sum(a.sum[:0])), nil
+}
+
+// matchChallenges is matchChallenges with the binding computed in a.
+func (a *verificationArena) matchChallenges(responses []ChallengeResponse, challenges []Challenge, commitment []byte, binding string) bool {
+	if len(responses) != len(challenges) {
+		return false
+	}
+	for i, response := range responses {
+		if !response.answers(challenges[i]) {
+			return false
+		}
+	}
+	if binding == "" {
+		return true
+	}
+	expected, err := a.challengeBinding(commitment, challenges, responses)
+	return err == nil && constantTimeEqualString(expected, binding)
+}
+
+// constantTimeEqualString is hmac.Equal for a byte slice and a string,
+// without copying the string.
+func constantTimeEqualString(b []byte, s string) bool {
+	if len(b) != len(s) {
+		return false
+	}
+	var v byte
+	for i := range b {
+		v |= b[i] ^ s[i]
+	}
+	return v == 0
+}
+
+// distinctIndices counts the distinct challenge indices of responses.
+func (a *verificationArena) distinctIndices(responses []ChallengeResponse) int {
+	a.indices = a.indices[:0]
+	for _, response := range responses {
+		a.indices = append(a.indices, response.ChallengeIndex)
+	}
+	slices.Sort(a.indices)
+	return len(slices.Compact(a.indices))
+}
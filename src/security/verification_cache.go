@@ -0,0 +1,254 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultVerificationCacheCapacity is the number of results a
+// VerificationCache keeps unless its options set another.
+const DefaultVerificationCacheCapacity = 4096
+
+// verifierProfileDomain separates verifier profile hashes from other
+// SHA-256 digests.
+const verifierProfileDomain = "qzkp/verifier-profile/v1"
+
+// VerificationCacheOptions configures a VerificationCache.
+type VerificationCacheOptions struct {
+	// Capacity bounds the cached results; the least recently used is
+	// evicted first. DefaultVerificationCacheCapacity if zero
+	Capacity int
+	// TTL bounds how long a result is served, for policies and key sets
+	// whose outcome depends on the time; zero serves results until they
+	// are evicted or invalidated
+	TTL   time.Duration
+	Clock func() time.Time // optional; nil uses time.Now
+}
+
+// CacheInvalidation reports the results a VerificationCache dropped.
+// Previous and Current are the verifier profile hashes before and after a
+// reload, equal when the cache was invalidated explicitly.
+type CacheInvalidation struct {
+	Previous string `json:"previous"`
+	Current  string `json:"current"`
+	Dropped  int    `json:"dropped"`
+}
+
+// VerificationCache remembers verification results keyed jointly by the
+// proof digest, the proof key and the profile hash of the Verifier that
+// produced them: its policy (see Policy.Hash), parameters, signer or key
+// set and options. It serves only results cached under the active
+// profile, the profile of the Verifier most recently created with the
+// cache; creating a Verifier under a different profile is a policy
+// reload, which drops every result and fires the invalidation hooks, so
+// acceptances under an old policy cannot leak through after its rules are
+// tightened.
+// A VerificationCache is safe for concurrent use.
+type VerificationCache struct {
+	mu      sync.Mutex
+	opts    VerificationCacheOptions
+	profile string // active verifier profile hash
+	entries map[verificationCacheKey]*list.Element
+	order   *list.List // most recently used first
+	hooks   []func(CacheInvalidation)
+}
+
+// verificationCacheKey identifies one cached result.
+type verificationCacheKey struct {
+	profile string
+	proof   string // ProofDigest
+	key     string // SHA-256 of the proof key
+}
+
+// verificationCacheEntry is one cached result.
+type verificationCacheEntry struct {
+	key    verificationCacheKey
+	result *VerificationResult
+	stored time.Time
+}
+
+// NewVerificationCache creates an empty cache.
+func NewVerificationCache(opts VerificationCacheOptions) (*VerificationCache, error) {
+	if opts.Capacity < 0 {
+		return nil, errors.New("verification cache capacity cannot be negative")
+	}
+	if opts.TTL < 0 {
+		return nil, errors.New("verification cache TTL cannot be negative")
+	}
+	if opts.Capacity == 0 {
+		opts.Capacity = DefaultVerificationCacheCapacity
+	}
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+	return &VerificationCache{
+		opts:    opts,
+		entries: make(map[verificationCacheKey]*list.Element),
+		order:   list.New(),
+	}, nil
+}
+
+// OnInvalidate registers hook to be called, outside the cache's lock,
+// whenever results are dropped by a reload or by Invalidate.
+func (c *VerificationCache) OnInvalidate(hook func(CacheInvalidation)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks = append(c.hooks, hook)
+}
+
+// Invalidate drops every cached result, for example after a key was
+// revoked outside the verifier's key set, and fires the hooks.
+func (c *VerificationCache) Invalidate() int {
+	c.mu.Lock()
+	event := CacheInvalidation{Previous: c.profile, Current: c.profile, Dropped: c.clear()}
+	hooks := slices.Clone(c.hooks)
+	c.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook(event)
+	}
+	return event.Dropped
+}
+
+// Len returns the number of cached results.
+func (c *VerificationCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Profile returns the active verifier profile hash, empty before the
+// cache is first used by a Verifier.
+func (c *VerificationCache) Profile() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.profile
+}
+
+// activate makes profile the active one. Switching from another profile
+// drops every result and fires the hooks.
+func (c *VerificationCache) activate(profile string) {
+	c.mu.Lock()
+	if c.profile == profile {
+		c.mu.Unlock()
+		return
+	}
+	event := CacheInvalidation{Previous: c.profile, Current: profile, Dropped: c.clear()}
+	reload := c.profile != ""
+	c.profile = profile
+	hooks := slices.Clone(c.hooks)
+	c.mu.Unlock()
+
+	if !reload {
+		return
+	}
+	for _, hook := range hooks {
+		hook(event)
+	}
+}
+
+// clear drops every result and returns how many there were. The caller
+// holds c.mu.
+func (c *VerificationCache) clear() int {
+	dropped := c.order.Len()
+	c.entries = make(map[verificationCacheKey]*list.Element)
+	c.order.Init()
+	return dropped
+}
+
+// get returns a copy of the result cached under key, if key belongs to
+// the active profile and has not expired.
+func (c *VerificationCache) get(key verificationCacheKey) (*VerificationResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if key.profile != c.profile {
+		return nil, false
+	}
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*verificationCacheEntry)
+	if c.opts.TTL > 0 && c.opts.Clock().Sub(entry.stored) >= c.opts.TTL {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return copyVerificationResult(entry.result), true
+}
+
+// put caches a copy of result under key unless key belongs to a profile
+// that is no longer active.
+func (c *VerificationCache) put(key verificationCacheKey, result *VerificationResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if key.profile != c.profile {
+		return
+	}
+	entry := &verificationCacheEntry{key: key, result: copyVerificationResult(result), stored: c.opts.Clock()}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.opts.Capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*verificationCacheEntry).key)
+	}
+}
+
+// copyVerificationResult returns a deep copy of result.
+func copyVerificationResult(result *VerificationResult) *VerificationResult {
+	copied := *result
+	copied.Caveats = append([]string(nil), result.Caveats...)
+	copied.Reasons = append([]string(nil), result.Reasons...)
+	copied.Signers = append([]string(nil), result.Signers...)
+	return &copied
+}
+
+// verifierProfileHash identifies everything besides the proof and key that
+// a Verifier's result depends on.
+func verifierProfileHash(policy *Policy, parametersHash string, context Context, signer []byte, keys *KeySet, keyPolicy KeySetPolicy, strict bool) (string, error) {
+	hasher := sha256.New()
+	hasher.Write([]byte(verifierProfileDomain))
+	policyHash := ""
+	if policy != nil {
+		policyHash = policy.Hash()
+	}
+	writeLengthPrefixed(hasher, []byte(policyHash))
+	writeLengthPrefixed(hasher, []byte(parametersHash))
+	writeLengthPrefixed(hasher, context.Bytes())
+	writeLengthPrefixed(hasher, signer)
+	var keySet []byte
+	if keys != nil {
+		var err error
+		if keySet, err = json.Marshal(keys); err != nil {
+			return "", err
+		}
+	}
+	writeLengthPrefixed(hasher, keySet)
+	writeLengthPrefixed(hasher, []byte(strconv.Itoa(int(keyPolicy))))
+	writeLengthPrefixed(hasher, []byte(strconv.FormatBool(strict)))
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// verificationCacheKeyFor returns the key of proof and key under the
+// verifier's profile.
+func (v *Verifier) verificationCacheKeyFor(proof *SecureProof, key []byte) (verificationCacheKey, error) {
+	digest, err := ProofDigest(proof)
+	if err != nil {
+		return verificationCacheKey{}, err
+	}
+	keyDigest := sha256.Sum256(key)
+	return verificationCacheKey{profile: v.profile, proof: digest, key: hex.EncodeToString(keyDigest[:])}, nil
+}
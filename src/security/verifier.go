@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"lukechampine.com/blake3"
+)
+
+// verifierPrecomputation holds the per-verifier work that does not depend
+// on the proof. It is only attached to the private copy of sq a Verifier
+// owns, whose parameters never change afterwards.
+type verifierPrecomputation struct {
+	parametersHash string
+	contextBytes   []byte
+	// challengeHasher has absorbed challengeDomain; deriveChallenges
+	// continues from a copy
+	challengeHasher blake3.Hasher
+}
+
+// VerifierConfig selects what a Verifier checks besides the proof itself.
+type VerifierConfig struct {
+	// Policy, when set, is evaluated against the proof header before any
+	// cryptographic check
+	Policy *Policy
+	// Keys, when set, verifies signatures against a key set under
+	// KeyPolicy instead of sq.Signer (see VerifyWithKeySet)
+	Keys      *KeySet
+	KeyPolicy KeySetPolicy
+	Options   VerifyOptions
+	// Cache, when set, remembers results under the verifier's profile
+	// (see VerificationCache); creating a verifier under a new profile
+	// invalidates results cached under the previous one. It cannot be
+	// combined with Options.NonceStore, as cached acceptances would let
+	// replays through
+	Cache *VerificationCache
+}
+
+// Verifier verifies proofs for sq with everything that does not depend on
+// the proof computed once: the parameters hash, the context encoding and
+// challenge-derivation hash state, the parsed key set and the policy
+// rules. It is meant for gateways verifying many proofs per second; the
+// result of Verify is the one sq would give. A Verifier is safe for
+// concurrent use and is unaffected by later changes to sq.
+type Verifier struct {
+	sq        *SecureQuantumZKP
+	rules     []Rule
+	keys      []keySetScheme
+	keyPolicy KeySetPolicy
+	options   VerifyOptions
+	cache     *VerificationCache
+	profile   string // see ProfileHash
+}
+
+// NewVerifier prepares a verifier for proofs made under sq's parameters.
+func NewVerifier(sq *SecureQuantumZKP, config VerifierConfig) (*Verifier, error) {
+	if sq == nil || sq.QuantumZKP == nil || sq.Signer == nil {
+		return nil, errors.New("verifier requires a configured SecureQuantumZKP")
+	}
+	parametersHash, err := sq.Parameters().Hash()
+	if err != nil {
+		return nil, err
+	}
+	v := &Verifier{sq: sq.withSigner(sq.Signer), keyPolicy: config.KeyPolicy, options: config.Options}
+	v.sq.precomputed = &verifierPrecomputation{
+		parametersHash:  parametersHash,
+		contextBytes:    sq.Context.Bytes(),
+		challengeHasher: *newChallengeHasher(),
+	}
+
+	if config.Policy != nil {
+		for i, rule := range config.Policy.Rules {
+			if rule == nil {
+				return nil, fmt.Errorf("policy rule %d is nil", i)
+			}
+		}
+		v.rules = append([]Rule(nil), config.Policy.Rules...)
+	}
+	if config.Cache != nil && config.Options.NonceStore != nil {
+		return nil, errors.New("a verification cache cannot be combined with a nonce store")
+	}
+	if config.Keys != nil {
+		if config.KeyPolicy != KeySetAnyOf && config.KeyPolicy != KeySetAllOf {
+			return nil, fmt.Errorf("unknown key set policy %v", config.KeyPolicy)
+		}
+		if v.keys, err = v.sq.keySetSchemes(config.Keys); err != nil {
+			return nil, err
+		}
+	}
+	if v.profile, err = verifierProfileHash(config.Policy, parametersHash, sq.Context, sq.Signer.PublicKeyBytes(), config.Keys, config.KeyPolicy, config.Options.StrictMode); err != nil {
+		return nil, err
+	}
+	if config.Cache != nil {
+		v.cache = config.Cache
+		v.cache.activate(v.profile)
+	}
+	return v, nil
+}
+
+// ProfileHash identifies what the verifier's results depend on besides the
+// proof and key: its policy, parameters, context, signer or key set and
+// options.
+func (v *Verifier) ProfileHash() string {
+	return v.profile
+}
+
+// Verify checks the policy, then the proof against the key set or sq's
+// signer, then the configured options. Policy violations are reported in
+// Reasons as "rule: reason". With a cache, a result cached under the
+// verifier's profile is returned instead.
+func (v *Verifier) Verify(proof *SecureProof, key []byte) *VerificationResult {
+	if v.cache == nil || proof == nil {
+		return v.verify(proof, key)
+	}
+	// Whether an epoch is accepted changes with time, so it is never cached
+	if _, err := v.sq.epochVerifier(proof); err != nil {
+		return v.verify(proof, key)
+	}
+	cacheKey, err := v.verificationCacheKeyFor(proof, key)
+	if err != nil {
+		return v.verify(proof, key)
+	}
+	if result, ok := v.cache.get(cacheKey); ok {
+		return result
+	}
+	result := v.verify(proof, key)
+	v.cache.put(cacheKey, result)
+	return result
+}
+
+// verify is Verify without the cache.
+func (v *Verifier) verify(proof *SecureProof, key []byte) *VerificationResult {
+	if len(v.rules) > 0 {
+		report := (&Policy{Rules: v.rules}).Evaluate(proof)
+		if !report.Allowed() {
+			result := &VerificationResult{}
+			for _, violation := range report.Violations {
+				result.Reasons = append(result.Reasons, violation.Rule+": "+violation.Reason)
+			}
+			return result
+		}
+	}
+	if proof == nil {
+		return &VerificationResult{}
+	}
+
+	var result *VerificationResult
+	if v.keys != nil {
+		result = v.sq.verifyWithKeySchemes(proof, key, v.keys, v.keyPolicy)
+	} else {
+		result = v.sq.VerifySecureProofVersioned(proof, key)
+	}
+	v.sq.applyVerifyOptions(proof, result, v.options)
+	return result
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// MaxWitnessShares is the most shares a witness can be split into; share
+// indexes are the non-zero elements of GF(2^8).
+const MaxWitnessShares = 255
+
+// ErrNotEnoughShares is returned when fewer consistent shares than the
+// threshold are available.
+var ErrNotEnoughShares = errors.New("not enough witness shares")
+
+// WitnessShare is one Shamir share of a witness: the bytes that would be
+// passed to SecureProveFromBytes. Fewer than Threshold shares of a split
+// reveal nothing about the witness but its length.
+type WitnessShare struct {
+	SplitID   string `json:"split_id"` // random, shared by the shares of one split
+	Index     int    `json:"index"`    // 1 to MaxWitnessShares
+	Threshold int    `json:"threshold"`
+	Data      []byte `json:"data"`
+}
+
+// SplitWitness splits witness into n Shamir shares over GF(2^8), any
+// threshold of which reconstruct it, so that custodians can each hold one
+// share and no single node holds the witness at rest.
+func SplitWitness(witness []byte, threshold, n int) ([]WitnessShare, error) {
+	if len(witness) == 0 {
+		return nil, errors.New("witness cannot be empty")
+	}
+	if threshold < 2 || threshold > n || n > MaxWitnessShares {
+		return nil, fmt.Errorf("threshold %d of %d shares must satisfy 2 <= threshold <= shares <= %d", threshold, n, MaxWitnessShares)
+	}
+	splitID := make([]byte, 16)
+	if _, err := rand.Read(splitID); err != nil {
+		return nil, err
+	}
+
+	shares := make([]WitnessShare, n)
+	for i := range shares {
+		shares[i] = WitnessShare{SplitID: hex.EncodeToString(splitID), Index: i + 1, Threshold: threshold, Data: make([]byte, len(witness))}
+	}
+	// One random polynomial of degree threshold-1 per witness byte, whose
+	// constant term is the byte
+	coefficients := make([]byte, threshold)
+	defer clear(coefficients)
+	for b, secret := range witness {
+		coefficients[0] = secret
+		if _, err := rand.Read(coefficients[1:]); err != nil {
+			return nil, err
+		}
+		for i := range shares {
+			shares[i].Data[b] = gf256Eval(coefficients, byte(shares[i].Index))
+		}
+	}
+	return shares, nil
+}
+
+// ReconstructWitness recombines threshold shares of one split. Extra
+// shares are ignored; shares of another split, with another threshold or
+// with a repeated index are refused. The caller should clear the returned
+// witness once done with it.
+func ReconstructWitness(shares []WitnessShare, threshold int) ([]byte, error) {
+	if threshold < 2 {
+		return nil, fmt.Errorf("threshold %d must be at least 2", threshold)
+	}
+	if len(shares) < threshold {
+		return nil, fmt.Errorf("%w: %d of %d", ErrNotEnoughShares, len(shares), threshold)
+	}
+	used := shares[:threshold]
+	seen := make(map[int]bool, threshold)
+	for _, share := range used {
+		switch {
+		case share.SplitID != used[0].SplitID:
+			return nil, errors.New("witness shares belong to different splits")
+		case share.Threshold != threshold:
+			return nil, fmt.Errorf("witness share %d was split with threshold %d, not %d", share.Index, share.Threshold, threshold)
+		case share.Index < 1 || share.Index > MaxWitnessShares:
+			return nil, fmt.Errorf("witness share index %d outside [1, %d]", share.Index, MaxWitnessShares)
+		case seen[share.Index]:
+			return nil, fmt.Errorf("witness share %d is repeated", share.Index)
+		case len(share.Data) == 0 || len(share.Data) != len(used[0].Data):
+			return nil, errors.New("witness shares differ in length")
+		}
+		seen[share.Index] = true
+	}
+
+	// Lagrange interpolation at zero: witness = sum of y_i * l_i(0), where
+	// l_i(0) = prod x_j / (x_j - x_i) over j != i; subtraction is XOR
+	weights := make([]byte, threshold)
+	for i, share := range used {
+		numerator, denominator := byte(1), byte(1)
+		for j, other := range used {
+			if i != j {
+				numerator = gf256Mul(numerator, byte(other.Index))
+				denominator = gf256Mul(denominator, byte(other.Index)^byte(share.Index))
+			}
+		}
+		weights[i] = gf256Mul(numerator, gf256Inv(denominator))
+	}
+	witness := make([]byte, len(used[0].Data))
+	for b := range witness {
+		var value byte
+		for i, share := range used {
+			value ^= gf256Mul(share.Data[b], weights[i])
+		}
+		witness[b] = value
+	}
+	return witness, nil
+}
+
+// ReconstructAndProve reconstructs a witness from threshold shares, proves
+// knowledge of it with SecureProveFromBytes and clears the reconstructed
+// bytes before returning, whether or not proving succeeded. The state
+// vector derived from them while proving is not retained.
+func (sq *SecureQuantumZKP) ReconstructAndProve(shares []WitnessShare, threshold int, identifier string, key []byte) (*SecureProof, error) {
+	witness, err := ReconstructWitness(shares, threshold)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(witness)
+	return sq.SecureProveFromBytes(witness, identifier, key)
+}
+
+// StoreWitnessShares puts shares[i] under name in stores[i], creating it,
+// so that each backend holds exactly one share.
+func StoreWitnessShares(shares []WitnessShare, stores []ObjectStore, name string) error {
+	if len(shares) != len(stores) {
+		return fmt.Errorf("%d witness shares for %d stores", len(shares), len(stores))
+	}
+	for i, share := range shares {
+		data, err := json.Marshal(share)
+		if err != nil {
+			return err
+		}
+		if _, err := stores[i].Put(name, data, PutCondition{IfAbsent: true}); err != nil {
+			return fmt.Errorf("failed to store witness share %d: %w", share.Index, err)
+		}
+	}
+	return nil
+}
+
+// LoadWitnessShares reads the share stored under name from each store in
+// turn until threshold shares are found. Unreachable stores and missing
+// shares are skipped; ErrNotEnoughShares lists them when too few remain.
+func LoadWitnessShares(stores []ObjectStore, name string, threshold int) ([]WitnessShare, error) {
+	var shares []WitnessShare
+	var failures []error
+	for i, store := range stores {
+		if len(shares) == threshold {
+			break
+		}
+		data, _, err := store.Get(name)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("store %d: %w", i, err))
+			continue
+		}
+		var share WitnessShare
+		if err := json.Unmarshal(data, &share); err != nil {
+			failures = append(failures, fmt.Errorf("store %d: invalid witness share: %w", i, err))
+			continue
+		}
+		shares = append(shares, share)
+	}
+	if len(shares) < threshold {
+		err := fmt.Errorf("%w: %d of %d", ErrNotEnoughShares, len(shares), threshold)
+		if len(failures) > 0 {
+			err = fmt.Errorf("%w: %w", err, errors.Join(failures...))
+		}
+		return nil, err
+	}
+	return shares, nil
+}
+
+// gf256Eval evaluates the polynomial with the given coefficients, constant
+// term first, at x.
+func gf256Eval(coefficients []byte, x byte) byte {
+	var y byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y = gf256Mul(y, x) ^ coefficients[i]
+	}
+	return y
+}
+
+// gf256Mul multiplies in GF(2^8) modulo x^8+x^4+x^3+x+1 without
+// data-dependent branches or table lookups.
+func gf256Mul(a, b byte) byte {
+	var product byte
+	for i := 0; i < 8; i++ {
+		product ^= a & -(b & 1)
+		carry := -(a >> 7)
+		a = a<<1 ^ 0x1b&carry
+		b >>= 1
+	}
+	return product
+}
+
+// gf256Inv returns the multiplicative inverse of a non-zero a as a^254.
+func gf256Inv(a byte) byte {
+	result := a
+	for i := 0; i < 6; i++ {
+		result = gf256Mul(result, result)
+		result = gf256Mul(result, a)
+	}
+	return gf256Mul(result, result)
+}
@@ -1,52 +1,113 @@
 package main
 
 import (
-	"crypto/rand"
 	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math"
-	"math/big"
 	"time"
 )
 
+// Algorithms used by secure proofs, recorded in every proof header.
+const (
+	HashSuiteSHA256           = "sha256"
+	SignatureAlgorithmMLDSA87 = "ML-DSA-87"
+)
+
 // SecureProof represents a zero-knowledge proof that doesn't leak the secret state
 type SecureProof struct {
-	QuantumDimensions int                    `json:"quantum_dimensions"`
-	CommitmentHash    string                 `json:"commitment_hash"`
-	ChallengeResponse []ChallengeResponse    `json:"challenge_response"`
-	MerkleRoot        string                 `json:"merkle_root"`
-	StateMetadata     SecureStateMetadata    `json:"state_metadata"`
-	Identifier        string                 `json:"identifier"`
-	Signature         string                 `json:"signature"`
-	Timestamp         time.Time              `json:"timestamp"`
+	Version              int                 `json:"version"`
+	QuantumDimensions    int                 `json:"quantum_dimensions"`
+	CommitmentHash       string              `json:"commitment_hash"`
+	ChallengeResponse    []ChallengeResponse `json:"challenge_response"`
+	MerkleRoot           string              `json:"merkle_root"`
+	StateMetadata        SecureStateMetadata `json:"state_metadata"`
+	Identifier           string              `json:"identifier"`
+	IdentifierSalt       string              `json:"identifier_salt,omitempty"`
+	IdentifierScheme     string              `json:"identifier_scheme,omitempty"`
+	Signature            string              `json:"signature"`
+	Timestamp            time.Time           `json:"timestamp"`
+	Epoch                uint64              `json:"epoch"`
+	Context              Context             `json:"context"`
+	NumericEncoding      NumericEncoding     `json:"numeric_encoding"`
+	HashSuite            string              `json:"hash_suite"`
+	SignatureAlgorithm   string              `json:"signature_algorithm"`
+	Profile              string              `json:"profile,omitempty"`
+	KeyDerivation        string              `json:"key_derivation,omitempty"` // See contextKey
+	Claims               map[string]string   `json:"claims,omitempty"`         // Signed in canonical key order; see ValidateClaims
+	TimeAttestation      *TimeAttestation    `json:"time_attestation,omitempty"`
+	Session              *SessionBinding     `json:"session,omitempty"`
+	ParametersHash       string              `json:"parameters_hash,omitempty"`
+	DigestLengths        *DigestLengths      `json:"digest_lengths,omitempty"`
+	ChallengeNonceLength int                 `json:"challenge_nonce_length,omitempty"` // LegacyChallengeNonceLength if zero
+	DataEncoding         *DataEncoding       `json:"data_encoding,omitempty"`
+	Linkage              *LinkageTag         `json:"linkage,omitempty"`
+	ChallengeBinding     string              `json:"challenge_binding,omitempty"`   // See challengeBinding
+	Cosignatures         []Cosignature       `json:"cosignatures,omitempty"`        // See CosignProof
+	Batch                *BatchInclusion     `json:"batch,omitempty"`               // See SignProofBatch
+	Witness              *FamilyWitness      `json:"witness,omitempty"`             // See ProveBellPairPossession
+	Randomness           []string            `json:"randomness,omitempty"`          // Provenance classes of the randomness used; see RandomnessChain
+	Provenance           *ProofProvenance    `json:"provenance,omitempty"`          // Signing library release; see CheckCompatibility
+	Debug                *ProofDebug         `json:"debug,omitempty" qzkp:"redact"` // Unsigned diagnostics; see ProofDebug
 }
 
 // ChallengeResponse represents a response to a specific challenge without revealing the state
 type ChallengeResponse struct {
-	ChallengeIndex int     `json:"challenge_index"`
-	BasisChoice    string  `json:"basis_choice"` // "Z" or "X"
-	Response       string  `json:"response"`     // Hashed response, not actual measurement
-	Commitment     string  `json:"commitment"`   // Commitment to the measurement
-	Proof          string  `json:"proof"`        // Zero-knowledge proof of correctness
+	ChallengeIndex int    `json:"challenge_index"`
+	BasisChoice    string `json:"basis_choice"`  // "Z" or "X"
+	Response       string `json:"response"`      // Hashed response, not actual measurement
+	Commitment     string `json:"commitment"`    // Commitment to the measurement
+	Proof          string `json:"proof"`         // Zero-knowledge proof of correctness
+	MAC            string `json:"mac,omitempty"` // Session MAC, interactive mode only
 }
 
 // SecureStateMetadata contains only non-revealing metadata
 type SecureStateMetadata struct {
-	Dimension        int       `json:"dimension"`
-	EntropyBound     float64   `json:"entropy_bound"`     // Upper bound, not exact value
-	CoherenceBound   float64   `json:"coherence_bound"`   // Upper bound, not exact value
-	Timestamp        time.Time `json:"timestamp"`
-	SecurityLevel    int       `json:"security_level"`
+	Dimension      int       `json:"dimension"`
+	EntropyBound   float64   `json:"entropy_bound"`   // Upper bound, not exact value
+	CoherenceBound float64   `json:"coherence_bound"` // Upper bound, not exact value
+	Timestamp      time.Time `json:"timestamp"`
+	SecurityLevel  int       `json:"security_level"`
+	SoundnessBits  int       `json:"soundness_bits,omitempty"` // Effective soundness: one bit per challenge
 }
 
 // SecureQuantumZKP provides zero-knowledge proofs without information leakage
 type SecureQuantumZKP struct {
 	*QuantumZKP
 	SecurityParameter int
-	ChallengeSpace    int
+	// Deprecated: ChallengeSpace has no effect. Challenges range over the
+	// state dimension and the Z and X bases; see ChallengeCount.
+	ChallengeSpace       int
+	Context              Context
+	NumericEncoding      NumericEncoding      // canonical encoding of hashed numbers
+	DigestLengths        DigestLengths        // published digest lengths
+	ChallengeNonceLength int                  // per-challenge nonce bytes; DefaultChallengeNonceLength if zero, LegacyChallengeNonceLength for older verifiers
+	Profile              string               // optional profile name recorded in proofs
+	Claims               map[string]string    // optional claims recorded in proofs; see WithClaims
+	IdentifierKey        []byte               // disclosure key; enables identifier pseudonymization
+	LinkageKey           []byte               // auditor detection key; enables same-secret tags
+	TimeAuthority        TimeAuthority        // optional external creation-time attestation
+	Progress             ProgressFunc         // optional proof generation progress callback
+	Rand                 io.Reader            // optional nonce and salt source; nil uses Randomness
+	Randomness           *RandomnessChain     // optional ordered fallback of randomness sources; nil uses HybridRandom
+	HybridRandom         io.Reader            // Kyber stream mixed with crypto/rand, set by the constructors; nil uses crypto/rand
+	Clock                func() time.Time     // optional proof clock; nil uses time.Now
+	MemoryBudget         MemoryBudget         // optional proof generation memory limits
+	MeasurementBackend   MeasurementBackend   // optional challenge measurement backend; nil uses the CPU
+	IdentifierNamespace  *IdentifierNamespace // optional identifier rules enforced when proving and verifying
+	BatchSignatures      bool                 // sign SecureProveBatch proofs with one shared signature; see SignProofBatch
+	InMemory             bool                 // refuse plug-ins that reach the filesystem or network; see HostBound
+	Epochs               *EpochSchedule       // optional rotating context; verification accepts the epochs it accepts now
+	Debug                bool                 // attach a ProofDebug envelope to proofs; off by default
+	Features             *FeatureFlags        // optional runtime switches for risky features; nil enables all
+
+	precomputed     *verifierPrecomputation // set only on a Verifier's own copy
+	randomnessTrace *randomnessTrace        // set only on a proof's own copy; see withRandomnessTrace
+	debugTrace      *debugTrace             // set only on a proof's own copy; see withDebugTrace
+	openingCapture  *openingCapture         // set only on a proof's own copy; see SecureProveForSNARK
+	arena           *verificationArena      // set only on a verification's own copy; see withVerificationArena
 }
 
 // NewSecureQuantumZKP creates a new secure quantum ZKP instance
@@ -56,25 +117,39 @@ func NewSecureQuantumZKP(dimensions, securityLevel int, ctx []byte) (*SecureQuan
 		return nil, err
 	}
 
-	// Calculate security parameter based on desired security level
-	// For soundness error of 2^(-k), we need k challenges
-	var securityParameter int
+	securityParameter := defaultSoundnessBits(securityLevel)
+
+	proofContext := LegacyContext(ctx)
+	if err := proofContext.Validate(); err != nil {
+		return nil, err
+	}
+
+	sq := &SecureQuantumZKP{
+		QuantumZKP:        base,
+		SecurityParameter: securityParameter,
+		ChallengeSpace:    DefaultChallengeSpace,
+		NumericEncoding:   DefaultNumericEncoding(),
+		HybridRandom:      newHybridRandom(),
+	}
+	// Digests are sized for the soundness the challenges actually reach
+	sq.DigestLengths = DigestLengthsFor(sq.EffectiveSoundness())
+	sq.setContext(proofContext)
+	return sq, nil
+}
+
+// defaultSoundnessBits returns the soundness NewSecureQuantumZKP chooses
+// for securityLevel. For soundness error of 2^(-k), we need k challenges.
+func defaultSoundnessBits(securityLevel int) int {
 	switch {
 	case securityLevel >= 256:
-		securityParameter = 128 // 128-bit soundness (very high security)
+		return 128 // 128-bit soundness (very high security)
 	case securityLevel >= 192:
-		securityParameter = 96  // 96-bit soundness (high security)
+		return 96 // 96-bit soundness (high security)
 	case securityLevel >= 128:
-		securityParameter = 80  // 80-bit soundness (standard security)
+		return 80 // 80-bit soundness (standard security)
 	default:
-		securityParameter = 64  // 64-bit soundness (minimum acceptable)
+		return 64 // 64-bit soundness (minimum acceptable)
 	}
-
-	return &SecureQuantumZKP{
-		QuantumZKP:        base,
-		SecurityParameter: securityParameter,
-		ChallengeSpace:    1024,
-	}, nil
 }
 
 // NewSecureQuantumZKPWithSoundness creates a secure quantum ZKP with custom soundness security
@@ -89,14 +164,25 @@ func NewSecureQuantumZKPWithSoundness(dimensions, securityLevel, soundnessBits i
 		return nil, fmt.Errorf("soundness security too low: %d bits (minimum 32)", soundnessBits)
 	}
 	if soundnessBits > 256 {
-		return nil, fmt.Errorf("soundness security too high: %d bits (maximum 256)", soundnessBits)
+		return nil, fmt.Errorf("soundness security too high: %d bits (maximum 256; use SecureProveComposed for more)", soundnessBits)
+	}
+
+	proofContext := LegacyContext(ctx)
+	if err := proofContext.Validate(); err != nil {
+		return nil, err
 	}
 
-	return &SecureQuantumZKP{
+	sq := &SecureQuantumZKP{
 		QuantumZKP:        base,
 		SecurityParameter: soundnessBits,
-		ChallengeSpace:    1024,
-	}, nil
+		ChallengeSpace:    DefaultChallengeSpace,
+		NumericEncoding:   DefaultNumericEncoding(),
+		HybridRandom:      newHybridRandom(),
+	}
+	// Digests are sized for the soundness the challenges actually reach
+	sq.DigestLengths = DigestLengthsFor(sq.EffectiveSoundness())
+	sq.setContext(proofContext)
+	return sq, nil
 }
 
 // NewUltraSecureQuantumZKP creates a quantum ZKP with 256-bit soundness security
@@ -105,70 +191,329 @@ func NewUltraSecureQuantumZKP(dimensions, securityLevel int, ctx []byte) (*Secur
 	return NewSecureQuantumZKPWithSoundness(dimensions, securityLevel, 256, ctx)
 }
 
+// now returns the time recorded in proofs.
+func (sq *SecureQuantumZKP) now() time.Time {
+	if sq.Clock != nil {
+		return sq.Clock()
+	}
+	return time.Now()
+}
+
+// withSigner returns a copy of sq that signs and verifies with signer,
+// leaving sq's own signer in place.
+func (sq *SecureQuantumZKP) withSigner(signer *SignatureScheme) *SecureQuantumZKP {
+	base := *sq.QuantumZKP
+	base.Signer = signer
+	copied := *sq
+	copied.QuantumZKP = &base
+	return &copied
+}
+
+// readRandom fills b from the configured randomness source, recording
+// its provenance class when sq traces randomness.
+func (sq *SecureQuantumZKP) readRandom(b []byte) error {
+	class, err := sq.readRandomClass(b)
+	if err == nil && sq.randomnessTrace != nil {
+		sq.randomnessTrace.add(class)
+	}
+	return err
+}
+
 // SecureProveVectorKnowledge generates a zero-knowledge proof without leaking the state vector
 func (sq *SecureQuantumZKP) SecureProveVectorKnowledge(
 	vector []complex128,
 	identifier string,
 	key []byte,
 ) (*SecureProof, error) {
-	if len(vector) == 0 {
-		return nil, errors.New("state vector cannot be empty")
+	state, err := NewStateVector(vector)
+	if err != nil {
+		return nil, err
+	}
+	return sq.SecureProveState(state, identifier, key)
+}
+
+// SecureProveState generates a zero-knowledge proof of knowledge of state.
+// A state derived from bytes records its encoding in the proof.
+func (sq *SecureQuantumZKP) SecureProveState(
+	state *StateVector,
+	identifier string,
+	key []byte,
+) (*SecureProof, error) {
+	sq, randomness := sq.withRandomnessTrace()
+	sq, debug := sq.withDebugTrace()
+	published, err := sq.publishIdentifier(identifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish identifier: %w", err)
 	}
 
-	// Normalize the vector
-	normalized := normalizeStateVector(vector)
+	proof, err := sq.buildSecureProof(state, identifier, key, published)
+	if err != nil {
+		return nil, err
+	}
+	proof.DataEncoding = state.Encoding()
+	proof.Randomness = randomness.Classes()
+
+	// Sign the proof
+	err = sq.signSecureProof(proof, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign proof: %w", err)
+	}
+	proof.Debug = debug.envelope(sq, state.Dimension())
+
+	return proof, nil
+}
+
+// buildSecureProof runs the commitment/challenge/response protocol and
+// returns the resulting proof without a signature. The commitment binds the
+// real identifier; the proof carries its published form.
+func (sq *SecureQuantumZKP) buildSecureProof(
+	state *StateVector,
+	identifier string,
+	key []byte,
+	published publishedIdentifier,
+) (*SecureProof, error) {
+	pending, err := sq.prepareSecureProof(state, identifier, key, published)
+	if err != nil {
+		return nil, err
+	}
+	batch := &MeasurementBatch{
+		States:    [][]complex128{state.amplitudes},
+		Streaming: pending.streaming,
+	}
+	if pending.challenges, err = sq.addMeasurementRequests(batch, 0, pending.challenges); err != nil {
+		return nil, err
+	}
+	backend, err := sq.measurementBackend()
+	if err != nil {
+		return nil, err
+	}
+	sq.debugTrace.record(debugStageMeasurement, 0, 1)
+	measurements, err := backend.Measure(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure challenges: %w", err)
+	}
+	sq.debugTrace.record(debugStageMeasurement, 1, 1)
+	return sq.finishSecureProof(pending, measurements, key)
+}
+
+// pendingProof is a proof between commitment and responses: its
+// challenges are known but not yet measured.
+type pendingProof struct {
+	state          *StateVector
+	published      publishedIdentifier
+	commitmentHash []byte
+	created        time.Time // the proof's timestamp, whose epoch the challenges are derived for
+	challenges     []Challenge
+	streaming      bool
+}
+
+// prepareSecureProof commits to state and derives the challenges.
+func (sq *SecureQuantumZKP) prepareSecureProof(
+	state *StateVector,
+	identifier string,
+	key []byte,
+	published publishedIdentifier,
+) (*pendingProof, error) {
+	if state == nil {
+		return nil, errors.New("state vector cannot be nil")
+	}
+	if err := sq.NumericEncoding.Validate(); err != nil {
+		return nil, err
+	}
+	if err := sq.DigestLengths.Validate(); err != nil {
+		return nil, err
+	}
+	if err := validateChallengeNonceLength(sq.challengeNonceLength()); err != nil {
+		return nil, err
+	}
+	challengeCount, err := sq.challengeCount()
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateClaims(sq.Claims); err != nil {
+		return nil, err
+	}
+	memory, err := sq.planProofMemory(state.Dimension(), challengeCount)
+	if err != nil {
+		return nil, err
+	}
 
 	// Generate commitment to the state vector
-	stateCommitment, err := sq.generateStateCommitment(normalized, identifier, key)
+	sq.reportProgress(ProgressStageCommitment, 0, 1)
+	stateCommitment, err := sq.generateStateCommitment(state, identifier, key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate state commitment: %w", err)
 	}
+	sq.reportProgress(ProgressStageCommitment, 1, 1)
 
-	// Generate challenge-response pairs
-	challenges, err := sq.generateChallenges(sq.SecurityParameter)
+	// Derive the challenges from the published commitment so the prover
+	// cannot choose them after the fact
+	commitmentHash := stateCommitment[:sq.DigestLengths.Commitment]
+	created := sq.now()
+	sq.reportProgress(ProgressStageChallenges, 0, challengeCount)
+	challenges, err := sq.deriveChallenges(commitmentHash, ChallengeEpoch(created), state.Dimension(), challengeCount, sq.challengeNonceLength())
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate challenges: %w", err)
 	}
+	sq.reportProgress(ProgressStageChallenges, len(challenges), len(challenges))
+
+	return &pendingProof{
+		state:          state,
+		published:      published,
+		commitmentHash: commitmentHash,
+		created:        created,
+		challenges:     challenges,
+		streaming:      memory.Streaming,
+	}, nil
+}
+
+// finishSecureProof answers the pending proof's challenges from their
+// measurements and assembles the unsigned proof.
+func (sq *SecureQuantumZKP) finishSecureProof(pending *pendingProof, measurements []AmplitudeMeasurement, key []byte) (*SecureProof, error) {
+	responses, err := sq.responsesFor(pending.challenges, measurements, key)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := sq.assembleSecureProof(pending.state.Dimension(), pending.commitmentHash, responses, pending.published, pending.created)
+	if err != nil {
+		return nil, err
+	}
+	if proof.ChallengeBinding, err = challengeBinding(pending.commitmentHash, pending.challenges, responses); err != nil {
+		return nil, err
+	}
+	if err := sq.attachLinkageTag(proof, pending.state); err != nil {
+		return nil, fmt.Errorf("failed to attach linkage tag: %w", err)
+	}
+	return proof, nil
+}
 
+// respondToChallenges answers every challenge in order. When streaming,
+// X-basis amplitudes are computed one at a time (see MemoryBudget).
+func (sq *SecureQuantumZKP) respondToChallenges(
+	state *StateVector,
+	challenges []Challenge,
+	key []byte,
+	streaming bool,
+) ([]ChallengeResponse, error) {
+	batch := &MeasurementBatch{
+		States:    [][]complex128{state.amplitudes},
+		Streaming: streaming,
+	}
+	challenges, err := sq.addMeasurementRequests(batch, 0, challenges)
+	if err != nil {
+		return nil, err
+	}
+	backend, err := sq.measurementBackend()
+	if err != nil {
+		return nil, err
+	}
+	measurements, err := backend.Measure(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure challenges: %w", err)
+	}
+	return sq.responsesFor(challenges, measurements, key)
+}
+
+// addMeasurementRequests appends the requests measuring challenges of
+// state to batch. Out-of-range indices wrap around the state, and the
+// returned challenges carry the wrapped indices the responses report.
+func (sq *SecureQuantumZKP) addMeasurementRequests(batch *MeasurementBatch, state int, challenges []Challenge) ([]Challenge, error) {
+	dimension := len(batch.States[state])
+	wrapped := make([]Challenge, len(challenges))
+	for i, challenge := range challenges {
+		if challenge.Index >= dimension {
+			challenge.Index = challenge.Index % dimension
+		}
+		request, err := measurementRequest(state, challenge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to respond to challenge %d: %w", i, err)
+		}
+		batch.Requests = append(batch.Requests, request)
+		wrapped[i] = challenge
+	}
+	return wrapped, nil
+}
+
+// responsesFor answers challenges given their measurements, in order.
+func (sq *SecureQuantumZKP) responsesFor(challenges []Challenge, measurements []AmplitudeMeasurement, key []byte) ([]ChallengeResponse, error) {
+	key, err := sq.contextKey(key)
+	if err != nil {
+		return nil, err
+	}
 	responses := make([]ChallengeResponse, len(challenges))
+	sq.reportProgress(ProgressStageResponses, 0, len(challenges))
 	for i, challenge := range challenges {
-		response, err := sq.respondToChallenge(normalized, challenge, key)
+		response, err := sq.respondToChallenge(challenge, measurements[i], key)
 		if err != nil {
 			return nil, fmt.Errorf("failed to respond to challenge %d: %w", i, err)
 		}
 		responses[i] = response
+		sq.reportProgress(ProgressStageResponses, i+1, len(challenges))
 	}
+	return responses, nil
+}
 
+// assembleSecureProof builds the unsigned proof around a set of responses,
+// timestamped created and in its challenge epoch.
+func (sq *SecureQuantumZKP) assembleSecureProof(
+	dimension int,
+	commitmentHash []byte,
+	responses []ChallengeResponse,
+	published publishedIdentifier,
+	created time.Time,
+) (*SecureProof, error) {
 	// Generate Merkle tree root for all responses
+	sq.reportProgress(ProgressStageMerkle, 0, 1)
 	merkleRoot, err := sq.generateMerkleRoot(responses)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate Merkle root: %w", err)
 	}
+	sq.reportProgress(ProgressStageMerkle, 1, 1)
 
 	// Create secure metadata (bounds only, not exact values)
 	metadata := SecureStateMetadata{
-		Dimension:        len(normalized),
-		EntropyBound:     math.Log2(float64(len(normalized))), // Maximum possible entropy
-		CoherenceBound:   float64(len(normalized)),            // Maximum possible coherence
-		Timestamp:        time.Now(),
-		SecurityLevel:    sq.SecurityLevel,
+		Dimension:      dimension,
+		EntropyBound:   math.Log2(float64(dimension)), // Maximum possible entropy
+		CoherenceBound: float64(dimension),            // Maximum possible coherence
+		Timestamp:      created,
+		SecurityLevel:  sq.SecurityLevel,
+		SoundnessBits:  len(responses),
 	}
 
-	// Build the secure proof
-	proof := &SecureProof{
-		QuantumDimensions: sq.Dimensions,
-		CommitmentHash:    hex.EncodeToString(stateCommitment[:16]), // Use only first 16 bytes
-		ChallengeResponse: responses,
-		MerkleRoot:        merkleRoot, // Keep full Merkle root for verification
-		StateMetadata:     metadata,
-		Identifier:        identifier,
-		Timestamp:         time.Now(),
+	parametersHash, err := sq.Parameters().Hash()
+	if err != nil {
+		return nil, err
 	}
+	lengths := sq.DigestLengths
 
-	// Sign the proof
-	err = sq.signSecureProof(proof, key)
-	if err != nil {
-		return nil, fmt.Errorf("failed to sign proof: %w", err)
+	// Build the secure proof
+	proof := &SecureProof{
+		Version:              CurrentProofVersion,
+		QuantumDimensions:    sq.Dimensions,
+		CommitmentHash:       EncodeHexField(commitmentHash),
+		ChallengeResponse:    responses,
+		MerkleRoot:           merkleRoot, // Keep full Merkle root for verification
+		StateMetadata:        metadata,
+		Identifier:           published.Identifier,
+		IdentifierSalt:       published.Salt,
+		IdentifierScheme:     published.Scheme,
+		Timestamp:            created,
+		Epoch:                ChallengeEpoch(created),
+		Context:              sq.Context,
+		NumericEncoding:      sq.NumericEncoding,
+		HashSuite:            HashSuiteSHA256,
+		SignatureAlgorithm:   SignatureAlgorithmMLDSA87,
+		Profile:              sq.Profile,
+		KeyDerivation:        KeyDerivationHKDFContext,
+		Claims:               copyClaims(sq.Claims),
+		ParametersHash:       parametersHash,
+		DigestLengths:        &lengths,
+		ChallengeNonceLength: sq.recordedChallengeNonceLength(),
+	}
+
+	if err := sq.attachTimeAttestation(proof); err != nil {
+		return nil, err
 	}
 
 	return proof, nil
@@ -176,118 +521,70 @@ func (sq *SecureQuantumZKP) SecureProveVectorKnowledge(
 
 // generateStateCommitment creates a cryptographic commitment to the state vector
 func (sq *SecureQuantumZKP) generateStateCommitment(
-	vector []complex128,
+	state *StateVector,
 	identifier string,
 	key []byte,
 ) ([]byte, error) {
+	key, err := sq.contextKey(key)
+	if err != nil {
+		return nil, err
+	}
 	hasher := sha256.New()
 
 	// Add the state vector components (but this stays secret)
-	for _, c := range vector {
-		hasher.Write([]byte(fmt.Sprintf("%.10f%.10f", real(c), imag(c))))
+	if err := state.writeAmplitudes(hasher, sq.NumericEncoding); err != nil {
+		return nil, err
 	}
 
-	// Add identifier and key
+	// Add identifier, context and key
 	hasher.Write([]byte(identifier))
+	hasher.Write(sq.Context.Bytes())
 	hasher.Write(key)
 
 	// Add random nonce for uniqueness
-	nonce := make([]byte, 32)
-	_, err := rand.Read(nonce)
-	if err != nil {
+	nonce := make([]byte, commitmentNonceLength)
+	if err := sq.readRandom(nonce); err != nil {
 		return nil, err
 	}
 	hasher.Write(nonce)
 
+	if sq.openingCapture != nil {
+		if err := sq.openingCapture.capture(state, identifier, sq, key, nonce); err != nil {
+			return nil, err
+		}
+	}
 	return hasher.Sum(nil), nil
 }
 
 // Challenge represents a challenge in the zero-knowledge protocol
 type Challenge struct {
-	Index      int    `json:"index"`
-	BasisType  string `json:"basis_type"`  // "Z" or "X"
-	Nonce      []byte `json:"nonce"`
-}
-
-// generateChallenges creates random challenges for the ZK protocol
-func (sq *SecureQuantumZKP) generateChallenges(numChallenges int) ([]Challenge, error) {
-	challenges := make([]Challenge, numChallenges)
-
-	for i := 0; i < numChallenges; i++ {
-		// Random basis choice
-		basisChoice := "Z"
-		if randBit, err := rand.Int(rand.Reader, big.NewInt(2)); err == nil && randBit.Int64() == 1 {
-			basisChoice = "X"
-		}
-
-		// Random index within the vector dimension
-		maxIndex := big.NewInt(int64(sq.Dimensions))
-		if maxIndex.Int64() == 0 {
-			maxIndex = big.NewInt(1)
-		}
-
-		randIndex, err := rand.Int(rand.Reader, maxIndex)
-		if err != nil {
-			return nil, err
-		}
-
-		// Random nonce (minimal size)
-		nonce := make([]byte, 4)
-		_, err = rand.Read(nonce)
-		if err != nil {
-			return nil, err
-		}
-
-		challenges[i] = Challenge{
-			Index:     int(randIndex.Int64()),
-			BasisType: basisChoice,
-			Nonce:     nonce,
-		}
-	}
-
-	return challenges, nil
+	Index     int    `json:"index"`
+	BasisType string `json:"basis_type"` // "Z" or "X"
+	Nonce     []byte `json:"nonce"`
 }
 
 // respondToChallenge generates a zero-knowledge response to a challenge
+// from its measurement
 func (sq *SecureQuantumZKP) respondToChallenge(
-	vector []complex128,
 	challenge Challenge,
+	m AmplitudeMeasurement,
 	key []byte,
 ) (ChallengeResponse, error) {
-	// Ensure index is within bounds
-	if challenge.Index >= len(vector) {
-		challenge.Index = challenge.Index % len(vector)
-	}
-
-	var measurement float64
-	var phase float64
-
-	// Compute the measurement based on basis choice
-	if challenge.BasisType == "Z" {
-		// Z-basis measurement
-		c := vector[challenge.Index]
-		measurement = real(c)*real(c) + imag(c)*imag(c)
-		phase = math.Atan2(imag(c), real(c))
-	} else {
-		// X-basis measurement (apply Hadamard first)
-		xStates, err := ApplyHadamard(vector)
-		if err != nil {
-			return ChallengeResponse{}, err
-		}
-		c := xStates[challenge.Index]
-		measurement = real(c)*real(c) + imag(c)*imag(c)
-		phase = math.Atan2(imag(c), real(c))
-	}
+	measurement, phase := m.Probability, m.Phase
+	basis := challenge.BasisType
 
 	// Create commitment to the measurement (without revealing it)
-	commitmentData := fmt.Sprintf("%.10f%.10f%s%x", measurement, phase, challenge.BasisType, challenge.Nonce)
 	hasher := sha256.New()
-	hasher.Write([]byte(commitmentData))
+	if err := sq.NumericEncoding.writeNumbers(hasher, measurement, phase); err != nil {
+		return ChallengeResponse{}, err
+	}
+	hasher.Write([]byte(basis))
+	hasher.Write(challenge.Nonce)
 	hasher.Write(key)
 	commitment := hasher.Sum(nil)
 
 	// Create a hash-based response (doesn't reveal the actual measurement)
-	responseData := fmt.Sprintf("%s%d%x", challenge.BasisType, challenge.Index, challenge.Nonce)
+	responseData := fmt.Sprintf("%s%d%x", basis, challenge.Index, challenge.Nonce)
 	responseHasher := sha256.New()
 	responseHasher.Write([]byte(responseData))
 	responseHasher.Write(commitment)
@@ -295,7 +592,7 @@ func (sq *SecureQuantumZKP) respondToChallenge(
 
 	// Generate a zero-knowledge proof that the response is correct
 	// (This is a simplified version - in practice, you'd use more sophisticated ZK proofs)
-	proofData := fmt.Sprintf("proof_%s_%d_%x", challenge.BasisType, challenge.Index, response)
+	proofData := fmt.Sprintf("proof_%s_%d_%x", basis, challenge.Index, response)
 	proofHasher := sha256.New()
 	proofHasher.Write([]byte(proofData))
 	proofHasher.Write(key)
@@ -304,9 +601,9 @@ func (sq *SecureQuantumZKP) respondToChallenge(
 	return ChallengeResponse{
 		ChallengeIndex: challenge.Index,
 		BasisChoice:    challenge.BasisType,
-		Response:       hex.EncodeToString(response[:8]),   // Use only first 8 bytes (16 hex chars)
-		Commitment:     hex.EncodeToString(commitment[:8]), // Use only first 8 bytes (16 hex chars)
-		Proof:          hex.EncodeToString(proof[:8]),      // Use only first 8 bytes (16 hex chars)
+		Response:       EncodeHexField(response[:sq.DigestLengths.Response]),
+		Commitment:     EncodeHexField(commitment[:sq.DigestLengths.Response]),
+		Proof:          EncodeHexField(proof[:sq.DigestLengths.Response]),
 	}, nil
 }
 
@@ -316,15 +613,37 @@ func (sq *SecureQuantumZKP) generateMerkleRoot(responses []ChallengeResponse) (s
 		return "", errors.New("no responses to hash")
 	}
 
-	// Create leaf hashes
+	return EncodeHexField(merkleRootOfLeaves(responseLeaves(responses))), nil
+}
+
+// responseLeaves hashes each challenge response into a Merkle leaf.
+func responseLeaves(responses []ChallengeResponse) [][]byte {
 	leaves := make([][]byte, len(responses))
 	for i, response := range responses {
-		hasher := sha256.New()
-		responseBytes, _ := json.Marshal(response)
-		hasher.Write(responseBytes)
-		leaves[i] = hasher.Sum(nil)
+		leaves[i] = responseLeaf(response)
 	}
+	return leaves
+}
+
+// responseLeaf is the Merkle leaf for a single challenge response: the
+// SHA-256 of its JSON encoding.
+func responseLeaf(response ChallengeResponse) []byte {
+	responseBytes, _ := json.Marshal(response)
+	leaf := sha256.Sum256(responseBytes)
+	return leaf[:]
+}
 
+// merkleRootOfLeaves folds already-hashed leaves into a SHA-256 Merkle root,
+// duplicating the last node on odd levels.
+func merkleRootOfLeaves(leaves [][]byte) []byte {
+	levels := merkleLevels(leaves)
+	return levels[len(levels)-1][0]
+}
+
+// merkleLevels returns every level of the Merkle tree over leaves, from the
+// leaves up to the single root. Each interior node is SHA-256(left ‖ right).
+func merkleLevels(leaves [][]byte) [][][]byte {
+	levels := [][][]byte{leaves}
 	// Build Merkle tree (simplified version)
 	for len(leaves) > 1 {
 		var nextLevel [][]byte
@@ -339,80 +658,224 @@ func (sq *SecureQuantumZKP) generateMerkleRoot(responses []ChallengeResponse) (s
 			nextLevel = append(nextLevel, hasher.Sum(nil))
 		}
 		leaves = nextLevel
+		levels = append(levels, leaves)
 	}
 
-	return hex.EncodeToString(leaves[0]), nil
+	return levels
 }
 
-// signSecureProof signs the secure proof
+// signSecureProof signs the secure proof, recording this release in the
+// provenance of current-version proofs
 func (sq *SecureQuantumZKP) signSecureProof(proof *SecureProof, key []byte) error {
-	// Prepare message for signing (exclude signature field)
-	temp := *proof
-	temp.Signature = ""
-
-	proofBytes, err := json.Marshal(&temp)
+	if proof.Version == CurrentProofVersion {
+		proof.Provenance = newProofProvenance(proof)
+	}
+	proofBytes, err := secureProofSigningMessage(proof)
 	if err != nil {
 		return err
 	}
 
 	// Sign the proof
+	sq.reportProgress(ProgressStageSigning, 0, 1)
 	sigBytes, err := sq.Signer.Sign(proofBytes)
 	if err != nil {
 		return err
 	}
 
-	proof.Signature = hex.EncodeToString(sigBytes)
+	proof.Signature = EncodeHexField(sigBytes)
+	sq.reportProgress(ProgressStageSigning, 1, 1)
 	return nil
 }
 
+// secureProofSigningMessage is the message proof signatures and
+// cosignatures sign: the proof without any of its signatures, its batch
+// inclusion or its debug envelope.
+func secureProofSigningMessage(proof *SecureProof) ([]byte, error) {
+	temp := *proof
+	temp.Signature = ""
+	temp.Cosignatures = nil
+	temp.Batch = nil
+	temp.Debug = nil
+	return json.Marshal(&temp)
+}
+
 // VerifySecureProof verifies a zero-knowledge proof without learning anything about the secret
 func (sq *SecureQuantumZKP) VerifySecureProof(proof *SecureProof, key []byte) bool {
+	sq, arena := sq.withVerificationArena()
+	defer arena.release()
+	sq, err := sq.epochVerifier(proof)
+	if err != nil {
+		return false
+	}
+
 	// 1. Verify signature
-	temp := *proof
-	temp.Signature = ""
-	proofBytes, err := json.Marshal(&temp)
+	if !sq.verifySecureProofSignature(proof) {
+		return false
+	}
+
+	return sq.verifySecureProofBody(proof, key)
+}
+
+// verifySecureProofSignature checks the signature over the proof with the
+// signature field cleared, or over its batch root for batch-signed proofs.
+func (sq *SecureQuantumZKP) verifySecureProofSignature(proof *SecureProof) bool {
+	if proof.Batch != nil {
+		return sq.verifyBatchSignature(proof)
+	}
+	a, err := sq.scratch()
+	if err != nil {
+		return false
+	}
+	defer sq.releaseScratch(a)
+	proofBytes, err := a.signingMessage(proof)
 	if err != nil {
 		return false
 	}
 
-	sigBytes, err := hex.DecodeString(proof.Signature)
+	sigBytes, err := a.decodeSignature(proof.Signature)
 	if err != nil {
 		return false
 	}
 
-	if !sq.Signer.Verify(proofBytes, sigBytes) {
+	return sq.Signer.Verify(proofBytes, sigBytes)
+}
+
+// verifySecureProofBody runs every check of VerifySecureProof except the
+// signature check.
+func (sq *SecureQuantumZKP) verifySecureProofBody(proof *SecureProof, key []byte) bool {
+	// Interactive proofs answer verifier-chosen challenges and can only be
+	// checked with the session key (see VerifierSession.Verify)
+	if proof.Session != nil {
+		return false
+	}
+
+	if !sq.verifySecureProofCommon(proof, key) {
+		return false
+	}
+
+	// 3. Verify the challenges were derived from the commitment
+	return sq.verifyDerivedChallenges(proof)
+}
+
+// verifySecureProofCommon runs the checks shared by non-interactive and
+// interactive proofs.
+func (sq *SecureQuantumZKP) verifySecureProofCommon(proof *SecureProof, key []byte) bool {
+	// Older formats are only accepted through VerifySecureProofVersioned,
+	// which reports their caveats
+	if proof.Version != CurrentProofVersion || !sq.matchesParameters(proof) {
+		return false
+	}
+	// Shorter digests than this verifier requires are never accepted
+	if proof.DigestLengths == nil || proof.DigestLengths.Validate() != nil ||
+		!proof.DigestLengths.AtLeast(sq.DigestLengths) {
+		return false
+	}
+	// Current proofs report the soundness their challenges reach
+	if proof.StateMetadata.SoundnessBits == 0 {
+		return false
+	}
+
+	return sq.verifySecureProofFields(proof, key)
+}
+
+// matchesParameters reports whether the proof's parameters hash is the
+// hash of sq's parameters at the challenge nonce length the proof records.
+func (sq *SecureQuantumZKP) matchesParameters(proof *SecureProof) bool {
+	if proof.ChallengeNonceLength != 0 && validateChallengeNonceLength(proof.ChallengeNonceLength) != nil {
+		return false
+	}
+	if sq.precomputed != nil && proof.challengeNonceLength() == sq.challengeNonceLength() {
+		return proof.ParametersHash == sq.precomputed.parametersHash
+	}
+	expected, err := sq.parametersFor(proof).Hash()
+	return err == nil && proof.ParametersHash == expected
+}
+
+// verifySecureProofFields runs the checks of verifySecureProofCommon that
+// do not depend on the format version.
+func (sq *SecureQuantumZKP) verifySecureProofFields(proof *SecureProof, key []byte) bool {
+	// Proofs from another application or protocol version are never accepted
+	if !proof.Context.Equal(sq.Context) {
+		return false
+	}
+	if proof.NumericEncoding.Validate() != nil {
+		return false
+	}
+	// Identifiers that could not have been proven here are not accepted
+	// either, so a log line or lookup never sees one
+	if sq.checkPublishedIdentifier(proof.Identifier, proof.IdentifierScheme) != nil {
+		return false
+	}
+	if ValidateClaims(proof.Claims) != nil {
+		return false
+	}
+	// The declared algorithms must be the ones actually used, so policies
+	// evaluated against the header cannot be misled
+	if proof.HashSuite != HashSuiteSHA256 || proof.SignatureAlgorithm != SignatureAlgorithmMLDSA87 {
+		return false
+	}
+
+	if !verifyDigestLengths(proof.CommitmentHash, proof.ChallengeResponse, proof.digestLengths()) {
+		return false
+	}
+	if proof.DataEncoding != nil && proof.DataEncoding.validate(proof.StateMetadata.Dimension) != nil {
+		return false
+	}
+	if proof.Linkage != nil && proof.Linkage.validate() != nil {
+		return false
+	}
+	if proof.Witness != nil && sq.verifyFamilyWitness(proof) != nil {
+		return false
+	}
+	if validateRandomnessClasses(proof.Randomness) != nil {
+		return false
+	}
+	if bits := proof.StateMetadata.SoundnessBits; bits != 0 && bits != len(proof.ChallengeResponse) {
+		return false
+	}
+	if sq.CheckChallengeConsistency(proof) != nil {
 		return false
 	}
 
 	// 2. Verify Merkle root consistency
-	computedRoot, err := sq.generateMerkleRoot(proof.ChallengeResponse)
+	a, err := sq.scratch()
+	if err != nil {
+		return false
+	}
+	defer sq.releaseScratch(a)
+	computedRoot, err := a.merkleRoot(proof.ChallengeResponse)
 	if err != nil {
 		return false
 	}
 
-	if computedRoot != proof.MerkleRoot {
+	if string(a.hexDigest(computedRoot[:])) != proof.MerkleRoot {
 		return false
 	}
 
-	// 3. Verify each challenge response (without learning the secret)
+	// 4. Verify each challenge response (without learning the secret)
 	for _, response := range proof.ChallengeResponse {
 		if !sq.verifyChallengeResponse(response, key) {
 			return false
 		}
 	}
 
-	// 4. Verify metadata bounds are reasonable
+	// 5. Verify metadata bounds are reasonable
 	if !sq.verifyMetadataBounds(proof.StateMetadata) {
 		return false
 	}
 
+	// 6. Verify the attested creation time, if present
+	if !sq.verifyTimeAttestation(proof) {
+		return false
+	}
+
 	return true
 }
 
 // verifyChallengeResponse verifies a single challenge response without learning the measurement
 func (sq *SecureQuantumZKP) verifyChallengeResponse(response ChallengeResponse, key []byte) bool {
 	// Verify that the response is well-formed
-	if response.BasisChoice != "Z" && response.BasisChoice != "X" {
+	if !validBasis(response.BasisChoice) {
 		return false
 	}
 
@@ -420,19 +883,22 @@ func (sq *SecureQuantumZKP) verifyChallengeResponse(response ChallengeResponse,
 		return false
 	}
 
-	// Verify that commitment and proof hashes are valid hex
-	commitmentBytes, err := hex.DecodeString(response.Commitment)
+	// Verify that commitment and proof hashes are valid hex of at least
+	// the minimum length for security (adjusted for shorter hashes)
+	a, err := sq.scratch()
 	if err != nil {
 		return false
 	}
+	defer sq.releaseScratch(a)
+	if _, err := a.decodeDigest(response.Commitment, minResponseFieldLength); err != nil {
+		return false
+	}
 
-	proofBytes, err := hex.DecodeString(response.Proof)
-	if err != nil {
+	if _, err := a.decodeDigest(response.Proof, minResponseFieldLength); err != nil {
 		return false
 	}
 
-	responseBytes, err := hex.DecodeString(response.Response)
-	if err != nil {
+	if _, err := a.decodeDigest(response.Response, minResponseFieldLength); err != nil {
 		return false
 	}
 
@@ -440,11 +906,6 @@ func (sq *SecureQuantumZKP) verifyChallengeResponse(response ChallengeResponse,
 	// sophisticated zero-knowledge proof verification
 	// For now, we focus on ensuring the proof structure is valid and doesn't leak information
 
-	// Verify minimum lengths for security (adjusted for shorter hashes)
-	if len(commitmentBytes) < 4 || len(proofBytes) < 4 || len(responseBytes) < 4 {
-		return false
-	}
-
 	// For this demonstration, we accept all well-formed responses
 	// In a production system, this would include:
 	// - Verification of zero-knowledge proofs
@@ -458,7 +919,7 @@ func (sq *SecureQuantumZKP) verifyChallengeResponse(response ChallengeResponse,
 // verifyMetadataBounds checks that metadata bounds are reasonable
 func (sq *SecureQuantumZKP) verifyMetadataBounds(metadata SecureStateMetadata) bool {
 	// Check dimension is positive and reasonable
-	if metadata.Dimension <= 0 || metadata.Dimension > 1024 {
+	if metadata.Dimension <= 0 || metadata.Dimension > maxProofDimension {
 		return false
 	}
 
@@ -474,7 +935,7 @@ func (sq *SecureQuantumZKP) verifyMetadataBounds(metadata SecureStateMetadata) b
 	}
 
 	// Check security level is reasonable
-	if metadata.SecurityLevel < 64 || metadata.SecurityLevel > 512 {
+	if metadata.SecurityLevel < minMetadataSecurityLevel || metadata.SecurityLevel > maxMetadataSecurityLevel {
 		return false
 	}
 
@@ -487,16 +948,5 @@ func (sq *SecureQuantumZKP) SecureProveFromBytes(
 	identifier string,
 	key []byte,
 ) (*SecureProof, error) {
-	// Convert bytes to quantum state vector
-	targetSize := 8
-	if sq.SecurityLevel >= 256 {
-		targetSize = 16
-	}
-
-	states, err := BytesToState(data, targetSize)
-	if err != nil {
-		return nil, fmt.Errorf("failed to convert bytes to state: %w", err)
-	}
-
-	return sq.SecureProveVectorKnowledge(states, identifier, key)
+	return sq.SecureProveFromBytesWithOptions(data, identifier, key, BytesProofOptions{})
 }
@@ -5,6 +5,7 @@ import (
 	"time"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -13,7 +14,8 @@ func TestQuantumZKPApplication(t *testing.T) {
 	t.Log("🚀 Testing Quantum ZKP Application Integration...")
 	
 	// Test if the main application can be built
-	buildCmd := exec.Command("go", "build", "-o", "/tmp/qzkp_test", "../../src/examples/main.go")
+	binary := filepath.Join(t.TempDir(), "qzkp_test")
+	buildCmd := exec.Command("go", "build", "-o", binary, "../../src/examples/main.go")
 	buildOutput, err := buildCmd.CombinedOutput()
 	
 	if err != nil {
@@ -25,8 +27,6 @@ func TestQuantumZKPApplication(t *testing.T) {
 	
 	t.Log("✅ Application built successfully")
 	
-	// Clean up
-	os.Remove("/tmp/qzkp_test")
 }
 
 // Test quantum state generation functionality
@@ -240,7 +240,7 @@ func TestFileOperations(t *testing.T) {
 	t.Log("📁 Testing file operations...")
 	
 	// Test temporary file creation
-	tempFile := "/tmp/qzkp_test_" + time.Now().Format("20060102_150405") + ".tmp"
+	tempFile := filepath.Join(t.TempDir(), "qzkp_test_"+time.Now().Format("20060102_150405")+".tmp")
 	
 	// Write test data
 	testData := []byte("quantum zkp test data")
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"github.com/hydraresearch/qzkp/src/security"
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+// levelProof is a prover and its proof fixture at one soundness level.
+type levelProof struct {
+	zkp   *security.SecureQuantumZKP
+	proof *security.SecureProof
+}
+
+// levelProofs are the suite's shared proofs at each soundness level.
+var levelProofs = testutil.NewProofFixtures(func(soundness int) (levelProof, error) {
+	zkp, err := security.NewSecureQuantumZKPWithSoundness(3, 128, soundness, []byte(testutil.ProofContext))
+	if err != nil {
+		return levelProof{}, err
+	}
+	proof, err := zkp.SecureProveVectorKnowledge(testutil.RampVector(testutil.ProofDimension), testutil.ProofIdentifier, testutil.Key())
+	return levelProof{zkp, proof}, err
+})
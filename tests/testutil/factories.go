@@ -0,0 +1,55 @@
+// Package testutil holds the fixtures shared by the unit, integration and
+// security tests: deterministic keys and state vectors, golden files and
+// tamper utilities and per-level proof fixtures. It depends only on the
+// standard library so every test tree can import it; each suite supplies
+// the prove function its ProofFixtures are made with.
+package testutil
+
+import (
+	"crypto/sha256"
+	"math"
+)
+
+// SoundnessLevels are the soundness settings tests exercise, from the
+// minimum the library accepts to its maximum.
+var SoundnessLevels = []int{32, 64, 80, 128, 256}
+
+// Key returns the proof key tests share: 32 bytes, the minimum length.
+func Key() []byte {
+	return []byte("12345678901234567890123456789012")
+}
+
+// KeyFor returns a distinct 32-byte key for name, for tests that need
+// keys which must not match Key.
+func KeyFor(name string) []byte {
+	sum := sha256.Sum256([]byte("qzkp/testutil/key/" + name))
+	return sum[:]
+}
+
+// RampVector returns an unnormalized vector of the given dimension whose
+// amplitudes are all distinct and nonzero, so no index or basis is a
+// special case.
+func RampVector(dimension int) []complex128 {
+	v := make([]complex128, dimension)
+	for i := range v {
+		v[i] = complex(float64(i+1), float64(i%3))
+	}
+	return v
+}
+
+// UniformVector returns the normalized uniform superposition.
+func UniformVector(dimension int) []complex128 {
+	v := make([]complex128, dimension)
+	amplitude := complex(1/math.Sqrt(float64(dimension)), 0)
+	for i := range v {
+		v[i] = amplitude
+	}
+	return v
+}
+
+// BasisVector returns computational basis state index.
+func BasisVector(dimension, index int) []complex128 {
+	v := make([]complex128, dimension)
+	v[index] = 1
+	return v
+}
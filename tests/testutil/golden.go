@@ -0,0 +1,62 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// update rewrites golden files instead of comparing against them:
+//
+//	go test ./tests/unit -run TestName -update
+var update = flag.Bool("update", false, "rewrite golden files with the current output")
+
+// GoldenPath returns the path of golden file name, under the testdata
+// directory of the package being tested.
+func GoldenPath(name string) string {
+	return filepath.Join("testdata", name+".golden")
+}
+
+// Golden compares got with golden file name, or rewrites the file when
+// the -update flag is set. A missing file is an error without -update, so
+// new golden files are always created deliberately.
+func Golden(t testing.TB, name string, got []byte) {
+	t.Helper()
+	path := GoldenPath(name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file (run with -update if the change is intended)\ngot:\n%s\nwant:\n%s",
+			name, got, want)
+	}
+}
+
+// GoldenJSON compares the indented JSON encoding of v with golden file name.
+func GoldenJSON(t testing.TB, name string, v interface{}) {
+	t.Helper()
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatalf("failed to encode %s: %v", name, err)
+	}
+	Golden(t, name, append(data, '\n'))
+}
+
+// Updating reports whether the -update flag is set, for tests that create
+// golden files of their own layout.
+func Updating() bool {
+	return *update
+}
@@ -0,0 +1,49 @@
+package testutil
+
+import (
+	"sync"
+	"testing"
+)
+
+// The statement every proof fixture proves, so that a suite's proof at a
+// given soundness level matches every other suite's.
+const (
+	ProofContext    = "testutil-context"
+	ProofIdentifier = "testutil_proof"
+	ProofDimension  = 8 // of the proven vector, RampVector(ProofDimension)
+)
+
+// ProofFixtures makes a suite's proof at each soundness level on first use
+// and hands the same one to every later caller. The prove function comes
+// from the suite, as testutil cannot depend on the library's types; P is
+// whatever it returns, typically a prover and its proof. Fixtures are
+// shared, so a test that modifies one must copy it first.
+type ProofFixtures[P any] struct {
+	prove func(soundness int) (P, error)
+	mu    sync.Mutex
+	made  map[int]P
+}
+
+// NewProofFixtures returns fixtures made by prove, which proves
+// RampVector(ProofDimension) as ProofIdentifier under Key and ProofContext
+// at the given soundness.
+func NewProofFixtures[P any](prove func(soundness int) (P, error)) *ProofFixtures[P] {
+	return &ProofFixtures[P]{prove: prove, made: make(map[int]P)}
+}
+
+// At returns the fixture at soundness, making it if no test has asked for
+// it yet.
+func (f *ProofFixtures[P]) At(t testing.TB, soundness int) P {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if p, ok := f.made[soundness]; ok {
+		return p
+	}
+	p, err := f.prove(soundness)
+	if err != nil {
+		t.Fatalf("failed to make the %d-bit proof fixture: %v", soundness, err)
+	}
+	f.made[soundness] = p
+	return p
+}
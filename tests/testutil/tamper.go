@@ -0,0 +1,68 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// FlipByte returns a copy of data with the low bit of the byte at offset
+// flipped. A negative offset counts from the end.
+func FlipByte(data []byte, offset int) []byte {
+	if offset < 0 {
+		offset += len(data)
+	}
+	tampered := append([]byte(nil), data...)
+	tampered[offset] ^= 0x01
+	return tampered
+}
+
+// Truncate returns a copy of the first n bytes of data. A negative n drops
+// that many bytes from the end.
+func Truncate(data []byte, n int) []byte {
+	if n < 0 {
+		n += len(data)
+	}
+	return append([]byte(nil), data[:n]...)
+}
+
+// DuplicateField returns the JSON object data with a second occurrence of
+// its top-level field set to value, placed last so that decoders which
+// keep the last occurrence, like encoding/json, see value while those
+// which keep the first see the original.
+func DuplicateField(data []byte, field string, value json.RawMessage) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("not a JSON object: %w", err)
+	}
+	if _, ok := fields[field]; !ok {
+		return nil, fmt.Errorf("field %q not found", field)
+	}
+	name, err := json.Marshal(field)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := bytes.TrimRight(data, " \t\r\n")
+	end := len(trimmed) - 1
+	tampered := append([]byte(nil), trimmed[:end]...)
+	tampered = append(tampered, ',')
+	tampered = append(tampered, name...)
+	tampered = append(tampered, ':')
+	tampered = append(tampered, value...)
+	return append(tampered, '}'), nil
+}
+
+// SetField returns the JSON object data with its top-level field replaced
+// by value. Field order is not preserved.
+func SetField(data []byte, field string, value json.RawMessage) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("not a JSON object: %w", err)
+	}
+	if _, ok := fields[field]; !ok {
+		return nil, fmt.Errorf("field %q not found", field)
+	}
+	fields[field] = value
+	return json.Marshal(fields)
+}
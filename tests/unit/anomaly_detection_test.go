@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStatisticalAnomalyDetectorFlagsProbing(t *testing.T) {
+	detector := NewStatisticalAnomalyDetector(AnomalyOptions{Window: time.Minute, FailureBurst: 5, Replays: 2, SizeSamples: 10})
+	start := time.Now()
+	observe := func(offset time.Duration, source string, decision VerificationDecision, size int) []Anomaly {
+		return detector.Observe(VerificationEvent{Time: start.Add(offset), Source: source, Decision: decision, ProofSize: size})
+	}
+
+	for i := 0; i < 10; i++ {
+		if got := observe(time.Duration(i)*time.Second, "client", DecisionAccepted, 4000+i*10); len(got) != 0 {
+			t.Fatalf("Normal traffic should not be flagged: %+v", got)
+		}
+	}
+
+	var bursts []Anomaly
+	for i := 0; i < 8; i++ {
+		bursts = append(bursts, observe(10*time.Second+time.Duration(i)*time.Second, "prober", DecisionRejected, 4050)...)
+	}
+	if len(bursts) != 1 || bursts[0].Kind != AnomalyFailureBurst || bursts[0].Source != "prober" {
+		t.Errorf("Expected one failure burst from the prober, got %+v", bursts)
+	}
+	if got := observe(20*time.Second, "client", DecisionRejected, 4050); len(got) != 0 {
+		t.Errorf("A single rejection from another source should not be flagged: %+v", got)
+	}
+	// Failures spread beyond the window do not form a burst
+	if got := observe(2*time.Minute, "prober", DecisionRejected, 4050); len(got) != 0 {
+		t.Errorf("Failures outside the window should have expired: %+v", got)
+	}
+
+	observe(3*time.Minute, "replayer", DecisionReplayed, 4050)
+	if got := observe(3*time.Minute+time.Second, "replayer", DecisionReplayed, 4050); len(got) != 1 || got[0].Kind != AnomalyReplays {
+		t.Errorf("Expected repeated replays to be flagged, got %+v", got)
+	}
+
+	if got := observe(4*time.Minute, "client", DecisionRejected, 400000); len(got) != 1 || got[0].Kind != AnomalyProofSize {
+		t.Errorf("Expected an abnormal proof size to be flagged, got %+v", got)
+	}
+	if got := observe(4*time.Minute+time.Second, "client", DecisionAccepted, 4040); len(got) != 0 {
+		t.Errorf("A typical size should not be flagged: %+v", got)
+	}
+}
+
+func TestDaemonReportsVerificationAnomalies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "qzkpd.json")
+	writeDaemonConfig(t, path, DaemonConfig{
+		ServiceConfig: ServiceConfig{
+			Dimensions:    3,
+			SecurityLevel: 128,
+			Application:   "anomaly-test",
+			Key:           "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		},
+		Workers:   2,
+		QueueSize: 8,
+	})
+	d, err := NewDaemon(path)
+	if err != nil {
+		t.Fatalf("NewDaemon failed: %v", err)
+	}
+	defer d.Shutdown(t.Context())
+	d.Anomalies = NewStatisticalAnomalyDetector(AnomalyOptions{FailureBurst: 3})
+	var mu sync.Mutex
+	var flagged []Anomaly
+	d.OnAnomaly = func(a Anomaly) {
+		mu.Lock()
+		defer mu.Unlock()
+		flagged = append(flagged, a)
+	}
+	server := httptest.NewServer(d.Handler())
+	defer server.Close()
+
+	forged, _ := json.Marshal(&VerifyRequest{Proof: &SecureProof{Identifier: "probe", Signature: "00"}})
+	for i := 0; i < 4; i++ {
+		resp, err := http.Post(server.URL+"/v1/verify", "application/json", bytes.NewReader(forged))
+		if err != nil {
+			t.Fatalf("POST /v1/verify failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(flagged) != 1 || flagged[0].Kind != AnomalyFailureBurst || flagged[0].Source != "127.0.0.1" {
+		t.Errorf("Expected one failure burst from 127.0.0.1, got %+v", flagged)
+	}
+}
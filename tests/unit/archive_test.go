@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestArchiveReSigning(t *testing.T) {
+	sq, proof := newTestProof(t, 64)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func(years int) func() time.Time {
+		return func() time.Time { return start.AddDate(years, 0, 0) }
+	}
+	first, _ := NewSignatureScheme([]byte("archive-2026"))
+	second, _ := NewSignatureScheme([]byte("archive-2036"))
+
+	archive, err := ReSignProof(proof, first, ArchivePolicy{HashSuite: ArchiveHashSHA256, Clock: clock(0)})
+	if err != nil {
+		t.Fatalf("ReSignProof failed: %v", err)
+	}
+	if err := VerifyArchivedProof(archive, first); err != nil {
+		t.Fatalf("Fresh archive should verify: %v", err)
+	}
+
+	upgraded, err := archive.ReSign(second, ArchivePolicy{Clock: clock(10)})
+	if err != nil {
+		t.Fatalf("ReSign failed: %v", err)
+	}
+	if len(archive.Chain) != 1 || len(upgraded.Chain) != 2 || upgraded.Chain[1].HashSuite != DefaultArchiveHashSuite {
+		t.Fatalf("ReSign should extend a copy of the chain with the default suite: %+v", upgraded.Chain)
+	}
+	// The archive survives a JSON round trip and still carries the
+	// original, verifiable proof
+	data, _ := json.Marshal(upgraded)
+	var stored ArchivedProof
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("Failed to decode archive: %v", err)
+	}
+	if err := VerifyArchivedProof(&stored, second); err != nil {
+		t.Errorf("Re-signed archive should verify: %v", err)
+	}
+	if !sq.VerifySecureProof(stored.Proof, testutil.Key()) {
+		t.Error("The archived proof should still verify with its original key")
+	}
+	if err := VerifyArchivedProof(&stored, first); err == nil {
+		t.Error("Only the newest link's key should be trusted")
+	}
+
+	tampered := stored
+	altered := *stored.Proof
+	altered.Profile = "altered"
+	tampered.Proof = &altered
+	if err := VerifyArchivedProof(&tampered, second); err == nil {
+		t.Error("An altered proof should break the chain")
+	}
+	if _, err := tampered.ReSign(first, ArchivePolicy{Clock: clock(20)}); err == nil {
+		t.Error("A broken archive should not be re-signed")
+	}
+	dropped := ArchivedProof{Proof: stored.Proof, Chain: stored.Chain[1:]}
+	if err := VerifyArchivedProof(&dropped, second); err == nil {
+		t.Error("Dropping a link should break the chain")
+	}
+	forged := ArchivedProof{Proof: stored.Proof, Chain: append([]CustodyLink(nil), stored.Chain...)}
+	forged.Chain[0].SignedAt = forged.Chain[0].SignedAt.Add(time.Hour)
+	if err := VerifyArchivedProof(&forged, second); err == nil {
+		t.Error("Altering an older link should break the chain")
+	}
+
+	if _, err := upgraded.ReSign(first, ArchivePolicy{Clock: clock(5)}); err == nil {
+		t.Error("A link dated before the previous one should be refused")
+	}
+	if _, err := upgraded.ReSign(first, ArchivePolicy{HashSuite: "md5", Clock: clock(20)}); err == nil {
+		t.Error("An unsupported hash suite should be refused")
+	}
+	if _, err := ReSignProof(proof, first, ArchivePolicy{Rules: NewPolicy(MinSoundness(256))}); err == nil {
+		t.Error("A proof below the archive policy should be refused")
+	}
+	verifyOnly, _ := NewVerificationScheme(first.PublicKeyBytes(), nil)
+	if _, err := ReSignProof(proof, verifyOnly, ArchivePolicy{}); err == nil {
+		t.Error("Re-signing requires a private key")
+	}
+}
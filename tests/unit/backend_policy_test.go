@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// hardwareBoundState returns a cached state from backend whose provenance
+// recorder signed with device.
+func hardwareBoundState(t *testing.T, recorder *ProvenanceRecorder, backend string, device *BackendDevice) *CachedQuantumState {
+	t.Helper()
+	result := &ExecutionResult{Counts: map[string]int{"00": 510, "11": 490}, Shots: 1000, Backend: backend}
+	exchange := &BackendExchange{JobID: "job-9", Device: device, Response: &http.Response{StatusCode: http.StatusOK}, Body: []byte("{}")}
+	bell := &QuantumCircuit{NumQubits: 2, NumClbits: 2, Gates: []QuantumGate{{Type: "h", Qubits: []int{0}}, {Type: "cx", Qubits: []int{0, 1}}}}
+	if err := recorder.Record(result, bell, exchange); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	return &CachedQuantumState{Backend: backend, JobID: "job-9", ProvenanceRecord: result.ProvenanceRecord}
+}
+
+func TestVerifyHardwareBindingBackendPolicy(t *testing.T) {
+	signer, _ := NewSignatureScheme(nil)
+	recorder, _ := NewProvenanceRecorder(signer)
+	ran := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	recorder.Clock = func() time.Time { return ran }
+	brisbane := &BackendDevice{Vendor: "ibm", Qubits: 127, CalibratedAt: ran.Add(-6 * time.Hour)}
+
+	policy := &BackendPolicy{
+		Allow:             []string{"ibm_brisbane", "ibm_kyiv"},
+		Deny:              []string{"ibm_kyiv"},
+		Vendors:           []string{"ibm"},
+		MinQubits:         100,
+		MaxCalibrationAge: 24 * time.Hour,
+	}
+	state := hardwareBoundState(t, recorder, "ibm_brisbane", brisbane)
+	if err := VerifyHardwareBinding(state, signer, nil, policy); err != nil {
+		t.Fatalf("Trusted backend rejected: %v", err)
+	}
+
+	for _, tc := range []struct {
+		backend string
+		device  *BackendDevice
+		want    BackendRejection
+	}{
+		{"ibm_sherbrooke", brisbane, BackendNotAllowed},
+		{"ibm_kyiv", brisbane, BackendDenied},
+		{"ibm_brisbane", nil, BackendDeviceUnknown},
+		{"ibm_brisbane", &BackendDevice{Vendor: "acme", Qubits: 127, CalibratedAt: brisbane.CalibratedAt}, BackendVendorNotAllowed},
+		{"ibm_brisbane", &BackendDevice{Vendor: "IBM", Qubits: 127, CalibratedAt: brisbane.CalibratedAt}, BackendVendorNotAllowed},
+		{"ibm_brisbane", &BackendDevice{Vendor: "ibm", Qubits: 5, CalibratedAt: brisbane.CalibratedAt}, BackendTooFewQubits},
+		{"ibm_brisbane", &BackendDevice{Vendor: "ibm", Qubits: 127, CalibratedAt: ran.Add(-72 * time.Hour)}, BackendCalibrationStale},
+		{"ibm_brisbane", &BackendDevice{Vendor: "ibm", Qubits: 127, CalibratedAt: ran.Add(time.Hour)}, BackendCalibrationStale},
+		{"ibm_brisbane", &BackendDevice{Vendor: "ibm", Qubits: 127}, BackendCalibrationStale},
+		// Spoofed look-alikes of an allowed name
+		{"IBM_Brisbane", brisbane, BackendNameMalformed},
+		{"ibm_brisbane ", brisbane, BackendNameMalformed},
+		{"ibm_brisbane\u200b", brisbane, BackendNameMalformed},
+		{"\u0456bm_brisbane", brisbane, BackendNameMalformed}, // Cyrillic і
+	} {
+		err := VerifyHardwareBinding(hardwareBoundState(t, recorder, tc.backend, tc.device), signer, nil, policy)
+		var rejection *BackendRejectionError
+		if !errors.As(err, &rejection) || rejection.Rejection != tc.want || !errors.Is(err, ErrUntrustedBackend) {
+			t.Errorf("%q with %+v: expected %s, got %v", tc.backend, tc.device, tc.want, err)
+		}
+	}
+
+	// A state claiming an allowed backend its signed record does not name
+	spoofed := hardwareBoundState(t, recorder, "ibm_sherbrooke", brisbane)
+	spoofed.Backend = "ibm_brisbane"
+	if err := VerifyHardwareBinding(spoofed, signer, nil, policy); err == nil || errors.Is(err, ErrUntrustedBackend) {
+		t.Errorf("State relabelled to an allowed backend should fail its provenance, got %v", err)
+	}
+	// Nor can the signed record itself be relabelled
+	relabelled := hardwareBoundState(t, recorder, "ibm_sherbrooke", brisbane)
+	relabelled.Backend = "ibm_brisbane"
+	relabelled.ProvenanceRecord.Backend = "ibm_brisbane"
+	if err := VerifyHardwareBinding(relabelled, signer, nil, policy); err == nil {
+		t.Error("Relabelled provenance record should not verify")
+	}
+
+	// Without a policy only the provenance is checked
+	if err := VerifyHardwareBinding(hardwareBoundState(t, recorder, "anything", nil), signer, nil, nil); err != nil {
+		t.Errorf("No policy should trust any recorded backend: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newTestBackend returns a backend that never sleeps and counts remote calls.
+func newTestBackend(t *testing.T, remote func(call int) (*ExecutionResult, error)) (*ResilientBackend, *int) {
+	t.Helper()
+	q, err := NewQuantumZKP(4, 128, []byte("resilience-test"))
+	if err != nil {
+		t.Fatalf("NewQuantumZKP failed: %v", err)
+	}
+	calls := 0
+	b := NewResilientBackend("test_backend", func(*QuantumCircuit, int) (*ExecutionResult, error) {
+		calls++
+		return remote(calls)
+	}, q)
+	b.Sleep = func(time.Duration) {}
+	return b, &calls
+}
+
+func TestBackendErrorClassification(t *testing.T) {
+	for status, transient := range map[int]bool{
+		http.StatusTooManyRequests:     true,
+		http.StatusServiceUnavailable:  true,
+		http.StatusRequestTimeout:      true,
+		http.StatusUnauthorized:        false,
+		http.StatusBadRequest:          false,
+		http.StatusInternalServerError: true,
+	} {
+		if got := IsTransient(HTTPStatusError("op", status, "body")); got != transient {
+			t.Errorf("Status %d: transient %v, expected %v", status, got, transient)
+		}
+	}
+	if !IsTransient(errors.New("connection reset")) {
+		t.Error("Unclassified errors should be retried")
+	}
+	if IsTransient(ErrCircuitOpen) || IsTransient(nil) {
+		t.Error("Open circuits and successes are not transient")
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Jitter: 0.5}
+	rng := rand.New(rand.NewSource(1))
+	for retry, ceiling := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		ceiling *= time.Millisecond
+		d := p.Delay(retry, rng)
+		if d > ceiling || d < ceiling/2 {
+			t.Errorf("Retry %d delay %v outside [%v, %v]", retry, d, ceiling/2, ceiling)
+		}
+	}
+}
+
+func TestResilientBackendRetriesTransientFailures(t *testing.T) {
+	b, calls := newTestBackend(t, func(call int) (*ExecutionResult, error) {
+		if call < 3 {
+			return nil, HTTPStatusError("execute", http.StatusServiceUnavailable, "maintenance")
+		}
+		return &ExecutionResult{Counts: map[string]int{"0": 10}, Shots: 10}, nil
+	})
+
+	result, err := b.Execute(&QuantumCircuit{NumQubits: 1, NumClbits: 1}, 10)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if *calls != 3 || result.Provenance != ProvenanceRemote || result.Backend != "test_backend" {
+		t.Errorf("Expected a remote result after 3 calls, got %d calls and %+v", *calls, result)
+	}
+}
+
+func TestResilientBackendFallsBackWhenUnavailable(t *testing.T) {
+	b, calls := newTestBackend(t, func(int) (*ExecutionResult, error) {
+		return nil, TransientError("execute", errors.New("timeout"))
+	})
+	b.Breaker = NewCircuitBreaker(3, time.Hour)
+
+	circuit := &QuantumCircuit{NumQubits: 1, NumClbits: 1}
+	result, err := b.Execute(circuit, 10)
+	if err != nil {
+		t.Fatalf("Execute should fall back, got %v", err)
+	}
+	if result.Provenance != ProvenanceFallback || result.FallbackReason == "" {
+		t.Errorf("Fallback result should be marked, got %+v", result)
+	}
+	if !b.Breaker.Open() {
+		t.Error("Breaker should open after repeated transient failures")
+	}
+
+	// While open, the remote backend is not contacted at all
+	before := *calls
+	if result, err := b.Execute(circuit, 10); err != nil || result.Provenance != ProvenanceFallback {
+		t.Errorf("Open circuit should fall back, got %v", err)
+	}
+	if *calls != before {
+		t.Errorf("Open circuit made %d remote calls", *calls-before)
+	}
+}
+
+func TestResilientBackendPermanentErrors(t *testing.T) {
+	b, calls := newTestBackend(t, func(int) (*ExecutionResult, error) {
+		return nil, HTTPStatusError("execute", http.StatusUnauthorized, "invalid token")
+	})
+
+	if _, err := b.Execute(&QuantumCircuit{NumQubits: 1, NumClbits: 1}, 10); err == nil {
+		t.Fatal("Permanent errors should not fall back")
+	}
+	if *calls != 1 {
+		t.Errorf("Permanent errors should not be retried, got %d calls", *calls)
+	}
+	if b.Breaker.Open() {
+		t.Error("Permanent errors should not open the breaker")
+	}
+}
+
+func TestCircuitBreakerHalfOpenTrial(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := NewCircuitBreaker(2, time.Minute)
+	cb.Now = func() time.Time { return now }
+
+	transient := TransientError("op", errors.New("down"))
+	cb.Record(transient)
+	cb.Record(transient)
+	if err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Breaker should be open, got %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if err := cb.Allow(); err != nil {
+		t.Fatalf("Trial call should be admitted after the cooldown: %v", err)
+	}
+	if err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Error("Only one trial call should be admitted")
+	}
+	cb.Record(nil)
+	if cb.Open() || cb.Allow() != nil {
+		t.Error("A successful trial should close the breaker")
+	}
+}
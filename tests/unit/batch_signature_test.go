@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestSecureProveBatchSharedSignature(t *testing.T) {
+	sq := newTestProver(t, 64)
+	sq.BatchSignatures = true
+
+	states := make([]*StateVector, 5)
+	identifiers := make([]string, len(states))
+	for i := range states {
+		states[i], _ = NewStateVector(testutil.RampVector(4))
+		identifiers[i] = fmt.Sprintf("batch_signed_%d", i)
+	}
+	proofs, err := sq.SecureProveBatch(states, identifiers, testutil.Key())
+	if err != nil {
+		t.Fatalf("SecureProveBatch failed: %v", err)
+	}
+	for i, proof := range proofs {
+		if proof.Batch == nil || proof.Batch.LeafIndex != i || proof.Batch.Size != len(proofs) {
+			t.Fatalf("Proof %d has batch inclusion %+v", i, proof.Batch)
+		}
+		if proof.Signature != proofs[0].Signature {
+			t.Errorf("Proof %d should share the batch signature", i)
+		}
+		if !sq.VerifySecureProof(proof, testutil.Key()) {
+			t.Errorf("Batch-signed proof %d should verify", i)
+		}
+		if r := sq.VerifySecureProofVersioned(proof, testutil.Key()); !r.Valid {
+			t.Errorf("Batch-signed proof %d should pass versioned verification: %v", i, r.Reasons)
+		}
+	}
+
+	// Batch-signed proofs survive serialization
+	data, err := json.Marshal(proofs[3])
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !verifiesJSON(sq, data) {
+		t.Error("Decoded batch-signed proof should verify")
+	}
+}
+
+func TestBatchSignatureTampering(t *testing.T) {
+	sq, first := newTestProof(t, 64)
+	second, err := sq.SecureProveVectorKnowledge(testutil.RampVector(8), "second", testutil.Key())
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if err := sq.SignProofBatch([]*SecureProof{first, second}); err != nil {
+		t.Fatalf("SignProofBatch failed: %v", err)
+	}
+
+	tampered := []struct {
+		name   string
+		modify func(p *SecureProof)
+	}{
+		{"identifier", func(p *SecureProof) { p.Identifier = "other" }},
+		{"leaf index", func(p *SecureProof) { p.Batch.LeafIndex = 1 }},
+		{"size", func(p *SecureProof) { p.Batch.Size = 3 }},
+		{"root", func(p *SecureProof) { p.Batch.Root = second.Batch.Path[0] }},
+		{"path", func(p *SecureProof) { p.Batch.Path = nil }},
+		{"no batch", func(p *SecureProof) { p.Batch = nil }},
+	}
+	for _, tc := range tampered {
+		proof := *first
+		batch := *first.Batch
+		proof.Batch = &batch
+		tc.modify(&proof)
+		if sq.VerifySecureProof(&proof, testutil.Key()) {
+			t.Errorf("Proof with modified %s should not verify", tc.name)
+		}
+	}
+
+	other := newTestProver(t, 64)
+	if other.VerifySecureProof(first, testutil.Key()) {
+		t.Error("A batch signed by another key should not verify")
+	}
+}
+
+func TestBatchSignedProofCosignature(t *testing.T) {
+	sq, proof := newTestProof(t, 64)
+	if err := sq.SignProofBatch([]*SecureProof{proof}); err != nil {
+		t.Fatalf("SignProofBatch failed: %v", err)
+	}
+	cosigner, _ := NewSignatureScheme(nil)
+	if err := sq.CosignProof(proof, cosigner); err != nil {
+		t.Fatalf("CosignProof failed: %v", err)
+	}
+
+	var keys KeySet
+	keys.Add(sq.Signer.PublicKeyBytes(), time.Time{}, time.Time{})
+	keys.Add(cosigner.PublicKeyBytes(), time.Time{}, time.Time{})
+	if r := sq.VerifyWithKeySet(proof, testutil.Key(), &keys, KeySetAllOf); !r.Valid {
+		t.Errorf("Batch signature and cosignature should both verify: %v", r.Reasons)
+	}
+}
+
+func TestBatchSignerWindow(t *testing.T) {
+	sq := newTestProver(t, 64)
+	var proofs []*SecureProof
+	for i := 0; i < 4; i++ {
+		proof, err := sq.SecureProveVectorKnowledge(testutil.RampVector(8), fmt.Sprintf("window_%d", i), testutil.Key())
+		if err != nil {
+			t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+		}
+		proofs = append(proofs, proof)
+	}
+	if _, err := NewBatchSigner(sq, 0, 0); err == nil {
+		t.Error("A zero window should be refused")
+	}
+
+	// A full batch is signed at once, without waiting for the window
+	signer, err := NewBatchSigner(sq, time.Hour, len(proofs))
+	if err != nil {
+		t.Fatalf("NewBatchSigner failed: %v", err)
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, len(proofs))
+	for i, proof := range proofs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = signer.Sign(proof)
+		}()
+	}
+	wg.Wait()
+	for i, proof := range proofs {
+		if errs[i] != nil {
+			t.Fatalf("Sign %d failed: %v", i, errs[i])
+		}
+		if proof.Batch == nil || proof.Batch.Size != len(proofs) || !sq.VerifySecureProof(proof, testutil.Key()) {
+			t.Errorf("Proof %d should be signed in the shared batch: %+v", i, proof.Batch)
+		}
+	}
+
+	// A partial batch is signed when its window closes
+	signer, _ = NewBatchSigner(sq, 10*time.Millisecond, 0)
+	if err := signer.Sign(proofs[0]); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if proofs[0].Batch.Size != 1 || !sq.VerifySecureProof(proofs[0], testutil.Key()) {
+		t.Errorf("Single-proof batch should verify: %+v", proofs[0].Batch)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+// batchOf returns n valid proofs by sq, with the proofs at the bad indexes
+// tampered.
+func batchOf(t *testing.T, sq *SecureQuantumZKP, n int, bad ...int) []*SecureProof {
+	t.Helper()
+	proofs := make([]*SecureProof, n)
+	for i := range proofs {
+		proof, err := sq.SecureProveVectorKnowledge(testutil.RampVector(8), "batch_verify", testutil.Key())
+		if err != nil {
+			t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+		}
+		proofs[i] = proof
+	}
+	for _, i := range bad {
+		proofs[i].Identifier = "tampered"
+	}
+	return proofs
+}
+
+func TestVerifyBatchCollectsAllInOrder(t *testing.T) {
+	sq := newTestProver(t, 64)
+	verifier, err := NewVerifier(sq, VerifierConfig{})
+	if err != nil {
+		t.Fatalf("NewVerifier failed: %v", err)
+	}
+	proofs := batchOf(t, sq, 8, 2, 5)
+
+	var stats BatchVerifyStats
+	results, err := verifier.VerifyBatch(t.Context(), proofs, BatchVerifyOptions{Key: testutil.Key(), Workers: 3, Stats: &stats})
+	if err != nil {
+		t.Fatalf("Collect-all VerifyBatch failed: %v", err)
+	}
+	if len(results) != len(proofs) {
+		t.Fatalf("Expected %d results, got %d", len(proofs), len(results))
+	}
+	for i, result := range results {
+		if want := i != 2 && i != 5; result.Valid != want {
+			t.Errorf("Proof %d: valid %v, expected %v", i, result.Valid, want)
+		}
+	}
+	if stats.Proofs != 8 || stats.Valid != 6 || stats.Invalid != 2 || stats.Skipped != 0 {
+		t.Errorf("Stats %+v", stats)
+	}
+	if stats.Min <= 0 || stats.Min > stats.P50 || stats.P50 > stats.P95 || stats.P95 > stats.Max || stats.Total < stats.Max {
+		t.Errorf("Inconsistent latency statistics %+v", stats)
+	}
+}
+
+func TestVerifyBatchFailFast(t *testing.T) {
+	sq := newTestProver(t, 64)
+	verifier, _ := NewVerifier(sq, VerifierConfig{})
+	proofs := batchOf(t, sq, 16, 0)
+
+	var stats BatchVerifyStats
+	results, err := verifier.VerifyBatch(t.Context(), proofs, BatchVerifyOptions{Key: testutil.Key(), Workers: 1, FailFast: true, Stats: &stats})
+	if !errors.Is(err, ErrBatchRejected) {
+		t.Fatalf("Expected ErrBatchRejected, got %v", err)
+	}
+	if results[0].Valid || stats.Invalid != 1 {
+		t.Errorf("Proof 0 should be rejected: %+v, %+v", results[0], stats)
+	}
+	// With one worker, at most the proof already handed over runs after
+	// the rejection
+	if stats.Skipped < len(proofs)-2 {
+		t.Errorf("Fail-fast batch verified %d proofs after the rejection", len(proofs)-1-stats.Skipped)
+	}
+	for _, result := range results[2:] {
+		if result.Valid {
+			t.Error("A skipped proof should not be reported valid")
+		}
+	}
+}
+
+func TestVerifyBatchHonoursCancellation(t *testing.T) {
+	sq := newTestProver(t, 64)
+	verifier, _ := NewVerifier(sq, VerifierConfig{})
+	proofs := batchOf(t, sq, 4)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	results, err := verifier.VerifyBatch(ctx, proofs, BatchVerifyOptions{Key: testutil.Key()})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	for i, result := range results {
+		if result.Valid || len(result.Reasons) == 0 {
+			t.Errorf("Proof %d of a cancelled batch: %+v", i, result)
+		}
+	}
+
+	if results, err := verifier.VerifyBatch(t.Context(), nil, BatchVerifyOptions{}); err != nil || len(results) != 0 {
+		t.Errorf("Empty batch returned %v, %v", results, err)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeBoundedJSONEnforcesLimits(t *testing.T) {
+	limits := JSONLimits{
+		MaxBytes:        256,
+		MaxDepth:        3,
+		MaxStringBytes:  8,
+		MaxArrayLength:  4,
+		MaxObjectFields: 3,
+		FieldBytes:      map[string]int{"blob": 32},
+	}
+	var ok struct {
+		Name string        `json:"name"`
+		Blob string        `json:"blob"`
+		List []interface{} `json:"list"`
+	}
+	if err := DecodeBoundedJSON(strings.NewReader(`{"name":"short","blob":"a longer value than eight","list":[1,[2],{"a":3}]}`), limits, &ok); err != nil {
+		t.Fatalf("Input within limits should decode: %v", err)
+	}
+	if ok.Blob != "a longer value than eight" || len(ok.List) != 3 {
+		t.Errorf("Decoded %+v", ok)
+	}
+
+	for name, input := range map[string]string{
+		"depth":       `{"list":[[[1]]]}`,
+		"string":      `{"name":"far too long"}`,
+		"field bytes": `{"blob":"` + strings.Repeat("x", 33) + `"}`,
+		"array":       `[1,2,3,4,5]`,
+		"fields":      `{"a":1,"b":2,"c":3,"d":4}`,
+		"key":         `{"a very long key":1}`,
+		"number":      `[123456789012]`,
+		"budget":      `[` + strings.Repeat(`"x",`, 100) + `"x"]`,
+	} {
+		var v interface{}
+		if err := DecodeBoundedJSON(strings.NewReader(input), limits, &v); !errors.Is(err, ErrJSONLimit) {
+			t.Errorf("%s: expected ErrJSONLimit, got %v", name, err)
+		}
+	}
+
+	var v interface{}
+	if err := DecodeBoundedJSON(strings.NewReader(`{"a":1} {"b":2}`), limits, &v); err == nil || errors.Is(err, ErrJSONLimit) {
+		t.Errorf("Trailing data should be refused, got %v", err)
+	}
+	if err := DecodeBoundedJSON(strings.NewReader(`{"a":`), limits, &v); err == nil {
+		t.Error("Truncated input should be refused")
+	}
+	// Whitespace up to the budget is fine
+	if err := DecodeBoundedJSON(strings.NewReader(`{"a":1}`+strings.Repeat(" ", 249)), limits, &v); err != nil {
+		t.Errorf("Input of exactly MaxBytes should decode: %v", err)
+	}
+}
+
+func TestParseSecureProofBoundsUntrustedInput(t *testing.T) {
+	_, proof := newTestProof(t, 128)
+	data, err := json.Marshal(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseSecureProof(data); err != nil {
+		t.Fatalf("A genuine proof should parse within ProofJSONLimits: %v", err)
+	}
+
+	nested := strings.Repeat("[", 10000) + strings.Repeat("]", 10000)
+	oversized := `{"version":2,"signature":"` + strings.Repeat("ab", ProofJSONLimits.MaxStringBytes) + `"}`
+	for name, input := range map[string]string{"nested": nested, "oversized": oversized} {
+		if _, err := ParseSecureProof([]byte(input)); !errors.Is(err, ErrJSONLimit) {
+			t.Errorf("%s: expected ErrJSONLimit, got %v", name, err)
+		}
+	}
+
+	// A gzip bomb is refused before it inflates in full
+	bomb, err := EncodeProof("base64+gzip+json", append([]byte(`{"padding":"`), bytes.Repeat([]byte("a"), int(ProofJSONLimits.MaxBytes))...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload, _, _ := detectProofCodec(bomb); payload != nil {
+		t.Errorf("An inflated payload of %d bytes should be refused", len(payload))
+	}
+}
+
+func TestDaemonRefusesOversizedVerifyRequests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "qzkpd.json")
+	writeDaemonConfig(t, path, DaemonConfig{ServiceConfig: ServiceConfig{
+		Dimensions:    3,
+		SecurityLevel: 128,
+		Application:   "bounded-json-test",
+		Key:           "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+	}})
+	d, err := NewDaemon(path)
+	if err != nil {
+		t.Fatalf("NewDaemon failed: %v", err)
+	}
+	server := httptest.NewServer(d.Handler())
+	defer server.Close()
+
+	body := `{"proof":` + strings.Repeat(`{"a":`, 100) + `1` + strings.Repeat(`}`, 100) + `}`
+	resp, err := http.Post(server.URL+"/v1/verify", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("Deeply nested request returned %s", resp.Status)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestAutoDimensionRules(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("encoding-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	random := make([]byte, 1<<20)
+	rand.Read(random)
+
+	cases := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"one byte", []byte{42}, 8},
+		{"repetitive megabyte", []byte(strings.Repeat("a", 1<<20)), 8},
+		{"random 256 bytes", random[:256], 64},
+		{"random megabyte", random, MaxAutoDimension},
+	}
+	for _, c := range cases {
+		if got := sq.AutoDimension(c.data); got != c.want {
+			t.Errorf("%s: dimension %d, expected %d", c.name, got, c.want)
+		}
+	}
+
+	ultra, _ := NewUltraSecureQuantumZKP(3, 256, []byte("encoding-test"))
+	if got := ultra.AutoDimension([]byte{42}); got != 16 {
+		t.Errorf("Security level 256 should use at least 16 amplitudes, got %d", got)
+	}
+}
+
+func TestSecureProveFromBytesRecordsEncoding(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("encoding-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	key := testutil.Key()
+	data := []byte("a moderately sized secret document")
+
+	proof, err := sq.SecureProveFromBytes(data, "encoding_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveFromBytes failed: %v", err)
+	}
+	enc := proof.DataEncoding
+	if enc == nil || enc.Encoder != BytesEncoderSHA256Expand || enc.Version != BytesEncoderVersion ||
+		enc.Selection != DimensionSelectionAuto || enc.Dimension != proof.StateMetadata.Dimension {
+		t.Fatalf("Unexpected encoding record: %+v", enc)
+	}
+	if !sq.VerifySecureProof(proof, key) {
+		t.Error("Proof should verify")
+	}
+
+	explicit, err := sq.SecureProveFromBytesWithOptions(data, "encoding_test", key, BytesProofOptions{Dimension: 32})
+	if err != nil {
+		t.Fatalf("SecureProveFromBytesWithOptions failed: %v", err)
+	}
+	if explicit.StateMetadata.Dimension != 32 || explicit.DataEncoding.Selection != DimensionSelectionExplicit {
+		t.Errorf("Explicit dimension not honored: %+v", explicit.DataEncoding)
+	}
+	if !sq.VerifySecureProof(explicit, key) {
+		t.Error("Explicit-dimension proof should verify")
+	}
+
+	// The encoding record is signed and must match the state
+	explicit.DataEncoding.Dimension = 16
+	if sq.VerifySecureProof(explicit, key) {
+		t.Error("Altered encoding record should not verify")
+	}
+
+	for _, bad := range []int{1, 12, 4096} {
+		if _, err := sq.SecureProveFromBytesWithOptions(data, "encoding_test", key, BytesProofOptions{Dimension: bad}); err == nil {
+			t.Errorf("Dimension %d should be rejected", bad)
+		}
+	}
+}
+
+func TestSecureProveFromBytesInputLimits(t *testing.T) {
+	sq := newTestProver(t, 64)
+	key := testutil.Key()
+	random := make([]byte, 4096)
+	rand.Read(random)
+
+	_, err := sq.SecureProveFromBytesWithOptions(random, "limits", key, BytesProofOptions{MaxInputSize: 1024})
+	if !errors.Is(err, ErrInputTooLarge) || !strings.Contains(err.Error(), "4096 bytes, limit 1024") {
+		t.Errorf("Expected ErrInputTooLarge naming the sizes, got %v", err)
+	}
+	_, err = sq.SecureProveFromBytesWithOptions(random, "limits", key, BytesProofOptions{MaxChunks: 100})
+	if !errors.Is(err, ErrTooManyChunks) {
+		t.Errorf("Expected ErrTooManyChunks, got %v", err)
+	}
+
+	// Repetitive input carries little information, so few chunks
+	repetitive := []byte(strings.Repeat("a", 4096))
+	if _, err := sq.SecureProveFromBytesWithOptions(repetitive, "limits", key, BytesProofOptions{MaxChunks: 100}); err != nil {
+		t.Errorf("Low-information input should be within the chunk limit: %v", err)
+	}
+}
+
+func TestSecureProveFromBytesCoverage(t *testing.T) {
+	sq := newTestProver(t, 64)
+	key := testutil.Key()
+	random := make([]byte, 4096)
+	rand.Read(random)
+
+	proof, err := sq.SecureProveFromBytesWithOptions(random, "coverage", key, BytesProofOptions{Dimension: 64})
+	if err != nil {
+		t.Fatalf("SecureProveFromBytesWithOptions failed: %v", err)
+	}
+	enc := proof.DataEncoding
+	if enc.InputSize != 4096 || enc.Chunks != InputChunks(random) || enc.CoveredChunks != 64 {
+		t.Fatalf("Unexpected chunk accounting: %+v", enc)
+	}
+	if got := enc.Coverage(); got <= 0 || got >= 0.1 {
+		t.Errorf("64 amplitudes should cover a small part of 4 KiB of random data, got %.3f", got)
+	}
+	if !sq.VerifySecureProof(proof, key) {
+		t.Fatal("Proof should verify")
+	}
+
+	small, err := sq.SecureProveFromBytes([]byte("short secret"), "coverage", key)
+	if err != nil {
+		t.Fatalf("SecureProveFromBytes failed: %v", err)
+	}
+	policy := NewPolicy(MinInputCoverage(0.5))
+	if err := policy.Evaluate(small).Err(); err != nil {
+		t.Errorf("A fully covered input should pass: %v", err)
+	}
+	if err := policy.Evaluate(proof).Err(); err == nil {
+		t.Error("A sparsely covered input should fail the coverage rule")
+	}
+
+	// Accounting is signed and must be consistent with the dimension
+	enc.CoveredChunks = enc.Chunks
+	if sq.VerifySecureProof(proof, key) {
+		t.Error("Inflated coverage should not verify")
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+type canonicalRecord struct {
+	Name     string            `json:"name"`
+	Balance  int               `json:"balance"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Password string            `json:"password" qzkp:"redact"`
+	internal string
+}
+
+func TestCanonicalizeValueDeterministic(t *testing.T) {
+	a := canonicalRecord{
+		Name:     "alice",
+		Balance:  42,
+		Tags:     map[string]string{"z": "last", "a": "first"},
+		Password: "hunter2",
+		internal: "ignored",
+	}
+	b := a
+	b.Password = "different"
+	b.internal = "also ignored"
+
+	encA, err := CanonicalizeValue(a)
+	if err != nil {
+		t.Fatalf("CanonicalizeValue failed: %v", err)
+	}
+	encB, err := CanonicalizeValue(&b)
+	if err != nil {
+		t.Fatalf("CanonicalizeValue failed: %v", err)
+	}
+
+	want := `{"balance":42,"name":"alice","tags":{"a":"first","z":"last"}}`
+	if string(encA) != want {
+		t.Errorf("CanonicalizeValue = %s, want %s", encA, want)
+	}
+	if string(encA) != string(encB) {
+		t.Errorf("Values differing only in redacted fields should canonicalize identically: %s vs %s", encA, encB)
+	}
+
+	if _, err := CanonicalizeValue(complex(1, 2)); err == nil {
+		t.Error("Expected an error for complex values")
+	}
+}
+
+func TestSecureProveFromValue(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("value-context"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+
+	key := testutil.Key()
+	record := canonicalRecord{Name: "bob", Balance: 7, Password: "secret"}
+
+	proof, err := sq.SecureProveFromValue(record, "record_bob", key)
+	if err != nil {
+		t.Fatalf("SecureProveFromValue failed: %v", err)
+	}
+	if !sq.VerifySecureProofFromValue(proof, key) {
+		t.Error("Value proof verification failed")
+	}
+}
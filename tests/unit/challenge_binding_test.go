@@ -0,0 +1,126 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+// resealResponses replaces a proof's responses, rebuilds the Merkle root
+// and re-signs it so that only the challenge binding can reject it.
+func resealResponses(t *testing.T, sq *SecureQuantumZKP, proof *SecureProof, responses []ChallengeResponse, key []byte) *SecureProof {
+	t.Helper()
+	p := *proof
+	p.ChallengeResponse = responses
+	root, err := sq.generateMerkleRoot(responses)
+	if err != nil {
+		t.Fatalf("generateMerkleRoot failed: %v", err)
+	}
+	p.MerkleRoot = root
+	if err := sq.signSecureProof(&p, key); err != nil {
+		t.Fatalf("signSecureProof failed: %v", err)
+	}
+	return &p
+}
+
+func TestChallengeResponsesAreBound(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("binding-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	key := testutil.Key()
+	proof, err := sq.SecureProveVectorKnowledge([]complex128{1, 1, 0, 1}, "binding", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if proof.ChallengeBinding == "" {
+		t.Fatal("Proof should carry a challenge binding")
+	}
+	if result := sq.VerifySecureProofWithOptions(proof, key, VerifyOptions{StrictMode: true}); !result.Valid {
+		t.Fatalf("Bound proof should pass strict verification: %v", result.Reasons)
+	}
+
+	// Swapping two responses that answer the same index and basis keeps
+	// the index/basis sequence but not the binding
+	responses := append([]ChallengeResponse(nil), proof.ChallengeResponse...)
+	swapped := false
+	for i := 0; i < len(responses) && !swapped; i++ {
+		for j := i + 1; j < len(responses); j++ {
+			if responses[i].ChallengeIndex == responses[j].ChallengeIndex &&
+				responses[i].BasisChoice == responses[j].BasisChoice && responses[i] != responses[j] {
+				responses[i], responses[j] = responses[j], responses[i]
+				swapped = true
+				break
+			}
+		}
+	}
+	if !swapped {
+		t.Fatal("Expected two distinct responses to the same index and basis")
+	}
+	if sq.VerifySecureProof(resealResponses(t, sq, proof, responses, key), key) {
+		t.Error("Responses moved to another challenge's position should not verify")
+	}
+
+	// A binding for another proof does not carry over
+	other, _ := sq.SecureProveVectorKnowledge([]complex128{1, 1, 0, 1}, "binding", key)
+	moved := *proof
+	moved.ChallengeBinding = other.ChallengeBinding
+	if err := sq.signSecureProof(&moved, key); err != nil {
+		t.Fatalf("signSecureProof failed: %v", err)
+	}
+	if sq.VerifySecureProof(&moved, key) {
+		t.Error("Proof carrying another proof's binding should not verify")
+	}
+
+	// Proofs made before bindings still verify, but not in strict mode
+	unbound := *proof
+	unbound.ChallengeBinding = ""
+	if err := sq.signSecureProof(&unbound, key); err != nil {
+		t.Fatalf("signSecureProof failed: %v", err)
+	}
+	if !sq.VerifySecureProof(&unbound, key) {
+		t.Error("Unbound version 3 proof should still verify")
+	}
+	result := sq.VerifySecureProofWithOptions(&unbound, key, VerifyOptions{StrictMode: true})
+	if result.Valid || !strings.Contains(strings.Join(result.Reasons, "\n"), "not bound") {
+		t.Errorf("Strict mode should reject unbound responses, got %v", result.Reasons)
+	}
+}
+
+func TestSessionResponsesAreBound(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("binding-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	key := testutil.Key()
+	verifier, err := sq.NewVerifierSession()
+	if err != nil {
+		t.Fatalf("NewVerifierSession failed: %v", err)
+	}
+	prover, err := sq.NewProverSession(verifier.Offer(), []complex128{1, 0, 1, 0}, "session", key)
+	if err != nil {
+		t.Fatalf("NewProverSession failed: %v", err)
+	}
+	commitment, err := prover.Commit()
+	if err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	challenges, err := verifier.Challenge(commitment)
+	if err != nil {
+		t.Fatalf("Challenge failed: %v", err)
+	}
+	proof, err := prover.Respond(challenges)
+	if err != nil {
+		t.Fatalf("Respond failed: %v", err)
+	}
+	if proof.ChallengeBinding == "" {
+		t.Fatal("Session proofs should carry a challenge binding")
+	}
+	if !verifier.Verify(proof, key) {
+		t.Error("Bound session proof should verify")
+	}
+	if !sq.AuditSessionProof(proof, key, verifier.Offer().SessionKey) {
+		t.Error("Auditors without the nonces should still accept the proof")
+	}
+}
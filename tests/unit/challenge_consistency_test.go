@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestChallengeConsistency(t *testing.T) {
+	sq := newTestProver(t, 128)
+	key := testutil.Key()
+	proof, err := sq.SecureProveVectorKnowledge(testutil.RampVector(256), "consistency", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if err := sq.CheckChallengeConsistency(proof); err != nil {
+		t.Fatalf("Honest proof is inconsistent: %v", err)
+	}
+
+	cases := []struct {
+		name          string
+		mutate        func(p *SecureProof)
+		inconsistency ChallengeInconsistency
+	}{
+		{"zero dimension", func(p *SecureProof) { p.StateMetadata.Dimension = 0 }, ChallengeDimensionOutOfRange},
+		{"security level out of range", func(p *SecureProof) { p.StateMetadata.SecurityLevel = 1 << 20 }, ChallengeSecurityLevelOutOfRange},
+		{"security level below verifier", func(p *SecureProof) { p.StateMetadata.SecurityLevel = 64 }, ChallengeSecurityLevelTooLow},
+		{"index at dimension", func(p *SecureProof) { p.ChallengeResponse[0].ChallengeIndex = 256 }, ChallengeIndexOutOfRange},
+		{"negative index", func(p *SecureProof) { p.ChallengeResponse[0].ChallengeIndex = -1 }, ChallengeIndexOutOfRange},
+		{"shrunk dimension", func(p *SecureProof) { p.StateMetadata.Dimension = 2 }, ChallengeIndexOutOfRange},
+		{"one repeated index", func(p *SecureProof) {
+			for i := range p.ChallengeResponse {
+				p.ChallengeResponse[i].ChallengeIndex = 7
+			}
+		}, ChallengeTooFewDistinctIndices},
+	}
+	for _, c := range cases {
+		mutated := *proof
+		mutated.ChallengeResponse = append([]ChallengeResponse(nil), proof.ChallengeResponse...)
+		c.mutate(&mutated)
+
+		var consistencyErr *ChallengeConsistencyError
+		err := sq.CheckChallengeConsistency(&mutated)
+		if !errors.As(err, &consistencyErr) || consistencyErr.Inconsistency != c.inconsistency || !errors.Is(err, ErrInconsistentChallenges) {
+			t.Errorf("%s: got %v, expected %s", c.name, err, c.inconsistency)
+		}
+		if sq.VerifySecureProof(&mutated, key) {
+			t.Errorf("%s: inconsistent proof verified", c.name)
+		}
+		if result := sq.VerifySecureProofVersioned(&mutated, key); result.Valid || !hasReason(result.Reasons, string(c.inconsistency)) {
+			t.Errorf("%s: result should name the inconsistency: %+v", c.name, result)
+		}
+	}
+}
+
+func TestMinDistinctChallengeIndices(t *testing.T) {
+	cases := []struct {
+		dimension, count, min int
+	}{
+		{2, 8, 1},  // two indices cannot be told from one with so few draws
+		{8, 13, 1}, // all 13 on one index happens once in 2^36
+		{8, 26, 3}, // two or fewer once in 2^47
+		{1024, 8, 3},
+		{1024, 0, 0},
+	}
+	for _, c := range cases {
+		if got := minDistinctChallengeIndices(c.dimension, c.count); got != c.min {
+			t.Errorf("minDistinctChallengeIndices(%d, %d) = %d, expected %d", c.dimension, c.count, got, c.min)
+		}
+	}
+}
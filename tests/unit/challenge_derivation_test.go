@@ -0,0 +1,114 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestDerivedChallengesDeterministic(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("derivation-context"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+
+	commitment := []byte("0123456789abcdef")
+	first, err := sq.deriveChallenges(commitment, 42, 8, 16, DefaultChallengeNonceLength)
+	if err != nil {
+		t.Fatalf("deriveChallenges failed: %v", err)
+	}
+	second, err := sq.deriveChallenges(commitment, 42, 8, 16, DefaultChallengeNonceLength)
+	if err != nil {
+		t.Fatalf("deriveChallenges failed: %v", err)
+	}
+
+	for i := range first {
+		if first[i].Index != second[i].Index || first[i].BasisType != second[i].BasisType {
+			t.Fatalf("challenge %d differs between derivations", i)
+		}
+		if first[i].Index < 0 || first[i].Index >= 8 {
+			t.Errorf("challenge %d index %d out of range", i, first[i].Index)
+		}
+	}
+
+	other, err := sq.deriveChallenges(commitment, 43, 8, 16, DefaultChallengeNonceLength)
+	if err != nil {
+		t.Fatalf("deriveChallenges failed: %v", err)
+	}
+	same := true
+	for i := range first {
+		if first[i].Index != other[i].Index || first[i].BasisType != other[i].BasisType {
+			same = false
+			break
+		}
+	}
+	if same {
+		t.Error("Expected a different epoch to change the derived challenges")
+	}
+}
+
+func TestGroundChallengesRejected(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("derivation-context"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+
+	key := testutil.Key()
+	vector := []complex128{complex(0.5, 0), complex(0.5, 0), complex(0.5, 0), complex(0.5, 0)}
+
+	proof, err := sq.SecureProveVectorKnowledge(vector, "grinding_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if !sq.VerifySecureProof(proof, key) {
+		t.Fatal("Honest proof should verify")
+	}
+
+	// A prover that picks its own basis for the first challenge produces a
+	// perfectly well-formed, signed proof that must still be rejected.
+	if proof.ChallengeResponse[0].BasisChoice == "Z" {
+		proof.ChallengeResponse[0].BasisChoice = "X"
+	} else {
+		proof.ChallengeResponse[0].BasisChoice = "Z"
+	}
+	proof.MerkleRoot, err = sq.generateMerkleRoot(proof.ChallengeResponse)
+	if err != nil {
+		t.Fatalf("generateMerkleRoot failed: %v", err)
+	}
+	if err := sq.signSecureProof(proof, key); err != nil {
+		t.Fatalf("signSecureProof failed: %v", err)
+	}
+
+	if sq.VerifySecureProof(proof, key) {
+		t.Error("Proof with prover-chosen challenges should not verify")
+	}
+}
+
+func TestForgedChallengeEpochRejected(t *testing.T) {
+	key := testutil.Key()
+	now := time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	// A prover grinding epochs makes its proof in one of its choosing and
+	// then claims a current timestamp for it
+	sq := newTestProver(t, 64)
+	sq.Clock = func() time.Time { return now.Add(-72 * time.Hour) }
+	proof, err := sq.SecureProveVectorKnowledge(testutil.RampVector(8), "epoch_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	forged := *proof
+	forged.Timestamp = now
+	if err := sq.signSecureProof(&forged, key); err != nil {
+		t.Fatalf("signSecureProof failed: %v", err)
+	}
+	sq.Clock = func() time.Time { return now }
+	if sq.VerifySecureProof(&forged, key) {
+		t.Error("Proof whose epoch is not its timestamp's verified")
+	}
+
+	// Consistent proofs keep verifying however old they are
+	if !sq.VerifySecureProof(proof, key) {
+		t.Error("Proof from three days ago rejected against today's clock")
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/rand"
+	"sync"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestChallengeNonceLengthIsRecordedAndBound(t *testing.T) {
+	verifier := newTestProver(t, 64)
+	key := testutil.Key()
+	if got := verifier.Parameters().ChallengeNonceLength; got != DefaultChallengeNonceLength {
+		t.Errorf("Default nonce length %d, expected %d", got, DefaultChallengeNonceLength)
+	}
+
+	for _, length := range []int{0, MinChallengeNonceLength, MaxChallengeNonceLength, LegacyChallengeNonceLength} {
+		prover := *verifier
+		prover.ChallengeNonceLength = length
+		proof, err := prover.SecureProveVectorKnowledge(testutil.RampVector(8), "nonces", key)
+		if err != nil {
+			t.Fatalf("Length %d: %v", length, err)
+		}
+		want := length
+		if length == 0 {
+			want = DefaultChallengeNonceLength
+		}
+		if length == LegacyChallengeNonceLength {
+			want = 0 // left out for verifiers of earlier releases
+		}
+		if proof.ChallengeNonceLength != want {
+			t.Errorf("Length %d: proof records %d, expected %d", length, proof.ChallengeNonceLength, want)
+		}
+		// Verifiers derive the challenges at the length the proof records
+		if !verifier.VerifySecureProof(proof, key) {
+			t.Errorf("Length %d: proof rejected by a default verifier", length)
+		}
+
+		tampered := *proof
+		tampered.ChallengeNonceLength = 24
+		verifier.signSecureProof(&tampered, key)
+		if verifier.VerifySecureProof(&tampered, key) {
+			t.Errorf("Length %d: proof verified with a rewritten nonce length", length)
+		}
+	}
+
+	for _, length := range []int{8, MaxChallengeNonceLength + 1} {
+		prover := *verifier
+		prover.ChallengeNonceLength = length
+		if _, err := prover.SecureProveVectorKnowledge(testutil.RampVector(8), "nonces", key); err == nil {
+			t.Errorf("Nonce length %d was accepted", length)
+		}
+	}
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	r.mu.Lock()
+	r.n += len(b)
+	r.mu.Unlock()
+	return rand.Read(b)
+}
+
+func TestInteractiveChallengesUseConfiguredRandomness(t *testing.T) {
+	sq := newTestProver(t, 64)
+	sq.ChallengeNonceLength = MaxChallengeNonceLength
+	source := &countingReader{}
+	sq.Rand = source
+
+	verifier, err := sq.NewVerifierSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prover, err := sq.NewProverSession(verifier.Offer(), testutil.RampVector(8), "nonces", testutil.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitment, err := prover.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := source.n
+	challenges, err := verifier.Challenge(commitment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source.n-before < len(challenges)*MaxChallengeNonceLength {
+		t.Errorf("Challenges read %d bytes from the configured source", source.n-before)
+	}
+	for i, c := range challenges {
+		if len(c.Nonce) != MaxChallengeNonceLength {
+			t.Fatalf("Challenge %d has a %d-byte nonce", i, len(c.Nonce))
+		}
+	}
+
+	short := append([]Challenge(nil), challenges...)
+	short[1].Nonce = short[1].Nonce[:MinChallengeNonceLength]
+	if _, err := prover.Respond(short); err == nil {
+		t.Error("Challenges with mixed nonce lengths were answered")
+	}
+	proof, err := prover.Respond(challenges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof.ChallengeNonceLength != MaxChallengeNonceLength || !verifier.Verify(proof, testutil.Key()) {
+		t.Errorf("Session proof records nonce length %d", proof.ChallengeNonceLength)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestChallengeSpaceSoundness(t *testing.T) {
+	sq, err := NewSecureQuantumZKPWithSoundness(3, 128, 128, []byte("challenge-space-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKPWithSoundness failed: %v", err)
+	}
+	// Each Z/X challenge is worth one bit
+	if sq.ChallengeCount() != 128 || sq.EffectiveSoundness() != 128 {
+		t.Errorf("128 bits should need 128 challenges: %d challenges, %d effective",
+			sq.ChallengeCount(), sq.EffectiveSoundness())
+	}
+	params := sq.Parameters()
+	if params.ChallengeCount != 128 || params.EffectiveSoundnessBits != 128 {
+		t.Errorf("Parameters should report the challenge count: %+v", params)
+	}
+
+	// The deprecated ChallengeSpace does not affect the parameters
+	data, _ := json.Marshal(params)
+	if strings.Contains(string(data), "challenge_space") {
+		t.Errorf("Parameters should not publish challenge_space: %s", data)
+	}
+	wider := *sq
+	wider.ChallengeSpace *= 2
+	hash, _ := params.Hash()
+	if widerHash, _ := wider.Parameters().Hash(); widerHash != hash {
+		t.Errorf("ChallengeSpace should not change the parameters hash: %s != %s", widerHash, hash)
+	}
+
+	key := testutil.Key()
+	proof, err := sq.SecureProveVectorKnowledge(testutil.RampVector(16), "challenge_space_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if len(proof.ChallengeResponse) != 128 || proof.StateMetadata.SoundnessBits != 128 {
+		t.Fatalf("Proof should carry 128 challenges reaching 128 bits, got %d reaching %d",
+			len(proof.ChallengeResponse), proof.StateMetadata.SoundnessBits)
+	}
+	if result := sq.VerifySecureProofWithOptions(proof, key, VerifyOptions{StrictMode: true}); !result.Valid {
+		t.Fatalf("Proof should pass strict verification: %v", result.Reasons)
+	}
+
+	// Overstating the soundness breaks verification even when re-signed
+	inflated := *proof
+	inflated.StateMetadata.SoundnessBits = 256
+	sq.signSecureProof(&inflated, key)
+	if sq.VerifySecureProof(&inflated, key) {
+		t.Error("A proof overstating its soundness should not verify")
+	}
+
+	// So does answering the other basis than was derived
+	responses := append([]ChallengeResponse(nil), proof.ChallengeResponse...)
+	if responses[0].BasisChoice == "Z" {
+		responses[0].BasisChoice = "X"
+	} else {
+		responses[0].BasisChoice = "Z"
+	}
+	if sq.VerifySecureProof(resealResponses(t, sq, proof, responses, key), key) {
+		t.Error("A response to the other basis should not verify")
+	}
+}
+
+func TestChallengeBases(t *testing.T) {
+	sq, err := NewSecureQuantumZKPWithSoundness(3, 128, 128, []byte("challenge-space-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKPWithSoundness failed: %v", err)
+	}
+	key := testutil.Key()
+	proof, err := sq.SecureProveVectorKnowledge(testutil.RampVector(8), "binary_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	data, _ := json.Marshal(proof.ChallengeResponse)
+	var raw []map[string]interface{}
+	json.Unmarshal(data, &raw)
+	seen := map[interface{}]bool{}
+	for _, r := range raw {
+		if _, ok := r["basis_angle"]; ok || (r["basis_choice"] != "Z" && r["basis_choice"] != "X") {
+			t.Fatalf("Challenges should keep the Z/X response format: %v", r)
+		}
+		seen[r["basis_choice"]] = true
+	}
+	if len(seen) != 2 {
+		t.Errorf("128 challenges should select both bases, got %v", seen)
+	}
+
+	// The derived bases are what the verifier checks against
+	responses := append([]ChallengeResponse(nil), proof.ChallengeResponse...)
+	responses[0].BasisChoice = "R"
+	if sq.VerifySecureProof(resealResponses(t, sq, proof, responses, key), key) {
+		t.Error("A response in an unknown basis should not verify")
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestCheckedArithmetic(t *testing.T) {
+	for _, exp := range []int{0, 10, 62} {
+		if v, err := checkedPow2(exp); err != nil || v != 1<<exp {
+			t.Errorf("2^%d = %d, %v", exp, v, err)
+		}
+	}
+	for _, exp := range []int{-1, 63, 64, 256} {
+		if _, err := checkedPow2(exp); !errors.Is(err, ErrIntegerOverflow) {
+			t.Errorf("2^%d should overflow, got %v", exp, err)
+		}
+	}
+
+	if maxOf[int32]() != math.MaxInt32 || maxOf[int64]() != math.MaxInt64 || maxOf[int]() != math.MaxInt || minOf[int32]() != math.MinInt32 {
+		t.Error("Type bounds do not match the math constants")
+	}
+
+	if v, err := checkedAdd(int64(math.MaxInt64-1), 1); err != nil || v != math.MaxInt64 {
+		t.Errorf("Sum at the boundary should fit, got %d, %v", v, err)
+	}
+	if _, err := checkedAdd(int64(math.MaxInt64), 1); !errors.Is(err, ErrIntegerOverflow) {
+		t.Error("MaxInt64 + 1 should overflow")
+	}
+	if _, err := checkedAdd(int32(math.MinInt32), -1); !errors.Is(err, ErrIntegerOverflow) {
+		t.Error("MinInt32 - 1 should overflow")
+	}
+	if v, err := checkedMul(int64(1)<<31, int64(1)<<31); err != nil || v != 1<<62 {
+		t.Errorf("2^31 · 2^31 should fit, got %d, %v", v, err)
+	}
+	for _, pair := range [][2]int64{{1 << 32, 1 << 31}, {math.MinInt64, -1}, {-1, math.MinInt64}, {math.MaxInt64, 2}} {
+		if _, err := checkedMul(pair[0], pair[1]); !errors.Is(err, ErrIntegerOverflow) {
+			t.Errorf("%d · %d should overflow", pair[0], pair[1])
+		}
+	}
+	if v, err := checkedCeilDiv(math.MaxInt, 10); err != nil || v != math.MaxInt/10+1 {
+		t.Errorf("Ceiling division near MaxInt should not wrap, got %d, %v", v, err)
+	}
+}
+
+func TestSoundnessOverflowGuards(t *testing.T) {
+	for bits, want := range map[int]int{63: 16, 64: 16, 256: FullDigestLength, math.MaxInt: FullDigestLength, -5: 16} {
+		if got := DigestLengthsFor(bits).Commitment; got != want {
+			t.Errorf("DigestLengthsFor(%d) = %d bytes, want %d", bits, got, want)
+		}
+	}
+
+	sq := newTestProver(t, 64)
+	if _, err := sq.CompositionRounds(math.MaxInt); err == nil {
+		t.Error("A target soundness near MaxInt should be refused, not wrap to a negative round count")
+	}
+	if rounds, err := sq.CompositionRounds(256); err != nil || rounds != 4 {
+		t.Errorf("256-bit composition: %d rounds, %v", rounds, err)
+	}
+
+	sq.SecurityParameter = math.MaxInt
+	if _, err := sq.SecureProveVectorKnowledge(testutil.RampVector(8), "overflow", testutil.Key()); !errors.Is(err, ErrIntegerOverflow) {
+		t.Errorf("Proof size estimate should overflow, got %v", err)
+	}
+	sq.SecurityParameter = -64
+	if sq.ChallengeCount() != 0 || sq.EffectiveSoundness() != 0 {
+		t.Error("A negative security parameter needs no challenges and reaches no soundness")
+	}
+	if _, err := sq.SecureProveVectorKnowledge(testutil.RampVector(8), "overflow", testutil.Key()); err == nil {
+		t.Error("A negative security parameter should be refused")
+	}
+
+	if _, err := EstimateProofMemory(math.MaxInt, 1, DigestLengthsFor(128), ""); !errors.Is(err, ErrIntegerOverflow) {
+		t.Errorf("Estimate for a huge dimension should overflow, got %v", err)
+	}
+	if _, err := EstimateProofMemory(1<<20, 1<<20, DigestLengthsFor(128), "base64+gzip+json"); err != nil {
+		t.Errorf("Large but representable estimate failed: %v", err)
+	}
+
+	for _, qubits := range []int{63, 64, 256} {
+		if _, err := witnessOperators(StateFamilyGHZ, qubits); err == nil {
+			t.Errorf("A %d-qubit GHZ witness should be refused", qubits)
+		}
+	}
+}
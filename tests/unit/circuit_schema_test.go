@@ -0,0 +1,108 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestValidateCircuitAcceptsBuiltCircuits(t *testing.T) {
+	ansatz, err := HardwareEfficientAnsatz(3, 2)
+	if err != nil {
+		t.Fatalf("HardwareEfficientAnsatz failed: %v", err)
+	}
+	bell, err := ParseQASM(`OPENQASM 2.0; include "qelib1.inc"; qreg q[2]; creg c[2];
+		h q[0]; cx q[0], q[1]; barrier q; measure q -> c;`)
+	if err != nil {
+		t.Fatalf("ParseQASM failed: %v", err)
+	}
+	q, _ := NewQuantumZKP(3, 128, []byte("circuit-schema-test"))
+	built, err := q.BuildCircuit([]complex128{0.6, 0.8i}, "doc")
+	if err != nil {
+		t.Fatalf("BuildCircuit failed: %v", err)
+	}
+	for name, circuit := range map[string]*QuantumCircuit{"ansatz": ansatz, "qasm": bell, "built": built} {
+		if err := ValidateCircuit(circuit); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestValidateCircuitRejections(t *testing.T) {
+	cases := []struct {
+		name  string
+		gates []QuantumGate
+		want  string
+	}{
+		{"unknown gate", []QuantumGate{{Type: "frobnicate", Qubits: []int{0}}}, "unknown gate"},
+		{"qubit out of range", []QuantumGate{{Type: "h", Qubits: []int{2}}}, "outside [0, 2)"},
+		{"negative qubit", []QuantumGate{{Type: "x", Qubits: []int{-1}}}, "outside"},
+		{"wrong arity", []QuantumGate{{Type: "cx", Qubits: []int{0}}}, "acts on 2 qubits"},
+		{"repeated qubit", []QuantumGate{{Type: "cx", Qubits: []int{1, 1}}}, "appears twice"},
+		{"missing angle", []QuantumGate{{Type: "rz", Qubits: []int{0}}}, "takes 1 parameters, got 0"},
+		{"extra angle", []QuantumGate{{Type: "h", Qubits: []int{0}, Params: []float64{1}}}, "takes 0 parameters"},
+		{"controlled angle", []QuantumGate{{Type: "crz", Qubits: []int{0, 1}}}, "takes 1 parameters"},
+		{"non-finite angle", []QuantumGate{{Type: "rx", Qubits: []int{0}, Params: []float64{math.NaN()}}}, "not finite"},
+		{"symbol count", []QuantumGate{{Type: "u2", Qubits: []int{0}, Params: []float64{0, 0}, Symbols: []string{"a"}}}, "1 symbols for 2"},
+		{"clbit out of range", []QuantumGate{{Type: "measure", Qubits: []int{0, 1}}}, "classical bit 1 outside [0, 1)"},
+		{"measure shape", []QuantumGate{{Type: "measure", Qubits: []int{0}}}, "[qubit, clbit]"},
+		{"clbit written twice", []QuantumGate{
+			{Type: "measure", Qubits: []int{0, 0}},
+			{Type: "measure", Qubits: []int{1, 0}},
+		}, "already written by gate 0"},
+		{"gate after measurement", []QuantumGate{
+			{Type: "measure", Qubits: []int{0, 0}},
+			{Type: "h", Qubits: []int{0}},
+		}, "used after it was measured"},
+	}
+	for _, c := range cases {
+		circuit := NewCircuit(2, 1)
+		circuit.Gates = c.gates
+		err := ValidateCircuit(circuit)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: expected an error containing %q, got %v", c.name, c.want, err)
+		}
+	}
+
+	// Unbound parameters are placeholders, not angles
+	ansatz, _ := HardwareEfficientAnsatz(1, 1)
+	ansatz.Gates[0].Params[0] = math.NaN()
+	if err := ValidateCircuit(ansatz); err != nil {
+		t.Errorf("Unbound parameter values should not be checked: %v", err)
+	}
+}
+
+func TestCircuitSchemaRoundTrip(t *testing.T) {
+	ansatz, _ := HardwareEfficientAnsatz(2, 1)
+	ansatz.Gates = append(ansatz.Gates,
+		QuantumGate{Type: "measure", Qubits: []int{0, 0}},
+		QuantumGate{Type: "measure", Qubits: []int{1, 1}})
+	data, err := MarshalCircuit(ansatz)
+	if err != nil {
+		t.Fatalf("MarshalCircuit failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"schema":"`+CircuitSchema+`"`) {
+		t.Errorf("Schema identifier missing: %s", data)
+	}
+	decoded, err := UnmarshalCircuit(data)
+	if err != nil {
+		t.Fatalf("UnmarshalCircuit failed: %v", err)
+	}
+	if decoded.NumQubits != 2 || len(decoded.Gates) != len(ansatz.Gates) ||
+		strings.Join(decoded.Parameters(), ",") != strings.Join(ansatz.Parameters(), ",") {
+		t.Errorf("Round trip changed the circuit: %+v", decoded)
+	}
+
+	for name, doc := range map[string]string{
+		"other schema":  `{"schema":"qzkp-circuit/v2","num_qubits":1,"num_clbits":0,"gates":[]}`,
+		"unknown field": `{"schema":"qzkp-circuit/v1","num_qubits":1,"num_clbits":0,"gates":[],"initialized":true}`,
+		"invalid gate":  `{"schema":"qzkp-circuit/v1","num_qubits":1,"num_clbits":0,"gates":[{"type":"cx","qubits":[0,1]}]}`,
+	} {
+		if _, err := UnmarshalCircuit([]byte(doc)); err == nil {
+			t.Errorf("%s should be rejected", name)
+		}
+	}
+	if _, err := MarshalCircuit(&QuantumCircuit{NumQubits: 1, Gates: []QuantumGate{{Type: "h", Qubits: []int{3}}}}); err == nil {
+		t.Error("Invalid circuits should not be marshaled")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestProofClaims(t *testing.T) {
+	sq := newTestProver(t, 64)
+	key := testutil.Key()
+	claims := map[string]string{
+		ClaimPurpose:      "kyc",
+		ClaimAudience:     "bank.example",
+		ClaimDocumentType: "passport",
+	}
+
+	proof, err := sq.WithClaims(claims).SecureProveVectorKnowledge(testutil.RampVector(8), "claims_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if len(sq.Claims) != 0 {
+		t.Error("WithClaims must not change the original prover")
+	}
+	if proof.Claims[ClaimPurpose] != "kyc" || !sq.VerifySecureProof(proof, key) {
+		t.Fatalf("Proof with claims should verify: %+v", proof.Claims)
+	}
+
+	// Claims are signed: changing, adding or removing one breaks the proof
+	for name, mutate := range map[string]func(map[string]string){
+		"changed": func(c map[string]string) { c[ClaimAudience] = "attacker.example" },
+		"added":   func(c map[string]string) { c["scope"] = "all" },
+		"removed": func(c map[string]string) { delete(c, ClaimPurpose) },
+	} {
+		tampered := *proof
+		tampered.Claims = copyClaims(proof.Claims)
+		mutate(tampered.Claims)
+		if sq.VerifySecureProof(&tampered, key) {
+			t.Errorf("Proof with a %s claim must not verify", name)
+		}
+	}
+
+	accepting := NewPolicy(
+		RequireClaim(ClaimPurpose, "kyc", "aml"),
+		RequireClaim(ClaimAudience),
+		AllowedClaimKeys(ClaimPurpose, ClaimAudience, ClaimDocumentType),
+	)
+	if _, err := sq.VerifySecureProofWithPolicy(proof, key, accepting); err != nil {
+		t.Errorf("Proof should satisfy the claim policy: %v", err)
+	}
+	rejecting := NewPolicy(
+		RequireClaim(ClaimPurpose, "marketing"),
+		RequireClaim("tenant"),
+		AllowedClaimKeys(ClaimPurpose),
+	)
+	report, err := sq.VerifySecureProofWithPolicy(proof, key, rejecting)
+	if err == nil || len(report.Violations) != 3 {
+		t.Errorf("Expected 3 claim violations, got %v", err)
+	}
+}
+
+func TestClaimLimits(t *testing.T) {
+	sq := newTestProver(t, 64)
+	tooMany := map[string]string{}
+	for i := 0; i <= MaxClaims; i++ {
+		tooMany[strings.Repeat("k", i+1)] = "v"
+	}
+	for name, claims := range map[string]map[string]string{
+		"too many":      tooMany,
+		"upper case":    {"Purpose": "kyc"},
+		"long key":      {strings.Repeat("k", MaxClaimKeyLength+1): "v"},
+		"long value":    {ClaimPurpose: strings.Repeat("v", MaxClaimValueLength+1)},
+		"control value": {ClaimPurpose: "kyc\nforged: line"},
+		"empty value":   {ClaimPurpose: ""},
+	} {
+		_, err := sq.WithClaims(claims).SecureProveVectorKnowledge(testutil.RampVector(8), "claims_test", testutil.Key())
+		if !errors.Is(err, ErrInvalidClaims) {
+			t.Errorf("%s: expected ErrInvalidClaims, got %v", name, err)
+		}
+	}
+}
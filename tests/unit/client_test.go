@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientRetriesAndPreValidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "qzkpd.json")
+	writeDaemonConfig(t, path, DaemonConfig{
+		ServiceConfig: ServiceConfig{
+			Dimensions:    3,
+			SecurityLevel: 128,
+			Application:   "client-test",
+			Key:           "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		},
+	})
+	d, err := NewDaemon(path)
+	if err != nil {
+		t.Fatalf("NewDaemon failed: %v", err)
+	}
+	defer d.Shutdown(context.Background())
+
+	// Fail the first two prove calls with a retryable status
+	var failures int32 = 2
+	handler := d.Handler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/prove" && atomic.AddInt32(&failures, -1) >= 0 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.BaseBackoff = time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	proof, err := client.Prove(ctx, "client_test", []byte("client payload"))
+	if err != nil {
+		t.Fatalf("Prove failed after retries: %v", err)
+	}
+	valid, err := client.Verify(ctx, proof)
+	if err != nil || !valid {
+		t.Fatalf("Verify failed: valid=%v err=%v", valid, err)
+	}
+
+	tampered := *proof
+	tampered.Identifier = "someone_else"
+	if err := client.PreValidate(ctx, &tampered); !errors.Is(err, ErrProofSignatureInvalid) {
+		t.Errorf("Expected ErrProofSignatureInvalid, got %v", err)
+	}
+
+	// Non-retryable errors surface immediately as *APIError
+	_, err = client.Prove(ctx, "", []byte("x"))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Retryable() {
+		t.Errorf("Expected a non-retryable APIError, got %v", err)
+	}
+}
+
+func TestClientBackoffBounds(t *testing.T) {
+	// A server asking for an hour's pause must not stall the client
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "3600")
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client := NewClient(server.URL)
+	client.MaxRetries = 2
+	client.BaseBackoff = time.Millisecond
+	client.MaxBackoff = 10 * time.Millisecond
+	start := time.Now()
+	_, err := client.Prove(ctx, "client_test", []byte("x"))
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter != time.Hour {
+		t.Fatalf("Expected the service's APIError, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Retry-After should be capped at MaxBackoff, retries took %v", elapsed)
+	}
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("Expected 3 attempts, got %d", n)
+	}
+
+	// A negative MaxBackoff falls back to the default instead of panicking
+	busy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	}))
+	defer busy.Close()
+	client = NewClient(busy.URL)
+	client.BaseBackoff = time.Millisecond
+	client.MaxBackoff = -time.Second
+	if _, err := client.Prove(ctx, "client_test", []byte("x")); !errors.As(err, &apiErr) {
+		t.Errorf("Expected the service's APIError, got %v", err)
+	}
+}
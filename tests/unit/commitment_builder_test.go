@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"math/bits"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+// syntheticInput is an endless pseudo-random byte stream that costs no
+// memory, standing in for multi-gigabyte inputs.
+type syntheticInput struct {
+	state uint64
+	left  int64
+}
+
+func (s *syntheticInput) Read(p []byte) (int, error) {
+	if s.left <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > s.left {
+		p = p[:s.left]
+	}
+	for i := range p {
+		s.state = s.state*6364136223846793005 + 1442695040888963407
+		p[i] = byte(s.state >> 56)
+	}
+	s.left -= int64(len(p))
+	return len(p), nil
+}
+
+func TestCommitmentBuilderMatchesTreeHash(t *testing.T) {
+	sq := newTestProver(t, 64)
+	for _, n := range []int{1, 2, 3, 5, 8, 13, 32, 33} {
+		builder, err := sq.NewCommitmentBuilder("chunked", testutil.Key())
+		if err != nil {
+			t.Fatalf("NewCommitmentBuilder failed: %v", err)
+		}
+		var leaves [][]byte
+		for i := 0; i < n; i++ {
+			chunk, err := NewStateVectorFromBytes([]byte{byte(i), byte(i >> 8), 42}, 4, DimensionSelectionExplicit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			leaf, err := builder.leaf(uint64(i), chunk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			leaves = append(leaves, leaf)
+			if err := builder.Add(chunk); err != nil {
+				t.Fatalf("Add failed: %v", err)
+			}
+			if len(builder.frontier) != bits.Len(uint(i+1)) {
+				t.Fatalf("After %d chunks the frontier holds %d levels", i+1, len(builder.frontier))
+			}
+		}
+		commitment, err := builder.Finalize()
+		if err != nil {
+			t.Fatalf("Finalize failed: %v", err)
+		}
+		if commitment.Chunks != n || commitment.Root != hex.EncodeToString(logTreeHash(leaves)) {
+			t.Errorf("%d chunks: root %s differs from the tree hash", n, commitment.Root)
+		}
+		if err := builder.Add(&StateVector{}); err == nil {
+			t.Error("Add after Finalize should fail")
+		}
+	}
+}
+
+func TestCommitChunksIsOrderSensitiveAndHiding(t *testing.T) {
+	sq := newTestProver(t, 64)
+	sq.Rand = bytes.NewReader(bytes.Repeat([]byte{7}, 1<<10))
+	a, err := sq.CommitChunks(&syntheticInput{state: 1, left: 10 << 10}, 1<<10, 8, "chunked", testutil.Key())
+	if err != nil {
+		t.Fatalf("CommitChunks failed: %v", err)
+	}
+	if a.Chunks != 10 {
+		t.Errorf("Expected 10 chunks, got %d", a.Chunks)
+	}
+
+	sq.Rand = bytes.NewReader(bytes.Repeat([]byte{7}, 1<<10))
+	b, _ := sq.CommitChunks(&syntheticInput{state: 2, left: 10 << 10}, 1<<10, 8, "chunked", testutil.Key())
+	if a.Root == b.Root {
+		t.Error("Different inputs should commit differently")
+	}
+
+	sq.Rand = nil
+	c, _ := sq.CommitChunks(&syntheticInput{state: 1, left: 10 << 10}, 1<<10, 8, "chunked", testutil.Key())
+	if a.Root == c.Root {
+		t.Error("Commitments to the same input should differ by their nonce")
+	}
+
+	if _, err := sq.CommitChunks(bytes.NewReader(nil), 1<<10, 8, "chunked", testutil.Key()); err == nil {
+		t.Error("An empty input should be refused")
+	}
+}
+
+// BenchmarkCommitmentBuilder commits to one 1 MiB chunk per iteration;
+// -benchtime 4096x streams a 4 GiB input through a single builder.
+func BenchmarkCommitmentBuilder(b *testing.B) {
+	sq := newTestProver(b, 128)
+	builder, err := sq.NewCommitmentBuilder("benchmark", testutil.Key())
+	if err != nil {
+		b.Fatal(err)
+	}
+	input := &syntheticInput{state: 1, left: 1 << 62}
+	chunk := make([]byte, 1<<20)
+	b.SetBytes(int64(len(chunk)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		io.ReadFull(input, chunk)
+		state, err := NewStateVectorFromBytes(chunk, 64, DimensionSelectionExplicit)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := builder.Add(state); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(len(builder.frontier)), "frontier-levels")
+	if _, err := builder.Finalize(); err != nil {
+		b.Fatal(err)
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+// compatDir holds proofs written by released versions. Files there are
+// never rewritten: -update only adds the current release's fixtures when
+// it has none yet, so a change that stops an archived proof from parsing
+// or verifying fails here instead of being regenerated away.
+var compatDir = filepath.Join("testdata", "compat")
+
+// compatCodecCOSE marks fixtures stored as a COSE_Sign1 envelope rather
+// than with one of ProofCodecs.
+const compatCodecCOSE = "cose"
+
+// compatFixture describes one archived proof and the outcome the current
+// code must give for it.
+type compatFixture struct {
+	File          string  `json:"file"`
+	Release       string  `json:"release"` // LibraryVersion of the release that wrote it
+	Codec         string  `json:"codec"`   // a ProofCodecs name, or "cose"
+	ProofVersion  int     `json:"proof_version"`
+	Dimensions    int     `json:"dimensions"`
+	SecurityLevel int     `json:"security_level"`
+	SoundnessBits int     `json:"soundness_bits"`
+	Context       Context `json:"context"`
+	PublicKey     string  `json:"public_key"` // file holding the signer's hex public key
+	Key           string  `json:"key"`
+	// Caveats is how many caveats VerifySecureProofVersioned reports;
+	// StrictRejects says strict mode rejects the proof for relying on a
+	// deprecated format
+	Caveats       int  `json:"caveats"`
+	StrictRejects bool `json:"strict_rejects"`
+}
+
+func readCompatManifest(t *testing.T) []compatFixture {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(compatDir, "manifest.json"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("Failed to read compatibility manifest: %v", err)
+	}
+	var fixtures []compatFixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		t.Fatalf("Corrupted compatibility manifest: %v", err)
+	}
+	return fixtures
+}
+
+// verifier returns a verify-only instance for the fixture's parameters,
+// context and signer.
+func (f *compatFixture) verifier(t *testing.T) *SecureQuantumZKP {
+	t.Helper()
+	sq, err := NewSecureQuantumZKPWithSoundness(f.Dimensions, f.SecurityLevel, f.SoundnessBits, nil)
+	if err != nil {
+		t.Fatalf("%s: %v", f.File, err)
+	}
+	sq.setContext(f.Context)
+	encoded, err := os.ReadFile(filepath.Join(compatDir, f.PublicKey))
+	if err != nil {
+		t.Fatalf("%s: %v", f.File, err)
+	}
+	pub, err := hex.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		t.Fatalf("%s: %v", f.File, err)
+	}
+	signer, err := NewVerificationScheme(pub, f.Context.Bytes())
+	if err != nil {
+		t.Fatalf("%s: %v", f.File, err)
+	}
+	return sq.withSigner(signer)
+}
+
+// decode parses the stored fixture with the codec it was written with.
+func (f *compatFixture) decode(t *testing.T, sq *SecureQuantumZKP) *SecureProof {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join(compatDir, f.File))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	if f.Codec == compatCodecCOSE {
+		proof, _, err := sq.DecodeProofCOSE(raw)
+		if err != nil {
+			t.Fatalf("%s: DecodeProofCOSE failed: %v", f.File, err)
+		}
+		return proof
+	}
+	// Detection may settle on an equivalent codec, e.g. base64 for
+	// base64url text without URL-specific characters
+	if d := DiagnoseProof(raw); d.Codec == "" {
+		t.Errorf("%s: codec not recognized", f.File)
+	}
+	i := slices.IndexFunc(proofCodecs, func(c proofCodec) bool { return c.name == f.Codec })
+	if i < 0 {
+		t.Fatalf("%s: codec %q is no longer supported", f.File, f.Codec)
+	}
+	payload, err := proofCodecs[i].decode(raw)
+	if err != nil {
+		t.Fatalf("%s: %v", f.File, err)
+	}
+	proof, err := ParseSecureProof(payload)
+	if err != nil {
+		t.Fatalf("%s: ParseSecureProof failed: %v", f.File, err)
+	}
+	return proof
+}
+
+func TestArchivedProofsStillVerify(t *testing.T) {
+	if testutil.Updating() {
+		addCompatFixtures(t)
+	}
+	fixtures := readCompatManifest(t)
+	if len(fixtures) == 0 {
+		t.Fatal("No compatibility fixtures (run with -update to add the current release's)")
+	}
+
+	for _, f := range fixtures {
+		t.Run(f.File, func(t *testing.T) {
+			sq := f.verifier(t)
+			proof := f.decode(t, sq)
+			key, _ := hex.DecodeString(f.Key)
+			if proof.Version != f.ProofVersion {
+				t.Errorf("Parsed as version %d, written as %d", proof.Version, f.ProofVersion)
+			}
+
+			result := sq.VerifySecureProofVersioned(proof, key)
+			if !result.Valid || len(result.Caveats) != f.Caveats {
+				t.Errorf("Versioned verification: %+v, expected valid with %d caveats", result, f.Caveats)
+			}
+			strict := sq.VerifySecureProofWithOptions(proof, key, VerifyOptions{StrictMode: true})
+			if strict.Valid == f.StrictRejects {
+				t.Errorf("Strict verification: %+v, expected rejection %v", strict, f.StrictRejects)
+			}
+			if f.StrictRejects && !slices.ContainsFunc(strict.Reasons, func(r string) bool { return strings.Contains(r, "deprecated") }) {
+				t.Errorf("Strict mode rejected without the documented reason: %v", strict.Reasons)
+			}
+
+			tampered := *proof
+			tampered.Identifier += "-tampered"
+			if sq.VerifySecureProofVersioned(&tampered, key).Valid {
+				t.Error("Tampered archived proof verified")
+			}
+		})
+	}
+}
+
+// addCompatFixtures writes the current release's fixtures, one per proof
+// format it can still produce, spread over the codecs, unless the
+// manifest already has fixtures of this release.
+func addCompatFixtures(t *testing.T) {
+	fixtures := readCompatManifest(t)
+	if slices.ContainsFunc(fixtures, func(f compatFixture) bool { return f.Release == LibraryVersion }) {
+		return
+	}
+	c := Context{Version: 1, Application: "qzkp-compat"}
+	sq, err := NewSecureQuantumZKPWithSoundness(3, 128, 32, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sq.setContext(c)
+	key := testutil.Key()
+	vector := testutil.RampVector(8)
+	fixture := func(file, codec string, version, caveats int, strictRejects bool) compatFixture {
+		return compatFixture{
+			File: LibraryVersion + "-" + file, Release: LibraryVersion, Codec: codec, ProofVersion: version,
+			Dimensions: 3, SecurityLevel: 128, SoundnessBits: 32, Context: c,
+			PublicKey: LibraryVersion + "-signer.pub", Key: hex.EncodeToString(key),
+			Caveats: caveats, StrictRejects: strictRejects,
+		}
+	}
+	write := func(f compatFixture, data []byte) {
+		if err := os.WriteFile(filepath.Join(compatDir, f.File), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		fixtures = append(fixtures, f)
+	}
+	encode := func(codec string, proof *SecureProof) []byte {
+		data, err := json.Marshal(proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, err = EncodeProof(codec, data); err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	if err := os.MkdirAll(compatDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	pub := hex.EncodeToString(sq.Signer.PublicKeyBytes()) + "\n"
+	if err := os.WriteFile(filepath.Join(compatDir, LibraryVersion+"-signer.pub"), []byte(pub), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The current format, in every codec
+	current, err := sq.SecureProveVectorKnowledge(vector, "compat-current", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, codec := range ProofCodecs() {
+		write(fixture(fmt.Sprintf("v%d.%s", CurrentProofVersion, strings.ReplaceAll(codec, "+", "-")), codec, CurrentProofVersion, 0, false), encode(codec, current))
+	}
+	envelope, err := sq.EncodeProofCOSE(current, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(fixture(fmt.Sprintf("v%d.cose", CurrentProofVersion), compatCodecCOSE, CurrentProofVersion, 0, false), envelope)
+
+	// Older formats, as this release's prover lays them out
+	legacyProver := *sq
+	legacyProver.DigestLengths = LegacyDigestLengths
+	legacyProver.ChallengeNonceLength = LegacyChallengeNonceLength
+	unbound, err := legacyProver.SecureProveVectorKnowledge(vector, "compat-v2", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unbound.Version = ProofVersionUnboundParameters
+	unbound.ParametersHash, unbound.DigestLengths, unbound.Provenance, unbound.Randomness = "", nil, nil, nil
+	if err := legacyProver.signSecureProof(unbound, key); err != nil {
+		t.Fatal(err)
+	}
+	write(fixture("v2.json", "json", ProofVersionUnboundParameters, len(proofFormats[ProofVersionUnboundParameters].Caveats), true), encode("json", unbound))
+
+	legacy, err := legacyProver.SecureProveVectorKnowledge(vector, "compat-v1", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1 := secureProofV1{
+		QuantumDimensions: legacy.QuantumDimensions,
+		CommitmentHash:    legacy.CommitmentHash,
+		ChallengeResponse: legacy.ChallengeResponse,
+		MerkleRoot:        legacy.MerkleRoot,
+		StateMetadata:     legacy.StateMetadata,
+		Identifier:        legacy.Identifier,
+		Timestamp:         legacy.Timestamp,
+	}
+	msg, _ := json.Marshal(&v1)
+	unsigned := *sq.Signer
+	unsigned.Ctx = nil
+	sig, err := unsigned.Sign(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1.Signature = hex.EncodeToString(sig)
+	data, _ := json.Marshal(&v1)
+	if data, err = EncodeProof("hex+json", data); err != nil {
+		t.Fatal(err)
+	}
+	write(fixture("v1.hex-json", "hex+json", ProofVersionLegacy, len(proofFormats[ProofVersionLegacy].Caveats), true), data)
+
+	manifest, _ := json.MarshalIndent(fixtures, "", "  ")
+	if err := os.WriteFile(filepath.Join(compatDir, "manifest.json"), append(manifest, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
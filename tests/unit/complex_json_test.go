@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestComplexAmplitudeWireFormat(t *testing.T) {
+	vector := []complex128{complex(0.6, 0), complex(0, -0.8)}
+	wire := ToComplexAmplitudes(vector)
+	if wire[1] != (ComplexAmplitude{Re: 0, Im: -0.8}) {
+		t.Errorf("Unexpected wire form %v", wire)
+	}
+	back := FromComplexAmplitudes(wire)
+	if back[0] != vector[0] || back[1] != vector[1] {
+		t.Errorf("Round trip changed the vector: %v", back)
+	}
+
+	// Both the canonical objects and legacy pairs decode
+	for _, data := range []string{
+		`[{"re": 0.6, "im": 0}, {"re": 0, "im": -0.8}]`,
+		`[[0.6, 0], [0, -0.8]]`,
+		`[{"re": 0.6, "im": 0}, [0, -0.8]]`,
+	} {
+		var decoded Amplitudes
+		if err := json.Unmarshal([]byte(data), &decoded); err != nil || len(decoded) != 2 || decoded[1] != vector[1] {
+			t.Errorf("%s: decoded %v (%v)", data, decoded, err)
+		}
+	}
+	for _, data := range []string{
+		`[[0.6]]`, `[[0.6, 0, 1]]`, `[{"re": 0.6}]`, `[{"re": 0.6, "im": 0, "phase": 1}]`, `[0.6]`, `[null]`, `{"re": 1, "im": 0}`,
+	} {
+		var decoded Amplitudes
+		if err := json.Unmarshal([]byte(data), &decoded); err == nil {
+			t.Errorf("%s: expected a decoding error, got %v", data, decoded)
+		}
+	}
+
+	if _, err := json.Marshal(Amplitudes{complex(math.NaN(), 0)}); err == nil {
+		t.Error("Non-finite amplitudes should fail to encode")
+	}
+
+	// Structs that used to hold bare []complex128 now serialize
+	data, err := NewQuantumStateVector(vector).Serialize()
+	if err != nil || !strings.Contains(string(data), `"coordinates":[{"re":`) {
+		t.Errorf("QuantumStateVector should serialize: %s (%v)", data, err)
+	}
+	q, err := NewQuantumZKP(2, 128, []byte("complex-json"))
+	if err != nil {
+		t.Fatalf("NewQuantumZKP failed: %v", err)
+	}
+	key := testutil.Key()
+	proof, err := q.Prove(vector, "complex_json", key)
+	if err != nil {
+		t.Fatalf("Prove failed: %v", err)
+	}
+	data, _ = json.Marshal(proof)
+	var decoded Proof
+	if err := json.Unmarshal(data, &decoded); err != nil || !q.VerifyProof(&decoded, key) {
+		t.Errorf("Decoded proof should verify (%v)", err)
+	}
+}
+
+func TestStateCacheAmplitudeMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), QuantumStateCacheFile)
+	legacy := `{"version": "1.0", "total_jobs": 1, "states": [
+		{"name": "minus", "vector": [[0.7071067811865476, 0], [-0.7071067811865476, 0]], "qubits": 1, "fidelity": 1}
+	]}`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cache, _ := NewQuantumStateCache(path)
+
+	migrated, err := cache.MigrateAmplitudeEncoding()
+	if err != nil || !migrated {
+		t.Fatalf("Expected a migration, got %v (%v)", migrated, err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"re": -0.7071067811865476`) || !strings.Contains(string(data), `"version": "`+StateLibraryVersion+`"`) {
+		t.Errorf("Cache was not rewritten canonically:\n%s", data)
+	}
+	library, err := cache.LoadStateLibrary()
+	if err != nil || len(library.States) != 1 || library.States[0].Vector[1] != complex(-0.7071067811865476, 0) || library.TotalJobs != 1 {
+		t.Errorf("Migration changed the cache: %+v (%v)", library, err)
+	}
+	if migrated, err := cache.MigrateAmplitudeEncoding(); err != nil || migrated {
+		t.Errorf("A current cache should not be rewritten: %v (%v)", migrated, err)
+	}
+}
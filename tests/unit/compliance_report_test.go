@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestGenerateComplianceReport(t *testing.T) {
+	sq := newTestProver(t, 128)
+	selfTest := sq.SelfTest()
+	if !selfTest.Passed {
+		t.Fatalf("Self-test failed: %v", selfTest.Err())
+	}
+	report, err := GenerateComplianceReport(sq, selfTest)
+	if err != nil {
+		t.Fatalf("GenerateComplianceReport failed: %v", err)
+	}
+
+	algorithms := map[string]ComplianceAlgorithm{}
+	for _, a := range report.Algorithms {
+		algorithms[a.Purpose] = a
+	}
+	if sig := algorithms["proof signature"]; sig.Algorithm != SignatureAlgorithmMLDSA87 || !sig.Approved {
+		t.Errorf("Unexpected signature algorithm: %+v", sig)
+	}
+	if xof := algorithms["challenge derivation"]; xof.Approved {
+		t.Error("BLAKE3 should not be reported as NIST-approved")
+	}
+	if report.Soundness.SoundnessBits != sq.Parameters().SoundnessBits || report.Keys[0].Bytes != len(sq.Signer.PublicKeyBytes()) {
+		t.Errorf("Report does not match the configuration: %+v", report)
+	}
+	if len(report.EntropySources) != 1 || report.EntropySources[0].Class != defaultRandomness {
+		t.Errorf("Expected the %s entropy source, got %+v", defaultRandomness, report.EntropySources)
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded ComplianceReport
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.SelfTest.Checks) != len(selfTest.Checks) {
+		t.Errorf("JSON round trip lost the self-test: %v", err)
+	}
+
+	markdown := report.Markdown()
+	for _, want := range []string{"## Algorithms", "FIPS 204", "## Entropy Sources", "## Soundness", SelfTestKnownAnswer + ": passed", "BLAKE3 XOF"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("Markdown is missing %q", want)
+		}
+	}
+}
+
+func TestComplianceReportFindings(t *testing.T) {
+	sq := newTestProver(t, 128)
+	sq.Rand = bytes.NewReader(make([]byte, 1<<10))
+	sq.IdentifierKey = []byte("short")
+	failed := &SelfTestResult{Checks: []SelfTestCheck{{Name: SelfTestEntropy, Error: "repetition count"}}}
+
+	report, err := GenerateComplianceReport(sq, failed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := strings.Join(report.Findings, "\n")
+	for _, want := range []string{"sq.Rand", "identifier disclosure key is 5 bytes", "self-test failed", "BLAKE3"} {
+		if !strings.Contains(findings, want) {
+			t.Errorf("Findings do not mention %q:\n%s", want, findings)
+		}
+	}
+	if !strings.Contains(report.Markdown(), SelfTestEntropy+": failed (repetition count)") {
+		t.Error("Markdown should list the failed check")
+	}
+
+	if report, _ := GenerateComplianceReport(newTestProver(t, 128), nil); !strings.Contains(strings.Join(report.Findings, "\n"), "no self-test") {
+		t.Error("A missing self-test should be a finding")
+	}
+	if _, err := GenerateComplianceReport(nil, nil); err == nil {
+		t.Error("A nil prover should be refused")
+	}
+}
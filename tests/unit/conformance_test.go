@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRunVerifierConformance(t *testing.T) {
+	sq := newTestProver(t, 80)
+	verifier, err := NewVerifier(sq, VerifierConfig{})
+	if err != nil {
+		t.Fatalf("NewVerifier failed: %v", err)
+	}
+	report := RunVerifierConformance(verifier)
+	if err := report.Err(); err != nil {
+		t.Fatalf("Default verifier should conform: %v", err)
+	}
+	// Every vector but the replay runs without a nonce store, after the
+	// valid control
+	if len(report.Cases) != len(ConformanceVectors()) || report.Cases[0].Name != CorpusVariantValid {
+		t.Errorf("Report should cover the valid proof and %d vectors, got %d cases", len(ConformanceVectors())-1, len(report.Cases))
+	}
+
+	var keys KeySet
+	keys.Add(sq.Signer.PublicKeyBytes(), time.Time{}, time.Time{})
+	configured, _ := NewVerifier(sq, VerifierConfig{
+		Policy:    NewPolicy(MinSoundness(80), AllowedHashSuites(HashSuiteSHA256)),
+		Keys:      &keys,
+		KeyPolicy: KeySetAllOf,
+		Options:   VerifyOptions{StrictMode: true, NonceStore: NewMemoryNonceStore()},
+	})
+	report = RunVerifierConformance(configured)
+	if err := report.Err(); err != nil {
+		t.Fatalf("Configured verifier should conform: %v", err)
+	}
+	if last := report.Cases[len(report.Cases)-1]; last.Name != ConformanceReplay || !last.Passed {
+		t.Errorf("Replay protection should be checked with a nonce store: %+v", last)
+	}
+
+	// A policy no proof of this deployment meets is a misconfiguration
+	misconfigured, _ := NewVerifier(sq, VerifierConfig{Policy: NewPolicy(RequireProfile("nonexistent"))})
+	report = RunVerifierConformance(misconfigured)
+	if report.Passed || len(report.Cases) != 1 || report.Cases[0].Name != CorpusVariantValid {
+		t.Errorf("A verifier rejecting valid proofs should fail the control case: %+v", report)
+	}
+}
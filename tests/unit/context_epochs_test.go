@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestContextEpochEncoding(t *testing.T) {
+	plain := Context{Version: 1, Application: "payments"}
+	if string(plain.Bytes()) != "qzkp-ctx/v1/payments" {
+		t.Errorf("Contexts without an epoch must keep their encoding, got %s", plain.Bytes())
+	}
+	q1 := Context{Version: 1, Application: "payments", Epoch: "2026q1"}
+	if plain.Equal(q1) || string(plain.Bytes()) == string(q1.Bytes()) {
+		t.Error("An epoch context must differ from the plain one")
+	}
+	// An application name cannot impersonate an epoch
+	lookalike := Context{Version: 1, Application: "payments/2026q1"}
+	nested := Context{Version: 1, Application: "payments/2026q1", Epoch: "x"}
+	split := Context{Version: 1, Application: "payments", Epoch: "2026q1/x"}
+	if string(lookalike.Bytes()) == string(q1.Bytes()) || string(nested.Bytes()) == string(split.Bytes()) {
+		t.Error("Context encodings collide")
+	}
+	if split.Validate() == nil || (Context{Version: 1, Application: "a", Epoch: strings.Repeat("q", 33)}).Validate() == nil {
+		t.Error("Malformed epoch names should be refused")
+	}
+}
+
+func TestEpochScheduleOverlap(t *testing.T) {
+	day := 24 * time.Hour
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	schedule, err := NewEpochSchedule("payments", 1, 14*day,
+		ContextEpoch{Name: "2026q2", Start: start.AddDate(0, 3, 0)},
+		ContextEpoch{Name: "2026q1", Start: start},
+		ContextEpoch{Name: "2026q3", Start: start.AddDate(0, 6, 0)})
+	if err != nil {
+		t.Fatalf("NewEpochSchedule failed: %v", err)
+	}
+	var transitions []string
+	schedule.OnTransition = func(tr EpochTransition) {
+		transitions = append(transitions, tr.Epoch.Epoch+" "+tr.Kind+" "+tr.At.Format("01-02"))
+	}
+
+	now := start.AddDate(0, 1, 0)
+	sq := newTestProver(t, 64)
+	sq.Epochs = schedule
+	sq.Clock = func() time.Time { return now }
+	prove := func() *SecureProof {
+		t.Helper()
+		prover, err := sq.AtCurrentEpoch()
+		if err != nil {
+			t.Fatalf("AtCurrentEpoch failed: %v", err)
+		}
+		proof, err := prover.SecureProveVectorKnowledge(testutil.RampVector(8), "epoch_proof", testutil.Key())
+		if err != nil {
+			t.Fatalf("Proving failed: %v", err)
+		}
+		return proof
+	}
+
+	q1 := prove()
+	if q1.Context.Epoch != "2026q1" {
+		t.Fatalf("Proof should record its epoch, got %s", q1.Context)
+	}
+	if !sq.VerifySecureProof(q1, testutil.Key()) {
+		t.Fatal("Proof of the current epoch should verify")
+	}
+
+	// In the overlap window after the rotation both epochs verify
+	now = start.AddDate(0, 3, 5)
+	q2 := prove()
+	cache, err := NewVerificationCache(VerificationCacheOptions{Capacity: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := NewVerifier(sq, VerifierConfig{Cache: cache})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, proof := range []*SecureProof{q1, q2} {
+		if !sq.VerifySecureProof(proof, testutil.Key()) || !verifier.Verify(proof, testutil.Key()).Valid {
+			t.Errorf("Proof of %s should verify during the overlap", proof.Context)
+		}
+	}
+
+	// Relabelling a proof's epoch breaks its bindings
+	relabelled := *q1
+	relabelled.Context = q2.Context
+	if sq.VerifySecureProof(&relabelled, testutil.Key()) {
+		t.Error("A proof moved to another epoch should not verify")
+	}
+
+	// Once the overlap closes only the current epoch verifies, cached or not
+	now = start.AddDate(0, 3, 20)
+	result := sq.VerifySecureProofVersioned(q1, testutil.Key())
+	if result.Valid || len(result.Reasons) == 0 || !strings.Contains(result.Reasons[0], "retired") {
+		t.Errorf("Retired epoch should be refused with a reason, got %+v", result)
+	}
+	if verifier.Verify(q1, testutil.Key()).Valid {
+		t.Error("A cached result should not outlive the overlap")
+	}
+	if !sq.VerifySecureProof(q2, testutil.Key()) {
+		t.Error("Current epoch should still verify")
+	}
+	if err := schedule.Accepts(Context{Version: 1, Application: "payments", Epoch: "2026q3"}, now); !errors.Is(err, ErrEpochNotAccepted) {
+		t.Errorf("A future epoch should not be accepted, got %v", err)
+	}
+
+	want := []string{"2026q1 activated 01-01", "2026q2 activated 04-01", "2026q1 retired 04-15"}
+	if strings.Join(transitions, ", ") != strings.Join(want, ", ") {
+		t.Errorf("Transitions %q, want %q", transitions, want)
+	}
+
+	if _, err := NewEpochSchedule("payments", 1, day, ContextEpoch{Name: "a", Start: start}, ContextEpoch{Name: "a", Start: start.Add(day)}); err == nil {
+		t.Error("Duplicate epochs should be refused")
+	}
+}
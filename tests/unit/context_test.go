@@ -0,0 +1,147 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestCrossContextProofRejected(t *testing.T) {
+	ctxA, err := NewContext("payments", 1)
+	if err != nil {
+		t.Fatalf("NewContext failed: %v", err)
+	}
+	ctxB, err := NewContext("identity", 1)
+	if err != nil {
+		t.Fatalf("NewContext failed: %v", err)
+	}
+
+	proverA, err := NewSecureQuantumZKPWithContext(3, 128, ctxA)
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKPWithContext failed: %v", err)
+	}
+	verifierB, err := NewSecureQuantumZKPWithContext(3, 128, ctxB)
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKPWithContext failed: %v", err)
+	}
+
+	// Share the key pair so only the context differs
+	verifierB.Signer.Pub = proverA.Signer.Pub
+	verifierB.Signer.Priv = proverA.Signer.Priv
+
+	key := testutil.Key()
+	vector := []complex128{complex(1, 0), complex(0, 0), complex(0, 0), complex(0, 0)}
+	proof, err := proverA.SecureProveVectorKnowledge(vector, "context_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+
+	if !proof.Context.Equal(ctxA) {
+		t.Errorf("Proof context = %v, want %v", proof.Context, ctxA)
+	}
+	if !proverA.VerifySecureProof(proof, key) {
+		t.Fatal("Proof should verify under its own context")
+	}
+	if verifierB.VerifySecureProof(proof, key) {
+		t.Error("Proof should not verify under a different context")
+	}
+}
+
+func TestContextIsolationMatrix(t *testing.T) {
+	contexts := []Context{
+		{Version: 1, Application: "tenant-a"},
+		{Version: 1, Application: "tenant-b"},
+		{Version: 2, Application: "tenant-a"},
+	}
+	keys := [][]byte{testutil.Key(), []byte("second-tenant-shared-secret-key!")}
+	profiles := []string{"", "standard"}
+
+	// Every prover and verifier shares one key pair so only the context
+	// can tell them apart
+	signer, err := NewSignatureScheme(nil)
+	if err != nil {
+		t.Fatalf("NewSignatureScheme failed: %v", err)
+	}
+	instances := make([]*SecureQuantumZKP, len(contexts))
+	for i, c := range contexts {
+		sq, err := NewSecureQuantumZKPWithContext(3, 128, c)
+		if err != nil {
+			t.Fatalf("NewSecureQuantumZKPWithContext(%v) failed: %v", c, err)
+		}
+		sq.Signer.Pub, sq.Signer.Priv = signer.Pub, signer.Priv
+		instances[i] = sq
+	}
+
+	vector := testutil.RampVector(8)
+	for p, prover := range instances {
+		for _, key := range keys {
+			for _, profile := range profiles {
+				prover.Profile = profile
+				proof, err := prover.SecureProveVectorKnowledge(vector, "isolation_test", key)
+				if err != nil {
+					t.Fatalf("SecureProveVectorKnowledge under %v failed: %v", prover.Context, err)
+				}
+				if proof.KeyDerivation != KeyDerivationHKDFContext {
+					t.Errorf("Proof under %v does not record the context key derivation", prover.Context)
+				}
+				for v, verifier := range instances {
+					if got := verifier.VerifySecureProof(proof, key); got != (p == v) {
+						t.Errorf("Proof under %v (profile %q) verifies under %v: %v", prover.Context, profile, verifier.Context, got)
+					}
+					// Relabelling the proof does not move it either
+					relabelled := *proof
+					relabelled.Context = verifier.Context
+					if p != v && verifier.VerifySecureProof(&relabelled, key) {
+						t.Errorf("Relabelled proof from %v verifies under %v", prover.Context, verifier.Context)
+					}
+				}
+			}
+		}
+	}
+
+	// The same key yields unrelated prover secrets per context
+	for _, key := range keys {
+		seen := map[string]Context{}
+		for _, sq := range instances {
+			derived, err := sq.contextKey(key)
+			if err != nil {
+				t.Fatalf("contextKey failed: %v", err)
+			}
+			if other, ok := seen[string(derived)]; ok {
+				t.Errorf("Contexts %v and %v derive the same secret", other, sq.Context)
+			}
+			seen[string(derived)] = sq.Context
+		}
+	}
+}
+
+func TestContextRegistry(t *testing.T) {
+	registry := NewContextRegistry()
+
+	v1, _ := NewContext("archive", 1)
+	v2, _ := NewContext("archive", 2)
+	if err := registry.Register(v1); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := registry.Register(v1); err != nil {
+		t.Errorf("Re-registering an identical context should succeed: %v", err)
+	}
+	if err := registry.Register(v2); err == nil {
+		t.Error("Expected an error when registering a second version for the same application")
+	}
+
+	prover, err := registry.NewProver("archive", 3, 128)
+	if err != nil {
+		t.Fatalf("NewProver failed: %v", err)
+	}
+	if !prover.Context.Equal(v1) {
+		t.Errorf("Prover context = %v, want %v", prover.Context, v1)
+	}
+	if _, err := registry.NewProver("unknown", 3, 128); err == nil {
+		t.Error("Expected an error for an unregistered application")
+	}
+
+	if _, err := NewContext("archive", 0); err == nil {
+		t.Error("Expected an error for a non-positive context version")
+	}
+}
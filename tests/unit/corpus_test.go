@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func smallCorpusOptions() CorpusOptions {
+	opts := DefaultCorpusOptions(bytes.Repeat([]byte{7}, 32))
+	opts.SoundnessLevels = []int{32}
+	opts.Codecs = []string{"json", "base64+gzip+json"}
+	opts.Profiles = []string{"", "interop"}
+	return opts
+}
+
+func TestGenerateCorpusLabels(t *testing.T) {
+	dir := t.TempDir()
+	opts := smallCorpusOptions()
+	manifest, err := GenerateCorpus(dir, opts)
+	if err != nil {
+		t.Fatalf("GenerateCorpus failed: %v", err)
+	}
+	want := len(opts.Profiles) * 2 * (1 + len(CorpusTampers)) * len(opts.Codecs)
+	if len(manifest.Entries) != want {
+		t.Fatalf("Expected %d entries, got %d", want, len(manifest.Entries))
+	}
+
+	// Check every label with a verifier built only from the manifest
+	raw, err := os.ReadFile(filepath.Join(dir, CorpusManifestFile))
+	if err != nil {
+		t.Fatalf("Manifest not written: %v", err)
+	}
+	var m CorpusManifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		t.Fatalf("Manifest is not valid JSON: %v", err)
+	}
+	publicKey, _ := hex.DecodeString(m.PublicKey)
+	key, _ := hex.DecodeString(m.Key)
+	verifier, err := NewSecureQuantumZKPWithSoundness(3, 128, 32, []byte(opts.Context))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKPWithSoundness failed: %v", err)
+	}
+	if verifier.Signer, err = NewVerificationScheme(publicKey, []byte(opts.Context)); err != nil {
+		t.Fatalf("Manifest public key is invalid: %v", err)
+	}
+
+	for _, entry := range m.Entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.File))
+		if err != nil {
+			t.Fatalf("%s: %v", entry.File, err)
+		}
+		if d := DiagnoseProof(data); d.Codec != entry.Codec {
+			t.Errorf("%s: detected codec %q, labeled %q", entry.File, d.Codec, entry.Codec)
+		}
+		payload, _, err := detectProofCodec(data)
+		if err != nil {
+			t.Fatalf("%s: %v", entry.File, err)
+		}
+		var proof SecureProof
+		if err := json.Unmarshal(payload, &proof); err != nil {
+			t.Fatalf("%s: %v", entry.File, err)
+		}
+		if proof.Profile != entry.Profile {
+			t.Errorf("%s: profile %q, labeled %q", entry.File, proof.Profile, entry.Profile)
+		}
+		if got := verifier.VerifySecureProofVersioned(&proof, key).Valid; got != entry.Valid {
+			t.Errorf("%s: verified %v, labeled %v", entry.File, got, entry.Valid)
+		}
+	}
+}
+
+func TestGenerateCorpusDeterministic(t *testing.T) {
+	opts := smallCorpusOptions()
+	opts.Profiles = []string{""}
+	opts.Tampers = nil
+	a, b := t.TempDir(), t.TempDir()
+	ma, err := GenerateCorpus(a, opts)
+	if err != nil {
+		t.Fatalf("GenerateCorpus failed: %v", err)
+	}
+	mb, err := GenerateCorpus(b, opts)
+	if err != nil {
+		t.Fatalf("GenerateCorpus failed: %v", err)
+	}
+
+	for i := range ma.Entries {
+		same := ma.Entries[i].SHA256 == mb.Entries[i].SHA256
+		if same != ma.Entries[i].Deterministic {
+			t.Errorf("%s: identical across runs = %v, deterministic = %v",
+				ma.Entries[i].File, same, ma.Entries[i].Deterministic)
+		}
+	}
+	if ma.PublicKey != mb.PublicKey || ma.Key != mb.Key {
+		t.Error("Keys should be derived from the seed")
+	}
+}
+
+func TestGenerateCorpusRejectsBadOptions(t *testing.T) {
+	opts := smallCorpusOptions()
+	opts.Seed = []byte("short")
+	if _, err := GenerateCorpus(t.TempDir(), opts); err == nil {
+		t.Error("Short seeds should be rejected")
+	}
+	opts = smallCorpusOptions()
+	opts.Codecs = []string{"zip"}
+	if _, err := GenerateCorpus(t.TempDir(), opts); err == nil {
+		t.Error("Unknown codecs should be rejected")
+	}
+	opts = smallCorpusOptions()
+	opts.Tampers = []string{"everything"}
+	if _, err := GenerateCorpus(t.TempDir(), opts); err == nil {
+		t.Error("Unknown tamper kinds should be rejected")
+	}
+}
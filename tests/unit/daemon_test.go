@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeDaemonConfig writes a daemon config file and returns its path.
+func writeDaemonConfig(t *testing.T, path string, cfg DaemonConfig) {
+	t.Helper()
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Failed to encode config: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+}
+
+func TestDaemonProveVerifyReloadDrain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "qzkpd.json")
+	cfg := DaemonConfig{
+		ServiceConfig: ServiceConfig{
+			Dimensions:    3,
+			SecurityLevel: 128,
+			Application:   "daemon-test",
+			Key:           "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		},
+		Workers:   2,
+		QueueSize: 4,
+	}
+	writeDaemonConfig(t, path, cfg)
+
+	d, err := NewDaemon(path)
+	if err != nil {
+		t.Fatalf("NewDaemon failed: %v", err)
+	}
+	server := httptest.NewServer(d.Handler())
+	defer server.Close()
+
+	post := func(endpoint string, body interface{}) *http.Response {
+		data, _ := json.Marshal(body)
+		resp, err := http.Post(server.URL+endpoint, "application/json", bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("POST %s failed: %v", endpoint, err)
+		}
+		return resp
+	}
+
+	resp, err := http.Get(server.URL + "/readyz")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Daemon should be ready: %v", err)
+	}
+
+	resp = post("/v1/prove", ProveRequest{Identifier: "daemon_test", Data: []byte("secret payload")})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Prove returned %s", resp.Status)
+	}
+	var proved ProveResponse
+	if err := json.NewDecoder(resp.Body).Decode(&proved); err != nil {
+		t.Fatalf("Failed to decode proof: %v", err)
+	}
+	resp.Body.Close()
+
+	// Reloading with an unchanged context keeps earlier proofs verifiable
+	if err := d.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	resp = post("/v1/verify", VerifyRequest{Proof: proved.Proof})
+	var verified VerifyResponse
+	json.NewDecoder(resp.Body).Decode(&verified)
+	resp.Body.Close()
+	if !verified.Valid {
+		t.Error("Proof should verify after a reload")
+	}
+
+	// A broken config is rejected and the running service kept
+	os.WriteFile(path, []byte("{"), 0600)
+	if err := d.Reload(); err == nil {
+		t.Error("Reload with an invalid config should fail")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	resp, _ = http.Get(server.URL + "/readyz")
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Draining daemon should not be ready, got %s", resp.Status)
+	}
+	resp = post("/v1/prove", ProveRequest{Identifier: "daemon_test", Data: []byte("late")})
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Work after shutdown should be rejected, got %s", resp.Status)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestDefaultDataDirsHonorsEnvironment(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv(DataDirEnv, base)
+	t.Setenv(CacheDirEnv, "")
+
+	dirs, err := DefaultDataDirs()
+	if err != nil {
+		t.Fatalf("DefaultDataDirs failed: %v", err)
+	}
+	if dirs.Data != base || dirs.Cache != filepath.Join(base, "cache") {
+		t.Errorf("Unexpected directories: %+v", dirs)
+	}
+}
+
+func TestQuantumStateCacheMigratesLegacyFile(t *testing.T) {
+	work := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(work); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	legacy := []byte(`{"states":[{"name":"bell","qubits":2}],"version":"1.0"}`)
+	if err := os.WriteFile(QuantumStateCacheFile, legacy, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dirs := NewDataDirs(filepath.Join(work, "data"))
+	cache, err := NewQuantumStateCacheIn(dirs)
+	if err != nil {
+		t.Fatalf("NewQuantumStateCacheIn failed: %v", err)
+	}
+	if cache.FilePath != dirs.CachePath(QuantumStateCacheFile) {
+		t.Errorf("Cache should live in the cache directory, got %s", cache.FilePath)
+	}
+	if _, err := os.Stat(QuantumStateCacheFile); !os.IsNotExist(err) {
+		t.Error("Legacy cache file should have been moved")
+	}
+
+	states, err := cache.GetStatesByQubits(2)
+	if err != nil || len(states) != 1 || states[0].Name != "bell" {
+		t.Errorf("Migrated cache not readable: %v %v", states, err)
+	}
+}
+
+func TestKeystoreAndProofStore(t *testing.T) {
+	dirs := NewDataDirs(t.TempDir())
+	key := testutil.Key()
+
+	ks, err := NewKeystore(dirs)
+	if err != nil {
+		t.Fatalf("NewKeystore failed: %v", err)
+	}
+	if err := ks.Put("signing", key); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	got, err := ks.Get("signing")
+	if err != nil || string(got) != string(key) {
+		t.Errorf("Get returned %q, %v", got, err)
+	}
+	if err := ks.Put("../escape", key); err == nil {
+		t.Error("Expected names with path separators to be rejected")
+	}
+
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("store-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	proof, err := sq.SecureProveVectorKnowledge([]complex128{complex(1, 0), complex(0, 0)}, "store_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+
+	ps, err := NewProofStore(dirs)
+	if err != nil {
+		t.Fatalf("NewProofStore failed: %v", err)
+	}
+	if err := ps.Save("first", proof); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := ps.Load("first")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !sq.VerifySecureProof(loaded, key) {
+		t.Error("Stored proof should still verify")
+	}
+	if names, _ := ps.List(); len(names) != 1 || names[0] != "first" {
+		t.Errorf("Unexpected proof list: %v", names)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestDatasetEntryProofs(t *testing.T) {
+	dataset, err := NewDataset(map[string][]byte{
+		"alice":   []byte("balance=100"),
+		"bob":     []byte("balance=250"),
+		"charlie": []byte("balance=7"),
+	})
+	if err != nil {
+		t.Fatalf("NewDataset failed: %v", err)
+	}
+	commitment := dataset.Commitment()
+	if commitment.Size != 3 {
+		t.Errorf("Expected dataset size 3, got %d", commitment.Size)
+	}
+
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("dataset-context"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	key := testutil.Key()
+
+	// Knowledge of an entry without disclosure
+	proof, err := sq.ProveEntryKnowledge(dataset, "bob", key)
+	if err != nil {
+		t.Fatalf("ProveEntryKnowledge failed: %v", err)
+	}
+	if !sq.VerifyEntryKnowledge(commitment, proof, key) {
+		t.Fatal("Entry knowledge proof verification failed")
+	}
+	proofJSON, _ := json.Marshal(proof)
+	if strings.Contains(string(proofJSON), "balance=250") {
+		t.Error("Entry knowledge proof leaks the entry value")
+	}
+
+	// The same proof must not be reusable for another key
+	proof.EntryKey = "alice"
+	if sq.VerifyEntryKnowledge(commitment, proof, key) {
+		t.Error("Entry knowledge proof should not verify for a different key")
+	}
+
+	// Selective disclosure of every entry
+	for _, entryKey := range []string{"alice", "bob", "charlie"} {
+		disclosure, err := dataset.ProveEntryValue(entryKey)
+		if err != nil {
+			t.Fatalf("ProveEntryValue(%s) failed: %v", entryKey, err)
+		}
+		if !VerifyEntryValue(commitment, disclosure) {
+			t.Errorf("Disclosure for %s failed to verify", entryKey)
+		}
+
+		disclosure.Value = []byte("balance=999999")
+		if VerifyEntryValue(commitment, disclosure) {
+			t.Errorf("Tampered disclosure for %s should not verify", entryKey)
+		}
+	}
+
+	if _, err := dataset.ProveEntryValue("mallory"); err == nil {
+		t.Error("Expected an error for an entry outside the dataset")
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// librarySourceDirs are the directories compiled into the library, relative
+// to this test directory.
+var librarySourceDirs = []string{
+	"../../src/quantum",
+	"../../src/classical",
+	"../../src/security",
+}
+
+// verifyDependencyBudget is every non-stdlib module a verify-only build may
+// import: ML-DSA signatures and the BLAKE3 hash suite.
+var verifyDependencyBudget = []string{
+	"github.com/cloudflare/circl",
+	"lukechampine.com/blake3",
+}
+
+// fullDependencyBudget adds the optional dependencies of the default build.
+var fullDependencyBudget = append([]string{"go.dedis.ch/kyber/v3"}, verifyDependencyBudget...)
+
+// libraryImports returns the non-stdlib imports of the library files
+// selected by tags, mapped to the files that import them.
+func libraryImports(t *testing.T, tags ...string) map[string][]string {
+	t.Helper()
+	ctx := build.Default
+	ctx.BuildTags = tags
+
+	imports := make(map[string][]string)
+	for _, dir := range librarySourceDirs {
+		if _, err := os.Stat(dir); err != nil {
+			t.Skipf("library sources not found at %s", dir)
+		}
+		files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range files {
+			if strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+			match, err := ctx.MatchFile(filepath.Dir(file), filepath.Base(file))
+			if err != nil {
+				t.Fatalf("%s: %v", file, err)
+			}
+			if !match {
+				continue
+			}
+			f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ImportsOnly)
+			if err != nil {
+				t.Fatalf("%s: %v", file, err)
+			}
+			for _, spec := range f.Imports {
+				path, _ := strconv.Unquote(spec.Path.Value)
+				if isStdlibImport(path) {
+					continue
+				}
+				imports[path] = append(imports[path], filepath.Base(file))
+			}
+		}
+	}
+	return imports
+}
+
+// isStdlibImport reports whether path belongs to the standard library, whose
+// first path element never contains a dot.
+func isStdlibImport(path string) bool {
+	return !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
+
+// checkDependencyBudget fails for every import outside budget.
+func checkDependencyBudget(t *testing.T, imports map[string][]string, budget []string) {
+	t.Helper()
+	paths := make([]string, 0, len(imports))
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		allowed := false
+		for _, module := range budget {
+			if path == module || strings.HasPrefix(path, module+"/") {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			t.Errorf("%s is outside the dependency budget (imported by %v)", path, imports[path])
+		}
+	}
+}
+
+func TestVerifyBuildDependencyBudget(t *testing.T) {
+	imports := libraryImports(t, "qzkp_verify")
+	checkDependencyBudget(t, imports, verifyDependencyBudget)
+
+	if _, ok := imports["go.dedis.ch/kyber/v3"]; ok {
+		t.Error("verify-only build should not import kyber")
+	}
+}
+
+func TestDefaultBuildDependencyBudget(t *testing.T) {
+	checkDependencyBudget(t, libraryImports(t), fullDependencyBudget)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestConfigurableDigestLengths(t *testing.T) {
+	key := testutil.Key()
+	vector := []complex128{complex(0.6, 0), complex(0, 0.8)}
+
+	sq, err := NewSecureQuantumZKPWithSoundness(3, 256, 128, []byte("digest-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKPWithSoundness failed: %v", err)
+	}
+	if sq.DigestLengths != (DigestLengths{Commitment: FullDigestLength, Response: FullDigestLength}) {
+		t.Fatalf("128-bit soundness should use full digests, got %+v", sq.DigestLengths)
+	}
+
+	proof, err := sq.SecureProveVectorKnowledge(vector, "digest_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if proof.DigestLengths == nil || *proof.DigestLengths != sq.DigestLengths {
+		t.Fatalf("Proof should record its digest lengths, got %+v", proof.DigestLengths)
+	}
+	if len(proof.CommitmentHash) != 2*FullDigestLength || len(proof.ChallengeResponse[0].Response) != 2*FullDigestLength {
+		t.Error("Digests should not be truncated")
+	}
+	if !sq.VerifySecureProof(proof, key) {
+		t.Fatal("Full-length proof should verify")
+	}
+
+	// A verifier requiring full digests rejects a truncated proof even when
+	// the prover signed it honestly
+	weak := *sq
+	weak.DigestLengths = LegacyDigestLengths
+	weakProof, err := weak.SecureProveVectorKnowledge(vector, "digest_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if !weak.VerifySecureProof(weakProof, key) {
+		t.Error("Truncated proof should verify under its own parameters")
+	}
+	if sq.VerifySecureProof(weakProof, key) {
+		t.Error("Truncated proof should not satisfy a full-length verifier")
+	}
+
+	if err := (DigestLengths{Commitment: 4, Response: 32}).Validate(); err == nil {
+		t.Error("Expected lengths below the minimum to be rejected")
+	}
+	if got := DigestLengthsFor(80); got.Commitment != 20 || got.Response != 20 {
+		t.Errorf("80-bit soundness should use 20-byte digests, got %+v", got)
+	}
+	if got := DigestLengthsFor(32); got.Response != 16 {
+		t.Errorf("Low soundness should still use 16-byte digests, got %+v", got)
+	}
+}
+
+func TestMinDigestLengthsRule(t *testing.T) {
+	rule := MinDigestLengths(DigestLengths{Commitment: FullDigestLength, Response: FullDigestLength})
+	full := &SecureProof{DigestLengths: &DigestLengths{Commitment: 32, Response: 32}}
+	if err := rule.Check(full); err != nil {
+		t.Errorf("Full-length proof should pass: %v", err)
+	}
+	if err := rule.Check(&SecureProof{}); err == nil {
+		t.Error("Proof without recorded lengths should be judged by legacy lengths")
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+// embeddedFixture returns a proof made under the admission context, its
+// signer's public key and a policy matching the prover.
+func embeddedFixture(t *testing.T) (proof, pubKey []byte, policy EmbeddedPolicy) {
+	t.Helper()
+	c := Context{Version: 1, Application: "admission"}
+	sq, err := NewSecureQuantumZKPWithContext(3, 128, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sq.Profile = "workload"
+	made, err := sq.SecureProveVectorKnowledge(testutil.RampVector(8), "pod-42", testutil.Key())
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if proof, err = json.Marshal(made); err != nil {
+		t.Fatal(err)
+	}
+	policy = EmbeddedPolicy{
+		Dimensions:     3,
+		SecurityLevel:  128,
+		Application:    "admission",
+		ContextVersion: 1,
+		Profile:        "workload",
+		StrictMode:     true,
+		MaxAge:         "1h",
+		Now:            made.Timestamp.Add(time.Minute),
+	}
+	return proof, sq.Signer.PublicKeyBytes(), policy
+}
+
+func encodePolicy(t *testing.T, policy EmbeddedPolicy) []byte {
+	t.Helper()
+	data, err := json.Marshal(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestVerifyBytesAcceptsAndExplainsRejections(t *testing.T) {
+	proof, pubKey, policy := embeddedFixture(t)
+	other, err := NewSignatureScheme(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, reason := VerifyBytes(proof, pubKey, encodePolicy(t, policy)); !ok || reason != ReasonOK {
+		t.Fatalf("Valid proof: %v, %s", ok, reason)
+	}
+
+	// A misspelled rule fails closed
+	misspelled := []byte(`{"dimensions": 3, "application": "admission", "context_version": 1, "min_soundnes": 80}`)
+	if _, reason := VerifyBytes(proof, pubKey, misspelled); reason != ReasonInvalidPolicy {
+		t.Errorf("Misspelled policy: %s", reason)
+	}
+
+	tampered := []byte(strings.Replace(string(proof), `"pod-42"`, `"pod-43"`, 1))
+	cases := []struct {
+		name   string
+		proof  []byte
+		key    []byte
+		policy func(*EmbeddedPolicy)
+		want   ReasonCode
+	}{
+		{"max_age without now", proof, pubKey, func(p *EmbeddedPolicy) { p.Now = time.Time{} }, ReasonInvalidPolicy},
+		{"oversized proof", proof, pubKey, func(p *EmbeddedPolicy) { p.MaxProofBytes = 1024 }, ReasonResourceLimit},
+		{"bad public key", proof, []byte("not a key"), nil, ReasonInvalidKey},
+		{"garbage proof", []byte(`{"version": 3}`), pubKey, nil, ReasonMalformedProof},
+		{"other application", proof, pubKey, func(p *EmbeddedPolicy) { p.Application = "billing" }, ReasonWrongContext},
+		{"stale proof", proof, pubKey, func(p *EmbeddedPolicy) { p.Now = p.Now.Add(2 * time.Hour) }, ReasonExpired},
+		{"higher soundness required", proof, pubKey, func(p *EmbeddedPolicy) { p.MinSoundness = 128 }, ReasonPolicyViolation},
+		{"other profile", proof, pubKey, func(p *EmbeddedPolicy) { p.Profile = "batch" }, ReasonPolicyViolation},
+		{"other signer", proof, other.PublicKeyBytes(), nil, ReasonInvalidProof},
+		{"tampered proof", tampered, pubKey, nil, ReasonInvalidProof},
+	}
+	for _, tc := range cases {
+		p := policy
+		if tc.policy != nil {
+			tc.policy(&p)
+		}
+		result := VerifyBytesDetailed(tc.proof, tc.key, encodePolicy(t, p))
+		if result.Valid || result.Reason != tc.want || result.Detail == "" {
+			t.Errorf("%s: %+v, expected %s", tc.name, result, tc.want)
+		}
+	}
+}
+
+func TestVerifyBytesWithoutNow(t *testing.T) {
+	proof, pubKey, policy := embeddedFixture(t)
+	policy.MaxAge = ""
+	policy.Now = time.Time{}
+	if ok, reason := VerifyBytes(proof, pubKey, encodePolicy(t, policy)); !ok || reason != ReasonOK {
+		t.Errorf("Valid proof under a policy without now: %v, %s", ok, reason)
+	}
+}
+
+func TestVerifyBytesIsPureAndDeterministic(t *testing.T) {
+	proof, pubKey, policy := embeddedFixture(t)
+	policyJSON := encodePolicy(t, policy)
+
+	host := restrictHost(t)
+	first := VerifyBytesDetailed(proof, pubKey, policyJSON)
+	second := VerifyBytesDetailed(proof, pubKey, policyJSON)
+	if accesses := host.Accesses(); len(accesses) > 0 {
+		t.Errorf("VerifyBytes reached the host: %v", accesses)
+	}
+	if !first.Valid || first.Cost != second.Cost {
+		t.Fatalf("Results differ: %+v, %+v", first, second)
+	}
+	cost := first.Cost
+	if cost.ProofBytes != len(proof) || cost.Challenges == 0 || cost.Hashes != 3*cost.Challenges-1 || cost.SignatureChecks != 1 {
+		t.Errorf("Unexpected cost %+v", cost)
+	}
+}
+
+func TestEmbeddedVerifierTemplateFuncs(t *testing.T) {
+	proof, pubKey, policy := embeddedFixture(t)
+	tmpl := template.Must(template.New("gate").Funcs(EmbeddedVerifierFuncs()).Parse(
+		`{{ if qzkpVerify .Proof .Key .Policy }}allow{{ else }}deny: {{ qzkpVerifyReason .Proof .Key .Policy }}{{ end }}`))
+
+	render := func(proof, key string) string {
+		var out strings.Builder
+		data := map[string]string{"Proof": proof, "Key": key, "Policy": string(encodePolicy(t, policy))}
+		if err := tmpl.Execute(&out, data); err != nil {
+			t.Fatal(err)
+		}
+		return out.String()
+	}
+	if got := render(string(proof), hex.EncodeToString(pubKey)); got != "allow" {
+		t.Errorf("Valid proof rendered %q", got)
+	}
+	if got := render(string(proof), "zz"); got != "deny: invalid_public_key" {
+		t.Errorf("Bad key rendered %q", got)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// Three-qubit states with known reduced spectra
+var (
+	entropyBell = []complex128{complex(1/math.Sqrt2, 0), 0, 0, complex(1/math.Sqrt2, 0)}
+	entropyGHZ  = []complex128{complex(1/math.Sqrt2, 0), 0, 0, 0, 0, 0, 0, complex(1/math.Sqrt2, 0)}
+	entropyW    = []complex128{0, complex(1/math.Sqrt(3), 0), complex(1/math.Sqrt(3), 0), 0, complex(1/math.Sqrt(3), 0), 0, 0, 0}
+)
+
+func TestEntanglementEntropyAnalytic(t *testing.T) {
+	// Tracing out two qubits of W leaves diag(2/3, 1/3)
+	wSingle := math.Log2(3) - 2.0/3
+	cases := []struct {
+		name   string
+		state  []complex128
+		qubits int
+		alpha  float64
+		want   float64
+	}{
+		{"Bell", entropyBell, 1, EntropyOrderShannon, 1},
+		{"Bell collision", entropyBell, 1, EntropyOrderCollision, 1},
+		{"GHZ 1|2", entropyGHZ, 1, EntropyOrderShannon, 1},
+		{"GHZ 2|1", entropyGHZ, 2, EntropyOrderShannon, 1},
+		{"W 1|2", entropyW, 1, EntropyOrderShannon, wSingle},
+		{"W 2|1", entropyW, 2, EntropyOrderShannon, wSingle},
+		{"W collision", entropyW, 1, EntropyOrderCollision, -math.Log2(5.0 / 9)},
+		{"W min", entropyW, 1, EntropyOrderMin, -math.Log2(2.0 / 3)},
+		{"product", Tensor([]complex128{1, 0}, []complex128{0, 1}), 1, EntropyOrderShannon, 0},
+	}
+	for _, c := range cases {
+		got, err := EntanglementSpectralEntropy(c.state, c.qubits, c.alpha)
+		if err != nil {
+			t.Fatalf("%s: EntanglementSpectralEntropy failed: %v", c.name, err)
+		}
+		if math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("%s: entropy = %.12f, want %.12f", c.name, got, c.want)
+		}
+	}
+
+	if _, err := EntanglementEntropy([]complex128{1, 0, 0}, 1); err == nil {
+		t.Error("Expected an error for a dimension that is not a power of two")
+	}
+	if h, err := BipartiteEntanglementEntropy([]complex128{1, 0, 0}); err != nil || h != 0 {
+		t.Errorf("States without qubit structure are unentangled, got %f, %v", h, err)
+	}
+}
+
+func TestVonNeumannEntropy(t *testing.T) {
+	// Pure states have zero entropy whatever their basis distribution
+	for name, state := range map[string][]complex128{"Bell": entropyBell, "GHZ": entropyGHZ, "W": entropyW} {
+		h, err := VonNeumannEntropy(DensityMatrix(state))
+		if err != nil {
+			t.Fatalf("%s: VonNeumannEntropy failed: %v", name, err)
+		}
+		if math.Abs(h) > 1e-9 {
+			t.Errorf("%s: pure state entropy = %g, want 0", name, h)
+		}
+	}
+
+	// Off-diagonal phases must be diagonalized, not ignored: this is |+i⟩⟨+i|
+	plusI := [][]complex128{{0.5, complex(0, -0.5)}, {complex(0, 0.5), 0.5}}
+	if h, err := VonNeumannEntropy(plusI); err != nil || math.Abs(h) > 1e-9 {
+		t.Errorf("|+i⟩ entropy = %g, %v, want 0", h, err)
+	}
+
+	reduced, err := PartialTrace(DensityMatrix(entropyGHZ), 2)
+	if err != nil {
+		t.Fatalf("PartialTrace failed: %v", err)
+	}
+	if h, err := VonNeumannEntropy(reduced); err != nil || math.Abs(h-1) > 1e-9 {
+		t.Errorf("GHZ with one qubit traced out should have 1 bit, got %g, %v", h, err)
+	}
+
+	if _, err := VonNeumannEntropy([][]complex128{{0.5, 1}, {0, 0.5}}); err == nil {
+		t.Error("Expected an error for a non-Hermitian matrix")
+	}
+	if _, err := VonNeumannEntropy([][]complex128{{1.5, 0}, {0, -0.5}}); err == nil {
+		t.Error("Expected an error for a negative eigenvalue")
+	}
+}
+
+func TestProbabilityEntropy(t *testing.T) {
+	cases := []struct {
+		name  string
+		state []complex128
+		want  float64
+	}{
+		{"Bell", entropyBell, 1},
+		{"GHZ", entropyGHZ, 1},
+		{"W", entropyW, math.Log2(3)},
+		// Unnormalized amplitudes are normalized first
+		{"scaled", []complex128{3, 4i}, -(0.36*math.Log2(0.36) + 0.64*math.Log2(0.64))},
+	}
+	for _, c := range cases {
+		got, err := ProbabilityEntropy(c.state)
+		if err != nil {
+			t.Fatalf("%s: ProbabilityEntropy failed: %v", c.name, err)
+		}
+		if math.Abs(got-c.want) > 1e-12 {
+			t.Errorf("%s: entropy = %.12f, want %.12f", c.name, got, c.want)
+		}
+	}
+	if h := CalculateEntropy([]complex128{3, 4i}); math.Abs(h-cases[3].want) > 1e-12 {
+		t.Errorf("CalculateEntropy should normalize, got %f", h)
+	}
+
+	probs := []float64{0.5, 0.25, 0.25}
+	for _, c := range []struct {
+		alpha, want float64
+	}{
+		{EntropyOrderHartley, math.Log2(3)},
+		{EntropyOrderShannon, 1.5},
+		{EntropyOrderCollision, -math.Log2(0.375)},
+		{EntropyOrderMin, 1},
+	} {
+		if got, err := RenyiEntropy(probs, c.alpha); err != nil || math.Abs(got-c.want) > 1e-12 {
+			t.Errorf("Rényi order %v = %f, %v, want %f", c.alpha, got, err, c.want)
+		}
+	}
+	if _, err := RenyiEntropy(probs, -1); err == nil {
+		t.Error("Expected an error for a negative order")
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStateEstimatesAreExact(t *testing.T) {
+	plus := []complex128{complex(1/math.Sqrt2, 0), complex(1/math.Sqrt2, 0)}
+
+	h, err := StateEntropy(plus)
+	if err != nil {
+		t.Fatalf("StateEntropy failed: %v", err)
+	}
+	if math.Abs(h.Value-1) > 1e-12 || h.Lower != h.Upper || h.Method != EstimateMethodExact {
+		t.Errorf("Unexpected entropy estimate: %+v", h)
+	}
+
+	c, err := StateCoherence(plus)
+	if err != nil {
+		t.Fatalf("StateCoherence failed: %v", err)
+	}
+	if math.Abs(c.Value-1) > 1e-12 {
+		t.Errorf("|+> should have l1-coherence 1, got %f", c.Value)
+	}
+
+	if _, err := StateEntropy(nil); err == nil {
+		t.Error("Expected an error for an empty state")
+	}
+}
+
+func TestEstimateEntropyFromCounts(t *testing.T) {
+	result := &ExecutionResult{Counts: map[string]int{"00": 2480, "01": 2530, "10": 2470, "11": 2520}}
+
+	h, err := EstimateEntropy(result, 0.95)
+	if err != nil {
+		t.Fatalf("EstimateEntropy failed: %v", err)
+	}
+	if h.Lower > h.Value || h.Value > h.Upper || h.Upper > 2 {
+		t.Errorf("Interval malformed: %+v", h)
+	}
+	if h.Upper < 1.99 {
+		t.Errorf("Uniform 2-qubit data should be near 2 bits: %+v", h)
+	}
+
+	// The interval is reproducible and narrows with more shots
+	again, _ := EstimateEntropy(result, 0.95)
+	if *again != *h {
+		t.Error("Estimates for identical counts should be identical")
+	}
+	small := &ExecutionResult{Counts: map[string]int{"00": 24, "01": 25, "10": 25, "11": 26}}
+	hs, _ := EstimateEntropy(small, 0.95)
+	if hs.Upper-hs.Lower <= h.Upper-h.Lower {
+		t.Errorf("Fewer shots should give a wider interval: %+v vs %+v", hs, h)
+	}
+
+	if _, err := EstimateEntropy(result, 1.5); err == nil {
+		t.Error("Expected an error for an invalid confidence level")
+	}
+}
+
+func TestMetadataClaimsFromEstimates(t *testing.T) {
+	bell := &ExecutionResult{Counts: map[string]int{"00": 4100, "11": 3900}}
+	entropy, err := EstimateEntropy(bell, 0.95)
+	if err != nil {
+		t.Fatalf("EstimateEntropy failed: %v", err)
+	}
+	coherence, err := EstimateCoherenceBound(bell, 0.95)
+	if err != nil {
+		t.Fatalf("EstimateCoherenceBound failed: %v", err)
+	}
+	if math.Abs(coherence.Value-1) > 0.01 {
+		t.Errorf("Bell populations bound l1-coherence near 1, got %f", coherence.Value)
+	}
+
+	metadata, err := TightenedMetadata(4, 128, entropy, coherence)
+	if err != nil {
+		t.Fatalf("TightenedMetadata failed: %v", err)
+	}
+	if metadata.EntropyBound >= 2 || metadata.CoherenceBound >= 4 {
+		t.Errorf("Bounds should be tighter than the maxima: %+v", metadata)
+	}
+
+	sq, _ := NewSecureQuantumZKP(3, 128, []byte("estimation-test"))
+	if !sq.verifyMetadataBounds(metadata) {
+		t.Error("Tightened metadata should satisfy verifier bounds")
+	}
+	if !CheckMetadataClaims(metadata, entropy, coherence).Consistent() {
+		t.Error("Claims built from the estimates should be consistent with them")
+	}
+
+	// A claim of a nearly pure computational basis state is refuted
+	metadata.EntropyBound = 0.1
+	if CheckMetadataClaims(metadata, entropy, coherence).EntropyConsistent {
+		t.Error("Entropy claim below the estimate interval should be refuted")
+	}
+}
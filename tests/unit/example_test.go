@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// The examples below are the runnable tutorials: go test checks their
+// output, so they stay correct as the API evolves.
+
+func ExampleSecureQuantumZKP_SecureProveFromBytes() {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("tutorial"))
+	if err != nil {
+		panic(err)
+	}
+	key := []byte("32-byte-authentication-key-here!")
+
+	// Prove knowledge of a document without revealing it
+	document := []byte("quarterly report, final version")
+	proof, err := sq.SecureProveFromBytes(document, "report-q3", key)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("identifier:", proof.Identifier)
+	fmt.Println("valid:", sq.VerifySecureProof(proof, key))
+
+	// Any change to the proof breaks it
+	proof.MerkleRoot = proof.CommitmentHash
+	fmt.Println("tampered valid:", sq.VerifySecureProof(proof, key))
+	// Output:
+	// identifier: report-q3
+	// valid: true
+	// tampered valid: false
+}
+
+func ExampleVerifier_policy() {
+	prover, err := NewSecureQuantumZKPWithSoundness(3, 128, 64, []byte("tutorial"))
+	if err != nil {
+		panic(err)
+	}
+	key := []byte("32-byte-authentication-key-here!")
+	proof, err := prover.SecureProveVectorKnowledge([]complex128{complex(0.6, 0), complex(0, 0.8)}, "alice", key)
+	if err != nil {
+		panic(err)
+	}
+
+	// The gateway only accepts proofs with 128 bits of soundness
+	verifier, err := NewVerifier(prover, VerifierConfig{Policy: NewPolicy(MinSoundness(128))})
+	if err != nil {
+		panic(err)
+	}
+	result := verifier.Verify(proof, key)
+	fmt.Println("valid:", result.Valid)
+	for _, reason := range result.Reasons {
+		fmt.Println("reason:", reason)
+	}
+	// Output:
+	// valid: false
+	// reason: min_soundness: soundness 64 bits is below the required 128
+}
+
+func ExampleVerifierSession() {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("tutorial"))
+	if err != nil {
+		panic(err)
+	}
+	key := []byte("32-byte-authentication-key-here!")
+
+	// The verifier opens the session and picks the challenges, so the
+	// prover cannot precompute its answers
+	verifier, err := sq.NewVerifierSession()
+	if err != nil {
+		panic(err)
+	}
+	prover, err := sq.NewProverSession(verifier.Offer(), []complex128{complex(0.6, 0), complex(0, 0.8)}, "alice", key)
+	if err != nil {
+		panic(err)
+	}
+	commitment, err := prover.Commit()
+	if err != nil {
+		panic(err)
+	}
+	challenges, err := verifier.Challenge(commitment)
+	if err != nil {
+		panic(err)
+	}
+	proof, err := prover.Respond(challenges)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("answered all challenges:", len(proof.ChallengeResponse) == len(challenges))
+	fmt.Println("valid:", verifier.Verify(proof, key))
+	// Output:
+	// answered all challenges: true
+	// valid: true
+}
+
+func ExampleSecureQuantumZKP_SecureProveVectorKnowledge() {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("tutorial"))
+	if err != nil {
+		panic(err)
+	}
+	key := []byte("32-byte-authentication-key-here!")
+
+	// The secret: the Bell state (|00⟩ + |11⟩)/√2
+	secret := []complex128{complex(0.7071, 0), 0, 0, complex(0.7071, 0)}
+	proof, err := sq.SecureProveVectorKnowledge(secret, "bell-state", key)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("valid:", sq.VerifySecureProof(proof, key))
+	fmt.Println("challenge responses:", len(proof.ChallengeResponse))
+
+	// The proof carries commitments to the amplitudes, never the amplitudes
+	encoded, err := json.Marshal(proof)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("amplitude in proof:", strings.Contains(string(encoded), "0.7071"))
+	// Output:
+	// valid: true
+	// challenge responses: 80
+	// amplitude in proof: false
+}
+
+func ExampleSecureQuantumZKP_SecureProveFromBytes_document() {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("tutorial"))
+	if err != nil {
+		panic(err)
+	}
+	key := []byte("32-byte-authentication-key-here!")
+
+	document := []byte("CONFIDENTIAL: quarterly results, not for release")
+	proof, err := sq.SecureProveFromBytes(document, "report-q3", key)
+	if err != nil {
+		panic(err)
+	}
+	encoded, err := json.Marshal(proof)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("valid:", sq.VerifySecureProof(proof, key))
+	fmt.Println("document text in proof:", strings.Contains(string(encoded), "CONFIDENTIAL"))
+	// Output:
+	// valid: true
+	// document text in proof: false
+}
+
+func ExampleQuantumZKP_BuildCircuit() {
+	q, err := NewQuantumZKP(3, 128, []byte("tutorial"))
+	if err != nil {
+		panic(err)
+	}
+	state := []complex128{complex(0.5, 0.1), complex(0.4, 0.2), complex(0.3, 0.3), complex(0.2, 0.4)}
+	circuit, err := q.BuildCircuit(state, "superposition")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%d qubits, %d classical bits, %d gates\n", circuit.NumQubits, circuit.NumClbits, len(circuit.Gates))
+
+	for level := 0; level <= 3; level++ {
+		transpiled, err := q.TranspileCircuit(circuit, level)
+		if err != nil {
+			panic(err)
+		}
+		mitigated, err := q.ApplyNoiseMitigation(transpiled)
+		if err != nil {
+			panic(err)
+		}
+		// Outcomes are sampled, so only their total is fixed
+		result, err := q.ExecuteCircuit(mitigated, 1000)
+		if err != nil {
+			panic(err)
+		}
+		shots := 0
+		for _, count := range result.Counts {
+			shots += count
+		}
+		fmt.Printf("level %d: %d gates, %d shots\n", level, len(mitigated.Gates), shots)
+	}
+	// Output:
+	// 2 qubits, 2 classical bits, 12 gates
+	// level 0: 12 gates, 1000 shots
+	// level 1: 12 gates, 1000 shots
+	// level 2: 12 gates, 1000 shots
+	// level 3: 12 gates, 1000 shots
+}
+
+func ExampleSecureQuantumZKP_zeroKnowledge() {
+	secret := []complex128{complex(0.8, 0.2), complex(0.3, 0.5), complex(0.1, 0.7), complex(0.4, 0.1)}
+	key := []byte("32-byte-authentication-key-here!")
+	// readable counts the amplitudes found in proof as JSON encodes them
+	readable := func(proof interface{}) int {
+		encoded, err := json.Marshal(proof)
+		if err != nil {
+			panic(err)
+		}
+		n := 0
+		for _, c := range secret {
+			if strings.Contains(string(encoded), fmt.Sprintf(`{"re":%v,"im":%v}`, real(c), imag(c))) {
+				n++
+			}
+		}
+		return n
+	}
+
+	// The original QuantumZKP proof publishes the state it proves
+	q, err := NewQuantumZKP(3, 128, []byte("tutorial"))
+	if err != nil {
+		panic(err)
+	}
+	insecure, err := q.Prove(secret, "comparison", key)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("QuantumZKP: %d of %d amplitudes readable\n", readable(insecure), len(secret))
+
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("tutorial"))
+	if err != nil {
+		panic(err)
+	}
+	secure, err := sq.SecureProveVectorKnowledge(secret, "comparison", key)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("SecureQuantumZKP: %d of %d amplitudes readable\n", readable(secure), len(secret))
+	fmt.Println("valid:", sq.VerifySecureProof(secure, key))
+	// Output:
+	// QuantumZKP: 4 of 4 amplitudes readable
+	// SecureQuantumZKP: 0 of 4 amplitudes readable
+	// valid: true
+}
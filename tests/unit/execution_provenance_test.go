@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fetchHardwareResult runs a RemoteExecutor-style exchange against a fake
+// backend and records its provenance.
+func fetchHardwareResult(t *testing.T, recorder *ProvenanceRecorder, circuit *QuantumCircuit) (*ExecutionResult, []byte) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-42")
+		w.Header().Set("Set-Cookie", "session=secret")
+		io.WriteString(w, `{"job_id":"job-7","counts":{"00":498,"11":502},"shots":1000}`)
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/v1/jobs/job-7/results?token=secret")
+	if err != nil {
+		t.Fatalf("Backend request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	var parsed struct {
+		JobID  string         `json:"job_id"`
+		Counts map[string]int `json:"counts"`
+		Shots  int            `json:"shots"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	result := &ExecutionResult{Counts: parsed.Counts, Shots: parsed.Shots, Backend: "ibm_brisbane"}
+	exchange := &BackendExchange{
+		JobID:      parsed.JobID,
+		Parameters: map[string]string{"optimization_level": "3"},
+		Response:   resp,
+		Body:       body,
+	}
+	if err := recorder.Record(result, circuit, exchange); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	return result, body
+}
+
+func TestExecutionProvenance(t *testing.T) {
+	signer, _ := NewSignatureScheme(nil)
+	recorder, err := NewProvenanceRecorder(signer)
+	if err != nil {
+		t.Fatalf("NewProvenanceRecorder failed: %v", err)
+	}
+	bell := &QuantumCircuit{NumQubits: 2, NumClbits: 2, Gates: []QuantumGate{
+		{Type: "h", Qubits: []int{0}},
+		{Type: "cx", Qubits: []int{0, 1}},
+	}}
+	result, body := fetchHardwareResult(t, recorder, bell)
+
+	if err := VerifyExecutionProvenance(result, bell, signer, nil); err != nil {
+		t.Fatalf("VerifyExecutionProvenance failed: %v", err)
+	}
+	record := result.ProvenanceRecord
+	if !record.MatchesBody(body) || record.MatchesBody([]byte("{}")) {
+		t.Error("The record should match exactly the raw response body")
+	}
+	if record.JobID != "job-7" || record.Parameters["optimization_level"] != "3" {
+		t.Errorf("Request details not recorded: %+v", record)
+	}
+	if strings.Contains(record.Endpoint, "secret") || record.ResponseHeaders["Set-Cookie"] != nil {
+		t.Errorf("Credentials should not be recorded: %s %v", record.Endpoint, record.ResponseHeaders)
+	}
+	if got := record.ResponseHeaders["X-Request-Id"]; len(got) != 1 || got[0] != "req-42" {
+		t.Errorf("Response headers not recorded: %v", record.ResponseHeaders)
+	}
+
+	result.Counts["00"]++
+	if err := VerifyExecutionProvenance(result, bell, signer, nil); err == nil {
+		t.Error("Altered counts should not verify")
+	}
+	result.Counts["00"]--
+	if err := VerifyExecutionProvenance(result, &QuantumCircuit{NumQubits: 1}, signer, nil); err == nil {
+		t.Error("Another circuit should not verify")
+	}
+	other, _ := NewSignatureScheme(nil)
+	if err := VerifyExecutionProvenance(result, bell, other, nil); err == nil {
+		t.Error("Another recorder key should not verify")
+	}
+
+	// Cached states carrying the record can be traced to the job
+	state := &CachedQuantumState{Name: "bell", Backend: "ibm_brisbane", JobID: "job-7", ProvenanceRecord: record}
+	if err := VerifyCachedStateProvenance(state, signer, nil); err != nil {
+		t.Errorf("VerifyCachedStateProvenance failed: %v", err)
+	}
+	state.JobID = "job-8"
+	if err := VerifyCachedStateProvenance(state, signer, nil); err == nil {
+		t.Error("A state claiming another job should not verify")
+	}
+}
+
+func TestExecutionProvenanceTimeAttestation(t *testing.T) {
+	signer, _ := NewSignatureScheme(nil)
+	authority, err := NewEd25519TimeAuthority("test-roughtime", time.Second)
+	if err != nil {
+		t.Fatalf("NewEd25519TimeAuthority failed: %v", err)
+	}
+	recorder, _ := NewProvenanceRecorder(signer)
+	recorder.TimeAuthority = authority
+	circuit := &QuantumCircuit{NumQubits: 1, NumClbits: 1, Gates: []QuantumGate{{Type: "h", Qubits: []int{0}}}}
+	result, _ := fetchHardwareResult(t, recorder, circuit)
+
+	if result.ProvenanceRecord.TimeAttestation == nil {
+		t.Fatal("Record should carry a time attestation")
+	}
+	if err := VerifyExecutionProvenance(result, circuit, signer, authority); err != nil {
+		t.Errorf("VerifyExecutionProvenance failed: %v", err)
+	}
+	if err := VerifyExecutionProvenance(result, circuit, signer, nil); err == nil {
+		t.Error("An attested record should need an authority to verify")
+	}
+}
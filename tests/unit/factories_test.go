@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+// newTestProver returns a prover at soundness bits under the shared test
+// context.
+func newTestProver(t testing.TB, soundness int) *SecureQuantumZKP {
+	t.Helper()
+	sq, err := NewSecureQuantumZKPWithSoundness(3, 128, soundness, []byte(testutil.ProofContext))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKPWithSoundness(%d) failed: %v", soundness, err)
+	}
+	return sq
+}
+
+// newTestProof returns a new prover at soundness bits and its proof of
+// the testutil fixture statement, for tests that modify either.
+func newTestProof(t testing.TB, soundness int) (*SecureQuantumZKP, *SecureProof) {
+	t.Helper()
+	sq := newTestProver(t, soundness)
+	proof, err := sq.SecureProveVectorKnowledge(testutil.RampVector(testutil.ProofDimension), testutil.ProofIdentifier, testutil.Key())
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge at %d bits failed: %v", soundness, err)
+	}
+	return sq, proof
+}
+
+// levelProof is a prover and its proof fixture at one soundness level.
+type levelProof struct {
+	sq    *SecureQuantumZKP
+	proof *SecureProof
+}
+
+// levelProofs are the suite's shared proofs at each soundness level.
+var levelProofs = testutil.NewProofFixtures(func(soundness int) (levelProof, error) {
+	sq, err := NewSecureQuantumZKPWithSoundness(3, 128, soundness, []byte(testutil.ProofContext))
+	if err != nil {
+		return levelProof{}, err
+	}
+	proof, err := sq.SecureProveVectorKnowledge(testutil.RampVector(testutil.ProofDimension), testutil.ProofIdentifier, testutil.Key())
+	return levelProof{sq, proof}, err
+})
+
+// verifiesJSON reports whether data decodes to a proof sq accepts.
+func verifiesJSON(sq *SecureQuantumZKP, data []byte) bool {
+	var proof SecureProof
+	if err := json.Unmarshal(data, &proof); err != nil {
+		return false
+	}
+	return sq.VerifySecureProof(&proof, testutil.Key())
+}
+
+func TestParametersGolden(t *testing.T) {
+	for _, soundness := range testutil.SoundnessLevels {
+		t.Run(fmt.Sprint(soundness), func(t *testing.T) {
+			testutil.GoldenJSON(t, fmt.Sprintf("parameters_%d", soundness), newTestProver(t, soundness).Parameters())
+		})
+	}
+}
+
+func TestProofFixtures(t *testing.T) {
+	for _, soundness := range testutil.SoundnessLevels {
+		fixture := levelProofs.At(t, soundness)
+		if fixture != levelProofs.At(t, soundness) {
+			t.Errorf("%d bits: fixture made twice", soundness)
+		}
+		if len(fixture.proof.ChallengeResponse) != soundness || !fixture.sq.VerifySecureProof(fixture.proof, testutil.Key()) {
+			t.Errorf("%d bits: fixture does not verify with one challenge per bit", soundness)
+		}
+	}
+}
+
+func TestTamperUtilities(t *testing.T) {
+	for _, soundness := range testutil.SoundnessLevels {
+		fixture := levelProofs.At(t, soundness)
+		sq, proof := fixture.sq, fixture.proof
+		data, err := json.Marshal(proof)
+		if err != nil {
+			t.Fatalf("Failed to encode proof: %v", err)
+		}
+		if !verifiesJSON(sq, data) {
+			t.Fatalf("%d-bit proof should verify after a JSON round trip", soundness)
+		}
+
+		signature := bytes.Index(data, []byte(`"signature":"`)) + len(`"signature":"`)
+		if verifiesJSON(sq, testutil.FlipByte(data, signature)) {
+			t.Errorf("%d bits: a flipped signature byte should not verify", soundness)
+		}
+		if verifiesJSON(sq, testutil.Truncate(data, -1)) {
+			t.Errorf("%d bits: a truncated proof should not verify", soundness)
+		}
+
+		root, _ := json.Marshal(proof.MerkleRoot)
+		same, err := testutil.DuplicateField(data, "merkle_root", root)
+		if err != nil {
+			t.Fatalf("DuplicateField failed: %v", err)
+		}
+		if !verifiesJSON(sq, same) {
+			t.Errorf("%d bits: repeating a field with its own value should not change the proof", soundness)
+		}
+		other, _ := json.Marshal(proof.CommitmentHash)
+		shadowed, _ := testutil.DuplicateField(data, "merkle_root", other)
+		if verifiesJSON(sq, shadowed) {
+			t.Errorf("%d bits: a duplicate field shadowing the signed value should not verify", soundness)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestParseFeatureFlags(t *testing.T) {
+	settings, err := ParseFeatureFlags(" legacy_verification=off, debug_envelopes=ON,,insecure_prover=0")
+	if err != nil {
+		t.Fatalf("ParseFeatureFlags failed: %v", err)
+	}
+	want := map[Feature]bool{FeatureLegacyVerification: false, FeatureDebugEnvelopes: true, FeatureInsecureProver: false}
+	if len(settings) != len(want) {
+		t.Fatalf("Parsed %v, expected %v", settings, want)
+	}
+	for feature, enabled := range want {
+		if settings[feature] != enabled {
+			t.Errorf("%s parsed as %v", feature, settings[feature])
+		}
+	}
+	for _, bad := range []string{"legacy_verification", "quantum_magic=on", "debug_envelopes=maybe"} {
+		if _, err := ParseFeatureFlags(bad); err == nil {
+			t.Errorf("%q was accepted", bad)
+		}
+	}
+
+	var unset *FeatureFlags
+	if !unset.Enabled(FeatureInsecureProver) {
+		t.Error("Nil flags should enable every feature")
+	}
+	flags, err := NewFeatureFlags(map[Feature]bool{FeatureHardwareBackends: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flags.Enabled(FeatureHardwareBackends) || !flags.Enabled(FeatureDebugEnvelopes) {
+		t.Errorf("Settings %v", flags.Settings())
+	}
+	if flags.Set(map[Feature]bool{"unknown": false}) == nil || flags.Enabled(FeatureHardwareBackends) {
+		t.Error("An unknown feature was accepted or changed the settings")
+	}
+}
+
+func TestFeatureFlagsGateRiskyFeatures(t *testing.T) {
+	sq := newTestProver(t, 64)
+	flags := &FeatureFlags{}
+	sq.Features = flags
+	sq.Debug = true
+	backend := &countingBackend{}
+	sq.MeasurementBackend = backend
+	vector := testutil.RampVector(8)
+
+	proof, err := sq.SecureProveVectorKnowledge(vector, "flags-on", testutil.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof.Debug == nil || backend.calls.Load() == 0 {
+		t.Fatal("Enabled features were not used")
+	}
+
+	flags.Set(map[Feature]bool{FeatureDebugEnvelopes: false, FeatureHardwareBackends: false})
+	calls := backend.calls.Load()
+	proof, err = sq.SecureProveVectorKnowledge(vector, "flags-off", testutil.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof.Debug != nil {
+		t.Error("Debug envelope attached while switched off")
+	}
+	if backend.calls.Load() != calls {
+		t.Error("Hardware backend used while switched off")
+	}
+	if !sq.VerifySecureProof(proof, testutil.Key()) {
+		t.Error("Proof made on the CPU fallback does not verify")
+	}
+}
+
+func TestFeatureFlagsGateLegacyVerification(t *testing.T) {
+	for _, f := range readCompatManifest(t) {
+		if f.ProofVersion == CurrentProofVersion {
+			continue
+		}
+		sq := f.verifier(t)
+		proof := f.decode(t, sq)
+		key, _ := hex.DecodeString(f.Key)
+		sq.Features = &FeatureFlags{}
+		if !sq.VerifySecureProofVersioned(proof, key).Valid {
+			t.Fatalf("%s: archived proof rejected", f.File)
+		}
+		sq.Features.Set(map[Feature]bool{FeatureLegacyVerification: false})
+		if result := sq.VerifySecureProofVersioned(proof, key); result.Valid || len(result.Reasons) == 0 {
+			t.Errorf("%s: legacy proof verified while switched off: %+v", f.File, result)
+		}
+	}
+
+	sq := newTestProver(t, 64)
+	l, _ := newTestLegacyZKP(t, sq, time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	proof, err := l.Prove(testutil.UniformVector(8), "legacy-doc", testutil.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.policy.Features = &FeatureFlags{}
+	l.policy.Features.Set(map[Feature]bool{FeatureInsecureProver: false, FeatureLegacyVerification: false})
+	if _, err := l.Prove(testutil.UniformVector(8), "legacy-doc", testutil.Key()); !errors.Is(err, ErrFeatureDisabled) {
+		t.Errorf("Insecure prover ran while switched off: %v", err)
+	}
+	if _, err := l.VerifyProof(proof, testutil.Key()); !errors.Is(err, ErrFeatureDisabled) {
+		t.Errorf("Legacy verification ran while switched off: %v", err)
+	}
+}
+
+func TestKillSwitchStopsIssuanceOnly(t *testing.T) {
+	t.Setenv(FeatureFlagsEnv, "debug_envelopes=off")
+	service, err := NewProverService(ServiceConfig{
+		Dimensions:    3,
+		SecurityLevel: 128,
+		Application:   "kill-switch-test",
+		Key:           hex.EncodeToString(testutil.Key()),
+		Features:      map[Feature]bool{FeatureDebugEnvelopes: true, FeatureHardwareBackends: false},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := service.FeatureStatus()
+	if status.Features[FeatureDebugEnvelopes] || status.Features[FeatureHardwareBackends] || !status.Features[FeatureLegacyVerification] {
+		t.Errorf("Environment should override the config: %v", status.Features)
+	}
+
+	proved, err := service.Prove(&ProveRequest{Identifier: "before", Data: []byte("payload")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.KillSwitch.Trip("key compromise drill")
+	if _, err := service.Prove(&ProveRequest{Identifier: "after", Data: []byte("payload")}); !errors.Is(err, ErrIssuanceDisabled) {
+		t.Errorf("Prove while tripped: %v", err)
+	}
+	if verified, err := service.Verify(&VerifyRequest{Proof: proved.Proof}); err != nil || !verified.Valid {
+		t.Errorf("Verification went offline with the kill switch: %v", err)
+	}
+	if state := service.KillSwitch.State(); !state.Tripped || state.Reason != "key compromise drill" || state.Since.IsZero() {
+		t.Errorf("Kill switch state %+v", state)
+	}
+	service.KillSwitch.Reset()
+	if _, err := service.Prove(&ProveRequest{Identifier: "reset", Data: []byte("payload")}); err != nil {
+		t.Errorf("Prove after reset: %v", err)
+	}
+}
+
+func TestDaemonFeatureFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "qzkpd.json")
+	featurePath := filepath.Join(dir, "features.json")
+	writeDaemonConfig(t, path, DaemonConfig{
+		ServiceConfig: ServiceConfig{
+			Dimensions:    3,
+			SecurityLevel: 128,
+			Application:   "daemon-features",
+			Key:           hex.EncodeToString(testutil.Key()),
+		},
+		Workers:     1,
+		FeatureFile: featurePath,
+	})
+	d, err := NewDaemon(path)
+	if err != nil {
+		t.Fatalf("NewDaemon failed: %v", err)
+	}
+	server := httptest.NewServer(d.Handler())
+	defer server.Close()
+
+	prove := func() int {
+		data, _ := json.Marshal(ProveRequest{Identifier: "feature-file", Data: []byte("payload")})
+		resp, err := http.Post(server.URL+"/v1/prove", "application/json", bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	writeFeatureFile := func(file FeatureFile) {
+		data, _ := json.Marshal(file)
+		if err := os.WriteFile(featurePath, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if changed, err := d.refreshFeatures(false); !changed || err != nil {
+			t.Fatalf("Feature file not applied: %v", err)
+		}
+	}
+	if prove() != http.StatusOK {
+		t.Fatal("Prove failed without a feature file")
+	}
+
+	writeFeatureFile(FeatureFile{Features: map[Feature]bool{FeatureLegacyVerification: false}, KillSwitch: "incident 7"})
+	if code := prove(); code != http.StatusServiceUnavailable {
+		t.Errorf("Prove while tripped answered %d", code)
+	}
+	resp, err := http.Get(server.URL + "/readyz")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Error("Daemon should stay ready while only issuance is stopped")
+	}
+	resp, err = http.Get(server.URL + "/v1/features")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var status FeatureStatus
+	json.NewDecoder(resp.Body).Decode(&status)
+	resp.Body.Close()
+	if status.Features[FeatureLegacyVerification] || status.KillSwitch.Reason != "incident 7" {
+		t.Errorf("Feature status %+v", status)
+	}
+
+	// Overrides survive a reload; clearing the file resets what it tripped
+	if err := d.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if d.service.Load().Features.Enabled(FeatureLegacyVerification) || !d.KillSwitch().State().Tripped {
+		t.Error("Reload dropped the feature file's settings")
+	}
+	writeFeatureFile(FeatureFile{})
+	if code := prove(); code != http.StatusOK {
+		t.Errorf("Prove after clearing the kill switch answered %d", code)
+	}
+
+	// Switches tripped through the API are left to the API
+	d.KillSwitch().Trip("manual")
+	writeFeatureFile(FeatureFile{Features: map[Feature]bool{FeatureDebugEnvelopes: false}})
+	if !d.KillSwitch().State().Tripped {
+		t.Error("Feature file reset a switch it did not trip")
+	}
+	os.WriteFile(featurePath, []byte("{"), 0600)
+	if _, err := d.refreshFeatures(true); err == nil || d.service.Load().Features.Enabled(FeatureDebugEnvelopes) {
+		t.Errorf("Broken feature file applied: %v", err)
+	}
+}
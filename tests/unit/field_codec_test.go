@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+func TestFieldCodecsMatchStandardLibrary(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for n := 0; n < 200; n++ {
+		src := make([]byte, n)
+		rng.Read(src)
+
+		encoded := EncodeHexField(src)
+		if encoded != hex.EncodeToString(src) {
+			t.Fatalf("%d bytes: hex %q differs from encoding/hex", n, encoded)
+		}
+		decoded, err := DecodeHexField(encoded, 0, n)
+		if err != nil || !bytes.Equal(decoded, src) {
+			t.Fatalf("%d bytes: hex round trip failed: %v", n, err)
+		}
+
+		encoded = EncodeBase64Field(src)
+		if encoded != base64.RawURLEncoding.EncodeToString(src) {
+			t.Fatalf("%d bytes: base64 %q differs from encoding/base64", n, encoded)
+		}
+		decoded, err = DecodeBase64Field(encoded, 0, n)
+		if err != nil || !bytes.Equal(decoded, src) {
+			t.Fatalf("%d bytes: base64 round trip failed: %v", n, err)
+		}
+	}
+}
+
+func TestFieldCodecsClassifyEveryCharacter(t *testing.T) {
+	for c := 0; c < 256; c++ {
+		hexInput := string([]byte{'a', byte(c)})
+		want, wantErr := hex.DecodeString(hexInput)
+		got, err := DecodeHexField(hexInput, 1, 1)
+		if (err != nil) != (wantErr != nil) || err == nil && !bytes.Equal(got, want) {
+			t.Errorf("Hex digit %#x: got %x, %v; encoding/hex gives %x, %v", c, got, err, want, wantErr)
+		}
+
+		// encoding/base64 skips newlines; the field codec refuses them
+		if c == '\r' || c == '\n' {
+			continue
+		}
+		b64Input := string([]byte{byte(c), 'A', 'A', 'A'})
+		want, wantErr = base64.RawURLEncoding.DecodeString(b64Input)
+		got, err = DecodeBase64Field(b64Input, 3, 3)
+		if (err != nil) != (wantErr != nil) || err == nil && !bytes.Equal(got, want) {
+			t.Errorf("Base64 digit %#x: got %x, %v; encoding/base64 gives %x, %v", c, got, err, want, wantErr)
+		}
+	}
+}
+
+func TestFieldCodecsRejectUniformly(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+	}{
+		{"odd hex length", func() error { _, err := DecodeHexField("abc", 0, 8); return err }()},
+		{"hex too short", func() error { _, err := DecodeHexField("abcd", 4, 8); return err }()},
+		{"hex too long", func() error { _, err := DecodeHexField("abcdabcd", 0, 2); return err }()},
+		{"hex bad digit", func() error { _, err := DecodeHexField("abzd", 0, 8); return err }()},
+		{"base64 impossible length", func() error { _, err := DecodeBase64Field("AAAAA", 0, 8); return err }()},
+		{"base64 too long", func() error { _, err := DecodeBase64Field("AAAAAA", 0, 3); return err }()},
+		{"base64 padding", func() error { _, err := DecodeBase64Field("AA==", 0, 8); return err }()},
+		{"base64 standard alphabet", func() error { _, err := DecodeBase64Field("ab+/", 0, 8); return err }()},
+		// "AB" leaves four set bits after its single byte, which
+		// encoding/base64 ignores; only "AA" encodes 0x00
+		{"base64 trailing bits", func() error { _, err := DecodeBase64Field("AB", 0, 8); return err }()},
+	} {
+		if tc.err != ErrMalformedField {
+			t.Errorf("%s: expected ErrMalformedField, got %v", tc.name, tc.err)
+		}
+	}
+
+	if _, err := DecodeHexField(EncodeHexField(make([]byte, 4627)), 0, 8192); err != nil {
+		t.Errorf("A signature-sized field should decode: %v", err)
+	}
+	_, proof := newTestProof(t, 64)
+	proof.Signature = proof.Signature[:len(proof.Signature)-2]
+	if err := validateProofStructure(proof); err == nil {
+		t.Error("A truncated signature should be a malformed proof")
+	}
+}
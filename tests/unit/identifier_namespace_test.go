@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestIdentifierNamespace(t *testing.T) {
+	ns := &IdentifierNamespace{Prefix: "billing/", MaxLength: 32, Charset: IdentifierCharsetPortable}
+	if err := ns.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+
+	cases := []struct {
+		identifier string
+		violation  IdentifierViolation
+	}{
+		{"billing/invoice-42", ""},
+		{"", IdentifierEmpty},
+		{"billing/invoice-42\nforged log line", IdentifierControlCharacter},
+		{"billing/\u202einvoice", IdentifierControlCharacter},
+		{"billing/invoice 42", IdentifierDisallowedCharacter},
+		{"shipping/invoice-42", IdentifierMissingPrefix},
+		{"billing/invoice-000000000000000000000042", IdentifierTooLong},
+		{"billing/\xff", IdentifierInvalidUTF8},
+	}
+	for _, c := range cases {
+		err := ns.Check(c.identifier)
+		if c.violation == "" {
+			if err != nil {
+				t.Errorf("Check(%q) failed: %v", c.identifier, err)
+			}
+			continue
+		}
+		var idErr *IdentifierError
+		if !errors.As(err, &idErr) || idErr.Violation != c.violation || !errors.Is(err, ErrInvalidIdentifier) {
+			t.Errorf("Check(%q) = %v, expected %s", c.identifier, err, c.violation)
+		}
+	}
+
+	if err := (&IdentifierNamespace{Prefix: "bad prefix/", Charset: IdentifierCharsetPortable}).Validate(); err == nil {
+		t.Error("A prefix outside the charset should not validate")
+	}
+}
+
+func TestIdentifierNamespaceEnforcement(t *testing.T) {
+	sq := newTestProver(t, 64)
+	sq.IdentifierNamespace = ContextIdentifierNamespace(sq.Context)
+	key := testutil.Key()
+	vector := testutil.RampVector(8)
+
+	_, err := sq.SecureProveVectorKnowledge(vector, "other-app/record", key)
+	var idErr *IdentifierError
+	if !errors.As(err, &idErr) || idErr.Violation != IdentifierMissingPrefix {
+		t.Fatalf("Expected a missing prefix error, got %v", err)
+	}
+
+	proof, err := sq.SecureProveVectorKnowledge(vector, sq.IdentifierNamespace.Prefix+"record", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if !sq.VerifySecureProof(proof, key) {
+		t.Error("Proof inside the namespace should verify")
+	}
+
+	// A prover without a namespace can still produce proofs, but a verifier
+	// enforcing one rejects them
+	unrestricted := newTestProver(t, 64)
+	foreign, err := unrestricted.SecureProveVectorKnowledge(vector, "other-app/record", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if sq.VerifySecureProof(foreign, key) {
+		t.Error("Proof outside the verifier's namespace must not verify")
+	}
+	report := NewPolicy(IdentifierInNamespace(sq.IdentifierNamespace)).Evaluate(foreign)
+	if report.Allowed() {
+		t.Error("Namespace rule should reject the foreign identifier")
+	}
+
+	if _, err := unrestricted.SecureProveVectorKnowledge(vector, "record\x1b[2K", key); !errors.Is(err, ErrInvalidIdentifier) {
+		t.Errorf("Control characters should be rejected without a namespace, got %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestIdentifierPrivacyMode(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("privacy-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	disclosureKey := []byte("disclosure-key-for-auditors-only")
+	sq.IdentifierKey = disclosureKey
+	key := testutil.Key()
+	vector := []complex128{complex(0.6, 0), complex(0, 0.8)}
+	identifier := "customer-4711-credit-limit"
+
+	first, err := sq.SecureProveVectorKnowledge(vector, identifier, key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	second, err := sq.SecureProveVectorKnowledge(vector, identifier, key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+
+	if strings.Contains(first.Identifier, "customer") || first.IdentifierScheme != IdentifierSchemeHMACSHA256 {
+		t.Errorf("Identifier should be pseudonymized, got %q (%s)", first.Identifier, first.IdentifierScheme)
+	}
+	if first.Identifier == second.Identifier {
+		t.Error("Proofs for the same identifier should be unlinkable")
+	}
+	if !sq.VerifySecureProof(first, key) {
+		t.Error("Pseudonymized proof should verify")
+	}
+
+	if !ConfirmIdentifier(first, identifier, disclosureKey) {
+		t.Error("Authorized party should confirm the expected identifier")
+	}
+	if ConfirmIdentifier(first, "customer-4712-credit-limit", disclosureKey) {
+		t.Error("A different identifier must not be confirmed")
+	}
+	if ConfirmIdentifier(first, identifier, []byte("wrong-key")) || ConfirmIdentifier(first, identifier, nil) {
+		t.Error("Confirmation without the disclosure key must fail")
+	}
+
+	composed, err := sq.SecureProveComposed(vector, identifier, key, 160)
+	if err != nil {
+		t.Fatalf("SecureProveComposed failed: %v", err)
+	}
+	if !sq.VerifyComposedProof(composed, key) {
+		t.Error("Pseudonymized composed proof should verify")
+	}
+	if !ConfirmIdentifier(composed.Rounds[0], identifier, disclosureKey) {
+		t.Error("Composed round identifier should be confirmable")
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+// restrictedHost refuses, and records, every filesystem and network access
+// made while it is installed.
+type restrictedHost struct {
+	mu       sync.Mutex
+	accesses []string
+}
+
+// restrictHost installs a restrictedHost for the rest of the test.
+func restrictHost(t *testing.T) *restrictedHost {
+	t.Helper()
+	host := &restrictedHost{}
+	fs, transport := hostFS, http.DefaultTransport
+	hostFS, http.DefaultTransport = host, host
+	t.Cleanup(func() { hostFS, http.DefaultTransport = fs, transport })
+	return host
+}
+
+func (h *restrictedHost) deny(op, name string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.accesses = append(h.accesses, op+" "+name)
+	return ErrInMemory
+}
+
+func (h *restrictedHost) Accesses() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.accesses...)
+}
+
+func (h *restrictedHost) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, h.deny("request", req.URL.String())
+}
+
+func (h *restrictedHost) ReadFile(name string) ([]byte, error) { return nil, h.deny("read", name) }
+func (h *restrictedHost) WriteFile(name string, _ []byte, _ os.FileMode) error {
+	return h.deny("write", name)
+}
+func (h *restrictedHost) Open(name string) (*os.File, error) { return nil, h.deny("open", name) }
+func (h *restrictedHost) OpenFile(name string, _ int, _ os.FileMode) (*os.File, error) {
+	return nil, h.deny("open", name)
+}
+func (h *restrictedHost) CreateTemp(dir, _ string) (*os.File, error) {
+	return nil, h.deny("create", dir)
+}
+func (h *restrictedHost) ReadDir(name string) ([]os.DirEntry, error) {
+	return nil, h.deny("readdir", name)
+}
+func (h *restrictedHost) Stat(name string) (os.FileInfo, error) { return nil, h.deny("stat", name) }
+func (h *restrictedHost) MkdirAll(path string, _ os.FileMode) error {
+	return h.deny("mkdir", path)
+}
+func (h *restrictedHost) Rename(oldpath, _ string) error { return h.deny("rename", oldpath) }
+func (h *restrictedHost) Remove(name string) error       { return h.deny("remove", name) }
+func (h *restrictedHost) Truncate(name string, _ int64) error {
+	return h.deny("truncate", name)
+}
+
+func inMemoryServiceConfig() ServiceConfig {
+	return ServiceConfig{
+		Dimensions:    3,
+		SecurityLevel: 128,
+		Application:   "in-memory-test",
+		Key:           "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		InMemory:      true,
+	}
+}
+
+func TestInMemoryServiceHasNoSideEffects(t *testing.T) {
+	host := restrictHost(t)
+
+	s, err := NewProverService(inMemoryServiceConfig())
+	if err != nil {
+		t.Fatalf("NewProverService failed: %v", err)
+	}
+	proved, err := s.Prove(&ProveRequest{Identifier: "doc-1", Data: []byte("sandboxed secret")})
+	if err != nil {
+		t.Fatalf("Prove failed: %v", err)
+	}
+	verified, err := s.Verify(&VerifyRequest{Proof: proved.Proof})
+	if err != nil || !verified.Valid {
+		t.Fatalf("Verify = %+v, %v", verified, err)
+	}
+	if accesses := host.Accesses(); len(accesses) != 0 {
+		t.Errorf("In-memory service reached the host: %v", accesses)
+	}
+}
+
+func TestInMemoryRejectsHostSettings(t *testing.T) {
+	host := restrictHost(t)
+
+	cfg := inMemoryServiceConfig()
+	cfg.NonceStore = filepath.Join(t.TempDir(), "nonces.log")
+	if _, err := NewProverService(cfg); !errors.Is(err, ErrInMemory) {
+		t.Errorf("Expected ErrInMemory for a nonce store, got %v", err)
+	}
+	if accesses := host.Accesses(); len(accesses) != 0 {
+		t.Errorf("Rejected settings still reached the host: %v", accesses)
+	}
+
+	// The shim sees what a service without in-memory mode does
+	cfg.InMemory = false
+	if _, err := NewProverService(cfg); err == nil || len(host.Accesses()) == 0 {
+		t.Errorf("A file nonce store should go through the filesystem shim: %v", err)
+	}
+}
+
+func TestInMemoryRefusesHostBoundPlugins(t *testing.T) {
+	store, err := OpenFileNonceStore(filepath.Join(t.TempDir(), "nonces.log"))
+	if err != nil {
+		t.Fatalf("OpenFileNonceStore failed: %v", err)
+	}
+	defer store.Close()
+	sq, proof := newTestProof(t, 64)
+	host := restrictHost(t)
+	sq.InMemory = true
+
+	result := sq.VerifySecureProofWithOptions(proof, testutil.Key(), VerifyOptions{NonceStore: store})
+	if result.Valid || !hasReason(result.Reasons, "in-memory") {
+		t.Errorf("A file nonce store should be refused: %+v", result)
+	}
+	result = sq.VerifySecureProofWithOptions(proof, testutil.Key(), VerifyOptions{NonceStore: NewMemoryNonceStore()})
+	if !result.Valid {
+		t.Errorf("A memory nonce store should be accepted: %v", result.Reasons)
+	}
+
+	pub, _, _ := ed25519.GenerateKey(nil)
+	sq.TimeAuthority = NewHTTPTimeAuthority("remote", "http://time.invalid/attest", pub)
+	if _, err := sq.SecureProveVectorKnowledge(testutil.RampVector(8), "doc", testutil.Key()); !errors.Is(err, ErrInMemory) {
+		t.Errorf("Expected ErrInMemory for a remote time authority, got %v", err)
+	}
+	if len(host.Accesses()) != 0 {
+		t.Errorf("In-memory prover reached the host: %v", host.Accesses())
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+// Actions the model-based harness can take on an interactive session pair.
+const (
+	actCommit = iota
+	actChallenge
+	actChallengeForeign
+	actRespond
+	actRespondDuplicate
+	actRespondReordered
+	actRespondOutOfRange
+	actRespondEmpty
+	actVerify
+	actVerifyTampered
+	actAbandon
+	numInteractiveActions
+)
+
+var interactiveActionNames = [...]string{
+	"commit", "challenge", "challenge_foreign", "respond", "respond_duplicate",
+	"respond_reordered", "respond_out_of_range", "respond_empty", "verify",
+	"verify_tampered", "abandon",
+}
+
+// interactiveSecret is the prover's private input for one harness run.
+type interactiveSecret struct {
+	vector []complex128
+	key    []byte
+}
+
+// interactiveModel tracks which protocol messages have been exchanged in the
+// current session pair and drives the real state machines alongside.
+type interactiveModel struct {
+	t      *testing.T
+	sq     *SecureQuantumZKP
+	secret interactiveSecret
+
+	verifier   *VerifierSession
+	prover     *ProverSession
+	commitment *SessionCommitment
+	challenges []Challenge
+	proof      *SecureProof
+	honest     bool // proof answers exactly the issued challenges
+
+	committed, challenged, responded bool
+	leaks                            []string
+}
+
+func newInteractiveModel(t *testing.T, sq *SecureQuantumZKP, secret interactiveSecret) *interactiveModel {
+	m := &interactiveModel{t: t, sq: sq, secret: secret}
+	m.abandon()
+	return m
+}
+
+// abandon drops the current sessions without finishing them and opens a
+// new pair.
+func (m *interactiveModel) abandon() {
+	verifier, err := m.sq.NewVerifierSession()
+	if err != nil {
+		m.t.Fatalf("NewVerifierSession failed: %v", err)
+	}
+	prover, err := m.sq.NewProverSession(verifier.Offer(), m.secret.vector, "model_test", m.secret.key)
+	if err != nil {
+		m.t.Fatalf("NewProverSession failed: %v", err)
+	}
+	*m = interactiveModel{t: m.t, sq: m.sq, secret: m.secret, verifier: verifier, prover: prover}
+	m.leaks = []string{
+		string(m.secret.key),
+		hex.EncodeToString(m.secret.key),
+		hex.EncodeToString(verifier.Offer().SessionKey),
+	}
+	for _, amplitude := range m.secret.vector {
+		m.leaks = append(m.leaks, fmt.Sprintf("%g", real(amplitude)))
+	}
+}
+
+// challengesToAnswer returns the issued challenges, or well-formed ones the
+// verifier never issued when the session has not been challenged.
+func (m *interactiveModel) challengesToAnswer() []Challenge {
+	if m.challenges != nil {
+		return append([]Challenge(nil), m.challenges...)
+	}
+	challenges, err := m.sq.randomChallenges(len(m.secret.vector), m.sq.ChallengeCount())
+	if err != nil {
+		m.t.Fatalf("randomChallenges failed: %v", err)
+	}
+	return challenges
+}
+
+// step performs action and checks the outcome against the model. It
+// returns a trace line that must not depend on the secret.
+func (m *interactiveModel) step(action int) (trace string) {
+	name := interactiveActionNames[action]
+	defer func() {
+		if r := recover(); r != nil {
+			m.t.Fatalf("%s panicked: %v", name, r)
+		}
+	}()
+
+	var err error
+	expectOK := false
+	switch action {
+	case actCommit:
+		var c *SessionCommitment
+		c, err = m.prover.Commit()
+		expectOK = !m.committed
+		if err == nil {
+			m.commitment, m.committed = c, true
+		}
+
+	case actChallenge:
+		var challenges []Challenge
+		challenges, err = m.verifier.Challenge(m.commitment)
+		expectOK = m.committed && !m.challenged
+		if err == nil {
+			m.challenges, m.challenged = challenges, true
+		}
+
+	case actChallengeForeign:
+		foreign := &SessionCommitment{SessionID: "foreign", CommitmentHash: "00", Dimension: len(m.secret.vector)}
+		if m.commitment != nil {
+			c := *m.commitment
+			c.SessionID += "00"
+			foreign = &c
+		}
+		_, err = m.verifier.Challenge(foreign)
+
+	case actRespond, actRespondDuplicate, actRespondReordered:
+		// Mutate the whole set so the result differs from the issued
+		// challenges except with negligible probability
+		challenges := m.challengesToAnswer()
+		switch action {
+		case actRespondDuplicate:
+			for i := range challenges {
+				challenges[i] = challenges[0]
+			}
+		case actRespondReordered:
+			for i, j := 0, len(challenges)-1; i < j; i, j = i+1, j-1 {
+				challenges[i], challenges[j] = challenges[j], challenges[i]
+			}
+		}
+		honest := m.challenged && challengeDigest(challenges) == challengeDigest(m.challenges)
+		var proof *SecureProof
+		proof, err = m.prover.Respond(challenges)
+		expectOK = m.committed && !m.responded
+		if err == nil {
+			m.proof, m.honest, m.responded = proof, honest, true
+		}
+
+	case actRespondOutOfRange:
+		challenges := m.challengesToAnswer()
+		challenges[0].Index = len(m.secret.vector)
+		_, err = m.prover.Respond(challenges)
+
+	case actRespondEmpty:
+		_, err = m.prover.Respond(nil)
+
+	case actVerify:
+		valid := m.verifier.Verify(m.proof, m.secret.key)
+		if want := m.proof != nil && m.honest && m.challenged; valid != want {
+			m.t.Errorf("verify: got %v, model expects %v", valid, want)
+		}
+		return fmt.Sprintf("%s=%v", name, valid)
+
+	case actVerifyTampered:
+		if m.proof == nil {
+			return name + "=skipped"
+		}
+		tampered := *m.proof
+		tampered.ChallengeResponse = append([]ChallengeResponse(nil), m.proof.ChallengeResponse...)
+		tampered.ChallengeResponse[0].Response = strings.Repeat("0", len(tampered.ChallengeResponse[0].Response))
+		if m.verifier.Verify(&tampered, m.secret.key) {
+			m.t.Error("verify_tampered: tampered proof accepted")
+		}
+		return name + "=false"
+
+	case actAbandon:
+		m.abandon()
+		return name
+	}
+
+	if (err == nil) != expectOK {
+		m.t.Errorf("%s: got error %v, model expects success=%v", name, err, expectOK)
+	}
+	if err == nil {
+		return name + "=ok"
+	}
+	for _, secret := range m.leaks {
+		if secret != "" && strings.Contains(err.Error(), secret) {
+			m.t.Errorf("%s: error %q reveals secret material", name, err)
+		}
+	}
+	return name + "=" + err.Error()
+}
+
+// runInteractiveModel drives the session machines through actions and
+// returns the outcome trace.
+func runInteractiveModel(t *testing.T, sq *SecureQuantumZKP, secret interactiveSecret, actions []byte) []string {
+	m := newInteractiveModel(t, sq, secret)
+	trace := make([]string, len(actions))
+	for i, a := range actions {
+		trace[i] = m.step(int(a) % numInteractiveActions)
+	}
+	return trace
+}
+
+// newModelInstance uses the minimum soundness to keep each step cheap.
+func newModelInstance(t testing.TB) *SecureQuantumZKP {
+	sq, err := NewSecureQuantumZKPWithSoundness(3, 128, 32, []byte("interactive-model"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKPWithSoundness failed: %v", err)
+	}
+	return sq
+}
+
+var modelSecrets = [2]interactiveSecret{
+	{
+		vector: []complex128{complex(0.5, 0), complex(0.5, 0), complex(0.5, 0), complex(0.5, 0)},
+		key:    testutil.Key(),
+	},
+	{
+		vector: []complex128{complex(0.9, 0), complex(0.1, 0), complex(0.3, 0), complex(0.3, 0)},
+		key:    []byte("abcdefghijklmnopqrstuvwxyzABCDEF"),
+	},
+}
+
+// checkInteractiveSequence runs actions under both secrets and requires the
+// same trace, so no outcome or error message depends on the secret.
+func checkInteractiveSequence(t *testing.T, sq *SecureQuantumZKP, actions []byte) {
+	a := runInteractiveModel(t, sq, modelSecrets[0], actions)
+	b := runInteractiveModel(t, sq, modelSecrets[1], actions)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("step %d outcome depends on the secret: %q vs %q", i, a[i], b[i])
+		}
+	}
+}
+
+func TestInteractiveProtocolScenarios(t *testing.T) {
+	sq := newModelInstance(t)
+	scenarios := map[string][]byte{
+		"honest":             {actCommit, actChallenge, actRespond, actVerify, actVerifyTampered},
+		"respond before ask": {actCommit, actRespond, actChallenge, actVerify},
+		"challenge first":    {actChallenge, actCommit, actChallenge, actRespond, actVerify},
+		"duplicate":          {actCommit, actChallenge, actRespondDuplicate, actVerify},
+		"reordered":          {actCommit, actChallenge, actRespondReordered, actVerify},
+		"replay":             {actCommit, actCommit, actChallenge, actChallenge, actRespond, actRespond, actVerify},
+		"foreign":            {actCommit, actChallengeForeign, actChallenge, actRespond, actVerify},
+		"malformed":          {actCommit, actChallenge, actRespondOutOfRange, actRespondEmpty, actRespond, actVerify},
+		"abandoned":          {actCommit, actChallenge, actAbandon, actRespond, actVerify, actCommit, actChallenge, actRespond, actVerify},
+	}
+	for name, actions := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			checkInteractiveSequence(t, sq, actions)
+		})
+	}
+}
+
+func TestInteractiveProtocolRandomWalks(t *testing.T) {
+	sq := newModelInstance(t)
+	rng := rand.New(rand.NewSource(4157))
+	walks := 20
+	if testing.Short() {
+		walks = 5
+	}
+	for w := 0; w < walks; w++ {
+		actions := make([]byte, 12)
+		for i := range actions {
+			actions[i] = byte(rng.Intn(numInteractiveActions))
+		}
+		checkInteractiveSequence(t, sq, actions)
+	}
+}
+
+func FuzzInteractiveProtocol(f *testing.F) {
+	f.Add([]byte{actCommit, actChallenge, actRespond, actVerify})
+	f.Add([]byte{actRespond, actCommit, actRespondDuplicate, actVerify, actAbandon})
+	sq := newModelInstance(f)
+	f.Fuzz(func(t *testing.T, actions []byte) {
+		if len(actions) > 32 {
+			actions = actions[:32]
+		}
+		checkInteractiveSequence(t, sq, actions)
+	})
+}
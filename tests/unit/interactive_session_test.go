@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+// runSession drives one interactive exchange and returns both ends.
+func runSession(t *testing.T, sq *SecureQuantumZKP, vector []complex128, key []byte) (*VerifierSession, *SecureProof) {
+	t.Helper()
+
+	verifier, err := sq.NewVerifierSession()
+	if err != nil {
+		t.Fatalf("NewVerifierSession failed: %v", err)
+	}
+	prover, err := sq.NewProverSession(verifier.Offer(), vector, "interactive_test", key)
+	if err != nil {
+		t.Fatalf("NewProverSession failed: %v", err)
+	}
+	commitment, err := prover.Commit()
+	if err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	challenges, err := verifier.Challenge(commitment)
+	if err != nil {
+		t.Fatalf("Challenge failed: %v", err)
+	}
+	proof, err := prover.Respond(challenges)
+	if err != nil {
+		t.Fatalf("Respond failed: %v", err)
+	}
+	return verifier, proof
+}
+
+func TestInteractiveSessionMACs(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("interactive-context"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	key := testutil.Key()
+	vector := []complex128{complex(0.5, 0), complex(0.5, 0), complex(0.5, 0), complex(0.5, 0)}
+
+	verifierA, proofA := runSession(t, sq, vector, key)
+	verifierB, proofB := runSession(t, sq, vector, key)
+
+	if !verifierA.Verify(proofA, key) {
+		t.Fatal("Session A proof should verify in session A")
+	}
+	if !verifierB.Verify(proofB, key) {
+		t.Fatal("Session B proof should verify in session B")
+	}
+	if verifierB.Verify(proofA, key) {
+		t.Error("Session A proof must not verify in session B")
+	}
+
+	// Interactive proofs are not accepted by the non-interactive verifier
+	if sq.VerifySecureProof(proofA, key) {
+		t.Error("Interactive proof should not pass non-interactive verification")
+	}
+
+	// Post-hoc audit with the right and wrong session keys
+	if !sq.AuditSessionProof(proofA, key, verifierA.Offer().SessionKey) {
+		t.Error("Audit with the session key should succeed")
+	}
+	if sq.AuditSessionProof(proofA, key, verifierB.Offer().SessionKey) {
+		t.Error("Audit with another session's key should fail")
+	}
+
+	// Splicing a response from session B into session A breaks its MAC
+	spliced := *proofA
+	spliced.ChallengeResponse = append([]ChallengeResponse(nil), proofA.ChallengeResponse...)
+	spliced.ChallengeResponse[0].MAC = proofB.ChallengeResponse[0].MAC
+	if verifierA.Verify(&spliced, key) {
+		t.Error("Proof with a spliced response MAC should not verify")
+	}
+}
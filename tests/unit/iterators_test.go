@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestProofStoreIterProofs(t *testing.T) {
+	store, err := NewProofStore(NewDataDirs(t.TempDir()))
+	if err != nil {
+		t.Fatalf("NewProofStore failed: %v", err)
+	}
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("iter-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	key := testutil.Key()
+	for i := 0; i < 5; i++ {
+		sq.Profile = "even"
+		if i%2 == 1 {
+			sq.Profile = "odd"
+		}
+		proof, err := sq.SecureProveVectorKnowledge([]complex128{1, complex(float64(i), 0)}, "iter", key)
+		if err != nil {
+			t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+		}
+		if err := store.Save(fmt.Sprintf("proof-%d", i), proof); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(store.Dir, "broken.proof.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	odd, failed := 0, 0
+	for stored, err := range store.IterProofs(func(_ string, p *SecureProof) bool { return p.Profile == "odd" }) {
+		switch {
+		case err != nil:
+			failed++
+			if stored.Name != "broken" {
+				t.Errorf("Load error reported for %q", stored.Name)
+			}
+		case stored.Proof.Profile != "odd":
+			t.Errorf("Filter let %s through", stored.Name)
+		default:
+			odd++
+		}
+	}
+	if odd != 2 || failed != 1 {
+		t.Errorf("Expected 2 odd proofs and 1 load error, got %d and %d", odd, failed)
+	}
+
+	// Breaking out stops the iteration
+	seen := 0
+	for range store.IterProofs(nil) {
+		seen++
+		break
+	}
+	if seen != 1 {
+		t.Errorf("Iteration continued after break: %d", seen)
+	}
+
+	valid, errs := 0, 0
+	for outcome := range sq.VerifyStored(store.IterProofs(nil), key, VerifyOptions{StrictMode: true}) {
+		if outcome.Err != nil {
+			errs++
+		} else if outcome.Result.Valid {
+			valid++
+		}
+	}
+	if valid != 5 || errs != 1 {
+		t.Errorf("Expected 5 valid proofs and 1 error, got %d and %d", valid, errs)
+	}
+}
+
+func TestQuantumStateCacheIterStates(t *testing.T) {
+	cache, _ := NewQuantumStateCache(filepath.Join(t.TempDir(), QuantumStateCacheFile))
+	for range cache.IterStates(0) {
+		t.Fatal("A missing cache should yield nothing")
+	}
+
+	data := []byte(`{"version":"1.0","states":[{"name":"bell","qubits":2},{"name":"ghz","qubits":3},` +
+		`{"name":"phi","qubits":2}],"used_time_seconds":1.5}`)
+	if err := os.WriteFile(cache.FilePath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for state, err := range cache.IterStates(2) {
+		if err != nil {
+			t.Fatalf("IterStates failed: %v", err)
+		}
+		names = append(names, state.Name)
+	}
+	if len(names) != 2 || names[0] != "bell" || names[1] != "phi" {
+		t.Errorf("Unexpected two-qubit states %v", names)
+	}
+	all, err := cache.GetStatesByType("all")
+	if err != nil || len(all) != 3 {
+		t.Errorf("Expected 3 states, got %d (%v)", len(all), err)
+	}
+
+	if err := os.WriteFile(cache.FilePath, []byte(`{"states":[{"name":"bell","qubits":2},`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var gotErr error
+	for _, err := range cache.IterStates(0) {
+		gotErr = err
+	}
+	if gotErr == nil {
+		t.Error("Truncated cache should end with an error")
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestKeySetSerializationAndPinning(t *testing.T) {
+	current, _ := NewSignatureScheme(nil)
+	next, _ := NewSignatureScheme(nil)
+
+	var keys KeySet
+	currentID, err := keys.Add(current.PublicKeyBytes(), time.Time{}, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	nextID, _ := keys.Add(next.PublicKeyBytes(), time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), time.Time{})
+	if currentID != KeyFingerprint(current.PublicKeyBytes()) {
+		t.Error("Key IDs should be key fingerprints")
+	}
+	if _, err := keys.Add(current.PublicKeyBytes(), time.Time{}, time.Time{}); err == nil {
+		t.Error("Adding a key twice should fail")
+	}
+
+	data, err := json.Marshal(&keys)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"kty":"AKP","alg":"ML-DSA-87","kid":"`+currentID) {
+		t.Errorf("Unexpected key set encoding: %s", data)
+	}
+	parsed, err := ParseKeySet(data, currentID, nextID)
+	if err != nil || len(parsed.Keys) != 2 || parsed.Keys[1].NotBefore == nil {
+		t.Fatalf("ParseKeySet failed: %v %v", parsed, err)
+	}
+	if _, err := ParseKeySet(data, currentID); err == nil {
+		t.Error("A key set with an unpinned key should be rejected")
+	}
+
+	relabeled := strings.Replace(string(data), currentID, nextID, 1)
+	if _, err := ParseKeySet([]byte(relabeled)); err == nil {
+		t.Error("A key ID that is not the key's fingerprint should be rejected")
+	}
+	if _, err := ParseKeySet([]byte(`{"keys": []}`)); err == nil {
+		t.Error("An empty key set should be rejected")
+	}
+}
+
+func TestVerifyWithKeySet(t *testing.T) {
+	sq, err := NewSecureQuantumZKPWithSoundness(3, 128, 128, []byte("key-set-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKPWithSoundness failed: %v", err)
+	}
+	proofTime := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	sq.Clock = func() time.Time { return proofTime }
+	key := testutil.Key()
+	proof, err := sq.SecureProveVectorKnowledge([]complex128{complex(0.6, 0), complex(0, 0.8)}, "key_set_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+
+	// A verifier holding only the published keys: the proving key and
+	// the key it is rotating to
+	verifier, _ := NewSecureQuantumZKPWithSoundness(3, 128, 128, []byte("key-set-test"))
+	next, _ := NewSignatureScheme(nil)
+	var keys KeySet
+	provingID, _ := keys.Add(sq.Signer.PublicKeyBytes(), time.Time{}, time.Time{})
+	nextID, _ := keys.Add(next.PublicKeyBytes(), time.Time{}, time.Time{})
+
+	verifierKey := verifier.Signer.PublicKeyBytes()
+	result := verifier.VerifyWithKeySet(proof, key, &keys, KeySetAnyOf)
+	if string(verifier.Signer.PublicKeyBytes()) != string(verifierKey) {
+		t.Error("Verifying with a key set should not replace the verifier's signer")
+	}
+	if !result.Valid || len(result.Signers) != 1 || result.Signers[0] != provingID || result.Version != CurrentProofVersion {
+		t.Fatalf("Any-of verification should pass with the proving key: %+v", result)
+	}
+	if result = verifier.VerifyWithKeySet(proof, key, &keys, KeySetAllOf); result.Valid || len(result.Reasons) != 1 {
+		t.Errorf("All-of verification needs the second key's signature: %+v", result)
+	}
+
+	if err := sq.CosignProof(proof, next); err != nil {
+		t.Fatalf("CosignProof failed: %v", err)
+	}
+	if err := sq.CosignProof(proof, next); err == nil {
+		t.Error("Cosigning twice with the same key should fail")
+	}
+	if result = verifier.VerifyWithKeySet(proof, key, &keys, KeySetAllOf); !result.Valid || len(result.Signers) != 2 {
+		t.Errorf("All-of verification should pass once cosigned: %+v", result)
+	}
+	if !sq.VerifySecureProof(proof, key) {
+		t.Error("Cosignatures should not affect the proof's own signature")
+	}
+
+	// After the rotation only the new key is published
+	rotated := KeySet{Keys: keys.Keys[1:]}
+	if result = verifier.VerifyWithKeySet(proof, key, &rotated, KeySetAnyOf); !result.Valid || result.Signers[0] != nextID {
+		t.Errorf("The cosignature should verify under the new key alone: %+v", result)
+	}
+
+	forged := *proof
+	forged.Cosignatures = []Cosignature{{KeyID: nextID, Signature: proof.Signature}}
+	if verifier.VerifyWithKeySet(&forged, key, &keys, KeySetAllOf).Valid {
+		t.Error("A cosignature made by another key should not count")
+	}
+
+	// Keys only count for proofs made within their validity window
+	expired := KeySet{Keys: append([]KeySetKey(nil), keys.Keys...)}
+	expiry := proofTime.Add(-time.Hour)
+	expired.Keys[1].NotAfter = &expiry
+	if verifier.VerifyWithKeySet(proof, key, &expired, KeySetAllOf).Valid {
+		t.Error("A key that expired before the proof was made should not count")
+	}
+	if !verifier.VerifyWithKeySet(proof, key, &expired, KeySetAnyOf).Valid {
+		t.Error("Any-of verification should still pass with the unexpired key")
+	}
+
+	tampered := *proof
+	tampered.Identifier = "tampered"
+	if verifier.VerifyWithKeySet(&tampered, key, &keys, KeySetAnyOf).Valid {
+		t.Error("A tampered proof should not verify with any key")
+	}
+}
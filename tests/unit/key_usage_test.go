@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestKeystoreUsageCountersAndLimits(t *testing.T) {
+	ks, err := NewKeystore(NewDataDirs(t.TempDir()))
+	if err != nil {
+		t.Fatalf("NewKeystore failed: %v", err)
+	}
+	ks.Limits = KeyUsageLimits{WarnProofs: 2, MaxProofs: 3}
+	key := testutil.Key()
+	if err := ks.Put("signing", key); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	statuses := []string{KeyStatusOK, KeyStatusWarning, KeyStatusExceeded}
+	for i, want := range statuses {
+		got, usage, err := ks.Use("signing")
+		if err != nil {
+			t.Fatalf("Use %d failed: %v", i, err)
+		}
+		if string(got) != string(key) || usage.Proofs != uint64(i+1) || usage.Status != want {
+			t.Errorf("Use %d: proofs %d status %q, expected %q", i, usage.Proofs, usage.Status, want)
+		}
+	}
+	if _, _, err := ks.Use("signing"); !errors.Is(err, ErrKeyRotationRequired) {
+		t.Errorf("Expected ErrKeyRotationRequired, got %v", err)
+	}
+
+	// Counters are persisted and survive reopening the keystore
+	reopened := &Keystore{Dir: ks.Dir, Limits: ks.Limits}
+	usage, err := reopened.Usage("signing")
+	if err != nil || usage.Proofs != 3 || len(usage.Warnings) == 0 {
+		t.Errorf("Persisted usage: %+v, %v", usage, err)
+	}
+
+	// Rotating the key resets its record
+	if err := reopened.Put("signing", key); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, usage, err := reopened.Use("signing"); err != nil || usage.Proofs != 1 {
+		t.Errorf("Rotated key should start over: %+v, %v", usage, err)
+	}
+}
+
+func TestKeystoreInventoryOrders(t *testing.T) {
+	ks, err := NewKeystore(NewDataDirs(t.TempDir()))
+	if err != nil {
+		t.Fatalf("NewKeystore failed: %v", err)
+	}
+	ks.Limits = KeyUsageLimits{WarnAge: time.Hour}
+	key := testutil.Key()
+	for _, name := range []string{"a", "b", "c"} {
+		if err := ks.Put(name, key); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	// Backdate "c" and use "b" the most
+	old, _ := ks.Usage("c")
+	old.Created = time.Now().Add(-2 * time.Hour)
+	if err := ks.writeUsage(old); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		ks.Use("b")
+	}
+
+	byAge, err := ks.Inventory(KeyOrderAge)
+	if err != nil || byAge[0].Name != "c" || byAge[0].Status != KeyStatusWarning {
+		t.Errorf("Oldest key should come first with a warning: %+v, %v", byAge[0], err)
+	}
+	byUsage, _ := ks.Inventory(KeyOrderUsage)
+	if byUsage[0].Name != "b" || byUsage[0].Proofs != 2 {
+		t.Errorf("Most used key should come first: %+v", byUsage[0])
+	}
+	if _, err := ks.Inventory("size"); err == nil {
+		t.Error("Unknown orders should be rejected")
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func newTestLegacyZKP(t *testing.T, sq *SecureQuantumZKP, now time.Time) (*LegacyZKP, *bytes.Buffer) {
+	t.Helper()
+	if !LegacySupport {
+		t.Skip("legacy support is not built in")
+	}
+	var logs bytes.Buffer
+	l, err := NewLegacyZKP(sq.QuantumZKP, LegacyPolicy{
+		Sunset: time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Logger: slog.New(slog.NewJSONHandler(&logs, nil)),
+		Clock:  func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatalf("NewLegacyZKP failed: %v", err)
+	}
+	return l, &logs
+}
+
+func TestLegacyZKPWarnsOncePerAPI(t *testing.T) {
+	sq := newTestProver(t, 64)
+	l, logs := newTestLegacyZKP(t, sq, time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+
+	for i := 0; i < 3; i++ {
+		proof, err := l.Prove(testutil.UniformVector(8), "legacy-doc", testutil.Key())
+		if err != nil {
+			t.Fatalf("Prove failed: %v", err)
+		}
+		if ok, err := l.VerifyProof(proof, testutil.Key()); !ok || err != nil {
+			t.Fatalf("VerifyProof = %v, %v", ok, err)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected one warning per API, got %d:\n%s", len(lines), logs)
+	}
+	for _, want := range []string{`"level":"WARN"`, `"api":"Prove"`, `"replacement":"SecureQuantumZKP.SecureProveVectorKnowledge"`, `"sunset":"2027-01-01T00:00:00Z"`} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("Warning lacks %s: %s", want, lines[0])
+		}
+	}
+}
+
+func TestLegacyZKPSunset(t *testing.T) {
+	sq := newTestProver(t, 64)
+	before, _ := newTestLegacyZKP(t, sq, time.Date(2026, time.December, 31, 23, 0, 0, 0, time.UTC))
+	proof, err := before.Prove(testutil.UniformVector(8), "legacy-doc", testutil.Key())
+	if err != nil {
+		t.Fatalf("Prove failed: %v", err)
+	}
+
+	after, logs := newTestLegacyZKP(t, sq, time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC))
+	if ok, err := after.VerifyProof(proof, testutil.Key()); ok || !errors.Is(err, ErrLegacySunset) {
+		t.Errorf("Expected ErrLegacySunset, got %v, %v", ok, err)
+	}
+	if _, err := after.ConvertProof(sq, proof, testutil.Key()); !errors.Is(err, ErrLegacySunset) {
+		t.Errorf("Expected ErrLegacySunset from ConvertProof, got %v", err)
+	}
+	if !strings.Contains(logs.String(), `"level":"ERROR"`) {
+		t.Errorf("Refusal was not logged: %s", logs)
+	}
+
+	l, err := NewLegacyZKP(sq.QuantumZKP, LegacyPolicy{})
+	if err != nil {
+		t.Fatalf("NewLegacyZKP failed: %v", err)
+	}
+	if !l.Sunset().Equal(DefaultLegacySunset) {
+		t.Errorf("Sunset = %v, want the default %v", l.Sunset(), DefaultLegacySunset)
+	}
+}
+
+func TestLegacyZKPConvertProof(t *testing.T) {
+	sq := newTestProver(t, 64)
+	l, _ := newTestLegacyZKP(t, sq, time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC))
+	legacy, err := l.Prove(testutil.UniformVector(8), "legacy-doc", testutil.Key())
+	if err != nil {
+		t.Fatalf("Prove failed: %v", err)
+	}
+
+	secure, err := l.ConvertProof(sq, legacy, testutil.Key())
+	if err != nil {
+		t.Fatalf("ConvertProof failed: %v", err)
+	}
+	if secure.Identifier != legacy.Identifier || !sq.VerifySecureProof(secure, testutil.Key()) {
+		t.Errorf("Converted proof does not verify: %+v", secure)
+	}
+
+	legacy.Identifier = "forged"
+	if _, err := l.ConvertProof(sq, legacy, testutil.Key()); !errors.Is(err, ErrLegacyProofInvalid) {
+		t.Errorf("Expected ErrLegacyProofInvalid for a tampered proof, got %v", err)
+	}
+}
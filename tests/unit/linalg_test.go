@@ -0,0 +1,63 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLinalgBellState(t *testing.T) {
+	s := complex(1/math.Sqrt2, 0)
+	bell := []complex128{s, 0, 0, s}
+
+	if n := Norm(bell); math.Abs(n-1) > 1e-15 {
+		t.Errorf("Norm = %.17f, want 1", n)
+	}
+
+	rho, err := PartialTrace(DensityMatrix(bell), 1)
+	if err != nil {
+		t.Fatalf("PartialTrace failed: %v", err)
+	}
+	// Tracing out half of a Bell pair leaves the maximally mixed state
+	if math.Abs(real(rho[0][0])-0.5) > 1e-12 || math.Abs(real(rho[1][1])-0.5) > 1e-12 || rho[0][1] != 0 {
+		t.Errorf("Unexpected reduced density matrix: %v", rho)
+	}
+
+	p, post, err := ProjectQubit(bell, 0, 1)
+	if err != nil {
+		t.Fatalf("ProjectQubit failed: %v", err)
+	}
+	if math.Abs(p-0.5) > 1e-12 {
+		t.Errorf("P(qubit 0 = 1) = %f, want 0.5", p)
+	}
+	if f, _ := Fidelity(post, []complex128{0, 0, 0, 1}); math.Abs(f-1) > 1e-12 {
+		t.Errorf("Post-measurement state should be |11⟩, fidelity %f", f)
+	}
+
+	if p, _ := Project(bell, 3); math.Abs(p-0.5) > 1e-12 {
+		t.Errorf("P(|11⟩) = %f, want 0.5", p)
+	}
+}
+
+func TestLinalgTensorAndStability(t *testing.T) {
+	zero := []complex128{1, 0}
+	plus := []complex128{complex(1/math.Sqrt2, 0), complex(1/math.Sqrt2, 0)}
+	product := Tensor(zero, plus)
+	if len(product) != 4 || product[0] != plus[0] || product[2] != 0 {
+		t.Errorf("Unexpected tensor product: %v", product)
+	}
+
+	// Amplitudes whose squares overflow or underflow float64
+	if n := Norm([]complex128{complex(3e200, 4e200)}); math.Abs(n/5e200-1) > 1e-12 {
+		t.Errorf("Norm overflowed: %g", n)
+	}
+	if n := Norm([]complex128{complex(3e-200, 4e-200)}); math.Abs(n/5e-200-1) > 1e-12 {
+		t.Errorf("Norm underflowed: %g", n)
+	}
+
+	if _, err := InnerProduct(zero, product); err == nil {
+		t.Error("Expected a dimension mismatch error")
+	}
+	if _, err := PartialTrace(DensityMatrix([]complex128{1, 0, 0}), 0); err == nil {
+		t.Error("Expected an error for a non-qubit dimension")
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func newLinkageProver(t *testing.T, linkageKey []byte) *SecureQuantumZKP {
+	t.Helper()
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("linkage-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	sq.LinkageKey = linkageKey
+	return sq
+}
+
+func TestLinkageTagsAreOffByDefault(t *testing.T) {
+	sq := newLinkageProver(t, nil)
+	key := testutil.Key()
+	proof, err := sq.SecureProveVectorKnowledge([]complex128{1, 1, 0, 0}, "plain", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if proof.Linkage != nil {
+		t.Error("Proofs should carry no linkage tag unless a linkage key is set")
+	}
+
+	sq.LinkageKey = []byte("short")
+	if _, err := sq.SecureProveVectorKnowledge([]complex128{1, 1, 0, 0}, "plain", key); err == nil {
+		t.Error("Short linkage keys should be rejected")
+	}
+}
+
+func TestLinkageDetectorFindsSameSecret(t *testing.T) {
+	detectionKey := bytes.Repeat([]byte{0x42}, 32)
+	sq := newLinkageProver(t, detectionKey)
+	key := testutil.Key()
+
+	secretA := []complex128{1, 1, 0, 0}
+	secretB := []complex128{0, 1, 1, 0}
+	proofs := map[string][]complex128{
+		"a1": secretA,
+		"b1": secretB,
+		"a2": {2, 2, 0, 0}, // same state as secretA once normalized
+	}
+	made := make(map[string]*SecureProof)
+	for _, id := range []string{"a1", "b1", "a2"} {
+		proof, err := sq.SecureProveVectorKnowledge(proofs[id], id, key)
+		if err != nil {
+			t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+		}
+		if !sq.VerifySecureProof(proof, key) {
+			t.Fatalf("Proof %s with a linkage tag should verify", id)
+		}
+		made[id] = proof
+	}
+	if made["a1"].Linkage.Sealed == made["a2"].Linkage.Sealed {
+		t.Error("Tags of the same secret should not be publicly linkable")
+	}
+
+	detector, err := NewLinkageDetector(detectionKey)
+	if err != nil {
+		t.Fatalf("NewLinkageDetector failed: %v", err)
+	}
+	for _, id := range []string{"a1", "b1"} {
+		if matches, err := detector.Observe(id, made[id]); err != nil || len(matches) != 0 {
+			t.Errorf("%s: unexpected matches %v (%v)", id, matches, err)
+		}
+	}
+	matches, err := detector.Observe("a2", made["a2"])
+	if err != nil || len(matches) != 1 || matches[0] != "a1" {
+		t.Errorf("a2 should match a1, got %v (%v)", matches, err)
+	}
+	if groups := detector.Duplicates(); len(groups) != 1 || len(groups[0]) != 2 {
+		t.Errorf("Expected one duplicate group, got %v", groups)
+	}
+
+	// Another auditor's key cannot open the tags
+	other, _ := NewLinkageDetector(bytes.Repeat([]byte{0x17}, 32))
+	if _, err := other.Open(made["a1"]); err == nil {
+		t.Error("A different detection key should not open the tag")
+	}
+}
+
+func TestLinkageDetectorRejectsMalformedTags(t *testing.T) {
+	detectionKey := bytes.Repeat([]byte{0x42}, 32)
+	sq := newLinkageProver(t, detectionKey)
+	proof, err := sq.SecureProveVectorKnowledge([]complex128{1, 1, 0, 0}, "a", testutil.Key())
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	detector, err := NewLinkageDetector(detectionKey)
+	if err != nil {
+		t.Fatalf("NewLinkageDetector failed: %v", err)
+	}
+	tag := *proof.Linkage
+	for name, malformed := range map[string]LinkageTag{
+		"key ID":      {KeyID: tag.KeyID[2:], Nonce: tag.Nonce, Sealed: tag.Sealed},
+		"nonce":       {KeyID: tag.KeyID, Nonce: "zz" + tag.Nonce[2:], Sealed: tag.Sealed},
+		"short nonce": {KeyID: tag.KeyID, Nonce: tag.Nonce[2:], Sealed: tag.Sealed},
+		"sealed":      {KeyID: tag.KeyID, Nonce: tag.Nonce, Sealed: tag.Sealed[1:]},
+	} {
+		proof.Linkage = &malformed
+		if _, err := detector.Open(proof); !errors.Is(err, ErrMalformedField) {
+			t.Errorf("Malformed %s: expected ErrMalformedField, got %v", name, err)
+		}
+	}
+}
+
+func TestLinkageTagIsSigned(t *testing.T) {
+	detectionKey := bytes.Repeat([]byte{0x42}, 32)
+	sq := newLinkageProver(t, detectionKey)
+	key := testutil.Key()
+	a, _ := sq.SecureProveVectorKnowledge([]complex128{1, 1, 0, 0}, "a", key)
+	b, _ := sq.SecureProveVectorKnowledge([]complex128{0, 1, 1, 0}, "b", key)
+
+	// Swapping in another proof's tag breaks the signature
+	a.Linkage = b.Linkage
+	if sq.VerifySecureProof(a, key) {
+		t.Error("Proof with a substituted linkage tag should not verify")
+	}
+
+	data, _ := json.Marshal(b)
+	var decoded SecureProof
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Linkage == nil {
+		t.Fatalf("Linkage tag should survive JSON round trip: %v", err)
+	}
+	decoded.Version = ProofVersionUnboundParameters
+	if sq.VerifySecureProofVersioned(&decoded, key).Valid {
+		t.Error("Version 2 proofs cannot carry linkage tags")
+	}
+}
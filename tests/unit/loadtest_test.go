@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadTestTarget serves a daemon proving at securityLevel, which refuses
+// every prove call with 429 when throttled.
+func loadTestTarget(t *testing.T, securityLevel int, throttled bool) *httptest.Server {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "qzkpd.json")
+	writeDaemonConfig(t, path, DaemonConfig{
+		ServiceConfig: ServiceConfig{
+			Dimensions:    3,
+			SecurityLevel: securityLevel,
+			Application:   "loadtest",
+			Key:           "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		},
+	})
+	d, err := NewDaemon(path)
+	if err != nil {
+		t.Fatalf("NewDaemon failed: %v", err)
+	}
+	t.Cleanup(func() { d.Shutdown(context.Background()) })
+	handler := d.Handler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/prove" && throttled {
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRunLoadScenario(t *testing.T) {
+	low, high := loadTestTarget(t, 64, false), loadTestTarget(t, 128, true)
+	scenario := LoadScenario{
+		Name:        "mixed",
+		Targets:     []LoadTarget{{URL: low.URL, Weight: 2}, {URL: high.URL, Weight: 1}},
+		ProofSizes:  []LoadProofSize{{Bytes: 64, Weight: 2}, {Bytes: 4096}},
+		VerifyRatio: 2,
+		Rate:        100,
+		Duration:    "400ms",
+		Bursts:      []LoadBurst{{Every: "200ms", Length: "50ms", Multiplier: 3}},
+		Seed:        7,
+	}
+	report, err := RunLoadScenario(t.Context(), scenario)
+	if err != nil {
+		t.Fatalf("RunLoadScenario failed: %v", err)
+	}
+	if report.Sent+report.Dropped < 40 {
+		t.Errorf("Expected at least 40 arrivals in 400ms at 100/s, got %d", report.Sent+report.Dropped)
+	}
+
+	requests := 0
+	ops := map[string]LoadOpStats{}
+	for _, total := range report.Totals {
+		requests += total.Requests
+		ops[total.Operation] = total
+	}
+	if requests != report.Sent {
+		t.Errorf("Totals count %d requests, %d were sent", requests, report.Sent)
+	}
+	if ops[LoadOpProve].Requests == 0 || ops[LoadOpVerify].Requests == 0 {
+		t.Fatalf("Expected both operations, got %+v", report.Totals)
+	}
+	if ops[LoadOpVerify].Errors != 0 {
+		t.Errorf("Verifying issued proofs should not fail: %+v", ops[LoadOpVerify])
+	}
+	prove := ops[LoadOpProve]
+	if prove.ErrorKinds["429"] == 0 || prove.ErrorKinds["429"] != prove.Errors {
+		t.Errorf("Expected only 429 errors from the throttled target, got %v", prove.ErrorKinds)
+	}
+	if prove.P50 <= 0 || prove.P50 > prove.P90 || prove.P90 > prove.P99 || prove.P99 > prove.Max {
+		t.Errorf("Percentiles out of order: %+v", prove)
+	}
+	for _, op := range report.Operations {
+		if op.Target == low.URL && op.Errors != 0 {
+			t.Errorf("Unthrottled target reported errors: %+v", op)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := report.WriteMarkdown(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "## per target") || !strings.Contains(out.String(), "429: ") {
+		t.Errorf("Unexpected report:\n%s", out.String())
+	}
+}
+
+func TestLoadScenarioValidation(t *testing.T) {
+	target := []LoadTarget{{URL: "http://127.0.0.1:1"}}
+	for _, scenario := range []LoadScenario{
+		{Rate: 10, Duration: "1s"},
+		{Targets: target, Duration: "1s"},
+		{Targets: target, Rate: 10, Duration: "soon"},
+		{Targets: target, Rate: 10, Duration: "1s", ProofSizes: []LoadProofSize{{Bytes: 0}}},
+		{Targets: target, Rate: 10, Duration: "1s", Bursts: []LoadBurst{{Every: "1s", Length: "2s", Multiplier: 2}}},
+	} {
+		if _, err := RunLoadScenario(t.Context(), scenario); err == nil {
+			t.Errorf("Scenario %+v should be refused", scenario)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+// countingBackend wraps the CPU backend and records how it was called.
+type countingBackend struct {
+	calls    atomic.Int64
+	requests atomic.Int64
+}
+
+func (b *countingBackend) Name() string { return "counting" }
+
+func (b *countingBackend) Measure(batch *MeasurementBatch) ([]AmplitudeMeasurement, error) {
+	b.calls.Add(1)
+	b.requests.Add(int64(len(batch.Requests)))
+	return CPUMeasurementBackend{Workers: 2}.Measure(batch)
+}
+
+func TestCPUMeasurementBackend(t *testing.T) {
+	state := testutil.RampVector(8)
+	norm := 0.0
+	for _, a := range state {
+		norm += real(a)*real(a) + imag(a)*imag(a)
+	}
+	for i := range state {
+		state[i] /= complex(math.Sqrt(norm), 0)
+	}
+	// X-basis amplitude k is (1/√n) Σ_j (-1)^{popcount(j&k)} ψ_j.
+	xAt := func(k int) complex128 {
+		var sum complex128
+		for j, a := range state {
+			if bits.OnesCount(uint(j&k))%2 == 1 {
+				sum -= a
+			} else {
+				sum += a
+			}
+		}
+		return sum / complex(math.Sqrt(float64(len(state))), 0)
+	}
+
+	batch := &MeasurementBatch{States: [][]complex128{state, state}}
+	for i := range state {
+		batch.Requests = append(batch.Requests,
+			MeasurementRequest{State: 0, Index: i},
+			MeasurementRequest{State: 1, Index: i, XBasis: true},
+			MeasurementRequest{State: i % 2, Index: i, XBasis: i%3 == 0})
+	}
+	for _, streaming := range []bool{false, true} {
+		batch.Streaming = streaming
+		got, err := CPUMeasurementBackend{}.Measure(batch)
+		if err != nil {
+			t.Fatalf("Measure (streaming %v) failed: %v", streaming, err)
+		}
+		for i, r := range batch.Requests {
+			want := state[r.Index]
+			if r.XBasis {
+				want = xAt(r.Index)
+			}
+			if math.Abs(got[i].Probability-real(want*cmplx.Conj(want))) > 1e-12 ||
+				math.Abs(got[i].Phase-cmplx.Phase(want)) > 1e-9 {
+				t.Errorf("Request %+v measured %+v, want amplitude %v", r, got[i], want)
+			}
+		}
+	}
+
+	for name, bad := range map[string]*MeasurementBatch{
+		"length":   {States: [][]complex128{state[:3]}},
+		"state":    {States: [][]complex128{state}, Requests: []MeasurementRequest{{State: 1}}},
+		"index":    {States: [][]complex128{state}, Requests: []MeasurementRequest{{Index: 8}}},
+		"negative": {States: [][]complex128{state}, Requests: []MeasurementRequest{{Index: -1}}},
+	} {
+		if _, err := (CPUMeasurementBackend{}).Measure(bad); err == nil {
+			t.Errorf("Invalid batch (%s) should be rejected", name)
+		}
+	}
+}
+
+func TestMeasurementBackendRegistry(t *testing.T) {
+	names := MeasurementBackends()
+	found := false
+	for _, name := range names {
+		found = found || name == "cpu"
+	}
+	if !found {
+		t.Fatalf("The cpu backend should always be available, got %v", names)
+	}
+	backend, err := NewMeasurementBackend("cpu")
+	if err != nil || backend.Name() != "cpu" {
+		t.Fatalf("NewMeasurementBackend(cpu) returned %v (%v)", backend, err)
+	}
+	if _, err := NewMeasurementBackend("abacus"); err == nil {
+		t.Error("An unknown backend should be rejected")
+	}
+}
+
+func TestSecureProveBatch(t *testing.T) {
+	sq := newTestProver(t, 64)
+	backend := &countingBackend{}
+	sq.MeasurementBackend = backend
+
+	var states []*StateVector
+	var identifiers []string
+	for i := 0; i < 6; i++ {
+		state, err := NewStateVector(testutil.RampVector(4 << (i % 3)))
+		if err != nil {
+			t.Fatalf("NewStateVector failed: %v", err)
+		}
+		states = append(states, state)
+		identifiers = append(identifiers, fmt.Sprintf("batch_%d", i))
+	}
+	proofs, err := sq.SecureProveBatch(states, identifiers, testutil.Key())
+	if err != nil {
+		t.Fatalf("SecureProveBatch failed: %v", err)
+	}
+	if backend.calls.Load() != 1 {
+		t.Errorf("All states should be measured in one batch, got %d calls", backend.calls.Load())
+	}
+	if want := int64(len(states) * sq.ChallengeCount()); backend.requests.Load() != want {
+		t.Errorf("Backend measured %d requests, want %d", backend.requests.Load(), want)
+	}
+
+	single, err := sq.SecureProveState(states[0], identifiers[0], testutil.Key())
+	if err != nil {
+		t.Fatalf("SecureProveState failed: %v", err)
+	}
+	for i, proof := range proofs {
+		if !sq.VerifySecureProof(proof, testutil.Key()) {
+			t.Errorf("Batch proof %d should verify", i)
+		}
+		if len(proof.ChallengeResponse) != len(single.ChallengeResponse) || proof.Version != single.Version {
+			t.Errorf("Batch proof %d should have the same form as a single proof", i)
+		}
+	}
+
+	if _, err := sq.SecureProveBatch(states, identifiers[:2], testutil.Key()); err == nil {
+		t.Error("Mismatched identifiers should be rejected")
+	}
+	if _, err := sq.SecureProveBatch(nil, nil, testutil.Key()); err == nil {
+		t.Error("An empty batch should be rejected")
+	}
+}
+
+func BenchmarkSecureProveBatch(b *testing.B) {
+	sq := newTestProver(b, 128)
+	states := make([]*StateVector, 64)
+	identifiers := make([]string, len(states))
+	for i := range states {
+		states[i], _ = NewStateVector(testutil.RampVector(16))
+		identifiers[i] = fmt.Sprintf("bench_%d", i)
+	}
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := sq.SecureProveBatch(states, identifiers, testutil.Key()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for j, state := range states {
+				if _, err := sq.SecureProveState(state, identifiers[j], testutil.Key()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"math/cmplx"
+	"runtime"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+// allocatedBy returns the bytes f allocates on the heap.
+func allocatedBy(f func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	f()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
+
+func TestHadamardAmplitude(t *testing.T) {
+	state := testutil.RampVector(16)
+	transform, _ := ApplyHadamard(state)
+	for k := range state {
+		amp, err := HadamardAmplitude(state, k)
+		if err != nil || cmplx.Abs(amp-transform[k]) > 1e-12 {
+			t.Errorf("Amplitude %d: got %v (%v), want %v", k, amp, err, transform[k])
+		}
+	}
+	if _, err := HadamardAmplitude(make([]complex128, 6), 0); err == nil {
+		t.Error("Non-power-of-two states should be rejected")
+	}
+	if _, err := HadamardAmplitude(state, 16); err == nil {
+		t.Error("Out-of-range indices should be rejected")
+	}
+}
+
+func TestMemoryBudget(t *testing.T) {
+	// The race detector's shadow memory and instrumentation allocate on
+	// top of what proving does
+	if raceEnabled {
+		t.Skip("allocation figures are not meaningful under the race detector")
+	}
+	key := testutil.Key()
+	sq, err := NewSecureQuantumZKPWithSoundness(3, 128, 128, []byte("memory-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKPWithSoundness failed: %v", err)
+	}
+	if _, err := EstimateProofMemory(1024, 128, sq.DigestLengths, "rot13+json"); err == nil {
+		t.Error("Unknown codecs should be rejected")
+	}
+
+	// The estimate bounds what generation and encoding actually allocate,
+	// with or without a materialized transform, without being vacuous
+	for _, codec := range []string{"json", "hex+json", "base64+gzip+json"} {
+		for _, soft := range []int64{0, 1} {
+			sq.MemoryBudget = MemoryBudget{Soft: soft, Codec: codec}
+			vector := testutil.RampVector(1 << 14)
+			estimate, err := sq.EstimateProofMemory(len(vector))
+			if err != nil || estimate.Streaming != (soft > 0) {
+				t.Fatalf("EstimateProofMemory: %+v (%v)", estimate, err)
+			}
+			used := allocatedBy(func() {
+				proof, err := sq.SecureProveVectorKnowledge(vector, "memory_test", key)
+				if err != nil {
+					t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+				}
+				data, _ := json.Marshal(proof)
+				if _, err := EncodeProof(codec, data); err != nil {
+					t.Fatal(err)
+				}
+			})
+			if used > uint64(estimate.Total()) || used < uint64(estimate.Total())/4 {
+				t.Errorf("%s, soft %d: allocated %d bytes, estimated %d", codec, soft, used, estimate.Total())
+			}
+		}
+	}
+
+	// Streaming saves the transform buffer and yields verifiable proofs
+	vector := testutil.RampVector(1 << 14)
+	sq.MemoryBudget = MemoryBudget{}
+	materialized := allocatedBy(func() { sq.SecureProveVectorKnowledge(vector, "memory_test", key) })
+	sq.MemoryBudget = MemoryBudget{Soft: 1}
+	streamed := allocatedBy(func() { sq.SecureProveVectorKnowledge(vector, "memory_test", key) })
+	if materialized < streamed+uint64(len(vector))*16*3/4 {
+		t.Errorf("Streaming should save the transform: %d vs %d bytes", streamed, materialized)
+	}
+	proof, err := sq.SecureProveVectorKnowledge(testutil.RampVector(1024), "memory_test", key)
+	if err != nil || !sq.VerifySecureProof(proof, key) {
+		t.Errorf("Streamed proof should verify: %v", err)
+	}
+
+	// Over the hard limit generation is refused before it starts
+	sq.MemoryBudget = MemoryBudget{Hard: 256 << 10}
+	var refused error
+	used := allocatedBy(func() { _, refused = sq.SecureProveVectorKnowledge(vector, "memory_test", key) })
+	if !errors.Is(refused, ErrMemoryBudgetExceeded) {
+		t.Fatalf("Expected ErrMemoryBudgetExceeded, got %v", refused)
+	}
+	if used > uint64(len(vector))*16+32<<10 {
+		t.Errorf("A refused proof allocated %d bytes", used)
+	}
+
+	// Interactive provers check the budget against the verifier's challenges
+	sq.MemoryBudget = MemoryBudget{}
+	verifier, _ := sq.NewVerifierSession()
+	prover, _ := sq.NewProverSession(verifier.Offer(), testutil.RampVector(8), "memory_test", key)
+	commitment, _ := prover.Commit()
+	challenges, err := verifier.Challenge(commitment)
+	if err != nil {
+		t.Fatalf("Challenge failed: %v", err)
+	}
+	sq.MemoryBudget = MemoryBudget{Hard: 512 << 10}
+	if _, err := prover.Respond(challenges); !errors.Is(err, ErrMemoryBudgetExceeded) {
+		t.Errorf("Expected the session response to be refused, got %v", err)
+	}
+}
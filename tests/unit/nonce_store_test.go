@@ -0,0 +1,227 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestNonceStores(t *testing.T) {
+	file, err := OpenFileNonceStore(filepath.Join(t.TempDir(), "nonces.log"))
+	if err != nil {
+		t.Fatalf("OpenFileNonceStore failed: %v", err)
+	}
+	defer file.Close()
+
+	now := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	memory := NewMemoryNonceStore()
+	memory.SetClock(clock)
+	file.SetClock(clock)
+
+	for name, store := range map[string]NonceStore{"memory": memory, "file": file} {
+		if err := store.Consume("a", now.Add(time.Hour)); err != nil {
+			t.Fatalf("%s: Consume failed: %v", name, err)
+		}
+		if err := store.Consume("a", now.Add(time.Hour)); !errors.Is(err, ErrNonceReplayed) {
+			t.Errorf("%s: a replayed nonce should be refused, got %v", name, err)
+		}
+		store.Consume("b", now.Add(2*time.Hour))
+
+		now = now.Add(90 * time.Minute)
+		if err := store.Consume("a", now.Add(time.Hour)); err != nil {
+			t.Errorf("%s: an expired nonce should be accepted again: %v", name, err)
+		}
+		if err := store.Consume("b", now.Add(time.Hour)); !errors.Is(err, ErrNonceReplayed) {
+			t.Errorf("%s: an unexpired nonce should still be refused, got %v", name, err)
+		}
+		if removed, err := store.Sweep(now.Add(100 * time.Minute)); err != nil || removed != 2 {
+			t.Errorf("%s: Sweep should remove both nonces, removed %d (%v)", name, removed, err)
+		}
+		now = now.Add(-90 * time.Minute)
+	}
+}
+
+func TestFileNonceStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nonces.log")
+	store, err := OpenFileNonceStore(path)
+	if err != nil {
+		t.Fatalf("OpenFileNonceStore failed: %v", err)
+	}
+	expires := time.Now().Add(time.Hour)
+	store.Consume("kept", expires)
+	store.Consume("expired", time.Now().Add(-time.Minute))
+	store.Close()
+
+	// A crash mid-write leaves a torn record, which was never acknowledged
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	f.WriteString("746f726e 12")
+	f.Close()
+
+	store, err = OpenFileNonceStore(path)
+	if err != nil {
+		t.Fatalf("Reopening the nonce log failed: %v", err)
+	}
+	if err := store.Consume("kept", expires); !errors.Is(err, ErrNonceReplayed) {
+		t.Errorf("A nonce should be refused after a restart, got %v", err)
+	}
+	if err := store.Consume("torn", expires); err != nil {
+		t.Errorf("A torn record should be discarded: %v", err)
+	}
+	if removed, err := store.Sweep(time.Now()); err != nil || removed != 1 {
+		t.Errorf("Sweep should remove the expired nonce, removed %d (%v)", removed, err)
+	}
+	store.Close()
+
+	store, err = OpenFileNonceStore(path)
+	if err != nil {
+		t.Fatalf("Reopening the compacted log failed: %v", err)
+	}
+	defer store.Close()
+	for _, nonce := range []string{"kept", "torn"} {
+		if err := store.Consume(nonce, expires); !errors.Is(err, ErrNonceReplayed) {
+			t.Errorf("Compaction should keep %q, got %v", nonce, err)
+		}
+	}
+
+	os.WriteFile(filepath.Join(filepath.Dir(path), "corrupt.log"), []byte("not a record\n"), 0600)
+	if _, err := OpenFileNonceStore(filepath.Join(filepath.Dir(path), "corrupt.log")); err == nil {
+		t.Error("A corrupt nonce log should be refused")
+	}
+}
+
+// failingTruncateFS is the OS filesystem except that truncation fails.
+type failingTruncateFS struct{ osFileSystem }
+
+func (failingTruncateFS) Truncate(string, int64) error { return errors.New("truncate failed") }
+
+func TestFileNonceStoreFailedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nonces.log")
+	store, err := OpenFileNonceStore(path)
+	if err != nil {
+		t.Fatalf("OpenFileNonceStore failed: %v", err)
+	}
+	defer store.Close()
+	expires := time.Now().Add(time.Hour)
+	if err := store.Consume("a", expires); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+
+	// A write that fails after putting part of its record on disk, made
+	// by appending the part and failing the write on a read-only file
+	failWrite := func() error {
+		f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+		f.WriteString("62")
+		f.Close()
+		good := store.file
+		store.file, _ = os.Open(path)
+		defer func() { store.file.Close(); store.file = good }()
+		return store.Consume("b", expires)
+	}
+	if err := failWrite(); err == nil {
+		t.Fatal("Consume should report the failed write")
+	}
+	if err := store.Consume("c", expires); err != nil {
+		t.Fatalf("The store should stay usable once the partial record is cut off: %v", err)
+	}
+	reopened, err := OpenFileNonceStore(path)
+	if err != nil {
+		t.Fatalf("Reopening the nonce log failed: %v", err)
+	}
+	for nonce, want := range map[string]error{"a": ErrNonceReplayed, "b": nil, "c": ErrNonceReplayed} {
+		if err := reopened.Consume(nonce, expires); !errors.Is(err, want) {
+			t.Errorf("Consume(%q) after reopening returned %v, want %v", nonce, err, want)
+		}
+	}
+	reopened.Close()
+
+	// A log that cannot be cut back is not written to again
+	fs := hostFS
+	hostFS = failingTruncateFS{}
+	t.Cleanup(func() { hostFS = fs })
+	if err := failWrite(); err == nil {
+		t.Fatal("Consume should report the failed write")
+	}
+	if err := store.Consume("d", expires); err == nil {
+		t.Error("A store whose log may hold a partial record should refuse nonces")
+	}
+	if _, err := store.Sweep(time.Now()); err == nil {
+		t.Error("A store whose log may hold a partial record should refuse to sweep")
+	}
+}
+
+func TestVerifyReplayProtection(t *testing.T) {
+	sq, err := NewSecureQuantumZKPWithSoundness(3, 128, 128, []byte("nonce-store-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKPWithSoundness failed: %v", err)
+	}
+	key := testutil.Key()
+	proof, err := sq.SecureProveVectorKnowledge(testutil.RampVector(8), "replay_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "nonces.log")
+	store, _ := OpenFileNonceStore(path)
+	opts := VerifyOptions{NonceStore: store}
+	if result := sq.VerifySecureProofWithOptions(proof, key, opts); !result.Valid {
+		t.Fatalf("First submission should verify: %v", result.Reasons)
+	}
+	if result := sq.VerifySecureProofWithOptions(proof, key, opts); result.Valid {
+		t.Error("A replayed proof should be refused")
+	}
+
+	// Restarting the verifier does not reopen the replay window
+	store.Close()
+	store, _ = OpenFileNonceStore(path)
+	defer store.Close()
+	opts.NonceStore = store
+	if result := sq.VerifySecureProofWithOptions(proof, key, opts); result.Valid {
+		t.Error("A proof replayed after a restart should be refused")
+	}
+
+	// Invalid proofs do not consume a nonce
+	fresh, _ := sq.SecureProveVectorKnowledge(testutil.RampVector(8), "replay_test", key)
+	tampered := *fresh
+	tampered.MerkleRoot = proof.MerkleRoot
+	if sq.VerifySecureProofWithOptions(&tampered, key, opts).Valid {
+		t.Fatal("A tampered proof should not verify")
+	}
+	if result := sq.VerifySecureProofWithOptions(fresh, key, opts); !result.Valid {
+		t.Errorf("A proof refused as invalid should still be accepted later: %v", result.Reasons)
+	}
+
+	// Proofs older than the window are stale once their nonce may be gone
+	sq.Clock = func() time.Time { return proof.Timestamp.Add(DefaultReplayWindow) }
+	if result := sq.VerifySecureProofWithOptions(proof, key, VerifyOptions{NonceStore: NewMemoryNonceStore()}); result.Valid {
+		t.Error("A proof older than the replay window should be refused")
+	}
+}
+
+func BenchmarkNonceStoreConsume(b *testing.B) {
+	file, err := OpenFileNonceStore(filepath.Join(b.TempDir(), "nonces.log"))
+	if err != nil {
+		b.Fatalf("OpenFileNonceStore failed: %v", err)
+	}
+	defer file.Close()
+	expires := time.Now().Add(time.Hour)
+
+	for name, store := range map[string]NonceStore{"memory": NewMemoryNonceStore(), "file": file} {
+		var counter atomic.Int64 // shared by every run, so nonces stay unique
+		b.Run(name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if err := store.Consume(strconv.FormatInt(counter.Add(1), 10)+name, expires); err != nil {
+						b.Error(err)
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestFixedPointEncoding(t *testing.T) {
+	enc := DefaultNumericEncoding()
+
+	a, err := enc.Encode(1 / math.Sqrt2)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	// A value differing only below the encoding precision hashes identically
+	b, err := enc.Encode(1/math.Sqrt2 + 1e-13)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Error("Values equal at the configured precision should encode identically")
+	}
+
+	negZero, _ := enc.Encode(math.Copysign(0, -1))
+	zero, _ := enc.Encode(0)
+	if !bytes.Equal(negZero, zero) {
+		t.Error("-0 and 0 should encode identically")
+	}
+
+	if _, err := enc.Encode(math.NaN()); err == nil {
+		t.Error("Expected an error encoding NaN")
+	}
+	if _, err := FixedPointEncoding(19); err == nil {
+		t.Error("Expected an error for excessive precision")
+	}
+	coarse, err := FixedPointEncoding(18)
+	if err != nil {
+		t.Fatalf("FixedPointEncoding failed: %v", err)
+	}
+	if _, err := coarse.Encode(1e3); err == nil {
+		t.Error("Expected an overflow error")
+	}
+}
+
+func TestProofRecordsNumericEncoding(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("encoding-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	sq.NumericEncoding, err = FixedPointEncoding(12)
+	if err != nil {
+		t.Fatalf("FixedPointEncoding failed: %v", err)
+	}
+	key := testutil.Key()
+	vector := []complex128{complex(0.6, 0), complex(0, 0.8)}
+
+	proof, err := sq.SecureProveVectorKnowledge(vector, "encoding_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if proof.NumericEncoding.Precision != 12 || proof.NumericEncoding.Scheme != FixedPointScheme {
+		t.Errorf("Proof header has encoding %+v", proof.NumericEncoding)
+	}
+	if !sq.VerifySecureProof(proof, key) {
+		t.Error("Proof with a custom encoding should verify")
+	}
+
+	sq.NumericEncoding = NumericEncoding{Scheme: "decimal-text"}
+	if _, err := sq.SecureProveVectorKnowledge(vector, "encoding_test", key); err == nil {
+		t.Error("Expected an error for an unsupported encoding")
+	}
+}
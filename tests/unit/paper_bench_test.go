@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func smallPaperBenchOptions() PaperBenchOptions {
+	opts := DefaultPaperBenchOptions()
+	opts.LeakageVectors, opts.Runs = 5, 1
+	opts.Claims.Levels = opts.Claims.Levels[1:2] // 64-bit
+	opts.Dimensions = []int{4, 8}
+	return opts
+}
+
+func TestRunPaperBench(t *testing.T) {
+	report, err := RunPaperBench(smallPaperBenchOptions())
+	if err != nil {
+		t.Fatalf("RunPaperBench failed: %v", err)
+	}
+	rows := map[string]int{}
+	for _, table := range report.Tables {
+		rows[table.Name] = len(table.Rows)
+	}
+	if rows["leakage"] != 2 || rows["latency"] != 1 || rows["scalability"] != 2 {
+		t.Fatalf("Unexpected tables: %v", rows)
+	}
+	if secure := report.Tables[0].Rows[1]; secure[0] != "secure" || secure[2] != "0" {
+		t.Errorf("The secure implementation should not leak: %v", secure)
+	}
+
+	var md bytes.Buffer
+	if err := report.WriteMarkdown(&md); err != nil {
+		t.Fatalf("WriteMarkdown failed: %v", err)
+	}
+	for _, want := range []string{"## machine", "| gomaxprocs |", "## leakage", "## latency", "## scalability", "## deviations"} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("Report lacks %q", want)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := report.Tables[1].WriteCSV(&out); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil || len(records) != 2 || records[0][0] != "soundness_bits" || records[1][0] != "64" {
+		t.Errorf("Unexpected CSV: %v, %v", records, err)
+	}
+}
+
+func TestPaperBenchFlagsDeviations(t *testing.T) {
+	opts := smallPaperBenchOptions()
+	opts.Claims.Levels[0].MaxProofSize = 1
+	opts.Claims.MaxScalingGenerationTime = time.Nanosecond
+	report, err := RunPaperBench(opts)
+	if err != nil {
+		t.Fatalf("RunPaperBench failed: %v", err)
+	}
+
+	var proofSize, scaling int
+	for _, d := range report.Deviations {
+		switch {
+		case d.Experiment == "latency 64-bit" && d.Metric == "proof size":
+			proofSize++
+		case strings.HasPrefix(d.Experiment, "scalability") && d.Metric == "generation time":
+			scaling++
+		}
+	}
+	if proofSize != 1 || scaling != 2 {
+		t.Errorf("Expected the tightened claims to be flagged: %+v", report.Deviations)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParameterizedCircuitBind(t *testing.T) {
+	ansatz, err := HardwareEfficientAnsatz(2, 1)
+	if err != nil {
+		t.Fatalf("HardwareEfficientAnsatz failed: %v", err)
+	}
+
+	params := ansatz.Parameters()
+	if len(params) != 4 {
+		t.Fatalf("Expected 4 parameters, got %v", params)
+	}
+
+	q, err := NewQuantumZKP(2, 128, []byte("ansatz-test"))
+	if err != nil {
+		t.Fatalf("NewQuantumZKP failed: %v", err)
+	}
+	if _, err := q.ExecuteCircuit(ansatz, 16); err == nil {
+		t.Error("Executing an unbound circuit should fail")
+	}
+
+	values := map[string]float64{"theta_0_0": 0.1, "theta_0_1": 0.2, "phi_0_0": 0.3, "phi_0_1": 0.4}
+	bound, err := ansatz.Bind(values)
+	if err != nil {
+		t.Fatalf("Bind failed: %v", err)
+	}
+	if len(bound.Parameters()) != 0 {
+		t.Errorf("Bound circuit still has parameters: %v", bound.Parameters())
+	}
+	if bound.Gates[0].Params[0] != 0.1 {
+		t.Errorf("First gate angle = %f, want 0.1", bound.Gates[0].Params[0])
+	}
+	if len(ansatz.Parameters()) != 4 {
+		t.Error("Bind must not modify the template")
+	}
+	if _, err := q.ExecuteCircuit(bound, 16); err != nil {
+		t.Errorf("Executing a bound circuit failed: %v", err)
+	}
+
+	delete(values, "phi_0_1")
+	if _, err := ansatz.Bind(values); err == nil {
+		t.Error("Bind with a missing parameter should fail")
+	}
+	values["phi_0_1"] = 0.4
+	values["typo"] = 1
+	if _, err := ansatz.Bind(values); err == nil {
+		t.Error("Bind with an unknown parameter should fail")
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestParametersBoundIntoProofs(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("parameters-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	key := testutil.Key()
+	proof, err := sq.SecureProveVectorKnowledge([]complex128{complex(0.6, 0), complex(0, 0.8)}, "parameters_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+
+	report, err := sq.ExplainParameters()
+	if err != nil {
+		t.Fatalf("ExplainParameters failed: %v", err)
+	}
+	if proof.ParametersHash == "" || proof.ParametersHash != report.ParametersHash {
+		t.Errorf("Proof parameters hash %q does not match report %q", proof.ParametersHash, report.ParametersHash)
+	}
+	if report.TrustedSetup || report.SoundnessError != -float64(sq.SecurityParameter) {
+		t.Errorf("Unexpected report header: %+v", report)
+	}
+	if _, err := json.Marshal(report); err != nil {
+		t.Errorf("Report should be machine-readable: %v", err)
+	}
+
+	// A verifier configured with different parameters rejects the proof
+	other, _ := NewSecureQuantumZKP(3, 128, []byte("parameters-test"))
+	other.Signer = sq.Signer
+	other.NumericEncoding.Precision++
+	if other.VerifySecureProof(proof, key) {
+		t.Error("Proof should not verify under different parameters")
+	}
+
+	// A version 2 proof carries no parameters hash, digest lengths, nonce
+	// length or provenance and verifies with caveats
+	legacySq := *sq
+	legacySq.DigestLengths = LegacyDigestLengths
+	legacySq.ChallengeNonceLength = LegacyChallengeNonceLength
+	legacyProof, err := legacySq.SecureProveVectorKnowledge([]complex128{complex(0.6, 0), complex(0, 0.8)}, "parameters_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	v2 := *legacyProof
+	v2.Version = ProofVersionUnboundParameters
+	v2.ParametersHash = ""
+	v2.DigestLengths = nil
+	v2.Provenance = nil
+	if err := sq.signSecureProof(&v2, key); err != nil {
+		t.Fatalf("signSecureProof failed: %v", err)
+	}
+	if sq.VerifySecureProof(&v2, key) {
+		t.Error("Strict verification must reject version 2 proofs")
+	}
+	result := sq.VerifySecureProofVersioned(&v2, key)
+	if !result.Valid || len(result.Caveats) == 0 {
+		t.Errorf("Version 2 proof should verify with caveats: %+v", result)
+	}
+}
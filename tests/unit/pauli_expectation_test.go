@@ -0,0 +1,65 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateExpectation(t *testing.T) {
+	// Bell state counts: ⟨ZZ⟩ = 1, ⟨ZI⟩ = 0
+	bell := &ExecutionResult{Counts: map[string]int{"00": 500, "11": 500}, Shots: 1000}
+
+	zz, err := EstimateExpectation(bell, "ZZ")
+	if err != nil {
+		t.Fatalf("EstimateExpectation failed: %v", err)
+	}
+	if zz.Value != 1 || zz.StdErr != 0 {
+		t.Errorf("⟨ZZ⟩ = %f ± %f, want 1 ± 0", zz.Value, zz.StdErr)
+	}
+
+	zi, err := EstimateExpectation(bell, "ZI")
+	if err != nil {
+		t.Fatalf("EstimateExpectation failed: %v", err)
+	}
+	if zi.Value != 0 || math.Abs(zi.StdErr-math.Sqrt(1.0/1000)) > 1e-12 {
+		t.Errorf("⟨ZI⟩ = %f ± %f, want 0 ± 0.0316", zi.Value, zi.StdErr)
+	}
+
+	if _, err := EstimateExpectation(bell, "ZZZ"); err == nil {
+		t.Error("Expected an error for a mismatched pauli length")
+	}
+	if _, err := EstimateExpectation(bell, "ZQ"); err == nil {
+		t.Error("Expected an error for an invalid pauli operator")
+	}
+}
+
+func TestPlanMeasurements(t *testing.T) {
+	base := NewCircuit(2, 2)
+	base.AddGate("h", []int{0})
+	base.AddGate("cx", []int{0, 1})
+	base.AddGate("measure", []int{0, 0})
+
+	settings, err := PlanMeasurements(base, []string{"ZZ", "ZI", "XX", "IX", "YY"})
+	if err != nil {
+		t.Fatalf("PlanMeasurements failed: %v", err)
+	}
+	if len(settings) != 3 {
+		t.Fatalf("Expected 3 measurement settings, got %d", len(settings))
+	}
+	if settings[0].Basis != "ZZ" || len(settings[0].Paulis) != 2 {
+		t.Errorf("Unexpected first setting: %+v", settings[0])
+	}
+	if settings[1].Basis != "XX" || len(settings[1].Paulis) != 2 {
+		t.Errorf("Unexpected second setting: %+v", settings[1])
+	}
+
+	measures := 0
+	for _, gate := range settings[2].Circuit.Gates {
+		if gate.Type == "measure" {
+			measures++
+		}
+	}
+	if measures != 2 {
+		t.Errorf("Expected every qubit measured exactly once, got %d measurements", measures)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestVerifierPolicy(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("policy-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	sq.Profile = "standard"
+	key := testutil.Key()
+	vector := []complex128{complex(0.6, 0), complex(0, 0.8)}
+
+	proof, err := sq.SecureProveVectorKnowledge(vector, "policy_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+
+	accepting := NewPolicy(
+		MinSoundness(80),
+		AllowedHashSuites(HashSuiteSHA256),
+		AllowedSignatureAlgorithms(SignatureAlgorithmMLDSA87),
+		RequireProfile("standard"),
+		AllowedContexts(sq.Context),
+		MaxAge(time.Hour, nil),
+	)
+	report, err := sq.VerifySecureProofWithPolicy(proof, key, accepting)
+	if err != nil {
+		t.Fatalf("Proof should satisfy the policy: %v", err)
+	}
+	if report.Evaluated != 6 || !report.Allowed() {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	later := func() time.Time { return proof.Timestamp.Add(2 * time.Hour) }
+	strict := NewPolicy(
+		MinSoundness(128),
+		AllowedHashSuites("blake3"),
+		RequireProfile("standard"),
+		MaxAge(time.Hour, later),
+	)
+	report, err = sq.VerifySecureProofWithPolicy(proof, key, strict)
+	if err == nil {
+		t.Fatal("Proof should violate the strict policy")
+	}
+	if len(report.Violations) != 3 {
+		t.Errorf("Expected 3 violations, got %+v", report.Violations)
+	}
+	for _, rule := range []string{"min_soundness", "allowed_hash_suites", "max_age"} {
+		if !strings.Contains(err.Error(), rule) {
+			t.Errorf("Aggregated error should mention %s: %v", rule, err)
+		}
+	}
+
+	// A header claiming a different hash suite fails cryptographic checks
+	tampered := *proof
+	tampered.HashSuite = "blake3"
+	if sq.VerifySecureProof(&tampered, key) {
+		t.Error("Proof with a modified hash suite should not verify")
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestPreimageKnowledge(t *testing.T) {
+	sq := newTestProver(t, 64)
+	key := testutil.Key()
+	preimage := []byte("correct horse battery staple")
+	digest := sha256.Sum256(preimage)
+
+	proof, err := sq.ProvePreimageKnowledge(PreimageHashSHA256, digest[:], preimage, key)
+	if err != nil {
+		t.Fatalf("ProvePreimageKnowledge failed: %v", err)
+	}
+	if !sq.VerifyPreimageKnowledge(proof, PreimageHashSHA256, digest[:], key) {
+		t.Fatal("Preimage proof should verify against its digest")
+	}
+
+	// The proof is bound to the published digest, not just any secret
+	other := sha256.Sum256([]byte("another secret"))
+	if sq.VerifyPreimageKnowledge(proof, PreimageHashSHA256, other[:], key) {
+		t.Error("Preimage proof must not verify against another digest")
+	}
+	if sq.VerifyPreimageKnowledge(proof, PreimageHashSHA3_256, digest[:], key) {
+		t.Error("Preimage proof must not verify under another hash algorithm")
+	}
+	plain, err := sq.SecureProveFromBytes(preimage, proof.Identifier, key)
+	if err != nil {
+		t.Fatalf("SecureProveFromBytes failed: %v", err)
+	}
+	if sq.VerifyPreimageKnowledge(plain, PreimageHashSHA256, digest[:], key) {
+		t.Error("A proof without the digest claims must not pass as a preimage proof")
+	}
+
+	if _, err := sq.ProvePreimageKnowledge(PreimageHashSHA256, other[:], preimage, key); !errors.Is(err, ErrPreimageMismatch) {
+		t.Errorf("Expected ErrPreimageMismatch, got %v", err)
+	}
+	if _, err := sq.ProvePreimageKnowledge(PreimageHashSHA512, digest[:], preimage, key); err == nil {
+		t.Error("Expected an error for a digest of the wrong length")
+	}
+	if _, err := sq.ProvePreimageKnowledge("md5", digest[:16], preimage, key); err == nil {
+		t.Error("Expected an error for an unsupported hash")
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+type progressEvent struct {
+	stage       string
+	done, total int
+}
+
+func TestProofGenerationProgress(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("progress-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	var events []progressEvent
+	sq.Progress = func(stage string, done, total int) {
+		events = append(events, progressEvent{stage, done, total})
+	}
+
+	key := testutil.Key()
+	if _, err := sq.SecureProveVectorKnowledge([]complex128{complex(0.6, 0), complex(0, 0.8)}, "progress_test", key); err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+
+	// Stages run in order, each starting at zero and ending complete
+	stage := -1
+	responses := 0
+	for i, e := range events {
+		if e.done < 0 || e.done > e.total {
+			t.Fatalf("Event %d out of range: %+v", i, e)
+		}
+		if stage < 0 || e.stage != ProgressStages[stage] {
+			stage++
+			if stage >= len(ProgressStages) || e.stage != ProgressStages[stage] || e.done != 0 {
+				t.Fatalf("Event %d starts an unexpected stage: %+v", i, e)
+			}
+		}
+		if e.stage == ProgressStageResponses && e.done > 0 {
+			responses++
+		}
+	}
+	last := events[len(events)-1]
+	if last.stage != ProgressStageSigning || last.done != last.total {
+		t.Errorf("Last event should complete signing, got %+v", last)
+	}
+	if responses != sq.SecurityParameter {
+		t.Errorf("Expected one responses event per challenge (%d), got %d", sq.SecurityParameter, responses)
+	}
+}
+
+func TestProgressMetricsAcrossServiceRequests(t *testing.T) {
+	s, err := NewProverService(ServiceConfig{
+		Dimensions:    3,
+		SecurityLevel: 128,
+		Application:   "progress-test",
+		Key:           "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+	})
+	if err != nil {
+		t.Fatalf("NewProverService failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Prove(&ProveRequest{Identifier: "progress_test", Data: []byte("payload")}); err != nil {
+				t.Errorf("Prove failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	snapshot := s.Metrics.Snapshot()
+	for _, stage := range ProgressStages {
+		if got := snapshot[stage].Completed; got != 4 {
+			t.Errorf("Stage %s completed %d times, expected 4", stage, got)
+		}
+	}
+	if got := snapshot[ProgressStageResponses].Updates; got <= 4 {
+		t.Errorf("Responses should report per-challenge updates, got %d", got)
+	}
+}
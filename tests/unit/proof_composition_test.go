@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestComposedProofAmplifiesSoundness(t *testing.T) {
+	sq, err := NewUltraSecureQuantumZKP(3, 256, []byte("composition-context"))
+	if err != nil {
+		t.Fatalf("NewUltraSecureQuantumZKP failed: %v", err)
+	}
+	created := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sq.Clock = func() time.Time { return created }
+
+	key := testutil.Key()
+	vector := []complex128{complex(0.6, 0), complex(0.8, 0), complex(0, 0), complex(0, 0)}
+
+	proof, err := sq.SecureProveComposed(vector, "archival_record", key, 512)
+	if err != nil {
+		t.Fatalf("SecureProveComposed failed: %v", err)
+	}
+
+	if !proof.Timestamp.Equal(created) {
+		t.Errorf("The composed proof should be timestamped by sq.Clock, got %v", proof.Timestamp)
+	}
+	if len(proof.Rounds) != 2 {
+		t.Errorf("Expected 2 rounds, got %d", len(proof.Rounds))
+	}
+	if proof.EffectiveSoundness != 512 {
+		t.Errorf("Expected 512-bit effective soundness, got %d", proof.EffectiveSoundness)
+	}
+	if proof.Rounds[0].CommitmentHash == proof.Rounds[1].CommitmentHash {
+		t.Error("Rounds should have independent commitments")
+	}
+
+	if !sq.VerifyComposedProof(proof, key) {
+		t.Fatal("Composed proof verification failed")
+	}
+
+	// Replaying the first round in place of the second must be rejected
+	proof.Rounds[1] = proof.Rounds[0]
+	if sq.VerifyComposedProof(proof, key) {
+		t.Error("Composed proof with a replayed round should not verify")
+	}
+}
+
+func TestCompositionRoundsLimit(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("composition-context"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+
+	rounds, err := sq.CompositionRounds(300)
+	if err != nil {
+		t.Fatalf("CompositionRounds failed: %v", err)
+	}
+	if rounds*sq.SecurityParameter < 300 {
+		t.Errorf("%d rounds of %d bits do not reach 300 bits", rounds, sq.SecurityParameter)
+	}
+
+	if _, err := sq.CompositionRounds(sq.SecurityParameter*MaxCompositionRounds + 1); err == nil {
+		t.Error("Expected an error when exceeding MaxCompositionRounds")
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestProofDebugEnvelope(t *testing.T) {
+	sq := newTestProver(t, 64)
+	vector := testutil.RampVector(8)
+
+	plain, err := sq.SecureProveVectorKnowledge(vector, "debug_off", testutil.Key())
+	if err != nil {
+		t.Fatalf("Proving failed: %v", err)
+	}
+	if plain.Debug != nil {
+		t.Fatal("Debug envelope should be off by default")
+	}
+
+	sq.Debug = true
+	proof, err := sq.SecureProveVectorKnowledge(vector, "debug_on", testutil.Key())
+	if err != nil {
+		t.Fatalf("Proving failed: %v", err)
+	}
+	debug := proof.Debug
+	if debug == nil {
+		t.Fatal("Debug envelope missing")
+	}
+	var names []string
+	for _, stage := range debug.Stages {
+		names = append(names, stage.Name)
+		if stage.Duration < 0 || stage.Duration > debug.Total {
+			t.Errorf("Stage %s took %s of a %s proof", stage.Name, stage.Duration, debug.Total)
+		}
+	}
+	want := "commitment challenges measurement responses merkle signing"
+	if strings.Join(names, " ") != want {
+		t.Errorf("Stages %v, want %s", names, want)
+	}
+	if debug.AllocatedBytes == 0 {
+		t.Error("Allocations should be counted")
+	}
+	if c := debug.Config; c.Dimensions != sq.Dimensions || c.Parameters.ChallengeCount != sq.ChallengeCount() ||
+		c.MeasurementBackend != "cpu" || c.Library != LibraryVersion || c.GOMAXPROCS == 0 {
+		t.Errorf("Unexpected configuration snapshot %+v", c)
+	}
+
+	// The envelope is outside the signed transcript
+	if !sq.VerifySecureProof(proof, testutil.Key()) {
+		t.Fatal("Proof with a debug envelope should verify")
+	}
+	digest, err := ProofDigest(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	altered := *proof
+	altered.Debug = &ProofDebug{Total: 1}
+	stripped := *proof
+	stripped.Debug = nil
+	for _, p := range []*SecureProof{&altered, &stripped} {
+		if !sq.VerifySecureProof(p, testutil.Key()) {
+			t.Error("Changing the debug envelope should not invalidate the proof")
+		}
+		if d, _ := ProofDigest(p); d != digest {
+			t.Error("The debug envelope should not change the proof digest")
+		}
+	}
+
+	encoded, err := json.Marshal(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed SecureProof
+	if err := json.Unmarshal(encoded, &parsed); err != nil || parsed.Debug == nil || len(parsed.Debug.Stages) != len(debug.Stages) {
+		t.Errorf("Debug envelope should survive a JSON round trip: %v", err)
+	}
+}
+
+func TestProofDebugRedaction(t *testing.T) {
+	sq := newTestProver(t, 64)
+	sq.Debug = true
+	sq.IdentifierKey = []byte("identifier-disclosure-key-0123456789")
+	sq.LinkageKey = []byte("linkage-detection-key-0123456789ab")
+	sq.Claims = map[string]string{"tenant": "secret-tenant-name"}
+	identifier := "patient-record-31337"
+	key := testutil.Key()
+	vector := testutil.RampVector(8)
+
+	proof, err := sq.SecureProveVectorKnowledge(vector, identifier, key)
+	if err != nil {
+		t.Fatalf("Proving failed: %v", err)
+	}
+	if !proof.Debug.Config.IdentifierPrivacy || !proof.Debug.Config.Linkage || proof.Debug.Config.Claims != 1 {
+		t.Errorf("Configuration should record which protections are on: %+v", proof.Debug.Config)
+	}
+	encoded, err := json.Marshal(proof.Debug)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope := string(encoded)
+
+	secrets := map[string][]byte{
+		"proof key":      key,
+		"identifier":     []byte(identifier),
+		"claim value":    []byte("secret-tenant-name"),
+		"identifier key": sq.IdentifierKey,
+		"linkage key":    sq.LinkageKey,
+	}
+	state, err := NewStateVector(vector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, amplitude := range state.Amplitudes() {
+		secrets[fmt.Sprintf("amplitude %d", i)] = []byte(fmt.Sprint(real(amplitude)))
+	}
+	for name, secret := range secrets {
+		for _, form := range []string{string(secret), hex.EncodeToString(secret), base64.StdEncoding.EncodeToString(secret)} {
+			if strings.Contains(envelope, form) {
+				t.Errorf("Debug envelope leaks the %s as %q", name, form)
+			}
+		}
+	}
+	// Every field the proof's signature covers stays out of the envelope
+	for _, field := range []string{proof.CommitmentHash, proof.MerkleRoot, proof.Identifier, proof.Signature} {
+		if field != "" && strings.Contains(envelope, field) {
+			t.Errorf("Debug envelope repeats proof field %q", field)
+		}
+	}
+}
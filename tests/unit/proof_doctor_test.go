@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestDiagnoseProof(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("doctor-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	key := testutil.Key()
+	proof, err := sq.SecureProveVectorKnowledge([]complex128{complex(0.6, 0), complex(0, 0.8)}, "doctor_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	data, _ := json.Marshal(proof)
+
+	d := DiagnoseProof(data)
+	if !d.Healthy() || d.Codec != "json" || d.ResponsesRecovered != len(proof.ChallengeResponse) {
+		t.Fatalf("Intact proof should be healthy: %+v", d)
+	}
+
+	// Truncation keeps the responses before the cut
+	cut := bytes.Index(data, []byte(`"merkle_root"`)) - 200
+	d = DiagnoseProof(data[:cut])
+	if !d.Truncated || d.Healthy() {
+		t.Errorf("Expected truncation to be reported: %+v", d)
+	}
+	if d.TruncatedIn != "challenge_response" {
+		t.Errorf("Expected truncation inside challenge_response, got %q", d.TruncatedIn)
+	}
+	if d.ResponsesRecovered == 0 || d.ResponsesRecovered >= len(proof.ChallengeResponse) {
+		t.Errorf("Expected a partial response count, got %d", d.ResponsesRecovered)
+	}
+
+	// Another codec is detected and reported
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	d = DiagnoseProof([]byte(base64.StdEncoding.EncodeToString(buf.Bytes())))
+	if d.Codec != "base64+gzip+json" || d.Truncated {
+		t.Errorf("Expected base64+gzip+json, got %+v", d)
+	}
+
+	// A modified response no longer matches the Merkle root
+	tampered := *proof
+	tampered.ChallengeResponse = append([]ChallengeResponse(nil), proof.ChallengeResponse...)
+	tampered.ChallengeResponse[3].Response = "00000000000000ff"
+	data, _ = json.Marshal(&tampered)
+	d = DiagnoseProof(data)
+	if d.Healthy() || !strings.Contains(strings.Join(d.Causes, "\n"), "tampering") {
+		t.Errorf("Expected tampering to be suggested: %v", d.Causes)
+	}
+
+	if d := DiagnoseProof([]byte("not a proof")); d.Codec != "" || d.Healthy() {
+		t.Errorf("Garbage input should be unrecognized: %+v", d)
+	}
+}
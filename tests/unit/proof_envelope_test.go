@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestProofJWS(t *testing.T) {
+	sq, proof := newTestProof(t, 64)
+	for _, codec := range ProofCodecs() {
+		token, payload, err := sq.EncodeProofJWS(proof, codec)
+		if err != nil {
+			t.Fatalf("EncodeProofJWS(%s) failed: %v", codec, err)
+		}
+		if strings.Count(token, ".") != 2 || !strings.Contains(token, "..") {
+			t.Fatalf("Token should be a detached compact JWS: %.40s", token)
+		}
+		decoded, header, err := sq.DecodeProofJWS(token, payload)
+		if err != nil {
+			t.Fatalf("DecodeProofJWS(%s) failed: %v", codec, err)
+		}
+		if header.Codec != codec || header.Version != proof.Version || header.KeyID != KeyFingerprint(sq.Signer.PublicKeyBytes()) {
+			t.Errorf("Unexpected header %+v", header)
+		}
+		if !sq.VerifySecureProof(decoded, testutil.Key()) {
+			t.Errorf("Proof carried with codec %s should verify", codec)
+		}
+		if _, _, err := sq.DecodeProofJWS(token, testutil.FlipByte(payload, len(payload)/2)); err == nil {
+			t.Errorf("A tampered %s payload should be rejected", codec)
+		}
+	}
+
+	token, payload, _ := sq.EncodeProofJWS(proof, "json")
+	other := newTestProver(t, 64)
+	if _, _, err := other.DecodeProofJWS(token, payload); err == nil {
+		t.Error("An envelope signed by another key should be rejected")
+	}
+	parts := strings.Split(token, ".")
+	if _, _, err := sq.DecodeProofJWS(parts[0]+".e30."+parts[2], payload); err == nil {
+		t.Error("An attached payload should be rejected")
+	}
+	if _, _, err := sq.EncodeProofJWS(proof, "zstd"); err == nil {
+		t.Error("An unknown codec should be rejected")
+	}
+}
+
+func TestProofCOSE(t *testing.T) {
+	sq, proof := newTestProof(t, 64)
+	message, err := sq.EncodeProofCOSE(proof, "gzip+json")
+	if err != nil {
+		t.Fatalf("EncodeProofCOSE failed: %v", err)
+	}
+	// Tag 18 followed by a four-item array
+	if !bytes.HasPrefix(message, []byte{0xd2, 0x84}) {
+		t.Fatalf("Message should be a tagged COSE_Sign1, starts %x", message[:2])
+	}
+	decoded, header, err := sq.DecodeProofCOSE(message)
+	if err != nil {
+		t.Fatalf("DecodeProofCOSE failed: %v", err)
+	}
+	want := &EnvelopeHeader{
+		Algorithm: SignatureAlgorithmMLDSA87,
+		KeyID:     KeyFingerprint(sq.Signer.PublicKeyBytes()),
+		Type:      EnvelopeContentType,
+		Profile:   proof.Profile,
+		Codec:     "gzip+json",
+		Version:   proof.Version,
+	}
+	if !reflect.DeepEqual(header, want) {
+		t.Errorf("Header %+v, want %+v", header, want)
+	}
+	if !sq.VerifySecureProof(decoded, testutil.Key()) {
+		t.Error("Proof carried in COSE should verify")
+	}
+
+	if _, _, err := sq.DecodeProofCOSE(message[1:]); err != nil {
+		t.Errorf("An untagged COSE_Sign1 should decode: %v", err)
+	}
+	for _, offset := range []int{10, len(message) / 2, len(message) - 10} {
+		if _, _, err := sq.DecodeProofCOSE(testutil.FlipByte(message, offset)); err == nil {
+			t.Errorf("A message altered at byte %d should be rejected", offset)
+		}
+	}
+	if _, _, err := sq.DecodeProofCOSE(testutil.Truncate(message, -1)); err == nil {
+		t.Error("A truncated message should be rejected")
+	}
+	if _, _, err := newTestProver(t, 64).DecodeProofCOSE(message); err == nil {
+		t.Error("A message signed by another key should be rejected")
+	}
+}
+
+func TestCBOR(t *testing.T) {
+	// Examples from RFC 8949 appendix A
+	for _, tc := range []struct {
+		value   interface{}
+		encoded string
+	}{
+		{int64(0), "00"},
+		{int64(23), "17"},
+		{int64(24), "1818"},
+		{int64(1000000), "1a000f4240"},
+		{int64(-1000), "3903e7"},
+		{"IETF", "6449455446"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+		{[]interface{}{int64(1), []interface{}{int64(2), int64(3)}}, "8201820203"},
+		{cborMap{{int64(1), int64(2)}, {int64(3), int64(4)}}, "a201020304"},
+		{cborTag{Number: 1, Value: int64(1363896240)}, "c11a514b67b0"},
+		{nil, "f6"},
+	} {
+		encoded, err := cborEncode(tc.value)
+		if err != nil || hex.EncodeToString(encoded) != tc.encoded {
+			t.Errorf("cborEncode(%v) = %x (%v), want %s", tc.value, encoded, err, tc.encoded)
+			continue
+		}
+		decoded, err := cborDecode(encoded)
+		if err != nil || !reflect.DeepEqual(decoded, tc.value) {
+			t.Errorf("cborDecode(%s) = %#v (%v)", tc.encoded, decoded, err)
+		}
+	}
+
+	for name, bad := range map[string]string{
+		"truncated":     "1a000f",
+		"trailing":      "0000",
+		"indefinite":    "9fff",
+		"float":         "f93c00",
+		"duplicate key": "a201020103",
+		"byte key":      "a1410102",
+		"long string":   "5a7fffffff00",
+	} {
+		data, _ := hex.DecodeString(bad)
+		if _, err := cborDecode(data); err == nil {
+			t.Errorf("Invalid CBOR (%s) should be rejected", name)
+		}
+	}
+}
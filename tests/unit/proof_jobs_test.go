@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newJobTestService(t *testing.T) *ProverService {
+	t.Helper()
+	s, err := NewProverService(ServiceConfig{
+		Dimensions:    3,
+		SecurityLevel: 128,
+		Application:   "jobs-test",
+		Key:           "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+	})
+	if err != nil {
+		t.Fatalf("NewProverService failed: %v", err)
+	}
+	return s
+}
+
+// waitForJob waits for the next completion reported on done.
+func waitForJob(t *testing.T, done <-chan JobInfo) JobInfo {
+	t.Helper()
+	select {
+	case info := <-done:
+		return info
+	case <-time.After(time.Minute):
+		t.Fatal("Timed out waiting for a job")
+		return JobInfo{}
+	}
+}
+
+func TestProofJobQueue(t *testing.T) {
+	service := newJobTestService(t)
+	store, err := NewProofStore(NewDataDirs(t.TempDir()))
+	if err != nil {
+		t.Fatalf("NewProofStore failed: %v", err)
+	}
+
+	callbacks := make(chan JobInfo, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var info JobInfo
+		json.NewDecoder(r.Body).Decode(&info)
+		callbacks <- info
+	}))
+	defer server.Close()
+
+	done := make(chan JobInfo, 4)
+	q, err := NewProofJobQueue(store, service.Prove, ProofJobOptions{Workers: 2, OnComplete: func(info JobInfo) { done <- info }})
+	if err != nil {
+		t.Fatalf("NewProofJobQueue failed: %v", err)
+	}
+	defer q.Close(context.Background())
+
+	id, err := q.SubmitProofJob(&ProofJobRequest{
+		ProveRequest: ProveRequest{Identifier: "job_test", Data: []byte("secret payload")},
+		CallbackURL:  server.URL,
+	})
+	if err != nil {
+		t.Fatalf("SubmitProofJob failed: %v", err)
+	}
+	if info := waitForJob(t, done); info.ID != id || info.State != JobSucceeded || info.CallbackError != "" {
+		t.Fatalf("Unexpected completion: %+v", info)
+	}
+	if info := <-callbacks; info.ID != id || info.State != JobSucceeded {
+		t.Errorf("Callback received %+v", info)
+	}
+
+	proof, err := q.JobResult(id)
+	if err != nil {
+		t.Fatalf("JobResult failed: %v", err)
+	}
+	if resp, err := service.Verify(&VerifyRequest{Proof: proof}); err != nil || !resp.Valid {
+		t.Errorf("Job proof should verify: %v", err)
+	}
+	record, err := os.ReadFile(filepath.Join(store.Dir, string(id)+".job.json"))
+	if err != nil || strings.Contains(string(record), `"data"`) {
+		t.Errorf("Finished job record should not keep the data: %v", err)
+	}
+
+	if _, err := q.JobStatus("unknown"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("Expected ErrJobNotFound, got %v", err)
+	}
+	if _, err := q.SubmitProofJob(&ProofJobRequest{ProveRequest: ProveRequest{Identifier: "job_test"}}); err == nil {
+		t.Error("A job without data should be refused")
+	}
+}
+
+func TestProofJobQueueResumesAfterRestart(t *testing.T) {
+	service := newJobTestService(t)
+	store, err := NewProofStore(NewDataDirs(t.TempDir()))
+	if err != nil {
+		t.Fatalf("NewProofStore failed: %v", err)
+	}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	gated := func(req *ProveRequest) (*ProveResponse, error) {
+		started <- struct{}{}
+		<-release
+		return service.Prove(req)
+	}
+	first, err := NewProofJobQueue(store, gated, ProofJobOptions{Workers: 1})
+	if err != nil {
+		t.Fatalf("NewProofJobQueue failed: %v", err)
+	}
+	running, err := first.SubmitProofJob(&ProofJobRequest{ProveRequest: ProveRequest{Identifier: "running", Data: []byte("one")}})
+	if err != nil {
+		t.Fatalf("SubmitProofJob failed: %v", err)
+	}
+	<-started
+	queued, err := first.SubmitProofJob(&ProofJobRequest{ProveRequest: ProveRequest{Identifier: "queued", Data: []byte("two")}})
+	if err != nil {
+		t.Fatalf("SubmitProofJob failed: %v", err)
+	}
+	if _, err := first.JobResult(queued); !errors.Is(err, ErrJobPending) {
+		t.Errorf("Expected ErrJobPending, got %v", err)
+	}
+
+	// Stop taking jobs while the first is still running
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	first.Close(cancelled)
+	if _, err := first.SubmitProofJob(&ProofJobRequest{ProveRequest: ProveRequest{Identifier: "late", Data: []byte("three")}}); !errors.Is(err, ErrDraining) {
+		t.Errorf("Expected ErrDraining, got %v", err)
+	}
+	close(release)
+	if err := first.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	done := make(chan JobInfo, 1)
+	second, err := NewProofJobQueue(store, service.Prove, ProofJobOptions{OnComplete: func(info JobInfo) { done <- info }})
+	if err != nil {
+		t.Fatalf("NewProofJobQueue failed: %v", err)
+	}
+	defer second.Close(context.Background())
+	if info, err := second.JobStatus(running); err != nil || info.State != JobSucceeded {
+		t.Errorf("Job finished before the restart should stay succeeded: %+v, %v", info, err)
+	}
+	if info := waitForJob(t, done); info.ID != queued || info.State != JobSucceeded {
+		t.Fatalf("Queued job should be resumed: %+v", info)
+	}
+	if _, err := second.JobResult(queued); err != nil {
+		t.Errorf("JobResult failed: %v", err)
+	}
+}
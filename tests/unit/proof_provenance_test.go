@@ -0,0 +1,109 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestProofRecordsProvenance(t *testing.T) {
+	sq, proof := newTestProof(t, 128)
+	if proof.Provenance == nil || proof.Provenance.Library != LibraryVersion {
+		t.Fatalf("Proof should record library %s, got %+v", LibraryVersion, proof.Provenance)
+	}
+	if !slices.Contains(proof.Provenance.Features, FeatureRandomnessProvenance) {
+		t.Errorf("Provenance features %v should include %s", proof.Provenance.Features, FeatureRandomnessProvenance)
+	}
+	if !sq.VerifySecureProof(proof, testutil.Key()) {
+		t.Fatal("Proof with provenance should verify")
+	}
+
+	// Provenance is signed
+	forged := *proof
+	forged.Provenance = &ProofProvenance{Library: "0.3.0", Features: proof.Provenance.Features}
+	if sq.VerifySecureProof(&forged, testutil.Key()) {
+		t.Error("Proof with altered provenance should not verify")
+	}
+}
+
+func TestCheckCompatibilityAgainstVersionMatrix(t *testing.T) {
+	_, proof := newTestProof(t, 128)
+	report, err := CheckCompatibility(proof)
+	if err != nil {
+		t.Fatalf("CheckCompatibility failed: %v", err)
+	}
+	if report.Library != LibraryVersion || report.ProofVersion != CurrentProofVersion {
+		t.Errorf("Report %+v", report)
+	}
+	if !report.Compatible(LibraryVersion) || report.Compatible("0.3.0") || report.Compatible("0.1.0") {
+		t.Errorf("Only the current release should verify a current proof, got %v", report.Verifiers)
+	}
+	for _, gap := range report.Gaps {
+		if gap.Library == "0.3.0" && !slices.Contains(gap.Reasons, "does not support "+FeatureProvenance) {
+			t.Errorf("0.3.0 gap should name the provenance, got %v", gap.Reasons)
+		}
+	}
+	for _, issue := range report.Issues {
+		if issue.Severity != IssueInfo {
+			t.Errorf("Current proof should have no known issues, got %+v", issue)
+		}
+	}
+
+	// A proof from before provenance and Fiat–Shamir challenges
+	legacy := &SecureProof{ChallengeResponse: []ChallengeResponse{{BasisChoice: "Z"}}}
+	report, _ = CheckCompatibility(legacy)
+	if report.ProofVersion != ProofVersionLegacy || len(report.Verifiers) != len(LibraryReleases()) {
+		t.Errorf("Every release should verify a version 1 proof, got %+v", report)
+	}
+	var ids []string
+	for _, issue := range report.Issues {
+		ids = append(ids, issue.ID)
+	}
+	for _, id := range []string{"pre-fiat-shamir", "unbound-context", "unbound-parameters", "truncated-digests", "unrecorded-provenance"} {
+		if !slices.Contains(ids, id) {
+			t.Errorf("Legacy proof should have issue %s, got %v", id, ids)
+		}
+	}
+
+	// Features recorded by a newer release count even if not recognizable
+	future := *proof
+	future.Provenance = &ProofProvenance{Library: "9.0.0", Features: []string{"quantum_teleportation"}}
+	report, _ = CheckCompatibility(&future)
+	if len(report.Verifiers) != 0 {
+		t.Errorf("No release should verify an unknown feature, got %v", report.Verifiers)
+	}
+	if !slices.ContainsFunc(report.Issues, func(issue KnownIssue) bool { return issue.ID == "provenance-mismatch" }) {
+		t.Error("Mismatched provenance features should be reported")
+	}
+
+	if _, err := CheckCompatibility(nil); err == nil {
+		t.Error("A nil proof should be refused")
+	}
+}
+
+func TestBatchSignedProofsRecordProvenance(t *testing.T) {
+	sq := newTestProver(t, 64)
+	sq.BatchSignatures = true
+	var states []*StateVector
+	for _, dimension := range []int{4, 8} {
+		state, err := NewStateVector(testutil.UniformVector(dimension))
+		if err != nil {
+			t.Fatal(err)
+		}
+		states = append(states, state)
+	}
+	proofs, err := sq.SecureProveBatch(states, []string{"a", "b"}, testutil.Key())
+	if err != nil {
+		t.Fatalf("SecureProveBatch failed: %v", err)
+	}
+	for _, proof := range proofs {
+		report, _ := CheckCompatibility(proof)
+		if proof.Provenance == nil || !slices.Contains(report.Features, FeatureBatchSignature) || !report.Compatible(LibraryVersion) {
+			t.Errorf("Batch-signed proof report %+v", report)
+		}
+		if !sq.VerifySecureProof(proof, testutil.Key()) {
+			t.Error("Batch-signed proof should verify")
+		}
+	}
+}
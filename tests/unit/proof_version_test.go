@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestProofVersioning(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("version-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	key := testutil.Key()
+	proof, err := sq.SecureProveVectorKnowledge([]complex128{complex(0.6, 0), complex(0, 0.8)}, "version_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if proof.Version != CurrentProofVersion {
+		t.Fatalf("Expected version %d, got %d", CurrentProofVersion, proof.Version)
+	}
+
+	data, _ := json.Marshal(proof)
+	parsed, err := ParseSecureProof(data)
+	if err != nil {
+		t.Fatalf("ParseSecureProof failed: %v", err)
+	}
+	result := sq.VerifySecureProofVersioned(parsed, key)
+	if !result.Valid || len(result.Caveats) != 0 {
+		t.Errorf("Current proof should verify without caveats: %+v", result)
+	}
+
+	// Rebuild a proof with the original truncated digests in the original
+	// unversioned layout, signed without a context string
+	sq.DigestLengths = LegacyDigestLengths
+	proof, err = sq.SecureProveVectorKnowledge([]complex128{complex(0.6, 0), complex(0, 0.8)}, "version_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	v1 := secureProofV1{
+		QuantumDimensions: proof.QuantumDimensions,
+		CommitmentHash:    proof.CommitmentHash,
+		ChallengeResponse: proof.ChallengeResponse,
+		MerkleRoot:        proof.MerkleRoot,
+		StateMetadata:     proof.StateMetadata,
+		Identifier:        proof.Identifier,
+		Timestamp:         proof.Timestamp,
+	}
+	msg, _ := json.Marshal(&v1)
+	legacySigner := *sq.Signer
+	legacySigner.Ctx = nil
+	sig, err := legacySigner.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	v1.Signature = hex.EncodeToString(sig)
+	data, _ = json.Marshal(&v1)
+
+	legacy, err := ParseSecureProof(data)
+	if err != nil {
+		t.Fatalf("ParseSecureProof failed for v1: %v", err)
+	}
+	if legacy.Version != ProofVersionLegacy || legacy.HashSuite != HashSuiteSHA256 {
+		t.Errorf("v1 proof not migrated: version %d, hash suite %q", legacy.Version, legacy.HashSuite)
+	}
+	if sq.VerifySecureProof(legacy, key) {
+		t.Error("Strict verification must reject v1 proofs")
+	}
+	result = sq.VerifySecureProofVersioned(legacy, key)
+	if !result.Valid || result.Version != ProofVersionLegacy || len(result.Caveats) == 0 {
+		t.Errorf("v1 proof should verify with caveats: %+v", result)
+	}
+
+	legacy.Identifier = "other"
+	if sq.VerifySecureProofVersioned(legacy, key).Valid {
+		t.Error("Tampered v1 proof should not verify")
+	}
+
+	if _, err := ParseSecureProof([]byte(`{"version": 99}`)); err == nil {
+		t.Error("Expected an error for an unknown version")
+	}
+}
@@ -0,0 +1,9 @@
+// Verify-only builds have no hybrid generator; see
+// quantum_safe_random_test.go.
+
+//go:build qzkp_verify
+
+package main
+
+// defaultRandomness is the class new instances draw their randomness from.
+const defaultRandomness = RandomnessSystem
@@ -0,0 +1,42 @@
+// The hybrid generator is compiled out of verify-only builds; see
+// quantum_safe_random.go.
+
+//go:build !qzkp_verify
+
+package main
+
+import (
+	"io"
+	"sync"
+	"testing"
+)
+
+// defaultRandomness is the class new instances draw their randomness from.
+const defaultRandomness = RandomnessHybrid
+
+func TestHybridRandomGeneratorIsConcurrent(t *testing.T) {
+	hrg, err := NewHybridRandomGenerator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	outputs := make([][]byte, 8)
+	for i := range outputs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			outputs[i] = make([]byte, 32)
+			if _, err := io.ReadFull(hrg, outputs[i]); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	seen := make(map[string]bool)
+	for _, out := range outputs {
+		if seen[string(out)] {
+			t.Fatal("Hybrid generator repeated an output")
+		}
+		seen[string(out)] = true
+	}
+}
@@ -502,16 +502,25 @@ func testPerformanceClaims(t *testing.T, ctx []byte) {
 	// Test different security levels as claimed in paper
 	// Note: CI environments are slower, so we use more generous time limits
 	securityTests := []struct {
-		name         string
-		bits         int
-		maxGenTime   time.Duration
-		maxProofSize int
+		name       string
+		bits       int
+		maxGenTime time.Duration
 	}{
-		{"80-bit", 80, 25 * time.Millisecond, 25000},   // Paper: ~20KB, <2ms (CI: <25ms)
-		{"128-bit", 128, 30 * time.Millisecond, 30000}, // Paper: ~26KB, <2ms (CI: <30ms)
-		{"256-bit", 256, 50 * time.Millisecond, 45000}, // Paper: ~42KB, <3ms (CI: <50ms)
+		{"80-bit", 80, 25 * time.Millisecond},   // Paper: <2ms (CI: <25ms)
+		{"128-bit", 128, 30 * time.Millisecond}, // Paper: <2ms (CI: <30ms)
+		{"256-bit", 256, 50 * time.Millisecond}, // Paper: <3ms (CI: <50ms)
 	}
 
+	// The paper's sizes (~20KB, ~26KB, ~42KB) assume truncated digests,
+	// while digests are now 2·bits/8 bytes, so the limit follows the
+	// encoding: a header dominated by the hex ML-DSA-87 signature (~9KB),
+	// plus per challenge three hex digests of the response length and the
+	// keys, index and basis around them.
+	const (
+		proofHeaderBudget  = 16 << 10
+		responseJSONBudget = 128
+	)
+
 	for _, test := range securityTests {
 		sq, err := NewSecureQuantumZKPWithSoundness(3, 128, test.bits, ctx)
 		if err != nil {
@@ -538,8 +547,9 @@ func testPerformanceClaims(t *testing.T, ctx []byte) {
 		if genTime > test.maxGenTime {
 			t.Errorf("%s generation time %v exceeds paper claim %v", test.name, genTime, test.maxGenTime)
 		}
-		if proofSize > test.maxProofSize {
-			t.Errorf("%s proof size %d exceeds paper claim %d", test.name, proofSize, test.maxProofSize)
+		maxProofSize := proofHeaderBudget + len(proof.ChallengeResponse)*(responseJSONBudget+3*2*sq.DigestLengths.Response)
+		if proofSize > maxProofSize {
+			t.Errorf("%s proof size %d exceeds encoding budget %d", test.name, proofSize, maxProofSize)
 		}
 
 		// Verify proof works
@@ -718,14 +728,19 @@ func TestQuantumStateVectorInit(t *testing.T) {
 		t.Errorf("Expected entanglement ~0 for basis state, got %f", qsv.Entanglement)
 	}
 
-	// Test superposition vector
+	// A superposition of one qubit is still a product state
 	sqrt2 := 1.0 / math.Sqrt(2)
 	superpos := []complex128{complex(sqrt2, 0), complex(sqrt2, 0), complex(0, 0), complex(0, 0)}
 	qsv2 := NewQuantumStateVector(superpos)
+	if qsv2.Entanglement > 1e-10 {
+		t.Errorf("Expected entanglement ~0 for a product superposition, got %f", qsv2.Entanglement)
+	}
 
-	// Superposition should have non-zero entanglement
-	if qsv2.Entanglement < 0.1 {
-		t.Errorf("Expected non-zero entanglement for superposition, got %f", qsv2.Entanglement)
+	// The Bell state (|00⟩+|11⟩)/√2 is maximally entangled
+	bell := []complex128{complex(sqrt2, 0), complex(0, 0), complex(0, 0), complex(sqrt2, 0)}
+	qsv3 := NewQuantumStateVector(bell)
+	if math.Abs(qsv3.Entanglement-1) > 1e-9 {
+		t.Errorf("Expected entanglement 1 for a Bell state, got %f", qsv3.Entanglement)
 	}
 
 	// Test empty vector (should panic)
@@ -1106,3 +1121,56 @@ func TestQuantumSafeRandomIntegration(t *testing.T) {
 
 	t.Log("Quantum-safe random integration test passed successfully")
 }
+
+func TestSuperpositionModes(t *testing.T) {
+	q, err := NewQuantumZKP(3, 128, []byte("superposition-test"))
+	if err != nil {
+		t.Fatalf("NewQuantumZKP failed: %v", err)
+	}
+	key := []byte("12345678901234567890123456789012")
+	data := []byte("superposition mode test data")
+
+	// Byte proofs are deterministic by default
+	first, err := q.ProveFromBytes(data, "bytes", key)
+	if err != nil {
+		t.Fatalf("ProveFromBytes failed: %v", err)
+	}
+	second, _ := q.ProveFromBytes(data, "bytes", key)
+	if first.Commitment != second.Commitment || first.StateMetadata.SuperpositionMode != SuperpositionDeterministic {
+		t.Errorf("Byte proofs should be deterministic: %s", first.StateMetadata.SuperpositionMode)
+	}
+	if !q.VerifyProof(first, key) {
+		t.Error("Deterministic proof should verify")
+	}
+	forged := *first
+	forged.Amplitudes = append([]float64(nil), first.Amplitudes...)
+	forged.Amplitudes[0], forged.Amplitudes[1] = forged.Amplitudes[1], forged.Amplitudes[0]
+	if forged.Amplitudes[0] != forged.Amplitudes[1] && q.VerifyProof(&forged, key) {
+		t.Error("Deterministic proof with other amplitudes must not verify")
+	}
+
+	// State proofs stay randomized, and an injected RNG makes them repeatable
+	proof, err := q.Prove(loadVector(), "vector", key)
+	if err != nil {
+		t.Fatalf("Prove failed: %v", err)
+	}
+	if proof.StateMetadata.SuperpositionMode != SuperpositionRandomized || !q.VerifyProof(proof, key) {
+		t.Errorf("Prove should record the randomized mode: %s", proof.StateMetadata.SuperpositionMode)
+	}
+	seeded := func() *Proof {
+		q.Superposition = SuperpositionOptions{Mode: SuperpositionRandomized, Rand: strings.NewReader(strings.Repeat("\x01\x22\x33\x44", 64))}
+		p, err := q.Prove(loadVector(), "vector", key)
+		if err != nil {
+			t.Fatalf("Prove with an injected RNG failed: %v", err)
+		}
+		return p
+	}
+	if a, b := seeded(), seeded(); a.Commitment != b.Commitment {
+		t.Error("The same RNG stream should give the same superposition")
+	}
+
+	q.Superposition = SuperpositionOptions{Mode: "quantum"}
+	if _, err := q.Prove(loadVector(), "vector", key); err == nil {
+		t.Error("Expected an error for an unknown superposition mode")
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+// failingReader fails every read once failed is set.
+type failingReader struct {
+	failed atomic.Bool
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.failed.Load() {
+		return 0, errors.New("device unplugged")
+	}
+	return rand.Read(p)
+}
+
+// constantReader returns the same byte forever, failing the health tests.
+type constantReader struct{}
+
+func (constantReader) Read(p []byte) (int, error) {
+	copy(p, bytes.Repeat([]byte{0x42}, len(p)))
+	return len(p), nil
+}
+
+func TestRandomnessChainFallsBackInOrder(t *testing.T) {
+	qrng := &failingReader{}
+	chain, err := NewRandomnessChain(
+		RandomnessSource{Class: RandomnessKyberStream, Reader: constantReader{}},
+		RandomnessSource{Class: RandomnessQRNG, Reader: qrng},
+		SystemRandomnessSource(),
+	)
+	if err != nil {
+		t.Fatalf("NewRandomnessChain failed: %v", err)
+	}
+	sq := newTestProver(t, 64)
+	sq.Randomness = chain
+
+	// The stream fails its health test, so the QRNG serves
+	proof, err := sq.SecureProveVectorKnowledge(testutil.RampVector(8), "doc", testutil.Key())
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if len(proof.Randomness) != 1 || proof.Randomness[0] != RandomnessQRNG {
+		t.Errorf("Expected QRNG provenance, got %v", proof.Randomness)
+	}
+	if !sq.VerifySecureProof(proof, testutil.Key()) {
+		t.Error("Proof with randomness provenance was rejected")
+	}
+
+	// Once the QRNG fails, proofs are system-only
+	qrng.failed.Store(true)
+	proof, err = sq.SecureProveVectorKnowledge(testutil.RampVector(8), "doc", testutil.Key())
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if len(proof.Randomness) != 1 || proof.Randomness[0] != RandomnessSystem {
+		t.Errorf("Expected system provenance, got %v", proof.Randomness)
+	}
+	if err := NewPolicy(AllowedRandomness(RandomnessKyberStream, RandomnessQRNG)).Evaluate(proof).Err(); err == nil {
+		t.Error("A system-only proof should fail a hybrid-entropy policy")
+	}
+
+	status := chain.Status()
+	if status[0].Healthy || status[1].Healthy || !status[2].Healthy {
+		t.Errorf("Unexpected chain status: %+v", status)
+	}
+
+	// A failed source stays out until the chain is reset
+	qrng.failed.Store(false)
+	if class, _ := chain.read(make([]byte, 32)); class != RandomnessSystem {
+		t.Errorf("A failed source served before Reset: %s", class)
+	}
+	chain.Reset()
+	if class, _ := chain.read(make([]byte, 32)); class != RandomnessQRNG {
+		t.Errorf("Expected the QRNG to serve again after Reset, got %s", class)
+	}
+}
+
+func TestRandomnessChainExhausted(t *testing.T) {
+	chain, err := NewRandomnessChain(RandomnessSource{Class: "stuck", Reader: constantReader{}})
+	if err != nil {
+		t.Fatalf("NewRandomnessChain failed: %v", err)
+	}
+	sq := newTestProver(t, 64)
+	sq.Randomness = chain
+	if _, err := sq.SecureProveVectorKnowledge(testutil.RampVector(8), "doc", testutil.Key()); !errors.Is(err, ErrNoHealthyRandomness) {
+		t.Errorf("Expected ErrNoHealthyRandomness, got %v", err)
+	}
+	if _, err := NewRandomnessChain(RandomnessSource{Class: "Bad Class", Reader: rand.Reader}); err == nil {
+		t.Error("Expected an error for an invalid class")
+	}
+}
+
+func TestRandomnessProvenanceIsBound(t *testing.T) {
+	sq := newTestProver(t, 64)
+	proof, err := sq.SecureProveVectorKnowledge(testutil.RampVector(8), "doc", testutil.Key())
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if len(proof.Randomness) != 1 || proof.Randomness[0] != defaultRandomness {
+		t.Fatalf("Expected %s provenance by default, got %v", defaultRandomness, proof.Randomness)
+	}
+
+	proof.Randomness = []string{RandomnessQRNG}
+	if sq.VerifySecureProof(proof, testutil.Key()) {
+		t.Error("Rewritten provenance should break the signature")
+	}
+	proof.Randomness = []string{RandomnessSystem, RandomnessSystem}
+	sq.signSecureProof(proof, testutil.Key())
+	if sq.VerifySecureProof(proof, testutil.Key()) {
+		t.Error("Duplicate provenance classes should be rejected")
+	}
+
+	// A caller-supplied source is recorded as such
+	sq.Rand = io.LimitReader(rand.Reader, 1<<20)
+	proof, err = sq.SecureProveVectorKnowledge(testutil.RampVector(8), "doc", testutil.Key())
+	if err != nil || len(proof.Randomness) != 1 || proof.Randomness[0] != RandomnessCaller {
+		t.Errorf("Expected caller provenance, got %v (%v)", proof.Randomness, err)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestVerificationReceipts(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("receipt-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	key := testutil.Key()
+	proof, err := sq.SecureProveVectorKnowledge([]complex128{complex(0.6, 0), complex(0, 0.8)}, "receipt_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+
+	verifierKey, err := NewSignatureScheme([]byte("receipts"))
+	if err != nil {
+		t.Fatalf("NewSignatureScheme failed: %v", err)
+	}
+	receipt, err := sq.IssueReceipt(proof, key, verifierKey)
+	if err != nil {
+		t.Fatalf("IssueReceipt failed: %v", err)
+	}
+	if receipt.Decision != ReceiptAccepted {
+		t.Fatalf("Valid proof should be accepted, got %q", receipt.Decision)
+	}
+
+	// A third party holding only the verifier's public key checks it
+	public, err := NewVerificationScheme(verifierKey.PublicKeyBytes(), []byte("receipts"))
+	if err != nil {
+		t.Fatalf("NewVerificationScheme failed: %v", err)
+	}
+	if err := VerifyReceipt(receipt, proof, public); err != nil {
+		t.Errorf("Receipt should verify: %v", err)
+	}
+
+	forged := *receipt
+	forged.Decision = ReceiptRejected
+	if VerifyReceipt(&forged, proof, public) == nil {
+		t.Error("Altered decision should invalidate the receipt")
+	}
+
+	other, _ := sq.SecureProveVectorKnowledge([]complex128{complex(1, 0), complex(0, 0)}, "receipt_test", key)
+	if VerifyReceipt(receipt, other, public) == nil {
+		t.Error("Receipt should not vouch for a different proof")
+	}
+
+	impostor, _ := NewSignatureScheme([]byte("receipts"))
+	if VerifyReceipt(receipt, proof, impostor) == nil {
+		t.Error("Receipt should not verify under another verifier key")
+	}
+
+	// Rejections are receipted as such
+	tampered := *proof
+	tampered.MerkleRoot = other.MerkleRoot
+	rejected, err := sq.IssueReceipt(&tampered, key, verifierKey)
+	if err != nil || rejected.Decision != ReceiptRejected {
+		t.Errorf("Expected a rejection receipt, got %v", err)
+	}
+}
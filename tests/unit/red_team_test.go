@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestRunRedTeam(t *testing.T) {
+	sq := newTestProver(t, 80)
+	verifier, err := NewVerifier(sq, VerifierConfig{})
+	if err != nil {
+		t.Fatalf("NewVerifier failed: %v", err)
+	}
+	report, err := RunRedTeam(verifier, RedTeamOptions{Trials: 4})
+	if err != nil {
+		t.Fatalf("RunRedTeam failed: %v", err)
+	}
+	if err := report.Err(); err != nil {
+		t.Fatalf("Verifier admitted a cheating strategy: %v", err)
+	}
+	if len(report.Strategies) != len(RedTeamStrategies()) {
+		t.Fatalf("Report should cover %d strategies, got %d", len(RedTeamStrategies()), len(report.Strategies))
+	}
+	results := make(map[string]RedTeamResult)
+	for _, r := range report.Strategies {
+		results[r.Name] = r
+		if r.Trials != 4 {
+			t.Errorf("%s ran %d trials, want 4", r.Name, r.Trials)
+		}
+		t.Logf("%-24s detectable=%-5v accepted %d/%d", r.Name, r.Detectable, r.Accepted, r.Trials)
+	}
+	for _, name := range []string{RedTeamChosenChallenges, RedTeamMerkleCorruption, RedTeamForeignSigningKey, RedTeamSessionSplice, RedTeamChallengeRace} {
+		if r := results[name]; !r.Detectable || r.Accepted != 0 {
+			t.Errorf("%s should always be caught: %+v", name, r)
+		}
+	}
+	// Without a nonce store a replayed commitment cannot be noticed
+	if r := results[RedTeamNonceReuse]; r.Detectable || r.AcceptanceRate != 1 {
+		t.Errorf("Nonce reuse should go unnoticed without a nonce store: %+v", r)
+	}
+
+	replayProtected, _ := NewVerifier(sq, VerifierConfig{Options: VerifyOptions{NonceStore: NewMemoryNonceStore()}})
+	report, err = RunRedTeam(replayProtected, RedTeamOptions{Trials: 2, Strategies: []string{RedTeamNonceReuse}})
+	if err != nil {
+		t.Fatalf("RunRedTeam failed: %v", err)
+	}
+	if r := report.Strategies[0]; !report.Passed || !r.Detectable || r.Accepted != 0 {
+		t.Errorf("Nonce store should catch every reused commitment: %+v", r)
+	}
+
+	if _, err := RunRedTeam(verifier, RedTeamOptions{Strategies: []string{"bribery"}}); err == nil {
+		t.Error("Unknown strategies should be refused")
+	}
+}
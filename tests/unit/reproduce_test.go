@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func newReproduction(t *testing.T) (*ReproductionManifest, ReproductionSecrets, []byte) {
+	t.Helper()
+	m := &ReproductionManifest{
+		Config:     ReproductionConfig{Dimensions: 3, SecurityLevel: 128, SoundnessBits: 64, Application: "audit", EncodingDimension: 8, Codec: "gzip+json"},
+		Identifier: "published-2026",
+		Nonce:      strings.Repeat("5a", 32),
+		Timestamp:  time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC),
+	}
+	secrets := ReproductionSecrets{Data: []byte("audited witness"), Key: testutil.Key(), SigningSeed: bytes.Repeat([]byte{7}, 32)}
+	_, artifact, err := ProveReproducibly(m, secrets)
+	if err != nil {
+		t.Fatalf("ProveReproducibly failed: %v", err)
+	}
+	return m, secrets, artifact
+}
+
+func TestReproduceProofMatchesArtifact(t *testing.T) {
+	m, secrets, artifact := newReproduction(t)
+	report := ReproduceProof(m, secrets, artifact)
+	if !report.Reproduced || report.Diverged != "" {
+		t.Fatalf("Proof should reproduce: %+v", report)
+	}
+	if last := report.Stages[len(report.Stages)-1]; last.Stage != StageSerialization || !last.OK {
+		t.Errorf("Serialization should be the last stage checked: %+v", last)
+	}
+
+	// The manifest survives a JSON round trip
+	data, _ := json.Marshal(m)
+	var loaded ReproductionManifest
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("Manifest round trip failed: %v", err)
+	}
+	if !ReproduceProof(&loaded, secrets, artifact).Reproduced {
+		t.Error("Proof should reproduce from the decoded manifest")
+	}
+}
+
+func TestReproduceProofNamesDivergingStage(t *testing.T) {
+	m, secrets, artifact := newReproduction(t)
+	payload, _, _ := detectProofCodec(artifact)
+	var published SecureProof
+	if err := json.Unmarshal(payload, &published); err != nil {
+		t.Fatalf("Failed to decode artifact: %v", err)
+	}
+	reencode := func(mutate func(p *SecureProof)) []byte {
+		p := published
+		p.ChallengeResponse = append([]ChallengeResponse(nil), published.ChallengeResponse...)
+		mutate(&p)
+		data, _ := json.Marshal(&p)
+		encoded, _ := EncodeProof("gzip+json", data)
+		return encoded
+	}
+
+	cases := []struct {
+		name     string
+		manifest func(m ReproductionManifest) *ReproductionManifest
+		secrets  func(s ReproductionSecrets) ReproductionSecrets
+		artifact []byte
+		want     string
+	}{
+		{name: "library", manifest: func(m ReproductionManifest) *ReproductionManifest { m.LibraryVersion = "proof-v2"; return &m }, want: StageLibrary},
+		{name: "data", secrets: func(s ReproductionSecrets) ReproductionSecrets { s.Data = []byte("other witness"); return s }, want: StageInput},
+		{name: "signing key", secrets: func(s ReproductionSecrets) ReproductionSecrets {
+			s.SigningSeed = bytes.Repeat([]byte{8}, 32)
+			return s
+		}, want: StageKeys},
+		{name: "codec", artifact: payload, want: StageArtifact},
+		{name: "timestamp", manifest: func(m ReproductionManifest) *ReproductionManifest {
+			m.Timestamp = m.Timestamp.Add(time.Second)
+			return &m
+		}, want: StageParameters},
+		{name: "nonce", manifest: func(m ReproductionManifest) *ReproductionManifest { m.Nonce = strings.Repeat("a5", 32); return &m }, want: StageCommitment},
+		{name: "response", artifact: reencode(func(p *SecureProof) { p.ChallengeResponse[3].Response = "00" }), want: StageResponses},
+		{name: "signature", artifact: reencode(func(p *SecureProof) { p.Signature = "00" }), want: StageSignature},
+		{name: "serialization", manifest: func(m ReproductionManifest) *ReproductionManifest {
+			m.Config.Codec = "json"
+			return &m
+		}, artifact: append(append([]byte(nil), payload...), '\n'), want: StageSerialization},
+	}
+	for _, tc := range cases {
+		manifest, s, a := m, secrets, artifact
+		if tc.manifest != nil {
+			manifest = tc.manifest(*m)
+		}
+		if tc.secrets != nil {
+			s = tc.secrets(secrets)
+		}
+		if tc.artifact != nil {
+			a = tc.artifact
+		}
+		report := ReproduceProof(manifest, s, a)
+		if report.Reproduced || report.Diverged != tc.want {
+			t.Errorf("%s: expected divergence at %s, got %+v", tc.name, tc.want, report)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestRerandomizeProof(t *testing.T) {
+	sq, err := NewSecureQuantumZKPWithSoundness(3, 128, 128, []byte("rerandomize-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKPWithSoundness failed: %v", err)
+	}
+	sq.IdentifierKey = []byte("disclosure-key-for-auditors-only")
+	sq.LinkageKey = bytes.Repeat([]byte{0x42}, 32)
+	key := testutil.Key()
+	vector := []complex128{complex(0.6, 0), complex(0, 0.8)}
+
+	proof, err := sq.SecureProveVectorKnowledge(vector, "rerandomize_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	witness, err := sq.NewProofWitness(vector, "rerandomize_test")
+	if err != nil {
+		t.Fatalf("NewProofWitness failed: %v", err)
+	}
+	fresh, err := witness.RerandomizeProof(proof, key)
+	if err != nil {
+		t.Fatalf("RerandomizeProof failed: %v", err)
+	}
+	if !sq.VerifySecureProof(fresh, key) || !ConfirmIdentifier(fresh, "rerandomize_test", sq.IdentifierKey) {
+		t.Fatal("Re-randomized proof should verify for the same identifier")
+	}
+
+	// Nothing a verifier sees besides the statement is shared
+	if fresh.CommitmentHash == proof.CommitmentHash || fresh.MerkleRoot == proof.MerkleRoot ||
+		fresh.Identifier == proof.Identifier || fresh.IdentifierSalt == proof.IdentifierSalt ||
+		fresh.ChallengeBinding == proof.ChallengeBinding || fresh.Signature == proof.Signature ||
+		fresh.Linkage.Sealed == proof.Linkage.Sealed {
+		t.Error("Re-randomized proof should share no per-proof values with the original")
+	}
+	seen := map[string]bool{}
+	for _, r := range proof.ChallengeResponse {
+		seen[r.Response], seen[r.Commitment], seen[r.Proof] = true, true, true
+	}
+	for _, r := range fresh.ChallengeResponse {
+		if seen[r.Response] || seen[r.Commitment] || seen[r.Proof] {
+			t.Fatal("Re-randomized proof should share no response digests with the original")
+		}
+	}
+
+	// The auditor can still link them
+	detector, _ := NewLinkageDetector(sq.LinkageKey)
+	detector.Observe("original", proof)
+	if linked, err := detector.Observe("fresh", fresh); err != nil || len(linked) != 1 {
+		t.Errorf("The auditor should link re-randomized proofs: %v (%v)", linked, err)
+	}
+
+	// Proofs the witness does not cover are refused
+	other, _ := sq.NewProofWitness(vector, "another_identifier")
+	if _, err := other.RerandomizeProof(proof, key); err == nil {
+		t.Error("A witness for another identifier should be refused")
+	}
+	wider, _ := sq.NewProofWitness([]complex128{1, 0, 0, 1}, "rerandomize_test")
+	if _, err := wider.RerandomizeProof(proof, key); err == nil {
+		t.Error("A witness of another dimension should be refused")
+	}
+	tampered := *proof
+	tampered.MerkleRoot = fresh.MerkleRoot
+	if _, err := witness.RerandomizeProof(&tampered, key); err == nil {
+		t.Error("A proof that does not verify should be refused")
+	}
+
+	sq.IdentifierKey = nil
+	plain, _ := sq.SecureProveVectorKnowledge(vector, "rerandomize_test", key)
+	if _, err := witness.RerandomizeProof(plain, key); err == nil {
+		t.Error("Proofs with plain identifiers should be refused")
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestEstimateResourcesBellCircuit(t *testing.T) {
+	circuit := &QuantumCircuit{
+		NumQubits: 2,
+		NumClbits: 2,
+		Gates: []QuantumGate{
+			{Type: "h", Qubits: []int{0}},
+			{Type: "cx", Qubits: []int{0, 1}},
+			{Type: "measure", Qubits: []int{0, 0}},
+			{Type: "measure", Qubits: []int{1, 1}},
+		},
+	}
+
+	est, err := EstimateResources(circuit)
+	if err != nil {
+		t.Fatalf("EstimateResources failed: %v", err)
+	}
+	if est.Depth != 3 {
+		t.Errorf("Depth = %d, want 3", est.Depth)
+	}
+	if est.TwoQubitGates != 1 || est.SingleQubitGates != 1 || est.Measurements != 2 {
+		t.Errorf("Unexpected gate counts: %+v", est)
+	}
+
+	var hardware *BackendEstimate
+	for i := range est.Backends {
+		if est.Backends[i].Backend == "ibm_brisbane" {
+			hardware = &est.Backends[i]
+		}
+	}
+	if hardware == nil {
+		t.Fatal("Missing ibm_brisbane estimate")
+	}
+	if hardware.EstimatedError <= 0 || hardware.EstimatedError >= 0.1 {
+		t.Errorf("Unexpected hardware error estimate: %f", hardware.EstimatedError)
+	}
+
+	backend, err := est.RecommendBackend(0.001)
+	if err != nil {
+		t.Fatalf("RecommendBackend failed: %v", err)
+	}
+	if backend != "simulator" {
+		t.Errorf("Expected the simulator for a tight error budget, got %s", backend)
+	}
+}
+
+func TestEstimateResourcesRejectsOutOfRangeQubits(t *testing.T) {
+	circuit := &QuantumCircuit{
+		NumQubits: 1,
+		Gates:     []QuantumGate{{Type: "cx", Qubits: []int{0, 1}}},
+	}
+	if _, err := EstimateResources(circuit); err == nil {
+		t.Error("Expected an error for a gate outside the circuit")
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestChiSquareGoodnessOfFit(t *testing.T) {
+	result := &ExecutionResult{Counts: map[string]int{"0": 520, "1": 480}, Shots: 1000}
+
+	fit, err := ChiSquareGoodnessOfFit(result, map[string]float64{"0": 0.5, "1": 0.5})
+	if err != nil {
+		t.Fatalf("ChiSquareGoodnessOfFit failed: %v", err)
+	}
+	if math.Abs(fit.Statistic-1.6) > 1e-9 {
+		t.Errorf("Statistic = %f, want 1.6", fit.Statistic)
+	}
+	// P(χ²₁ > 1.6) ≈ 0.2059
+	if math.Abs(fit.PValue-0.2059) > 1e-3 {
+		t.Errorf("PValue = %f, want ≈0.2059", fit.PValue)
+	}
+
+	biased := &ExecutionResult{Counts: map[string]int{"0": 900, "1": 100}, Shots: 1000}
+	fit, err = ChiSquareGoodnessOfFit(biased, map[string]float64{"0": 0.5, "1": 0.5})
+	if err != nil {
+		t.Fatalf("ChiSquareGoodnessOfFit failed: %v", err)
+	}
+	if fit.PValue > 1e-6 {
+		t.Errorf("Expected a vanishing p-value for a heavily biased run, got %g", fit.PValue)
+	}
+}
+
+func TestProbabilityIntervalAndDistances(t *testing.T) {
+	a := &ExecutionResult{Counts: map[string]int{"00": 500, "11": 500}, Shots: 1000}
+	b := &ExecutionResult{Counts: map[string]int{"00": 400, "01": 100, "11": 500}, Shots: 1000}
+
+	lo, hi, err := a.ProbabilityInterval("00", 0.95)
+	if err != nil {
+		t.Fatalf("ProbabilityInterval failed: %v", err)
+	}
+	if lo > 0.5 || hi < 0.5 || hi-lo > 0.07 {
+		t.Errorf("Unexpected 95%% interval [%f, %f] for p=0.5, n=1000", lo, hi)
+	}
+
+	if d := TotalVariationDistance(a, b); math.Abs(d-0.1) > 1e-9 {
+		t.Errorf("TotalVariationDistance = %f, want 0.1", d)
+	}
+	if d := HellingerDistance(a, a); d != 0 {
+		t.Errorf("HellingerDistance of a run with itself = %f, want 0", d)
+	}
+	if d := HellingerDistance(a, b); d <= 0 || d >= 1 {
+		t.Errorf("HellingerDistance = %f, want within (0, 1)", d)
+	}
+}
+
+func TestEvaluateCHSH(t *testing.T) {
+	// Counts matching the Tsirelson-optimal correlators ±1/√2
+	correlated := func(e float64) *ExecutionResult {
+		same := int(math.Round(10000 * (1 + e) / 2))
+		return &ExecutionResult{
+			Counts: map[string]int{"00": same / 2, "11": same - same/2, "01": (10000 - same) / 2, "10": (10000 - same) - (10000-same)/2},
+			Shots:  10000,
+		}
+	}
+	e := 1 / math.Sqrt2
+	chsh, err := EvaluateCHSH(correlated(e), correlated(-e), correlated(e), correlated(e))
+	if err != nil {
+		t.Fatalf("EvaluateCHSH failed: %v", err)
+	}
+	if math.Abs(chsh.S-2*math.Sqrt2) > 0.01 {
+		t.Errorf("S = %f, want ≈2.828", chsh.S)
+	}
+	if !chsh.Violation {
+		t.Error("Expected a Bell inequality violation")
+	}
+}
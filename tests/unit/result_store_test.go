@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResultStoreHistory(t *testing.T) {
+	store, err := NewResultStore(NewDataDirs(t.TempDir()))
+	if err != nil {
+		t.Fatalf("NewResultStore failed: %v", err)
+	}
+
+	bell := &QuantumCircuit{NumQubits: 2, NumClbits: 2, Gates: []QuantumGate{
+		{Type: "h", Qubits: []int{0}},
+		{Type: "cx", Qubits: []int{0, 1}},
+	}}
+	hash, err := CircuitHash(bell)
+	if err != nil {
+		t.Fatalf("CircuitHash failed: %v", err)
+	}
+	relabelled := *bell
+	relabelled.Metadata = map[string]interface{}{"identifier": "another run"}
+	if again, _ := CircuitHash(&relabelled); again != hash {
+		t.Error("Circuit metadata must not change the circuit hash")
+	}
+
+	// A Bell pair whose correlations decay by a fixed amount per day
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for day := 0; day < 4; day++ {
+		errs := 100 * day
+		result := &ExecutionResult{
+			Counts:  map[string]int{"00": 500 - errs/2, "11": 500 - errs/2, "01": errs / 2, "10": errs / 2},
+			Shots:   1000,
+			Backend: "ibm_brisbane",
+		}
+		if err := store.Record(hash, result, start.AddDate(0, 0, day)); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	simulated := &ExecutionResult{Counts: map[string]int{"00": 512, "11": 512}, Shots: 1024, Backend: "aer_simulator"}
+	if err := store.Record(hash, simulated, start); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	backends, err := store.Backends(hash)
+	if err != nil || len(backends) != 2 || backends[0] != "aer_simulator" {
+		t.Fatalf("Unexpected backends %v, %v", backends, err)
+	}
+	history, err := store.History(hash, "ibm_brisbane")
+	if err != nil || len(history) != 4 {
+		t.Fatalf("Expected 4 runs, got %d, %v", len(history), err)
+	}
+	if !history[0].RecordedAt.Equal(start) || history[3].Result.Counts["01"] != 150 {
+		t.Errorf("History should be in time order: %+v", history[0])
+	}
+
+	ideal := map[string]float64{"00": 0.5, "11": 0.5}
+	points, err := ResultTrend(history, ideal)
+	if err != nil {
+		t.Fatalf("ResultTrend failed: %v", err)
+	}
+	if points[0].Fidelity != 1 || points[0].Drift != 0 {
+		t.Errorf("First run should be ideal with no drift: %+v", points[0])
+	}
+	if math.Abs(points[2].Fidelity-0.8) > 1e-9 || math.Abs(points[2].Drift-0.1) > 1e-9 {
+		t.Errorf("Day 2 should have fidelity 0.8 and drift 0.1: %+v", points[2])
+	}
+
+	summary, err := SummarizeTrend(history, points)
+	if err != nil {
+		t.Fatalf("SummarizeTrend failed: %v", err)
+	}
+	if math.Abs(summary.FidelitySlopePerDay+0.1) > 1e-9 || math.Abs(summary.TotalDrift-0.3) > 1e-9 {
+		t.Errorf("Expected a slope of -0.1/day and total drift 0.3: %+v", summary)
+	}
+
+	var csv bytes.Buffer
+	if err := WriteTrendCSV(&csv, points); err != nil {
+		t.Fatalf("WriteTrendCSV failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[3], "2026-03-03T00:00:00Z,1000,0.800000,0.100000") {
+		t.Errorf("Unexpected CSV export:\n%s", csv.String())
+	}
+
+	if err := store.Record("../escape", simulated, start); err == nil {
+		t.Error("Expected an error for a circuit hash outside the store")
+	}
+	if runs, err := store.History(hash, "ibm_kyoto"); err != nil || len(runs) != 0 {
+		t.Errorf("Unknown backend should have no history, got %v, %v", runs, err)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestSampledVerification(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("sampled-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	key := testutil.Key()
+	proof, err := sq.SecureProveVectorKnowledge([]complex128{complex(0.6, 0), complex(0, 0.8)}, "sampled_test", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+
+	seed := []byte("verifier-chosen-randomness-0001")
+	opening, err := sq.OpenSampledResponses(proof, seed, 16)
+	if err != nil {
+		t.Fatalf("OpenSampledResponses failed: %v", err)
+	}
+	if len(opening.Header.ChallengeResponse) != 0 {
+		t.Fatal("Opening header must not carry all responses")
+	}
+
+	result := sq.VerifySampledOpening(opening, seed, 16, key)
+	if !result.Valid {
+		t.Fatalf("Sampled opening should verify: %s", result.Reason)
+	}
+	if result.EffectiveSoundness != 16 || result.Total != sq.SecurityParameter {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	// The opening only answers the seed it was made for
+	if r := sq.VerifySampledOpening(opening, []byte("a-different-verifier-seed-0002"), 16, key); r.Valid {
+		t.Error("Opening should not verify under another seed")
+	}
+
+	// A modified response breaks its inclusion proof
+	tampered := *opening
+	tampered.Responses = append([]ChallengeResponse(nil), opening.Responses...)
+	tampered.Responses[0].Response = "00000000000000ff"
+	if r := sq.VerifySampledOpening(&tampered, seed, 16, key); r.Valid {
+		t.Error("Tampered response should not verify")
+	}
+
+	// A modified header breaks its signature
+	header := *opening.Header
+	header.Identifier = "other"
+	tampered = *opening
+	tampered.Header = &header
+	if r := sq.VerifySampledOpening(&tampered, seed, 16, key); r.Valid {
+		t.Error("Tampered header should not verify")
+	}
+}
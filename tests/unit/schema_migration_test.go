@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestSchemaMigrationsRunInOrder(t *testing.T) {
+	schema := &Schema{
+		Name: "test",
+		Migrations: []Migration{
+			{Description: "rename size to dimensions", Apply: func(doc map[string]json.RawMessage) error {
+				doc["dimensions"] = doc["size"]
+				delete(doc, "size")
+				return nil
+			}},
+			{Description: "double dimensions", Apply: func(doc map[string]json.RawMessage) error {
+				var d int
+				if err := json.Unmarshal(doc["dimensions"], &d); err != nil {
+					return err
+				}
+				doc["dimensions"] = json.RawMessage(fmt.Sprint(2 * d))
+				return nil
+			}},
+		},
+	}
+
+	for _, tc := range []struct {
+		input   string
+		from    int
+		applied int
+	}{
+		{`{"size": 4}`, 0, 2},
+		{`{"schema_version": 1, "dimensions": 4}`, 1, 1},
+		{`{"schema_version": 2, "dimensions": 8}`, 2, 0},
+	} {
+		var doc map[string]json.RawMessage
+		json.Unmarshal([]byte(tc.input), &doc)
+		from, applied, err := schema.Upgrade(doc)
+		if err != nil || from != tc.from || len(applied) != tc.applied {
+			t.Errorf("%s: from %d, applied %v, %v", tc.input, from, applied, err)
+		}
+		if string(doc["dimensions"]) != "8" || string(doc[SchemaVersionField]) != "2" {
+			t.Errorf("%s upgraded to %v", tc.input, doc)
+		}
+	}
+
+	var doc map[string]json.RawMessage
+	json.Unmarshal([]byte(`{"schema_version": 3}`), &doc)
+	if _, _, err := schema.Upgrade(doc); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Expected ErrSchemaTooNew for a newer document, got %v", err)
+	}
+}
+
+func TestMigrateConfigFileWithBackupAndDryRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "qzkpd.json")
+	legacy := []byte(`{"dimensions": 3, "security_level": 128, "application": "app", "key": "00", "listen": ":9090"}`)
+	if err := os.WriteFile(path, legacy, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := ConfigSchema.MigrateFile(path, MigrateOptions{DryRun: true})
+	if err != nil || !report.Changed() || report.From != 0 || report.To != ConfigSchema.Current() || report.Backup != "" {
+		t.Fatalf("Dry run: %+v, %v", report, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(legacy) {
+		t.Error("Dry run rewrote the file")
+	}
+	if _, err := os.Stat(path + ".v0.bak"); !os.IsNotExist(err) {
+		t.Error("Dry run took a backup")
+	}
+
+	report, err = ConfigSchema.MigrateFile(path, MigrateOptions{})
+	if err != nil || report.Backup != path+".v0.bak" {
+		t.Fatalf("Migration: %+v, %v", report, err)
+	}
+	if backup, _ := os.ReadFile(report.Backup); string(backup) != string(legacy) {
+		t.Error("Backup differs from the original file")
+	}
+	cfg, err := LoadDaemonConfig(path)
+	if err != nil || cfg.SchemaVersion != ConfigSchema.Current() || cfg.Listen != ":9090" || cfg.Application != "app" {
+		t.Fatalf("Migrated config: %+v, %v", cfg, err)
+	}
+
+	// A current file is left alone
+	report, err = ConfigSchema.MigrateFile(path, MigrateOptions{})
+	if err != nil || report.Changed() || report.Backup != "" {
+		t.Errorf("Second migration: %+v, %v", report, err)
+	}
+}
+
+func TestConfigLoadersUpgradeAndRefuseNewerSchemas(t *testing.T) {
+	dir := t.TempDir()
+	legacy := filepath.Join(dir, "legacy.json")
+	os.WriteFile(legacy, []byte(`{"dimensions": 3, "application": "app"}`), 0600)
+	cfg, err := LoadServiceConfig(legacy)
+	if err != nil || cfg.SchemaVersion != ConfigSchema.Current() || cfg.Dimensions != 3 {
+		t.Errorf("Legacy config: %+v, %v", cfg, err)
+	}
+	if data, _ := os.ReadFile(legacy); strings.Contains(string(data), SchemaVersionField) {
+		t.Error("Loading rewrote the config")
+	}
+
+	newer := filepath.Join(dir, "newer.json")
+	original := []byte(`{"schema_version": 99, "dimensions": 3}`)
+	os.WriteFile(newer, original, 0600)
+	if _, err := LoadServiceConfig(newer); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("LoadServiceConfig: expected ErrSchemaTooNew, got %v", err)
+	}
+	if _, err := LoadDaemonConfig(newer); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("LoadDaemonConfig: expected ErrSchemaTooNew, got %v", err)
+	}
+	_, err = ConfigSchema.MigrateFile(newer, MigrateOptions{})
+	if !errors.Is(err, ErrSchemaTooNew) || !strings.Contains(err.Error(), "upgrade qzkp") {
+		t.Errorf("MigrateFile: expected ErrSchemaTooNew with advice, got %v", err)
+	}
+	if data, _ := os.ReadFile(newer); string(data) != string(original) {
+		t.Error("A newer config was rewritten")
+	}
+}
+
+func TestMigrateDataDirsUpgradesKeyUsageRecords(t *testing.T) {
+	dirs := NewDataDirs(t.TempDir())
+	if reports, err := MigrateDataDirs(dirs, MigrateOptions{}); err != nil || len(reports) != 0 {
+		t.Fatalf("Empty data directory: %v, %v", reports, err)
+	}
+
+	ks, err := NewKeystore(dirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Put("current", testutil.Key()); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Put("legacy", testutil.Key()); err != nil {
+		t.Fatal(err)
+	}
+	legacyRecord := []byte(`{"name": "legacy", "created": "2025-01-02T03:04:05Z", "last_used": "0001-01-01T00:00:00Z", "proofs": 7}`)
+	os.WriteFile(ks.usagePath("legacy"), legacyRecord, 0600)
+
+	usage, err := ks.Usage("legacy")
+	if err != nil || usage.Proofs != 7 {
+		t.Fatalf("Legacy record: %+v, %v", usage, err)
+	}
+
+	reports, err := MigrateDataDirs(dirs, MigrateOptions{})
+	if err != nil || len(reports) != 2 {
+		t.Fatalf("MigrateDataDirs: %v, %v", reports, err)
+	}
+	for _, r := range reports {
+		if r.Changed() != strings.HasSuffix(r.Path, "legacy.usage.json") {
+			t.Errorf("Report %+v", r)
+		}
+	}
+	if usage, err := ks.Usage("legacy"); err != nil || usage.Proofs != 7 {
+		t.Errorf("Migrated record: %+v, %v", usage, err)
+	}
+
+	// Records written by a newer release stop the migration and are kept
+	newer := []byte(`{"schema_version": 42, "name": "current"}`)
+	os.WriteFile(ks.usagePath("current"), newer, 0600)
+	if _, err := MigrateDataDirs(dirs, MigrateOptions{}); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Expected ErrSchemaTooNew, got %v", err)
+	}
+	if _, err := ks.Usage("current"); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Usage: expected ErrSchemaTooNew, got %v", err)
+	}
+}
+
+func TestQuantumStateCacheRefusesNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), QuantumStateCacheFile)
+	original := []byte(`{"states": [], "version": "3.0"}`)
+	os.WriteFile(path, original, 0644)
+	cache, _ := NewQuantumStateCache(path)
+	if _, err := cache.MigrateAmplitudeEncoding(); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Expected ErrSchemaTooNew, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(original) {
+		t.Error("A newer cache file was rewritten")
+	}
+}
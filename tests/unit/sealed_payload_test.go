@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestSealedPayloadVerifyThenDecrypt(t *testing.T) {
+	sq, proof := newTestProof(t, 64)
+	verifierKey, err := NewPayloadKEM()
+	if err != nil {
+		t.Fatalf("NewPayloadKEM failed: %v", err)
+	}
+	recipient, err := NewPayloadRecipient(verifierKey.PublicKeyBytes())
+	if err != nil {
+		t.Fatalf("NewPayloadRecipient failed: %v", err)
+	}
+
+	bound, err := sq.SealPayload(proof, []byte("handover content"), recipient)
+	if err != nil {
+		t.Fatalf("SealPayload failed: %v", err)
+	}
+	content, err := sq.OpenPayload(bound, testutil.Key(), verifierKey)
+	if err != nil || string(content) != "handover content" {
+		t.Fatalf("OpenPayload = %q, %v", content, err)
+	}
+	if _, err := sq.OpenPayload(bound, testutil.Key(), recipient); err == nil {
+		t.Error("Opening without the private key should fail")
+	}
+
+	// The proof must verify under the verifier's parameters before
+	// decryption
+	stricter := newTestProver(t, 128)
+	stricter.Signer = sq.Signer
+	if _, err := stricter.OpenPayload(bound, testutil.Key(), verifierKey); !errors.Is(err, ErrPayloadProofInvalid) {
+		t.Errorf("Expected ErrPayloadProofInvalid, got %v", err)
+	}
+
+	other, _ := NewPayloadKEM()
+	if _, err := sq.OpenPayload(bound, testutil.Key(), other); err == nil {
+		t.Error("Another recipient should not open the payload")
+	}
+}
+
+func TestSealedPayloadBinding(t *testing.T) {
+	sq, proof := newTestProof(t, 64)
+	verifierKey, _ := NewPayloadKEM()
+	bound, err := sq.SealPayload(proof, []byte("content"), verifierKey)
+	if err != nil {
+		t.Fatalf("SealPayload failed: %v", err)
+	}
+
+	// Moving the payload to another valid proof
+	second, err := sq.SecureProveVectorKnowledge(testutil.RampVector(8), "second", testutil.Key())
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if _, err := sq.OpenPayload(&ProofBoundPayload{Proof: second, Payload: bound.Payload}, testutil.Key(), verifierKey); err == nil {
+		t.Error("A payload moved to another proof should not open")
+	}
+
+	// Substituting content sealed by someone else under the same proof
+	forger := newTestProver(t, 64)
+	forged, err := forger.SealPayload(proof, []byte("forged"), verifierKey)
+	if err != nil {
+		t.Fatalf("SealPayload failed: %v", err)
+	}
+	if _, err := sq.OpenPayload(forged, testutil.Key(), verifierKey); err == nil {
+		t.Error("A payload not signed by the prover should not open")
+	}
+
+	tampered := *bound.Payload
+	sealed, _ := hex.DecodeString(tampered.Ciphertext)
+	sealed[len(sealed)-1] ^= 1
+	tampered.Ciphertext = hex.EncodeToString(sealed)
+	if _, err := sq.OpenPayload(&ProofBoundPayload{Proof: proof, Payload: &tampered}, testutil.Key(), verifierKey); err == nil {
+		t.Error("A modified ciphertext should not open")
+	}
+
+	// Malformed fields are rejected by the field codecs, even when the
+	// prover signed them
+	for name, malform := range map[string]func(p *SealedPayload){
+		"signature":     func(p *SealedPayload) { p.Signature = p.Signature[1:] },
+		"encapsulation": func(p *SealedPayload) { p.Encapsulation = p.Encapsulation[2:] },
+		"ciphertext":    func(p *SealedPayload) { p.Ciphertext = "zz" + p.Ciphertext[2:] },
+	} {
+		malformed := *bound.Payload
+		malform(&malformed)
+		if name != "signature" {
+			msg, _ := malformed.signingMessage()
+			sig, _ := sq.Signer.Sign(msg)
+			malformed.Signature = hex.EncodeToString(sig)
+		}
+		if _, err := sq.OpenPayload(&ProofBoundPayload{Proof: proof, Payload: &malformed}, testutil.Key(), verifierKey); !errors.Is(err, ErrMalformedField) {
+			t.Errorf("Malformed %s: expected ErrMalformedField, got %v", name, err)
+		}
+	}
+
+	if _, err := sq.SealPayload(&SecureProof{}, []byte("content"), verifierKey); err == nil {
+		t.Error("Unsigned proofs should not carry a payload")
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// zeroReader is a randomness source stuck at zero.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func selfTestCheck(t *testing.T, result *SelfTestResult, name string) SelfTestCheck {
+	t.Helper()
+	for _, c := range result.Checks {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("Self-test has no %s check: %+v", name, result)
+	return SelfTestCheck{}
+}
+
+func TestSelfTest(t *testing.T) {
+	sq, err := NewSecureQuantumZKPWithSoundness(3, 128, 128, []byte("self-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKPWithSoundness failed: %v", err)
+	}
+	result := sq.SelfTest()
+	if !result.Passed || result.Err() != nil || len(result.Checks) != 3 {
+		t.Fatalf("Self-test should pass: %+v", result)
+	}
+	for _, c := range result.Checks {
+		if !c.Passed || c.Duration <= 0 || c.Error != "" {
+			t.Errorf("Check %s: %+v", c.Name, c)
+		}
+	}
+
+	// A broken randomness source fails only the entropy check; the
+	// known-answer test brings its own nonces
+	signer := sq.Signer
+	sq.Rand = zeroReader{}
+	result = sq.SelfTest()
+	if result.Passed || selfTestCheck(t, result, SelfTestEntropy).Passed || !selfTestCheck(t, result, SelfTestKnownAnswer).Passed {
+		t.Errorf("A constant randomness source should fail the entropy check: %+v", result)
+	}
+	if sq.Signer != signer || sq.Rand != (zeroReader{}) {
+		t.Error("The self-test should not change the prover's configuration")
+	}
+
+	sample := make([]byte, 1024)
+	rand.Read(sample)
+	sq.Rand = io.MultiReader(bytes.NewReader(sample), bytes.NewReader(sample))
+	if check := selfTestCheck(t, sq.SelfTest(), SelfTestEntropy); check.Passed {
+		t.Error("A randomness source that repeats its output should fail the entropy check")
+	}
+	sq.Rand = nil
+
+	// A verify-only signer cannot sign proofs
+	sq.Signer, _ = NewVerificationScheme(signer.PublicKeyBytes(), signer.Ctx)
+	result = sq.SelfTest()
+	if check := selfTestCheck(t, result, SelfTestSigner); check.Passed || check.Error == "" || result.Err() == nil {
+		t.Errorf("A signer without a private key should fail the signer check: %+v", result)
+	}
+}
+
+func TestDaemonHealthzSelfTest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "qzkpd.json")
+	writeDaemonConfig(t, path, DaemonConfig{
+		ServiceConfig: ServiceConfig{
+			Dimensions:    3,
+			SecurityLevel: 128,
+			Application:   "self-test",
+			Key:           "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		},
+	})
+	d, err := NewDaemon(path)
+	if err != nil {
+		t.Fatalf("NewDaemon failed: %v", err)
+	}
+	server := httptest.NewServer(d.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/healthz")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Health check failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var result SelfTestResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || !result.Passed || len(result.Checks) != 3 {
+		t.Errorf("Health check should report a passing self-test: %+v (%v)", result, err)
+	}
+
+	// Probes report the startup self-test instead of rerunning it
+	again, err := http.Get(server.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("Health check failed: %v", err)
+	}
+	defer again.Body.Close()
+	var cached SelfTestResult
+	if err := json.NewDecoder(again.Body).Decode(&cached); err != nil || !cached.Timestamp.Equal(result.Timestamp) {
+		t.Errorf("Health checks should report the stored self-test: %v, then %v (%v)", result.Timestamp, cached.Timestamp, err)
+	}
+
+	// A reload runs it again
+	if err := d.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if d.service.Load().SelfTest().Timestamp.Equal(result.Timestamp) {
+		t.Error("A reload should run the self-test again")
+	}
+}
+
+func TestEntropyHealthCutoffs(t *testing.T) {
+	// Every byte value twice per window, never twice in a row
+	sample := make([]byte, entropySampleBytes)
+	for i := range sample {
+		sample[i] = byte(i)
+	}
+	if err := checkEntropyHealth(sample); err != nil {
+		t.Fatalf("A balanced sample should pass: %v", err)
+	}
+
+	run := bytes.Clone(sample)
+	copy(run[100:], bytes.Repeat([]byte{0x42}, entropyRepetitionCutoff-1))
+	if err := checkEntropyHealth(run); err != nil {
+		t.Errorf("A run below the cutoff should pass: %v", err)
+	}
+	run[100+entropyRepetitionCutoff-1] = 0x42
+	if err := checkEntropyHealth(run); err == nil {
+		t.Error("A run at the cutoff should fail the repetition count test")
+	}
+
+	// The window's first byte, which also appears at offset 256, spread
+	// out so no run forms
+	proportion := bytes.Clone(sample)
+	for i := 1; i < entropyProportionCutoff-1; i++ {
+		proportion[i*8] = proportion[0]
+	}
+	if err := checkEntropyHealth(proportion); err == nil {
+		t.Error("A value at the proportion cutoff should fail the adaptive proportion test")
+	}
+}
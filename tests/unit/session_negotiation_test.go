@@ -0,0 +1,136 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestNegotiateSession(t *testing.T) {
+	params, err := NegotiateSession(
+		SessionPolicy{MinSoundness: 80, HashSuites: []string{"sha3-256", HashSuiteSHA256}},
+		SessionPolicy{MinSoundness: 128, HashSuites: []string{HashSuiteSHA256}, Profile: "audited"},
+	)
+	if err != nil {
+		t.Fatalf("NegotiateSession failed: %v", err)
+	}
+	want := SessionParameters{SoundnessBits: 128, HashSuite: HashSuiteSHA256, Profile: "audited"}
+	if params != want {
+		t.Errorf("Negotiation should take the stronger requirements: got %+v, want %+v", params, want)
+	}
+
+	for name, policies := range map[string][2]SessionPolicy{
+		"no common hash suite": {
+			{MinSoundness: 80, HashSuites: []string{"sha3-256"}},
+			{MinSoundness: 80, HashSuites: []string{HashSuiteSHA256, "sha3-256"}},
+		},
+		"conflicting profiles": {
+			{MinSoundness: 80, HashSuites: []string{HashSuiteSHA256}, Profile: "a"},
+			{MinSoundness: 80, HashSuites: []string{HashSuiteSHA256}, Profile: "b"},
+		},
+		"soundness too high": {
+			{MinSoundness: 512, HashSuites: []string{HashSuiteSHA256}},
+			{MinSoundness: 80, HashSuites: []string{HashSuiteSHA256}},
+		},
+	} {
+		if _, err := NegotiateSession(policies[0], policies[1]); err == nil {
+			t.Errorf("%s: negotiation should fail", name)
+		}
+	}
+}
+
+func TestNegotiatedSession(t *testing.T) {
+	verifierSQ, err := NewSecureQuantumZKPWithSoundness(3, 128, 80, []byte("negotiation-context"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKPWithSoundness failed: %v", err)
+	}
+	proverSQ, _ := NewSecureQuantumZKPWithSoundness(3, 128, 80, []byte("negotiation-context"))
+	proverSQ.Signer = verifierSQ.Signer
+	key := testutil.Key()
+	state, _ := NewStateVector(testutil.RampVector(8))
+
+	verifier, err := verifierSQ.NewVerifierSessionWithPolicy(SessionPolicy{MinSoundness: 100})
+	if err != nil {
+		t.Fatalf("NewVerifierSessionWithPolicy failed: %v", err)
+	}
+	prover, err := proverSQ.NewProverSessionWithPolicy(verifier.Offer(), state, "negotiation_test", key,
+		SessionPolicy{MinSoundness: 120, Profile: "audited"})
+	if err != nil {
+		t.Fatalf("NewProverSessionWithPolicy failed: %v", err)
+	}
+	commitment, err := prover.Commit()
+	if err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	challenges, err := verifier.Challenge(commitment)
+	if err != nil {
+		t.Fatalf("Challenge failed: %v", err)
+	}
+	if len(challenges) != 120 {
+		t.Errorf("120 negotiated bits need 120 challenges, got %d", len(challenges))
+	}
+
+	// A verifier cannot weaken the agreement by issuing fewer challenges
+	if _, err := prover.Respond(challenges[:8]); err == nil {
+		t.Error("Prover should refuse fewer challenges than negotiated")
+	}
+	proof, err := prover.Respond(challenges)
+	if err != nil {
+		t.Fatalf("Respond failed: %v", err)
+	}
+	want := SessionParameters{SoundnessBits: 120, HashSuite: HashSuiteSHA256, Profile: "audited"}
+	if proof.Session.Parameters == nil || *proof.Session.Parameters != want || proof.Profile != "audited" {
+		t.Fatalf("Proof should record the negotiated parameters %+v, got %+v", want, proof.Session.Parameters)
+	}
+	if !verifier.Verify(proof, key) {
+		t.Fatal("Negotiated session proof should verify")
+	}
+	if !verifierSQ.AuditSessionProof(proof, key, verifier.Offer().SessionKey) {
+		t.Error("Auditors should check the proof against the recorded agreement")
+	}
+
+	// Neither side can later claim a weaker agreement: the record is signed
+	weaker := *proof
+	session := *proof.Session
+	session.Parameters = &SessionParameters{SoundnessBits: 80, HashSuite: HashSuiteSHA256, Profile: "audited"}
+	weaker.Session = &session
+	if verifierSQ.AuditSessionProof(&weaker, key, verifier.Offer().SessionKey) {
+		t.Error("A proof with an altered agreement should not pass the audit")
+	}
+}
+
+func TestNegotiationRefusals(t *testing.T) {
+	sq, err := NewSecureQuantumZKPWithSoundness(3, 128, 80, []byte("negotiation-context"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKPWithSoundness failed: %v", err)
+	}
+	key := testutil.Key()
+	state, _ := NewStateVector(testutil.RampVector(8))
+
+	// A prover that requires negotiation refuses a plain offer
+	plain, _ := sq.NewVerifierSession()
+	if _, err := sq.NewProverSessionWithPolicy(plain.Offer(), state, "negotiation_test", key, SessionPolicy{}); err == nil {
+		t.Error("A policy prover should refuse an offer without negotiation")
+	}
+
+	// Incompatible policies fail before anything is committed
+	verifier, _ := sq.NewVerifierSessionWithPolicy(SessionPolicy{Profile: "a"})
+	if _, err := sq.NewProverSessionWithPolicy(verifier.Offer(), state, "negotiation_test", key, SessionPolicy{Profile: "b"}); err == nil {
+		t.Error("Conflicting profiles should fail negotiation")
+	}
+
+	// The verifier recomputes the agreement and rejects a misreported one
+	verifier, _ = sq.NewVerifierSessionWithPolicy(SessionPolicy{MinSoundness: 100})
+	prover, err := sq.NewProverSessionForState(verifier.Offer(), state, "negotiation_test", key)
+	if err != nil {
+		t.Fatalf("Default prover should negotiate: %v", err)
+	}
+	commitment, _ := prover.Commit()
+	if commitment.Parameters == nil || commitment.Parameters.SoundnessBits != 100 {
+		t.Fatalf("Default prover should accept the verifier's minimum: %+v", commitment.Parameters)
+	}
+	commitment.Parameters.SoundnessBits = 80
+	if _, err := verifier.Challenge(commitment); err == nil {
+		t.Error("Verifier should reject a commitment claiming weaker parameters")
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestSNARKLayoutGolden(t *testing.T) {
+	sq, err := NewSecureQuantumZKPWithContext(3, 128, Context{Version: 1, Application: "snark"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout, err := sq.SNARKLayout(8, 6)
+	if err != nil {
+		t.Fatalf("SNARKLayout failed: %v", err)
+	}
+	testutil.GoldenJSON(t, "snark_layout", layout)
+}
+
+func TestSecureProveForSNARKOpensTheCommitment(t *testing.T) {
+	sq := newTestProver(t, 64)
+	key := testutil.Key()
+	state, err := NewStateVector(testutil.RampVector(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, statement, witness, err := sq.SecureProveForSNARK(state, "snark-doc", key)
+	if err != nil {
+		t.Fatalf("SecureProveForSNARK failed: %v", err)
+	}
+	if !sq.VerifySecureProof(proof, key) {
+		t.Fatal("Composable proof does not verify")
+	}
+
+	// The verifier derives the same statement from the proof alone
+	derived, err := sq.SNARKStatement(proof, len(proof.Identifier))
+	if err != nil || !reflect.DeepEqual(derived, statement) {
+		t.Fatalf("Derived statement %+v differs from the prover's %+v (%v)", derived, statement, err)
+	}
+	last := statement.Layout.PublicInputs[len(statement.Layout.PublicInputs)-1]
+	if len(statement.PublicInputs) != last.Offset+last.Length {
+		t.Errorf("%d public inputs for layout %+v", len(statement.PublicInputs), statement.Layout.PublicInputs)
+	}
+
+	// A circuit reading the layout recomputes the published commitment
+	preimage, _ := hex.DecodeString(witness.Preimage)
+	digest := sha256.Sum256(preimage)
+	if hex.EncodeToString(digest[:statement.Layout.CommitmentBytes]) != proof.CommitmentHash {
+		t.Error("Preimage does not hash to the published commitment")
+	}
+	amplitudes, err := witness.Field("amplitudes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := sq.NumericEncoding.Encode(real(state.Amplitudes()[0]))
+	if len(amplitudes) != 16*8 || !bytes.Equal(amplitudes[:8], first) {
+		t.Errorf("Amplitude field does not follow the layout: %x", amplitudes[:8])
+	}
+
+	// Altered witnesses and other proofs' statements are refused
+	tampered := *witness
+	tampered.Preimage = hex.EncodeToString(testutil.FlipByte(preimage, 3))
+	if tampered.Check(statement) == nil {
+		t.Error("Altered amplitudes opened the commitment")
+	}
+	other, err := sq.SecureProveState(state, "snark-doc", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherStatement, err := sq.SNARKStatement(other, len("snark-doc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if witness.Check(otherStatement) == nil {
+		t.Error("Witness opened another proof's commitment")
+	}
+	longer, err := sq.SNARKStatement(proof, len("snark-doc")+1)
+	if err != nil || witness.Check(longer) == nil {
+		t.Errorf("Witness accepted under another layout (%v)", err)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+// catState returns (|0…0⟩ + e^{iφ}|1…1⟩)/√2 on n qubits.
+func catState(n int, phase float64) []complex128 {
+	v := make([]complex128, 1<<n)
+	v[0] = complex(1/math.Sqrt2, 0)
+	v[len(v)-1] = complex(math.Cos(phase)/math.Sqrt2, math.Sin(phase)/math.Sqrt2)
+	return v
+}
+
+func TestProveBellPairPossession(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(4, 128, []byte("state-family-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	proof, err := sq.ProveBellPairPossession(catState(2, 0), "bell", testutil.Key())
+	if err != nil {
+		t.Fatalf("ProveBellPairPossession failed: %v", err)
+	}
+	if proof.Witness == nil || proof.Witness.Family != StateFamilyBell || len(proof.Witness.Responses) == 0 {
+		t.Fatalf("Unexpected witness: %+v", proof.Witness)
+	}
+	if !sq.VerifySecureProof(proof, testutil.Key()) {
+		t.Fatal("Bell pair proof was rejected")
+	}
+	if err := NewPolicy(RequireStateFamily(StateFamilyBell, 2)).Evaluate(proof).Err(); err != nil {
+		t.Errorf("Policy rejected the Bell witness: %v", err)
+	}
+	if err := NewPolicy(RequireStateFamily(StateFamilyGHZ, 3)).Evaluate(proof).Err(); err == nil {
+		t.Error("A Bell witness should not satisfy a GHZ requirement")
+	}
+
+	// Product states and the orthogonal Bell state are not witnessed
+	product := []complex128{1, 0, 0, 0}
+	singlet := catState(2, math.Pi)
+	for name, v := range map[string][]complex128{"product": product, "phi-minus": singlet} {
+		if _, err := sq.ProveBellPairPossession(v, "bell", testutil.Key()); !errors.Is(err, ErrNotInStateFamily) {
+			t.Errorf("%s: expected ErrNotInStateFamily, got %v", name, err)
+		}
+	}
+	if _, err := sq.ProveBellPairPossession(catState(3, 0), "bell", testutil.Key()); err == nil {
+		t.Error("Expected an error for a three-qubit vector")
+	}
+}
+
+func TestProveGHZPossession(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(8, 128, []byte("state-family-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	// A small phase keeps the fidelity above the bound
+	proof, err := sq.ProveGHZPossession(3, catState(3, 0.3), "ghz", testutil.Key())
+	if err != nil {
+		t.Fatalf("ProveGHZPossession failed: %v", err)
+	}
+	if !sq.VerifySecureProof(proof, testutil.Key()) {
+		t.Fatal("GHZ proof was rejected")
+	}
+	if err := NewPolicy(RequireStateFamily(StateFamilyGHZ, 4)).Evaluate(proof).Err(); err == nil {
+		t.Error("A 3-qubit witness should not satisfy a 4-qubit requirement")
+	}
+	if _, err := sq.ProveGHZPossession(2, catState(2, 0), "ghz", testutil.Key()); err == nil {
+		t.Error("Expected an error for a 2-qubit GHZ state")
+	}
+}
+
+func TestFamilyWitnessTampering(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(4, 128, []byte("state-family-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	proof, err := sq.ProveGHZPossession(3, catState(3, 0), "ghz", testutil.Key())
+	if err != nil {
+		t.Fatalf("ProveGHZPossession failed: %v", err)
+	}
+
+	// Each tampered proof is re-signed, so only the witness is wrong
+	tampers := map[string]func(w *FamilyWitness){
+		"family":     func(w *FamilyWitness) { w.Family = StateFamilyBell },
+		"qubits":     func(w *FamilyWitness) { w.Qubits = 4 },
+		"bound":      func(w *FamilyWitness) { w.FidelityBound = 0.9 },
+		"operator":   func(w *FamilyWitness) { w.Responses[0].Operator = "+ZZZ" },
+		"response":   func(w *FamilyWitness) { w.Responses[1].Response = w.Responses[0].Response },
+		"commitment": func(w *FamilyWitness) { w.Responses[2].Commitment = w.Responses[3].Commitment },
+		"truncated":  func(w *FamilyWitness) { w.Responses = w.Responses[1:] },
+	}
+	for name, tamper := range tampers {
+		p, err := copySecureProof(proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tamper(p.Witness)
+		if err := sq.signSecureProof(p, testutil.Key()); err != nil {
+			t.Fatal(err)
+		}
+		if sq.VerifySecureProof(p, testutil.Key()) {
+			t.Errorf("Witness with tampered %s was accepted", name)
+		}
+	}
+}
+
+func TestPauliExpectationOfStabilizers(t *testing.T) {
+	for n := 3; n <= 5; n++ {
+		operators, err := witnessOperators(StateFamilyGHZ, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, op := range operators {
+			if got := pauliExpectation(catState(n, 0), op); math.Abs(got-1) > 1e-12 {
+				t.Errorf("⟨GHZ_%d|%s|GHZ_%d⟩ = %v, want 1", n, op, n, got)
+			}
+		}
+	}
+	bell, _ := witnessOperators(StateFamilyBell, 2)
+	for _, op := range bell {
+		if got := pauliExpectation(catState(2, 0), op); math.Abs(got-1) > 1e-12 {
+			t.Errorf("⟨Φ+|%s|Φ+⟩ = %v, want 1", op, got)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/cmplx"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const bellQASM = `OPENQASM 2.0;
+include "qelib1.inc";
+qreg q[2];
+creg c[2];
+h q[0]; // superposition
+cx q[0],q[1];
+barrier q;
+measure q -> c;
+`
+
+func TestParseQASMAndSimulate(t *testing.T) {
+	circuit, err := ParseQASM(bellQASM)
+	if err != nil {
+		t.Fatalf("ParseQASM failed: %v", err)
+	}
+	if circuit.NumQubits != 2 || circuit.NumClbits != 2 {
+		t.Errorf("Unexpected registers: %d qubits, %d clbits", circuit.NumQubits, circuit.NumClbits)
+	}
+	state, err := SimulateStatevector(circuit)
+	if err != nil {
+		t.Fatalf("SimulateStatevector failed: %v", err)
+	}
+	want := []complex128{complex(1/math.Sqrt2, 0), 0, 0, complex(1/math.Sqrt2, 0)}
+	for i := range want {
+		if cmplx.Abs(state[i]-want[i]) > 1e-12 {
+			t.Fatalf("Bell state amplitude %d: got %v, want %v", i, state[i], want[i])
+		}
+	}
+
+	// Qubit 0 is the least significant bit, and a register operand
+	// broadcasts single-qubit gates; nested parentheses are evaluated
+	circuit, err = ParseQASM("OPENQASM 2.0;\nqreg a[1];\nqreg b[2];\nx a[0];\nry(2*(pi/4)) b;\n")
+	if err != nil {
+		t.Fatalf("ParseQASM failed: %v", err)
+	}
+	state, _ = SimulateStatevector(circuit)
+	for i, amp := range state {
+		expected := 0.0
+		if i&1 == 1 {
+			expected = 0.25
+		}
+		if p := real(amp * cmplx.Conj(amp)); math.Abs(p-expected) > 1e-12 {
+			t.Errorf("Probability of basis state %d: got %f, want %f", i, p, expected)
+		}
+	}
+
+	for name, src := range map[string]string{
+		"no header":         "qreg q[1];\nh q[0];\n",
+		"missing semicolon": "OPENQASM 2.0;\nqreg q[1];\nh q[0]",
+		"unknown gate":      "OPENQASM 2.0;\nqreg q[1];\nfoo q[0];\n",
+		"custom gate":       "OPENQASM 2.0;\ngate g a { h a; }\nqreg q[1];\n",
+		"after measure":     "OPENQASM 2.0;\nqreg q[1];\ncreg c[1];\nmeasure q[0] -> c[0];\nh q[0];\n",
+		"out of range":      "OPENQASM 2.0;\nqreg q[1];\nh q[1];\n",
+		"bad angle":         "OPENQASM 2.0;\nqreg q[1];\nrx(pi/) q[0];\n",
+		"missing angle":     "OPENQASM 2.0;\nqreg q[1];\nrz q[0];\n",
+		"repeated qubit":    "OPENQASM 2.0;\nqreg q[2];\ncx q[0],q[0];\n",
+	} {
+		circuit, err := ParseQASM(src)
+		if err == nil {
+			_, err = SimulateStatevector(circuit)
+		}
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestAmplitudesJSON(t *testing.T) {
+	state := CachedQuantumState{Name: "phase", Vector: Amplitudes{complex(0.6, 0), complex(0, 0.8)}, Qubits: 1}
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"vector":[{"re":0.6,"im":0},{"re":0,"im":0.8}]`) {
+		t.Errorf("Vector should encode as ComplexAmplitude objects: %s", data)
+	}
+	var decoded CachedQuantumState
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Vector[1] != complex(0, 0.8) {
+		t.Errorf("Round trip failed: %v %v", decoded.Vector, err)
+	}
+}
+
+func TestCheckCachedState(t *testing.T) {
+	bell, err := StateFromQASM("bell", bellQASM)
+	if err != nil {
+		t.Fatalf("StateFromQASM failed: %v", err)
+	}
+	if check := CheckCachedState(bell); !check.OK() {
+		t.Errorf("Imported Bell state should pass: %v", check.Problems)
+	}
+
+	bad := bell
+	bad.Vector = Amplitudes{1, 1, 0, 0}
+	bad.Fidelity = 0.99
+	check := CheckCachedState(bad)
+	problems := strings.Join(check.Problems, "\n")
+	if !strings.Contains(problems, "not normalized") || !strings.Contains(problems, "recomputed") {
+		t.Errorf("Expected normalization and fidelity problems, got %v", check.Problems)
+	}
+
+	bad = bell
+	bad.Qubits = 3
+	if CheckCachedState(bad).OK() {
+		t.Error("A 4-amplitude vector cannot hold 3 qubits")
+	}
+}
+
+func TestStateLibraryCuration(t *testing.T) {
+	cache, _ := NewQuantumStateCache(filepath.Join(t.TempDir(), QuantumStateCacheFile))
+
+	// The layout qiskit_executor.py writes
+	states, err := ImportStatesJSON([]byte(`{"states": {
+		"plus": {"vector": [[0.7071067811865476, 0], [0.7071067811865476, 0]], "qubits": 1,
+			"backend": "fake_almaden", "fidelity": 0.97},
+		"zero": {"vector": [[1, 0], [0, 0]], "qubits": 1, "backend": "ibm_brisbane", "fidelity": 0.99}
+	}}`))
+	if err != nil || len(states) != 2 || states[0].Name != "plus" {
+		t.Fatalf("ImportStatesJSON failed: %v %v", states, err)
+	}
+	bell, _ := StateFromQASM("bell", bellQASM)
+	states = append(states, bell)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := cache.ImportStates(states, now); err != nil {
+		t.Fatalf("ImportStates failed: %v", err)
+	}
+	bad := states[0]
+	bad.Name = "broken"
+	bad.Vector = Amplitudes{2, 0}
+	if err := cache.ImportStates([]CachedQuantumState{bad}, now); err == nil {
+		t.Error("Importing an unnormalized state should fail")
+	}
+
+	checks, err := cache.VerifyStates()
+	if err != nil || len(checks) != 3 {
+		t.Fatalf("VerifyStates returned %v (%v)", checks, err)
+	}
+	for _, check := range checks {
+		if !check.OK() {
+			t.Errorf("%s: %v", check.Name, check.Problems)
+		}
+	}
+
+	if _, err := cache.PruneStates(PruneCriteria{}); err == nil {
+		t.Error("Prune without criteria should be rejected")
+	}
+	if pruned, _ := cache.PruneStates(PruneCriteria{OlderThan: now}); len(pruned) != 0 {
+		t.Errorf("Nothing is older than the import time, pruned %v", pruned)
+	}
+	pruned, err := cache.PruneStates(PruneCriteria{OlderThan: now.Add(time.Hour), Backend: "fake_almaden"})
+	if err != nil || len(pruned) != 1 || pruned[0] != "plus" {
+		t.Errorf("Expected to prune plus, got %v (%v)", pruned, err)
+	}
+	remaining, _ := cache.GetStatesByType("all")
+	if len(remaining) != 2 {
+		t.Errorf("Expected 2 remaining states, got %d", len(remaining))
+	}
+
+	if err := cache.UpdateUsageTime(150); err != nil {
+		t.Fatal(err)
+	}
+	usage, err := cache.QuotaUsage(DefaultQuantumTimeQuota)
+	if err != nil || usage.Used != 150*time.Second || usage.Remaining() != 450*time.Second || usage.Fraction() != 0.25 {
+		t.Errorf("Unexpected quota usage %+v (%v)", usage, err)
+	}
+	var summary bytes.Buffer
+	if err := cache.WriteSummary(&summary, DefaultQuantumTimeQuota); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(summary.String(), "Total States: 2") || !strings.Contains(summary.String(), "25.0%") {
+		t.Errorf("Unexpected summary:\n%s", summary.String())
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestNewStateVectorInvariants(t *testing.T) {
+	input := []complex128{complex(3, 0), complex(0, 4)}
+	state, err := NewStateVector(input)
+	if err != nil {
+		t.Fatalf("NewStateVector failed: %v", err)
+	}
+	if input[0] != complex(3, 0) {
+		t.Error("NewStateVector should not modify its input")
+	}
+	if state.Dimension() != 2 || math.Abs(Norm(state.Amplitudes())-1) > 1e-12 {
+		t.Errorf("Expected a normalized 2-dimensional state, got %v", state.Amplitudes())
+	}
+	if p, _ := state.Probability(1); math.Abs(p-0.64) > 1e-12 {
+		t.Errorf("Probability of |1> is %g, expected 0.64", p)
+	}
+
+	for name, amplitudes := range map[string][]complex128{
+		"empty":    nil,
+		"zero":     {0, 0, 0, 0},
+		"nan":      {complex(math.NaN(), 0), 1},
+		"infinite": {cmplx.Inf(), 1},
+	} {
+		if _, err := NewStateVector(amplitudes); err == nil {
+			t.Errorf("%s vector should be rejected", name)
+		}
+	}
+}
+
+func TestStateVectorAccessorsAreSafe(t *testing.T) {
+	state, _ := NewStateVector([]complex128{1, 0, 0, 0})
+	if _, err := state.Amplitude(4); err == nil {
+		t.Error("Out-of-range amplitude index should fail")
+	}
+	if _, err := state.Probability(-1); err == nil {
+		t.Error("Negative probability index should fail")
+	}
+
+	amplitudes := state.Amplitudes()
+	amplitudes[0] = 0
+	if a, _ := state.Amplitude(0); a != 1 {
+		t.Error("Amplitudes should return a copy")
+	}
+}
+
+func TestStateVectorCanonicalHash(t *testing.T) {
+	enc := DefaultNumericEncoding()
+	a, _ := NewStateVector([]complex128{1, 1, 0, 0})
+	b, _ := NewStateVector([]complex128{2, 2, 0, 0})
+	c, _ := NewStateVector([]complex128{1, 1})
+
+	ha, err := a.CanonicalHash(enc)
+	if err != nil {
+		t.Fatalf("CanonicalHash failed: %v", err)
+	}
+	hb, _ := b.CanonicalHash(enc)
+	hc, _ := c.CanonicalHash(enc)
+	if !bytes.Equal(ha, hb) {
+		t.Error("States that normalize to the same amplitudes should hash equally")
+	}
+	if bytes.Equal(ha, hc) {
+		t.Error("States of different dimension should hash differently")
+	}
+	if _, err := a.CanonicalHash(NumericEncoding{Scheme: "float-text"}); err == nil {
+		t.Error("Unknown numeric encodings should be rejected")
+	}
+}
+
+func TestStateVectorEncodingMetadata(t *testing.T) {
+	state, err := NewStateVectorFromBytes([]byte("typed state"), 8, DimensionSelectionExplicit)
+	if err != nil {
+		t.Fatalf("NewStateVectorFromBytes failed: %v", err)
+	}
+	if e := state.Encoding(); e == nil || e.Encoder != BytesEncoderSHA256Expand || e.Dimension != 8 {
+		t.Fatalf("Unexpected encoding %+v", e)
+	}
+	if _, err := state.WithEncoding(DataEncoding{
+		Encoder: BytesEncoderSHA256Expand, Version: BytesEncoderVersion, Dimension: 4, Selection: DimensionSelectionAuto,
+	}); err == nil {
+		t.Error("Encoding with a mismatched dimension should be rejected")
+	}
+
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("state-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	key := []byte("state-vector-test-key-32-bytes!!")
+	proof, err := sq.SecureProveState(state, "typed", key)
+	if err != nil {
+		t.Fatalf("SecureProveState failed: %v", err)
+	}
+	if proof.DataEncoding == nil || proof.DataEncoding.Dimension != 8 {
+		t.Errorf("Proof should record the state's encoding, got %+v", proof.DataEncoding)
+	}
+	if !sq.VerifySecureProof(proof, key) {
+		t.Error("Proof of a typed state should verify")
+	}
+}
+
+func TestSecureProveRejectsZeroVector(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("state-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	zero := make([]complex128, 4)
+	if _, err := sq.SecureProveVectorKnowledge(zero, "zero", []byte("key")); err == nil {
+		t.Error("Zero vectors have no quantum state and should be rejected")
+	}
+	if _, err := sq.SecureProveVectors([][]complex128{{1, 0}, zero}, "set", []byte("key")); err == nil {
+		t.Error("Vector sets containing a zero vector should be rejected")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func hasReason(reasons []string, substr string) bool {
+	for _, r := range reasons {
+		if strings.Contains(r, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestStrictModeAcceptsHardenedProofs(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("strict-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	key := testutil.Key()
+	proof, err := sq.SecureProveVectorKnowledge([]complex128{complex(0.6, 0), complex(0, 0.8)}, "strict", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+
+	result := sq.VerifySecureProofWithOptions(proof, key, VerifyOptions{StrictMode: true})
+	if !result.Valid || len(result.Reasons) != 0 {
+		t.Errorf("Current proof should pass strict mode: %+v", result)
+	}
+	proof.MerkleRoot = strings.Repeat("0", len(proof.MerkleRoot))
+	if sq.VerifySecureProofWithOptions(proof, key, VerifyOptions{StrictMode: true}).Valid {
+		t.Error("Strict mode must still verify the proof itself")
+	}
+}
+
+func TestStrictModeRejectsTruncatedDigests(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("strict-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	sq.DigestLengths = LegacyDigestLengths
+	key := testutil.Key()
+	proof, err := sq.SecureProveVectorKnowledge([]complex128{complex(0.6, 0), complex(0, 0.8)}, "strict", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+
+	if !sq.VerifySecureProofWithOptions(proof, key, VerifyOptions{}).Valid {
+		t.Fatal("Truncated digests are accepted outside strict mode")
+	}
+	result := sq.VerifySecureProofWithOptions(proof, key, VerifyOptions{StrictMode: true})
+	if result.Valid || !hasReason(result.Reasons, "8-byte responses") {
+		t.Errorf("Strict mode should reject truncated digests: %+v", result)
+	}
+}
+
+func TestStrictModeRejectsLegacyProofs(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("strict-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	sq.DigestLengths = LegacyDigestLengths
+	key := testutil.Key()
+	proof, err := sq.SecureProveVectorKnowledge([]complex128{complex(0.6, 0), complex(0, 0.8)}, "strict", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	v1 := secureProofV1{
+		QuantumDimensions: proof.QuantumDimensions,
+		CommitmentHash:    proof.CommitmentHash,
+		ChallengeResponse: proof.ChallengeResponse,
+		MerkleRoot:        proof.MerkleRoot,
+		StateMetadata:     proof.StateMetadata,
+		Identifier:        proof.Identifier,
+		Timestamp:         proof.Timestamp,
+	}
+	msg, _ := json.Marshal(&v1)
+	legacySigner := *sq.Signer
+	legacySigner.Ctx = nil
+	sig, _ := legacySigner.Sign(msg)
+	v1.Signature = hex.EncodeToString(sig)
+	data, _ := json.Marshal(&v1)
+	legacy, err := ParseSecureProof(data)
+	if err != nil {
+		t.Fatalf("ParseSecureProof failed: %v", err)
+	}
+
+	if !sq.VerifySecureProofWithOptions(legacy, key, VerifyOptions{}).Valid {
+		t.Fatal("v1 proof should verify outside strict mode")
+	}
+	result := sq.VerifySecureProofWithOptions(legacy, key, VerifyOptions{StrictMode: true})
+	if result.Valid {
+		t.Fatal("Strict mode must reject v1 proofs")
+	}
+	for _, want := range []string{"version 1 is deprecated", "fixed truncation", "context string",
+		"parameters are not bound", "Fiat–Shamir", "numeric encoding"} {
+		if !hasReason(result.Reasons, want) {
+			t.Errorf("Missing reason %q in %q", want, result.Reasons)
+		}
+	}
+}
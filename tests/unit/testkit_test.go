@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTestKitMintsEveryDefect(t *testing.T) {
+	kit, err := NewTestKit(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("NewTestKit failed: %v", err)
+	}
+	valid, err := kit.Mint("")
+	if err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+	if !valid.Valid || !kit.Verify(valid.Proof).Valid {
+		t.Fatalf("Valid proof was rejected: %v", kit.Verify(valid.Proof).Reasons)
+	}
+	for _, defect := range TestKitDefects() {
+		minted, err := kit.Mint(defect)
+		if err != nil {
+			t.Errorf("Mint(%s) failed: %v", defect, err)
+			continue
+		}
+		if minted.Valid || kit.Verify(minted.Proof).Valid {
+			t.Errorf("Proof with defect %s was accepted", defect)
+		}
+	}
+	if _, err := kit.Mint("no_such_defect"); err == nil {
+		t.Error("Expected an error for an unknown defect")
+	}
+}
+
+func TestTestKitSeedPinsKeys(t *testing.T) {
+	a, _ := NewTestKit(make([]byte, 32))
+	b, _ := NewTestKit(make([]byte, 32))
+	if a.Info().PublicKey != b.Info().PublicKey || a.Info().Key != b.Info().Key {
+		t.Error("The same seed should give the same keys")
+	}
+	if _, err := NewTestKit([]byte("short")); err == nil {
+		t.Error("Expected an error for a short seed")
+	}
+}
+
+func TestTestKitHandler(t *testing.T) {
+	kit, err := NewTestKit(nil)
+	if err != nil {
+		t.Fatalf("NewTestKit failed: %v", err)
+	}
+	server := httptest.NewServer(kit.Handler())
+	defer server.Close()
+
+	var info TestKitInfo
+	resp, err := http.Get(server.URL + "/v1/info")
+	if err != nil {
+		t.Fatalf("GET /v1/info failed: %v", err)
+	}
+	json.NewDecoder(resp.Body).Decode(&info)
+	resp.Body.Close()
+	if len(info.Defects) != len(TestKitDefects()) || info.PublicKey == "" {
+		t.Fatalf("Unexpected info: %+v", info)
+	}
+
+	for _, defect := range []string{CorpusVariantValid, TamperMerkleRoot, ConformanceForeignContext} {
+		resp, err := http.Get(server.URL + "/v1/mint?defect=" + defect)
+		if err != nil {
+			t.Fatalf("GET /v1/mint failed: %v", err)
+		}
+		var minted TestKitProof
+		json.NewDecoder(resp.Body).Decode(&minted)
+		resp.Body.Close()
+
+		body, _ := json.Marshal(VerifyRequest{Proof: minted.Proof})
+		resp, err = http.Post(server.URL+"/v1/verify", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST /v1/verify failed: %v", err)
+		}
+		var result VerificationResult
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if result.Valid != minted.Valid || result.Valid != (defect == CorpusVariantValid) {
+			t.Errorf("%s: verified %v, minted as valid=%v", defect, result.Valid, minted.Valid)
+		}
+	}
+
+	resp, err = http.Get(server.URL + "/v1/mint?defect=" + ConformanceReplay)
+	if err != nil {
+		t.Fatalf("GET /v1/mint failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unmintable defect, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestTimeAttestedProofWindow(t *testing.T) {
+	authority, err := NewEd25519TimeAuthority("test-authority", 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewEd25519TimeAuthority failed: %v", err)
+	}
+	server := httptest.NewServer(authority)
+	defer server.Close()
+
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("time-context"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	sq.TimeAuthority = NewHTTPTimeAuthority("test-authority", server.URL, authority.PublicKey())
+
+	key := testutil.Key()
+	vector := []complex128{complex(1, 0), complex(0, 0), complex(0, 0), complex(0, 0)}
+	proof, err := sq.SecureProveVectorKnowledge(vector, "timed_proof", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	if proof.TimeAttestation == nil {
+		t.Fatal("Expected a time attestation on the proof")
+	}
+
+	if !sq.VerifySecureProof(proof, key) {
+		t.Fatal("Time-attested proof should verify")
+	}
+
+	now := proof.TimeAttestation.Midpoint
+	if !sq.VerifySecureProofWithin(proof, key, now.Add(time.Minute), time.Hour) {
+		t.Error("Proof should be inside a one hour window after one minute")
+	}
+	if sq.VerifySecureProofWithin(proof, key, now.Add(2*time.Hour), time.Hour) {
+		t.Error("Proof should be outside a one hour window after two hours")
+	}
+	if sq.VerifySecureProofWithin(proof, key, now.Add(-time.Hour), time.Hour) {
+		t.Error("Proof attested in the future should be rejected")
+	}
+
+	// An attestation the configured authority did not issue is rejected
+	other, _ := NewEd25519TimeAuthority("test-authority", time.Second)
+	sq.TimeAuthority = other
+	if sq.VerifySecureProof(proof, key) {
+		t.Error("Proof attested by a different key should not verify")
+	}
+}
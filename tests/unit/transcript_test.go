@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+// checkStep recomputes a SHA-256 transcript step from its input.
+func checkStep(t *testing.T, name string, step *TranscriptStep) []byte {
+	t.Helper()
+	if step == nil {
+		t.Fatalf("Transcript has no %s step", name)
+	}
+	input, err := hex.DecodeString(step.Input)
+	if err != nil {
+		t.Fatalf("%s input is not hex: %v", name, err)
+	}
+	if sum := sha256.Sum256(input); step.Algorithm == "sha256" && hex.EncodeToString(sum[:]) != step.Output {
+		t.Errorf("%s output does not hash from its input", name)
+	}
+	return input
+}
+
+func TestExtractTranscript(t *testing.T) {
+	sq, proof := newTestProof(t, 64)
+	transcript, err := sq.ExtractTranscript(proof)
+	if err != nil {
+		t.Fatalf("ExtractTranscript failed: %v", err)
+	}
+	if m := transcript.Mismatches(proof); len(m) != 0 {
+		t.Errorf("A valid proof should reproduce every step, mismatched %v", m)
+	}
+
+	checkStep(t, "parameters", transcript.Parameters)
+	if transcript.Parameters.Output != proof.ParametersHash {
+		t.Error("Parameters step should produce the proof's parameters hash")
+	}
+	checkStep(t, "challenge_binding", transcript.ChallengeBinding)
+	if transcript.ChallengeBinding.Output != proof.ChallengeBinding {
+		t.Error("Binding step should produce the proof's challenge binding")
+	}
+	seed := checkStep(t, "challenge_derivation", transcript.ChallengeDerivation)
+	commitment, _ := hex.DecodeString(proof.CommitmentHash)
+	if !bytes.HasPrefix(seed, []byte("qzkp/challenge-derivation/v1")) || !bytes.Contains(seed, commitment) {
+		t.Error("Challenge seed should start with the domain and contain the commitment")
+	}
+	if len(transcript.Challenges) != len(proof.ChallengeResponse) {
+		t.Errorf("Got %d challenges for %d responses", len(transcript.Challenges), len(proof.ChallengeResponse))
+	}
+
+	for i, leaf := range transcript.ResponseLeaves {
+		checkStep(t, "response_leaf", &leaf)
+		if leaf.Output != transcript.MerkleLevels[0][i] {
+			t.Errorf("Response leaf %d should be the first Merkle level", i)
+		}
+	}
+	levels := transcript.MerkleLevels
+	if root := levels[len(levels)-1]; len(root) != 1 || root[0] != proof.MerkleRoot {
+		t.Errorf("Merkle levels should end at the proof's root, got %v", root)
+	}
+
+	msg := checkStep(t, "signature", transcript.Signature)
+	sig, _ := hex.DecodeString(transcript.Signature.Claimed)
+	if !sq.Signer.Verify(msg, sig) {
+		t.Error("The proof signature should verify over the transcript's signed message")
+	}
+	if bytes.Contains(msg, testutil.Key()) {
+		t.Error("The transcript should not contain the key")
+	}
+}
+
+func TestExtractTranscriptDetectsMismatches(t *testing.T) {
+	sq, proof := newTestProof(t, 64)
+	tampered := *proof
+	tampered.ChallengeResponse = append([]ChallengeResponse(nil), proof.ChallengeResponse...)
+	tampered.ChallengeResponse[0].ChallengeIndex++
+	tampered.Epoch++
+
+	transcript, err := sq.ExtractTranscript(&tampered)
+	if err != nil {
+		t.Fatalf("ExtractTranscript failed: %v", err)
+	}
+	got := transcript.Mismatches(&tampered)
+	for _, want := range []string{"challenges", "challenge_binding", "merkle_root"} {
+		if !hasReason(got, want) {
+			t.Errorf("Expected a %s mismatch, got %v", want, got)
+		}
+	}
+
+	other := newTestProver(t, 128)
+	if transcript, err := other.ExtractTranscript(proof); err != nil || !hasReason(transcript.Mismatches(proof), "parameters") {
+		t.Errorf("Other parameters should not reproduce the parameters hash: %v", err)
+	}
+	if _, err := sq.ExtractTranscript(&SecureProof{Version: ProofVersionLegacy}); err == nil {
+		t.Error("Version 1 proofs should have no transcript")
+	}
+}
+
+func TestExtractTranscriptBatchSigned(t *testing.T) {
+	sq, proof := newTestProof(t, 64)
+	if err := sq.SignProofBatch([]*SecureProof{proof}); err != nil {
+		t.Fatalf("SignProofBatch failed: %v", err)
+	}
+	transcript, err := sq.ExtractTranscript(proof)
+	if err != nil {
+		t.Fatalf("ExtractTranscript failed: %v", err)
+	}
+	checkStep(t, "batch_leaf", transcript.BatchLeaf)
+	if transcript.BatchLeaf.Output != proof.Batch.Root {
+		t.Error("A single-proof batch root should be its leaf")
+	}
+	msg := checkStep(t, "batch_root", transcript.BatchRoot)
+	sig, _ := hex.DecodeString(transcript.BatchRoot.Claimed)
+	if !sq.Signer.Verify(msg, sig) {
+		t.Error("The batch signature should verify over the transcript's root message")
+	}
+}
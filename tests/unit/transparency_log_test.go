@@ -0,0 +1,100 @@
+package main
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestTransparencyLog(t *testing.T) {
+	sq, first := newTestProof(t, 64)
+	second, err := sq.SecureProveVectorKnowledge(testutil.RampVector(4), "logged_second", testutil.Key())
+	if err != nil {
+		t.Fatalf("SecureProveVectorKnowledge failed: %v", err)
+	}
+	third, _ := sq.SecureProveVectorKnowledge(testutil.RampVector(4), "logged_third", testutil.Key())
+
+	logKey, _ := NewSignatureScheme([]byte("transparency-log"))
+	log, err := NewTransparencyLog(logKey)
+	if err != nil {
+		t.Fatalf("NewTransparencyLog failed: %v", err)
+	}
+	for i, proof := range []*SecureProof{first, second, first} {
+		if index, err := log.Append(proof); err != nil || index != i%2 {
+			t.Fatalf("Append %d returned index %d (%v)", i, index, err)
+		}
+	}
+	oldRoot, err := log.Root()
+	if err != nil || oldRoot.Size != 2 {
+		t.Fatalf("Root should cover both proofs: %+v (%v)", oldRoot, err)
+	}
+	log.Append(third)
+	root, _ := log.Root()
+
+	inclusion, err := log.InclusionProof(second, root.Size)
+	if err != nil {
+		t.Fatalf("InclusionProof failed: %v", err)
+	}
+	if err := VerifyLogged(second, inclusion, root, logKey); err != nil {
+		t.Errorf("Logged proof should verify: %v", err)
+	}
+	if err := VerifyLogged(first, inclusion, root, logKey); err == nil {
+		t.Error("Another proof's inclusion proof should not verify")
+	}
+	if err := VerifyLogged(second, inclusion, oldRoot, logKey); err == nil {
+		t.Error("An inclusion proof for another tree size should not verify")
+	}
+	otherKey, _ := NewSignatureScheme([]byte("transparency-log"))
+	if err := VerifyLogged(second, inclusion, root, otherKey); err == nil {
+		t.Error("A root from another log should not verify")
+	}
+	forged := *root
+	forged.RootHash = oldRoot.RootHash
+	if err := VerifyLogged(second, inclusion, &forged, logKey); err == nil {
+		t.Error("A root with an altered hash should not verify")
+	}
+	if _, err := log.InclusionProof(third, oldRoot.Size); err == nil {
+		t.Error("A proof logged after the root should have no inclusion proof under it")
+	}
+
+	consistency, err := log.ConsistencyProof(oldRoot.Size, root.Size)
+	if err != nil {
+		t.Fatalf("ConsistencyProof failed: %v", err)
+	}
+	if err := VerifyLogConsistency(oldRoot, root, consistency, logKey); err != nil {
+		t.Errorf("Later root should extend the earlier one: %v", err)
+	}
+	if err := VerifyLogConsistency(root, oldRoot, consistency, logKey); err == nil {
+		t.Error("Roots in the wrong order should not be consistent")
+	}
+}
+
+func TestTransparencyLogTreeMath(t *testing.T) {
+	var leaves [][]byte
+	for i := 0; i < 20; i++ {
+		sum := sha256.Sum256([]byte{byte(i)})
+		leaves = append(leaves, sum[:])
+	}
+	for size := 1; size <= len(leaves); size++ {
+		root := logTreeHash(leaves[:size])
+		for index := 0; index < size; index++ {
+			path := logInclusionPath(index, leaves[:size])
+			if !verifyLogInclusion(leaves[index], index, size, path, root) {
+				t.Fatalf("Leaf %d of %d should verify", index, size)
+			}
+			if verifyLogInclusion(leaves[(index+1)%size], index, size, path, root) && size > 1 {
+				t.Fatalf("Leaf %d of %d should not verify at another index", index, size)
+			}
+		}
+		for oldSize := 1; oldSize <= size; oldSize++ {
+			path := logConsistencyPath(oldSize, leaves[:size], true)
+			if !verifyLogConsistency(oldSize, size, logTreeHash(leaves[:oldSize]), root, path) {
+				t.Fatalf("Size %d should be consistent with %d", oldSize, size)
+			}
+			if oldSize < size && verifyLogConsistency(oldSize, size, logTreeHash(leaves[1:oldSize+1]), root, path) {
+				t.Fatalf("A rewritten prefix of %d should not be consistent with %d", oldSize, size)
+			}
+		}
+	}
+}
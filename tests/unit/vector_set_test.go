@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestSecureProveVectors(t *testing.T) {
+	sq, err := NewSecureQuantumZKP(3, 128, []byte("vector-set-test"))
+	if err != nil {
+		t.Fatalf("NewSecureQuantumZKP failed: %v", err)
+	}
+	created := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sq.Clock = func() time.Time { return created }
+	key := testutil.Key()
+	vectors := [][]complex128{
+		{complex(1, 0), complex(0, 0)},
+		{complex(0.6, 0), complex(0, 0.8)},
+		{complex(0.5, 0), complex(0.5, 0), complex(0.5, 0), complex(0.5, 0)},
+	}
+
+	proof, err := sq.SecureProveVectors(vectors, "ensemble", key)
+	if err != nil {
+		t.Fatalf("SecureProveVectors failed: %v", err)
+	}
+	if !proof.Timestamp.Equal(created) {
+		t.Errorf("The vector set proof should be timestamped by sq.Clock, got %v", proof.Timestamp)
+	}
+	if len(proof.SubProofs) != len(vectors) {
+		t.Fatalf("Expected %d sub-proofs, got %d", len(vectors), len(proof.SubProofs))
+	}
+	if !sq.VerifyVectorSetProof(proof, key) {
+		t.Fatal("Vector set proof should verify")
+	}
+	if !sq.VerifyVectorSetProofSample(proof, key, 1) {
+		t.Error("Sampled verification should succeed")
+	}
+
+	// Reordering sub-proofs changes the combined root
+	reordered := *proof
+	reordered.SubProofs = []*SecureProof{proof.SubProofs[1], proof.SubProofs[0], proof.SubProofs[2]}
+	if sq.VerifyVectorSetProof(&reordered, key) {
+		t.Error("Reordered vector set proof should not verify")
+	}
+
+	// A corrupted sub-proof is caught by full verification
+	corrupted := *proof
+	corrupted.SubProofs = append([]*SecureProof(nil), proof.SubProofs...)
+	bad := *proof.SubProofs[2]
+	bad.Epoch++
+	corrupted.SubProofs[2] = &bad
+	if sq.VerifyVectorSetProof(&corrupted, key) {
+		t.Error("Vector set proof with a corrupted sub-proof should not verify")
+	}
+
+	if _, err := sq.SecureProveVectors(nil, "ensemble", key); err == nil {
+		t.Error("Expected an error for an empty vector set")
+	}
+}
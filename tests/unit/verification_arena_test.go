@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestVerificationArenaMatchesAllocatingEncodings(t *testing.T) {
+	sq, proof := newTestProof(t, 128)
+	a := getVerificationArena()
+	defer a.release()
+
+	want, err := secureProofSigningMessage(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := a.signingMessage(proof); err != nil || !bytes.Equal(got, want) {
+		t.Errorf("Signing message differs from json.Marshal (%v)", err)
+	}
+
+	leaves := make([][]byte, len(proof.ChallengeResponse))
+	for i, response := range proof.ChallengeResponse {
+		encoded, _ := json.Marshal(response)
+		leaf := sha256.Sum256(encoded)
+		leaves[i] = leaf[:]
+	}
+	root, err := a.merkleRoot(proof.ChallengeResponse)
+	if err != nil || !bytes.Equal(root[:], merkleRootOfLeaves(leaves)) {
+		t.Errorf("Merkle root differs from the allocating fold (%v)", err)
+	}
+	if string(a.hexDigest(root[:])) != proof.MerkleRoot {
+		t.Error("Hex digest differs from the proof's Merkle root")
+	}
+
+	commitment, _ := hex.DecodeString(proof.CommitmentHash)
+	n, nonceLength := len(proof.ChallengeResponse), proof.ChallengeNonceLength
+	wantChallenges, err := sq.deriveChallenges(commitment, proof.Epoch, proof.StateMetadata.Dimension, n, nonceLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := a.deriveChallenges(sq, commitment, proof.Epoch, proof.StateMetadata.Dimension, n, nonceLength)
+	if err != nil || !reflect.DeepEqual(got, wantChallenges) {
+		t.Errorf("Arena challenges differ from the allocating derivation (%v)", err)
+	}
+	if binding, err := a.challengeBinding(commitment, got, proof.ChallengeResponse); err != nil || string(binding) != proof.ChallengeBinding {
+		t.Errorf("Arena challenge binding differs from the prover's (%v)", err)
+	}
+}
+
+func TestVerificationArenaIsScrubbedOnRelease(t *testing.T) {
+	sq, proof := newTestProof(t, 128)
+	a := getVerificationArena()
+	if _, err := a.signingMessage(proof); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.decodeSignature(proof.Signature); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.merkleRoot(proof.ChallengeResponse); err != nil {
+		t.Fatal(err)
+	}
+	commitment, _ := hex.DecodeString(proof.CommitmentHash)
+	challenges, err := a.deriveChallenges(sq, commitment, proof.Epoch, proof.StateMetadata.Dimension, len(proof.ChallengeResponse), proof.ChallengeNonceLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.matchChallenges(proof.ChallengeResponse, challenges, commitment, proof.ChallengeBinding)
+
+	// Keep views of the buffers so that their contents can be checked once
+	// the arena has let go of them
+	encoded := a.encoded.Bytes()[:a.encoded.Cap()]
+	signature, nodes, nonces := a.signature[:cap(a.signature)], a.nodes[:cap(a.nodes)], a.nonces[:cap(a.nonces)]
+	a.release()
+
+	if !allZero(encoded) || !allZero(signature) || !allZero(nonces) {
+		t.Error("Released arena still holds proof material")
+	}
+	for i := range nodes {
+		if nodes[i] != [sha256.Size]byte{} {
+			t.Fatalf("Merkle node %d survived release", i)
+		}
+	}
+	if a.verifier.QuantumZKP != nil || a.proof.Signature != "" || a.response.Response != "" {
+		t.Error("Released arena still references the verification")
+	}
+
+	if err := a.release(); err != errArenaReleased {
+		t.Errorf("Releasing an arena twice returned %v", err)
+	}
+}
+
+func TestVerificationArenaDropsOversizedBuffers(t *testing.T) {
+	a := getVerificationArena()
+	a.encoded.Write(make([]byte, maxArenaBytes+1))
+	a.signature = make([]byte, 0, maxArenaBytes+1)
+	a.challenges = make([]Challenge, maxArenaElements+1)
+	a.release()
+	if a.encoded.Cap() != 0 || a.signature != nil || a.challenges != nil {
+		t.Error("Oversized buffers were kept in the pool")
+	}
+}
+
+func TestVerificationArenaUseAfterRelease(t *testing.T) {
+	sq, proof := newTestProof(t, 64)
+	verifier, a := sq.withVerificationArena()
+	// Copies made during the verification, as for epochs, keep the arena
+	copied := *verifier
+	a.release()
+	if _, err := copied.scratch(); err != errArenaReleased {
+		t.Errorf("A released arena was handed out (%v)", err)
+	}
+	if copied.VerifySecureProof(proof, testutil.Key()) {
+		t.Error("Verification with a released arena succeeded")
+	}
+}
+
+func TestVerificationArenaKeepsProofsApart(t *testing.T) {
+	sq, proof := newTestProof(t, 128)
+	verifier, err := NewVerifier(sq, VerifierConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := *proof
+	tampered.ChallengeResponse = append([]ChallengeResponse(nil), proof.ChallengeResponse...)
+	tampered.ChallengeResponse[0].Response = proof.ChallengeResponse[1].Response
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if !verifier.Verify(proof, testutil.Key()).Valid {
+					t.Error("Valid proof rejected")
+					return
+				}
+				// An arena that last verified the valid proof must not
+				// vouch for the tampered one
+				if verifier.Verify(&tampered, testutil.Key()).Valid || sq.VerifySecureProof(&tampered, testutil.Key()) {
+					t.Error("Tampered proof accepted")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestVerifierAllocations(t *testing.T) {
+	// The race detector makes pools drop arenas at random
+	if raceEnabled {
+		t.Skip("allocation counts are not meaningful under the race detector")
+	}
+	sq, proof := newTestProof(t, 128)
+	verifier, err := NewVerifier(sq, VerifierConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// About 20 allocations remain, mostly inside the ML-DSA verifier; the
+	// encodings, derivation and Merkle fold made over 2000 before they used
+	// arenas (see BenchmarkVerifySecureProof)
+	allocs := testing.AllocsPerRun(20, func() {
+		verifier.Verify(proof, testutil.Key())
+	})
+	if allocs > 50 {
+		t.Errorf("Verifier.Verify made %.0f allocations", allocs)
+	}
+}
+
+func allZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// BenchmarkVerifySecureProof measures what one verification of the 128-bit
+// test proof allocates. The README's arena table comes from
+//
+//	go test -run '^$' -bench BenchmarkVerifySecureProof -benchmem -count 6 ./tests/unit
+//
+// run before and after the arenas were introduced; add -memprofile mem.out
+// and see go tool pprof -sample_index=alloc_objects -top mem.out for where
+// the remaining allocations are.
+func BenchmarkVerifySecureProof(b *testing.B) {
+	sq, proof := newTestProof(b, 128)
+	verifier, err := NewVerifier(sq, VerifierConfig{})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("VerifySecureProof", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sq.VerifySecureProof(proof, testutil.Key())
+		}
+	})
+	b.Run("VerifySecureProofVersioned", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sq.VerifySecureProofVersioned(proof, testutil.Key())
+		}
+	})
+	b.Run("Verifier", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			verifier.Verify(proof, testutil.Key())
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestPolicyHashTracksRuleParameters(t *testing.T) {
+	base := NewPolicy(MinSoundness(64), AllowedHashSuites(HashSuiteSHA256)).Hash()
+	if again := NewPolicy(MinSoundness(64), AllowedHashSuites(HashSuiteSHA256)).Hash(); again != base {
+		t.Error("Equal policies should hash equally")
+	}
+	for name, policy := range map[string]*Policy{
+		"tightened": NewPolicy(MinSoundness(128), AllowedHashSuites(HashSuiteSHA256)),
+		"reordered": NewPolicy(AllowedHashSuites(HashSuiteSHA256), MinSoundness(64)),
+		"added":     NewPolicy(MinSoundness(64), AllowedHashSuites(HashSuiteSHA256), MaxAge(time.Hour, nil)),
+	} {
+		if policy.Hash() == base {
+			t.Errorf("%s policy should hash differently", name)
+		}
+	}
+}
+
+func TestVerifierCacheServesRepeatedVerifications(t *testing.T) {
+	sq, proof := newTestProof(t, 64)
+	cache, err := NewVerificationCache(VerificationCacheOptions{})
+	if err != nil {
+		t.Fatalf("NewVerificationCache failed: %v", err)
+	}
+	verifier, err := NewVerifier(sq, VerifierConfig{Policy: NewPolicy(MinSoundness(64)), Cache: cache})
+	if err != nil {
+		t.Fatalf("NewVerifier failed: %v", err)
+	}
+
+	first := verifier.Verify(proof, testutil.Key())
+	if !first.Valid || cache.Len() != 1 {
+		t.Fatalf("Valid proof should verify and be cached: %+v, %d entries", first, cache.Len())
+	}
+	first.Reasons = append(first.Reasons, "mutated by caller")
+	if second := verifier.Verify(proof, testutil.Key()); !second.Valid || len(second.Reasons) != 0 {
+		t.Errorf("Cached result should be returned unchanged: %+v", second)
+	}
+
+	verifier.Verify(proof, make([]byte, 32))
+	if cache.Len() != 2 {
+		t.Errorf("Results should be cached per proof key, got %d entries", cache.Len())
+	}
+
+	if _, err := NewVerifier(sq, VerifierConfig{Cache: cache, Options: VerifyOptions{NonceStore: NewMemoryNonceStore()}}); err == nil {
+		t.Error("A cache should not be combined with a nonce store")
+	}
+}
+
+func TestVerifierCacheInvalidatedOnPolicyReload(t *testing.T) {
+	sq, proof := newTestProof(t, 64)
+	cache, _ := NewVerificationCache(VerificationCacheOptions{})
+	var events []CacheInvalidation
+	cache.OnInvalidate(func(event CacheInvalidation) { events = append(events, event) })
+
+	lenient, err := NewVerifier(sq, VerifierConfig{Policy: NewPolicy(MinSoundness(64)), Cache: cache})
+	if err != nil {
+		t.Fatalf("NewVerifier failed: %v", err)
+	}
+	if !lenient.Verify(proof, testutil.Key()).Valid {
+		t.Fatal("Proof should pass the lenient policy")
+	}
+	if len(events) != 0 {
+		t.Errorf("First use of the cache should not fire hooks: %+v", events)
+	}
+
+	strict, err := NewVerifier(sq, VerifierConfig{Policy: NewPolicy(MinSoundness(128)), Cache: cache})
+	if err != nil {
+		t.Fatalf("NewVerifier failed: %v", err)
+	}
+	want := []CacheInvalidation{{Previous: lenient.ProfileHash(), Current: strict.ProfileHash(), Dropped: 1}}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("Reload should fire %+v, got %+v", want, events)
+	}
+	if cache.Profile() != strict.ProfileHash() {
+		t.Error("The reloaded verifier's profile should be active")
+	}
+	if strict.Verify(proof, testutil.Key()).Valid {
+		t.Error("A stale acceptance leaked through the tightened policy")
+	}
+	if lenient.Verify(proof, testutil.Key()); cache.Len() != 1 {
+		t.Errorf("The superseded verifier should not populate the cache: %d entries", cache.Len())
+	}
+
+	if dropped := cache.Invalidate(); dropped != 1 || len(events) != 2 || events[1].Previous != events[1].Current {
+		t.Errorf("Invalidate should drop the entry and fire the hooks: %d, %+v", dropped, events)
+	}
+}
+
+func TestVerificationCacheBounds(t *testing.T) {
+	sq, proof := newTestProof(t, 64)
+	now := time.Now()
+	cache, _ := NewVerificationCache(VerificationCacheOptions{Capacity: 1, TTL: time.Minute, Clock: func() time.Time { return now }})
+	verifier, err := NewVerifier(sq, VerifierConfig{Cache: cache})
+	if err != nil {
+		t.Fatalf("NewVerifier failed: %v", err)
+	}
+	verifier.Verify(proof, testutil.Key())
+	verifier.Verify(proof, make([]byte, 32))
+	if cache.Len() != 1 {
+		t.Errorf("Capacity 1 should keep one entry, got %d", cache.Len())
+	}
+
+	now = now.Add(time.Minute)
+	key, err := verifier.verificationCacheKeyFor(proof, make([]byte, 32))
+	if err != nil {
+		t.Fatalf("verificationCacheKeyFor failed: %v", err)
+	}
+	if _, ok := cache.get(key); ok || cache.Len() != 0 {
+		t.Errorf("Expired entry should be dropped, got %d entries", cache.Len())
+	}
+
+	if _, err := NewVerificationCache(VerificationCacheOptions{Capacity: -1}); err == nil {
+		t.Error("Negative capacity should be rejected")
+	}
+}
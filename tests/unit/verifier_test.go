@@ -0,0 +1,127 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestVerifierMatchesSecureQuantumZKP(t *testing.T) {
+	sq, proof := newTestProof(t, 128)
+	tampered := *proof
+	tampered.MerkleRoot = tampered.CommitmentHash
+	wrongParameters := *proof
+	wrongParameters.ParametersHash = tampered.CommitmentHash
+
+	verifier, err := NewVerifier(sq, VerifierConfig{})
+	if err != nil {
+		t.Fatalf("NewVerifier failed: %v", err)
+	}
+	for name, p := range map[string]*SecureProof{"valid": proof, "tampered": &tampered, "parameters": &wrongParameters, "nil": nil} {
+		want := sq.VerifySecureProofVersioned(p, testutil.Key())
+		if got := verifier.Verify(p, testutil.Key()); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: Verifier returned %+v, SecureQuantumZKP %+v", name, got, want)
+		}
+	}
+
+	// The verifier keeps the parameters it was created with
+	sq.SecurityParameter = 64
+	if !verifier.Verify(proof, testutil.Key()).Valid {
+		t.Error("Changing sq after NewVerifier should not affect the verifier")
+	}
+	if sq.VerifySecureProof(proof, testutil.Key()) {
+		t.Error("sq itself should now reject the 128-bit proof")
+	}
+}
+
+func TestVerifierKeySetPolicyAndOptions(t *testing.T) {
+	sq, proof := newTestProof(t, 64)
+	other, _ := NewSignatureScheme(sq.Signer.Ctx)
+	var keys KeySet
+	provingID, _ := keys.Add(sq.Signer.PublicKeyBytes(), time.Time{}, time.Time{})
+	keys.Add(other.PublicKeyBytes(), time.Time{}, time.Time{})
+
+	verifier, err := NewVerifier(sq, VerifierConfig{
+		Policy:  NewPolicy(MinSoundness(64), AllowedHashSuites(HashSuiteSHA256)),
+		Keys:    &keys,
+		Options: VerifyOptions{NonceStore: NewMemoryNonceStore()},
+	})
+	if err != nil {
+		t.Fatalf("NewVerifier failed: %v", err)
+	}
+	result := verifier.Verify(proof, testutil.Key())
+	if !result.Valid || !reflect.DeepEqual(result.Signers, []string{provingID}) {
+		t.Fatalf("Proof should verify with the proving key: %+v", result)
+	}
+	if verifier.Verify(proof, testutil.Key()).Valid {
+		t.Error("A replayed proof should be rejected")
+	}
+
+	strict, _ := NewVerifier(sq, VerifierConfig{Policy: NewPolicy(MinSoundness(128))})
+	if result := strict.Verify(proof, testutil.Key()); result.Valid || len(result.Reasons) != 1 {
+		t.Errorf("A policy violation should be reported: %+v", result)
+	}
+	if _, err := NewVerifier(sq, VerifierConfig{Keys: &KeySet{}}); err == nil {
+		t.Error("An empty key set should be rejected")
+	}
+	if _, err := NewVerifier(sq, VerifierConfig{Keys: &keys, KeyPolicy: KeySetPolicy(7)}); err == nil {
+		t.Error("An unknown key set policy should be rejected")
+	}
+	if _, err := NewVerifier(sq, VerifierConfig{Policy: NewPolicy(nil)}); err == nil {
+		t.Error("A nil rule should be rejected")
+	}
+	if _, err := NewVerifier(nil, VerifierConfig{}); err == nil {
+		t.Error("A nil SecureQuantumZKP should be rejected")
+	}
+}
+
+func TestVerifierConcurrent(t *testing.T) {
+	sq, proof := newTestProof(t, 64)
+	verifier, _ := NewVerifier(sq, VerifierConfig{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if !verifier.Verify(proof, testutil.Key()).Valid {
+					t.Error("Concurrent verification failed")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkVerifier(b *testing.B) {
+	sq, proof := newTestProof(b, 128)
+	var keys KeySet
+	keys.Add(sq.Signer.PublicKeyBytes(), time.Time{}, time.Time{})
+	verifier, _ := NewVerifier(sq, VerifierConfig{})
+	keyed, _ := NewVerifier(sq, VerifierConfig{Keys: &keys})
+
+	b.Run("SecureQuantumZKP", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sq.VerifySecureProofVersioned(proof, testutil.Key())
+		}
+	})
+	b.Run("Verifier", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			verifier.Verify(proof, testutil.Key())
+		}
+	})
+	b.Run("VerifyWithKeySet", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sq.VerifyWithKeySet(proof, testutil.Key(), &keys, KeySetAnyOf)
+		}
+	})
+	b.Run("VerifierWithKeySet", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			keyed.Verify(proof, testutil.Key())
+		}
+	})
+}
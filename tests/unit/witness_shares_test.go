@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/hydraresearch/qzkp/tests/testutil"
+)
+
+func TestSplitWitnessReconstructsFromAnyThreshold(t *testing.T) {
+	witness := []byte("custodial witness held by no single node")
+	shares, err := SplitWitness(witness, 3, 5)
+	if err != nil {
+		t.Fatalf("SplitWitness failed: %v", err)
+	}
+	for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}} {
+		picked := []WitnessShare{shares[subset[0]], shares[subset[1]], shares[subset[2]]}
+		got, err := ReconstructWitness(picked, 3)
+		if err != nil || !bytes.Equal(got, witness) {
+			t.Errorf("Shares %v reconstructed %q, %v", subset, got, err)
+		}
+	}
+	for _, share := range shares {
+		if bytes.Equal(share.Data, witness) {
+			t.Error("A share should not contain the witness")
+		}
+	}
+
+	if _, err := ReconstructWitness(shares[:2], 3); !errors.Is(err, ErrNotEnoughShares) {
+		t.Errorf("Two of three shares should be refused, got %v", err)
+	}
+	if _, err := ReconstructWitness([]WitnessShare{shares[0], shares[0], shares[1]}, 3); err == nil {
+		t.Error("A repeated share should be refused")
+	}
+	other, _ := SplitWitness(witness, 3, 5)
+	if _, err := ReconstructWitness([]WitnessShare{shares[0], shares[1], other[2]}, 3); err == nil {
+		t.Error("Shares of different splits should be refused")
+	}
+	for _, bad := range [][2]int{{1, 3}, {4, 3}, {2, 256}} {
+		if _, err := SplitWitness(witness, bad[0], bad[1]); err == nil {
+			t.Errorf("Threshold %d of %d should be refused", bad[0], bad[1])
+		}
+	}
+}
+
+func TestReconstructAndProveFromObjectStores(t *testing.T) {
+	witness := []byte("distributed witness bytes")
+	shares, err := SplitWitness(witness, 2, 3)
+	if err != nil {
+		t.Fatalf("SplitWitness failed: %v", err)
+	}
+	stores := []ObjectStore{NewMemoryObjectStore(), NewMemoryObjectStore(), NewMemoryObjectStore()}
+	if err := StoreWitnessShares(shares, stores, "witness/alice"); err != nil {
+		t.Fatalf("StoreWitnessShares failed: %v", err)
+	}
+	if err := StoreWitnessShares(shares, stores, "witness/alice"); err == nil {
+		t.Error("Existing shares should not be overwritten")
+	}
+
+	// One custodian is unavailable
+	stores[0].Delete("witness/alice")
+	loaded, err := LoadWitnessShares(stores, "witness/alice", 2)
+	if err != nil {
+		t.Fatalf("LoadWitnessShares failed: %v", err)
+	}
+
+	sq := newTestProver(t, 64)
+	proof, err := sq.ReconstructAndProve(loaded, 2, "alice", testutil.Key())
+	if err != nil {
+		t.Fatalf("ReconstructAndProve failed: %v", err)
+	}
+	if !sq.VerifySecureProof(proof, testutil.Key()) || proof.Identifier != "alice" {
+		t.Error("Proof from reconstructed shares should verify")
+	}
+
+	stores[1].Delete("witness/alice")
+	if _, err := LoadWitnessShares(stores, "witness/alice", 2); !errors.Is(err, ErrNotEnoughShares) || !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Expected ErrNotEnoughShares naming the missing shares, got %v", err)
+	}
+}